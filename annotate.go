@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// maxAnnotatedIssues is how many validation findings one annotation lists.
+const maxAnnotatedIssues = 10
+
+// annotateParseErrors points a GitHub Actions annotation at each schema
+// problem in err.
+func annotateParseErrors(path, level string, err error) {
+	var list schema.ParseErrors
+	var one *schema.ParseError
+	switch {
+	case errors.As(err, &list):
+	case errors.As(err, &one):
+		list = schema.ParseErrors{one}
+	}
+	for _, e := range list {
+		title := "Schema parse error"
+		if e.Table != "" {
+			title += " in table " + e.Table
+		}
+		msg := e.Msg
+		if e.Fragment != "" {
+			msg += ": " + e.Fragment
+		}
+		reporter.Annotate(level, reporter.Location{File: path, Line: e.Line, Col: e.Col}, title, msg)
+	}
+}
+
+// annotateIssues points a GitHub Actions annotation listing tr's
+// validation findings at the table's CREATE TABLE in the schema file.
+func annotateIssues(path string, s *schema.Schema, tr *pipeline.TableResult, level string) {
+	if len(tr.Issues) == 0 {
+		return
+	}
+	at := reporter.Location{File: path}
+	if t := s.TableMap[tr.Name]; t != nil {
+		at.Line = t.Line
+	}
+	msg := strings.Join(validator.Messages(tr.Issues[:min(len(tr.Issues), maxAnnotatedIssues)]), "\n")
+	if more := len(tr.Issues) - maxAnnotatedIssues; more > 0 {
+		msg += fmt.Sprintf("\n...and %d more", more)
+	}
+	reporter.Annotate(level, at, fmt.Sprintf("%d validation issues in %s", len(tr.Issues), tr.Name), msg)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+)
+
+// defaultCITimeout is the per-table limit of seed --ci when
+// --table-timeout is not set.
+const defaultCITimeout = 10 * time.Minute
+
+// ciIssueLines is how many validation findings seed --ci prints for the
+// table that failed.
+const ciIssueLines = 5
+
+// ciProgress returns the progress handler for seed --ci: one line per
+// table, and the first findings of a table that fails validation.
+func ciProgress(dryRun bool) pipeline.ProgressFunc {
+	var last *pipeline.TableResult
+	return func(ev pipeline.Event) {
+		switch ev.Stage {
+		case pipeline.StageSkipped:
+			ciLine("skip", ev.Table, fmt.Sprintf("%d rows already there", ev.Result.Existing))
+		case pipeline.StageGenerated:
+			last = ev.Result
+			if dryRun {
+				ciLine("ok", ev.Table, fmt.Sprintf("%s generated in %s", ciRows(ev.Result), ciDuration(ev.Result.Elapsed)))
+			}
+		case pipeline.StageInserted:
+			msg := fmt.Sprintf("%s, %d inserted in %s", ciRows(ev.Result), ev.Result.Inserted, ciDuration(ev.Result.Elapsed))
+			if n := len(ev.Result.Rejected); n > 0 {
+				msg += fmt.Sprintf(", %d rejected (first: row %d: %v)", n, ev.Result.Rejected[0].Row, ev.Result.Rejected[0].Err)
+			}
+			ciLine("ok", ev.Table, msg)
+		case pipeline.StageAbandoned:
+			ciLine("SKIP", ev.Table, ev.Err.Error())
+		case pipeline.StageFailed:
+			ciLine("FAIL", ev.Table, ev.Err.Error())
+			if last != nil && last.Name == ev.Table {
+				for _, issue := range last.Issues[:min(len(last.Issues), ciIssueLines)] {
+					fmt.Fprintln(os.Stderr, "     "+issue.Error())
+				}
+			}
+		}
+	}
+}
+
+// ciSummary prints the last line of seed --ci; code is the exit code.
+func ciSummary(res *pipeline.Result, code int, elapsed time.Duration) {
+	status := "PASS"
+	if code != 0 {
+		status = fmt.Sprintf("FAIL (exit %d)", code)
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d tables, %d rows inserted in %s; model %s\n",
+		status, len(res.Tables), res.Inserted, ciDuration(elapsed), res.Usage.String())
+}
+
+// ciLine prints one aligned status line.
+func ciLine(status, table, msg string) {
+	fmt.Fprintf(os.Stderr, "%-4s %-20s %s\n", status, table, msg)
+}
+
+// ciRows says how many rows a table got, and of how many when it fell short.
+func ciRows(tr *pipeline.TableResult) string {
+	if tr.Short() {
+		return fmt.Sprintf("%d/%d rows", tr.Rows, tr.Generated.Requested)
+	}
+	return fmt.Sprintf("%d rows", tr.Rows)
+}
+
+func ciDuration(d time.Duration) string {
+	return d.Round(10 * time.Millisecond).String()
+}
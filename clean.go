@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func runClean(args []string) {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file (names the tables and their foreign keys)")
+	dbConn := fs.String("db", "", "Database connection string")
+	dbWait := addWaitFlag(fs)
+	runID := fs.String("run-id", "", "Delete the rows of this seed run (printed by seed, and in its --report)")
+	all := fs.Bool("all", false, "Delete the rows of every seed run")
+	tagColumn := fs.String("tag-column", inserter.DefaultTagColumn, "Column seed tagged rows with")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	guard := addGuardFlags(fs)
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" || *dbConn == "" {
+		fmt.Fprintln(os.Stderr, "clean requires --schema and --db")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	if (*runID == "") == !*all {
+		fmt.Fprintln(os.Stderr, "clean requires exactly one of --run-id and --all")
+		os.Exit(exitUsage)
+	}
+	tables, err := loadSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+
+	plan, err := schema.PlanDelete(tables)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+	for _, e := range plan.Cascades {
+		reporter.Info(fmt.Sprintf("%s.%s is ON DELETE %s: %s rows pointing at deleted %s rows change too, seeded or not",
+			e.From, e.Column, e.OnDelete, e.From, e.To))
+	}
+	for _, e := range plan.Unlink {
+		reporter.Info(fmt.Sprintf("%s.%s is set to NULL in deleted rows first, to break a reference cycle", e.From, e.Column))
+	}
+
+	db, driver := openDB(*dbConn, dbWait)
+	defer db.Close()
+	guard.check("clean", *dbConn, db, driver, protectSpec())
+
+	deleted, err := inserter.DeleteTagged(db, driver, tables, inserter.Tag{RunID: *runID, Column: *tagColumn})
+	if err != nil {
+		reporter.Err(err.Error())
+		os.Exit(dbExitCode(err))
+	}
+	if len(deleted) == 0 {
+		reporter.Warn("No tagged rows found (seed tags rows in tables with a " + *tagColumn + " column, and others with --ledger)")
+		return
+	}
+	for _, t := range plan.Order {
+		if n, ok := deleted[t.Name]; ok {
+			reporter.Ok(fmt.Sprintf("%-20s %d rows deleted", t.Name, n))
+		}
+	}
+}
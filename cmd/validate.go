@@ -1,4 +0,0 @@
-package main
-
-
-
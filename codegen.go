@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/satyammistari/db-seed-ai/internal/codegen"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func runCodegen(args []string) {
+	fs := flag.NewFlagSet("codegen", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file")
+	recordDir := fs.String("record", "", "Directory a seed --record run wrote (or an unpacked pack)")
+	lang := fs.String("lang", "go", "Language to write: go")
+	pkg := fs.String("package", "fixtures", "Package name of the generated file")
+	out := fs.String("out", "", "File to write (default: stdout)")
+	tableName := fs.String("table", "", "Only this table (default: all)")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	_ = fs.Parse(args)
+	packDefaults(*recordDir, schemaPath, new(string))
+
+	if *schemaPath == "" || *recordDir == "" {
+		fmt.Fprintln(os.Stderr, "codegen requires --schema and --record")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	if *lang != "go" {
+		fmt.Fprintf(os.Stderr, "unknown language %q (use go)\n", *lang)
+		os.Exit(exitUsage)
+	}
+	tables, err := loadSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+	if *tableName != "" {
+		t := schema.TableByName(tables, *tableName)
+		if t == nil {
+			fmt.Fprintf(os.Stderr, "table %q not found\n", *tableName)
+			os.Exit(exitUsage)
+		}
+		tables = []*schema.Table{t}
+	}
+
+	rows := make(map[string][]map[string]interface{}, len(tables))
+	for _, t := range tables {
+		saved, ok, err := pipeline.LoadRows(*recordDir, t.Name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitFailure)
+		}
+		if !ok {
+			reporter.Warn(fmt.Sprintf("%s: no recorded rows (record with seed --record)", t.Name))
+		}
+		rows[t.Name] = saved
+	}
+	var buf bytes.Buffer
+	if err := codegen.Go(&buf, tables, rows, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "codegen:", err)
+		os.Exit(exitFailure)
+	}
+	if *out == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+	reporter.Ok(fmt.Sprintf("Wrote %d tables to %s", len(tables), *out))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// printCoverage prints the coverage matrix of seed --coverage: for every
+// table, which boundaries each column got a row for. A cell is "ok" when
+// all of the column's cases of that kind were placed, "-" when the column
+// has none, and placed/wanted when the model returned too few rows. It
+// returns the number of cases left out.
+func printCoverage(res *pipeline.Result, s *schema.Schema, cfg generator.Config) int {
+	missing := 0
+	for _, tr := range res.Tables {
+		t := s.TableMap[tr.Name]
+		if t == nil || tr.Generated == nil {
+			continue
+		}
+		type key struct{ column, kind string }
+		want, got := make(map[key]int), make(map[key]int)
+		var columns []string
+		for _, b := range cfg.Boundaries(t) {
+			k := key{b.Column, b.Kind}
+			if len(columns) == 0 || columns[len(columns)-1] != b.Column {
+				columns = append(columns, b.Column)
+			}
+			want[k]++
+		}
+		for _, b := range tr.Generated.Coverage {
+			got[key{b.Column, b.Kind}]++
+		}
+		var rows []map[string]interface{}
+		for _, col := range columns {
+			row := map[string]interface{}{"column": col}
+			for _, kind := range generator.BoundaryKinds {
+				k := key{col, kind}
+				placed := min(got[k], want[k])
+				missing += want[k] - placed
+				switch {
+				case want[k] == 0:
+					row[kind] = "-"
+				case placed == want[k]:
+					row[kind] = "ok"
+				default:
+					row[kind] = fmt.Sprintf("%d/%d", placed, want[k])
+				}
+			}
+			rows = append(rows, row)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		reporter.Info("")
+		reporter.Info("Coverage: " + t.Name)
+		reporter.Table(append([]string{"column"}, generator.BoundaryKinds...), rows)
+	}
+	return missing
+}
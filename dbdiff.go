@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/dbdiff"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func runDBDiff(args []string) {
+	fs := flag.NewFlagSet("dbdiff", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file (names the tables to compare)")
+	connA := fs.String("a", "", "Connection string of the first database, or a target name from seeddb.yaml (read only)")
+	connB := fs.String("b", "", "Connection string of the second database, or a target name from seeddb.yaml (read only)")
+	dbWait := addWaitFlag(fs)
+	tableName := fs.String("table", "", "Only this table (default: all)")
+	sampleRows := fs.Int("sample", dbdiff.DefaultSample, "Rows compared per table, in primary key order")
+	ignore := fs.String("ignore", inserter.DefaultTagColumn, "Comma-separated columns to leave out, as column or table.column (the run tag differs between separate runs)")
+	configPath := fs.String("config", "", "Project config with targets (default: ./seeddb.yaml if present)")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" || *connA == "" || *connB == "" {
+		fmt.Fprintln(os.Stderr, "dbdiff requires --schema, --a and --b")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	tables, err := loadSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+	if *tableName != "" {
+		t := schema.TableByName(tables, *tableName)
+		if t == nil {
+			fmt.Fprintf(os.Stderr, "table %q not found\n", *tableName)
+			os.Exit(exitUsage)
+		}
+		tables = []*schema.Table{t}
+	}
+	targets := resolveTargets([]string{*connA, *connB}, false, *configPath)
+
+	a, _ := openDB(targets[0].conn, dbWait)
+	defer a.Close()
+	b, _ := openDB(targets[1].conn, dbWait)
+	defer b.Close()
+
+	var ignored []string
+	for _, c := range strings.Split(*ignore, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			ignored = append(ignored, c)
+		}
+	}
+	diffs, err := dbdiff.Compare(a, b, tables, dbdiff.Options{Sample: *sampleRows, Ignore: ignored})
+	if err != nil {
+		reporter.Err(err.Error())
+		os.Exit(dbExitCode(err))
+	}
+	reporter.Info("a: " + targets[0].name)
+	reporter.Info("b: " + targets[1].name)
+	reporter.Info("")
+	differ := 0
+	for _, d := range diffs {
+		if d.Equal() {
+			reporter.Ok(fmt.Sprintf("%-20s %d rows, %d sampled rows match", d.Table, d.RowsA, d.Compared))
+			continue
+		}
+		differ++
+		msg := fmt.Sprintf("%-20s %d rows in a, %d in b", d.Table, d.RowsA, d.RowsB)
+		if d.Differing > 0 {
+			msg += fmt.Sprintf("; %d of %d sampled rows differ", d.Differing, d.Compared)
+		}
+		reporter.Err(msg)
+		for _, m := range d.Mismatches {
+			reporter.Info(fmt.Sprintf("      row %d %s: %s in a, %s in b", m.Row, m.Column, m.A, m.B))
+		}
+	}
+	reporter.Info("")
+	if differ > 0 {
+		reporter.Err(fmt.Sprintf("%d of %d tables differ", differ, len(diffs)))
+		os.Exit(exitFailure)
+	}
+	reporter.Ok(fmt.Sprintf("All %d tables match", len(diffs)))
+}
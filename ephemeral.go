@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+)
+
+// ephemeralWait is how long a new container gets to accept connections.
+const ephemeralWait = 60 * time.Second
+
+// runEphemeral is seed --ephemeral: it starts a throwaway Postgres in a
+// Docker container of image, runs the same seed against it in a child
+// process (so every way seed can exit still lets the container be
+// removed), prints the connection string and keeps the container until
+// Ctrl+C.
+func runEphemeral(image string, fs *flag.FlagSet) {
+	if !isPostgresImage(image) {
+		fmt.Fprintf(os.Stderr, "--ephemeral: %q is not a Postgres image (e.g. postgres:16, postgis/postgis:16-3.4)\n", image)
+		os.Exit(exitUsage)
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		fmt.Fprintln(os.Stderr, "--ephemeral needs docker on PATH")
+		os.Exit(exitUsage)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+
+	// Interrupts now stop the container; the child handles its own.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	reporter.Info("Starting " + image + "...")
+	id, conn, err := startContainer(image)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "--ephemeral:", err)
+		os.Exit(exitConnection)
+	}
+	stopped := false
+	stop := func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		reporter.Info("Removing the ephemeral database...")
+		if out, err := exec.Command("docker", "stop", id).CombinedOutput(); err != nil {
+			reporter.Warn(fmt.Sprintf("docker stop %s: %v %s", id, err, strings.TrimSpace(string(out))))
+		}
+	}
+	defer stop()
+	fail := func(code int) {
+		stop()
+		os.Exit(code)
+	}
+	if err := waitForPostgres(conn, ephemeralWait, interrupt); err != nil {
+		reporter.Err("--ephemeral: " + err.Error())
+		fail(exitConnection)
+	}
+	reporter.Ok("Database ready: " + conn)
+
+	args := []string{"seed", "--db", conn, "--create-tables"}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "ephemeral", "db", "create-tables":
+		default:
+			args = append(args, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	cmd := exec.Command(self, append(args, fs.Args()...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() > 0 {
+			fail(exit.ExitCode())
+		}
+		reporter.Err(err.Error())
+		fail(exitFailure)
+	}
+
+	reporter.Info("")
+	reporter.Ok("Seeded ephemeral database: " + conn)
+	reporter.Info("Press Ctrl+C to stop and remove it.")
+	<-interrupt
+	reporter.Info("")
+}
+
+// isPostgresImage reports whether image looks like a Postgres image, the
+// only database --ephemeral can start.
+func isPostgresImage(image string) bool {
+	name, _, _ := strings.Cut(image, ":")
+	name = strings.ToLower(name)
+	return strings.Contains(name, "postgres") || strings.Contains(name, "postgis") || strings.Contains(name, "timescale")
+}
+
+// startContainer runs image detached with a random local port, removed
+// once stopped, and returns the container ID and connection string.
+func startContainer(image string) (id, conn string, err error) {
+	const user, password, db = "seeddb", "seeddb", "seeddb"
+	out, err := exec.Command("docker", "run", "--detach", "--rm",
+		"--label", "seeddb.ephemeral=true",
+		"--env", "POSTGRES_USER="+user, "--env", "POSTGRES_PASSWORD="+password, "--env", "POSTGRES_DB="+db,
+		"--publish", "127.0.0.1::5432", image).Output()
+	if err != nil {
+		return "", "", dockerError("docker run", err)
+	}
+	id = strings.TrimSpace(string(out))
+	out, err = exec.Command("docker", "port", id, "5432/tcp").Output()
+	if err != nil {
+		_ = exec.Command("docker", "stop", id).Run()
+		return "", "", dockerError("docker port", err)
+	}
+	// One line per address family, e.g. "127.0.0.1:49153".
+	hostPort, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return id, fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", user, password, hostPort, db), nil
+}
+
+// dockerError adds what docker printed to stderr to err.
+func dockerError(what string, err error) error {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		return fmt.Errorf("%s: %s", what, strings.TrimSpace(string(exit.Stderr)))
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
+
+// waitForPostgres tries to connect to conn until it answers, for at most
+// limit or until an interrupt.
+func waitForPostgres(conn string, limit time.Duration, interrupt <-chan os.Signal) error {
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+	for {
+		ins, err := inserter.Connect(conn)
+		if err == nil {
+			return ins.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the database did not accept connections within %s: %w", limit, err)
+		case <-interrupt:
+			return errors.New("interrupted")
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
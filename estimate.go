@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// confirmRows is the run size from which seed estimates how long it will
+// take and asks before it starts (unless --yes).
+const confirmRows = 5000
+
+// checkLimits exits if opts asks for more rows than the project config's
+// limits allow.
+func checkLimits(opts pipeline.Options, tables []*schema.Table) {
+	if err := pipeline.CheckLimits(opts, tables); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Lower --rows, or raise limits in the project config if you mean it.")
+		os.Exit(exitUsage)
+	}
+}
+
+// confirmLargeRun times a small calibration sample, prints how long the
+// run should take and asks to go on. Smaller runs start right away, and
+// so does a run with no terminal to answer on: it only prints the estimate.
+func confirmLargeRun(opts pipeline.Options, tables []*schema.Table) {
+	total := 0
+	for _, n := range pipeline.PlannedRows(opts, tables) {
+		total += n
+	}
+	if total < confirmRows {
+		return
+	}
+	reporter.Info(fmt.Sprintf("Estimating: generating %d sample rows...", pipeline.DefaultCalibrationRows))
+	est, err := pipeline.EstimateRun(context.Background(), opts, tables, pipeline.DefaultCalibrationRows)
+	if err != nil {
+		reporter.Err("estimate: " + err.Error())
+		os.Exit(runExitCode(err, 0))
+	}
+	line := fmt.Sprintf("~%s for %d rows: generating ~%s (%.1f rows/s on %s)",
+		roundDuration(est.Total), est.Rows, roundDuration(est.Generate), est.RowsPerSec, est.Calibrated)
+	if est.Insert > 0 {
+		line += fmt.Sprintf(", inserting ~%s alongside", roundDuration(est.Insert))
+	}
+	reporter.Info("Estimated time: " + line)
+	if !stdinIsTerminal() {
+		reporter.Info("")
+		return
+	}
+	if !askYes("Continue? [y/N] ") {
+		fmt.Fprintln(os.Stderr, "Nothing was generated. Pass --yes to skip this question.")
+		os.Exit(exitFailure)
+	}
+	reporter.Info("")
+}
+
+// askYes prints question and reports whether the answer is y or yes.
+func askYes(question string) bool {
+	fmt.Fprint(os.Stderr, question)
+	switch strings.ToLower(readLine()) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// readLine reads one line of stdin, without its line ending. It is empty
+// when stdin is closed.
+func readLine() string {
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// stdinIsTerminal reports whether stdin is a terminal someone can answer
+// a question on, rather than a pipe, a file or /dev/null.
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// roundDuration rounds d to what matters in an estimate: seconds under
+// a minute, minutes under an hour.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second)
+	case d < time.Hour:
+		return d.Round(time.Minute)
+	}
+	return d.Round(10 * time.Minute)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+)
+
+// Exit codes, so wrapper scripts and CI can branch on the cause of a
+// failure. 2 is also what the flag package uses for unknown flags.
+const (
+	exitFailure    = 1  // anything not listed below
+	exitUsage      = 2  // bad flags, arguments or project config
+	exitSchema     = 3  // the schema file could not be read or parsed
+	exitModel      = 4  // the model could not be reached or its answer not parsed
+	exitValidation = 5  // generated rows break the schema's constraints
+	exitPartial    = 6  // the run failed after some rows were inserted
+	exitConnection = 7  // the database could not be reached
+	exitTimeout    = 8  // a table ran past --table-timeout
+	exitProtected  = 9  // the database is protected and --force was not confirmed
+	exitPermission = 10 // the database user cannot insert into a table the run seeds
+)
+
+// runExitCode returns the exit code for an error from pipeline.Run that
+// happened after inserted rows were committed.
+func runExitCode(err error, inserted int) int {
+	var te *pipeline.TableError
+	var le *pipeline.LimitError
+	switch {
+	case errors.As(err, &le):
+		return exitUsage
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.As(err, &te) && te.Op == pipeline.OpValidate:
+		return exitValidation
+	case errors.As(err, &te) && te.Op == pipeline.OpGenerate:
+		return exitModel
+	case isConnError(err):
+		return exitConnection
+	case inserted > 0:
+		return exitPartial
+	}
+	return exitFailure
+}
+
+// dbExitCode returns exitConnection for connection errors and exitFailure
+// for anything else, such as a rejected statement.
+func dbExitCode(err error) int {
+	if isConnError(err) {
+		return exitConnection
+	}
+	return exitFailure
+}
+
+// isConnError reports whether err means the database server could not be
+// reached or dropped the connection.
+func isConnError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.As(err, &netErr)
+}
+
+// openDB opens conn and checks the server answers, waiting for it as
+// --wait-db says, and exits with exitConnection if it does not.
+func openDB(conn string, wait *dbWait) (*sql.DB, string) {
+	ins, err := inserter.ConnectWait(conn, wait.forConn(conn), func(err error, pause time.Duration) {
+		reporter.Info(fmt.Sprintf("Waiting for the database: %v (next try in %s)", err, pause.Round(time.Millisecond)))
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "db open:", err)
+		os.Exit(exitConnection)
+	}
+	return ins.DB(), ins.Driver()
+}
+
+// dbWait is the --wait-db flag: how long to keep trying to reach a
+// database that is not up yet. Unset, it is inserter.DefaultServiceWait
+// for a host named like a docker compose service and nothing otherwise.
+type dbWait struct {
+	d   time.Duration
+	set bool
+}
+
+func addWaitFlag(fs *flag.FlagSet) *dbWait {
+	w := &dbWait{}
+	fs.Var(w, "wait-db", fmt.Sprintf("Keep trying to connect this long while the database is starting (default: %s when --db names a compose service such as db, else 0)", inserter.DefaultServiceWait))
+	return w
+}
+
+func (w *dbWait) String() string {
+	if w == nil || !w.set {
+		return ""
+	}
+	return w.d.String()
+}
+
+func (w *dbWait) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return fmt.Errorf("want a duration such as 30s or 2m")
+	}
+	w.d, w.set = d, true
+	return nil
+}
+
+// forConn returns how long to wait for conn.
+func (w *dbWait) forConn(conn string) time.Duration {
+	switch {
+	case w != nil && w.set:
+		return w.d
+	case inserter.ServiceHost(conn):
+		return inserter.DefaultServiceWait
+	}
+	return 0
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func runGraph(args []string) {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file")
+	format := fs.String("format", "dot", "dot or mermaid")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" {
+		fmt.Fprintln(os.Stderr, "graph requires --schema")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	tables, err := loadSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+
+	g := newFKGraph(tables)
+	switch *format {
+	case "dot":
+		g.writeDot(os.Stdout)
+	case "mermaid":
+		g.writeMermaid(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q (use dot or mermaid)\n", *format)
+		os.Exit(exitUsage)
+	}
+	for _, c := range g.cycles {
+		fmt.Fprintf(os.Stderr, "FK cycle: %s\n", strings.Join(c, ", "))
+	}
+}
+
+// fkGraph is the table dependency graph as drawn by `seeddb graph`.
+// Tables in a cycle and the edges between them are highlighted; tables that
+// are referenced but not defined in the schema are drawn dashed.
+type fkGraph struct {
+	nodes   []string
+	missing map[string]bool
+	inCycle map[string]int // table -> index into cycles
+	cycles  [][]string
+	edges   []schema.Edge
+}
+
+func newFKGraph(tables []*schema.Table) *fkGraph {
+	g := &fkGraph{
+		missing: make(map[string]bool),
+		inCycle: make(map[string]int),
+		cycles:  schema.Cycles(tables),
+		edges:   schema.Edges(tables),
+	}
+	known := make(map[string]bool)
+	for _, t := range tables {
+		g.nodes = append(g.nodes, t.Name)
+		known[t.Name] = true
+	}
+	for _, e := range g.edges {
+		if !known[e.To] && !g.missing[e.To] {
+			g.missing[e.To] = true
+			g.nodes = append(g.nodes, e.To)
+		}
+	}
+	for i, c := range g.cycles {
+		for _, name := range c {
+			g.inCycle[name] = i
+		}
+	}
+	return g
+}
+
+// cyclic reports whether the edge runs between two tables of the same cycle.
+func (g *fkGraph) cyclic(e schema.Edge) bool {
+	from, ok1 := g.inCycle[e.From]
+	to, ok2 := g.inCycle[e.To]
+	return ok1 && ok2 && from == to
+}
+
+func (g *fkGraph) writeDot(w io.Writer) {
+	fmt.Fprintln(w, "digraph schema {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box, fontname=\"Helvetica\"];")
+	for _, n := range g.nodes {
+		var attrs []string
+		if g.missing[n] {
+			attrs = append(attrs, "style=dashed")
+		}
+		if _, ok := g.inCycle[n]; ok {
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(w, "  %s [%s];\n", strconv.Quote(n), strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(w, "  %s;\n", strconv.Quote(n))
+		}
+	}
+	for _, e := range g.edges {
+		attrs := "label=" + strconv.Quote(e.Column)
+		if g.cyclic(e) {
+			attrs += ", color=red, fontcolor=red, penwidth=2"
+		}
+		fmt.Fprintf(w, "  %s -> %s [%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func (g *fkGraph) writeMermaid(w io.Writer) {
+	// Mermaid node ids must be plain words; table names go in the labels.
+	ids := make(map[string]string, len(g.nodes))
+	fmt.Fprintln(w, "flowchart LR")
+	for i, n := range g.nodes {
+		ids[n] = "t" + strconv.Itoa(i)
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[n], mermaidText(n))
+	}
+	var cycleLinks []string
+	for i, e := range g.edges {
+		fmt.Fprintf(w, "  %s -->|%s| %s\n", ids[e.From], mermaidText(e.Column), ids[e.To])
+		if g.cyclic(e) {
+			cycleLinks = append(cycleLinks, strconv.Itoa(i))
+		}
+	}
+	var cycleNodes, missingNodes []string
+	for _, n := range g.nodes {
+		if _, ok := g.inCycle[n]; ok {
+			cycleNodes = append(cycleNodes, ids[n])
+		}
+		if g.missing[n] {
+			missingNodes = append(missingNodes, ids[n])
+		}
+	}
+	if len(cycleNodes) > 0 {
+		fmt.Fprintln(w, "  classDef cycle stroke:#d33,stroke-width:2px,color:#d33")
+		fmt.Fprintf(w, "  class %s cycle\n", strings.Join(cycleNodes, ","))
+	}
+	if len(missingNodes) > 0 {
+		fmt.Fprintln(w, "  classDef missing stroke-dasharray:5 5")
+		fmt.Fprintf(w, "  class %s missing\n", strings.Join(missingNodes, ","))
+	}
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(w, "  linkStyle %s stroke:#d33,stroke-width:2px\n", strings.Join(cycleLinks, ","))
+	}
+}
+
+// mermaidText escapes characters that would end a Mermaid label early.
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;").Replace(s)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// guardFlags are the production-safety flags of the commands that write.
+type guardFlags struct {
+	marker *string
+	force  *bool
+}
+
+func addGuardFlags(fs *flag.FlagSet) guardFlags {
+	return guardFlags{
+		marker: fs.String("production-guard", "", "Refuse to write when the database has this marker table (default: protect.marker_table in seeddb.yaml, else "+inserter.DefaultGuardTable+")"),
+		force:  fs.Bool("force", false, "Write to a protected database after typing its name to confirm"),
+	}
+}
+
+// check refuses to let the command write to a protected database: one
+// whose host matches protect.hosts in the config, or that has the marker
+// table. With --force the user may go on by typing the database's name.
+func (g guardFlags) check(verb, conn string, db *sql.DB, driver string, spec config.ProtectSpec) {
+	marker := *g.marker
+	if marker == "" {
+		marker = spec.MarkerTable
+	}
+	reason, err := inserter.Protected(db, driver, conn, spec.Hosts, marker)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(dbExitCode(err))
+	}
+	if reason == "" {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "%s looks like a production database: %s.\n", pipeline.RedactConn(conn), reason)
+	if !*g.force {
+		fmt.Fprintf(os.Stderr, "Refusing to %s it. Pass --force if you really mean to.\n", verb)
+		os.Exit(exitProtected)
+	}
+	name := inserter.ConnName(conn)
+	fmt.Fprintf(os.Stderr, "Type the database name (%s) to %s it anyway: ", name, verb)
+	if readLine() != name {
+		fmt.Fprintln(os.Stderr, "Name did not match; nothing was written.")
+		os.Exit(exitProtected)
+	}
+}
+
+// protectSpec reads the protect section of the project config for the
+// commands that take no other settings from it.
+func protectSpec() config.ProtectSpec {
+	f, err := config.Load("")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	return f.Protect
+}
+
+// checkWritable exits with a report of every table in tables the user of
+// db cannot insert into, before anything is generated: a run should not
+// fail on its fourteenth table for a missing grant. name labels db when
+// there are several targets.
+func checkWritable(name string, db *sql.DB, driver string, tables []*schema.Table) {
+	denied, err := inserter.CheckWrite(db, driver, tableNames(tables))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(dbExitCode(err))
+	}
+	if len(denied) == 0 {
+		return
+	}
+	if name != "" {
+		name = " in " + name
+	}
+	reporter.Err(fmt.Sprintf("Cannot seed %d of %d tables%s; nothing was generated:", len(denied), len(tables), name))
+	for _, d := range denied {
+		reporter.Info(fmt.Sprintf("      %-20s %s", d.Table, d.Reason))
+	}
+	os.Exit(exitPermission)
+}
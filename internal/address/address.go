@@ -0,0 +1,260 @@
+// Package address holds a small dataset of real places, so a row's city,
+// state, postal code and country can be made to agree with each other
+// instead of the mix a model writes ("Austin, CA 10001, Canada").
+package address
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Place is one city with the state and postal codes that go with it.
+type Place struct {
+	City      string
+	Aliases   []string // other spellings matched by Find, e.g. NYC
+	State     string   // subdivision code, e.g. TX
+	StateName string
+	Postal    string // pattern; each # is a random digit
+	Country   string // ISO 3166 alpha-2 code
+}
+
+// Country is a country of the dataset.
+type Country struct {
+	Code    string
+	Name    string
+	Aliases []string
+}
+
+var countries = []Country{
+	{"US", "United States", []string{"USA", "United States of America", "America"}},
+	{"CA", "Canada", nil},
+	{"GB", "United Kingdom", []string{"UK", "Great Britain", "Britain", "England", "Scotland", "Wales"}},
+	{"DE", "Germany", []string{"Deutschland"}},
+	{"FR", "France", nil},
+	{"ES", "Spain", []string{"España"}},
+	{"IT", "Italy", []string{"Italia"}},
+	{"NL", "Netherlands", []string{"The Netherlands", "Holland"}},
+	{"AU", "Australia", nil},
+	{"IN", "India", nil},
+	{"JP", "Japan", nil},
+	{"BR", "Brazil", []string{"Brasil"}},
+	{"MX", "Mexico", []string{"México"}},
+}
+
+var places = []Place{
+	{"New York", []string{"NYC", "New York City"}, "NY", "New York", "100##", "US"},
+	{"Los Angeles", []string{"LA"}, "CA", "California", "900##", "US"},
+	{"San Francisco", nil, "CA", "California", "941##", "US"},
+	{"Chicago", nil, "IL", "Illinois", "606##", "US"},
+	{"Houston", nil, "TX", "Texas", "770##", "US"},
+	{"Austin", nil, "TX", "Texas", "787##", "US"},
+	{"Seattle", nil, "WA", "Washington", "981##", "US"},
+	{"Boston", nil, "MA", "Massachusetts", "021##", "US"},
+	{"Denver", nil, "CO", "Colorado", "802##", "US"},
+	{"Miami", nil, "FL", "Florida", "331##", "US"},
+	{"Toronto", nil, "ON", "Ontario", "M5V #T6", "CA"},
+	{"Vancouver", nil, "BC", "British Columbia", "V6B #A1", "CA"},
+	{"Montreal", []string{"Montréal"}, "QC", "Quebec", "H2X #Y4", "CA"},
+	{"Calgary", nil, "AB", "Alberta", "T2P #N3", "CA"},
+	{"London", nil, "ENG", "England", "SW1A #AA", "GB"},
+	{"Manchester", nil, "ENG", "England", "M1 #AE", "GB"},
+	{"Edinburgh", nil, "SCT", "Scotland", "EH1 #YZ", "GB"},
+	{"Cardiff", nil, "WLS", "Wales", "CF10 #AT", "GB"},
+	{"Berlin", nil, "BE", "Berlin", "101##", "DE"},
+	{"Munich", []string{"München"}, "BY", "Bavaria", "803##", "DE"},
+	{"Hamburg", nil, "HH", "Hamburg", "203##", "DE"},
+	{"Frankfurt", []string{"Frankfurt am Main"}, "HE", "Hesse", "603##", "DE"},
+	{"Paris", nil, "IDF", "Île-de-France", "7500#", "FR"},
+	{"Lyon", nil, "ARA", "Auvergne-Rhône-Alpes", "6900#", "FR"},
+	{"Marseille", nil, "PAC", "Provence-Alpes-Côte d'Azur", "130##", "FR"},
+	{"Bordeaux", nil, "NAQ", "Nouvelle-Aquitaine", "330##", "FR"},
+	{"Madrid", nil, "MD", "Community of Madrid", "280##", "ES"},
+	{"Barcelona", nil, "CT", "Catalonia", "080##", "ES"},
+	{"Valencia", nil, "VC", "Valencian Community", "460##", "ES"},
+	{"Seville", []string{"Sevilla"}, "AN", "Andalusia", "410##", "ES"},
+	{"Rome", []string{"Roma"}, "RM", "Lazio", "001##", "IT"},
+	{"Milan", []string{"Milano"}, "MI", "Lombardy", "201##", "IT"},
+	{"Naples", []string{"Napoli"}, "NA", "Campania", "801##", "IT"},
+	{"Turin", []string{"Torino"}, "TO", "Piedmont", "101##", "IT"},
+	{"Amsterdam", nil, "NH", "North Holland", "10## AB", "NL"},
+	{"Rotterdam", nil, "ZH", "South Holland", "30## CD", "NL"},
+	{"Utrecht", nil, "UT", "Utrecht", "35## EF", "NL"},
+	{"Sydney", nil, "NSW", "New South Wales", "20##", "AU"},
+	{"Melbourne", nil, "VIC", "Victoria", "30##", "AU"},
+	{"Brisbane", nil, "QLD", "Queensland", "40##", "AU"},
+	{"Perth", nil, "WA", "Western Australia", "60##", "AU"},
+	{"Mumbai", []string{"Bombay"}, "MH", "Maharashtra", "4000##", "IN"},
+	{"New Delhi", []string{"Delhi"}, "DL", "Delhi", "1100##", "IN"},
+	{"Bengaluru", []string{"Bangalore"}, "KA", "Karnataka", "5600##", "IN"},
+	{"Chennai", []string{"Madras"}, "TN", "Tamil Nadu", "6000##", "IN"},
+	{"Tokyo", nil, "13", "Tokyo", "100-00##", "JP"},
+	{"Osaka", nil, "27", "Osaka", "530-00##", "JP"},
+	{"Kyoto", nil, "26", "Kyoto", "600-8###", "JP"},
+	{"São Paulo", []string{"Sao Paulo"}, "SP", "São Paulo", "01###-000", "BR"},
+	{"Rio de Janeiro", nil, "RJ", "Rio de Janeiro", "20###-000", "BR"},
+	{"Belo Horizonte", nil, "MG", "Minas Gerais", "30###-000", "BR"},
+	{"Mexico City", []string{"Ciudad de México", "CDMX"}, "CMX", "Mexico City", "06###", "MX"},
+	{"Guadalajara", nil, "JAL", "Jalisco", "44###", "MX"},
+	{"Monterrey", nil, "NLE", "Nuevo León", "64###", "MX"},
+}
+
+// Codes returns the country codes of the dataset, sorted.
+func Codes() []string {
+	out := make([]string, len(countries))
+	for i, c := range countries {
+		out[i] = c.Code
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LookupCountry finds a country by code, name or alias, in any case.
+func LookupCountry(s string) (Country, bool) {
+	s = strings.TrimSpace(s)
+	for _, c := range countries {
+		if strings.EqualFold(s, c.Code) || strings.EqualFold(s, c.Name) {
+			return c, true
+		}
+		for _, a := range c.Aliases {
+			if strings.EqualFold(s, a) {
+				return c, true
+			}
+		}
+	}
+	return Country{}, false
+}
+
+// Find returns the place called city, by name or alias, in any case.
+func Find(city string) (Place, bool) {
+	city = strings.TrimSpace(city)
+	for _, p := range places {
+		if strings.EqualFold(city, p.City) {
+			return p, true
+		}
+		for _, a := range p.Aliases {
+			if strings.EqualFold(city, a) {
+				return p, true
+			}
+		}
+	}
+	return Place{}, false
+}
+
+// Random returns a place drawn from r in one of the given countries, or
+// in any country when none is given or none is known.
+func Random(codes []string, r *rand.Rand) Place {
+	var pool []Place
+	for _, p := range places {
+		for _, c := range codes {
+			if strings.EqualFold(c, p.Country) {
+				pool = append(pool, p)
+			}
+		}
+	}
+	if len(pool) == 0 {
+		pool = places
+	}
+	return pool[r.IntN(len(pool))]
+}
+
+// PostalCode fills the place's postal pattern with digits drawn from r.
+func (p Place) PostalCode(r *rand.Rand) string {
+	b := []byte(p.Postal)
+	for i, c := range b {
+		if c == '#' {
+			b[i] = byte('0' + r.IntN(10))
+		}
+	}
+	return string(b)
+}
+
+// Group is a set of columns that together hold one address. Street is
+// kept as written; the others are set from one Place.
+type Group struct {
+	Prefix  string // e.g. "billing_"; "" for plain city, state, ...
+	Street  string
+	City    string
+	State   string
+	Postal  string
+	Country string
+}
+
+var fields = []struct {
+	field    string
+	suffixes []string
+}{
+	{"street", []string{"street_address", "address_line1", "address_line_1", "address1", "street", "address"}},
+	{"city", []string{"city", "town"}},
+	{"state", []string{"state_code", "state", "province", "region"}},
+	{"postal", []string{"postal_code", "postcode", "zip_code", "zipcode", "zip"}},
+	{"country", []string{"country_code", "country_name", "country"}},
+}
+
+// Groups finds address columns in t, grouped by prefix: city, state,
+// zip and country, or billing_city, billing_state and so on. A group has
+// at least two of city, state, postal code and country; text columns only.
+func Groups(t *schema.Table) []Group {
+	byPrefix := make(map[string]*Group)
+	var order []string
+	for _, col := range t.Columns {
+		if col.Type != "text" || col.ForeignKey != nil {
+			continue
+		}
+		n := strings.ToLower(col.Name)
+		for _, f := range fields {
+			prefix, ok := "", false
+			for _, s := range f.suffixes {
+				if n == s || strings.HasSuffix(n, "_"+s) {
+					prefix, ok = col.Name[:len(n)-len(s)], true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+			g := byPrefix[prefix]
+			if g == nil {
+				g = &Group{Prefix: prefix}
+				byPrefix[prefix] = g
+				order = append(order, prefix)
+			}
+			switch f.field {
+			case "street":
+				g.Street = first(g.Street, col.Name)
+			case "city":
+				g.City = first(g.City, col.Name)
+			case "state":
+				g.State = first(g.State, col.Name)
+			case "postal":
+				g.Postal = first(g.Postal, col.Name)
+			case "country":
+				g.Country = first(g.Country, col.Name)
+			}
+			break
+		}
+	}
+	var out []Group
+	for _, prefix := range order {
+		g := byPrefix[prefix]
+		n := 0
+		for _, c := range []string{g.City, g.State, g.Postal, g.Country} {
+			if c != "" {
+				n++
+			}
+		}
+		if n >= 2 {
+			out = append(out, *g)
+		}
+	}
+	return out
+}
+
+func first(have, col string) string {
+	if have != "" {
+		return have
+	}
+	return col
+}
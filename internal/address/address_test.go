@@ -0,0 +1,50 @@
+package address
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestGroups(t *testing.T) {
+	tbl := &schema.Table{Name: "orders", Columns: []schema.Column{
+		{Name: "email_address", Type: "text"},
+		{Name: "billing_street", Type: "text"},
+		{Name: "billing_city", Type: "text"},
+		{Name: "billing_zip", Type: "text"},
+		{Name: "city", Type: "text"},
+		{Name: "state", Type: "text"},
+		{Name: "country_code", Type: "text"},
+		{Name: "region", Type: "text"},
+	}}
+	got := Groups(tbl)
+	want := []Group{
+		{Prefix: "billing_", Street: "billing_street", City: "billing_city", Postal: "billing_zip"},
+		{City: "city", State: "state", Country: "country_code"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("group %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if c, ok := LookupCountry("usa"); !ok || c.Code != "US" {
+		t.Errorf("LookupCountry(usa) = %+v, %v", c, ok)
+	}
+	if p, ok := Find("münchen"); !ok || p.City != "Munich" || p.Country != "DE" {
+		t.Errorf("Find(münchen) = %+v, %v", p, ok)
+	}
+	p, _ := Find("Austin")
+	if zip := p.PostalCode(rand.New(rand.NewPCG(1, 2))); len(zip) != 5 || zip[:3] != "787" {
+		t.Errorf("unexpected Austin zip %q", zip)
+	}
+	if p := Random([]string{"jp"}, rand.New(rand.NewPCG(1, 2))); p.Country != "JP" {
+		t.Errorf("Random(jp) gave %+v", p)
+	}
+}
@@ -0,0 +1,198 @@
+// Package cache stores model responses where a team can share them: a
+// local directory, any HTTP server that takes GET and PUT, an S3 bucket or
+// a GCS bucket. CI runners and teammates seeding an unchanged schema then
+// reuse each other's responses instead of each calling a model.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend stores entries by key. Get reports a missing entry with ok
+// false and no error.
+type Backend interface {
+	Get(ctx context.Context, key string) (data []byte, ok bool, err error)
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// Open returns the backend a --cache location names:
+//
+//	/path/or/relative/dir, file:///path   a local directory
+//	http://host/prefix, https://...      GET and PUT on prefix/key
+//	s3://bucket/prefix                   AWS_* credentials, signed requests
+//	gs://bucket/prefix                   GOOGLE_OAUTH_ACCESS_TOKEN
+//
+// HTTP requests carry "Authorization: Bearer $SEEDDB_CACHE_TOKEN" when
+// that is set.
+func Open(location string) (Backend, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // C:\ on Windows
+		return dirBackend(location)
+	}
+	switch u.Scheme {
+	case "file":
+		return dirBackend(u.Path)
+	case "http", "https":
+		h := &HTTP{Base: strings.TrimSuffix(location, "/") + "/"}
+		if tok := os.Getenv("SEEDDB_CACHE_TOKEN"); tok != "" {
+			h.Header = http.Header{"Authorization": {"Bearer " + tok}}
+		}
+		return h, nil
+	case "s3":
+		return newS3(u.Host, strings.Trim(u.Path, "/"))
+	case "gs":
+		tok := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		if tok == "" {
+			return nil, errors.New("gs:// cache needs GOOGLE_OAUTH_ACCESS_TOKEN (e.g. from gcloud auth print-access-token)")
+		}
+		base := "https://storage.googleapis.com/" + u.Host + "/"
+		if p := strings.Trim(u.Path, "/"); p != "" {
+			base += p + "/"
+		}
+		return &HTTP{Base: base, Header: http.Header{"Authorization": {"Bearer " + tok}}}, nil
+	}
+	return nil, fmt.Errorf("cache %q: unknown scheme %s (want a directory, http(s)://, s3:// or gs://)", location, u.Scheme)
+}
+
+// Dir is a Backend in a local directory, one file per key.
+type Dir string
+
+func dirBackend(path string) (Backend, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("cache dir: %w", err)
+	}
+	return Dir(path), nil
+}
+
+// Get implements Backend.
+func (d Dir) Get(_ context.Context, key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	return data, err == nil, err
+}
+
+// Put implements Backend. The entry is written under a temporary name
+// and renamed, so concurrent readers never see half of it.
+func (d Dir) Put(_ context.Context, key string, data []byte) error {
+	tmp, err := os.CreateTemp(string(d), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(string(d), key))
+}
+
+// HTTP is a Backend on any server that answers GET Base+key with the
+// entry or 404, and stores a PUT body.
+type HTTP struct {
+	Base   string      // ends in "/"
+	Header http.Header // added to every request
+	Client *http.Client
+	// sign, if set, signs each request (S3).
+	sign func(req *http.Request, body []byte)
+}
+
+func (h *HTTP) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+func (h *HTTP) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.Base+url.PathEscape(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	if h.sign != nil {
+		h.sign(req, body)
+	}
+	return h.client().Do(req)
+}
+
+// Get implements Backend.
+func (h *HTTP) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	resp, err := h.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("cache GET %s: %s", key, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, err == nil, err
+}
+
+// Put implements Backend.
+func (h *HTTP) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := h.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("cache PUT %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// Tolerant wraps b so that its failures never fail a run: a Get that
+// fails is a miss and a Put that fails is dropped. warn hears about the
+// first failure only, since the rest are usually the same outage.
+func Tolerant(b Backend, warn func(error)) Backend {
+	return &tolerant{b: b, warn: warn}
+}
+
+type tolerant struct {
+	b    Backend
+	once sync.Once
+	warn func(error)
+}
+
+func (t *tolerant) failed(err error) {
+	t.once.Do(func() { t.warn(err) })
+}
+
+func (t *tolerant) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, ok, err := t.b.Get(ctx, key)
+	if err != nil {
+		t.failed(err)
+		return nil, false, nil
+	}
+	return data, ok, nil
+}
+
+func (t *tolerant) Put(ctx context.Context, key string, data []byte) error {
+	if err := t.b.Put(ctx, key, data); err != nil {
+		t.failed(err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// store is a minimal object store: GET, PUT, 404 for missing keys.
+func store(t *testing.T, check func(*http.Request)) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func roundTrip(t *testing.T, b Backend) {
+	t.Helper()
+	ctx := context.Background()
+	if _, ok, err := b.Get(ctx, "k1.json"); ok || err != nil {
+		t.Fatalf("empty cache: ok %v, err %v", ok, err)
+	}
+	if err := b.Put(ctx, "k1.json", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	data, ok, err := b.Get(ctx, "k1.json")
+	if !ok || err != nil || string(data) != "hello" {
+		t.Fatalf("got %q, %v, %v", data, ok, err)
+	}
+}
+
+func TestBackends(t *testing.T) {
+	dir, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, dir)
+
+	t.Setenv("SEEDDB_CACHE_TOKEN", "secret")
+	srv := store(t, func(r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing bearer token on %s %s", r.Method, r.URL)
+		}
+	})
+	h, err := Open(srv.URL + "/team/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, h)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "example")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	s3srv := store(t, func(r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") ||
+			!strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") {
+			t.Errorf("unexpected Authorization %q", auth)
+		}
+		if !strings.HasPrefix(r.URL.Path, "/bucket/seeds/") {
+			t.Errorf("expected a path-style request under the prefix, got %s", r.URL.Path)
+		}
+	})
+	t.Setenv("AWS_ENDPOINT_URL", s3srv.URL)
+	s3, err := Open("s3://bucket/seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip(t, s3)
+
+	if _, err := Open("ftp://x/y"); err == nil {
+		t.Error("expected an error for an unknown scheme")
+	}
+}
+
+type brokenBackend struct{}
+
+func (brokenBackend) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("down")
+}
+func (brokenBackend) Put(context.Context, string, []byte) error { return errors.New("down") }
+
+func TestTolerant(t *testing.T) {
+	var warned []error
+	b := Tolerant(brokenBackend{}, func(err error) { warned = append(warned, err) })
+	if _, ok, err := b.Get(context.Background(), "k"); ok || err != nil {
+		t.Errorf("a failed Get should be a miss, got %v, %v", ok, err)
+	}
+	if err := b.Put(context.Background(), "k", nil); err != nil {
+		t.Errorf("a failed Put should be dropped, got %v", err)
+	}
+	if len(warned) != 1 {
+		t.Errorf("expected one warning, got %v", warned)
+	}
+}
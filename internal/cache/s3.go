@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// newS3 returns an HTTP backend for s3://bucket/prefix, signed with AWS
+// Signature Version 4 from the usual environment: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION. AWS_ENDPOINT_URL
+// points it at an S3-compatible store such as MinIO (path-style). The
+// credentials need s3:ListBucket as well, or S3 answers 403 instead of
+// 404 for entries not cached yet.
+func newS3(bucket, prefix string) (Backend, error) {
+	id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if id == "" || secret == "" {
+		return nil, errors.New("s3:// cache needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	base := "https://" + bucket + ".s3." + region + ".amazonaws.com/"
+	if ep := os.Getenv("AWS_ENDPOINT_URL_S3"); ep != "" {
+		base = strings.TrimSuffix(ep, "/") + "/" + bucket + "/"
+	} else if ep := os.Getenv("AWS_ENDPOINT_URL"); ep != "" {
+		base = strings.TrimSuffix(ep, "/") + "/" + bucket + "/"
+	}
+	if prefix != "" {
+		base += prefix + "/"
+	}
+	s := sigV4{id: id, secret: secret, token: os.Getenv("AWS_SESSION_TOKEN"), region: region, now: time.Now}
+	return &HTTP{Base: base, sign: s.sign}, nil
+}
+
+// sigV4 signs S3 requests.
+type sigV4 struct {
+	id, secret, token, region string
+	now                       func() time.Time
+}
+
+func (s sigV4) sign(req *http.Request, body []byte) {
+	t := s.now().UTC()
+	amzDate, day := t.Format("20060102T150405Z"), t.Format("20060102")
+	payload := hexSum(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	if s.token != "" {
+		req.Header.Set("X-Amz-Security-Token", s.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canon := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonHeaders.String(), signed, payload}, "\n")
+
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSum([]byte(canon))
+	key := []byte("AWS4" + s.secret)
+	for _, part := range []string{day, s.region, "s3", "aws4_request"} {
+		key = hmacSum(key, part)
+	}
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.id+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+hex.EncodeToString(hmacSum(key, toSign)))
+}
+
+func hexSum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSum(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
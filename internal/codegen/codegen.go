@@ -0,0 +1,465 @@
+// Package codegen turns the rows a seed run recorded into source code, so
+// test suites can refer to seeded entities by name instead of by magic ids.
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Go writes a Go file in package pkg with, for each table:
+//
+//   - a struct with a field per column, named like the table in the
+//     singular (users -> User);
+//   - the recorded rows as a slice of it (Users), in insert order;
+//   - a constant for each row's index in the slice, named by its key
+//     column (UserAliceExampleCom), when the table has one (see keyColumn);
+//   - a loader (LoadUsers) that reads the rows back from a seeded database,
+//     with the values the database filled in, such as ids.
+//
+// rows maps table names to the rows recorded for them, as
+// pipeline.LoadRows returns them; a table without any gets an empty slice.
+func Go(w io.Writer, tables []*schema.Table, rows map[string][]map[string]interface{}, pkg string) error {
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("%q is not a Go package name", pkg)
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no tables to generate code for")
+	}
+	g := &goFile{used: make(map[string]bool)}
+	var body bytes.Buffer
+	for _, t := range tables {
+		if err := g.table(&body, t, rows[t.Name]); err != nil {
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by seeddb codegen; DO NOT EDIT.\n\npackage %s\n\nimport (\n\t\"context\"\n\t\"database/sql\"\n\t\"fmt\"\n", pkg)
+	if g.needTime {
+		out.WriteString("\t\"time\"\n")
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+	if g.needPtr {
+		out.WriteString("\nfunc ptr[T any](v T) *T { return &v }\n")
+	}
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated code does not parse: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goFile collects what the tables written so far need.
+type goFile struct {
+	used     map[string]bool // top-level identifiers taken
+	needTime bool
+	needPtr  bool
+}
+
+// ident returns name, or name with a number appended if it is taken.
+func (g *goFile) ident(name string) string {
+	out := name
+	for i := 2; g.used[out]; i++ {
+		out = name + strconv.Itoa(i)
+	}
+	g.used[out] = true
+	return out
+}
+
+func (g *goFile) table(w *bytes.Buffer, t *schema.Table, rows []map[string]interface{}) error {
+	typ := g.ident(exported(singular(t.Name), "Row"))
+	list := exported(t.Name, "Rows")
+	if list == typ {
+		list += "Rows"
+	}
+	list = g.ident(list)
+	load := g.ident("Load" + list)
+
+	fields := make([]string, len(t.Columns))
+	taken := make(map[string]bool)
+	fmt.Fprintf(w, "\n// %s is a row of the %s table.\ntype %s struct {\n", typ, t.Name, typ)
+	for i, c := range t.Columns {
+		name := exported(c.Name, "Col")
+		for n := 2; taken[name]; n++ {
+			name = exported(c.Name, "Col") + strconv.Itoa(n)
+		}
+		taken[name] = true
+		fields[i] = name
+		fmt.Fprintf(w, "\t%s %s `db:%s`\n", name, g.goType(c), strconv.Quote(c.Name))
+	}
+	w.WriteString("}\n")
+
+	fmt.Fprintf(w, "\n// %s are the rows seeded into %s, in insert order.\nvar %s = []%s{\n", list, t.Name, list, typ)
+	for n, row := range rows {
+		w.WriteString("\t{")
+		sep := ""
+		for i, c := range t.Columns {
+			v, ok := row[c.Name]
+			if !ok || v == nil {
+				continue // the zero value: NULL, or filled in by the database
+			}
+			lit, err := g.literal(c, v)
+			if err != nil {
+				return fmt.Errorf("%s row %d: %s: %w", t.Name, n+1, c.Name, err)
+			}
+			fmt.Fprintf(w, "%s%s: %s", sep, fields[i], lit)
+			sep = ", "
+		}
+		w.WriteString("},\n")
+	}
+	w.WriteString("}\n")
+
+	key := keyColumn(t)
+	if key != nil {
+		var consts []string
+		for n, row := range rows {
+			v := row[key.Name]
+			if v == nil {
+				continue
+			}
+			name := exported(fmt.Sprint(v), "")
+			if name == "" {
+				name = strconv.Itoa(n + 1)
+			}
+			consts = append(consts, fmt.Sprintf("\t%s = %d\n", g.ident(typ+name), n))
+		}
+		if len(consts) > 0 {
+			fmt.Fprintf(w, "\n// Indexes into %s, named by %s.\nconst (\n%s)\n", list, key.Name, strings.Join(consts, ""))
+		}
+	}
+
+	var cols, dests []string
+	for i, c := range t.Columns {
+		cols = append(cols, quoteIdent(c.Name))
+		dests = append(dests, "&r."+fields[i])
+	}
+	query := "SELECT " + strings.Join(cols, ", ") + " FROM " + schema.QuoteTable(t.Name)
+	if key == nil {
+		var pk []string
+		for _, c := range t.Columns {
+			if c.PrimaryKey {
+				pk = append(pk, quoteIdent(c.Name))
+			}
+		}
+		if len(pk) > 0 {
+			query += " ORDER BY " + strings.Join(pk, ", ")
+		}
+		fmt.Fprintf(w, "\n// %s reads every row of the %s table from db.\n", load, t.Name)
+	} else {
+		fmt.Fprintf(w, "\n// %s reads the rows of %s back from db, as the database stored them,\n"+
+			"// in the same order. Rows are matched by %s; one missing from db is an\n// error.\n", load, list, key.Name)
+	}
+	fmt.Fprintf(w, "func %s(ctx context.Context, db *sql.DB) ([]%s, error) {\n", load, typ)
+	quoted := "`" + query + "`"
+	if strings.Contains(query, "`") {
+		quoted = strconv.Quote(query)
+	}
+	fmt.Fprintf(w, "\trows, err := db.QueryContext(ctx, %s)\n", quoted)
+	fmt.Fprintf(w, "\tif err != nil {\n\t\treturn nil, fmt.Errorf(\"%%s: %%w\", %s, err)\n\t}\n\tdefer rows.Close()\n", strconv.Quote(t.Name))
+	fmt.Fprintf(w, "\tvar all []%s\n\tfor rows.Next() {\n\t\tvar r %s\n", typ, typ)
+	fmt.Fprintf(w, "\t\tif err := rows.Scan(%s); err != nil {\n\t\t\treturn nil, fmt.Errorf(\"%%s: %%w\", %s, err)\n\t\t}\n", strings.Join(dests, ", "), strconv.Quote(t.Name))
+	w.WriteString("\t\tall = append(all, r)\n\t}\n\tif err := rows.Err(); err != nil {\n\t\treturn nil, err\n\t}\n")
+	if key == nil {
+		w.WriteString("\treturn all, nil\n}\n")
+		return nil
+	}
+	field := fields[keyIndex(t, key)]
+	deref := ""
+	if g.goType(*key)[0] == '*' {
+		deref = "*"
+	}
+	fmt.Fprintf(w, "\tbyKey := make(map[%s]%s, len(all))\n\tfor _, r := range all {\n", strings.TrimPrefix(g.goType(*key), "*"), typ)
+	if deref != "" {
+		fmt.Fprintf(w, "\t\tif r.%s != nil {\n\t\t\tbyKey[*r.%s] = r\n\t\t}\n", field, field)
+	} else {
+		fmt.Fprintf(w, "\t\tbyKey[r.%s] = r\n", field)
+	}
+	fmt.Fprintf(w, "\t}\n\tout := make([]%s, len(%s))\n\tfor i, want := range %s {\n", typ, list, list)
+	if deref != "" {
+		fmt.Fprintf(w, "\t\tif want.%s == nil {\n\t\t\treturn nil, fmt.Errorf(\"%s: row %%d has no %s\", i+1)\n\t\t}\n", field, t.Name, key.Name)
+	}
+	fmt.Fprintf(w, "\t\tr, ok := byKey[%swant.%s]\n\t\tif !ok {\n", deref, field)
+	fmt.Fprintf(w, "\t\t\treturn nil, fmt.Errorf(\"%s: no row with %s %%v\", %swant.%s)\n\t\t}\n", t.Name, key.Name, deref, field)
+	w.WriteString("\t\tout[i] = r\n\t}\n\treturn out, nil\n}\n")
+	return nil
+}
+
+// keyColumn returns the column a table's rows are named and matched by: a
+// UNIQUE or primary key text or integer column the database does not fill
+// in, preferring text. It is nil when there is none.
+func keyColumn(t *schema.Table) *schema.Column {
+	var found *schema.Column
+	for i, c := range t.Columns {
+		if !c.Unique && !c.PrimaryKey || c.Identity || c.PrimaryKey && c.Type == "integer" && c.ForeignKey == nil {
+			continue
+		}
+		if c.Type == "text" {
+			return &t.Columns[i]
+		}
+		if c.Type == "integer" && found == nil {
+			found = &t.Columns[i]
+		}
+	}
+	return found
+}
+
+func keyIndex(t *schema.Table, key *schema.Column) int {
+	for i := range t.Columns {
+		if &t.Columns[i] == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// goType is the field type for c: a pointer when the column may be NULL,
+// except for []byte, where nil already means NULL.
+func (g *goFile) goType(c schema.Column) string {
+	var t string
+	switch c.Type {
+	case "integer":
+		t = "int64"
+	case "decimal":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "timestamp":
+		g.needTime = true
+		t = "time.Time"
+	case "binary":
+		return "[]byte"
+	default:
+		t = "string"
+	}
+	if !c.NotNull && !c.PrimaryKey {
+		return "*" + t
+	}
+	return t
+}
+
+// literal writes v as a Go expression of c's field type.
+func (g *goFile) literal(c schema.Column, v interface{}) (string, error) {
+	typ := g.goType(c)
+	base := strings.TrimPrefix(typ, "*")
+	var lit string
+	switch base {
+	case "int64":
+		n, ok := toInt(v)
+		if !ok {
+			return "", fmt.Errorf("%v is not an integer", v)
+		}
+		lit = strconv.FormatInt(n, 10)
+	case "float64":
+		f, ok := toFloat(v)
+		if !ok || math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", fmt.Errorf("%v is not a number", v)
+		}
+		lit = strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(lit, ".e") {
+			lit += ".0"
+		}
+	case "bool":
+		b, ok := toBool(v)
+		if !ok {
+			return "", fmt.Errorf("%v is not a boolean", v)
+		}
+		lit = strconv.FormatBool(b)
+	case "time.Time":
+		ts, ok := toTime(v)
+		if !ok {
+			return "", fmt.Errorf("%v is not a timestamp", v)
+		}
+		lit = timeLiteral(ts)
+	case "[]byte":
+		if b, ok := v.([]byte); ok {
+			return fmt.Sprintf("[]byte(%q)", b), nil
+		}
+		return fmt.Sprintf("[]byte(%q)", toText(v)), nil
+	default:
+		lit = strconv.Quote(toText(v))
+	}
+	if typ[0] == '*' {
+		g.needPtr = true
+		return fmt.Sprintf("ptr[%s](%s)", base, lit), nil
+	}
+	return lit, nil
+}
+
+func toInt(v interface{}) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case int:
+		return int64(x), true
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<63 {
+			return int64(x), true
+		}
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int64:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case int64:
+		return x != 0, x == 0 || x == 1
+	case float64:
+		return x != 0, x == 0 || x == 1
+	case string:
+		switch strings.ToLower(strings.TrimSpace(x)) {
+		case "true", "t", "yes", "y", "1":
+			return true, true
+		case "false", "f", "no", "n", "0":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// timeLayouts are the timestamp forms recorded rows hold, with and without
+// a zone; a time without one is taken as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func timeLiteral(t time.Time) string {
+	loc := "time.UTC"
+	if _, off := t.Zone(); off != 0 {
+		loc = fmt.Sprintf("time.FixedZone(\"\", %d)", off)
+	}
+	return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, %s)",
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// toText is v as a string column holds it; JSON values are written back
+// as JSON.
+func toText(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case map[string]interface{}, []interface{}:
+		if b, err := json.Marshal(x); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// initialisms are written in capitals in Go names, as golint asks.
+var initialisms = map[string]bool{
+	"id": true, "url": true, "uri": true, "uuid": true, "api": true, "http": true,
+	"https": true, "json": true, "sql": true, "ip": true, "html": true, "sku": true,
+}
+
+// exported turns s into an exported Go name: words split on anything but
+// ASCII letters and digits, each capitalized. A name that would start with
+// a digit gets fallback in front; an empty one is fallback.
+func exported(s, fallback string) string {
+	var b strings.Builder
+	word := func(w string) {
+		if w == "" {
+			return
+		}
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			return
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		alnum := i < len(s) && (s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || s[i] >= '0' && s[i] <= '9')
+		if alnum && start < 0 {
+			start = i
+		} else if !alnum && start >= 0 {
+			word(s[start:i])
+			start = -1
+		}
+	}
+	out := b.String()
+	if len(out) > 40 {
+		out = out[:40]
+	}
+	if out == "" {
+		return fallback
+	}
+	if out[0] >= '0' && out[0] <= '9' {
+		return fallback + out
+	}
+	return out
+}
+
+// singular guesses the singular of a table name's last word.
+func singular(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,80 @@
+package codegen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestGo(t *testing.T) {
+	tables, err := schema.ParseFile(`
+CREATE TABLE users (id SERIAL PRIMARY KEY, email VARCHAR(100) UNIQUE, is_admin BOOLEAN NOT NULL, avatar BYTEA);
+CREATE TABLE categories (id SERIAL PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+CREATE TABLE posts (id SERIAL PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), price NUMERIC(8,2),
+  published_at TIMESTAMP, body TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := map[string][]map[string]interface{}{
+		"users": {
+			{"email": "alice@example.com", "is_admin": true, "avatar": []byte{0xff, 0}},
+			{"email": "alice.example.com", "is_admin": int64(0)},
+			{"email": nil, "is_admin": "false"},
+		},
+		"categories": {{"name": "Default"}, {"name": "2024 Sale"}},
+		"posts": {
+			{"user_id": int64(1), "price": float64(12), "published_at": "2024-03-01T09:30:00+02:00", "body": map[string]interface{}{"a": 1.0}},
+			{"user_id": "2", "published_at": "2024-03-02 10:00:00"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Go(&buf, tables, rows, "fixtures"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fixtures.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("fixtures", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"type User struct {",
+		"\tEmail   *string `db:\"email\"`",
+		"\tIsAdmin bool    `db:\"is_admin\"`",
+		`{Email: ptr[string]("alice@example.com"), IsAdmin: true, Avatar: []byte("\xff\x00")},`,
+		"UserAliceExampleCom  = 0",
+		"UserAliceExampleCom2 = 1",
+		"CategoryDefault  = 0",
+		"Category2024Sale = 1",
+		`{UserID: 1, Price: ptr[float64](12.0), PublishedAt: ptr[time.Time](time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("", 7200))), Body: ptr[string]("{\"a\":1}")},`,
+		`{UserID: 2, PublishedAt: ptr[time.Time](time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC))},`,
+		"func LoadUsers(ctx context.Context, db *sql.DB) ([]User, error) {",
+		`SELECT "id", "email", "is_admin", "avatar" FROM "users"`,
+		`SELECT "id", "user_id", "price", "published_at", "body" FROM "posts" ORDER BY "id"`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated code is missing %s", want)
+		}
+	}
+
+	rows["users"][0]["is_admin"] = "maybe"
+	if err := Go(&bytes.Buffer{}, tables, rows, "fixtures"); err == nil || !strings.Contains(err.Error(), "users row 1: is_admin") {
+		t.Errorf("bad value: %v", err)
+	}
+	if err := Go(&bytes.Buffer{}, tables, rows, "my-fixtures"); err == nil {
+		t.Error("accepted a package name with a dash")
+	}
+}
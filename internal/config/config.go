@@ -0,0 +1,613 @@
+// Package config reads seeddb.yaml, the optional project file that tailors
+// generation to a team's domain.
+//
+//	domain: ecommerce
+//	no_pii: true
+//	dictionaries:
+//	  categories: [Books, Games, Garden]
+//	  departments: [Platform, Growth, Finance]
+//	columns:
+//	  products.category: {dictionary: categories}
+//	  department: {dictionary: departments, mode: direct}
+//	  status: {values: [active, churned]}
+//	derived:
+//	  full_name: first_name + " " + last_name
+//	  order_items.total: round(quantity * unit_price, 2)
+//	currencies: [USD, EUR, GBP]
+//	binary: {mode: data-url, size: 32}
+//	emails: {domains: [example.test]}
+//	phones:
+//	  "*": {country: US, format: national}
+//	  users.mobile: {country: GB}
+//	addresses:
+//	  countries: [US, CA]
+//	null_refs:
+//	  orders.coupon_id: 0.8
+//	  "*": 0.1
+//	soft_delete:
+//	  users.deleted_at: 0.2
+//	dedup: {model: nomic-embed-text, threshold: 0.9}
+//	lengths:
+//	  bio: 1-2 sentences
+//	  comments.body: at most 140 chars
+//	languages:
+//	  reviews: {en: 0.6, es: 0.25, ja: 0.15}
+//	locales: [en, es, de]
+//	archetypes:
+//	  plans: entity
+//	tokens:
+//	  api_key: {prefix: sk_dev_, length: 24}
+//	  sessions.jwt: {format: jwt, secret: dev-secret, ttl: 24h}
+//	images:
+//	  products.image_url: https://cdn.example.test/{table}/{seed}.jpg
+//	  "*": keep
+//	cache: s3://team-bucket/seeddb
+//	protect:
+//	  hosts: ["*.prod.example.com", 10.20.0.0/16]
+//	anchors:
+//	  users:
+//	    - name: admin
+//	      values: {email: admin@example.com, role: admin}
+//	  posts:
+//	    - name: welcome
+//	      values: {title: Welcome, user_id: "@admin"}
+//	limits:
+//	  max_rows_per_table: 50000
+//	  max_total_rows: 200000
+//	  max_write_rate: 2000/s
+//	hooks:
+//	  - table: users
+//	    command: [python3, scripts/hash_passwords.py]
+//	rules:
+//	  - table: orders
+//	    if: status = 'refunded'
+//	    then: refunded_at NOT NULL
+//	    else: refunded_at IS NULL
+//	targets:
+//	  - {name: local, db: "sqlite:./dev.db"}
+//	  - {name: shared, db: '{{ env "SHARED_DB" }}'}
+//
+// Column keys are "table.column" or a bare "column" that matches every
+// table. In prompt mode (the default) the values are listed in the prompt
+// and the model picks among them; in direct mode the column is filled from
+// the list after generation. Derived columns are computed from other
+// columns of the same row after generation. Rules are conditional
+// constraints between columns (see package rules); a rule without a table
+// applies to every table that has its columns. Currencies restricts the
+// codes used in currency columns that go with amounts. Binary sets how
+// bytea/BLOB columns are filled: bytes (default), path or data-url. An
+// emails section makes email columns valid and unique, on its domains if
+// given. Phones formats phone columns, keyed like columns or "*" for every
+// column named like a phone; format is e164 (default) or national.
+// Addresses limits the countries city, state, postal code and country
+// columns are set to, so each row names one real place.
+// Null_refs sets the share of rows left NULL in nullable foreign key
+// columns, keyed like columns or "*" for all of them; the other rows always
+// reference a real parent. Soft_delete sets the share of rows deleted in
+// tables with a deleted_at or is_deleted column, keyed like columns by
+// that column or "*" (default generator.DefaultSoftDeleted). Dedup
+// embeds the values of free-text columns with an Ollama embedding model
+// and asks again for those too close to an earlier row's. Lengths sets
+// how long text columns are, in chars, words, sentences or paragraphs;
+// the model is asked for it and longer texts are cut. Languages sets the
+// share of rows written in each language, keyed by table or by
+// "table.column" for one column; a table's locale or language column is
+// set to each row's code. Shares are relative and need not add up to 1.
+// Locales are the languages of translation tables such as
+// product_translations: each parent row gets one row per locale, its text
+// translated from the parent's.
+// Archetypes corrects the guessed archetype of a
+// table (see schema.Classify), which sets its default row count, style and
+// prompt hints. Tokens sets how token columns are built: random with a
+// prefix and length (default), jwt, or keep for the model's value. Images
+// maps image URL columns to URL templates with {seed}, {width}, {height},
+// {table}, {column} and {row}, or keep; other image columns get DiceBear
+// or Lorem Picsum placeholders. Cache is where model responses are shared
+// between runs (see package cache). Protect names the databases seed,
+// stream, shift and clean refuse to write to without --force: hosts are
+// globs or CIDR ranges, and marker_table replaces the default marker
+// table (see inserter.DefaultGuardTable). Anchors are rows seed makes
+// sure exist before generating (see pipeline.Anchor); "@name" in a foreign
+// key column refers to an anchor of the referenced table. Anchor values
+// and direct column values may use {{ now }}, {{ today }},
+// {{ env "NAME" }} and {{ uuid }} (see package interp), expanded when the
+// rows are made. Limits caps the rows a seed may
+// ask for and the rate it inserts them at (see pipeline.Limits). Hooks are
+// commands the rows pass through, as JSON lines, before they are inserted;
+// one without a table gets every table. Targets name the databases a
+// project seeds, picked in the ui; their connection strings may use
+// {{ env "NAME" }} so passwords stay out of the file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/address"
+	"github.com/satyammistari/db-seed-ai/internal/derive"
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/interp"
+	"github.com/satyammistari/db-seed-ai/internal/money"
+	"github.com/satyammistari/db-seed-ai/internal/phone"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/repair"
+	"github.com/satyammistari/db-seed-ai/internal/rules"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/token"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is read when --config is not given, if it exists.
+const DefaultPath = "seeddb.yaml"
+
+// File is the parsed config file.
+type File struct {
+	Domain       string                `yaml:"domain"` // built-in preset, see generator.DomainNames
+	NoPII        bool                  `yaml:"no_pii"`
+	Dictionaries map[string][]string   `yaml:"dictionaries"`
+	Columns      map[string]ColumnRule `yaml:"columns"`
+	Derived      map[string]string     `yaml:"derived"` // column key -> expression, see package derive
+	Rules        []RuleSpec            `yaml:"rules"`
+	Currencies   []string              `yaml:"currencies"` // ISO 4217 codes
+	Binary       BinarySpec            `yaml:"binary"`
+	Emails       *EmailSpec            `yaml:"emails"`
+	Phones       map[string]phone.Rule `yaml:"phones"`
+	Addresses    AddressSpec           `yaml:"addresses"`
+	NullRefs     map[string]float64    `yaml:"null_refs"`  // column key or "*" -> share of NULLs
+	Archetypes   map[string]string     `yaml:"archetypes"` // table -> entity, lookup, join or event-log
+	Hooks        []HookSpec            `yaml:"hooks"`
+	SoftDelete   map[string]float64    `yaml:"soft_delete"`
+	Dedup        *DedupSpec            `yaml:"dedup"`
+	Lengths      map[string]string     `yaml:"lengths"` // column key -> length, see repair.ParseLength
+	Tokens       map[string]token.Rule `yaml:"tokens"`
+	Images       map[string]string     `yaml:"images"` // column key or "*" -> URL template or keep
+	Cache        string                `yaml:"cache"`  // directory, http(s)://, s3:// or gs:// location, see package cache
+	Protect      ProtectSpec           `yaml:"protect"`
+	Limits       LimitSpec             `yaml:"limits"`
+	UI           UISpec                `yaml:"ui"`
+	Targets      []TargetSpec          `yaml:"targets"`
+
+	Anchors map[string][]AnchorSpec `yaml:"anchors"` // table -> rows that must exist
+
+	// Languages maps a table or "table.column" to the share of rows in
+	// each language, by code.
+	Languages map[string]map[string]float64 `yaml:"languages"`
+	Locales   []string                      `yaml:"locales"` // one translation row each, see generator.TranslationOf
+
+	derived map[string]*derive.Expr
+	rules   []*rules.Rule
+	lengths map[string]repair.Length
+}
+
+// BinarySpec configures binary column payloads.
+type BinarySpec struct {
+	Mode string `yaml:"mode"` // bytes, path or data-url
+	Size int    `yaml:"size"` // bytes per generic payload
+}
+
+// AddressSpec limits the places address columns are set to.
+type AddressSpec struct {
+	Countries []string `yaml:"countries"` // ISO 3166 alpha-2 codes
+}
+
+// ProtectSpec names the databases that must not be written to by accident.
+type ProtectSpec struct {
+	Hosts       []string `yaml:"hosts"`        // host globs or CIDR ranges
+	MarkerTable string   `yaml:"marker_table"` // default inserter.DefaultGuardTable
+}
+
+// LimitSpec holds the safety caps of pipeline.Limits.
+type LimitSpec struct {
+	MaxRowsPerTable int    `yaml:"max_rows_per_table"`
+	MaxTotalRows    int    `yaml:"max_total_rows"`
+	MaxWriteRate    string `yaml:"max_write_rate"` // rows per s, m or h, e.g. 500/s
+}
+
+// UISpec configures the ui command. The tui package checks the names.
+type UISpec struct {
+	Keymap string              `yaml:"keymap"` // default, vim or emacs
+	Keys   map[string][]string `yaml:"keys"`   // action -> keys, replacing the keymap's
+}
+
+// TargetSpec is a database seeds can go to, by name.
+type TargetSpec struct {
+	Name string `yaml:"name"`
+	DB   string `yaml:"db"` // connection string, may use {{ env "NAME" }}
+}
+
+// Conn returns the target's connection string with its expressions
+// expanded.
+func (t TargetSpec) Conn() (string, error) {
+	conn, err := interp.Expand(t.DB, interp.Env{})
+	if err != nil {
+		return "", fmt.Errorf("target %s: %w", t.Name, err)
+	}
+	return conn, nil
+}
+
+// Pipeline returns the caps as pipeline.Limits. The file must have been
+// checked.
+func (l LimitSpec) Pipeline() pipeline.Limits {
+	out := pipeline.Limits{MaxTableRows: l.MaxRowsPerTable, MaxTotalRows: l.MaxTotalRows}
+	if l.MaxWriteRate != "" {
+		out.MaxWriteRate, _ = pipeline.ParseRate(l.MaxWriteRate)
+	}
+	return out
+}
+
+// AnchorSpec is a named row that must exist.
+type AnchorSpec struct {
+	Name   string                 `yaml:"name"`
+	Values map[string]interface{} `yaml:"values"` // column -> value
+}
+
+// AnchorRows returns the anchors as pipeline.Anchors, keyed by table.
+func (f *File) AnchorRows() map[string][]pipeline.Anchor {
+	if len(f.Anchors) == 0 {
+		return nil
+	}
+	out := make(map[string][]pipeline.Anchor, len(f.Anchors))
+	for table, specs := range f.Anchors {
+		for _, a := range specs {
+			out[table] = append(out[table], pipeline.Anchor{Name: a.Name, Values: a.Values})
+		}
+	}
+	return out
+}
+
+// DedupSpec turns on the near-duplicate pass (see generator.Dedup).
+type DedupSpec struct {
+	Model     string  `yaml:"model"`     // Ollama embedding model
+	Threshold float64 `yaml:"threshold"` // cosine similarity, default generator.DefaultDedupThreshold
+}
+
+// EmailSpec turns on the email pass.
+type EmailSpec struct {
+	Domains []string `yaml:"domains"`
+}
+
+// HookSpec is a command rows pass through before insertion (see
+// generator.ExecHook).
+type HookSpec struct {
+	Table   string   `yaml:"table"`
+	Command []string `yaml:"command"`
+}
+
+// RuleSpec is a conditional rule as written in the file.
+type RuleSpec struct {
+	Table string `yaml:"table"`
+	If    string `yaml:"if"`
+	Then  string `yaml:"then"`
+	Else  string `yaml:"else"`
+}
+
+// ColumnRule assigns a dictionary (or inline values) to a column.
+type ColumnRule struct {
+	Dictionary string   `yaml:"dictionary"`
+	Values     []string `yaml:"values"`
+	Mode       string   `yaml:"mode"` // "prompt" (default) or "direct"
+}
+
+// Load reads and checks the config at path. An empty path loads
+// DefaultPath when it exists and returns an empty File otherwise.
+func Load(path string) (*File, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return Parse(data, path)
+}
+
+// Parse decodes config data; name is used in error messages.
+func Parse(data []byte, name string) (*File, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := f.check(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &f, nil
+}
+
+func (f *File) check() error {
+	if f.Domain != "" {
+		if _, err := generator.LookupDomain(f.Domain); err != nil {
+			return err
+		}
+	}
+	for _, k := range sortedKeys(f.Columns) {
+		r := f.Columns[k]
+		switch {
+		case r.Dictionary != "" && len(r.Values) > 0:
+			return fmt.Errorf("columns.%s: set dictionary or values, not both", k)
+		case r.Dictionary != "":
+			if _, ok := f.Dictionaries[r.Dictionary]; !ok {
+				return fmt.Errorf("columns.%s: unknown dictionary %q", k, r.Dictionary)
+			}
+		case len(r.Values) == 0:
+			return fmt.Errorf("columns.%s: needs a dictionary or values", k)
+		}
+		if r.Mode != "" && r.Mode != "prompt" && r.Mode != "direct" {
+			return fmt.Errorf("columns.%s: mode must be prompt or direct, got %q", k, r.Mode)
+		}
+		values := r.Values
+		if r.Dictionary != "" {
+			values = f.Dictionaries[r.Dictionary]
+		}
+		for _, v := range values {
+			if !interp.Has(v) {
+				continue
+			}
+			if r.Mode != "direct" {
+				return fmt.Errorf("columns.%s: %q needs mode: direct, the model is not shown {{ }} expressions", k, v)
+			}
+			if err := interp.Check(v); err != nil {
+				return fmt.Errorf("columns.%s: %w", k, err)
+			}
+		}
+	}
+	f.derived = make(map[string]*derive.Expr, len(f.Derived))
+	for k, src := range f.Derived {
+		e, err := derive.Parse(src)
+		if err != nil {
+			return fmt.Errorf("derived.%s: %w", k, err)
+		}
+		f.derived[k] = e
+	}
+	switch f.Binary.Mode {
+	case "", generator.BinaryBytes, generator.BinaryPath, generator.BinaryDataURL:
+	default:
+		return fmt.Errorf("binary.mode must be bytes, path or data-url, got %q", f.Binary.Mode)
+	}
+	if f.Binary.Size < 0 {
+		return fmt.Errorf("binary.size must not be negative")
+	}
+	if f.Emails != nil {
+		for i, d := range f.Emails.Domains {
+			if d == "" || strings.ContainsAny(d, "@ ") || !strings.Contains(d, ".") {
+				return fmt.Errorf("emails.domains[%d]: %q is not a domain", i, d)
+			}
+		}
+	}
+	for _, k := range sortedKeys(f.Phones) {
+		r := f.Phones[k]
+		if _, ok := phone.Lookup(r.Country); !ok {
+			return fmt.Errorf("phones.%s: unknown country %q (known: %s)", k, r.Country, strings.Join(phone.Countries(), ", "))
+		}
+		if r.Format != "" && r.Format != phone.E164 && r.Format != phone.National {
+			return fmt.Errorf("phones.%s: format must be e164 or national, got %q", k, r.Format)
+		}
+	}
+	for i, code := range f.Addresses.Countries {
+		c, ok := address.LookupCountry(code)
+		if !ok {
+			return fmt.Errorf("addresses.countries[%d]: unknown country %q (known: %s)", i, code, strings.Join(address.Codes(), ", "))
+		}
+		f.Addresses.Countries[i] = c.Code
+	}
+	for _, k := range sortedKeys(f.NullRefs) {
+		if r := f.NullRefs[k]; r < 0 || r > 1 || math.IsNaN(r) {
+			return fmt.Errorf("null_refs.%s: must be between 0 and 1, got %v", k, r)
+		}
+	}
+	for _, k := range sortedKeys(f.SoftDelete) {
+		if r := f.SoftDelete[k]; r < 0 || r > 1 || math.IsNaN(r) {
+			return fmt.Errorf("soft_delete.%s: must be between 0 and 1, got %v", k, r)
+		}
+	}
+	f.lengths = make(map[string]repair.Length, len(f.Lengths))
+	for _, k := range sortedKeys(f.Lengths) {
+		l, err := repair.ParseLength(f.Lengths[k])
+		if err != nil {
+			return fmt.Errorf("lengths.%s: %w", k, err)
+		}
+		f.lengths[k] = l
+	}
+	for _, k := range sortedKeys(f.Languages) {
+		mix := f.Languages[k]
+		if len(mix) == 0 {
+			return fmt.Errorf("languages.%s: needs at least one language, e.g. {en: 0.6, es: 0.4}", k)
+		}
+		for _, code := range sortedKeys(mix) {
+			if r := mix[code]; r <= 0 || math.IsNaN(r) || math.IsInf(r, 0) {
+				return fmt.Errorf("languages.%s.%s: share must be above 0, got %v", k, code, r)
+			}
+		}
+	}
+	seenLocale := make(map[string]bool, len(f.Locales))
+	for i, code := range f.Locales {
+		switch {
+		case strings.TrimSpace(code) == "":
+			return fmt.Errorf("locales[%d]: empty language code", i)
+		case seenLocale[code]:
+			return fmt.Errorf("locales[%d]: %q is listed twice", i, code)
+		}
+		seenLocale[code] = true
+	}
+	if f.Dedup != nil {
+		if f.Dedup.Model == "" {
+			return errors.New("dedup: needs an embedding model, e.g. nomic-embed-text")
+		}
+		if t := f.Dedup.Threshold; t < 0 || t > 1 || math.IsNaN(t) {
+			return fmt.Errorf("dedup.threshold: must be between 0 and 1, got %v", t)
+		}
+	}
+	for _, k := range sortedKeys(f.Archetypes) {
+		if _, ok := schema.ParseArchetype(f.Archetypes[k]); !ok {
+			return fmt.Errorf("archetypes.%s: must be entity, lookup, join or event-log, got %q", k, f.Archetypes[k])
+		}
+	}
+	for _, k := range sortedKeys(f.Tokens) {
+		if err := f.Tokens[k].Check(); err != nil {
+			return fmt.Errorf("tokens.%s: %w", k, err)
+		}
+	}
+	for _, k := range sortedKeys(f.Images) {
+		if err := repair.CheckImageTemplate(f.Images[k]); err != nil {
+			return fmt.Errorf("images.%s: %w", k, err)
+		}
+	}
+	for i, p := range f.Protect.Hosts {
+		if err := inserter.CheckHostPattern(p); err != nil {
+			return fmt.Errorf("protect.hosts[%d]: %w", i, err)
+		}
+	}
+	for _, table := range sortedKeys(f.Anchors) {
+		for _, a := range f.Anchors[table] {
+			for _, col := range sortedKeys(a.Values) {
+				if v, ok := a.Values[col].(string); ok {
+					if err := interp.Check(v); err != nil {
+						return fmt.Errorf("anchors.%s.%s.%s: %w", table, a.Name, col, err)
+					}
+				}
+			}
+		}
+	}
+	if f.Limits.MaxRowsPerTable < 0 || f.Limits.MaxTotalRows < 0 {
+		return errors.New("limits: row caps must not be negative")
+	}
+	if f.Limits.MaxWriteRate != "" {
+		if _, err := pipeline.ParseRate(f.Limits.MaxWriteRate); err != nil {
+			return fmt.Errorf("limits.max_write_rate: %w", err)
+		}
+	}
+	for i, h := range f.Hooks {
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return fmt.Errorf("hooks[%d]: needs a command, e.g. [python3, hash_passwords.py]", i)
+		}
+	}
+	seen := make(map[string]bool, len(f.Targets))
+	for i, t := range f.Targets {
+		switch {
+		case t.Name == "" || t.DB == "":
+			return fmt.Errorf("targets[%d]: needs a name and a db", i)
+		case seen[t.Name]:
+			return fmt.Errorf("targets[%d]: %q is named twice", i, t.Name)
+		}
+		seen[t.Name] = true
+		if err := interp.Check(t.DB); err != nil {
+			return fmt.Errorf("targets.%s: %w", t.Name, err)
+		}
+	}
+	for i, code := range f.Currencies {
+		c, ok := money.Lookup(code)
+		if !ok {
+			return fmt.Errorf("currencies[%d]: unknown ISO 4217 code %q", i, code)
+		}
+		f.Currencies[i] = c.Code
+	}
+	f.rules = nil
+	for i, spec := range f.Rules {
+		r, err := rules.New(spec.Table, spec.If, spec.Then, spec.Else)
+		if err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		f.rules = append(f.rules, r)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ColumnValues resolves the column rules into generator settings.
+func (f *File) ColumnValues() map[string]generator.ColumnValues {
+	if len(f.Columns) == 0 {
+		return nil
+	}
+	out := make(map[string]generator.ColumnValues, len(f.Columns))
+	for k, r := range f.Columns {
+		values := r.Values
+		if r.Dictionary != "" {
+			values = f.Dictionaries[r.Dictionary]
+		}
+		out[k] = generator.ColumnValues{Values: values, Direct: r.Mode == "direct"}
+	}
+	return out
+}
+
+// Apply copies the config's settings into cfg.
+func (f *File) Apply(cfg *generator.Config) {
+	cfg.Values = f.ColumnValues()
+	if len(f.derived) > 0 {
+		cfg.Derived = f.derived
+	}
+	if len(f.rules) > 0 {
+		cfg.Rules = f.rules
+	}
+	if len(f.Currencies) > 0 {
+		cfg.Currencies = f.Currencies
+	}
+	if f.Emails != nil {
+		cfg.Emails, cfg.EmailDomains = true, f.Emails.Domains
+	}
+	if len(f.Phones) > 0 {
+		cfg.Phones = f.Phones
+	}
+	if len(f.Addresses.Countries) > 0 {
+		cfg.AddressCountries = f.Addresses.Countries
+	}
+	if len(f.NullRefs) > 0 {
+		cfg.NullRefs = f.NullRefs
+	}
+	if len(f.SoftDelete) > 0 {
+		cfg.SoftDeleted = f.SoftDelete
+	}
+	if len(f.lengths) > 0 {
+		cfg.Lengths = f.lengths
+	}
+	if len(f.Languages) > 0 {
+		cfg.Languages = f.Languages
+	}
+	if len(f.Locales) > 0 {
+		cfg.Locales = f.Locales
+	}
+	if f.Dedup != nil {
+		cfg.Dedup = &generator.Dedup{Model: f.Dedup.Model, Threshold: f.Dedup.Threshold}
+	}
+	if len(f.Tokens) > 0 {
+		cfg.Tokens = f.Tokens
+	}
+	if len(f.Images) > 0 {
+		cfg.Images = f.Images
+	}
+	if len(f.Archetypes) > 0 {
+		cfg.Archetypes = make(map[string]schema.Archetype, len(f.Archetypes))
+		for table, name := range f.Archetypes {
+			cfg.Archetypes[table], _ = schema.ParseArchetype(name) // checked by Parse
+		}
+	}
+	for _, h := range f.Hooks {
+		cfg.Hooks = append(cfg.Hooks, generator.ExecHook{Table: h.Table, Command: h.Command})
+	}
+	if f.Binary.Mode != "" || f.Binary.Size != 0 {
+		cfg.Binary = generator.BinaryFill{Mode: f.Binary.Mode, Size: f.Binary.Size}
+	}
+	cfg.NoPII = cfg.NoPII || f.NoPII
+	if f.Domain != "" {
+		cfg.Domain, _ = generator.LookupDomain(f.Domain) // checked by Parse
+	}
+}
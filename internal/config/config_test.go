@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(`
+dictionaries:
+  categories: [Books, Games]
+columns:
+  products.category: {dictionary: categories}
+  department: {values: [Platform, Growth], mode: direct}
+`), "seeddb.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := f.ColumnValues()
+	if got := v["products.category"]; got.Direct || strings.Join(got.Values, ",") != "Books,Games" {
+		t.Errorf("products.category: %+v", got)
+	}
+	if got := v["department"]; !got.Direct || len(got.Values) != 2 {
+		t.Errorf("department: %+v", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"columns:\n  a: {dictionary: nope}\n":          `unknown dictionary "nope"`,
+		"columns:\n  a: {values: [x], mode: always}\n": "mode must be prompt or direct",
+		"columns:\n  a: {}\n":                          "needs a dictionary or values",
+		"derived:\n  total: qty *\n":                   "derived.total",
+		"rules:\n  - {if: \"a = 1\"}\n":                "rules[0]: a rule needs both if and then",
+		"binary: {mode: base64}\n":                     "binary.mode must be",
+		"emails: {domains: [\"@example.test\"]}\n":     "is not a domain",
+		"phones:\n  phone: {country: XX}\n":            `phones.phone: unknown country "XX"`,
+		"currencies: [USD, XXY]\n":                     `unknown ISO 4217 code "XXY"`,
+		"null_refs:\n  coupon_id: 1.5\n":               "null_refs.coupon_id: must be between 0 and 1",
+		"archetypes:\n  plans: reference\n":            "archetypes.plans: must be entity, lookup, join or event-log",
+		"soft_delete: {deleted_at: -1}\n":              "soft_delete.deleted_at: must be between 0 and 1",
+		"dedup: {model: m, threshold: 2}\n":            "dedup.threshold: must be between 0 and 1",
+		"lengths: {bio: 3 lines}\n":                    "lengths.bio: \"3 lines\": unit must be",
+		"languages: {posts: {en: 1, es: 0}}\n":         "languages.posts.es: share must be above 0",
+		"locales: [en, es, en]\n":                      `locales[2]: "en" is listed twice`,
+		"hooks:\n  - table: users\n":                   "hooks[0]: needs a command",
+		"images:\n  logo: ftp://x/{id}\n":              "images.logo: \"ftp://x/{id}\" must be keep",
+		"images:\n  logo: https://x/{id}.png\n":        "images.logo: \"https://x/{id}.png\": unknown placeholder",
+		"addresses:\n  countries: [US, XX]\n":          "addresses.countries[1]: unknown country \"XX\"",
+		"tokens:\n  api_key: {format: uuid}\n":         "tokens.api_key: format must be random, jwt or keep",
+		"protect: {hosts: [10.0.0.0/33]}\n":            "protect.hosts[0]: \"10.0.0.0/33\" is not a CIDR range",
+		"limits: {max_write_rate: fast}\n":             "limits.max_write_rate: invalid rate \"fast\"",
+		"dictionaries: {}\ncolums: {}\n":               "field colums not found",
+		"columns: {a: {values: ['{{now}}']}}\n":        "columns.a: \"{{now}}\" needs mode: direct",
+		"anchors: {u: [{name: a, values: {b: '{{'}}]}": "anchors.u.a.b: {{: template",
+		"targets: [{name: local}]\n":                   "targets[0]: needs a name and a db",
+		"targets: [{name: a, db: x},{name: a, db: y}]": `targets[1]: "a" is named twice`,
+	}
+	for src, want := range cases {
+		_, err := Parse([]byte(src), "seeddb.yaml")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", src, want, err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Package dbdiff compares the seeded tables of two databases: their row
+// counts, and a sample of rows taken in primary key order, value by
+// value. It checks that a seed mirrored to several databases, or replayed
+// from a recording, produced the same data, also between SQLite and
+// Postgres: values are compared as text in one form per column type, so
+// 1 and true, 12.5 and 12.50, or a timestamp string and a time.Time match.
+package dbdiff
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Defaults for Options fields left zero.
+const (
+	DefaultSample     = 100 // rows compared per table
+	DefaultMismatches = 10  // differing values kept per table
+)
+
+// Options sets what Compare reads.
+type Options struct {
+	Sample     int      // rows compared per table, in primary key order
+	Mismatches int      // differing values kept per table; the rest are only counted
+	Ignore     []string // columns left out, as "column" for every table or "table.column"
+}
+
+// TableDiff is how one table differs.
+type TableDiff struct {
+	Table      string
+	RowsA      int64
+	RowsB      int64
+	Compared   int        // sampled rows compared
+	Differing  int        // of those, rows with a differing value
+	Mismatches []Mismatch // the first differing values
+}
+
+// Mismatch is a value that differs between the databases.
+type Mismatch struct {
+	Row    int // position in primary key order, from 1
+	Column string
+	A, B   string // as compared; NULL for NULL
+}
+
+// Equal reports whether the table has as many rows in both databases and
+// its sampled rows match.
+func (d *TableDiff) Equal() bool { return d.RowsA == d.RowsB && d.Differing == 0 }
+
+// Compare compares each of tables in a and b.
+func Compare(a, b *sql.DB, tables []*schema.Table, opts Options) ([]*TableDiff, error) {
+	if opts.Sample <= 0 {
+		opts.Sample = DefaultSample
+	}
+	if opts.Mismatches <= 0 {
+		opts.Mismatches = DefaultMismatches
+	}
+	var out []*TableDiff
+	for _, t := range tables {
+		d, err := compareTable(a, b, t, opts)
+		if err != nil {
+			return out, fmt.Errorf("dbdiff %s: %w", t.Name, err)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func compareTable(a, b *sql.DB, t *schema.Table, opts Options) (*TableDiff, error) {
+	d := &TableDiff{Table: t.Name}
+	count := "SELECT COUNT(*) FROM " + schema.QuoteTable(t.Name)
+	if err := a.QueryRow(count).Scan(&d.RowsA); err != nil {
+		return nil, fmt.Errorf("a: %w", err)
+	}
+	if err := b.QueryRow(count).Scan(&d.RowsB); err != nil {
+		return nil, fmt.Errorf("b: %w", err)
+	}
+	cols := columns(t, opts.Ignore)
+	if len(cols) == 0 {
+		return d, nil
+	}
+	q := sampleQuery(t, cols, opts.Sample)
+	rowsA, err := sample(a, q, cols)
+	if err != nil {
+		return nil, fmt.Errorf("a: %w", err)
+	}
+	rowsB, err := sample(b, q, cols)
+	if err != nil {
+		return nil, fmt.Errorf("b: %w", err)
+	}
+	d.Compared = min(len(rowsA), len(rowsB))
+	for i := 0; i < d.Compared; i++ {
+		differs := false
+		for j, c := range cols {
+			if rowsA[i][j] == rowsB[i][j] {
+				continue
+			}
+			differs = true
+			if len(d.Mismatches) < opts.Mismatches {
+				d.Mismatches = append(d.Mismatches, Mismatch{Row: i + 1, Column: c.Name, A: rowsA[i][j], B: rowsB[i][j]})
+			}
+		}
+		if differs {
+			d.Differing++
+		}
+	}
+	return d, nil
+}
+
+// columns returns the columns of t that are compared.
+func columns(t *schema.Table, ignore []string) []schema.Column {
+	skip := make(map[string]bool, len(ignore))
+	for _, k := range ignore {
+		skip[k] = true
+	}
+	var out []schema.Column
+	for _, c := range t.Columns {
+		if !skip[c.Name] && !skip[t.Name+"."+c.Name] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// sampleQuery selects cols from the first n rows of t by primary key, or
+// by every compared column when t has none.
+func sampleQuery(t *schema.Table, cols []schema.Column, n int) string {
+	var sel, order []string
+	for _, c := range cols {
+		sel = append(sel, quote(c.Name))
+	}
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			order = append(order, quote(c.Name))
+		}
+	}
+	if len(order) == 0 {
+		order = sel
+	}
+	return fmt.Sprintf("SELECT %s FROM %s ORDER BY %s LIMIT %d",
+		strings.Join(sel, ", "), schema.QuoteTable(t.Name), strings.Join(order, ", "), n)
+}
+
+// sample runs q and returns each row's values in canonical form.
+func sample(db *sql.DB, q string, cols []schema.Column) ([][]string, error) {
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out [][]string
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = canonical(c, values[i])
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// timeLayouts are the ways SQLite may hold a timestamp as text.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// canonical returns v as text in one form for its column's type.
+func canonical(c schema.Column, v interface{}) string {
+	if v == nil {
+		return "NULL"
+	}
+	if b, ok := v.([]byte); ok {
+		if c.Type == "binary" {
+			return `\x` + hex.EncodeToString(b)
+		}
+		v = string(b)
+	}
+	switch c.Type {
+	case "boolean":
+		switch x := v.(type) {
+		case bool:
+			return strconv.FormatBool(x)
+		case int64:
+			return strconv.FormatBool(x != 0)
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(x)); err == nil {
+				return strconv.FormatBool(b)
+			}
+		}
+	case "integer", "decimal":
+		if n, err := strconv.ParseInt(strings.TrimSpace(fmt.Sprint(v)), 10, 64); err == nil {
+			return strconv.FormatInt(n, 10) // past 2^53 a float64 would round
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case "timestamp":
+		switch x := v.(type) {
+		case time.Time:
+			return x.UTC().Format("2006-01-02 15:04:05.999999999")
+		case string:
+			for _, layout := range timeLayouts {
+				if ts, err := time.Parse(layout, x); err == nil {
+					return ts.UTC().Format("2006-01-02 15:04:05.999999999")
+				}
+			}
+		}
+	}
+	if s, ok := v.(string); ok && strings.Contains(c.SQLType, "json") {
+		var doc interface{}
+		if json.Unmarshal([]byte(s), &doc) == nil {
+			if out, err := json.Marshal(doc); err == nil {
+				return string(out)
+			}
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,82 @@
+package dbdiff
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+const testSchema = `CREATE TABLE users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT NOT NULL,
+  active BOOLEAN,
+  balance DECIMAL(10,2),
+  created_at TIMESTAMP,
+  seed_batch_id TEXT
+);`
+
+func open(t *testing.T, inserts ...string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	for _, q := range append([]string{testSchema}, inserts...) {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestCompare(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := open(t,
+		`INSERT INTO users (email, active, balance, created_at, seed_batch_id) VALUES ('a@x.io', 1, 12.5, '2024-03-01 10:00:00', 'run-1')`,
+		`INSERT INTO users (email, active, balance, created_at, seed_batch_id) VALUES ('b@x.io', 0, 3, '2024-03-02', 'run-1')`)
+	b := open(t,
+		`INSERT INTO users (email, active, balance, created_at, seed_batch_id) VALUES ('a@x.io', 'true', '12.50', '2024-03-01T10:00:00Z', 'run-2')`,
+		`INSERT INTO users (email, active, balance, created_at, seed_batch_id) VALUES ('c@x.io', 'false', 3.0, '2024-03-02 00:00:00', 'run-2')`,
+		`INSERT INTO users (email) VALUES ('d@x.io')`)
+
+	diffs, err := Compare(a, b, s.Tables, Options{Ignore: []string{"seed_batch_id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := diffs[0]
+	if d.RowsA != 2 || d.RowsB != 3 || d.Compared != 2 || d.Differing != 1 || d.Equal() {
+		t.Fatalf("diff = %+v", d)
+	}
+	if len(d.Mismatches) != 1 || d.Mismatches[0] != (Mismatch{Row: 2, Column: "email", A: "b@x.io", B: "c@x.io"}) {
+		t.Errorf("mismatches = %+v", d.Mismatches)
+	}
+
+	// Without the ignore, the run tags differ too.
+	diffs, _ = Compare(a, b, s.Tables, Options{Mismatches: 1})
+	if d := diffs[0]; d.Differing != 2 || len(d.Mismatches) != 1 {
+		t.Errorf("with tags: %+v", d)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	ts := schema.Column{Type: "timestamp"}
+	when := time.Date(2024, 3, 1, 11, 0, 0, 0, time.FixedZone("CET", 3600))
+	if a, b := canonical(ts, when), canonical(ts, "2024-03-01 10:00:00"); a != b {
+		t.Errorf("timestamps: %q != %q", a, b)
+	}
+	doc := schema.Column{Type: "text", SQLType: "jsonb"}
+	if a, b := canonical(doc, `{"b": 1, "a": [1, 2]}`), canonical(doc, []byte(`{"a":[1,2],"b":1}`)); a != b {
+		t.Errorf("json: %q != %q", a, b)
+	}
+	if got := canonical(schema.Column{Type: "binary"}, []byte{0x0a, 0xff}); got != `\x0aff` {
+		t.Errorf("binary: %q", got)
+	}
+}
@@ -0,0 +1,263 @@
+// Package derive evaluates the small expressions used for derived columns
+// in seeddb.yaml:
+//
+//	full_name: first_name + " " + last_name
+//	total:     round(quantity * unit_price, 2)
+//	slug:      slugify(title)
+//
+// Names refer to other columns of the same row. + adds two numbers and
+// concatenates anything else; - * / work on numbers (numeric strings are
+// accepted). A NULL operand makes the result NULL, except in concat and
+// coalesce.
+package derive
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// String returns the source text.
+func (e *Expr) String() string { return e.src }
+
+// Columns returns the column names the expression reads, sorted.
+func (e *Expr) Columns() []string {
+	seen := make(map[string]bool)
+	e.root.columns(seen)
+	out := make([]string, 0, len(seen))
+	for c := range seen {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Eval computes the expression for one row.
+func (e *Expr) Eval(row map[string]interface{}) (interface{}, error) {
+	v, err := e.root.eval(row)
+	if f, ok := v.(float64); ok {
+		// Hide binary floating point noise: 3 * 19.99 is 59.97, not 59.970000000000006.
+		v = math.Round(f*1e9) / 1e9
+	}
+	return v, err
+}
+
+type node interface {
+	eval(row map[string]interface{}) (interface{}, error)
+	columns(seen map[string]bool)
+}
+
+type (
+	literal struct{ v interface{} }
+	column  struct{ name string }
+	unary   struct{ x node }
+	binary  struct {
+		op   byte
+		l, r node
+	}
+	call struct {
+		fn   string
+		args []node
+	}
+)
+
+func (n literal) eval(map[string]interface{}) (interface{}, error) { return n.v, nil }
+func (n literal) columns(map[string]bool)                          {}
+
+func (n column) eval(row map[string]interface{}) (interface{}, error) {
+	v, ok := row[n.name]
+	if !ok {
+		return nil, fmt.Errorf("column %q is not in the row", n.name)
+	}
+	return v, nil
+}
+func (n column) columns(seen map[string]bool) { seen[n.name] = true }
+
+func (n unary) eval(row map[string]interface{}) (interface{}, error) {
+	v, err := n.x.eval(row)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	f, err := number(v)
+	if err != nil {
+		return nil, err
+	}
+	return -f, nil
+}
+func (n unary) columns(seen map[string]bool) { n.x.columns(seen) }
+
+func (n binary) eval(row map[string]interface{}) (interface{}, error) {
+	l, err := n.l.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.eval(row)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	if n.op == '+' && !(isNumber(l) && isNumber(r)) {
+		return text(l) + text(r), nil
+	}
+	a, err := number(l)
+	if err != nil {
+		return nil, err
+	}
+	b, err := number(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case '+':
+		return a + b, nil
+	case '-':
+		return a - b, nil
+	case '*':
+		return a * b, nil
+	default:
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}
+}
+func (n binary) columns(seen map[string]bool) { n.l.columns(seen); n.r.columns(seen) }
+
+func (n call) columns(seen map[string]bool) {
+	for _, a := range n.args {
+		a.columns(seen)
+	}
+}
+
+func (n call) eval(row map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(row)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch n.fn {
+	case "concat":
+		var b strings.Builder
+		for _, a := range args {
+			if a != nil {
+				b.WriteString(text(a))
+			}
+		}
+		return b.String(), nil
+	case "coalesce":
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	switch n.fn {
+	case "lower":
+		return strings.ToLower(text(args[0])), nil
+	case "upper":
+		return strings.ToUpper(text(args[0])), nil
+	case "trim":
+		return strings.TrimSpace(text(args[0])), nil
+	case "slugify":
+		return Slugify(text(args[0])), nil
+	case "round":
+		f, err := number(args[0])
+		if err != nil {
+			return nil, err
+		}
+		places := 0.0
+		if len(args) == 2 && args[1] != nil {
+			if places, err = number(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		p := math.Pow(10, places)
+		return math.Round(f*p) / p, nil
+	}
+	return nil, fmt.Errorf("unknown function %s", n.fn)
+}
+
+// functions maps each function to its allowed argument count (-1: one or more).
+var functions = map[string][2]int{
+	"concat":   {1, -1},
+	"coalesce": {1, -1},
+	"lower":    {1, 1},
+	"upper":    {1, 1},
+	"trim":     {1, 1},
+	"slugify":  {1, 1},
+	"round":    {1, 2},
+}
+
+// Slugify lowercases s and joins its words with hyphens: "Hello, World!"
+// becomes "hello-world".
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, float32, int, int64, int32:
+		return true
+	}
+	return false
+}
+
+func number(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case float32:
+		return float64(x), nil
+	case int:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case int32:
+		return float64(x), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", x)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%v is not a number", v)
+}
+
+func text(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
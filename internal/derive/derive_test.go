@@ -0,0 +1,48 @@
+package derive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	row := map[string]interface{}{
+		"first_name": "Ada", "last_name": "Lovelace", "quantity": float64(3),
+		"unit_price": "19.99", "title": "Hello, World!", "nickname": nil,
+	}
+	cases := map[string]interface{}{
+		`first_name + " " + last_name`:          "Ada Lovelace",
+		`quantity * unit_price`:                 59.97,
+		`round(quantity * unit_price * 1.1, 2)`: 65.97,
+		`slugify(title)`:                        "hello-world",
+		`-quantity + 10`:                        float64(7),
+		`coalesce(nickname, lower(first_name))`: "ada",
+		`nickname + "x"`:                        nil,
+		`concat(nickname, first_name)`:          "Ada",
+	}
+	for src, want := range cases {
+		e, err := Parse(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		got, err := e.Eval(row)
+		if err != nil || got != want {
+			t.Errorf("%s = %#v (%v), want %#v", src, got, err, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		`a +`:          "unexpected end",
+		`frob(a)`:      "unknown function frob",
+		`round(a,1,2)`: "round takes 1 or 2 arguments",
+		`(a + b`:       "missing )",
+		`"open`:        "unterminated string",
+	} {
+		_, err := Parse(src)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", src, want, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package derive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse compiles an expression.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	p.next()
+	root, err := p.expr()
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", src, err)
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("%q: unexpected %q at %d", src, p.tok, p.pos)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// parser is a recursive-descent parser over a one-token lookahead.
+type parser struct {
+	src  string
+	off  int    // read offset
+	tok  string // current token; "" at end of input
+	pos  int    // offset of tok
+	kind byte   // 'n'umber, 's'tring, 'i'dent or 'p'unct
+	err  error
+}
+
+func (p *parser) next() {
+	for p.off < len(p.src) && (p.src[p.off] == ' ' || p.src[p.off] == '\t') {
+		p.off++
+	}
+	p.pos = p.off
+	if p.off >= len(p.src) {
+		p.tok = ""
+		return
+	}
+	c := p.src[p.off]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.off < len(p.src) && (p.src[p.off] >= '0' && p.src[p.off] <= '9' || p.src[p.off] == '.') {
+			p.off++
+		}
+		p.kind = 'n'
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.off+1:], c)
+		if end == -1 {
+			p.err = fmt.Errorf("unterminated string at %d", p.off)
+			p.off = len(p.src)
+		} else {
+			p.off += end + 2
+		}
+		p.kind = 's'
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.off < len(p.src) && (p.src[p.off] == '_' || p.src[p.off] >= 'a' && p.src[p.off] <= 'z' ||
+			p.src[p.off] >= 'A' && p.src[p.off] <= 'Z' || p.src[p.off] >= '0' && p.src[p.off] <= '9') {
+			p.off++
+		}
+		p.kind = 'i'
+	default:
+		p.off++
+		p.kind = 'p'
+	}
+	p.tok = p.src[p.pos:p.off]
+}
+
+// expr := term (('+' | '-') term)*
+func (p *parser) expr() (node, error) {
+	l, err := p.term()
+	for err == nil && (p.tok == "+" || p.tok == "-") {
+		op := p.tok[0]
+		p.next()
+		var r node
+		if r, err = p.term(); err == nil {
+			l = binary{op: op, l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+// term := unary (('*' | '/') unary)*
+func (p *parser) term() (node, error) {
+	l, err := p.unary()
+	for err == nil && (p.tok == "*" || p.tok == "/") {
+		op := p.tok[0]
+		p.next()
+		var r node
+		if r, err = p.unary(); err == nil {
+			l = binary{op: op, l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+// unary := '-' unary | primary
+func (p *parser) unary() (node, error) {
+	if p.tok == "-" {
+		p.next()
+		x, err := p.unary()
+		return unary{x: x}, err
+	}
+	return p.primary()
+}
+
+// primary := number | string | name | name '(' args ')' | '(' expr ')'
+func (p *parser) primary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	tok, pos := p.tok, p.pos
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case p.kind == 'n':
+		p.next()
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q at %d", tok, pos)
+		}
+		return literal{f}, nil
+	case p.kind == 's':
+		p.next()
+		return literal{tok[1 : len(tok)-1]}, nil
+	case tok == "(":
+		p.next()
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, fmt.Errorf("missing ) at %d", p.pos)
+		}
+		p.next()
+		return x, nil
+	case p.kind == 'i':
+		p.next()
+		if p.tok != "(" {
+			return column{tok}, nil
+		}
+		return p.call(strings.ToLower(tok), pos)
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok, pos)
+}
+
+func (p *parser) call(fn string, pos int) (node, error) {
+	arity, ok := functions[fn]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at %d", fn, pos)
+	}
+	p.next() // (
+	var args []node
+	for p.tok != ")" {
+		a, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if p.tok == "," {
+			p.next()
+		} else if p.tok != ")" {
+			return nil, fmt.Errorf("expected , or ) at %d", p.pos)
+		}
+	}
+	p.next()
+	if len(args) < arity[0] || arity[1] != -1 && len(args) > arity[1] {
+		return nil, fmt.Errorf("%s takes %s arguments, got %d", fn, arityText(arity), len(args))
+	}
+	return call{fn: fn, args: args}, nil
+}
+
+func arityText(a [2]int) string {
+	switch {
+	case a[1] == -1:
+		return fmt.Sprintf("at least %d", a[0])
+	case a[0] == a[1]:
+		return strconv.Itoa(a[0])
+	}
+	return fmt.Sprintf("%d or %d", a[0], a[1])
+}
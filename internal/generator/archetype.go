@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// ArchetypeOf returns t's archetype: Config.Archetypes if it names t, or
+// else schema.Classify's guess against the tables of s (nil: t alone).
+func (c Config) ArchetypeOf(t *schema.Table, s *schema.Schema) schema.Archetype {
+	if a, ok := c.Archetypes[t.Name]; ok {
+		return a
+	}
+	tables := []*schema.Table{t}
+	if s != nil {
+		tables = s.Tables
+	}
+	return schema.Classify(t, tables)
+}
+
+// formatArchetype tells the model what kind of table it is filling, for
+// every archetype but plain entities.
+func formatArchetype(t *schema.Table, a schema.Archetype) string {
+	switch a {
+	case schema.ArchetypeLookup:
+		return `
+TABLE TYPE: lookup table (a fixed list other tables refer to)
+- Every row is a distinct, canonical entry an application would ship with
+- Use short human-readable names, no numbering like "Category 1", no test values
+- Codes, if any, are short and upper-case or lower-snake-case, consistently`
+	case schema.ArchetypeJoin:
+		var fks []string
+		for _, c := range t.FKColumns() {
+			fks = append(fks, promptIdent(c.Name))
+		}
+		return fmt.Sprintf(`
+TABLE TYPE: join table linking rows of other tables
+- Every row links a different combination of %s; never repeat a combination
+- Spread the links: some rows link to many others, most to a few`, strings.Join(fks, ", "))
+	case schema.ArchetypeEvent:
+		hint := `
+TABLE TYPE: event log (append-only record of things that happened)
+- Most events are routine; a few are warnings, failures or unusual actions
+- Several events usually belong to the same user or object`
+		if col := schema.EventTimeColumn(t); col != "" {
+			hint += fmt.Sprintf("\n- %s: spread over the period, in increasing order down the array", promptIdent(col))
+		}
+		return hint
+	}
+	return ""
+}
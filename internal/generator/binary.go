@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Binary fill modes.
+const (
+	BinaryBytes   = "bytes"    // small placeholder payload (default)
+	BinaryPath    = "path"     // a relative file path such as files/users/avatar-1.png
+	BinaryDataURL = "data-url" // data:<mime>;base64,<payload>
+)
+
+// BinaryFill says how binary (bytea, BLOB) columns are filled. Models
+// produce invalid base64 or enormous strings for them, so they are left
+// out of the prompt and filled locally.
+type BinaryFill struct {
+	Mode string // BinaryBytes, BinaryPath or BinaryDataURL; empty means BinaryBytes
+	Size int    // payload bytes for columns that are not images or PDFs; 0 means 16
+}
+
+// onePixelPNG is a valid 1x1 transparent PNG.
+var onePixelPNG, _ = base64.StdEncoding.DecodeString(
+	"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=")
+
+var minimalPDF = []byte("%PDF-1.4\n1 0 obj<</Type/Catalog>>endobj\ntrailer<</Root 1 0 R>>\n%%EOF\n")
+
+var fixedSizeRe = regexp.MustCompile(`^(?:var)?binary\((\d+)\)`)
+
+// binaryColumns returns the names of t's binary columns.
+func binaryColumns(t *schema.Table) []string {
+	var names []string
+	for _, col := range t.NonAutoColumns() {
+		if col.Type == "binary" {
+			names = append(names, col.Name)
+		}
+	}
+	return names
+}
+
+// formatBinary lists the binary columns so the model leaves them out.
+func formatBinary(t *schema.Table) string {
+	names := binaryColumns(t)
+	if len(names) == 0 {
+		return ""
+	}
+	return "\nBINARY COLUMNS (leave these out, they are filled automatically): " +
+		strings.Join(promptIdents(names), ", ") + "\n"
+}
+
+// applyBinary fills every binary column of t. Payloads are derived from the
+// table, column and row number, so replays produce the same bytes.
+func applyBinary(t *schema.Table, rows []map[string]interface{}, fill BinaryFill) {
+	for _, col := range t.NonAutoColumns() {
+		if col.Type != "binary" {
+			continue
+		}
+		size, fixed := fill.Size, false
+		if size <= 0 {
+			size = 16
+		}
+		if m := fixedSizeRe.FindStringSubmatch(col.SQLType); m != nil {
+			size, _ = strconv.Atoi(m[1]) // binary(16) holds exactly 16 bytes
+			fixed = true
+		}
+		for i, row := range rows {
+			row[col.Name] = binaryValue(t.Name, col.Name, i+1, size, fixed, fill.Mode)
+		}
+	}
+}
+
+func binaryValue(table, column string, row, size int, fixed bool, mode string) interface{} {
+	data, mime, ext := binaryPayload(table, column, row, size, fixed)
+	switch mode {
+	case BinaryPath:
+		return fmt.Sprintf("files/%s/%s-%d%s", table, column, row, ext)
+	case BinaryDataURL:
+		return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	return data
+}
+
+// binaryPayload picks a tiny valid file for image- and PDF-like column
+// names and size pseudo-random bytes otherwise, or always when the column
+// has a fixed size.
+func binaryPayload(table, column string, row, size int, fixed bool) (data []byte, mime, ext string) {
+	n := strings.ToLower(column)
+	if !fixed {
+		for _, w := range []string{"image", "photo", "avatar", "picture", "thumbnail", "logo", "icon"} {
+			if strings.Contains(n, w) {
+				return onePixelPNG, "image/png", ".png"
+			}
+		}
+		for _, w := range []string{"pdf", "document", "invoice", "receipt", "contract"} {
+			if strings.Contains(n, w) {
+				return minimalPDF, "application/pdf", ".pdf"
+			}
+		}
+	}
+	seed := []byte(fmt.Sprintf("%s.%s.%d", table, column, row))
+	for len(data) < size {
+		sum := sha256.Sum256(append(seed, byte(len(data))))
+		data = append(data, sum[:]...)
+	}
+	return data[:size], "application/octet-stream", ".bin"
+}
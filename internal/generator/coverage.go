@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/repair"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Boundary kinds, one per edge case Config.Coverage puts in some row.
+const (
+	BoundaryNull    = "null"       // NULL in a nullable column
+	BoundaryMaxLen  = "max-length" // a value filling a varchar(n) exactly
+	BoundaryMin     = "min"        // zero in a numeric column
+	BoundaryMax     = "max"        // the largest value the numeric type holds
+	BoundaryValue   = "value"      // one entry of a CHECK list, value list or boolean
+	BoundaryUnicode = "unicode"    // accents, CJK and an emoji in a text column
+)
+
+// BoundaryKinds lists the kinds in the order the coverage matrix shows
+// them.
+var BoundaryKinds = []string{BoundaryNull, BoundaryMaxLen, BoundaryMin, BoundaryMax, BoundaryValue, BoundaryUnicode}
+
+// unicodeSample mixes scripts and widths that trip up encodings, fonts
+// and length checks.
+const unicodeSample = "Zoë Ångström-O'Brien 東京 Ωμέγα 🚀"
+
+// lorem fills max-length values with readable text.
+const lorem = "Lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore "
+
+// Boundary is one edge case for a column.
+type Boundary struct {
+	Column string
+	Kind   string
+	Value  interface{}
+}
+
+// Boundaries returns the edge cases of t's columns that Coverage puts in
+// rows. Columns filled locally afterwards (foreign keys aside from NULL,
+// derived, direct-value, token and image columns) and email columns, which
+// must keep their format, only get the cases they can take.
+func (c Config) Boundaries(t *schema.Table) []Boundary {
+	var out []Boundary
+	values := c.values()
+	local := append(repair.TokenColumns(t, c.Tokens), repair.ImageColumns(t, c.Images)...)
+	for _, col := range t.NonAutoColumns() {
+		if lookupDerived(c.Derived, t.Name, col.Name) != nil || slices.Contains(local, col.Name) {
+			continue
+		}
+		if !col.NotNull && !col.PrimaryKey {
+			out = append(out, Boundary{Column: col.Name, Kind: BoundaryNull})
+		}
+		if col.ForeignKey != nil || col.PrimaryKey {
+			continue
+		}
+		list := coverList(t, col, values)
+		for _, v := range list {
+			out = append(out, Boundary{Column: col.Name, Kind: BoundaryValue, Value: v})
+		}
+		if len(list) > 0 {
+			continue
+		}
+		if v, ok := lookupValues(values, t.Name, col.Name); ok && v.Direct {
+			continue
+		}
+		switch col.Type {
+		case "integer", "decimal":
+			out = append(out, Boundary{Column: col.Name, Kind: BoundaryMin, Value: 0},
+				Boundary{Column: col.Name, Kind: BoundaryMax, Value: maxNumber(col)})
+		case "text":
+			if col.CheckEmail || strings.Contains(strings.ToLower(col.Name), "email") {
+				continue
+			}
+			n := col.MaxLength()
+			if n > 0 {
+				out = append(out, Boundary{Column: col.Name, Kind: BoundaryMaxLen, Value: fill(lorem, n)})
+			}
+			out = append(out, Boundary{Column: col.Name, Kind: BoundaryUnicode, Value: cutRunes(unicodeSample, n)})
+		}
+	}
+	return out
+}
+
+// CoverageRows returns the rows t needs for every boundary to get a row:
+// the most any one column has.
+func (c Config) CoverageRows(t *schema.Table) int {
+	per := make(map[string]int)
+	n := 1
+	for _, b := range c.Boundaries(t) {
+		per[b.Column]++
+		n = max(n, per[b.Column])
+	}
+	return n
+}
+
+// applyBoundaries writes each column's boundaries into the first rows, one
+// per row, and returns those that found a row.
+func applyBoundaries(rows []map[string]interface{}, cases []Boundary) []Boundary {
+	var placed []Boundary
+	next := make(map[string]int)
+	for _, b := range cases {
+		i := next[b.Column]
+		if i >= len(rows) {
+			continue
+		}
+		next[b.Column]++
+		rows[i][b.Column] = b.Value
+		placed = append(placed, b)
+	}
+	return placed
+}
+
+// maxNumber returns the largest value col's type can hold: by integer
+// width, or by precision and scale for numeric(p,s), or a large
+// round-ish figure for unsized decimals.
+func maxNumber(col schema.Column) interface{} {
+	sql := col.SQLType
+	switch {
+	case strings.HasPrefix(sql, "smallint") || strings.HasPrefix(sql, "int2"):
+		return math.MaxInt16
+	case strings.HasPrefix(sql, "bigint") || strings.HasPrefix(sql, "int8") || strings.HasPrefix(sql, "bigserial"):
+		return math.MaxInt64
+	case col.Type == "integer":
+		return math.MaxInt32
+	}
+	if p, s, ok := precision(sql); ok {
+		scale := math.Pow10(s)
+		return math.Round((math.Pow10(p-s)-1/scale)*scale) / scale
+	}
+	return 999999999.99
+}
+
+// precision parses numeric(p,s) or decimal(p), s defaulting to 0.
+func precision(sql string) (p, s int, ok bool) {
+	open := strings.IndexByte(sql, '(')
+	end := strings.IndexByte(sql, ')')
+	if open < 0 || end < open {
+		return 0, 0, false
+	}
+	parts := strings.Split(sql[open+1:end], ",")
+	for i, part := range parts {
+		n := 0
+		for _, r := range strings.TrimSpace(part) {
+			if r < '0' || r > '9' {
+				return 0, 0, false
+			}
+			n = n*10 + int(r-'0')
+		}
+		if i == 0 {
+			p = n
+		} else {
+			s = n
+		}
+	}
+	return p, s, p > 0 && s <= p
+}
+
+// fill repeats text to exactly n runes.
+func fill(text string, n int) string {
+	r := []rune(strings.Repeat(text, n/len([]rune(text))+1))[:n]
+	if r[n-1] == ' ' {
+		r[n-1] = '.'
+	}
+	return string(r)
+}
+
+// cutRunes returns the first n runes of s; n of zero or less keeps all.
+func cutRunes(s string, n int) string {
+	if r := []rune(s); n > 0 && len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
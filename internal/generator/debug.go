@@ -0,0 +1,30 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// debugSeq numbers captured calls so the files sort in call order even
+// when two calls start within the same millisecond.
+var debugSeq atomic.Int64
+
+// captureDebug writes one model call to dir as a pair of files,
+// <time>-<seq>.prompt.txt and <time>-<seq>.response.txt. A failed call
+// writes the error in place of the response.
+func captureDebug(dir string, started time.Time, prompt, response string, callErr error) error {
+	base := fmt.Sprintf("%s-%04d", started.Format("20060102-150405.000"), debugSeq.Add(1))
+	if err := os.WriteFile(filepath.Join(dir, base+".prompt.txt"), []byte(prompt), 0o644); err != nil {
+		return fmt.Errorf("debug capture: %w", err)
+	}
+	if callErr != nil {
+		response = "error: " + callErr.Error() + "\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, base+".response.txt"), []byte(response), 0o644); err != nil {
+		return fmt.Errorf("debug capture: %w", err)
+	}
+	return nil
+}
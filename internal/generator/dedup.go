@@ -0,0 +1,288 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/repair"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindNearDuplicate marks a free-text value replaced because it read
+// almost the same as one in an earlier row.
+const KindNearDuplicate repair.Kind = "near-duplicate"
+
+// DefaultDedupThreshold is the cosine similarity from which two texts
+// count as near-duplicates when Dedup.Threshold is 0.
+const DefaultDedupThreshold = 0.92
+
+// Dedup turns on the near-duplicate pass for free-text columns (reviews,
+// comments, descriptions, bios): their values are embedded with Model, and
+// rows whose text is within Threshold of an earlier row's are sent back to
+// the model once for different text. Values still too close after that
+// are kept.
+type Dedup struct {
+	Model     string  // Ollama embedding model, e.g. nomic-embed-text
+	Threshold float64 // cosine similarity, 0 to 1; 0 means DefaultDedupThreshold
+}
+
+// Embedder turns texts into embedding vectors. The near-duplicate pass
+// uses the Generator's LLMClient when it also implements Embedder, as
+// OllamaClient does.
+type Embedder interface {
+	Embed(texts []string) ([][]float64, error)
+}
+
+// freeTextHints are the column name parts of prose columns.
+var freeTextHints = []string{
+	"review", "comment", "description", "bio", "body", "content", "message",
+	"note", "summary", "feedback", "about", "caption", "excerpt", "remark", "details",
+}
+
+// isFreeText reports whether col holds prose a model tends to repeat: a
+// text column named like a review, comment or description, that is not a
+// key, UNIQUE or limited to CHECK IN values.
+func isFreeText(col schema.Column) bool {
+	if col.Type != "text" || col.ForeignKey != nil || col.PrimaryKey || col.Unique || len(col.CheckIn) > 0 {
+		return false
+	}
+	name := strings.ToLower(col.Name)
+	for _, h := range freeTextHints {
+		if strings.Contains(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// nearDuplicates returns, per row index, the free-text columns of t whose
+// value is within threshold of the same column in an earlier row.
+func nearDuplicates(e Embedder, t *schema.Table, rows []map[string]interface{}, cols []string, threshold float64) (map[int][]string, error) {
+	dups := make(map[int][]string)
+	for _, col := range cols {
+		var idx []int
+		var texts []string
+		for i, row := range rows {
+			if s, ok := row[col].(string); ok && strings.TrimSpace(s) != "" {
+				idx = append(idx, i)
+				texts = append(texts, s)
+			}
+		}
+		if len(texts) < 2 {
+			continue
+		}
+		vecs, err := e.Embed(texts)
+		if err != nil {
+			return nil, fmt.Errorf("embed %s.%s: %w", t.Name, col, err)
+		}
+		if len(vecs) != len(texts) {
+			return nil, fmt.Errorf("embed %s.%s: got %d vectors for %d texts", t.Name, col, len(vecs), len(texts))
+		}
+		var kept [][]float64
+		for i, v := range vecs {
+			if closeToAny(v, kept, threshold) {
+				dups[idx[i]] = append(dups[idx[i]], col)
+				continue
+			}
+			kept = append(kept, v)
+		}
+	}
+	return dups, nil
+}
+
+func closeToAny(v []float64, kept [][]float64, threshold float64) bool {
+	for _, k := range kept {
+		if cosine(v, k) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// dedupText replaces the near-duplicate free-text values in rows with text
+// from one more prompt. It returns the repairs made, the prompt sent ("" if
+// none) and its usage; without Config.Dedup, an Embedder or free-text
+// columns it does nothing.
+func (g *Generator) dedupText(
+	table *schema.Table,
+	rows []map[string]interface{},
+	fullSchema *schema.Schema,
+	style string,
+	existingIDs map[string][]interface{},
+	pc promptContext,
+) ([]repair.Action, string, Usage, error) {
+	e, ok := g.client.(Embedder)
+	if g.cfg.Dedup == nil || !ok {
+		return nil, "", Usage{}, nil
+	}
+	var cols []string
+	for _, col := range table.NonAutoColumns() {
+		if _, pinned := lookupValues(pc.values, table.Name, col.Name); !pinned && isFreeText(col) &&
+			lookupDerived(pc.derived, table.Name, col.Name) == nil {
+			cols = append(cols, col.Name)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, "", Usage{}, nil
+	}
+	threshold := g.cfg.Dedup.Threshold
+	if threshold <= 0 {
+		threshold = DefaultDedupThreshold
+	}
+	dups, err := nearDuplicates(e, table, rows, cols, threshold)
+	if err != nil || len(dups) == 0 {
+		return nil, "", Usage{}, err
+	}
+	prompt := buildPrompt(table, len(dups), fullSchema, style, existingIDs, pc) + dedupHint(rows, dups, cols)
+	raw, usage, err := g.client.Generate(prompt)
+	if err != nil {
+		return nil, prompt, usage, fmt.Errorf("generate for %s: %w", table.Name, err)
+	}
+	fresh, err := ParseJSONRows(raw, nonAutoColNames(table))
+	if err != nil {
+		return nil, prompt, usage, nil // keep the rows as they are
+	}
+	var actions []repair.Action
+	for i := range rows {
+		if len(fresh) == 0 {
+			break
+		}
+		if len(dups[i]) == 0 {
+			continue
+		}
+		for _, col := range dups[i] {
+			if s, ok := fresh[0][col].(string); ok && strings.TrimSpace(s) != "" {
+				actions = append(actions, repair.Action{Row: i + 1, Column: col, Kind: KindNearDuplicate, From: rows[i][col], To: s})
+				rows[i][col] = s
+			}
+		}
+		fresh = fresh[1:]
+	}
+	return actions, prompt, usage, nil
+}
+
+// dedupHint lists the texts the replacement rows must not resemble.
+func dedupHint(rows []map[string]interface{}, dups map[int][]string, cols []string) string {
+	var sb strings.Builder
+	sb.WriteString("\n\nThese texts are ALREADY USED — write clearly different ones, not rewordings:\n")
+	for _, col := range cols {
+		var vals []string
+		for _, row := range rows {
+			if s, ok := row[col].(string); ok && s != "" {
+				vals = append(vals, strconv.Quote(truncateRunes(s, maxPromptLiteral)))
+			}
+			if len(vals) == maxUsedValuesShown {
+				break
+			}
+		}
+		if len(vals) > 0 {
+			sb.WriteString(fmt.Sprintf("  %s: [%s]\n", promptIdent(col), strings.Join(vals, ", ")))
+		}
+	}
+	sb.WriteString("\nReturn ONLY the JSON array.")
+	return sb.String()
+}
+
+// EmbedRequest is the JSON body for the Ollama embed API.
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbedResponse is the JSON response of the Ollama embed API.
+type EmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed returns an embedding of each text from Config.Dedup.Model. Calls
+// are recorded, replayed and cached like Generate's, so a replay needs no
+// embedding model either.
+func (c *OllamaClient) Embed(texts []string) ([][]float64, error) {
+	if c.cfg.Dedup == nil || c.cfg.Dedup.Model == "" {
+		return nil, fmt.Errorf("no embedding model set")
+	}
+	model := c.cfg.Dedup.Model
+	key, _ := json.Marshal(texts)
+	prompt := "embed " + string(key)
+	var raw string
+	var err error
+	switch {
+	case c.cfg.ReplayDir != "":
+		raw, _, err = loadRecording(c.cfg.ReplayDir, model, prompt)
+	default:
+		ok := false
+		if c.cfg.Cache != nil {
+			var rec recording
+			rec, ok, err = loadCached(c.cfg.Cache, model, prompt)
+			raw = rec.Response
+		}
+		if err != nil {
+			break
+		}
+		if !ok {
+			if raw, err = c.postEmbed(model, texts); err != nil {
+				break
+			}
+		}
+		if c.cfg.RecordDir != "" {
+			err = saveRecording(c.cfg.RecordDir, model, prompt, raw, Usage{})
+		}
+		if err == nil && c.cfg.Cache != nil && !ok {
+			err = saveCached(c.cfg.Cache, model, prompt, raw, Usage{})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var vecs [][]float64
+	if err := json.Unmarshal([]byte(raw), &vecs); err != nil {
+		return nil, fmt.Errorf("embeddings: %w", err)
+	}
+	return vecs, nil
+}
+
+// postEmbed makes one /api/embed request and returns the vectors as JSON.
+func (c *OllamaClient) postEmbed(model string, texts []string) (string, error) {
+	body, _ := json.Marshal(EmbedRequest{Model: model, Input: texts})
+	url := strings.TrimSuffix(c.cfg.OllamaURL, "/") + "/api/embed"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ollama request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama returned status %d for embedding model %s", resp.StatusCode, model)
+	}
+	var embResp EmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(embResp.Embeddings)
+	return string(out), err
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Domain is a built-in preset that steers generation toward one kind of
+// business: extra prompt hints, typical value ranges and naming, and value
+// lists for columns that commonly appear in that domain.
+type Domain struct {
+	Name        string
+	Description string
+	Hints       []string
+	// Values apply in prompt mode to columns with these names unless the
+	// project config sets the column itself.
+	Values map[string]ColumnValues
+}
+
+func list(values ...string) ColumnValues { return ColumnValues{Values: values} }
+
+// domains holds the presets selectable with --domain.
+var domains = map[string]*Domain{
+	"ecommerce": {
+		Name:        "ecommerce",
+		Description: "online store: catalog, carts, orders, shipping",
+		Hints: []string{
+			"Product names are concrete retail items with brand-like names (\"Nordlys Wool Throw\")",
+			"SKUs look like ABC-12345; prices mostly 5 to 300 with .99 or .00 endings",
+			"Order totals equal plausible sums of items; quantities usually 1 to 5",
+			"Addresses are full street addresses; order dates follow account creation dates",
+		},
+		Values: map[string]ColumnValues{
+			"currency":       list("USD", "EUR", "GBP"),
+			"payment_method": list("card", "paypal", "apple_pay", "gift_card"),
+			"carrier":        list("UPS", "FedEx", "DHL", "USPS"),
+		},
+	},
+	"healthcare": {
+		Name:        "healthcare",
+		Description: "clinics and hospitals: patients, providers, appointments",
+		Hints: []string{
+			"Appointments fall on weekdays between 08:00 and 18:00 in 15 or 30 minute slots",
+			"Diagnosis codes look like ICD-10 (E11.9, J45.909); procedure codes like CPT (99213)",
+			"Medical record numbers look like MRN-0001234; ages span newborns to 95",
+			"Provider names carry titles (Dr., NP, PA) and specialties such as Cardiology",
+		},
+		Values: map[string]ColumnValues{
+			"blood_type": list("A+", "A-", "B+", "B-", "AB+", "AB-", "O+", "O-"),
+			"specialty":  list("Cardiology", "Dermatology", "Family Medicine", "Pediatrics", "Orthopedics"),
+		},
+	},
+	"fintech": {
+		Name:        "fintech",
+		Description: "payments and banking: accounts, transactions, ledgers",
+		Hints: []string{
+			"Amounts use two decimals; most transactions are 1 to 2000, a few up to 50000",
+			"Account numbers look like IBANs (DE89 3704 0044 0532 0130 00) or masked cards (**** 4242)",
+			"Transaction references look like TXN-8F3K2Q9Z; timestamps include seconds",
+			"Balances stay consistent with the sign of debits and credits",
+		},
+		Values: map[string]ColumnValues{
+			"currency":         list("USD", "EUR", "GBP", "JPY", "CHF"),
+			"transaction_type": list("debit", "credit", "transfer", "refund", "fee"),
+			"account_type":     list("checking", "savings", "credit", "brokerage"),
+		},
+	},
+	"saas": {
+		Name:        "saas",
+		Description: "B2B software: organizations, seats, subscriptions, usage",
+		Hints: []string{
+			"Companies have realistic B2B names (\"Brightline Analytics\", \"Corvid Labs\") and domains to match",
+			"User emails use the company domain; job titles are typical of software buyers",
+			"Plans are monthly or annual; seat counts 1 to 500; MRR consistent with plan and seats",
+			"Trial periods are 14 or 30 days; churn dates follow start dates",
+		},
+		Values: map[string]ColumnValues{
+			"plan":          list("free", "starter", "pro", "enterprise"),
+			"billing_cycle": list("monthly", "annual"),
+			"role":          list("owner", "admin", "member", "viewer"),
+		},
+	},
+	"logistics": {
+		Name:        "logistics",
+		Description: "freight and delivery: shipments, warehouses, routes, fleet",
+		Hints: []string{
+			"Tracking numbers look like 1Z999AA10123456784; weights in kg with one decimal",
+			"Warehouses and depots are named after real-sounding cities and regions",
+			"Delivery dates follow pickup dates by 1 to 10 days; ETAs are in the future for open shipments",
+			"Vehicle plates and driver IDs follow consistent per-country formats",
+		},
+		Values: map[string]ColumnValues{
+			"shipment_status": list("created", "picked_up", "in_transit", "out_for_delivery", "delivered", "exception"),
+			"vehicle_type":    list("van", "box_truck", "semi", "cargo_bike"),
+			"incoterm":        list("EXW", "FOB", "CIF", "DAP", "DDP"),
+		},
+	},
+}
+
+// LookupDomain returns the preset with the given name.
+func LookupDomain(name string) (*Domain, error) {
+	if d, ok := domains[strings.ToLower(name)]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("unknown domain %q (available: %s)", name, strings.Join(DomainNames(), ", "))
+}
+
+// DomainNames lists the built-in presets in alphabetical order.
+func DomainNames() []string {
+	names := make([]string, 0, len(domains))
+	for n := range domains {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatDomainHints renders the preset's hints for the prompt.
+func formatDomainHints(d *Domain) string {
+	if d == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\nDOMAIN: %s (%s)\n", d.Name, d.Description))
+	for _, h := range d.Hints {
+		sb.WriteString("- " + h + "\n")
+	}
+	return sb.String()
+}
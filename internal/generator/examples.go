@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// maxExampleRows is how many few-shot rows go into a prompt.
+const maxExampleRows = 3
+
+// minExampleColumns is how many of a table's columns an example set must
+// fill for its rows to be shown; fewer teach the model little.
+const minExampleColumns = 2
+
+// exampleSet holds hand-written rows for one type of table. Small models
+// copy the shape and tone of rows they are shown far better than they
+// follow descriptions, so a prompt for a matching table includes a few
+// of them, cut down to the table's columns.
+type exampleSet struct {
+	kind   string
+	tables []string // table names, singular, that are this type
+	hints  []string // columns that mark the type when the name does not
+	rows   []map[string]interface{}
+}
+
+// exampleSets is the few-shot library, tried in order.
+var exampleSets = []exampleSet{
+	{
+		kind:   "users",
+		tables: []string{"user", "account", "customer", "member", "person", "people", "profile", "employee", "author"},
+		hints:  []string{"first_name", "last_name", "email", "password_hash", "username"},
+		rows: []map[string]interface{}{
+			{"first_name": "Priya", "last_name": "Raman", "full_name": "Priya Raman", "name": "Priya Raman", "email": "priya.raman@gmail.com",
+				"username": "priya_raman", "phone": "+1-415-555-0182", "country": "US", "is_active": true, "role": "admin",
+				"date_of_birth": "1991-04-17", "created_at": "2024-02-11 09:24:51", "last_login_at": "2025-06-02 18:03:10"},
+			{"first_name": "Tomás", "last_name": "Ortega", "full_name": "Tomás Ortega", "name": "Tomás Ortega", "email": "t.ortega@outlook.es",
+				"username": "tomas.ortega", "phone": "+34 612 48 93 07", "country": "ES", "is_active": true, "role": "member",
+				"date_of_birth": "1985-11-02", "created_at": "2023-09-30 14:10:05", "last_login_at": "2025-05-28 07:45:33"},
+			{"first_name": "Hannah", "last_name": "O'Connell", "full_name": "Hannah O'Connell", "name": "Hannah O'Connell", "email": "hannah.oc@yahoo.co.uk",
+				"username": "hoconnell", "phone": "+44 7700 900123", "country": "GB", "is_active": false, "role": "member",
+				"date_of_birth": "1999-07-23", "created_at": "2024-12-01 20:41:17", "last_login_at": "2025-01-14 12:00:42"},
+		},
+	},
+	{
+		kind:   "products",
+		tables: []string{"product", "item", "article", "listing", "sku", "variant"},
+		hints:  []string{"sku", "price", "stock", "inventory", "unit_price"},
+		rows: []map[string]interface{}{
+			{"name": "Nordlys Wool Throw", "title": "Nordlys Wool Throw", "sku": "NWT-10482", "description": "Soft merino blanket, 130 x 170 cm, in forest green.",
+				"price": 89.00, "unit_price": 89.00, "currency": "EUR", "stock": 42, "inventory": 42, "category": "Home", "is_active": true,
+				"weight_grams": 950, "created_at": "2024-03-08 10:15:00"},
+			{"name": "TrailFlex Running Shoe", "title": "TrailFlex Running Shoe", "sku": "TFR-22017", "description": "Lightweight trail shoe with a grippy outsole.",
+				"price": 129.99, "unit_price": 129.99, "currency": "USD", "stock": 7, "inventory": 7, "category": "Sports", "is_active": true,
+				"weight_grams": 610, "created_at": "2024-08-19 16:42:11"},
+			{"name": "Brewmate Pour-Over Kettle", "title": "Brewmate Pour-Over Kettle", "sku": "BPK-00931", "description": "1 L gooseneck kettle for slow, even pours.",
+				"price": 34.50, "unit_price": 34.50, "currency": "GBP", "stock": 0, "inventory": 0, "category": "Kitchen", "is_active": false,
+				"weight_grams": 720, "created_at": "2023-11-27 08:05:39"},
+		},
+	},
+	{
+		kind:   "orders",
+		tables: []string{"order", "purchase", "invoice", "sale", "transaction", "payment", "booking"},
+		hints:  []string{"order_number", "total", "subtotal", "paid_at", "shipped_at"},
+		rows: []map[string]interface{}{
+			{"order_number": "ORD-2025-004817", "status": "paid", "subtotal": 118.00, "tax": 11.80, "shipping": 4.99, "total": 134.79,
+				"amount": 134.79, "currency": "USD", "payment_method": "card", "placed_at": "2025-03-14 11:02:45", "created_at": "2025-03-14 11:02:45",
+				"paid_at": "2025-03-14 11:03:10", "shipped_at": "2025-03-15 09:30:00", "notes": "Leave at the back door"},
+			{"order_number": "ORD-2025-004818", "status": "pending", "subtotal": 24.50, "tax": 2.45, "shipping": 0.00, "total": 26.95,
+				"amount": 26.95, "currency": "USD", "payment_method": "paypal", "placed_at": "2025-03-14 13:47:09", "created_at": "2025-03-14 13:47:09",
+				"paid_at": nil, "shipped_at": nil, "notes": nil},
+			{"order_number": "ORD-2025-004823", "status": "shipped", "subtotal": 312.40, "tax": 31.24, "shipping": 12.00, "total": 355.64,
+				"amount": 355.64, "currency": "EUR", "payment_method": "card", "placed_at": "2025-03-15 08:21:55", "created_at": "2025-03-15 08:21:55",
+				"paid_at": "2025-03-15 08:22:31", "shipped_at": "2025-03-17 14:05:12", "notes": "Gift wrap, please"},
+		},
+	},
+	{
+		kind:   "addresses",
+		tables: []string{"address", "location", "shipping_address", "billing_address"},
+		hints:  []string{"street", "line1", "city", "postal_code", "zip"},
+		rows: []map[string]interface{}{
+			{"line1": "742 Evergreen Terrace", "street": "742 Evergreen Terrace", "line2": nil, "city": "Springfield", "state": "OR", "region": "OR",
+				"postal_code": "97477", "zip": "97477", "country": "US", "latitude": 44.0462, "longitude": -123.0220, "is_default": true},
+			{"line1": "Karl-Marx-Allee 91", "street": "Karl-Marx-Allee 91", "line2": "3. OG", "city": "Berlin", "state": "BE", "region": "BE",
+				"postal_code": "10243", "zip": "10243", "country": "DE", "latitude": 52.5178, "longitude": 13.4334, "is_default": false},
+			{"line1": "18 Rue des Martyrs", "street": "18 Rue des Martyrs", "line2": "Bâtiment B", "city": "Paris", "state": "IDF", "region": "IDF",
+				"postal_code": "75009", "zip": "75009", "country": "FR", "latitude": 48.8790, "longitude": 2.3397, "is_default": true},
+		},
+	},
+	{
+		kind:   "logs",
+		tables: []string{"log", "event", "audit", "audit_log", "activity", "history", "request"},
+		hints:  []string{"level", "event_type", "action", "ip_address", "user_agent"},
+		rows: []map[string]interface{}{
+			{"level": "info", "event_type": "user.login", "event": "user.login", "action": "login", "message": "Login succeeded via password",
+				"ip_address": "203.0.113.24", "user_agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 Safari/605.1.15",
+				"status_code": 200, "duration_ms": 84, "created_at": "2025-04-02 08:14:03", "occurred_at": "2025-04-02 08:14:03"},
+			{"level": "warn", "event_type": "payment.retry", "event": "payment.retry", "action": "retry", "message": "Card declined, retrying in 30s",
+				"ip_address": "198.51.100.7", "user_agent": "okhttp/4.12.0", "status_code": 402, "duration_ms": 1290,
+				"created_at": "2025-04-02 08:15:47", "occurred_at": "2025-04-02 08:15:47"},
+			{"level": "error", "event_type": "export.failed", "event": "export.failed", "action": "export", "message": "Timed out writing report.csv after 30000 ms",
+				"ip_address": "192.0.2.145", "user_agent": "curl/8.5.0", "status_code": 504, "duration_ms": 30002,
+				"created_at": "2025-04-02 09:01:12", "occurred_at": "2025-04-02 09:01:12"},
+		},
+	},
+}
+
+// exampleSetFor returns the example set for t: the first whose table
+// names include t's name, singular, or else the first that fills at least
+// two of t's marker columns. It returns nil when none fits.
+func exampleSetFor(t *schema.Table) *exampleSet {
+	name := singular(strings.ToLower(t.Name))
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	for i := range exampleSets {
+		for _, n := range exampleSets[i].tables {
+			if name == n || strings.HasSuffix(name, "_"+n) {
+				return &exampleSets[i]
+			}
+		}
+	}
+	for i := range exampleSets {
+		hits := 0
+		for _, h := range exampleSets[i].hints {
+			if t.Column(h) != nil {
+				hits++
+			}
+		}
+		if hits >= 2 {
+			return &exampleSets[i]
+		}
+	}
+	return nil
+}
+
+// singular strips a plural ending from a table name: users, addresses,
+// categories, people stay recognisable.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ies"):
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// exampleColumns returns the columns of t that example rows may show:
+// those the model fills, other than foreign keys (their values come from
+// the FK list) and binary columns. A value is left out of a row when it
+// does not fit the column's type or CHECK list.
+func exampleColumns(t *schema.Table) []schema.Column {
+	var cols []schema.Column
+	for _, c := range t.NonAutoColumns() {
+		if c.ForeignKey != nil || c.Type == "binary" {
+			continue
+		}
+		cols = append(cols, c)
+	}
+	return cols
+}
+
+// fits reports whether example value v suits column c.
+func fits(c schema.Column, v interface{}) bool {
+	if v == nil {
+		return !c.NotNull
+	}
+	if len(c.CheckIn) > 0 {
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		for _, allowed := range c.CheckIn {
+			if s == allowed {
+				return true
+			}
+		}
+		return false
+	}
+	switch v.(type) {
+	case bool:
+		return c.Type == "boolean"
+	case int:
+		return c.Type == "integer" || c.Type == "decimal"
+	case float64:
+		return c.Type == "decimal"
+	case string:
+		return c.Type == "text" || c.Type == "timestamp"
+	}
+	return false
+}
+
+// formatExamples renders up to maxExampleRows rows of t's example set as
+// a JSON array, keys in column order. It returns "" when t has no example
+// set or the rows would show fewer than minExampleColumns columns.
+func formatExamples(t *schema.Table) string {
+	set := exampleSetFor(t)
+	if set == nil {
+		return ""
+	}
+	cols := exampleColumns(t)
+	shown := make(map[string]bool)
+	var lines []string
+	for _, row := range set.rows[:min(len(set.rows), maxExampleRows)] {
+		var fields []string
+		for _, c := range cols {
+			v, ok := row[c.Name]
+			if !ok || !fits(c, v) {
+				continue
+			}
+			key, _ := json.Marshal(c.Name)
+			val, _ := json.Marshal(v)
+			fields = append(fields, string(key)+": "+string(val))
+			shown[c.Name] = true
+		}
+		lines = append(lines, "  {"+strings.Join(fields, ", ")+"}")
+	}
+	if len(shown) < minExampleColumns {
+		return ""
+	}
+	return "[\n" + strings.Join(lines, ",\n") + "\n]"
+}
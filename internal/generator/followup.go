@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// maxUsedValuesShown caps how many already-used values per column are echoed
+// back into a follow-up prompt, so large tables don't blow up the context.
+const maxUsedValuesShown = 50
+
+// followUpPrompt builds the prompt asking for the rows still missing after a
+// short response. When avoidUnique is set, values already generated for
+// UNIQUE columns are listed so the model does not repeat them.
+func followUpPrompt(
+	table *schema.Table,
+	remaining int,
+	fullSchema *schema.Schema,
+	style string,
+	existingIDs map[string][]interface{},
+	have []map[string]interface{},
+	avoidUnique bool,
+	pc promptContext,
+) string {
+	prompt := buildPrompt(table, remaining, fullSchema, style, existingIDs, pc)
+	if !avoidUnique {
+		return prompt
+	}
+	used := formatUsedUniques(table, have)
+	if used == "" {
+		return prompt
+	}
+	return prompt + "\n\nThese values are ALREADY USED — do NOT repeat them:\n" + used +
+		"\nReturn ONLY the JSON array."
+}
+
+// formatUsedUniques lists the values already generated for each UNIQUE column.
+// Example output:
+//
+//	email: ["sarah@example.com", "james@mail.com"]
+func formatUsedUniques(t *schema.Table, rows []map[string]interface{}) string {
+	var sb strings.Builder
+	for _, col := range t.NonAutoColumns() {
+		if !col.Unique && !col.PrimaryKey {
+			continue
+		}
+		var vals []string
+		for _, row := range rows {
+			v, ok := row[col.Name]
+			if !ok || v == nil {
+				continue
+			}
+			vals = append(vals, strconv.Quote(truncateRunes(fmt.Sprint(v), maxPromptLiteral)))
+			if len(vals) == maxUsedValuesShown {
+				break
+			}
+		}
+		if len(vals) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %s: [%s]\n", promptIdent(col.Name), strings.Join(vals, ", ")))
+	}
+	return sb.String()
+}
@@ -3,10 +3,20 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/satyammistari/db-seed-ai/internal/cache"
+	"github.com/satyammistari/db-seed-ai/internal/derive"
+	"github.com/satyammistari/db-seed-ai/internal/interp"
+	"github.com/satyammistari/db-seed-ai/internal/phone"
+	"github.com/satyammistari/db-seed-ai/internal/repair"
+	"github.com/satyammistari/db-seed-ai/internal/rules"
 	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/stats"
+	"github.com/satyammistari/db-seed-ai/internal/token"
 )
 
 // Generate calls Ollama to produce rows for a single table.
@@ -18,9 +28,20 @@ func (g *Generator) Generate(
 	style string,
 	existingIDs map[string][]interface{},
 ) (*GenerationResult, error) {
-	prompt := BuildPrompt(table, numRows, fullSchema, style, existingIDs)
+	if err := repair.CheckTokens(table, g.cfg.Tokens); err != nil {
+		return nil, err
+	}
+	start := g.clock.Now()
+	pc := g.promptContext()
+	pc.archetype = g.cfg.ArchetypeOf(table, fullSchema)
+	plan := g.cfg.languagesFor(table, numRows)
+	pc.languages = plan.chunk(0, numRows)
+	prompt := buildPrompt(table, numRows, fullSchema, style, existingIDs, pc)
+	prompts := []string{prompt}
+	progress := Progress{Table: table.Name, Kind: ProgressRequest, Chunk: 1, Requested: numRows}
+	g.report(progress)
 
-	raw, err := g.client.Generate(prompt)
+	raw, usage, err := g.client.Generate(prompt)
 	if err != nil {
 		return nil, fmt.Errorf("generate for %s: %w", table.Name, err)
 	}
@@ -30,14 +51,172 @@ func (g *Generator) Generate(
 	if err != nil {
 		return nil, fmt.Errorf("parse: %w", err)
 	}
+	progress.Kind, progress.Rows = ProgressRows, min(len(rows), numRows)
+	g.report(progress)
+
+	// Small models often stop early (37 of 100 rows). Ask again for the
+	// remainder instead of silently inserting a short table.
+	followUps, retries := 0, 0
+	for len(rows) < numRows && followUps < g.cfg.MaxFollowUps {
+		followUps++
+		progress.Kind, progress.Chunk, progress.Err = ProgressRequest, followUps+1, nil
+		g.report(progress)
+		pc.languages = plan.chunk(len(rows), numRows)
+		prompt := followUpPrompt(table, numRows-len(rows), fullSchema, style,
+			existingIDs, rows, g.cfg.AvoidUsedUniques, pc)
+		prompts = append(prompts, prompt)
+		raw, u, err := g.client.Generate(prompt)
+		if err != nil {
+			retries++
+			progress.Kind, progress.Err = ProgressRetry, fmt.Errorf("generate for %s: %w", table.Name, err)
+			g.report(progress)
+			continue
+		}
+		usage.Add(u)
+		more, err := ParseJSONRows(raw, colNames)
+		if err != nil {
+			retries++
+			progress.Kind, progress.Err = ProgressRetry, fmt.Errorf("parse: %w", err)
+			g.report(progress)
+			continue
+		}
+		rows = append(rows, more...)
+		progress.Kind, progress.Rows = ProgressRows, min(len(rows), numRows)
+		g.report(progress)
+	}
+	if len(rows) > numRows {
+		rows = rows[:numRows]
+	}
+	pc.languages = languages{}
+	applyLanguages(rows, plan, g.cfg.Random())
+	dedups, prompt, u, err := g.dedupText(table, rows, fullSchema, style, existingIDs, pc)
+	if err != nil {
+		return nil, err
+	}
+	if prompt != "" {
+		prompts = append(prompts, prompt)
+		usage.Add(u)
+	}
+	rows, repairs, coverage, err := g.finish(table, rows, existingIDs, pc, start)
+	if err != nil {
+		return nil, err
+	}
+	repairs.Actions = append(repairs.Actions, dedups...)
+	progress.Kind, progress.Rows, progress.Err = ProgressDone, len(rows), nil
+	g.report(progress)
 
 	return &GenerationResult{
 		TableName: table.Name,
 		Columns:   colNames,
 		Rows:      rows,
+		Requested: numRows,
+		FollowUps: followUps,
+		Retries:   retries,
+		Repairs:   repairs,
+		Coverage:  coverage,
+		Usage:     usage,
+		Elapsed:   g.clock.Now().Sub(start),
+
+		Provenance: g.provenance(style, prompts),
 	}, nil
 }
 
+// finish runs the passes that follow the model's response over rows:
+// direct values, coverage, repairs, edge cases and hooks.
+func (g *Generator) finish(
+	table *schema.Table,
+	rows []map[string]interface{},
+	existingIDs map[string][]interface{},
+	pc promptContext,
+	start time.Time,
+) ([]map[string]interface{}, repair.Report, []Boundary, error) {
+	if err := applyDirectValues(table, rows, pc.values, interp.Env{Now: start}); err != nil {
+		return nil, repair.Report{}, nil, fmt.Errorf("values for %s: %w", table.Name, err)
+	}
+	if g.cfg.CoverValues {
+		applyCoverage(table, rows, pc.values)
+	}
+	applyBinary(table, rows, g.cfg.Binary)
+	applyNullRefs(table, rows, g.cfg.NullRefs, existingIDs, g.cfg.Random())
+	repairs := repair.Rows(table, rows)
+	repairs.Actions = append(repairs.Actions, repair.Money(table, rows, pc.currencies).Actions...)
+	repairs.Actions = append(repairs.Actions, applyDerived(table, rows, pc.derived)...)
+	repairs.Actions = append(repairs.Actions, repair.Rules(table, rows, pc.rules).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.Lengths(table, rows, pc.lengths).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.RichText(table, rows).Actions...)
+	if g.cfg.NoPII {
+		repairs.Actions = append(repairs.Actions, repair.PII(table, rows).Actions...)
+	}
+	repairs.Actions = append(repairs.Actions, repair.Addresses(table, rows, g.cfg.AddressCountries, g.cfg.Random()).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.Persons(table, rows).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.Phones(table, rows, g.cfg.Phones).Actions...)
+	if g.cfg.Emails {
+		repairs.Actions = append(repairs.Actions, repair.Emails(table, rows, g.cfg.EmailDomains).Actions...)
+	}
+	repairs.Actions = append(repairs.Actions, repair.Tokens(table, rows, g.cfg.Tokens).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.Images(table, rows, g.cfg.Images, g.cfg.Random()).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.Times(table, rows).Actions...)
+	repairs.Actions = append(repairs.Actions, repair.SoftDeletes(table, rows, softDeleteShare(table, g.cfg.SoftDeleted), start, g.cfg.Random()).Actions...)
+	repairs.Actions = append(repairs.Actions, g.uniqueHandles(table, rows).Actions...)
+	var coverage []Boundary
+	if g.cfg.Coverage {
+		coverage = applyBoundaries(rows, g.cfg.Boundaries(table))
+	}
+	rows, err := runHooks(g.cfg.Hooks, table, rows)
+	return rows, repairs, coverage, err
+}
+
+// uniqueHandles rebuilds the table's slug, username and SKU columns (see
+// repair.Handles), avoiding the values earlier calls gave out.
+func (g *Generator) uniqueHandles(table *schema.Table, rows []map[string]interface{}) repair.Report {
+	owner := g
+	if g.parent != nil {
+		owner = g.parent
+	}
+	owner.mu.Lock()
+	defer owner.mu.Unlock()
+	if g.detached {
+		return repair.Handles(table, rows, nil)
+	}
+	if owner.handles == nil {
+		owner.handles = make(map[string]map[string]map[string]bool)
+	}
+	used := owner.handles[table.Name]
+	if used == nil {
+		used = make(map[string]map[string]bool)
+		owner.handles[table.Name] = used
+	}
+	return repair.Handles(table, rows, used)
+}
+
+// promptContext collects the Config settings that extend the prompt.
+func (g *Generator) promptContext() promptContext {
+	return promptContext{values: g.values(), derived: g.cfg.Derived, rules: g.cfg.Rules,
+		currencies: g.cfg.Currencies, emailDomains: g.cfg.EmailDomains,
+		phones: g.cfg.Phones, tokens: g.cfg.Tokens, images: g.cfg.Images, lengths: g.cfg.Lengths, stats: g.cfg.Stats, domain: g.cfg.Domain, noPII: g.cfg.NoPII,
+		version: g.cfg.PromptVersion}
+}
+
+// values merges the domain preset's value lists under the configured ones;
+// a bare column name in Config.Values also overrides the preset.
+func (g *Generator) values() map[string]ColumnValues {
+	return g.cfg.values()
+}
+
+func (c Config) values() map[string]ColumnValues {
+	if c.Domain == nil || len(c.Domain.Values) == 0 {
+		return c.Values
+	}
+	merged := make(map[string]ColumnValues, len(c.Domain.Values)+len(c.Values))
+	for k, v := range c.Domain.Values {
+		merged[k] = v
+	}
+	for k, v := range c.Values {
+		merged[k] = v
+	}
+	return merged
+}
+
 // nonAutoColNames returns column names for non-auto (non-serial PK) columns.
 func nonAutoColNames(t *schema.Table) []string {
 	var names []string
@@ -60,14 +239,125 @@ type Config struct {
 	Model     string
 	Style     Style
 	OllamaURL string
+	Pricing   Pricing // only set for hosted models; local Ollama is free
+
+	// PromptVersion picks the prompt format (see PromptVersions); empty
+	// means DefaultPromptVersion.
+	PromptVersion string
+
+	// MaxFollowUps is how many extra prompts are sent when the model returns
+	// fewer rows than requested. 0 disables follow-ups.
+	MaxFollowUps int
+	// AvoidUsedUniques lists already-generated UNIQUE values in follow-up
+	// prompts so the model does not repeat them.
+	AvoidUsedUniques bool
+	// Values pins columns to user-supplied value lists, keyed by
+	// "table.column" or "column" (see ColumnValues).
+	Values map[string]ColumnValues
+	// Derived computes columns from others after generation, keyed like
+	// Values; the model is told to leave them out.
+	Derived map[string]*derive.Expr
+	// Rules are conditional cross-column constraints. They are listed in
+	// the prompt and enforced after generation where the fix is clear.
+	Rules []*rules.Rule
+	// Currencies restricts currency columns paired with amounts to these
+	// ISO 4217 codes; empty allows any known code.
+	Currencies []string
+	// Emails makes email columns valid and unique after generation;
+	// EmailDomains, if set, also moves them onto these domains.
+	Emails       bool
+	EmailDomains []string
+	// AddressCountries limits the places address columns are set to (see
+	// repair.Addresses) to these ISO 3166 codes; empty allows any.
+	AddressCountries []string
+	// Phones formats phone columns after generation, keyed like Values
+	// or "*" for every column named like a phone.
+	Phones map[string]phone.Rule
+	// Tokens sets how API keys, tokens and JWTs are built, keyed like
+	// Values or "*" for every column named like a token; such columns are
+	// filled locally even without a rule (see repair.Tokens).
+	Tokens map[string]token.Rule
+	// Images maps image URL columns, keyed like Values or "*" for every
+	// column named like an image, to URL templates or repair.KeepImages;
+	// such columns get placeholder URLs even without one (see
+	// repair.Images).
+	Images map[string]string
+	// NullRefs is the share of rows, from 0 to 1, left NULL in nullable
+	// FK columns, keyed like Values or "*" for every such column. The
+	// other rows get a real reference, whatever the model chose.
+	NullRefs map[string]float64
+	// SoftDeleted is the share of rows, from 0 to 1, soft-deleted in
+	// tables with a deleted_at or is_deleted column (see
+	// repair.SoftDeletes), keyed like Values by that column or "*".
+	// Tables it has no rate for get DefaultSoftDeleted.
+	SoftDeleted map[string]float64
+	// CoverValues makes the first rows of every table show each value of
+	// its enum-like columns once, and leaves the last row's nullable FKs
+	// NULL (see MinimalRows).
+	CoverValues bool
+	// Coverage puts every boundary of every column (see Boundaries) into
+	// some row, after all other passes, so QA data hits each edge case.
+	Coverage bool
+	// Lengths are target lengths of text columns, keyed like Values. They
+	// are given in the prompt and longer texts are cut (see
+	// repair.Lengths).
+	Lengths map[string]repair.Length
+	// Languages sets the share of rows written in each language, by
+	// language code, keyed by table or "table.column" (see
+	// languagePlan). The table's locale or language column is set to
+	// each row's code.
+	Languages map[string]map[string]float64
+	// Locales are the languages translation tables (see TranslationOf)
+	// get a row in for every parent row.
+	Locales []string
+	// Dedup, if set, replaces near-duplicate free-text values (see Dedup).
+	Dedup *Dedup
+	// Hooks transform the rows last, in order (see RowHook).
+	Hooks []RowHook
+	// Binary says how bytea/BLOB columns are filled; they are never sent
+	// to the model.
+	Binary BinaryFill
+	// Stats describes production data (see stats.Collect); the prompt asks
+	// for rows with the same NULL ratios, ranges and value mix.
+	Stats *stats.Profile
+	// Archetypes overrides schema.Classify's guess for the tables it
+	// names; the prompt describes lookup, join and event log tables.
+	Archetypes map[string]schema.Archetype
+	// Domain, if set, adds a preset's hints and value lists (see LookupDomain).
+	Domain *Domain
+	// NoPII asks for obviously fictional personal data and rewrites any
+	// real-looking emails, phone numbers, SSNs and card numbers afterwards.
+	NoPII bool
+	// DebugDir, if set, receives a copy of every prompt and raw response.
+	// The directory must exist.
+	DebugDir string
+	// RecordDir saves every successful response for later replay;
+	// ReplayDir answers prompts from such a directory without calling the
+	// model at all. Both directories must exist.
+	RecordDir string
+	ReplayDir string
+	// Cache, if set, answers prompts it has seen from any run sharing it,
+	// and stores new responses for the next (see package cache).
+	Cache cache.Backend
+	// Rand makes the random choices taken without the model: which rows
+	// get NULL references, a language or a soft delete, placeholder
+	// image seeds, places for addresses, and in package pipeline the
+	// skewed FK values, join table links and actors. nil means
+	// math/rand/v2's top-level source; a seeded one from NewRand, e.g.
+	// NewRand(rand.NewPCG(1, 2)), makes them repeat from run to run.
+	// Parent rows sampled from a database are the database's pick.
+	Rand *rand.Rand
 }
 
 // DefaultConfig returns config with defaults.
 func DefaultConfig() Config {
 	return Config{
-		Model:     "llama3",
-		Style:     StyleRealistic,
-		OllamaURL: "http://localhost:11434",
+		Model:            "llama3",
+		Style:            StyleRealistic,
+		PromptVersion:    DefaultPromptVersion,
+		OllamaURL:        "http://localhost:11434",
+		MaxFollowUps:     3,
+		AvoidUsedUniques: true,
 	}
 }
 
@@ -79,36 +369,97 @@ type GenerateRequest struct {
 }
 
 // GenerateResponse is the JSON response from Ollama (stream=false).
+// Durations are reported in nanoseconds.
 type GenerateResponse struct {
-	Response string `json:"response"`
+	Response        string `json:"response"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	TotalDuration   int64  `json:"total_duration"`
 }
 
 // CallOllama sends the prompt to Ollama and returns the raw response text.
 func CallOllama(cfg Config, prompt string) (string, error) {
+	raw, _, err := CallOllamaUsage(cfg, prompt)
+	return raw, err
+}
+
+// CallOllamaUsage is CallOllama but also returns the token usage Ollama
+// reported for the call.
+func CallOllamaUsage(cfg Config, prompt string) (string, Usage, error) {
+	return NewOllamaClient(cfg, nil).Generate(prompt)
+}
+
+// generateRecorded answers from cfg.ReplayDir or cfg.Cache, or calls
+// Ollama and saves the response to cfg.RecordDir and cfg.Cache. A cache
+// hit is saved to cfg.RecordDir too, so a recording is complete whatever
+// the cache held. Only responses that parse as rows are saved, and a
+// cached one that does not is a miss: one bad reply must not be served to
+// everyone sharing the cache, nor to the follow-up that retries it.
+func (c *OllamaClient) generateRecorded(prompt string) (string, Usage, error) {
+	if c.cfg.ReplayDir != "" {
+		return loadRecording(c.cfg.ReplayDir, c.cfg.Model, prompt)
+	}
+	if c.cfg.Cache != nil {
+		rec, ok, err := loadCached(c.cfg.Cache, c.cfg.Model, prompt)
+		ok = ok && parses(rec.Response)
+		if err == nil && ok && c.cfg.RecordDir != "" {
+			err = saveRecording(c.cfg.RecordDir, c.cfg.Model, prompt, rec.Response, rec.Usage)
+		}
+		if err != nil || ok {
+			return rec.Response, Usage{CacheHits: 1}, err
+		}
+	}
+	raw, usage, err := c.post(prompt)
+	if err != nil || !parses(raw) {
+		return raw, usage, err
+	}
+	if c.cfg.RecordDir != "" {
+		err = saveRecording(c.cfg.RecordDir, c.cfg.Model, prompt, raw, usage)
+	}
+	if err == nil && c.cfg.Cache != nil {
+		err = saveCached(c.cfg.Cache, c.cfg.Model, prompt, raw, usage)
+	}
+	return raw, usage, err
+}
+
+// parses reports whether a model response holds rows ParseJSONRows reads.
+func parses(raw string) bool {
+	_, err := parseJSONResponse(raw)
+	return err == nil
+}
+
+// post makes one /api/generate request.
+func (c *OllamaClient) post(prompt string) (string, Usage, error) {
 	body, _ := json.Marshal(GenerateRequest{
-		Model:  cfg.Model,
+		Model:  c.cfg.Model,
 		Prompt: prompt,
 		Stream: false,
 	})
-	url := strings.TrimSuffix(cfg.OllamaURL, "/") + "/api/generate"
+	url := strings.TrimSuffix(c.cfg.OllamaURL, "/") + "/api/generate"
 	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("ollama request: %w", err)
+		return "", Usage{}, fmt.Errorf("ollama request: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
+		return "", Usage{}, fmt.Errorf("ollama returned status %d", resp.StatusCode)
 	}
 	var genResp GenerateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
-		return "", err
+		return "", Usage{}, err
+	}
+	usage := Usage{
+		PromptTokens: genResp.PromptEvalCount,
+		EvalTokens:   genResp.EvalCount,
+		Duration:     time.Duration(genResp.TotalDuration),
+		Calls:        1,
 	}
-	return genResp.Response, nil
+	return genResp.Response, usage, nil
 }
 
 // parseJSONResponse extracts a JSON array from the raw AI response string.
@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// RowHook transforms a table's rows after generation and every built-in
+// pass, before they are validated and inserted: hashing passwords,
+// encrypting fields, anything the project does on write. It returns the
+// rows to keep, which may be fewer.
+type RowHook interface {
+	Process(t *schema.Table, rows []map[string]interface{}) ([]map[string]interface{}, error)
+}
+
+// ExecHook is a RowHook that runs a command. The rows go to its stdin as
+// JSON lines, one object per row keyed by column; it writes the rows to
+// keep to stdout the same way. Binary values are objects of one key,
+// {"$bytes": "<base64>"}, both ways, as in recorded data (see
+// pipeline.DataDir). SEEDDB_TABLE holds the table's name. A non-zero exit
+// fails the table.
+type ExecHook struct {
+	Table   string   // only this table; "" for every table
+	Command []string // program and arguments, run without a shell
+}
+
+// Process implements RowHook.
+func (h ExecHook) Process(t *schema.Table, rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	if h.Table != "" && h.Table != t.Name || len(rows) == 0 {
+		return rows, nil
+	}
+	var in bytes.Buffer
+	enc := json.NewEncoder(&in)
+	for _, row := range rows {
+		out := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			if b, ok := v.([]byte); ok {
+				v = map[string]string{bytesKey: base64.StdEncoding.EncodeToString(b)}
+			}
+			out[k] = v
+		}
+		if err := enc.Encode(out); err != nil {
+			return nil, fmt.Errorf("hook %s: %w", h.Command[0], err)
+		}
+	}
+	cmd := exec.Command(h.Command[0], h.Command[1:]...)
+	cmd.Env = append(os.Environ(), "SEEDDB_TABLE="+t.Name)
+	cmd.Stdin = &in
+	var out, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("hook %s: %w: %s", h.Command[0], err, lastLine(msg))
+		}
+		return nil, fmt.Errorf("hook %s: %w", h.Command[0], err)
+	}
+	var kept []map[string]interface{}
+	sc := bufio.NewScanner(&out)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err != nil {
+			return nil, fmt.Errorf("hook %s: output line %d: %w", h.Command[0], line, err)
+		}
+		for k, v := range row {
+			row[k] = hookValue(v)
+		}
+		kept = append(kept, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("hook %s: %w", h.Command[0], err)
+	}
+	return kept, nil
+}
+
+// bytesKey wraps binary values, which JSON would turn into plain strings.
+const bytesKey = "$bytes"
+
+// hookValue undoes the JSON encoding of a value a hook wrote: a
+// json.Number becomes an int64 when it is whole, so large keys survive the
+// round trip, and a float64 otherwise; wrapped bytes become []byte again.
+func hookValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		if s, ok := x[bytesKey].(string); ok && len(x) == 1 {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// lastLine returns the last line of s, where commands usually say what
+// went wrong.
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// runHooks passes rows through each hook in turn.
+func runHooks(hooks []RowHook, t *schema.Table, rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	for _, h := range hooks {
+		var err error
+		if rows, err = h.Process(t, rows); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
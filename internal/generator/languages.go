@@ -0,0 +1,213 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// languageNames names the common language codes in prompts; other codes
+// are shown as they are.
+var languageNames = map[string]string{
+	"ar": "Arabic", "cs": "Czech", "da": "Danish", "de": "German", "el": "Greek",
+	"en": "English", "es": "Spanish", "fi": "Finnish", "fr": "French", "he": "Hebrew",
+	"hi": "Hindi", "id": "Indonesian", "it": "Italian", "ja": "Japanese", "ko": "Korean",
+	"nl": "Dutch", "no": "Norwegian", "pl": "Polish", "pt": "Portuguese", "ro": "Romanian",
+	"ru": "Russian", "sv": "Swedish", "th": "Thai", "tr": "Turkish", "uk": "Ukrainian",
+	"vi": "Vietnamese", "zh": "Chinese",
+}
+
+// LanguageName returns the English name of a language code such as "es"
+// or "pt-BR", or the code itself.
+func LanguageName(code string) string {
+	base, region, _ := strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+	name, ok := languageNames[strings.ToLower(base)]
+	if !ok {
+		return code
+	}
+	if region != "" {
+		return name + " (" + region + ")"
+	}
+	return name
+}
+
+// isLanguageColumn reports whether col holds the row's language code.
+func isLanguageColumn(col schema.Column) bool {
+	if col.Type != "text" || col.ForeignKey != nil || col.PrimaryKey {
+		return false
+	}
+	switch strings.ToLower(col.Name) {
+	case "locale", "lang", "language", "lang_code", "language_code", "locale_code":
+		return true
+	}
+	return false
+}
+
+// languageColumn returns the name of t's language column, or "".
+func languageColumn(t *schema.Table) string {
+	for _, col := range t.NonAutoColumns() {
+		if isLanguageColumn(col) {
+			return col.Name
+		}
+	}
+	return ""
+}
+
+// languagePlan spreads n rows over the languages of mix by their shares,
+// largest share first, in runs: the language of each row.
+func languagePlan(mix map[string]float64, n int) []string {
+	codes := make([]string, 0, len(mix))
+	total := 0.0
+	for code, share := range mix {
+		codes = append(codes, code)
+		total += share
+	}
+	if total <= 0 || n <= 0 {
+		return nil
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if mix[codes[i]] != mix[codes[j]] {
+			return mix[codes[i]] > mix[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	counts := make([]int, len(codes))
+	rest := n
+	for i, code := range codes {
+		counts[i] = int(math.Floor(mix[code] / total * float64(n)))
+		rest -= counts[i]
+	}
+	byRemainder := make([]int, len(codes))
+	for i := range byRemainder {
+		byRemainder[i] = i
+	}
+	sort.SliceStable(byRemainder, func(a, b int) bool {
+		ra := mix[codes[byRemainder[a]]]/total*float64(n) - float64(counts[byRemainder[a]])
+		rb := mix[codes[byRemainder[b]]]/total*float64(n) - float64(counts[byRemainder[b]])
+		return ra > rb
+	})
+	for i := 0; i < rest; i++ {
+		counts[byRemainder[i%len(codes)]]++
+	}
+	plan := make([]string, 0, n)
+	for i, code := range codes {
+		for j := 0; j < counts[i]; j++ {
+			plan = append(plan, code)
+		}
+	}
+	return plan
+}
+
+// languages is the language of each row of a table, for the whole table
+// and for single columns, from Config.Languages.
+type languages struct {
+	rows   []string            // nil without a mix for the table
+	cols   map[string][]string // column -> language of each row
+	column string              // the table's language column, set from rows
+}
+
+// languagesFor plans the languages of t's n rows. Config.Languages is
+// keyed by table for whole rows, or "table.column" for one column.
+func (c Config) languagesFor(t *schema.Table, n int) languages {
+	var l languages
+	if len(c.Languages) == 0 {
+		return l
+	}
+	if mix, ok := c.Languages[t.Name]; ok {
+		l.rows = languagePlan(mix, n)
+		l.column = languageColumn(t)
+	}
+	for _, col := range t.NonAutoColumns() {
+		if mix, ok := c.Languages[t.Name+"."+col.Name]; ok && col.Type == "text" {
+			if l.cols == nil {
+				l.cols = make(map[string][]string)
+			}
+			l.cols[col.Name] = languagePlan(mix, n)
+		}
+	}
+	return l
+}
+
+// chunk returns the plan of rows from to to, for the prompt asking for
+// them.
+func (l languages) chunk(from, to int) languages {
+	out := languages{column: l.column}
+	if from < len(l.rows) {
+		out.rows = l.rows[from:min(to, len(l.rows))]
+	}
+	for col, plan := range l.cols {
+		if from < len(plan) {
+			if out.cols == nil {
+				out.cols = make(map[string][]string)
+			}
+			out.cols[col] = plan[from:min(to, len(plan))]
+		}
+	}
+	return out
+}
+
+// formatLanguages tells the model which rows to write in which language.
+// Example output:
+//
+//	LANGUAGES (write the text of each row in its language, in this order):
+//	  - rows 1-6: English (en)
+//	  - rows 7-10: Spanish (es)
+//	  - locale: filled in automatically with the row's language code, leave it out
+func formatLanguages(l languages) string {
+	var lines []string
+	lines = append(lines, formatRuns("", l.rows)...)
+	cols := make([]string, 0, len(l.cols))
+	for col := range l.cols {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	for _, col := range cols {
+		lines = append(lines, formatRuns(promptIdent(col)+", ", l.cols[col])...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	if l.column != "" && len(l.rows) > 0 {
+		lines = append(lines, fmt.Sprintf("  - %s: filled in automatically with the row's language code, leave it out", promptIdent(l.column)))
+	}
+	return "\nLANGUAGES (write the text of each row in its language, in this order):\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// formatRuns describes each run of one language in plan.
+func formatRuns(prefix string, plan []string) []string {
+	var lines []string
+	for start := 0; start < len(plan); {
+		end := start
+		for end < len(plan) && plan[end] == plan[start] {
+			end++
+		}
+		rows := fmt.Sprintf("rows %d-%d", start+1, end)
+		if end == start+1 {
+			rows = fmt.Sprintf("row %d", end)
+		}
+		lines = append(lines, fmt.Sprintf("  - %s%s: %s (%s)", prefix, rows, LanguageName(plan[start]), plan[start]))
+		start = end
+	}
+	return lines
+}
+
+// applyLanguages sets the language column of each row to its planned
+// language, then shuffles the rows so the languages are mixed through the
+// table instead of in runs.
+func applyLanguages(rows []map[string]interface{}, l languages, r *rand.Rand) {
+	if len(l.rows) == 0 && len(l.cols) == 0 {
+		return
+	}
+	if l.column != "" {
+		for i, row := range rows {
+			if i < len(l.rows) {
+				row[l.column] = l.rows[i]
+			}
+		}
+	}
+	r.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+}
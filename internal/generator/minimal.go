@@ -0,0 +1,62 @@
+package generator
+
+import "github.com/satyammistari/db-seed-ai/internal/schema"
+
+// MinimalRows returns the fewest rows of t that can show every value its
+// enum-like columns allow: each entry of a CHECK list or a configured
+// value list, true and false, and for nullable foreign keys both a
+// reference and NULL. It is at least 1, so every foreign key into t can
+// be satisfied.
+func (c Config) MinimalRows(t *schema.Table) int {
+	n := 1
+	values := c.values()
+	for _, col := range t.NonAutoColumns() {
+		n = max(n, len(coverList(t, col, values)))
+		if col.ForeignKey != nil && !col.NotNull && !col.PrimaryKey {
+			n = max(n, 2)
+		}
+	}
+	return n
+}
+
+// coverList returns the values col must show once each, or nil. Direct
+// value lists are left out: they already fill the column in rotation.
+func coverList(t *schema.Table, col schema.Column, values map[string]ColumnValues) []interface{} {
+	var list []string
+	v, ok := lookupValues(values, t.Name, col.Name)
+	switch {
+	case ok && v.Direct:
+		return nil
+	case len(col.CheckIn) > 0:
+		list = col.CheckIn
+	case ok:
+		list = v.Values
+	case col.Type == "boolean":
+		return []interface{}{true, false}
+	}
+	out := make([]interface{}, len(list))
+	for i, s := range list {
+		out[i] = s
+	}
+	return out
+}
+
+// applyCoverage gives the first rows one value each of every enum-like
+// column's list and, with two rows or more, sets nullable foreign keys in
+// the last row to NULL, so a dataset of MinimalRows rows has every case.
+func applyCoverage(t *schema.Table, rows []map[string]interface{}, values map[string]ColumnValues) {
+	for _, col := range t.NonAutoColumns() {
+		if col.ForeignKey != nil {
+			if !col.NotNull && !col.PrimaryKey && len(rows) >= 2 {
+				rows[len(rows)-1][col.Name] = nil
+			}
+			continue
+		}
+		for i, v := range coverList(t, col, values) {
+			if i >= len(rows) {
+				break
+			}
+			rows[i][col.Name] = v
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// lookupNullRate finds the NULL rate for a nullable FK column, or another
+// per-column rate, keyed "table.column", "column", or "*" for every such
+// column.
+func lookupNullRate(rates map[string]float64, table, column string) (float64, bool) {
+	if r, ok := rates[table+"."+column]; ok {
+		return r, true
+	}
+	if r, ok := rates[column]; ok {
+		return r, true
+	}
+	r, ok := rates["*"]
+	return r, ok
+}
+
+// applyNullRefs makes the configured share of rows NULL in each nullable
+// FK column with a rate, picked at random, and gives the other rows a
+// reference: the model's value if it chose one, or else one of the
+// sampled parent values in refIDs. UNIQUE columns are only ever set to
+// NULL, since a drawn value could repeat.
+func applyNullRefs(t *schema.Table, rows []map[string]interface{}, rates map[string]float64, refIDs map[string][]interface{}, r *rand.Rand) {
+	if len(rates) == 0 || len(rows) == 0 {
+		return
+	}
+	for _, col := range t.FKColumns() {
+		if col.NotNull || col.PrimaryKey {
+			continue
+		}
+		rate, ok := lookupNullRate(rates, t.Name, col.Name)
+		if !ok {
+			continue
+		}
+		nulls := int(math.Round(rate * float64(len(rows))))
+		ids := refIDs[col.Name]
+		for i, j := range r.Perm(len(rows)) {
+			row := rows[j]
+			switch {
+			case i < nulls:
+				row[col.Name] = nil
+			case row[col.Name] == nil && len(ids) > 0 && !col.Unique:
+				row[col.Name] = ids[r.IntN(len(ids))]
+			}
+		}
+	}
+}
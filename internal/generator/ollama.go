@@ -1,42 +1,132 @@
 package generator
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
 	"math/rand"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/satyammistari/db-seed-ai/internal/repair"
 	"github.com/satyammistari/db-seed-ai/internal/schema"
 )
 
+// LLMClient is the model behind a Generator. OllamaClient is the only
+// implementation in this repo; tests and embedders can supply their own.
+type LLMClient interface {
+	// Generate sends one prompt and returns the raw text response and the
+	// token usage for the call.
+	Generate(prompt string) (string, Usage, error)
+}
+
+// Clock supplies the current time. Generator uses it to time each table.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the Clock backed by time.Now.
+var SystemClock Clock = systemClock{}
+
 // OllamaClient wraps the Ollama HTTP API.
 type OllamaClient struct {
-	cfg Config
+	cfg  Config
+	http *http.Client
 }
 
-// NewOllamaClient creates a client from a Config.
-func NewOllamaClient(cfg Config) *OllamaClient {
-	return &OllamaClient{cfg: cfg}
+// NewOllamaClient creates a client from a Config. A nil httpClient means
+// http.DefaultClient.
+func NewOllamaClient(cfg Config, httpClient *http.Client) *OllamaClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &OllamaClient{cfg: cfg, http: httpClient}
 }
 
-// Generate sends a prompt to Ollama and returns the raw text response.
-func (c *OllamaClient) Generate(prompt string) (string, error) {
-	return CallOllama(c.cfg, prompt)
+// Generate sends a prompt to Ollama and returns the raw text response
+// together with the token usage reported for the call. With cfg.DebugDir
+// set the prompt and response are saved there; cfg.RecordDir and
+// cfg.ReplayDir record or replay the call, and cfg.Cache shares it.
+func (c *OllamaClient) Generate(prompt string) (string, Usage, error) {
+	if c.cfg.DebugDir == "" {
+		return c.generateRecorded(prompt)
+	}
+	started := time.Now()
+	raw, usage, err := c.generateRecorded(prompt)
+	if derr := captureDebug(c.cfg.DebugDir, started, prompt, raw, err); derr != nil && err == nil {
+		err = derr
+	}
+	return raw, usage, err
 }
 
-// Generator holds an OllamaClient and is the high-level entry point.
+// Generator turns tables into rows using an LLMClient.
 type Generator struct {
-	client *OllamaClient
-	cfg    Config
+	client   LLMClient
+	cfg      Config
+	clock    Clock
+	progress ProgressFunc
+
+	// handles holds the slugs, usernames and SKUs given out so far, by
+	// table and column, so later calls do not repeat them. A fork uses
+	// its parent's, until detached (both guarded by the parent's mu).
+	mu       sync.Mutex
+	handles  map[string]map[string]map[string]bool
+	parent   *Generator
+	detached bool
 }
 
-// New returns a Generator with the given config.
+// New returns a Generator that talks to Ollama as configured in cfg.
 func New(cfg Config) *Generator {
-	return &Generator{
-		client: NewOllamaClient(cfg),
-		cfg:    cfg,
+	return NewWithClient(cfg, NewOllamaClient(cfg, nil))
+}
+
+// NewWithClient returns a Generator that sends prompts to client instead
+// of Ollama. cfg still supplies the style and follow-up settings.
+func NewWithClient(cfg Config, client LLMClient) *Generator {
+	return &Generator{client: client, cfg: cfg, clock: SystemClock}
+}
+
+// SetClock replaces the clock used to time generation.
+func (g *Generator) SetClock(c Clock) {
+	g.clock = c
+}
+
+// OnProgress registers fn to receive progress events from Generate.
+// Passing nil turns progress reporting off.
+func (g *Generator) OnProgress(fn ProgressFunc) {
+	g.progress = fn
+}
+
+// Fork returns a Generator for one call that may be given up on while it
+// runs: it shares g's client, settings and handles, but reports progress
+// to fn. Once Detach is called the fork no longer changes anything of g's,
+// so a call left running cannot disturb the ones after it.
+func (g *Generator) Fork(fn ProgressFunc) *Generator {
+	root := g
+	if g.parent != nil {
+		root = g.parent
+	}
+	return &Generator{client: g.client, cfg: g.cfg, clock: g.clock, progress: fn, parent: root}
+}
+
+// Detach cuts a fork off from the Generator it came from: the handles it
+// gives out from then on are not recorded there.
+func (g *Generator) Detach() {
+	if g.parent == nil {
+		return
+	}
+	g.parent.mu.Lock()
+	defer g.parent.mu.Unlock()
+	g.detached = true
+}
+
+// report sends p to the registered ProgressFunc, if any.
+func (g *Generator) report(p Progress) {
+	if g.progress != nil {
+		g.progress(p)
 	}
 }
 
@@ -46,12 +136,51 @@ type GenerationResult struct {
 	TableName string
 	Columns   []string
 	Rows      []map[string]interface{}
+	Requested int // rows asked for; len(Rows) may be lower if the model fell short
+	FollowUps int // extra prompts sent to fill in missing rows
+	Retries   int // follow-ups the model failed or answered with rows that could not be parsed
+	Repairs   repair.Report
+	Coverage  []Boundary // edge cases placed in rows, with Config.Coverage
+	Usage     Usage
+	Elapsed   time.Duration // wall time for the table, follow-ups included
+
+	Provenance Provenance
+}
+
+// Short reports whether fewer rows were produced than requested.
+func (r *GenerationResult) Short() bool {
+	return len(r.Rows) < r.Requested
 }
 
 // ParseJSONRows parses the raw AI response into typed rows.
-// columnHint is used to filter / order columns if provided.
+// With columnHint, rows that share no key with it are dropped: they were
+// written for some other table, as a schema steering the model might ask
+// for. Unknown keys in the rows kept are left to repair.Rows.
 func ParseJSONRows(raw string, columnHint []string) ([]map[string]interface{}, error) {
-	return parseJSONResponse(raw)
+	rows, err := parseJSONResponse(raw)
+	if err != nil || len(columnHint) == 0 {
+		return rows, err
+	}
+	known := make(map[string]bool, len(columnHint))
+	for _, c := range columnHint {
+		known[c] = true
+	}
+	kept := rows[:0]
+	for _, row := range rows {
+		if len(row) == 0 || sharesKey(row, known) {
+			kept = append(kept, row)
+		}
+	}
+	return kept, nil
+}
+
+func sharesKey(row map[string]interface{}, known map[string]bool) bool {
+	for k := range row {
+		if known[k] {
+			return true
+		}
+	}
+	return false
 }
 
 // GenerateForTable is a convenience wrapper used by cmd.
@@ -76,46 +205,6 @@ func colNames(t *schema.Table) []string {
 	return names
 }
 
-// buildOllamaRequestBody creates the JSON body for the Ollama generate API.
-func buildOllamaRequestBody(model, prompt string) ([]byte, error) {
-	return json.Marshal(struct {
-		Model  string `json:"model"`
-		Prompt string `json:"prompt"`
-		Stream bool   `json:"stream"`
-	}{
-		Model:  model,
-		Prompt: prompt,
-		Stream: false,
-	})
-}
-
-// sendOllamaRequest sends the HTTP request and decodes the response.
-func sendOllamaRequest(url string, body []byte) (string, error) {
-	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("ollama request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
-	var genResp struct {
-		Response string `json:"response"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
-		return "", err
-	}
-	return genResp.Response, nil
-}
-
 // shuffleStrings shuffles a string slice in-place (used for edge-case style).
 func shuffleStrings(s []string) {
 	rand.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
@@ -0,0 +1,46 @@
+package generator
+
+// ProgressKind says what a Progress event reports.
+type ProgressKind int
+
+const (
+	ProgressRequest ProgressKind = iota // a prompt (chunk) was sent to the model
+	ProgressRows                        // a response was parsed; Rows is the running total
+	ProgressRetry                       // a follow-up failed or its response could not be parsed; Err says why
+	ProgressDone                        // the table is finished; Rows is the final count
+)
+
+func (k ProgressKind) String() string {
+	switch k {
+	case ProgressRequest:
+		return "request"
+	case ProgressRows:
+		return "rows"
+	case ProgressRetry:
+		return "retry"
+	case ProgressDone:
+		return "done"
+	}
+	return "unknown"
+}
+
+// Progress is one step of Generator.Generate for a single table.
+type Progress struct {
+	Table     string
+	Kind      ProgressKind
+	Chunk     int // 1 for the first prompt, 2+ for follow-ups
+	Rows      int // rows collected so far
+	Requested int
+	Err       error // why the chunk was retried, for ProgressRetry
+}
+
+// ProgressFunc receives progress events. It is called synchronously from
+// Generate, so it should return quickly.
+type ProgressFunc func(Progress)
+
+// ProgressChan returns a ProgressFunc that forwards events to ch, for
+// callers that render progress from another goroutine. Sends block until
+// the event is received.
+func ProgressChan(ch chan<- Progress) ProgressFunc {
+	return func(p Progress) { ch <- p }
+}
@@ -2,12 +2,45 @@ package generator
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/satyammistari/db-seed-ai/internal/derive"
+	"github.com/satyammistari/db-seed-ai/internal/phone"
+	"github.com/satyammistari/db-seed-ai/internal/repair"
+	"github.com/satyammistari/db-seed-ai/internal/rules"
 	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/stats"
+	"github.com/satyammistari/db-seed-ai/internal/token"
 )
 
-// BuildPrompt creates the text we send to Ollama.
+// Prompt formats, for Config.PromptVersion. An old format keeps producing
+// the prompt it always did, so an earlier run can be reproduced, or two
+// formats compared on the same schema.
+const (
+	PromptV1 = "1" // the column list gives types and constraints only
+	PromptV2 = "2" // it also gives varchar lengths and numeric precision
+
+	DefaultPromptVersion = PromptV2
+)
+
+// PromptVersions lists the prompt formats, oldest first.
+var PromptVersions = []string{PromptV1, PromptV2}
+
+// CheckPromptVersion returns an error unless v is one of PromptVersions.
+func CheckPromptVersion(v string) error {
+	for _, known := range PromptVersions {
+		if v == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown prompt version %q (have %s)", v, strings.Join(PromptVersions, ", "))
+}
+
+// BuildPrompt creates the text we send to Ollama, in the
+// DefaultPromptVersion format.
 // It tells the AI:
 //  1. What table to generate for
 //  2. What columns exist and their rules
@@ -20,6 +53,36 @@ func BuildPrompt(
 	fullSchema *schema.Schema,
 	style string,
 	existingIDs map[string][]interface{},
+) string {
+	return buildPrompt(table, numRows, fullSchema, style, existingIDs, promptContext{})
+}
+
+// promptContext carries the run-wide settings that add to the base prompt.
+type promptContext struct {
+	values       map[string]ColumnValues
+	derived      map[string]*derive.Expr
+	rules        []*rules.Rule
+	currencies   []string
+	emailDomains []string
+	phones       map[string]phone.Rule
+	tokens       map[string]token.Rule
+	images       map[string]string
+	lengths      map[string]repair.Length
+	languages    languages // of the rows the prompt asks for
+	stats        *stats.Profile
+	domain       *Domain
+	noPII        bool
+	archetype    schema.Archetype
+	version      string // "" means DefaultPromptVersion
+}
+
+func buildPrompt(
+	table *schema.Table,
+	numRows int,
+	fullSchema *schema.Schema,
+	style string,
+	existingIDs map[string][]interface{},
+	pc promptContext,
 ) string {
 	return fmt.Sprintf(
 		`You are a database seed data generator.
@@ -35,10 +98,11 @@ RULES YOU MUST FOLLOW STRICTLY:
 
 DATA STYLE: %s
 %s
+%s
 
 FOREIGN KEY VALUES (ONLY use these exact values for FK columns):
 %s
-
+%s
 OUTPUT RULES — FOLLOW EXACTLY:
 - Your response must start with [ and end with ]
 - Return ONLY the JSON array, absolutely nothing else
@@ -54,35 +118,55 @@ OUTPUT RULES — FOLLOW EXACTLY:
 START YOUR RESPONSE WITH [ AND NOTHING ELSE.
 END YOUR RESPONSE WITH ] AND NOTHING ELSE.
 
-EXAMPLE of correct output format:
-[
-  {"name": "Sarah Mitchell", "email": "sarah@example.com"},
-  {"name": "James Rodriguez", "email": "james@mail.com"}
-]
+%s
 
 Generate the complete JSON array with exactly %d rows for table %s now:`,
 		numRows,
-		numRows,
-		table.Name,
-		formatColumnDefs(table),
+		promptIdent(table.Name),
+		formatColumnDefs(table, pc.version),
 		formatConstraints(table, existingIDs),
 		style,
 		formatStyleHints(style),
+		formatArchetype(table, pc.archetype)+formatDomainHints(pc.domain)+formatPrivacyRules(pc.noPII),
 		formatExistingIDs(existingIDs),
-		table.Name,
+		formatColumnValues(table, pc.values)+formatDerived(table, pc.derived)+formatRules(table, pc.rules)+
+			formatMoney(table, pc.currencies)+formatBinary(table)+formatEmails(table, pc.emailDomains)+
+			formatPhones(table, pc.phones)+formatLengths(table, pc.lengths)+formatRichText(table)+formatLanguages(pc.languages)+
+			formatLocal(table, pc.tokens, pc.images)+formatStats(table, pc.stats.Table(table.Name)),
+		numRows,
+		formatExampleOutput(table),
+		numRows,
+		promptIdent(table.Name),
 	)
 }
 
+// formatExampleOutput shows the model what rows should look like: a few
+// hand-written rows for the table's type (see exampleSetFor), or a
+// generic two-row array to show the format.
+func formatExampleOutput(t *schema.Table) string {
+	if rows := formatExamples(t); rows != "" {
+		return "EXAMPLE rows for a table like this one (match their style and realism; never copy them, and still fill every column):\n" + rows
+	}
+	return `EXAMPLE of correct output format:
+[
+  {"name": "Sarah Mitchell", "email": "sarah@example.com"},
+  {"name": "James Rodriguez", "email": "james@mail.com"}
+]`
+}
+
 // formatColumnDefs builds the column list for the prompt.
 // Example output:
-//   - email: text [REQUIRED] [MUST BE UNIQUE]
+//   - email: text [REQUIRED] [MUST BE UNIQUE] [AT MOST 255 CHARACTERS]
 //   - user_id: integer [REQUIRED] [FK → users.id]
 //   - status: text [ONLY ALLOWED: pending, paid, shipped]
-func formatColumnDefs(t *schema.Table) string {
+func formatColumnDefs(t *schema.Table, version string) string {
 	var sb strings.Builder
 
 	for _, col := range t.NonAutoColumns() {
-		sb.WriteString(fmt.Sprintf("  - %s: %s", col.Name, col.Type))
+		if col.Type == "binary" {
+			continue // filled locally, see formatBinary
+		}
+		sb.WriteString(fmt.Sprintf("  - %s: %s", promptIdent(col.Name), col.Type))
 
 		if col.NotNull {
 			sb.WriteString(" [REQUIRED]")
@@ -90,17 +174,23 @@ func formatColumnDefs(t *schema.Table) string {
 		if col.Unique {
 			sb.WriteString(" [MUST BE UNIQUE]")
 		}
+		if n := col.MaxLength(); n > 0 && version != PromptV1 {
+			sb.WriteString(fmt.Sprintf(" [AT MOST %d CHARACTERS]", n))
+		}
+		if digits, scale := col.Precision(); digits > 0 && version != PromptV1 {
+			sb.WriteString(fmt.Sprintf(" [AT MOST %d DIGITS, %d AFTER THE DECIMAL POINT]", digits, scale))
+		}
 		if col.ForeignKey != nil {
 			sb.WriteString(fmt.Sprintf(
 				" [FK → %s.%s]",
-				col.ForeignKey.RefTable,
-				col.ForeignKey.RefColumn,
+				promptIdent(col.ForeignKey.RefTable),
+				promptIdent(col.ForeignKey.RefColumn),
 			))
 		}
 		if len(col.CheckIn) > 0 {
 			sb.WriteString(fmt.Sprintf(
 				" [ONLY ALLOWED VALUES: %s]",
-				strings.Join(col.CheckIn, ", "),
+				strings.Join(promptLiterals(col.CheckIn), ", "),
 			))
 		}
 		sb.WriteString("\n")
@@ -120,16 +210,19 @@ func formatConstraints(
 	var constraints []string
 
 	for _, col := range t.NonAutoColumns() {
+		if col.Type == "binary" {
+			continue
+		}
 		if col.NotNull {
 			constraints = append(constraints,
-				fmt.Sprintf("  - %s MUST NOT be null or empty", col.Name),
+				fmt.Sprintf("  - %s MUST NOT be null or empty", promptIdent(col.Name)),
 			)
 		}
 		if col.Unique {
 			constraints = append(constraints,
 				fmt.Sprintf(
 					"  - %s MUST be unique — no two rows can have the same value",
-					col.Name,
+					promptIdent(col.Name),
 				),
 			)
 		}
@@ -137,8 +230,8 @@ func formatConstraints(
 			constraints = append(constraints,
 				fmt.Sprintf(
 					"  - %s MUST be exactly one of: %s",
-					col.Name,
-					strings.Join(col.CheckIn, " | "),
+					promptIdent(col.Name),
+					strings.Join(promptLiterals(col.CheckIn), " | "),
 				),
 			)
 		}
@@ -150,12 +243,12 @@ func formatConstraints(
 				}
 				vals := make([]string, len(shown))
 				for i, v := range shown {
-					vals[i] = fmt.Sprintf("%v", v)
+					vals[i] = promptLiteral(fmt.Sprintf("%v", v))
 				}
 				constraints = append(constraints,
 					fmt.Sprintf(
 						"  - %s MUST be one of these exact values: [%s]",
-						col.Name,
+						promptIdent(col.Name),
 						strings.Join(vals, ", "),
 					),
 				)
@@ -163,13 +256,22 @@ func formatConstraints(
 				constraints = append(constraints,
 					fmt.Sprintf(
 						"  - %s is a foreign key — use small integers like 1, 2, 3",
-						col.Name,
+						promptIdent(col.Name),
 					),
 				)
 			}
 		}
 	}
 
+	for _, set := range t.UniqueTogether {
+		constraints = append(constraints,
+			fmt.Sprintf(
+				"  - the combination (%s) MUST be unique across all rows",
+				strings.Join(promptIdents(set), ", "),
+			),
+		)
+	}
+
 	if len(constraints) == 0 {
 		return "  No special constraints"
 	}
@@ -210,6 +312,22 @@ STYLE HINTS for minimal data:
 	}
 }
 
+// formatPrivacyRules asks for obviously fake personal data. It is listed
+// after the style hints so it overrides them.
+func formatPrivacyRules(noPII bool) string {
+	if !noPII {
+		return ""
+	}
+	return `
+PRIVACY RULES (override the style hints above):
+- Person names must be obviously fictional, e.g. "Ada Placeholder", "Testy McTest"
+- Emails use ONLY the domains example.com, example.org or example.net
+- Phone numbers use ONLY the fictional range 555-0100 to 555-0199, e.g. +1-202-555-0142
+- Addresses are made up, e.g. "123 Example Street, Sampletown"
+- Government IDs look like 000-00-1234 and card numbers are 4242424242424242
+`
+}
+
 // formatExistingIDs shows the AI what FK reference IDs exist.
 // Example output:
 //
@@ -220,23 +338,98 @@ func formatExistingIDs(existingIDs map[string][]interface{}) string {
 		return "  This table has no foreign keys"
 	}
 
+	// Sorted so the same IDs always produce the same prompt (--replay
+	// looks responses up by prompt).
+	cols := make([]string, 0, len(existingIDs))
+	for col := range existingIDs {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
 	var sb strings.Builder
-	for col, ids := range existingIDs {
-		shown := ids
+	for _, col := range cols {
+		shown := existingIDs[col]
 		if len(shown) > 20 {
 			shown = shown[:20]
 		}
 		vals := make([]string, len(shown))
 		for i, v := range shown {
-			vals[i] = fmt.Sprintf("%v", v)
+			vals[i] = promptLiteral(fmt.Sprintf("%v", v))
 		}
 		sb.WriteString(fmt.Sprintf(
 			"  %s: [%s]\n",
-			col,
+			promptIdent(col),
 			strings.Join(vals, ", "),
 		))
 	}
 	return sb.String()
 }
 
+// promptIdent double-quotes names that contain spaces or punctuation so the
+// model sees "Order Items" as one identifier and uses it verbatim as a key.
+// Quoting also escapes newlines, so a name cannot start a prompt section of
+// its own. Names longer than maxPromptIdent are cut, as PostgreSQL would.
+func promptIdent(name string) string {
+	name = truncateRunes(name, maxPromptIdent)
+	for _, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+// Limits on schema and database text repeated in a prompt. They leave room
+// for any real identifier or CHECK value, not for a paragraph of
+// instructions hidden in one.
+const (
+	maxPromptIdent   = 63
+	maxPromptLiteral = 80
+)
+
+// promptLiteral renders a value taken from the schema or the database (a
+// CHECK literal, an existing key, a profiled value) for the prompt. A
+// single plain word is shown as it is; anything else is cut to
+// maxPromptLiteral and double-quoted, so the model reads it as one value
+// rather than as instructions. Spaces are not plain: "ignore the rules"
+// is a sentence, however harmless its characters.
+func promptLiteral(v string) string {
+	plain := true
+	for _, r := range v {
+		if !(r == '_' || r == '-' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			plain = false
+			break
+		}
+	}
+	if plain && len(v) <= maxPromptIdent {
+		return v
+	}
+	return strconv.Quote(truncateRunes(v, maxPromptLiteral))
+}
+
+func promptLiterals(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = promptLiteral(v)
+	}
+	return out
+}
+
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
 
+func promptIdents(names []string) []string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = promptIdent(n)
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package generator
+
+// Provenance records how a table's rows were produced, so a dataset can be
+// traced back to the model, settings and prompts behind it.
+type Provenance struct {
+	Model         string
+	PromptVersion string
+	Style         string
+	Domain        string // preset name; "" when none
+	NoPII         bool
+	Coverage      bool
+	MaxFollowUps  int
+	// Prompts holds a hash of each prompt sent, in order. A hash is also
+	// the name of the file --record saves the call under.
+	Prompts []string
+	// Replayed is set for rows taken from a recorded run rather than
+	// generated.
+	Replayed bool
+}
+
+// provenance describes a Generate call for style that sent prompts.
+func (g *Generator) provenance(style string, prompts []string) Provenance {
+	p := Provenance{
+		Model:         g.cfg.Model,
+		PromptVersion: g.cfg.PromptVersion,
+		Style:         style,
+		NoPII:         g.cfg.NoPII,
+		Coverage:      g.cfg.Coverage,
+		MaxFollowUps:  g.cfg.MaxFollowUps,
+		Prompts:       make([]string, len(prompts)),
+	}
+	if p.PromptVersion == "" {
+		p.PromptVersion = DefaultPromptVersion
+	}
+	if g.cfg.Domain != nil {
+		p.Domain = g.cfg.Domain.Name
+	}
+	for i, prompt := range prompts {
+		p.Prompts[i] = recordingKey(g.cfg.Model, prompt)
+	}
+	return p
+}
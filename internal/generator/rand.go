@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"math/rand/v2"
+	"sync"
+)
+
+// NewRand returns a Rand drawing from src that is safe for concurrent
+// use, as Config.Rand must be: the pipeline generates one table while it
+// inserts another.
+func NewRand(src rand.Source) *rand.Rand {
+	return rand.New(&lockedSource{src: src})
+}
+
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+// globalSource draws from math/rand/v2's top-level source.
+type globalSource struct{}
+
+func (globalSource) Uint64() uint64 { return rand.Uint64() }
+
+var globalRand = rand.New(globalSource{})
+
+// Random returns c.Rand, or a Rand backed by math/rand/v2's top-level
+// source when it is nil.
+func (c Config) Random() *rand.Rand {
+	if c.Rand != nil {
+		return c.Rand
+	}
+	return globalRand
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/satyammistari/db-seed-ai/internal/cache"
+)
+
+// recording is one saved model call. Calls are keyed by a hash of the
+// model and prompt, so a replay only needs the same schema, flags and
+// database contents to hit every recording.
+type recording struct {
+	Model    string `json:"model"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+	Usage    Usage  `json:"usage"`
+}
+
+// recordingKey names the file a call is stored under.
+func recordingKey(model, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\n" + prompt))
+	return hex.EncodeToString(sum[:8])
+}
+
+func recordingPath(dir, model, prompt string) string {
+	return filepath.Join(dir, recordingKey(model, prompt)+".json")
+}
+
+// saveRecording stores a successful call in dir.
+func saveRecording(dir, model, prompt, response string, usage Usage) error {
+	data, err := json.MarshalIndent(recording{Model: model, Prompt: prompt, Response: response, Usage: usage}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(recordingPath(dir, model, prompt), data, 0o644); err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	return nil
+}
+
+// loadRecording returns the recorded response for a call. A prompt that
+// was never recorded is an error: replay never falls back to the model.
+func loadRecording(dir, model, prompt string) (string, Usage, error) {
+	path := recordingPath(dir, model, prompt)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", Usage{}, fmt.Errorf("replay: no recorded response for this prompt (%s); re-record with --record", filepath.Base(path))
+	}
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("replay: %w", err)
+	}
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return "", Usage{}, fmt.Errorf("replay %s: %w", filepath.Base(path), err)
+	}
+	return rec.Response, rec.Usage, nil
+}
+
+// loadCached returns the call cache holds for model and prompt. An entry
+// for another prompt that happens to share the key is a miss.
+func loadCached(c cache.Backend, model, prompt string) (rec recording, ok bool, err error) {
+	data, ok, err := c.Get(context.Background(), recordingKey(model, prompt)+".json")
+	if err != nil || !ok {
+		return recording{}, false, err
+	}
+	if json.Unmarshal(data, &rec) != nil || rec.Model != model || rec.Prompt != prompt {
+		return recording{}, false, nil
+	}
+	return rec, true, nil
+}
+
+// saveCached stores a successful call in cache, in the format of a
+// recording, so a cache directory also works as a --replay directory.
+func saveCached(c cache.Backend, model, prompt, response string, usage Usage) error {
+	data, err := json.MarshalIndent(recording{Model: model, Prompt: prompt, Response: response, Usage: usage}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return c.Put(context.Background(), recordingKey(model, prompt)+".json", data)
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/cache"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestRecordReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response":"[{\"name\":\"Ada\"},{\"name\":\"Linus\"}]","prompt_eval_count":10,"eval_count":5}`)
+	}))
+	table := &schema.Table{Name: "people", Columns: []schema.Column{{Name: "name", Type: "text"}}}
+	ids := map[string][]interface{}{"b_id": {1, 2}, "a_id": {3}}
+	dir := t.TempDir()
+
+	cfg := DefaultConfig()
+	cfg.OllamaURL = srv.URL
+	cfg.RecordDir = dir
+	recorded, err := New(cfg).Generate(table, 2, nil, "realistic", ids)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	srv.Close()
+
+	cfg.RecordDir, cfg.ReplayDir = "", dir
+	replayed, err := New(cfg).Generate(table, 2, nil, "realistic", ids)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(replayed.Rows) != 2 || replayed.Rows[1]["name"] != "Linus" {
+		t.Errorf("replayed rows: %v", replayed.Rows)
+	}
+	if replayed.Usage != recorded.Usage {
+		t.Errorf("usage: recorded %+v, replayed %+v", recorded.Usage, replayed.Usage)
+	}
+
+	_, err = New(cfg).Generate(table, 3, nil, "realistic", ids)
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected a missing-recording error, got %v", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"response":"[{\"name\":\"Ada\"}]","prompt_eval_count":10,"eval_count":5}`)
+	}))
+	defer srv.Close()
+	table := &schema.Table{Name: "people", Columns: []schema.Column{{Name: "name", Type: "text"}}}
+
+	cfg := DefaultConfig()
+	cfg.OllamaURL = srv.URL
+	cfg.Cache = cache.Dir(t.TempDir())
+	first, err := New(cfg).Generate(table, 1, nil, "realistic", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New(cfg).Generate(table, 1, nil, "realistic", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the second run to be answered by the cache, got %d model calls", calls)
+	}
+	if second.Rows[0]["name"] != "Ada" || second.Usage.CacheHits != 1 || second.Usage.EvalTokens != 0 || first.Usage.CacheHits != 0 {
+		t.Errorf("unexpected cached result: %v, usage %+v", second.Rows, second.Usage)
+	}
+
+	// A cache hit is recorded too, so the recording replays without the cache.
+	cfg.RecordDir = t.TempDir()
+	if _, err := New(cfg).Generate(table, 1, nil, "realistic", nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Cache, cfg.ReplayDir = nil, cfg.RecordDir
+	replayed, err := New(cfg).Generate(table, 1, nil, "realistic", nil)
+	if err != nil {
+		t.Fatalf("replay of a cache hit: %v", err)
+	}
+	if calls != 1 || replayed.Rows[0]["name"] != "Ada" || replayed.Usage.EvalTokens != first.Usage.EvalTokens {
+		t.Errorf("unexpected replay of a cache hit: %v, usage %+v, %d model calls", replayed.Rows, replayed.Usage, calls)
+	}
+}
+
+func TestCacheSkipsUnparsedReplies(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			fmt.Fprint(w, `{"response":"Sure! Here are your rows:","eval_count":5}`)
+			return
+		}
+		fmt.Fprint(w, `{"response":"[{\"name\":\"Ada\"}]","eval_count":5}`)
+	}))
+	defer srv.Close()
+	table := &schema.Table{Name: "people", Columns: []schema.Column{{Name: "name", Type: "text"}}}
+
+	cfg := DefaultConfig()
+	cfg.OllamaURL = srv.URL
+	cfg.Cache = cache.Dir(t.TempDir())
+	cfg.RecordDir = t.TempDir()
+	if _, err := New(cfg).Generate(table, 1, nil, "realistic", nil); err == nil {
+		t.Fatal("expected a parse error for a reply without rows")
+	}
+	for i := 0; i < 2; i++ {
+		res, err := New(cfg).Generate(table, 1, nil, "realistic", nil)
+		if err != nil || len(res.Rows) != 1 {
+			t.Fatalf("run %d after a bad reply: %v, %v", i+2, res, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected the bad reply to be asked again once and the good one cached, got %d model calls", calls)
+	}
+	cfg.Cache, cfg.ReplayDir, cfg.RecordDir = nil, cfg.RecordDir, ""
+	if res, err := New(cfg).Generate(table, 1, nil, "realistic", nil); err != nil || res.Rows[0]["name"] != "Ada" {
+		t.Errorf("replay should hold the good reply: %v, %v", res, err)
+	}
+}
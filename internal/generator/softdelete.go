@@ -0,0 +1,21 @@
+package generator
+
+import "github.com/satyammistari/db-seed-ai/internal/schema"
+
+// DefaultSoftDeleted is the share of rows soft-deleted in a table with a
+// deleted_at or is_deleted column when Config.SoftDeleted has no rate for
+// it: most rows are live, as in a real database.
+const DefaultSoftDeleted = 0.05
+
+// softDeleteShare returns the share of t's rows to soft-delete, looked up
+// in rates by t's deleted_at column, or its flag if it has none.
+func softDeleteShare(t *schema.Table, rates map[string]float64) float64 {
+	at, flag, _ := schema.SoftDeleteColumns(t)
+	if at == "" {
+		at = flag
+	}
+	if r, ok := lookupNullRate(rates, t.Name, at); ok {
+		return r
+	}
+	return DefaultSoftDeleted
+}
@@ -0,0 +1,237 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// translationSuffixes end the names of i18n tables such as
+// product_translations.
+var translationSuffixes = []string{"_translations", "_translation", "_i18n", "_localizations", "_locales"}
+
+// Translation is an i18n table that holds its parent's text once per
+// locale, e.g. product_translations (product_id, locale, name,
+// description) for products.
+type Translation struct {
+	Parent  *schema.Table
+	FK      string   // the column referencing Parent
+	Ref     string   // the Parent column FK references
+	Locale  string   // the language column, see isLanguageColumn
+	Columns []string // Parent's text columns the model translates
+
+	// Sources are the parent rows to translate, with Ref and Columns.
+	Sources []map[string]interface{}
+}
+
+// TranslationOf reports whether t is a translation table in s: named like
+// product_translations, with a language column and a foreign key to the
+// table it translates. Sources is left for the caller to fill.
+func TranslationOf(t *schema.Table, s *schema.Schema) (Translation, bool) {
+	name := strings.ToLower(t.Name)
+	base := ""
+	for _, suffix := range translationSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			base = strings.TrimSuffix(name, suffix)
+		}
+	}
+	locale := languageColumn(t)
+	if base == "" || locale == "" || s == nil {
+		return Translation{}, false
+	}
+	var tr Translation
+	for _, col := range t.FKColumns() {
+		fk := col.ForeignKey
+		if fk == nil || fk.RefTable == t.Name {
+			continue
+		}
+		parent := s.TableMap[fk.RefTable]
+		if parent == nil {
+			continue
+		}
+		if tr.Parent == nil || strings.HasPrefix(strings.ToLower(fk.RefTable), base) {
+			tr = Translation{Parent: parent, FK: col.Name, Ref: fk.RefColumn, Locale: locale}
+		}
+	}
+	if tr.Parent == nil {
+		return Translation{}, false
+	}
+	own := make(map[string]bool)
+	for _, col := range t.Columns {
+		own[col.Name] = true
+	}
+	var all []string
+	for _, col := range tr.Parent.Columns {
+		if col.Type != "text" || col.ForeignKey != nil || col.PrimaryKey || isLanguageColumn(col) {
+			continue
+		}
+		all = append(all, col.Name)
+		if own[col.Name] {
+			tr.Columns = append(tr.Columns, col.Name)
+		}
+	}
+	if len(tr.Columns) == 0 {
+		tr.Columns = all
+	}
+	return tr, len(tr.Columns) > 0
+}
+
+// Translate generates tr's table from its sources: one row per source row
+// and locale, whose text is the source's text translated. Each locale is
+// asked for in its own prompt, with follow-ups for source rows the model
+// skipped. The rows then go through the same passes as Generate's.
+func (g *Generator) Translate(table *schema.Table, tr Translation, locales []string, style string) (*GenerationResult, error) {
+	start := g.clock.Now()
+	pc := g.promptContext()
+	colNames := nonAutoColNames(table)
+	requested := len(tr.Sources) * len(locales)
+	progress := Progress{Table: table.Name, Kind: ProgressRequest, Chunk: 1, Requested: requested}
+	var prompts []string
+	var usage Usage
+	var rows []map[string]interface{}
+	followUps, retries := 0, 0
+	for _, locale := range locales {
+		todo := tr.Sources
+		for attempt := 0; len(todo) > 0 && attempt <= g.cfg.MaxFollowUps; attempt++ {
+			if attempt > 0 {
+				followUps++
+			}
+			progress.Kind, progress.Err = ProgressRequest, nil
+			g.report(progress)
+			prompt := translationPrompt(table, tr, todo, locale, pc)
+			prompts = append(prompts, prompt)
+			raw, u, err := g.client.Generate(prompt)
+			if err != nil {
+				if len(rows) == 0 {
+					return nil, fmt.Errorf("generate for %s: %w", table.Name, err)
+				}
+				break
+			}
+			usage.Add(u)
+			got, err := ParseJSONRows(raw, colNames)
+			if err != nil {
+				retries++
+				progress.Kind, progress.Err = ProgressRetry, err
+				g.report(progress)
+				continue
+			}
+			var done []map[string]interface{}
+			done, todo = matchTranslations(got, todo, tr, locale)
+			rows = append(rows, done...)
+			progress.Kind, progress.Rows, progress.Chunk = ProgressRows, len(rows), progress.Chunk+1
+			g.report(progress)
+		}
+	}
+	keys := make([][2]interface{}, len(rows))
+	for i, row := range rows {
+		keys[i] = [2]interface{}{row[tr.FK], row[tr.Locale]}
+	}
+	existingIDs := map[string][]interface{}{tr.FK: sourceIDs(tr)}
+	kept := rows
+	rows, repairs, coverage, err := g.finish(table, rows, existingIDs, pc, start)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range kept {
+		row[tr.FK], row[tr.Locale] = keys[i][0], keys[i][1] // no pass may move a translation
+	}
+	progress.Kind, progress.Rows, progress.Err = ProgressDone, len(rows), nil
+	g.report(progress)
+
+	return &GenerationResult{
+		TableName: table.Name,
+		Columns:   colNames,
+		Rows:      rows,
+		Requested: requested,
+		FollowUps: followUps,
+		Retries:   retries,
+		Repairs:   repairs,
+		Coverage:  coverage,
+		Usage:     usage,
+		Elapsed:   g.clock.Now().Sub(start),
+
+		Provenance: g.provenance(style, prompts),
+	}, nil
+}
+
+// matchTranslations pairs the model's rows with the source rows by their
+// FK value, or by position when the model left it out, and sets their FK
+// and locale. It returns the translations and the sources still without
+// one.
+func matchTranslations(got, sources []map[string]interface{}, tr Translation, locale string) (done, todo []map[string]interface{}) {
+	bySource := make(map[string]map[string]interface{}, len(got))
+	for i, row := range got {
+		key := fmt.Sprint(row[tr.FK])
+		if row[tr.FK] == nil && i < len(sources) {
+			key = fmt.Sprint(sources[i][tr.Ref])
+		}
+		if _, dup := bySource[key]; !dup {
+			bySource[key] = row
+		}
+	}
+	for _, src := range sources {
+		row, ok := bySource[fmt.Sprint(src[tr.Ref])]
+		if !ok {
+			todo = append(todo, src)
+			continue
+		}
+		row[tr.FK], row[tr.Locale] = src[tr.Ref], locale
+		done = append(done, row)
+	}
+	return done, todo
+}
+
+func sourceIDs(tr Translation) []interface{} {
+	ids := make([]interface{}, len(tr.Sources))
+	for i, src := range tr.Sources {
+		ids[i] = src[tr.Ref]
+	}
+	return ids
+}
+
+// translationPrompt asks for the translation of sources into locale.
+func translationPrompt(table *schema.Table, tr Translation, sources []map[string]interface{}, locale string, pc promptContext) string {
+	src := make([]map[string]interface{}, len(sources))
+	for i, row := range sources {
+		src[i] = map[string]interface{}{tr.Ref: row[tr.Ref]}
+		for _, col := range tr.Columns {
+			src[i][col] = row[col]
+		}
+	}
+	data, _ := json.MarshalIndent(src, "", "  ")
+	return fmt.Sprintf(`You are translating database content.
+Translate each row of table %s below into %s (%s) and return one row of table %s for it.
+
+TABLE NAME: %s
+
+COLUMNS (what each column needs):
+%s
+SOURCE ROWS (%s):
+%s
+
+RULES YOU MUST FOLLOW STRICTLY:
+- Return exactly %d objects, one per source row, in the same order
+- Set %s to the source row's %s
+- Translate %s from the source row faithfully; keep brand names, numbers and units; if a text is already in %s, keep it as it is
+- Fill any other column so it matches the translated text
+- Leave out %s, it is filled in automatically
+%s%s
+OUTPUT RULES — FOLLOW EXACTLY:
+- Return ONLY the JSON array, starting with [ and ending with ]
+- No markdown, no backticks, no text before or after the array
+
+Generate the JSON array with exactly %d rows for table %s now:`,
+		promptIdent(tr.Parent.Name), LanguageName(locale), locale, promptIdent(table.Name),
+		promptIdent(table.Name),
+		formatColumnDefs(table, pc.version),
+		promptIdent(tr.Parent.Name), data,
+		len(sources),
+		promptIdent(tr.FK), promptIdent(tr.Ref),
+		strings.Join(promptIdents(tr.Columns), ", "), LanguageName(locale),
+		promptIdent(tr.Locale),
+		formatLengths(table, pc.lengths), formatRichText(table),
+		len(sources), promptIdent(table.Name),
+	)
+}
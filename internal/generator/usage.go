@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"fmt"
+	"time"
+)
+
+// Usage records the token counts and model time reported for one or more
+// generate calls. Ollama returns these as prompt_eval_count / eval_count.
+type Usage struct {
+	PromptTokens int
+	EvalTokens   int
+	Duration     time.Duration
+	Calls        int
+	CacheHits    int // calls answered by Config.Cache; they count no tokens
+}
+
+// Add accumulates another Usage into u.
+func (u *Usage) Add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.EvalTokens += o.EvalTokens
+	u.Duration += o.Duration
+	u.Calls += o.Calls
+	u.CacheHits += o.CacheHits
+}
+
+// TotalTokens returns prompt + eval tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.EvalTokens
+}
+
+// Pricing is the price in USD per million tokens for a hosted model.
+// The zero value means the model is free (local Ollama).
+type Pricing struct {
+	PromptPerMillion float64
+	EvalPerMillion   float64
+}
+
+// IsZero reports whether no price was configured.
+func (p Pricing) IsZero() bool {
+	return p.PromptPerMillion == 0 && p.EvalPerMillion == 0
+}
+
+// Cost estimates the USD cost of u under pricing p.
+func (u Usage) Cost(p Pricing) float64 {
+	return float64(u.PromptTokens)/1e6*p.PromptPerMillion +
+		float64(u.EvalTokens)/1e6*p.EvalPerMillion
+}
+
+// String formats usage for summaries, e.g. "1203 in / 4410 out tokens in
+// 12s", followed by ", 3 cached" when the cache answered some calls.
+func (u Usage) String() string {
+	s := fmt.Sprintf("%d in / %d out tokens in %s",
+		u.PromptTokens, u.EvalTokens, u.Duration.Round(time.Second))
+	if u.CacheHits > 0 {
+		s += fmt.Sprintf(", %d cached", u.CacheHits)
+	}
+	return s
+}
@@ -0,0 +1,354 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/derive"
+	"github.com/satyammistari/db-seed-ai/internal/interp"
+	"github.com/satyammistari/db-seed-ai/internal/money"
+	"github.com/satyammistari/db-seed-ai/internal/phone"
+	"github.com/satyammistari/db-seed-ai/internal/repair"
+	"github.com/satyammistari/db-seed-ai/internal/rules"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/stats"
+	"github.com/satyammistari/db-seed-ai/internal/token"
+)
+
+// ColumnValues is a user-supplied list of values for one column, e.g. the
+// company's product categories or department names.
+type ColumnValues struct {
+	Values []string
+	// Direct fills the column from Values after generation instead of
+	// asking the model to choose among them. Only direct values may hold
+	// {{ }} expressions, such as {{ uuid }}; see package interp.
+	Direct bool
+}
+
+// lookupValues finds the values configured for a column. Keys are either
+// "table.column" or a bare "column" that applies to every table; the
+// qualified key wins.
+func lookupValues(values map[string]ColumnValues, table, column string) (ColumnValues, bool) {
+	if v, ok := values[table+"."+column]; ok {
+		return v, true
+	}
+	v, ok := values[column]
+	return v, ok
+}
+
+// formatColumnValues lists the configured values for the table's columns.
+// Example output:
+//
+//	VALUE LISTS (use ONLY these values for these columns):
+//	  - category: Books | Games | Garden
+//	  - department: filled in automatically, leave it out
+func formatColumnValues(t *schema.Table, values map[string]ColumnValues) string {
+	var lines []string
+	for _, col := range t.NonAutoColumns() {
+		v, ok := lookupValues(values, t.Name, col.Name)
+		if !ok || len(v.Values) == 0 {
+			continue
+		}
+		if len(col.CheckIn) > 0 && !v.Direct {
+			continue // the CHECK list already says what is allowed
+		}
+		if v.Direct {
+			lines = append(lines, fmt.Sprintf("  - %s: filled in automatically, leave it out", promptIdent(col.Name)))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  - %s: %s", promptIdent(col.Name), strings.Join(v.Values, " | ")))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\nVALUE LISTS (use ONLY these values for these columns):\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// applyDirectValues fills Direct columns by cycling through their values,
+// so every value is used about equally often. Values with {{ }}
+// expressions (see package interp) are expanded for each row.
+func applyDirectValues(t *schema.Table, rows []map[string]interface{}, values map[string]ColumnValues, env interp.Env) error {
+	for _, col := range t.NonAutoColumns() {
+		v, ok := lookupValues(values, t.Name, col.Name)
+		if !ok || !v.Direct || len(v.Values) == 0 {
+			continue
+		}
+		for i, row := range rows {
+			s, err := interp.Expand(v.Values[i%len(v.Values)], env)
+			if err != nil {
+				return fmt.Errorf("%s: %w", col.Name, err)
+			}
+			row[col.Name] = s
+		}
+	}
+	return nil
+}
+
+// lookupDerived finds the derived-column expression for a column, keyed
+// like Config.Values.
+func lookupDerived(derived map[string]*derive.Expr, table, column string) *derive.Expr {
+	if e, ok := derived[table+"."+column]; ok {
+		return e
+	}
+	return derived[column]
+}
+
+// formatDerived lists the computed columns so the model leaves them out.
+func formatDerived(t *schema.Table, derived map[string]*derive.Expr) string {
+	var names []string
+	for _, col := range t.NonAutoColumns() {
+		if lookupDerived(derived, t.Name, col.Name) != nil {
+			names = append(names, promptIdent(col.Name))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return "\nCOMPUTED COLUMNS (leave these out, they are calculated afterwards): " + strings.Join(names, ", ") + "\n"
+}
+
+// formatRules lists the conditional rules that apply to t.
+func formatRules(t *schema.Table, rs []*rules.Rule) string {
+	rs = rules.ForTable(rs, t.Name, func(c string) bool { return t.Column(c) != nil })
+	if len(rs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nCONDITIONAL RULES (every row must satisfy these):\n")
+	for _, r := range rs {
+		fmt.Fprintf(&b, "- IF %s THEN %s", r.If, r.Then)
+		if !r.Else.Empty() {
+			fmt.Fprintf(&b, ", OTHERWISE %s", r.Else)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatMoney tells the model which amounts go with which currency
+// column, so magnitudes match the currency.
+func formatMoney(t *schema.Table, currencies []string) string {
+	pairs := money.Pairs(t)
+	if len(pairs) == 0 {
+		return ""
+	}
+	codes := "ISO 4217 codes such as USD, EUR, GBP, JPY"
+	if len(currencies) > 0 {
+		codes = "ONLY these ISO 4217 codes: " + strings.Join(currencies, ", ")
+	}
+	var b strings.Builder
+	b.WriteString("\nMONEY COLUMNS:\n")
+	for _, p := range pairs {
+		fmt.Fprintf(&b, "- %s are in the currency named by %s\n",
+			strings.Join(promptIdents(p.Amounts), ", "), promptIdent(p.Currency))
+	}
+	fmt.Fprintf(&b, "- Currencies use %s\n", codes)
+	b.WriteString("- Amounts fit their currency: JPY and KRW have no decimals and are 100-1000x the USD amount\n")
+	return b.String()
+}
+
+// formatEmails names the allowed email domains when the table has email
+// columns.
+func formatEmails(t *schema.Table, domains []string) string {
+	if len(domains) == 0 {
+		return ""
+	}
+	var names []string
+	for _, col := range t.NonAutoColumns() {
+		if repair.IsEmailColumn(col) {
+			names = append(names, promptIdent(col.Name))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nEMAILS: %s must be unique and use ONLY these domains: %s\n",
+		strings.Join(names, ", "), strings.Join(domains, ", "))
+}
+
+// formatLengths gives the length target of each text column that has
+// one.
+func formatLengths(t *schema.Table, lengths map[string]repair.Length) string {
+	var lines []string
+	for _, col := range t.NonAutoColumns() {
+		l, ok := repair.LookupLength(lengths, t.Name, col.Name)
+		if !ok {
+			continue
+		}
+		line := fmt.Sprintf("- %s: %s", promptIdent(col.Name), l)
+		if l.Unit == repair.Paragraphs {
+			line += ", separated by a blank line (\\n\\n)"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\nLENGTHS (write texts of this length, longer ones are cut):\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// formatRichText asks for structured HTML or Markdown in rich-text
+// columns (see repair.RichTextFormat) instead of plain sentences.
+func formatRichText(t *schema.Table) string {
+	var lines []string
+	for _, col := range t.NonAutoColumns() {
+		switch repair.RichTextFormat(col) {
+		case repair.RichHTML:
+			lines = append(lines, fmt.Sprintf(`- %s: an HTML snippet (no <html> or <body>) with an <h2> heading, <p> paragraphs, a <ul> or <ol> list and an <a href="https://..."> link; use only h2, h3, p, ul, ol, li, a, strong, em, code and blockquote, and no attributes but href`, promptIdent(col.Name)))
+		case repair.RichMarkdown:
+			lines = append(lines, fmt.Sprintf("- %s: Markdown with a ## heading, paragraphs, a - list and a [link](https://...), lines separated by \\n", promptIdent(col.Name)))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\nRICH TEXT (write formatted content, not plain sentences):\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// formatPhones shows the model one example number per formatted column.
+func formatPhones(t *schema.Table, rules map[string]phone.Rule) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	// Run a sample row through the same repair the real rows get.
+	sample := make(map[string]interface{})
+	for _, col := range t.NonAutoColumns() {
+		sample[col.Name] = "2025550142"
+	}
+	rep := repair.Phones(t, []map[string]interface{}{sample}, rules)
+	if len(rep.Actions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nPHONE NUMBERS (use exactly this format):\n")
+	for _, a := range rep.Actions {
+		fmt.Fprintf(&b, "- %s like %v\n", promptIdent(a.Column), a.To)
+	}
+	return b.String()
+}
+
+// formatLocal names the token and image columns filled in locally (see
+// repair.Tokens and repair.Images), so the model does not spend output on
+// them.
+func formatLocal(t *schema.Table, tokens map[string]token.Rule, images map[string]string) string {
+	cols := append(repair.TokenColumns(t, tokens), repair.ImageColumns(t, images)...)
+	if len(cols) == 0 {
+		return ""
+	}
+	for i, c := range cols {
+		cols[i] = promptIdent(c)
+	}
+	return fmt.Sprintf("\nFILLED LOCALLY: %s are filled in automatically, leave them out\n", strings.Join(cols, ", "))
+}
+
+// formatStats describes the production shape of t's columns: NULL share,
+// value mix, range, typical length and how distinct values are.
+func formatStats(t *schema.Table, ts *stats.Table) string {
+	if ts == nil || ts.Rows == 0 {
+		return ""
+	}
+	var lines []string
+	for _, col := range t.NonAutoColumns() {
+		cs := ts.Columns[col.Name]
+		if cs == nil || col.ForeignKey != nil || col.Type == "binary" {
+			continue
+		}
+		var parts []string
+		if cs.NullRatio > 0 {
+			parts = append(parts, fmt.Sprintf("%s NULL", percent(cs.NullRatio)))
+		}
+		if len(cs.Top) > 0 {
+			var mix []string
+			rest := 1.0
+			for _, v := range cs.Top {
+				mix = append(mix, fmt.Sprintf("%s %s", promptLiteral(v.Value), percent(v.Share)))
+				rest -= v.Share
+			}
+			if rest >= 0.01 {
+				mix = append(mix, "other values "+percent(rest))
+			}
+			parts = append(parts, strings.Join(mix, ", "))
+		}
+		if cs.Min != "" && cs.Max != "" {
+			parts = append(parts, fmt.Sprintf("from %s to %s", promptLiteral(cs.Min), promptLiteral(cs.Max)))
+		}
+		if cs.AvgLen > 0 && len(cs.Top) == 0 {
+			parts = append(parts, fmt.Sprintf("about %.0f characters", cs.AvgLen))
+		}
+		if nonNull := float64(ts.Rows) * (1 - cs.NullRatio); len(cs.Top) == 0 && nonNull > 0 {
+			if float64(cs.Distinct) >= nonNull*0.99 {
+				parts = append(parts, "all distinct")
+			} else if cs.Distinct > 0 {
+				parts = append(parts, fmt.Sprintf("about %d distinct values", cs.Distinct))
+			}
+		}
+		if len(parts) > 0 {
+			lines = append(lines, fmt.Sprintf("- %s: %s", promptIdent(col.Name), strings.Join(parts, "; ")))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\nPRODUCTION DATA SHAPE (match these proportions and ranges; invent the values themselves):\n" +
+		strings.Join(lines, "\n") + "\n"
+}
+
+// percent renders a share such as 0.125 as 12.5%.
+func percent(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1000)/10, 'f', -1, 64) + "%"
+}
+
+// applyDerived computes the derived columns of t for every row. Columns
+// that depend on other derived columns are computed after them. A row whose
+// inputs do not fit the expression (text where a number is needed) keeps
+// the model's value. Changed values are returned as repair actions.
+func applyDerived(t *schema.Table, rows []map[string]interface{}, derived map[string]*derive.Expr) []repair.Action {
+	exprs := make(map[string]*derive.Expr)
+	for _, col := range t.NonAutoColumns() {
+		if e := lookupDerived(derived, t.Name, col.Name); e != nil {
+			exprs[col.Name] = e
+		}
+	}
+	if len(exprs) == 0 {
+		return nil
+	}
+	var order []string
+	state := make(map[string]int) // 1 visiting, 2 done
+	var visit func(name string)
+	visit = func(name string) {
+		if state[name] != 0 {
+			return // done, or a cycle: evaluate in whatever order we reached
+		}
+		state[name] = 1
+		for _, dep := range exprs[name].Columns() {
+			if _, ok := exprs[dep]; ok {
+				visit(dep)
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+	}
+	for _, col := range t.NonAutoColumns() {
+		if _, ok := exprs[col.Name]; ok {
+			visit(col.Name)
+		}
+	}
+
+	var actions []repair.Action
+	for i, row := range rows {
+		for _, name := range order {
+			v, err := exprs[name].Eval(row)
+			if err != nil {
+				continue
+			}
+			old, had := row[name]
+			row[name] = v
+			if had && fmt.Sprint(old) != fmt.Sprint(v) {
+				actions = append(actions, repair.Action{Row: i + 1, Column: name, Kind: repair.KindDerived, From: old, To: v})
+			}
+		}
+	}
+	return actions
+}
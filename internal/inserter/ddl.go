@@ -0,0 +1,126 @@
+package inserter
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+var (
+	createTableRe     = regexp.MustCompile(`(?i)^\s*CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?`)
+	serialPKRe        = regexp.MustCompile(`(?i)\b(BIG|SMALL)?SERIAL\s+PRIMARY\s+KEY\b`)
+	serialRe          = regexp.MustCompile(`(?i)\b(BIG|SMALL)?SERIAL\b`)
+	autoincrementPKRe = regexp.MustCompile(`(?i)\bINTEGER\s+PRIMARY\s+KEY\s+AUTOINCREMENT\b`)
+	autoincrementRe   = regexp.MustCompile(`(?i)\s+AUTOINCREMENT\b`)
+	pgCastRe          = regexp.MustCompile(`::\s*\w+(\s*\[\])?`)
+)
+
+// ddlRule rewrites one dialect-specific construct.
+type ddlRule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+func rule(pattern, repl string) ddlRule {
+	return ddlRule{re: regexp.MustCompile(`(?i)` + pattern), repl: repl}
+}
+
+// toSQLite maps Postgres-only types and defaults to SQLite equivalents.
+// SQLite accepts most type names, but functions such as now() and
+// gen_random_uuid() fail at CREATE time.
+var toSQLite = []ddlRule{
+	rule(`\bTIMESTAMP\s+WITH(OUT)?\s+TIME\s+ZONE\b`, "TIMESTAMP"),
+	rule(`\bTIMESTAMPTZ\b`, "TIMESTAMP"),
+	rule(`\bJSONB?\b`, "TEXT"),
+	rule(`\bUUID\b`, "TEXT"),
+	rule(`\bBYTEA\b`, "BLOB"),
+	rule(`\bDOUBLE\s+PRECISION\b`, "REAL"),
+	rule(`\bCITEXT\b`, "TEXT COLLATE NOCASE"),
+	rule(`\bDEFAULT\s+(NOW|CURRENT_TIMESTAMP)\s*\(\s*\)`, "DEFAULT CURRENT_TIMESTAMP"),
+	rule(`\bDEFAULT\s+(gen_random_uuid|uuid_generate_v4)\s*\(\s*\)`, ""),
+	rule(`\bDEFAULT\s+TRUE\b`, "DEFAULT 1"),
+	rule(`\bDEFAULT\s+FALSE\b`, "DEFAULT 0"),
+}
+
+// toPostgres maps SQLite/MySQL-isms to Postgres equivalents.
+var toPostgres = []ddlRule{
+	rule(`\bDEFAULT\s+\(?\s*datetime\s*\(\s*'now'\s*\)\s*\)?`, "DEFAULT CURRENT_TIMESTAMP"),
+	rule(`\bDATETIME\b`, "TIMESTAMP"),
+	rule(`\bBLOB\b`, "BYTEA"),
+	rule(`\bTINYINT\s*\(\s*1\s*\)`, "BOOLEAN"),
+	rule(`\bTINYINT\b`, "SMALLINT"),
+	rule(`\bDOUBLE\b(\s+PRECISION)?`, "DOUBLE PRECISION"),
+	rule(`\bCOLLATE\s+NOCASE\b`, ""),
+}
+
+// TranslateDDL rewrites a CREATE TABLE statement for the target driver
+// ("pgx" or "sqlite3") and makes it idempotent with IF NOT EXISTS.
+// SQLite only treats "INTEGER PRIMARY KEY" as an auto-increment rowid, so
+// SERIAL keys must be rewritten or every generated row gets a NULL id.
+func TranslateDDL(ddl, driver string) string {
+	ddl = createTableRe.ReplaceAllString(ddl, "CREATE TABLE IF NOT EXISTS ")
+	if driver == "sqlite3" {
+		ddl = serialPKRe.ReplaceAllString(ddl, "INTEGER PRIMARY KEY AUTOINCREMENT")
+		ddl = serialRe.ReplaceAllString(ddl, "INTEGER")
+		ddl = pgCastRe.ReplaceAllString(ddl, "")
+		return applyRules(ddl, toSQLite)
+	}
+	ddl = autoincrementPKRe.ReplaceAllString(ddl, "SERIAL PRIMARY KEY")
+	ddl = autoincrementRe.ReplaceAllString(ddl, "")
+	return applyRules(ddl, toPostgres)
+}
+
+func applyRules(ddl string, rules []ddlRule) string {
+	for _, r := range rules {
+		ddl = r.re.ReplaceAllString(ddl, r.repl)
+	}
+	return ddl
+}
+
+// CreateTables executes the schema's CREATE TABLE statements in dependency
+// order. Tables that already exist are left untouched.
+func CreateTables(db *sql.DB, driver string, tables []*schema.Table) error {
+	for _, t := range tables {
+		if strings.TrimSpace(t.DDL) == "" {
+			continue
+		}
+		stmt := TranslateDDL(t.DDL, driver)
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("create table %s: %w\nSQL: %s", t.Name, err, stmt)
+		}
+	}
+	return nil
+}
+
+// RefreshMaterializedViews runs REFRESH MATERIALIZED VIEW for each view, in
+// order. SQLite has no materialized views, so it returns an error there.
+func RefreshMaterializedViews(db *sql.DB, driver string, views []schema.View) error {
+	if driver == "sqlite3" {
+		return fmt.Errorf("sqlite does not support materialized views")
+	}
+	for _, v := range views {
+		if _, err := db.Exec("REFRESH MATERIALIZED VIEW " + quoteTable(v.Name)); err != nil {
+			return fmt.Errorf("refresh %s: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// DisableTriggers turns off user triggers (and FK enforcement triggers) for
+// the rest of the session by setting session_replication_role = replica.
+// The setting is per connection, so the pool is pinned to one connection.
+// Postgres only; the role needs superuser or the replication privilege.
+func DisableTriggers(db *sql.DB, driver string) error {
+	if driver == "sqlite3" {
+		return fmt.Errorf("--disable-triggers is only supported on Postgres")
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	if _, err := db.Exec("SET session_replication_role = replica"); err != nil {
+		return fmt.Errorf("disable triggers: %w", err)
+	}
+	return nil
+}
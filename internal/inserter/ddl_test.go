@@ -0,0 +1,24 @@
+package inserter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTranslateDDL(t *testing.T) {
+	pg := `CREATE TABLE events (id SERIAL PRIMARY KEY, payload JSONB NOT NULL DEFAULT '{}'::jsonb, at TIMESTAMPTZ DEFAULT now())`
+	got := TranslateDDL(pg, "sqlite3")
+	for _, want := range []string{"IF NOT EXISTS", "id INTEGER PRIMARY KEY AUTOINCREMENT", "payload TEXT", "DEFAULT '{}',", "at TIMESTAMP DEFAULT CURRENT_TIMESTAMP"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("sqlite: missing %q in %s", want, got)
+		}
+	}
+
+	lite := `CREATE TABLE logs (id INTEGER PRIMARY KEY AUTOINCREMENT, at DATETIME DEFAULT (datetime('now')), data BLOB)`
+	got = TranslateDDL(lite, "pgx")
+	for _, want := range []string{"id SERIAL PRIMARY KEY", "at TIMESTAMP DEFAULT CURRENT_TIMESTAMP", "data BYTEA"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("postgres: missing %q in %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package inserter
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InMemory reports whether conn names an in-memory SQLite database, such
+// as "sqlite::memory:", which lives only as long as the connection.
+func InMemory(conn string) bool {
+	driver, dsn := parseConn(conn)
+	if driver != "sqlite3" {
+		return false
+	}
+	return dsn == ":memory:" || strings.HasPrefix(dsn, "file::memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// Export writes the SQLite database db to path: a SQL dump, as the sqlite3
+// shell's .dump prints, when path ends in .sql, and a copy of the database
+// file otherwise. It is how an in-memory database is kept. path is replaced
+// only once the export is complete.
+func Export(db *sql.DB, path string) error {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	_ = os.Remove(tmp)
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".sql") {
+		err = dumpFile(db, tmp)
+	} else {
+		_, err = db.Exec("VACUUM INTO ?", tmp)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("export %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("export %s: %w", path, err)
+	}
+	return nil
+}
+
+func dumpFile(db *sql.DB, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Dump(db, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Dump writes the schema and rows of the SQLite database db to w as SQL
+// statements that recreate it: every table followed by its rows, then the
+// AUTOINCREMENT counters, then indexes, triggers and views.
+func Dump(db *sql.DB, w io.Writer) error {
+	objects, err := db.Query(`SELECT type, name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	type object struct{ typ, name, sql string }
+	var all []object
+	for objects.Next() {
+		var o object
+		if err := objects.Scan(&o.typ, &o.name, &o.sql); err != nil {
+			objects.Close()
+			return err
+		}
+		all = append(all, o)
+	}
+	objects.Close()
+	if err := objects.Err(); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\n")
+	for _, o := range all {
+		if o.typ != "table" {
+			continue
+		}
+		bw.WriteString(o.sql + ";\n")
+		if err := dumpRows(db, bw, o.name); err != nil {
+			return err
+		}
+	}
+	var seq int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'sqlite_sequence'").Scan(&seq); err != nil {
+		return err
+	}
+	if seq > 0 {
+		bw.WriteString("DELETE FROM sqlite_sequence;\n")
+		if err := dumpRows(db, bw, "sqlite_sequence"); err != nil {
+			return err
+		}
+	}
+	for _, o := range all {
+		if o.typ != "table" {
+			bw.WriteString(o.sql + ";\n")
+		}
+	}
+	bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// dumpRows writes one INSERT statement per row of table. SQLite's quote()
+// formats each value, so it reads back exactly as stored.
+func dumpRows(db *sql.DB, w *bufio.Writer, table string) error {
+	cols, err := queryValues(db, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		if b, ok := c.([]byte); ok {
+			c = string(b)
+		}
+		quoted[i] = "quote(" + quoteIdent(fmt.Sprint(c)) + ")"
+	}
+	rows, err := db.Query("SELECT " + strings.Join(quoted, " || ',' || ") + " FROM " + quoteIdent(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var values string
+		if err := rows.Scan(&values); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "INSERT INTO %s VALUES(%s);\n", quoteIdent(table), values)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,77 @@
+package inserter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	if !InMemory("sqlite::memory:") || InMemory("sqlite:./dev.db") || InMemory("postgres://localhost/memory") {
+		t.Fatal("InMemory: wrong answer")
+	}
+	ins, err := Connect("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ins.Close()
+	// More than one connection would see an empty database of its own.
+	if _, err := ins.DB().Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE, avatar BLOB, created_at DATETIME);
+		CREATE INDEX users_created ON users (created_at);`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ins.InsertBatch("users", []string{"email", "avatar", "created_at"}, []map[string]interface{}{
+		{"email": "o'brien@x.io", "avatar": []byte{0xca, 0xfe}, "created_at": "2024-03-01T09:30:00Z"},
+		{"email": "b@x.io"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	dbPath, sqlPath := filepath.Join(dir, "seed.db"), filepath.Join(dir, "seed.sql")
+	for _, path := range []string{dbPath, sqlPath} {
+		if err := Export(ins.DB(), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dump, err := os.ReadFile(sqlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`INSERT INTO "users" VALUES(1,'o''brien@x.io',X'CAFE','2024-03-01T09:30:00Z');`,
+		`INSERT INTO "sqlite_sequence" VALUES('users',2);`,
+		"CREATE INDEX users_created ON users (created_at);\nCOMMIT;\n",
+	} {
+		if !strings.Contains(string(dump), want) {
+			t.Errorf("dump lacks %q:\n%s", want, dump)
+		}
+	}
+
+	file, err := Connect("sqlite:" + dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	replayed, err := Connect("sqlite::memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayed.Close()
+	if _, err := replayed.DB().Exec(string(dump)); err != nil {
+		t.Fatalf("replaying the dump: %v", err)
+	}
+	for name, db := range map[string]*Inserter{"file": file, "dump": replayed} {
+		var n int
+		var email string
+		if err := db.DB().QueryRow("SELECT COUNT(*), MIN(email) FROM users").Scan(&n, &email); err != nil || n != 2 || email != "b@x.io" {
+			t.Errorf("%s: %d rows, %q, %v", name, n, email, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,59 @@
+package inserter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FindRow returns the first row of table whose columns equal the values
+// in match, with every column of the table, or nil when there is none.
+func (in *Inserter) FindRow(table string, match map[string]interface{}) (map[string]interface{}, error) {
+	cols := make([]string, 0, len(match))
+	for c := range match {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+	var where []string
+	args := make([]interface{}, len(cols))
+	for i, c := range cols {
+		ph := "?"
+		if in.driver != "sqlite3" {
+			ph = fmt.Sprintf("$%d", i+1)
+		}
+		where = append(where, quoteIdent(c)+" = "+ph)
+		args[i] = match[c]
+	}
+	query := "SELECT * FROM " + quoteTable(table)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := in.db.Query(query+" LIMIT 1", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, len(names))
+	ptrs := make([]interface{}, len(names))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		if b, ok := vals[i].([]byte); ok {
+			vals[i] = string(b)
+		}
+		row[name] = vals[i]
+	}
+	return row, nil
+}
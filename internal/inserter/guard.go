@@ -0,0 +1,147 @@
+package inserter
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultGuardTable is the marker table that flags a database as one
+// seeddb must not write to. Creating it, with any columns, protects a
+// database whatever its host is called.
+const DefaultGuardTable = "seeddb_production_guard"
+
+// ConnHosts returns the hosts a Postgres connection string points at, from
+// a URL or the key=value form, falling back to PGHOST. It is empty for
+// SQLite and for a local socket.
+func ConnHosts(conn string) []string {
+	if driver, _ := parseConn(conn); driver != "pgx" {
+		return nil
+	}
+	var hosts string
+	if u, err := url.Parse(conn); err == nil && u.Scheme != "" {
+		hosts = u.Host
+		if hosts == "" {
+			hosts = u.Query().Get("host")
+		}
+	} else {
+		hosts = connParam(conn, "host")
+	}
+	if hosts == "" {
+		hosts = os.Getenv("PGHOST")
+	}
+	var out []string
+	for _, h := range strings.Split(hosts, ",") {
+		if host, _, err := net.SplitHostPort(h); err == nil {
+			h = host
+		}
+		if h = strings.Trim(h, "[]"); h != "" && !strings.HasPrefix(h, "/") {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// ConnName returns the database a connection string names: the Postgres
+// database (or its host when none is given), or the SQLite file.
+func ConnName(conn string) string {
+	driver, dsn := parseConn(conn)
+	if driver == "sqlite3" {
+		return dsn
+	}
+	name := ""
+	if u, err := url.Parse(conn); err == nil && u.Scheme != "" {
+		name = strings.TrimPrefix(u.Path, "/")
+	} else {
+		name = connParam(conn, "dbname")
+	}
+	if name == "" {
+		if hosts := ConnHosts(conn); len(hosts) > 0 {
+			name = hosts[0]
+		}
+	}
+	return name
+}
+
+// connParam returns key from a key=value connection string.
+func connParam(conn, key string) string {
+	for _, f := range strings.Fields(conn) {
+		if k, v, ok := strings.Cut(f, "="); ok && k == key {
+			return strings.Trim(v, "'")
+		}
+	}
+	return ""
+}
+
+// CheckHostPattern reports whether p is a usable protected-host pattern:
+// a CIDR range, or a shell glob such as *.prod.example.com.
+func CheckHostPattern(p string) error {
+	if strings.Contains(p, "/") {
+		if _, _, err := net.ParseCIDR(p); err != nil {
+			return fmt.Errorf("%q is not a CIDR range", p)
+		}
+		return nil
+	}
+	if _, err := path.Match(p, ""); err != nil {
+		return fmt.Errorf("%q: %w", p, err)
+	}
+	return nil
+}
+
+// ProtectedHost returns the first pattern host matches. Globs are matched
+// case-insensitively against the name; CIDR ranges against host itself
+// when it is an address, or else against what lookup resolves it to.
+// lookup is only called for CIDR patterns and may be nil.
+func ProtectedHost(host string, patterns []string, lookup func(string) ([]net.IP, error)) (string, bool) {
+	var ips []net.IP
+	resolved := false
+	for _, p := range patterns {
+		if _, cidr, err := net.ParseCIDR(p); err == nil {
+			if !resolved {
+				resolved = true
+				if ip := net.ParseIP(host); ip != nil {
+					ips = []net.IP{ip}
+				} else if lookup != nil {
+					ips, _ = lookup(host)
+				}
+			}
+			for _, ip := range ips {
+				if cidr.Contains(ip) {
+					return p, true
+				}
+			}
+			continue
+		}
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(host)); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// Protected explains why the database conn points at must not be written
+// to: a host matching one of hosts (see ProtectedHost), or the marker table
+// (DefaultGuardTable when marker is empty). The reason is empty when
+// neither applies.
+func Protected(db *sql.DB, driver, conn string, hosts []string, marker string) (string, error) {
+	for _, host := range ConnHosts(conn) {
+		if p, ok := ProtectedHost(host, hosts, net.LookupIP); ok {
+			return fmt.Sprintf("host %s matches the protected pattern %q", host, p), nil
+		}
+	}
+	if marker == "" {
+		marker = DefaultGuardTable
+	}
+	found, err := hasTable(db, driver, marker)
+	if err != nil {
+		return "", fmt.Errorf("production guard: %w", err)
+	}
+	if found {
+		return "it has the marker table " + marker, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,62 @@
+package inserter
+
+import (
+	"database/sql"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestConnHosts(t *testing.T) {
+	t.Setenv("PGHOST", "")
+	cases := map[string][]string{
+		"postgres://app:pw@db.prod.example.com:5432/shop": {"db.prod.example.com"},
+		"postgresql://a,b:5433/shop":                      {"a", "b"},
+		"host=10.20.1.5 port=5432 dbname=shop":            {"10.20.1.5"},
+		"postgres:///shop?host=/var/run/postgresql":       nil,
+		"sqlite:./dev.db":                                 nil,
+	}
+	for conn, want := range cases {
+		if got := ConnHosts(conn); !reflect.DeepEqual(got, want) {
+			t.Errorf("ConnHosts(%q) = %v, want %v", conn, got, want)
+		}
+	}
+	if got := ConnName("host=db dbname=shop"); got != "shop" {
+		t.Errorf("ConnName = %q, want shop", got)
+	}
+}
+
+func TestProtectedHost(t *testing.T) {
+	patterns := []string{"*.prod.example.com", "10.20.0.0/16"}
+	lookup := func(string) ([]net.IP, error) { return []net.IP{net.ParseIP("10.20.3.4")}, nil }
+	for host, want := range map[string]string{
+		"DB.Prod.Example.com": "*.prod.example.com",
+		"10.20.1.5":           "10.20.0.0/16",
+		"primary.internal":    "10.20.0.0/16", // resolves into the range
+	} {
+		if p, ok := ProtectedHost(host, patterns, lookup); !ok || p != want {
+			t.Errorf("ProtectedHost(%q) = %q, %v; want %q", host, p, ok, want)
+		}
+	}
+	if p, ok := ProtectedHost("localhost", patterns, nil); ok {
+		t.Errorf("localhost matched %q", p)
+	}
+}
+
+func TestProtectedMarkerTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if reason, err := Protected(db, "sqlite3", "sqlite::memory:", nil, ""); err != nil || reason != "" {
+		t.Fatalf("unmarked database: reason %q, err %v", reason, err)
+	}
+	if _, err := db.Exec("CREATE TABLE " + DefaultGuardTable + " (note TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if reason, err := Protected(db, "sqlite3", "sqlite::memory:", nil, ""); err != nil || reason == "" {
+		t.Errorf("marker table not noticed: reason %q, err %v", reason, err)
+	}
+}
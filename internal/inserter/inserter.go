@@ -4,21 +4,110 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"sync"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
 )
 
-// Open opens a database from a connection string.
-// Formats: "postgres://...", "postgresql://...", "sqlite:path" or "sqlite://path"
-// Returns db and driver name ("pgx" or "sqlite3") for placeholder style in inserts.
-func Open(conn string) (*sql.DB, string, error) {
+// BatchInserter takes generated rows in batches. *Inserter is one; tests
+// and embedders can supply their own to receive the rows of a run without
+// a database (see pipeline.Options.Inserter).
+type BatchInserter interface {
+	// InsertBatch writes rows of table with the given columns and returns
+	// how many it wrote. Columns missing from a row are NULL.
+	InsertBatch(table string, columns []string, rows []map[string]interface{}) (int, error)
+}
+
+// Inserter writes rows to one database, with the placeholder style of its
+// driver. Every batch is one transaction: it is written whole or not at all.
+// The INSERT statements of each table stay prepared, so its batches reuse
+// them instead of preparing each one.
+type Inserter struct {
+	db     *sql.DB
+	driver string
+
+	mu    sync.Mutex
+	stmts map[string]map[string]*sql.Stmt // by table, then by query
+}
+
+// Connect opens a database from a connection string and checks that it
+// answers. Formats: "postgres://...", "postgresql://...", "sqlite:path" or
+// "sqlite://path"; anything else is handed to Postgres. "sqlite::memory:"
+// is a database that lasts until the Inserter is closed (see Export).
+func Connect(conn string) (*Inserter, error) {
 	driver, dsn := parseConn(conn)
 	db, err := sql.Open(driver, dsn)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if InMemory(conn) {
+		// Every connection to :memory: is a database of its own.
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return New(db, driver), nil
+}
+
+// New wraps an already-open database. driver is "pgx" or "sqlite3", as
+// Connect picks; SQLite also accepts the $N placeholders used for
+// anything else. Closing the Inserter closes db.
+func New(db *sql.DB, driver string) *Inserter {
+	return &Inserter{db: db, driver: driver}
+}
+
+// DB returns the database rows are written to.
+func (in *Inserter) DB() *sql.DB { return in.db }
+
+// Driver returns the driver name given to New.
+func (in *Inserter) Driver() string { return in.driver }
+
+// Close closes the database.
+func (in *Inserter) Close() error {
+	in.CloseStatements()
+	return in.db.Close()
+}
+
+// CloseStatements closes the prepared statements kept for reuse, leaving
+// the database open. The next batch prepares its statement again.
+func (in *Inserter) CloseStatements() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, byQuery := range in.stmts {
+		for _, stmt := range byQuery {
+			stmt.Close()
+		}
+	}
+	in.stmts = nil
+}
+
+// prepared returns query, an INSERT into table, prepared once and kept
+// until CloseStatements: the full batches of a table share one statement
+// and its last batch has another. Tables seeded at the same time, or one
+// after the other, keep statements of their own.
+func (in *Inserter) prepared(table, query string) (*sql.Stmt, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if stmt, ok := in.stmts[table][query]; ok {
+		return stmt, nil
+	}
+	stmt, err := in.db.Prepare(query)
+	if err != nil {
+		return nil, err
 	}
-	return db, driver, nil
+	if in.stmts == nil {
+		in.stmts = make(map[string]map[string]*sql.Stmt)
+	}
+	if in.stmts[table] == nil {
+		in.stmts[table] = make(map[string]*sql.Stmt)
+	}
+	in.stmts[table][query] = stmt
+	return stmt, nil
 }
 
 func parseConn(conn string) (driver, dsn string) {
@@ -34,10 +123,9 @@ func parseConn(conn string) (driver, dsn string) {
 	return "pgx", conn
 }
 
-// FetchRefIDs returns existing values for a table.column (e.g. for FK context).
-func FetchRefIDs(db *sql.DB, table, column string, limit int) ([]interface{}, error) {
-	query := fmt.Sprintf("SELECT %s FROM %s LIMIT %d", quoteIdent(column), quoteIdent(table), limit)
-	rows, err := db.Query(query)
+// queryValues returns the first column of every row of query.
+func queryValues(db *sql.DB, query string, args ...interface{}) ([]interface{}, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -53,35 +141,62 @@ func FetchRefIDs(db *sql.DB, table, column string, limit int) ([]interface{}, er
 	return ids, rows.Err()
 }
 
+// CountRows returns the number of rows in table.
+func CountRows(db *sql.DB, table string) (int, error) {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM " + quoteTable(table)).Scan(&n)
+	return n, err
+}
+
+// ExistingValues returns every non-NULL value of table.column, formatted
+// with fmt.Sprint, for checking new rows against UNIQUE constraints.
+func ExistingValues(db *sql.DB, table, column string) (map[string]bool, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL", quoteIdent(column), quoteTable(table), quoteIdent(column))
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var v interface{}
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		seen[fmt.Sprint(v)] = true
+	}
+	return seen, rows.Err()
+}
+
 func quoteIdent(s string) string {
 	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
 }
 
-// InsertBatch inserts rows in a single transaction. Each row is a map of column name -> value.
-// driverName is "pgx" for PostgreSQL ($1, $2) or "sqlite3" for SQLite (?).
-func InsertBatch(db *sql.DB, driverName, table string, columns []string, rows []map[string]interface{}) (int, error) {
+// quoteTable quotes a table name, and the schema it is qualified with
+// separately (see schema.SplitName).
+func quoteTable(name string) string {
+	return schema.QuoteTable(name)
+}
+
+// InsertBatch inserts rows into table in a single transaction. Each row is
+// a map of column name -> value; columns missing from a row are NULL.
+func (in *Inserter) InsertBatch(table string, columns []string, rows []map[string]interface{}) (int, error) {
 	if len(rows) == 0 {
 		return 0, nil
 	}
-	tx, err := db.Begin()
+	stmt, err := in.insertStmt(table, columns, len(rows), "")
 	if err != nil {
 		return 0, err
 	}
-	defer tx.Rollback()
-	placeholders := buildPlaceholders(driverName, len(columns), len(rows))
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
-		quoteIdent(table),
-		quotedList(columns),
-		placeholders,
-	)
-	stmt, err := tx.Prepare(query)
+	tx, err := in.db.Begin()
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
-	args := flattenArgs(columns, rows)
-	_, err = stmt.Exec(args...)
-	if err != nil {
+	defer tx.Rollback()
+	if _, err := tx.Stmt(stmt).Exec(flattenArgs(columns, rows)...); err != nil {
 		return 0, err
 	}
 	if err := tx.Commit(); err != nil {
@@ -90,6 +205,48 @@ func InsertBatch(db *sql.DB, driverName, table string, columns []string, rows []
 	return len(rows), nil
 }
 
+// batchLimitErrors are the messages of databases refusing a statement
+// for its size rather than for a row in it.
+var batchLimitErrors = []string{
+	"too many sql variables",       // SQLite: SQLITE_MAX_VARIABLE_NUMBER
+	"string or blob too big",       // SQLite: SQLITE_MAX_SQL_LENGTH
+	"extended protocol limited to", // pgx: 65535 parameters per statement
+	"stack depth limit exceeded",   // Postgres, on very long VALUES lists
+}
+
+// BatchTooLarge reports whether err is the database refusing a multi-row
+// INSERT for having too many parameters or being too long, which a
+// smaller batch gets past.
+func BatchTooLarge(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range batchLimitErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertStmt returns the INSERT of n rows into table, prepared (see
+// prepared) before the transaction that runs it begins: with a single
+// connection, as for :memory:, the transaction holds it. A returning list
+// is added as RETURNING.
+func (in *Inserter) insertStmt(table string, columns []string, n int, returning string) (*sql.Stmt, error) {
+	placeholders := buildPlaceholders(in.driver, len(columns), n)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		quoteTable(table),
+		quotedList(columns),
+		placeholders,
+	)
+	if returning != "" {
+		query += " RETURNING " + returning
+	}
+	return in.prepared(table, query)
+}
+
 func buildPlaceholders(driverName string, numCols, numRows int) string {
 	var parts []string
 	idx := 0
@@ -0,0 +1,93 @@
+package inserter
+
+import (
+	"database/sql"
+	"math/rand/v2"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestInsertBatchReusesStatements(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT); CREATE TABLE tags (name TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	in := New(db, "sqlite3")
+	defer in.CloseStatements()
+	batch := []map[string]interface{}{{"email": "a@x.io"}, {"email": "b@x.io"}}
+	for i := 0; i < 3; i++ {
+		if _, err := in.InsertBatch("users", []string{"email"}, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(in.stmts["users"]) != 1 {
+		t.Fatalf("expected one statement for three batches of the same size, got %d", len(in.stmts["users"]))
+	}
+	if _, err := in.InsertBatch("users", []string{"email"}, batch[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(in.stmts["users"]) != 2 {
+		t.Errorf("expected a second statement for the shorter last batch, got %d", len(in.stmts["users"]))
+	}
+	if _, err := in.InsertBatch("tags", []string{"name"}, []map[string]interface{}{{"name": "go"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.InsertBatch("users", []string{"email"}, batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(in.stmts) != 2 || len(in.stmts["users"]) != 2 || len(in.stmts["tags"]) != 1 {
+		t.Errorf("expected the statements of users kept beside those of tags, got %v", in.stmts)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil || n != 9 {
+		t.Errorf("expected 9 users, got %d (%v)", n, err)
+	}
+	in.CloseStatements()
+	if in.stmts != nil {
+		t.Errorf("expected every statement closed, got %v", in.stmts)
+	}
+}
+
+func TestSchemaQualifiedTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`ATTACH DATABASE ':memory:' AS billing;
+		CREATE TABLE billing."Order Items" (id INTEGER PRIMARY KEY, sku TEXT);
+		CREATE TABLE "Order Items" (id INTEGER PRIMARY KEY, sku TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	const table = "billing.Order Items"
+	if denied, err := CheckWrite(db, "sqlite3", []string{table}); err != nil || len(denied) != 0 {
+		t.Fatalf("CheckWrite: got %v, %v", denied, err)
+	}
+	in := New(db, "sqlite3")
+	defer in.CloseStatements()
+	if _, err := in.InsertBatch(table, []string{"sku"}, []map[string]interface{}{{"sku": "A-1"}, {"sku": "B-2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := CountRows(db, table); err != nil || n != 2 {
+		t.Errorf("CountRows: got %d, %v", n, err)
+	}
+	if n, err := CountRows(db, "Order Items"); err != nil || n != 0 {
+		t.Errorf("the unqualified table should be untouched, got %d rows, %v", n, err)
+	}
+	if ids, err := FetchRefIDs(db, "sqlite3", table, "id", 10, rand.New(rand.NewPCG(1, 2))); err != nil || len(ids) != 2 {
+		t.Errorf("FetchRefIDs: got %v, %v", ids, err)
+	}
+	if row, err := in.FindRow(table, map[string]interface{}{"sku": "B-2"}); err != nil || row == nil {
+		t.Errorf("FindRow: got %v, %v", row, err)
+	}
+	if next, ok, err := NextID(db, "sqlite3", table, "id"); err != nil || !ok || next != 3 {
+		t.Errorf("NextID: got %d, %v, %v", next, ok, err)
+	}
+}
@@ -0,0 +1,46 @@
+package inserter
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// NextID returns the value the database gives table.column on the next
+// insert that leaves it out. ok is false when the database does not fill
+// the column. On Postgres that is the next value of the column's sequence
+// (SERIAL or IDENTITY); on SQLite one past the largest value, or past the
+// largest ever handed out for an AUTOINCREMENT column.
+func NextID(db *sql.DB, driver, table, column string) (next int64, ok bool, err error) {
+	if driver != "pgx" {
+		var last int64
+		if err := db.QueryRow("SELECT COALESCE(MAX(" + quoteIdent(column) + "), 0) FROM " + quoteTable(table)).Scan(&last); err != nil {
+			return 0, false, err
+		}
+		var seq int64
+		err := db.QueryRow(`SELECT seq FROM sqlite_sequence WHERE name = ?`, table).Scan(&seq)
+		switch {
+		case err == nil:
+			last = max(last, seq)
+		case errors.Is(err, sql.ErrNoRows), strings.Contains(err.Error(), "no such table"):
+			// no AUTOINCREMENT table, or no row handed out yet
+		default:
+			return 0, false, err
+		}
+		return last + 1, true, nil
+	}
+
+	var seq sql.NullString
+	if err := db.QueryRow(`SELECT pg_get_serial_sequence($1, $2)`, quoteTable(table), column).Scan(&seq); err != nil {
+		return 0, false, err
+	}
+	if !seq.Valid {
+		return 0, false, nil
+	}
+	err = db.QueryRow(`SELECT COALESCE(pg_sequence_last_value(s.seqrelid) + s.seqincrement, s.seqstart)
+		FROM pg_sequence s WHERE s.seqrelid = to_regclass($1)`, seq.String).Scan(&next)
+	if err != nil {
+		return 0, false, err
+	}
+	return next, true, nil
+}
@@ -0,0 +1,92 @@
+package inserter
+
+import (
+	"database/sql"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Rejected is a row of a batch the database refused.
+type Rejected struct {
+	Row int // index of the row in the batch
+	Err error
+}
+
+// InsertBatchPartial inserts rows like InsertBatch, or like
+// InsertBatchLedger when runID is set, but rows the database rejects do
+// not cost the rest of the batch. The batch is tried behind a savepoint;
+// if it fails, its rows are inserted one at a time in the same
+// transaction, each behind a savepoint of its own, and a row that fails
+// is rolled back to it and returned in rejected. err is for the
+// transaction itself, which then inserts nothing, or for a batch too
+// large for the database (see BatchTooLarge).
+func (in *Inserter) InsertBatchPartial(t *schema.Table, columns []string, rows []map[string]interface{}, runID string) (n int, rejected []Rejected, err error) {
+	if len(rows) == 0 {
+		return 0, nil, nil
+	}
+	var pk []string
+	returning := ""
+	if runID != "" {
+		if pk = primaryKey(t); len(pk) > 0 {
+			returning = keyExpr(pk)
+		}
+	}
+	batch, err := in.insertStmt(t.Name, columns, len(rows), returning)
+	if err != nil {
+		return 0, nil, err
+	}
+	one, err := in.insertStmt(t.Name, columns, 1, returning)
+	if err != nil {
+		return 0, nil, err
+	}
+	tx, err := in.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	batch, one = tx.Stmt(batch), tx.Stmt(one)
+	insert := func(stmt *sql.Stmt, rows []map[string]interface{}) error {
+		if len(pk) > 0 {
+			return in.insertLedger(tx, stmt, t.Name, columns, rows, runID)
+		}
+		_, err := stmt.Exec(flattenArgs(columns, rows)...)
+		return err
+	}
+	rowErr, err := savepoint(tx, "seeddb_batch", func() error { return insert(batch, rows) })
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(rows) > 1 && BatchTooLarge(rowErr) {
+		return 0, nil, rowErr // no row is at fault; a smaller batch goes through
+	}
+	if rowErr != nil {
+		for i := range rows {
+			rowErr, err := savepoint(tx, "seeddb_row", func() error { return insert(one, rows[i:i+1]) })
+			if err != nil {
+				return 0, nil, err
+			}
+			if rowErr != nil {
+				rejected = append(rejected, Rejected{Row: i, Err: rowErr})
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return len(rows) - len(rejected), rejected, nil
+}
+
+// savepoint runs fn behind the savepoint name and rolls back to it if fn
+// fails, returning fn's error as failed. err is for the savepoint itself.
+func savepoint(tx *sql.Tx, name string, fn func() error) (failed, err error) {
+	if _, err := tx.Exec("SAVEPOINT " + name); err != nil {
+		return nil, err
+	}
+	if failed = fn(); failed != nil {
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT " + name); err != nil {
+			return failed, err
+		}
+	}
+	_, err = tx.Exec("RELEASE SAVEPOINT " + name)
+	return failed, err
+}
@@ -0,0 +1,48 @@
+package inserter
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestInsertBatchPartial(t *testing.T) {
+	tables, err := schema.ParseFile(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := CreateTables(db, "sqlite3", tables); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateLedger(db); err != nil {
+		t.Fatal(err)
+	}
+	in := New(db, "sqlite3")
+	rows := []map[string]interface{}{{"email": "a@x.io"}, {"email": nil}, {"email": "b@x.io"}, {"email": "a@x.io"}}
+	n, rejected, err := in.InsertBatchPartial(tables[0], []string{"email"}, rows, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(rejected) != 2 || rejected[0].Row != 1 || rejected[1].Row != 3 {
+		t.Fatalf("expected rows 1 and 3 rejected, got %d inserted, %v", n, rejected)
+	}
+	var users, recorded int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users); err != nil || users != 2 {
+		t.Errorf("expected 2 users, got %d (%v)", users, err)
+	}
+	if err := db.QueryRow(`SELECT COUNT(*) FROM _seeddb_rows WHERE run_id = 'run-1'`).Scan(&recorded); err != nil || recorded != 2 {
+		t.Errorf("expected the 2 inserted users in the ledger, got %d (%v)", recorded, err)
+	}
+
+	n, rejected, err = in.InsertBatchPartial(tables[0], []string{"email"}, []map[string]interface{}{{"email": "c@x.io"}}, "")
+	if err != nil || n != 1 || rejected != nil {
+		t.Errorf("clean batch: %d, %v, %v", n, rejected, err)
+	}
+}
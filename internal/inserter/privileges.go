@@ -0,0 +1,90 @@
+package inserter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Denied is a table the connected user cannot seed, and why.
+type Denied struct {
+	Table  string
+	Reason string // e.g. "no INSERT privilege" or "table does not exist"
+}
+
+func (d Denied) String() string { return d.Table + ": " + d.Reason }
+
+// CheckWrite reports the tables the connected user cannot insert into,
+// before any row is generated. On Postgres that is a missing table, a
+// missing INSERT privilege, or a missing USAGE privilege on the sequence
+// of a SERIAL column; on SQLite a missing table or a read-only database.
+// The error is for the check itself failing.
+func CheckWrite(db *sql.DB, driver string, tables []string) ([]Denied, error) {
+	var denied []Denied
+	for _, table := range tables {
+		reason, err := writeDenied(db, driver, table)
+		if err != nil {
+			return denied, fmt.Errorf("check privileges on %s: %w", table, err)
+		}
+		if reason != "" {
+			denied = append(denied, Denied{Table: table, Reason: reason})
+		}
+	}
+	return denied, nil
+}
+
+// writeDenied returns why table cannot be inserted into, or "".
+func writeDenied(db *sql.DB, driver, table string) (string, error) {
+	if driver != "pgx" {
+		var n int
+		master, name := "sqlite_master", table
+		if qualifier, own := schema.SplitName(table); qualifier != "" {
+			master, name = quoteIdent(qualifier)+".sqlite_master", own
+		}
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+master+` WHERE type = 'table' AND name = ?`, name).Scan(&n); err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return "table does not exist", nil
+		}
+		// A write that matches no row still needs a writable database.
+		tx, err := db.Begin()
+		if err != nil {
+			return "", err
+		}
+		defer tx.Rollback()
+		if _, err := tx.Exec("DELETE FROM " + quoteTable(table) + " WHERE 0"); err != nil {
+			return err.Error(), nil
+		}
+		return "", nil
+	}
+
+	name := quoteTable(table)
+	var exists, insert bool
+	err := db.QueryRow(`SELECT to_regclass($1) IS NOT NULL, COALESCE(has_table_privilege(to_regclass($1), 'INSERT'), false)`, name).Scan(&exists, &insert)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !exists:
+		return "table does not exist", nil
+	case !insert:
+		return "no INSERT privilege", nil
+	}
+	// Identity columns draw from their sequence without a privilege check;
+	// SERIAL columns call nextval(), which needs USAGE.
+	var seq sql.NullString
+	err = db.QueryRow(`SELECT s.seq FROM (
+		SELECT pg_get_serial_sequence($1, a.attname) AS seq, a.attidentity
+		FROM pg_attribute a WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+	) s WHERE s.seq IS NOT NULL AND s.attidentity = '' AND NOT has_sequence_privilege(s.seq, 'USAGE') LIMIT 1`, name).Scan(&seq)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+	return "no USAGE privilege on sequence " + seq.String, nil
+}
@@ -0,0 +1,42 @@
+package inserter
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCheckWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	denied, err := CheckWrite(db, "sqlite3", []string{"users", "posts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(denied) != 1 || denied[0].String() != "posts: table does not exist" {
+		t.Errorf("expected only posts denied, got %v", denied)
+	}
+
+	ro, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	denied, err = CheckWrite(ro, "sqlite3", []string{"users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(denied) != 1 || !strings.Contains(denied[0].Reason, "readonly") {
+		t.Errorf("expected users denied in a read-only database, got %v", denied)
+	}
+}
@@ -0,0 +1,128 @@
+package inserter
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// RunsTable records the seed runs under an idempotency key, so a retried
+// run can tell it has nothing left to do. A run claims its key before it
+// inserts anything and is marked finished at the end, or failed if it
+// stopped with rows inserted, so a retry does not seed on top of them;
+// the primary key lets only one of two runs starting together claim it.
+const RunsTable = "_seeddb_runs"
+
+// SchemaHash returns a short hash of a schema file's text. Changes in
+// whitespace alone do not change it.
+func SchemaHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Run is a run recorded in RunsTable.
+type Run struct {
+	Key        string
+	SchemaHash string
+	RunID      string
+	StartedAt  string
+	FinishedAt string // empty while the run is still going, or if it died
+	FailedAt   string // set if it stopped with some of its rows inserted
+}
+
+// Finished reports whether the run got to the end.
+func (r *Run) Finished() bool { return r.FinishedAt != "" }
+
+// Failed reports whether the run stopped with some of its rows inserted.
+func (r *Run) Failed() bool { return r.FailedAt != "" }
+
+// FindRun returns the run recorded under key for the schema with hash
+// schemaHash, finished or not, or nil if there is none.
+func FindRun(db *sql.DB, driver, key, schemaHash string) (*Run, error) {
+	ok, err := hasTable(db, driver, RunsTable)
+	if err != nil || !ok {
+		return nil, err
+	}
+	r := &Run{Key: key, SchemaHash: schemaHash}
+	var finished, failed sql.NullString
+	err = db.QueryRow("SELECT run_id, started_at, finished_at, failed_at FROM "+quoteIdent(RunsTable)+
+		" WHERE idempotency_key = "+placeholder(driver, 1)+" AND schema_hash = "+placeholder(driver, 2),
+		key, schemaHash).Scan(&r.RunID, &r.StartedAt, &finished, &failed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.FinishedAt, r.FailedAt = finished.String, failed.String
+	return r, nil
+}
+
+// ClaimRun records in RunsTable, creating it if needed, that the run runID
+// started at the given time under key for the schema schemaHash. If
+// another run holds the key already, nothing is recorded and that run is
+// returned instead. A run resuming runID takes back the key it failed
+// with.
+func ClaimRun(db *sql.DB, driver, key, schemaHash, runID string, started time.Time) (*Run, error) {
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS " + quoteIdent(RunsTable) +
+		" (idempotency_key TEXT NOT NULL, schema_hash TEXT NOT NULL, run_id TEXT NOT NULL," +
+		" started_at TEXT NOT NULL, finished_at TEXT, failed_at TEXT, PRIMARY KEY (idempotency_key, schema_hash))"); err != nil {
+		return nil, err
+	}
+	res, err := db.Exec("INSERT INTO "+quoteIdent(RunsTable)+" (idempotency_key, schema_hash, run_id, started_at) VALUES ("+
+		placeholder(driver, 1)+", "+placeholder(driver, 2)+", "+placeholder(driver, 3)+", "+placeholder(driver, 4)+
+		") ON CONFLICT DO NOTHING",
+		key, schemaHash, runID, started.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return nil, err
+	}
+	res, err = db.Exec("UPDATE "+quoteIdent(RunsTable)+" SET failed_at = NULL"+
+		" WHERE idempotency_key = "+placeholder(driver, 1)+" AND schema_hash = "+placeholder(driver, 2)+
+		" AND run_id = "+placeholder(driver, 3)+" AND failed_at IS NOT NULL",
+		key, schemaHash, runID)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return nil, err
+	}
+	run, err := FindRun(db, driver, key, schemaHash)
+	if err == nil && run == nil {
+		err = errors.New("key claimed and released by another run meanwhile; try again")
+	}
+	return run, err
+}
+
+// FinishRun marks the run that claimed key for schemaHash finished at the
+// given time.
+func FinishRun(db *sql.DB, driver, key, schemaHash string, finished time.Time) error {
+	_, err := db.Exec("UPDATE "+quoteIdent(RunsTable)+" SET finished_at = "+placeholder(driver, 1)+
+		" WHERE idempotency_key = "+placeholder(driver, 2)+" AND schema_hash = "+placeholder(driver, 3),
+		finished.UTC().Format(time.RFC3339), key, schemaHash)
+	return err
+}
+
+// FailRun marks the run that claimed key for schemaHash failed at the
+// given time. It keeps the key, so a retry does not seed on top of the
+// rows it left; cleaning the run frees it.
+func FailRun(db *sql.DB, driver, key, schemaHash string, failed time.Time) error {
+	_, err := db.Exec("UPDATE "+quoteIdent(RunsTable)+" SET failed_at = "+placeholder(driver, 1)+
+		" WHERE idempotency_key = "+placeholder(driver, 2)+" AND schema_hash = "+placeholder(driver, 3),
+		failed.UTC().Format(time.RFC3339), key, schemaHash)
+	return err
+}
+
+// ReleaseRun gives up the claim of a run that failed before inserting
+// anything, so a retry can seed.
+func ReleaseRun(db *sql.DB, driver, key, schemaHash string) error {
+	_, err := db.Exec("DELETE FROM "+quoteIdent(RunsTable)+
+		" WHERE idempotency_key = "+placeholder(driver, 1)+" AND schema_hash = "+placeholder(driver, 2)+" AND finished_at IS NULL",
+		key, schemaHash)
+	return err
+}
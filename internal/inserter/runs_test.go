@@ -0,0 +1,88 @@
+package inserter
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestClaimRun(t *testing.T) {
+	h := SchemaHash("CREATE TABLE t (id INT);")
+	if h != SchemaHash("CREATE TABLE t (id\tINT);\n\n") || h == SchemaHash("CREATE TABLE t (id BIGINT);") {
+		t.Error("SchemaHash should ignore whitespace and only whitespace")
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if run, err := FindRun(db, "sqlite3", "ci-42", "abc"); run != nil || err != nil {
+		t.Fatalf("no runs table yet: got %+v, %v", run, err)
+	}
+	started := time.Date(2024, 3, 1, 14, 20, 0, 0, time.UTC)
+	if run, err := ClaimRun(db, "sqlite3", "ci-42", "abc", "run-1", started); run != nil || err != nil {
+		t.Fatalf("ClaimRun: got %+v, %v", run, err)
+	}
+	// A run starting meanwhile with the same key finds it held.
+	run, err := ClaimRun(db, "sqlite3", "ci-42", "abc", "run-2", started)
+	if err != nil || run == nil || run.RunID != "run-1" || run.Finished() {
+		t.Fatalf("second ClaimRun: got %+v, %v", run, err)
+	}
+	// A run that failed with rows inserted keeps the key from other runs,
+	// but resuming it takes the key back.
+	if err := FailRun(db, "sqlite3", "ci-42", "abc", started); err != nil {
+		t.Fatal(err)
+	}
+	run, err = ClaimRun(db, "sqlite3", "ci-42", "abc", "run-2", started)
+	if err != nil || run == nil || run.RunID != "run-1" || !run.Failed() {
+		t.Fatalf("ClaimRun of a failed run's key: got %+v, %v", run, err)
+	}
+	if run, err := ClaimRun(db, "sqlite3", "ci-42", "abc", "run-1", started); run != nil || err != nil {
+		t.Fatalf("ClaimRun resuming the failed run: got %+v, %v", run, err)
+	}
+	if run, err := FindRun(db, "sqlite3", "ci-42", "abc"); err != nil || run == nil || run.Failed() {
+		t.Fatalf("resumed run: got %+v, %v", run, err)
+	}
+	// A run that failed before inserting lets go of the key.
+	if err := ReleaseRun(db, "sqlite3", "ci-42", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if run, err := ClaimRun(db, "sqlite3", "ci-42", "abc", "run-1", started); run != nil || err != nil {
+		t.Fatalf("ClaimRun after release: got %+v, %v", run, err)
+	}
+	finished := time.Date(2024, 3, 1, 14, 25, 1, 0, time.UTC)
+	if err := FinishRun(db, "sqlite3", "ci-42", "abc", finished); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReleaseRun(db, "sqlite3", "ci-42", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	run, err = FindRun(db, "sqlite3", "ci-42", "abc")
+	if err != nil || run == nil || run.RunID != "run-1" || run.FinishedAt != "2024-03-01T14:25:01Z" {
+		t.Fatalf("FindRun: got %+v, %v", run, err)
+	}
+	for _, other := range [][2]string{{"ci-43", "abc"}, {"ci-42", "def"}} {
+		if run, err := FindRun(db, "sqlite3", other[0], other[1]); run != nil || err != nil {
+			t.Errorf("key %s, schema %s: got %+v, %v", other[0], other[1], run, err)
+		}
+	}
+
+	// Cleaning the run's rows forgets it, so the key seeds again.
+	tables, err := schema.ParseFile(`CREATE TABLE users (id INTEGER PRIMARY KEY, seed_batch_id TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateTables(db, "sqlite3", tables); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeleteTagged(db, "sqlite3", tables, Tag{RunID: "run-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if run, err := FindRun(db, "sqlite3", "ci-42", "abc"); run != nil || err != nil {
+		t.Errorf("after clean: got %+v, %v", run, err)
+	}
+}
@@ -0,0 +1,132 @@
+package inserter
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// Sampling limits for FetchRefIDs. Tables up to sampleSortMax rows are
+// sampled with ORDER BY random(); larger ones with TABLESAMPLE on Postgres
+// and by paging through the column on SQLite, samplePage rows at a time.
+var (
+	sampleSortMax = 200_000
+	samplePage    = 10_000
+)
+
+// FetchRefIDs returns up to limit non-NULL values of table.column for FK
+// columns to reference, picked at random from the whole table and in
+// random order, so new rows do not all point at the oldest parents. r
+// orders them and samples large SQLite tables; which rows ORDER BY
+// RANDOM() and TABLESAMPLE pick is up to the database.
+func FetchRefIDs(db *sql.DB, driver, table, column string, limit int, r *rand.Rand) ([]interface{}, error) {
+	n, err := estimateRows(db, driver, table)
+	if err != nil {
+		return nil, err
+	}
+	col, tbl := quoteIdent(column), quoteTable(table)
+	var ids []interface{}
+	switch {
+	case n <= sampleSortMax:
+		ids, err = queryValues(db, fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL ORDER BY RANDOM() LIMIT %d", col, tbl, col, limit))
+	case driver != "sqlite3":
+		// Read about four times the rows needed, in whole pages, so the
+		// LIMIT still fills when the pages are unevenly full.
+		pct := min(100, 400*float64(limit)/float64(n))
+		ids, err = queryValues(db, fmt.Sprintf("SELECT %s FROM %s TABLESAMPLE SYSTEM (%.4f) WHERE %s IS NOT NULL LIMIT %d", col, tbl, pct, col, limit))
+		if err == nil && len(ids) < limit {
+			ids, err = queryValues(db, fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL ORDER BY RANDOM() LIMIT %d", col, tbl, col, limit))
+		}
+	default:
+		ids, err = reservoir(db, col, tbl, limit, r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	return ids, nil
+}
+
+// FetchRows returns up to limit rows of table with the given columns, in
+// the order of the first, skipping rows where it is NULL. []byte values
+// are returned as strings.
+func FetchRows(db *sql.DB, table string, columns []string, limit int) ([]map[string]interface{}, error) {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdent(c)
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL ORDER BY %s LIMIT %d",
+		strings.Join(quoted, ", "), quoteTable(table), quoted[0], quoted[0], limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []map[string]interface{}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[c] = string(b)
+			} else {
+				row[c] = values[i]
+			}
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// estimateRows returns the planner's row estimate on Postgres, which is
+// free, and COUNT(*) when there is none or on SQLite.
+func estimateRows(db *sql.DB, driver, table string) (int, error) {
+	if driver != "sqlite3" {
+		var n float64
+		err := db.QueryRow("SELECT reltuples FROM pg_class WHERE oid = $1::regclass", quoteTable(table)).Scan(&n)
+		if err == nil && n > 0 {
+			return int(n), nil
+		}
+	}
+	return CountRows(db, table)
+}
+
+// reservoir pages through column in key order, samplePage rows per query,
+// keeping a uniform random sample of limit values. Memory stays at one
+// page however large the table is.
+func reservoir(db *sql.DB, col, tbl string, limit int, r *rand.Rand) ([]interface{}, error) {
+	var sample []interface{}
+	seen := 0
+	var last interface{}
+	for {
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s IS NOT NULL ORDER BY %s LIMIT %d", col, tbl, col, col, samplePage)
+		args := []interface{}{}
+		if last != nil {
+			query = fmt.Sprintf("SELECT %s FROM %s WHERE %s > ? ORDER BY %s LIMIT %d", col, tbl, col, col, samplePage)
+			args = append(args, last)
+		}
+		page, err := queryValues(db, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page {
+			seen++
+			if len(sample) < limit {
+				sample = append(sample, v)
+			} else if k := r.IntN(seen); k < limit {
+				sample[k] = v
+			}
+		}
+		if len(page) < samplePage {
+			return sample, nil
+		}
+		last = page[len(page)-1]
+	}
+}
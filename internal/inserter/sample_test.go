@@ -0,0 +1,67 @@
+package inserter
+
+import (
+	"database/sql"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestFetchRefIDs(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 100; i++ {
+		if _, err := db.Exec(`INSERT INTO users (id) VALUES (?)`, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(sortMax, page int) { sampleSortMax, samplePage = sortMax, page }(sampleSortMax, samplePage)
+	for _, paged := range []bool{false, true} {
+		if paged {
+			sampleSortMax, samplePage = 10, 7
+		}
+		ids, err := FetchRefIDs(db, "sqlite3", "users", "id", 10, rand.New(rand.NewPCG(1, 2)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := make(map[int64]bool)
+		beyond := false
+		for _, v := range ids {
+			id := v.(int64)
+			if id < 1 || id > 100 || seen[id] {
+				t.Fatalf("paged=%v: bad sample %v", paged, ids)
+			}
+			seen[id] = true
+			beyond = beyond || id > 10
+		}
+		if len(ids) != 10 || !beyond {
+			t.Errorf("paged=%v: want 10 ids from the whole table, got %v", paged, ids)
+		}
+	}
+
+	all, err := FetchRefIDs(db, "sqlite3", "users", "id", 500, rand.New(rand.NewPCG(1, 2)))
+	if err != nil || len(all) != 100 {
+		t.Errorf("a limit above the row count should return every id, got %d (%v)", len(all), err)
+	}
+
+	// The same Rand picks and orders the same sample.
+	sampleSortMax, samplePage = 10, 7
+	a, err := FetchRefIDs(db, "sqlite3", "users", "id", 10, rand.New(rand.NewPCG(3, 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := FetchRefIDs(db, "sqlite3", "users", "id", 10, rand.New(rand.NewPCG(3, 4)))
+	if err != nil || !reflect.DeepEqual(a, b) {
+		t.Errorf("same seed, different samples: %v and %v (%v)", a, b, err)
+	}
+}
@@ -0,0 +1,55 @@
+package inserter
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// ShiftTimestamps moves every timestamp and date column of tables by d, in
+// one transaction, and returns the rows updated per table. Every value
+// moves by the same amount, so the order of events within and across
+// tables is kept. Tables without such columns are left out of the result.
+func ShiftTimestamps(db *sql.DB, driver string, tables []*schema.Table, d time.Duration) (map[string]int64, error) {
+	secs := int64(d / time.Second)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	updated := make(map[string]int64)
+	for _, t := range tables {
+		var sets []string
+		for _, c := range t.Columns {
+			if c.Type != "timestamp" {
+				continue
+			}
+			sets = append(sets, quoteIdent(c.Name)+" = "+shiftExpr(driver, quoteIdent(c.Name), secs))
+		}
+		if len(sets) == 0 {
+			continue
+		}
+		res, err := tx.Exec("UPDATE " + quoteTable(t.Name) + " SET " + strings.Join(sets, ", "))
+		if err != nil {
+			return nil, fmt.Errorf("shift %s: %w", t.Name, err)
+		}
+		n, _ := res.RowsAffected()
+		updated[t.Name] = n
+	}
+	return updated, tx.Commit()
+}
+
+// shiftExpr returns the SQL that moves col by secs seconds. SQLite stores
+// times as text or numbers, so it keeps dates as dates and moves Unix
+// timestamps arithmetically.
+func shiftExpr(driver, col string, secs int64) string {
+	if driver != "sqlite3" {
+		return fmt.Sprintf("%s + interval '%d seconds'", col, secs)
+	}
+	mod := fmt.Sprintf("'%+d seconds'", secs)
+	return fmt.Sprintf("CASE WHEN typeof(%[1]s) IN ('integer', 'real') THEN %[1]s + %[2]d "+
+		"WHEN length(%[1]s) = 10 THEN date(%[1]s, %[3]s) ELSE datetime(%[1]s, %[3]s) END", col, secs, mod)
+}
@@ -0,0 +1,50 @@
+package inserter
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestShiftTimestampsSQLite(t *testing.T) {
+	tables, err := schema.ParseFile(`CREATE TABLE events (id INTEGER PRIMARY KEY, name TEXT, at DATETIME, day DATE, unix_at TIMESTAMP);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := CreateTables(db, "sqlite3", tables); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO events (name, at, day, unix_at) VALUES
+		('a', '2024-02-28 10:00:00', '2024-02-28', 1700000000), ('b', NULL, NULL, NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := ShiftTimestamps(db, "sqlite3", tables, 2*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated["events"] != 2 {
+		t.Errorf("updated %v, want 2 rows in events", updated)
+	}
+	var at, day string
+	var unix int64
+	// Expressions have no declared type, so the driver returns them as stored.
+	if err := db.QueryRow(`SELECT at || '', day || '', unix_at + 0 FROM events WHERE name = 'a'`).Scan(&at, &day, &unix); err != nil {
+		t.Fatal(err)
+	}
+	if at != "2024-03-01 10:00:00" || day != "2024-03-01" || unix != 1700000000+2*86400 {
+		t.Errorf("got at=%s day=%s unix=%d", at, day, unix)
+	}
+	var nulls int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM events WHERE name = 'b' AND at IS NULL AND day IS NULL`).Scan(&nulls); err != nil || nulls != 1 {
+		t.Errorf("NULL timestamps should stay NULL (%v)", err)
+	}
+}
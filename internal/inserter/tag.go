@@ -0,0 +1,278 @@
+package inserter
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// DefaultTagColumn is the column a seed run fills with its run ID in the
+// tables that have one.
+const DefaultTagColumn = "seed_batch_id"
+
+// LedgerTable maps the primary keys of seeded rows to the run that
+// inserted them, for tables without a tag column.
+const LedgerTable = "_seeddb_rows"
+
+// Tag marks the rows a run inserts, so they can be told apart from real
+// data and removed with DeleteTagged. Tables that have Column get RunID in
+// it; with Ledger, rows of the other tables are recorded in LedgerTable.
+type Tag struct {
+	RunID  string
+	Column string // "" means DefaultTagColumn
+	Ledger bool
+}
+
+// NewRunID returns an ID for a run starting at now, e.g. 20240301-142501-3fa9c2.
+func NewRunID(now time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+func (tag *Tag) column() string {
+	if tag.Column == "" {
+		return DefaultTagColumn
+	}
+	return tag.Column
+}
+
+// Apply sets the tag column of rows to the run ID when t has that column,
+// adding it to columns if missing, and returns the columns to insert and
+// whether the rows are tagged. A nil *Tag tags nothing.
+func (tag *Tag) Apply(t *schema.Table, columns []string, rows []map[string]interface{}) ([]string, bool) {
+	if tag == nil || t.Column(tag.column()) == nil {
+		return columns, false
+	}
+	col := tag.column()
+	for _, row := range rows {
+		row[col] = tag.RunID
+	}
+	for _, c := range columns {
+		if c == col {
+			return columns, true
+		}
+	}
+	return append(columns[:len(columns):len(columns)], col), true
+}
+
+// CreateLedger creates LedgerTable if it does not exist.
+func CreateLedger(db *sql.DB) error {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS " + quoteIdent(LedgerTable) +
+		" (run_id TEXT NOT NULL, table_name TEXT NOT NULL, pk TEXT NOT NULL)")
+	return err
+}
+
+// primaryKey returns the names of t's primary key columns.
+func primaryKey(t *schema.Table) []string {
+	var pk []string
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			pk = append(pk, c.Name)
+		}
+	}
+	return pk
+}
+
+// keyExpr is the text a row's primary key is recorded as in LedgerTable.
+// The database renders it both when rows are recorded and when they are
+// deleted, so the two always match.
+func keyExpr(pk []string) string {
+	parts := make([]string, len(pk))
+	for i, c := range pk {
+		parts[i] = "CAST(" + quoteIdent(c) + " AS TEXT)"
+	}
+	return strings.Join(parts, " || ',' || ")
+}
+
+// InsertBatchLedger inserts rows like InsertBatch and, in the same
+// transaction, records each row's primary key in LedgerTable under runID.
+// Keys the database assigns, such as SERIAL ids, are read back with
+// RETURNING. A table without a primary key is inserted unrecorded.
+func (in *Inserter) InsertBatchLedger(t *schema.Table, columns []string, rows []map[string]interface{}, runID string) (int, error) {
+	pk := primaryKey(t)
+	if len(rows) == 0 || len(pk) == 0 {
+		return in.InsertBatch(t.Name, columns, rows)
+	}
+	stmt, err := in.insertStmt(t.Name, columns, len(rows), keyExpr(pk))
+	if err != nil {
+		return 0, err
+	}
+	tx, err := in.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if err := in.insertLedger(tx, tx.Stmt(stmt), t.Name, columns, rows, runID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// insertLedger runs stmt, an INSERT of rows into table returning their
+// primary keys (see insertStmt), within tx and records the keys in
+// LedgerTable under runID.
+func (in *Inserter) insertLedger(tx *sql.Tx, stmt *sql.Stmt, table string, columns []string, rows []map[string]interface{}, runID string) error {
+	res, err := stmt.Query(flattenArgs(columns, rows)...)
+	if err != nil {
+		return err
+	}
+	var args []interface{}
+	for res.Next() {
+		var key string
+		if err := res.Scan(&key); err != nil {
+			res.Close()
+			return err
+		}
+		args = append(args, runID, table, key)
+	}
+	res.Close()
+	if err := res.Err(); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		query := fmt.Sprintf("INSERT INTO %s (run_id, table_name, pk) VALUES %s",
+			quoteIdent(LedgerTable), buildPlaceholders(in.driver, 3, len(args)/3))
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("%s: %w", LedgerTable, err)
+		}
+	}
+	return nil
+}
+
+// DeleteTagged deletes, in one transaction, the rows of tables that tag
+// marks: those whose tag column holds tag.RunID and, if LedgerTable
+// exists, those recorded there under it, then their ledger entries and
+// the run's record in RunsTable, so its idempotency key can seed again. An
+// empty RunID matches every run. Tables are emptied in the order
+// schema.PlanDelete gives, after setting the references it unlinks to
+// NULL in the rows to be deleted. It returns the rows deleted per table;
+// rows the database removes through ON DELETE CASCADE are not counted.
+func DeleteTagged(db *sql.DB, driver string, tables []*schema.Table, tag Tag) (map[string]int64, error) {
+	plan, err := schema.PlanDelete(tables)
+	if err != nil {
+		return nil, err
+	}
+	ledger, err := hasTable(db, driver, LedgerTable)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := hasTable(db, driver, RunsTable)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	byName := make(map[string]*schema.Table, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+	for _, e := range plan.Unlink {
+		for _, m := range tag.matches(driver, byName[e.From], ledger) {
+			query := "UPDATE " + quoteTable(e.From) + " SET " + quoteIdent(e.Column) + " = NULL WHERE " + m.where
+			if _, err := tx.Exec(query, m.args...); err != nil {
+				return nil, fmt.Errorf("clean %s: unlink %s: %w", e.From, e.Column, err)
+			}
+		}
+	}
+	deleted := make(map[string]int64)
+	for _, t := range plan.Order {
+		for _, m := range tag.matches(driver, t, ledger) {
+			res, err := tx.Exec("DELETE FROM "+quoteTable(t.Name)+" WHERE "+m.where, m.args...)
+			if err != nil {
+				return nil, fmt.Errorf("clean %s: %w", t.Name, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				deleted[t.Name] += n
+			}
+		}
+	}
+	for _, t := range plan.Order {
+		if !ledger || len(primaryKey(t)) == 0 {
+			continue
+		}
+		query := "DELETE FROM " + quoteIdent(LedgerTable) + " WHERE table_name = " + placeholder(driver, 1)
+		args := []interface{}{t.Name}
+		if tag.RunID != "" {
+			query += " AND run_id = " + placeholder(driver, 2)
+			args = append(args, tag.RunID)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("clean %s: %w", LedgerTable, err)
+		}
+	}
+	if runs {
+		query := "DELETE FROM " + quoteIdent(RunsTable)
+		var args []interface{}
+		if tag.RunID != "" {
+			query += " WHERE run_id = " + placeholder(driver, 1)
+			args = append(args, tag.RunID)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("clean %s: %w", RunsTable, err)
+		}
+	}
+	return deleted, tx.Commit()
+}
+
+// match is a WHERE clause selecting tagged rows of a table.
+type match struct {
+	where string
+	args  []interface{}
+}
+
+// matches returns the clauses selecting the rows of t that tag marks: by
+// tag column, and with ledger through LedgerTable.
+func (tag *Tag) matches(driver string, t *schema.Table, ledger bool) []match {
+	var out []match
+	if col := tag.column(); t.Column(col) != nil {
+		if tag.RunID == "" {
+			out = append(out, match{where: quoteIdent(col) + " IS NOT NULL"})
+		} else {
+			out = append(out, match{where: quoteIdent(col) + " = " + placeholder(driver, 1), args: []interface{}{tag.RunID}})
+		}
+	}
+	if pk := primaryKey(t); ledger && len(pk) > 0 {
+		m := match{where: keyExpr(pk) + " IN (SELECT pk FROM " + quoteIdent(LedgerTable) + " WHERE table_name = " + placeholder(driver, 1),
+			args: []interface{}{t.Name}}
+		if tag.RunID != "" {
+			m.where += " AND run_id = " + placeholder(driver, 2)
+			m.args = append(m.args, tag.RunID)
+		}
+		m.where += ")"
+		out = append(out, m)
+	}
+	return out
+}
+
+// placeholder returns the n-th query parameter for driver.
+func placeholder(driver string, n int) string {
+	if driver == "sqlite3" {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+// hasTable reports whether the database has a table named name.
+func hasTable(db *sql.DB, driver, name string) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = $1"
+	if driver == "sqlite3" {
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	}
+	var n int
+	if err := db.QueryRow(query, name).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,121 @@
+package inserter
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestTagAndDeleteTagged(t *testing.T) {
+	tables, err := schema.ParseFile(`
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL, seed_batch_id TEXT);
+CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER REFERENCES users(id), title TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := CreateTables(db, "sqlite3", tables); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (email) VALUES ('real@x.io'); INSERT INTO posts (user_id, title) VALUES (1, 'real')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateLedger(db); err != nil {
+		t.Fatal(err)
+	}
+	users, posts := tables[0], tables[1]
+	for _, run := range []string{"run-1", "run-2"} {
+		tag := &Tag{RunID: run, Ledger: true}
+		rows := []map[string]interface{}{{"email": "a@x.io"}, {"email": "b@x.io"}}
+		cols, tagged := tag.Apply(users, []string{"email"}, rows)
+		if !tagged || len(cols) != 2 || rows[1]["seed_batch_id"] != run {
+			t.Fatalf("users not tagged: %v %v", cols, rows)
+		}
+		if _, err := New(db, "sqlite3").InsertBatch("users", cols, rows); err != nil {
+			t.Fatal(err)
+		}
+		if _, tagged := tag.Apply(posts, []string{"title"}, nil); tagged {
+			t.Fatal("posts has no tag column")
+		}
+		rows = []map[string]interface{}{{"user_id": 1, "title": "p1"}, {"user_id": 1, "title": "p2"}}
+		if n, err := New(db, "sqlite3").InsertBatchLedger(posts, []string{"user_id", "title"}, rows, run); err != nil || n != 2 {
+			t.Fatalf("InsertBatchLedger: %d, %v", n, err)
+		}
+	}
+	var recorded int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM _seeddb_rows WHERE run_id = 'run-1' AND table_name = 'posts' AND pk IN ('2', '3')`).Scan(&recorded); err != nil || recorded != 2 {
+		t.Fatalf("expected the posts' SERIAL ids in the ledger, got %d (%v)", recorded, err)
+	}
+
+	deleted, err := DeleteTagged(db, "sqlite3", tables, Tag{RunID: "run-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted["users"] != 2 || deleted["posts"] != 2 {
+		t.Errorf("deleted %v, want 2 users and 2 posts", deleted)
+	}
+	count := func(query string) int {
+		var n int
+		if err := db.QueryRow(query).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(`SELECT COUNT(*) FROM posts WHERE title LIKE 'p%'`); n != 2 {
+		t.Errorf("run-2 posts should remain, got %d", n)
+	}
+
+	if _, err := DeleteTagged(db, "sqlite3", tables, Tag{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(`SELECT COUNT(*) FROM users`) + count(`SELECT COUNT(*) FROM posts`); n != 2 {
+		t.Errorf("only the real rows should remain, got %d", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM _seeddb_rows`); n != 0 {
+		t.Errorf("ledger should be empty, has %d rows", n)
+	}
+}
+
+func TestDeleteTaggedCycle(t *testing.T) {
+	tables, err := schema.ParseFile(`
+CREATE TABLE teams (id INTEGER PRIMARY KEY, owner_id INTEGER REFERENCES users(id), seed_batch_id TEXT);
+CREATE TABLE users (id INTEGER PRIMARY KEY, team_id INTEGER NOT NULL REFERENCES teams(id), seed_batch_id TEXT);
+CREATE TABLE notes (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := CreateTables(db, "sqlite3", tables); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`PRAGMA foreign_keys = ON;
+		INSERT INTO teams (id, seed_batch_id) VALUES (1, 'run-1');
+		INSERT INTO users (id, team_id, seed_batch_id) VALUES (1, 1, 'run-1');
+		UPDATE teams SET owner_id = 1;
+		INSERT INTO notes (user_id) VALUES (1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := DeleteTagged(db, "sqlite3", tables, Tag{RunID: "run-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted["teams"] != 1 || deleted["users"] != 1 {
+		t.Errorf("deleted %v, want the team and its owner", deleted)
+	}
+	var notes int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&notes); err != nil || notes != 0 {
+		t.Errorf("the note should go with its user (ON DELETE CASCADE), %d left (%v)", notes, err)
+	}
+}
@@ -0,0 +1,91 @@
+package inserter
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// ConvertRows rewrites generated values in place so they bind cleanly for
+// driver ("pgx" or "sqlite3"). JSON decoding turns every number into a
+// float64 and every nested object into a map, neither of which the drivers
+// accept for integer or JSON columns.
+func ConvertRows(driver string, t *schema.Table, rows []map[string]interface{}) {
+	for _, row := range rows {
+		for i := range t.Columns {
+			col := &t.Columns[i]
+			if v, ok := row[col.Name]; ok {
+				row[col.Name] = ConvertValue(driver, *col, v)
+			}
+		}
+	}
+}
+
+// ConvertValue converts a single generated value for col.
+func ConvertValue(driver string, col schema.Column, v interface{}) interface{} {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprint(x)
+		}
+		return string(b)
+	case float64:
+		switch col.Type {
+		case "integer":
+			if x == math.Trunc(x) {
+				return int64(x)
+			}
+		case "boolean":
+			return boolValue(driver, x != 0)
+		}
+	case bool:
+		if col.Type == "boolean" {
+			return boolValue(driver, x)
+		}
+		if driver == "sqlite3" {
+			return boolValue(driver, x)
+		}
+	case string:
+		if col.Type == "binary" {
+			return binaryValue(x)
+		}
+		if col.Type == "boolean" {
+			switch strings.ToLower(strings.TrimSpace(x)) {
+			case "true", "t", "yes", "y", "1":
+				return boolValue(driver, true)
+			case "false", "f", "no", "n", "0":
+				return boolValue(driver, false)
+			}
+		}
+	}
+	return v
+}
+
+// binaryValue turns text bound for a binary column into bytes, decoding
+// Postgres hex format (\x0a1b...) when the text is in it.
+func binaryValue(s string) []byte {
+	if strings.HasPrefix(s, `\x`) {
+		if b, err := hex.DecodeString(s[2:]); err == nil {
+			return b
+		}
+	}
+	return []byte(s)
+}
+
+// boolValue returns b as the driver expects it; SQLite has no boolean type.
+func boolValue(driver string, b bool) interface{} {
+	if driver != "sqlite3" {
+		return b
+	}
+	if b {
+		return int64(1)
+	}
+	return int64(0)
+}
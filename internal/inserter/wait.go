@@ -0,0 +1,79 @@
+package inserter
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DefaultServiceWait is how long to wait for a database whose host looks
+// like a docker compose service (see ServiceHost) to come up.
+const DefaultServiceWait = 30 * time.Second
+
+// Pauses between attempts of ConnectWait: the first, and the most it
+// grows to.
+const (
+	firstRetryPause = 250 * time.Millisecond
+	maxRetryPause   = 5 * time.Second
+)
+
+// ConnectWait is Connect for a database that may still be starting, as a
+// compose service often is when seeding runs alongside docker compose up.
+// While the server cannot be reached, or says it is starting up, it tries
+// again with doubling pauses until wait has passed, then returns the last
+// error. Other errors, such as a wrong password, return at once. retry, if
+// not nil, is told about each failed attempt and the pause before the next.
+func ConnectWait(conn string, wait time.Duration, retry func(err error, pause time.Duration)) (*Inserter, error) {
+	deadline := time.Now().Add(wait)
+	pause := firstRetryPause
+	for {
+		ins, err := Connect(conn)
+		if err == nil || !Unreachable(err) {
+			return ins, err
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return nil, err
+		}
+		pause = min(pause, left)
+		if retry != nil {
+			retry(err, pause)
+		}
+		time.Sleep(pause)
+		pause = min(pause*2, maxRetryPause)
+	}
+}
+
+// Unreachable reports whether err means the database server could not be
+// reached yet: the connection was refused, reset or timed out, the host
+// name does not resolve yet, or Postgres is still starting up.
+func Unreachable(err error) bool {
+	var netErr net.Error
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr):
+		return pgErr.Code == "57P03" // cannot_connect_now: starting up or shutting down
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr):
+		return true
+	}
+	return false
+}
+
+// ServiceHost reports whether conn points at a bare host name such as db
+// or postgres — how docker compose names its services — rather than
+// localhost, an IP address or a fully qualified name.
+func ServiceHost(conn string) bool {
+	hosts := ConnHosts(conn)
+	if len(hosts) == 0 {
+		return false
+	}
+	h := hosts[0]
+	return h != "localhost" && net.ParseIP(h) == nil && !strings.Contains(h, ".")
+}
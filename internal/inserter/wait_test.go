@@ -0,0 +1,53 @@
+package inserter
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServiceHost(t *testing.T) {
+	t.Setenv("PGHOST", "")
+	for conn, want := range map[string]bool{
+		"postgres://app:pw@db:5432/shop":              true,
+		"host=postgres dbname=shop":                   true,
+		"postgres://app:pw@localhost:5432/shop":       false,
+		"postgres://app:pw@127.0.0.1/shop":            false,
+		"postgres://app:pw@db.staging.example.com/db": false,
+		"sqlite:./dev.db":                             false,
+	} {
+		if got := ServiceHost(conn); got != want {
+			t.Errorf("ServiceHost(%q) = %v, want %v", conn, got, want)
+		}
+	}
+}
+
+func TestConnectWait(t *testing.T) {
+	// A port nothing listens on refuses connections.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	var retries []time.Duration
+	start := time.Now()
+	_, err = ConnectWait("postgres://app:pw@"+addr+"/shop?connect_timeout=1", 600*time.Millisecond,
+		func(err error, pause time.Duration) { retries = append(retries, pause) })
+	if err == nil || !Unreachable(err) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+	if len(retries) < 2 || retries[0] != firstRetryPause || retries[1] <= retries[0] {
+		t.Errorf("expected growing pauses, got %v", retries)
+	}
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("gave up after %s, want about 600ms", elapsed)
+	}
+
+	retries = nil
+	if _, err := ConnectWait("sqlite:"+t.TempDir()+"/missing/dev.db", time.Minute,
+		func(err error, pause time.Duration) { retries = append(retries, pause) }); err == nil || len(retries) > 0 {
+		t.Errorf("an error other than an unreachable server should not be retried: %v after %d retries", err, len(retries))
+	}
+}
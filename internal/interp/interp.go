@@ -0,0 +1,106 @@
+// Package interp expands the {{ }} expressions allowed in the fixed values
+// of seeddb.yaml, in anchor rows and direct column values:
+//
+//	created_at: "{{ now }}"
+//	company:    '{{ env "COMPANY_NAME" }}'
+//	api_id:     "{{ uuid }}"
+//
+// now is the current time in RFC 3339 and today the current date; env
+// reads an environment variable and fails when it is not set; uuid is a
+// new random (version 4) UUID each time it is expanded. The syntax is
+// text/template's, so "{{ env "ORG" }}-admin" mixes text and expressions.
+// Strings without "{{" are left alone.
+package interp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Env is what expressions read. The zero Env uses the current time and
+// the process environment.
+type Env struct {
+	Now    time.Time                   // zero means time.Now()
+	Lookup func(string) (string, bool) // nil means os.LookupEnv
+}
+
+// Has reports whether s contains an expression.
+func Has(s string) bool { return strings.Contains(s, "{{") }
+
+// Check returns an error if s does not parse or calls an unknown function.
+func Check(s string) error {
+	if !Has(s) {
+		return nil
+	}
+	_, err := parse(s, Env{})
+	return err
+}
+
+// Expand returns s with its expressions evaluated.
+func Expand(s string, env Env) (string, error) {
+	if !Has(s) {
+		return s, nil
+	}
+	t, err := parse(s, env)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, nil); err != nil {
+		if e, ok := err.(template.ExecError); ok {
+			err = e.Err // drop the template name and position
+		}
+		return "", fmt.Errorf("%s: %w", s, err)
+	}
+	return b.String(), nil
+}
+
+// Value expands v if it is a string and returns anything else as it is.
+func Value(v interface{}, env Env) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		return Expand(s, env)
+	}
+	return v, nil
+}
+
+func parse(s string, env Env) (*template.Template, error) {
+	now := env.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	lookup := env.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	t, err := template.New("value").Option("missingkey=error").Funcs(template.FuncMap{
+		"now":   func() string { return now.Format(time.RFC3339) },
+		"today": func() string { return now.Format("2006-01-02") },
+		"env": func(name string) (string, error) {
+			v, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			return v, nil
+		},
+		"uuid": newUUID,
+	}).Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+	return t, nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
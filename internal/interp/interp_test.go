@@ -0,0 +1,51 @@
+package interp
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	env := Env{
+		Now: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		Lookup: func(name string) (string, bool) {
+			if name == "COMPANY_NAME" {
+				return "Acme", true
+			}
+			return "", false
+		},
+	}
+	cases := map[string]string{
+		"plain":                          "plain",
+		"{{ now }}":                      "2024-03-01T09:30:00Z",
+		"since {{today}}":                "since 2024-03-01",
+		`{{ env "COMPANY_NAME" }} Admin`: "Acme Admin",
+	}
+	for src, want := range cases {
+		got, err := Expand(src, env)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q (%v), want %q", src, got, err, want)
+		}
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, err := Expand("{{ uuid }}", env)
+	if err != nil || !uuid.MatchString(a) {
+		t.Errorf("uuid: got %q (%v)", a, err)
+	}
+	if b, _ := Expand("{{ uuid }}", env); a == b {
+		t.Errorf("uuid repeated %s", a)
+	}
+
+	if _, err := Expand(`{{ env "MISSING" }}`, env); err == nil || !strings.Contains(err.Error(), "MISSING is not set") {
+		t.Errorf("unset variable: %v", err)
+	}
+	if err := Check("{{ nwo }}"); err == nil || !strings.Contains(err.Error(), `"nwo" not defined`) {
+		t.Errorf("unknown function: %v", err)
+	}
+	if v, err := Value(42, env); v != 42 || err != nil {
+		t.Errorf("non-string value: got %v (%v)", v, err)
+	}
+}
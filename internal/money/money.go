@@ -0,0 +1,187 @@
+// Package money knows enough about currencies to keep generated amounts
+// and currency codes consistent: ISO 4217 codes, their minor units and a
+// rough exchange rate for picking plausible magnitudes.
+package money
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Currency is one ISO 4217 currency.
+type Currency struct {
+	Code       string
+	MinorUnits int     // digits after the decimal point: 2 for USD, 0 for JPY
+	PerUSD     float64 // approximate units per US dollar, for magnitudes only
+}
+
+var currencies = map[string]Currency{}
+
+func init() {
+	for _, c := range []Currency{
+		{"USD", 2, 1}, {"EUR", 2, 0.92}, {"GBP", 2, 0.79}, {"JPY", 0, 150},
+		{"CNY", 2, 7.2}, {"INR", 2, 83}, {"CAD", 2, 1.36}, {"AUD", 2, 1.52},
+		{"NZD", 2, 1.65}, {"CHF", 2, 0.88}, {"SEK", 2, 10.5}, {"NOK", 2, 10.7},
+		{"DKK", 2, 6.9}, {"PLN", 2, 4.0}, {"CZK", 2, 23}, {"HUF", 2, 360},
+		{"TRY", 2, 32}, {"BRL", 2, 5.0}, {"MXN", 2, 17}, {"ARS", 2, 870},
+		{"CLP", 0, 940}, {"COP", 2, 3900}, {"ZAR", 2, 18.5}, {"NGN", 2, 1500},
+		{"EGP", 2, 48}, {"KRW", 0, 1330}, {"SGD", 2, 1.34}, {"HKD", 2, 7.8},
+		{"TWD", 2, 32}, {"THB", 2, 36}, {"IDR", 2, 15800}, {"VND", 0, 25000},
+		{"PHP", 2, 56}, {"MYR", 2, 4.7}, {"AED", 2, 3.67}, {"SAR", 2, 3.75},
+		{"ILS", 2, 3.7}, {"ISK", 0, 138}, {"KWD", 3, 0.31}, {"BHD", 3, 0.38},
+		{"OMR", 3, 0.38}, {"JOD", 3, 0.71},
+	} {
+		currencies[c.Code] = c
+	}
+}
+
+// aliases maps symbols and common names to codes.
+var aliases = map[string]string{
+	"$": "USD", "US$": "USD", "DOLLAR": "USD", "DOLLARS": "USD", "US DOLLAR": "USD",
+	"€": "EUR", "EURO": "EUR", "EUROS": "EUR",
+	"£": "GBP", "POUND": "GBP", "POUNDS": "GBP", "STERLING": "GBP",
+	"¥": "JPY", "YEN": "JPY", "₹": "INR", "RUPEE": "INR", "RUPEES": "INR",
+	"₩": "KRW", "WON": "KRW", "YUAN": "CNY", "RMB": "CNY", "C$": "CAD", "A$": "AUD",
+	"R$": "BRL", "FRANC": "CHF", "FRANCS": "CHF",
+}
+
+// Lookup returns the currency for an ISO 4217 code (any case).
+func Lookup(code string) (Currency, bool) {
+	c, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// Normalize turns a code, symbol or common name ("usd", "€", "Euro")
+// into an ISO 4217 code.
+func Normalize(s string) (string, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if c, ok := currencies[s]; ok {
+		return c.Code, true
+	}
+	code, ok := aliases[s]
+	return code, ok
+}
+
+// Codes returns the known codes, sorted.
+func Codes() []string {
+	out := make([]string, 0, len(currencies))
+	for code := range currencies {
+		out = append(out, code)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Convert moves amount from one currency to another at the rough rate,
+// rounded to the target's minor units.
+func Convert(amount float64, from, to Currency) float64 {
+	return Round(amount/from.PerUSD*to.PerUSD, to)
+}
+
+// Round rounds amount to the currency's minor units.
+func Round(amount float64, c Currency) float64 {
+	p := math.Pow(10, float64(c.MinorUnits))
+	return math.Round(amount*p) / p
+}
+
+// Pair links a currency column to the amount columns it qualifies.
+type Pair struct {
+	Currency string
+	Amounts  []string
+}
+
+var amountWords = []string{"amount", "price", "total", "cost", "balance", "fee",
+	"salary", "revenue", "tax", "subtotal", "payment", "refund", "budget", "spend"}
+
+// Pairs finds currency columns in t and the amount columns they belong to.
+// A column named like price_currency claims price and price_*; a plain
+// currency or currency_code column claims the remaining amount columns.
+// Tables without a currency column have no pairs.
+func Pairs(t *schema.Table) []Pair {
+	var amounts []string
+	for _, col := range t.Columns {
+		if isAmount(col) {
+			amounts = append(amounts, col.Name)
+		}
+	}
+	claimed := make(map[string]bool)
+	var pairs []Pair
+	var plain []string
+	for _, col := range t.Columns {
+		prefix, ok := currencyPrefix(col)
+		if !ok {
+			continue
+		}
+		if prefix == "" {
+			plain = append(plain, col.Name)
+			continue
+		}
+		p := Pair{Currency: col.Name}
+		for _, a := range amounts {
+			if !claimed[a] && (a == prefix || strings.HasPrefix(a, prefix+"_")) {
+				claimed[a] = true
+				p.Amounts = append(p.Amounts, a)
+			}
+		}
+		if len(p.Amounts) > 0 {
+			pairs = append(pairs, p)
+		}
+	}
+	if len(plain) > 0 {
+		p := Pair{Currency: plain[0]}
+		for _, a := range amounts {
+			if !claimed[a] {
+				p.Amounts = append(p.Amounts, a)
+			}
+		}
+		if len(p.Amounts) > 0 {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// currencyPrefix reports whether col holds a currency code and, for names
+// like price_currency, the prefix naming its amount.
+func currencyPrefix(col schema.Column) (string, bool) {
+	if col.Type != "text" || col.ForeignKey != nil {
+		return "", false
+	}
+	n := strings.ToLower(col.Name)
+	for _, suffix := range []string{"currency_code", "currency"} {
+		if n == suffix {
+			return "", true
+		}
+		if strings.HasSuffix(n, "_"+suffix) {
+			return col.Name[:len(n)-len(suffix)-1], true
+		}
+	}
+	return "", false
+}
+
+func isAmount(col schema.Column) bool {
+	if col.Type != "decimal" && col.Type != "integer" || col.PrimaryKey || col.ForeignKey != nil {
+		return false
+	}
+	n := strings.ToLower(col.Name)
+	if strings.HasSuffix(n, "_id") || strings.HasSuffix(n, "_count") || strings.HasSuffix(n, "_pct") ||
+		strings.HasSuffix(n, "_rate") || strings.HasSuffix(n, "_percent") {
+		return false
+	}
+	for _, w := range amountWords {
+		if strings.Contains(n, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// MinorAmount reports whether an amount column stores minor units
+// (amount_cents), which are whole numbers in every currency.
+func MinorAmount(name string) bool {
+	n := strings.ToLower(name)
+	return strings.HasSuffix(n, "_cents") || strings.HasSuffix(n, "_minor") || strings.HasSuffix(n, "_pence")
+}
@@ -0,0 +1,39 @@
+package money
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestPairs(t *testing.T) {
+	tbl := &schema.Table{Name: "orders", Columns: []schema.Column{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "customer_id", Type: "integer"},
+		{Name: "total", Type: "decimal"},
+		{Name: "tax_rate", Type: "decimal"},
+		{Name: "shipping_cost", Type: "decimal"},
+		{Name: "shipping_currency", Type: "text"},
+		{Name: "currency", Type: "text"},
+	}}
+	got := fmt.Sprint(Pairs(tbl))
+	want := "[{shipping_currency [shipping_cost]} {currency [total]}]"
+	if got != want {
+		t.Errorf("Pairs = %s, want %s", got, want)
+	}
+	if p := Pairs(&schema.Table{Columns: []schema.Column{{Name: "price", Type: "decimal"}}}); p != nil {
+		t.Errorf("no currency column should give no pairs, got %v", p)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{"usd": "USD", " € ": "EUR", "Pounds": "GBP", "KWD": "KWD"} {
+		if got, ok := Normalize(in); !ok || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := Normalize("doubloons"); ok {
+		t.Error("unknown currency should not normalize")
+	}
+}
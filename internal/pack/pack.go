@@ -0,0 +1,212 @@
+// Package pack bundles what it takes to seed the same database again
+// without a model: the schema, the project config, and a record directory
+// holding the model's responses and the rows each table got. The bundle is
+// a gzipped tar with a manifest of checksums.
+package pack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Names inside a pack.
+const (
+	ManifestName  = "manifest.json"
+	SchemaName    = "schema.sql"
+	ConfigName    = "seeddb.yaml"
+	RecordingsDir = "recordings"
+)
+
+// Format is the manifest version this package writes and reads.
+const Format = 1
+
+// MaxEntryBytes caps the size of one file Extract unpacks, so a small
+// pack cannot claim a huge entry and exhaust memory.
+const MaxEntryBytes = 256 << 20
+
+// Manifest describes a pack.
+type Manifest struct {
+	Format  int               `json:"format"`
+	Created time.Time         `json:"created"`
+	Tool    string            `json:"tool"`   // seeddb version that made it
+	Schema  string            `json:"schema"` // original schema path, for reference
+	Config  bool              `json:"config"` // whether ConfigName is included
+	Files   map[string]string `json:"files"`  // path in the pack -> SHA-256
+}
+
+// Write bundles schemaPath, configPath (skipped when empty) and every file
+// under recordDir into w. Entries are sorted and stamped with created, so
+// the same inputs give the same archive.
+func Write(w io.Writer, schemaPath, configPath, recordDir, tool string, created time.Time) (*Manifest, error) {
+	files := map[string]string{SchemaName: schemaPath}
+	if configPath != "" {
+		files[ConfigName] = configPath
+	}
+	err := filepath.WalkDir(recordDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(recordDir, p)
+		if err != nil {
+			return err
+		}
+		files[path.Join(RecordingsDir, filepath.ToSlash(rel))] = p
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("record dir: %w", err)
+	}
+	m := &Manifest{Format: Format, Created: created.UTC(), Tool: tool, Schema: schemaPath,
+		Config: configPath != "", Files: make(map[string]string, len(files))}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(w)
+	gz.ModTime = m.Created
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: m.Created, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	contents := make(map[string][]byte, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(files[name])
+		if err != nil {
+			return nil, err
+		}
+		contents[name] = data
+		sum := sha256.Sum256(data)
+		m.Files[name] = hex.EncodeToString(sum[:])
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := add(ManifestName, append(manifest, '\n')); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err := add(name, contents[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return m, gz.Close()
+}
+
+// Extract unpacks a pack read from r into dir and checks every file
+// against the manifest. Entries that would land outside dir or have a
+// backslash in their name (a separator on Windows), entries over
+// MaxEntryBytes, and files missing from or not listed in the manifest, are
+// errors.
+func Extract(r io.Reader, dir string) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a seeddb pack: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	var m *Manifest
+	seen := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read pack: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if strings.Contains(hdr.Name, `\`) || !filepath.IsLocal(filepath.FromSlash(name)) {
+			return nil, fmt.Errorf("pack entry %q points outside the pack", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(dir, filepath.Clean(target)); err != nil || !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("pack entry %q points outside the pack", hdr.Name)
+		}
+		if hdr.Size > MaxEntryBytes {
+			return nil, fmt.Errorf("%s is %d bytes, over the limit of %d", name, hdr.Size, MaxEntryBytes)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, MaxEntryBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		if len(data) > MaxEntryBytes {
+			return nil, fmt.Errorf("%s is over the limit of %d bytes", name, MaxEntryBytes)
+		}
+		if name == ManifestName {
+			m = new(Manifest)
+			if err := json.Unmarshal(data, m); err != nil {
+				return nil, fmt.Errorf("manifest: %w", err)
+			}
+			if m.Format != Format {
+				return nil, fmt.Errorf("pack format %d is not supported (want %d)", m.Format, Format)
+			}
+		} else {
+			if m == nil {
+				return nil, fmt.Errorf("pack has no %s before %s", ManifestName, name)
+			}
+			want, ok := m.Files[name]
+			if !ok {
+				return nil, fmt.Errorf("%s is not listed in the manifest", name)
+			}
+			if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != want {
+				return nil, fmt.Errorf("%s does not match its checksum; the pack is damaged", name)
+			}
+			seen[name] = true
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	if m == nil {
+		return nil, fmt.Errorf("not a seeddb pack: no %s", ManifestName)
+	}
+	for name := range m.Files {
+		if !seen[name] {
+			return nil, fmt.Errorf("%s is listed in the manifest but missing", name)
+		}
+	}
+	return m, nil
+}
+
+// Read returns the manifest of a pack unpacked into dir, or false if dir
+// is not one.
+func Read(dir string) (*Manifest, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, false
+	}
+	var m Manifest
+	if json.Unmarshal(data, &m) != nil || m.Format != Format {
+		return nil, false
+	}
+	return &m, true
+}
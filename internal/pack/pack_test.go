@@ -0,0 +1,90 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteExtract(t *testing.T) {
+	src := t.TempDir()
+	write := func(name, data string) string {
+		p := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	schemaPath := write("db.sql", "CREATE TABLE users (id INTEGER PRIMARY KEY);")
+	write("rec/ab12.json", `{"response": "[]"}`)
+	write("rec/data/users.jsonl", `{"id": 1}`+"\n")
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var a, b bytes.Buffer
+	if _, err := Write(&a, schemaPath, "", filepath.Join(src, "rec"), "test", created); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(&b, schemaPath, "", filepath.Join(src, "rec"), "test", created); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("the same inputs should give the same archive")
+	}
+
+	dir := t.TempDir()
+	m, err := Extract(bytes.NewReader(a.Bytes()), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 3 || m.Config {
+		t.Errorf("unexpected manifest %+v", m)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, RecordingsDir, "data", "users.jsonl"))
+	if err != nil || string(data) != `{"id": 1}`+"\n" {
+		t.Errorf("recorded rows: %q, %v", data, err)
+	}
+	if _, ok := Read(dir); !ok {
+		t.Error("Read should recognise an unpacked pack")
+	}
+
+	if _, err := Extract(bytes.NewReader(a.Bytes()[:a.Len()/2]), t.TempDir()); err == nil {
+		t.Error("expected an error for a truncated pack")
+	}
+	if _, err := Extract(strings.NewReader("not gzip"), t.TempDir()); err == nil {
+		t.Error("expected an error for a file that is not a pack")
+	}
+}
+
+func TestExtractRefusesUnsafeEntries(t *testing.T) {
+	pack := func(name string, size int64) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if size <= 16 {
+			tw.Write(bytes.Repeat([]byte("x"), int(size)))
+			tw.Close()
+		}
+		gz.Close()
+		return buf.Bytes()
+	}
+	for _, name := range []string{"../evil", `..\..\evil`, `recordings\..\..\evil`, "/etc/evil"} {
+		dir := t.TempDir()
+		if _, err := Extract(bytes.NewReader(pack(name, 4)), dir); err == nil || !strings.Contains(err.Error(), "outside the pack") {
+			t.Errorf("%s: got %v, want an error for an entry outside the pack", name, err)
+		}
+	}
+	if _, err := Extract(bytes.NewReader(pack(ManifestName, MaxEntryBytes+1)), t.TempDir()); err == nil || !strings.Contains(err.Error(), "over the limit") {
+		t.Errorf("huge entry: got %v, want an error", err)
+	}
+}
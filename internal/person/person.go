@@ -0,0 +1,135 @@
+// Package person knows which first names, salutations and pronouns go
+// with which gender, so a row's person columns can be made to agree
+// ("Ms. Maria Garcia, she/her") instead of the mix a model writes.
+package person
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Gender is the gender a row's columns agree on.
+type Gender string
+
+const (
+	Unknown   Gender = ""
+	Male      Gender = "male"
+	Female    Gender = "female"
+	NonBinary Gender = "non-binary"
+)
+
+var maleNames = words(`james john robert michael william david richard joseph thomas charles
+	christopher daniel matthew anthony mark donald steven paul andrew joshua kevin brian george
+	edward ronald timothy jason jeffrey ryan jacob gary nicholas eric jonathan stephen larry
+	justin scott brandon benjamin samuel gregory frank raymond patrick jack dennis jerry tyler
+	aaron jose adam henry nathan peter zachary kyle noah ethan liam oliver lucas mason logan
+	juan carlos luis miguel diego pedro pablo javier alejandro ahmed mohammed muhammad omar ali
+	hassan ivan dmitri sergei hans klaus stefan lukas felix jan piotr marco luca giovanni
+	pierre louis antoine hiroshi takeshi kenji wei hao jun raj arjun rahul vikram amit
+	kwame chinedu tunde oluwaseun`)
+
+var femaleNames = words(`mary patricia jennifer linda elizabeth barbara susan jessica sarah karen
+	lisa nancy betty margaret sandra ashley kimberly emily donna michelle carol amanda dorothy
+	melissa deborah stephanie rebecca sharon laura cynthia kathleen amy angela shirley anna
+	brenda pamela emma nicole helen samantha katherine christine rachel carolyn janet catherine
+	maria heather diane olivia julie joyce victoria ruth virginia lauren kelly christina joan
+	evelyn judith megan andrea cheryl hannah jacqueline martha gloria teresa ann sara madison
+	frances kathryn janice abigail alice sophia isabella mia charlotte amelia harper ava
+	grace chloe zoe lily ella sofia lucia carmen ana elena isabel valentina camila fatima
+	aisha layla olga natasha anastasia ingrid greta anja katarzyna giulia francesca chiara
+	sophie camille marie claire yuki sakura aiko mei ling priya ananya pooja sunita
+	ada chioma ngozi amara`)
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// FromName returns the gender a first name is usually given to, or
+// Unknown for names the table does not have or that are common for both.
+// Only the first word counts, so a full name works too.
+func FromName(name string) Gender {
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return Unknown
+	}
+	n := strings.ToLower(strings.TrimRightFunc(fields[0], func(r rune) bool { return !unicode.IsLetter(r) }))
+	switch {
+	case maleNames[n]:
+		return Male
+	case femaleNames[n]:
+		return Female
+	}
+	return Unknown
+}
+
+// Parse reads a gender column's value: male, M, man, female, F, woman,
+// non-binary, nonbinary, NB, other. Anything else is Unknown.
+func Parse(v string) Gender {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "male", "m", "man", "boy":
+		return Male
+	case "female", "f", "woman", "girl":
+		return Female
+	case "non-binary", "nonbinary", "non_binary", "nb", "x", "other", "enby":
+		return NonBinary
+	}
+	return Unknown
+}
+
+// FromSalutation returns the gender a salutation implies: Mr, Ms, Mrs,
+// Miss or Mx. Dr, Prof and others imply none.
+func FromSalutation(v string) Gender {
+	switch strings.ToLower(strings.TrimSuffix(strings.TrimSpace(v), ".")) {
+	case "mr", "sir", "herr", "monsieur", "señor", "sr":
+		return Male
+	case "ms", "mrs", "miss", "madam", "frau", "madame", "señora", "sra":
+		return Female
+	case "mx":
+		return NonBinary
+	}
+	return Unknown
+}
+
+// FromPronouns returns the gender pronouns like "she/her" imply.
+func FromPronouns(v string) Gender {
+	first, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(v)), "/")
+	switch strings.TrimSpace(first) {
+	case "he", "him", "his":
+		return Male
+	case "she", "her", "hers":
+		return Female
+	case "they", "them", "xe", "ze":
+		return NonBinary
+	}
+	return Unknown
+}
+
+// Salutation returns the salutation for g.
+func Salutation(g Gender) string {
+	switch g {
+	case Male:
+		return "Mr"
+	case Female:
+		return "Ms"
+	case NonBinary:
+		return "Mx"
+	}
+	return ""
+}
+
+// Pronouns returns the pronouns for g.
+func Pronouns(g Gender) string {
+	switch g {
+	case Male:
+		return "he/him"
+	case Female:
+		return "she/her"
+	case NonBinary:
+		return "they/them"
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package person
+
+import "testing"
+
+func TestGender(t *testing.T) {
+	cases := []struct {
+		got, want Gender
+	}{
+		{FromName("Maria Garcia"), Female},
+		{FromName("james"), Male},
+		{FromName("Alex"), Unknown},
+		{Parse("F"), Female},
+		{Parse("Non-Binary"), NonBinary},
+		{Parse("unknown"), Unknown},
+		{FromSalutation("Mrs."), Female},
+		{FromSalutation("Dr."), Unknown},
+		{FromPronouns("her/hers"), Female},
+		{FromPronouns("He/Him"), Male},
+		{FromPronouns("they/them"), NonBinary},
+	}
+	for i, c := range cases {
+		if c.got != c.want {
+			t.Errorf("case %d: got %q, want %q", i+1, c.got, c.want)
+		}
+	}
+}
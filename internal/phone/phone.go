@@ -0,0 +1,141 @@
+// Package phone rewrites phone numbers into one consistent format per
+// column, so seeded data passes the same validation the application does.
+package phone
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Formats.
+const (
+	E164     = "e164"     // +12025550142
+	National = "national" // (202) 555-0142
+)
+
+// Rule is the format for one column.
+type Rule struct {
+	Country string `yaml:"country"` // ISO 3166 alpha-2 code, e.g. US
+	Format  string `yaml:"format"`  // E164 or National; empty means E164
+}
+
+// Country describes how a country writes its numbers.
+type Country struct {
+	Code    string
+	Calling string // country calling code without +
+	Trunk   string // national prefix dialled before the number, e.g. 0
+	Length  int    // digits in the national significant number
+	Pattern string // national format; # is a digit of the significant number
+}
+
+var countries = map[string]Country{}
+
+func init() {
+	for _, c := range []Country{
+		{"US", "1", "", 10, "(###) ###-####"},
+		{"CA", "1", "", 10, "(###) ###-####"},
+		{"GB", "44", "0", 10, "0#### ######"},
+		{"IE", "353", "0", 9, "0## ### ####"},
+		{"DE", "49", "0", 10, "0### #######"},
+		{"FR", "33", "0", 9, "0# ## ## ## ##"},
+		{"ES", "34", "", 9, "### ### ###"},
+		{"IT", "39", "", 10, "### ### ####"},
+		{"NL", "31", "0", 9, "0## ### ####"},
+		{"SE", "46", "0", 9, "0##-### ## ##"},
+		{"PL", "48", "", 9, "### ### ###"},
+		{"IN", "91", "0", 10, "##### #####"},
+		{"JP", "81", "0", 10, "0##-####-####"},
+		{"CN", "86", "0", 11, "### #### ####"},
+		{"KR", "82", "0", 10, "0##-####-####"},
+		{"SG", "65", "", 8, "#### ####"},
+		{"AU", "61", "0", 9, "0# #### ####"},
+		{"NZ", "64", "0", 9, "0## ### ####"},
+		{"BR", "55", "0", 11, "(##) #####-####"},
+		{"MX", "52", "", 10, "## #### ####"},
+		{"ZA", "27", "0", 9, "0## ### ####"},
+		{"NG", "234", "0", 10, "0### ### ####"},
+		{"AE", "971", "0", 9, "0## ### ####"},
+	} {
+		countries[c.Code] = c
+	}
+}
+
+// Lookup returns the country for an alpha-2 code (any case).
+func Lookup(code string) (Country, bool) {
+	c, ok := countries[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// Countries returns the supported codes, sorted.
+func Countries() []string {
+	out := make([]string, 0, len(countries))
+	for code := range countries {
+		out = append(out, code)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Format rewrites s as a number of country c in the given format. Calling
+// codes, trunk prefixes and punctuation are stripped first; a number with
+// too many digits keeps its last digits and one with too few is padded
+// from seed, so every row gets a well-formed number. It fails when s has
+// fewer than four digits, which is not a phone number at all.
+func Format(s string, c Country, format string, seed int) (string, error) {
+	digits := onlyDigits(s)
+	if len(digits) < 4 {
+		return "", fmt.Errorf("%q is not a phone number", s)
+	}
+	intl := strings.HasPrefix(strings.TrimSpace(s), "+")
+	if strings.HasPrefix(digits, "00") {
+		digits, intl = digits[2:], true
+	}
+	if (intl || len(digits) > c.Length) && strings.HasPrefix(digits, c.Calling) {
+		digits = digits[len(c.Calling):]
+	}
+	if c.Trunk != "" && len(digits) > c.Length && strings.HasPrefix(digits, c.Trunk) {
+		digits = digits[len(c.Trunk):]
+	}
+	if len(digits) > c.Length {
+		digits = digits[len(digits)-c.Length:]
+	}
+	for i := 0; len(digits) < c.Length; i++ {
+		digits += string(rune('0' + (seed+i*7)%10))
+	}
+	if c.Calling == "1" && (digits[0] == '0' || digits[0] == '1') {
+		digits = "2" + digits[1:] // NANP area codes start with 2-9
+	}
+	if c.Trunk != "" && strings.HasPrefix(digits, c.Trunk) {
+		digits = "1" + digits[1:] // a significant number never starts with the trunk prefix
+	}
+	if format == National {
+		return fill(c.Pattern, digits), nil
+	}
+	return "+" + c.Calling + digits, nil
+}
+
+// fill replaces each # in pattern with the next digit.
+func fill(pattern, digits string) string {
+	var b strings.Builder
+	i := 0
+	for _, r := range pattern {
+		if r == '#' && i < len(digits) {
+			b.WriteByte(digits[i])
+			i++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String() + digits[i:]
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
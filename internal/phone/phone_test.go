@@ -0,0 +1,31 @@
+package phone
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	us, _ := Lookup("us")
+	gb, _ := Lookup("GB")
+	cases := []struct {
+		in     string
+		c      Country
+		format string
+		want   string
+	}{
+		{"(202) 555-0142", us, E164, "+12025550142"},
+		{"1-202-555-0142", us, National, "(202) 555-0142"},
+		{"+1 202.555.0142 ", us, National, "(202) 555-0142"},
+		{"555-0142", us, E164, "+15550142074"},
+		{"020 7946 0018", gb, E164, "+442079460018"},
+		{"+44 20 7946 0018", gb, National, "02079 460018"},
+		{"0044 7700 900123", gb, National, "07700 900123"},
+	}
+	for _, c := range cases {
+		got, err := Format(c.in, c.c, c.format, 0)
+		if err != nil || got != c.want {
+			t.Errorf("Format(%q, %s, %s) = %q, %v; want %q", c.in, c.c.Code, c.format, got, err, c.want)
+		}
+	}
+	if _, err := Format("n/a", us, E164, 0); err == nil {
+		t.Error("expected an error for a value without digits")
+	}
+}
@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/interp"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Anchor is a row that must exist with fixed values, such as the admin
+// login of a demo or a "Default" category. Run inserts the anchors that
+// are missing before it generates anything, and the generated rows of
+// tables referencing the anchor's table may point at it.
+//
+// A foreign key value of "@name" is the referenced column of the anchor
+// called name in the referenced table, so anchors can refer to each other
+// without knowing their IDs; "@@" starts a value that really begins with
+// "@". Text values may hold {{ }} expressions such as {{ now }}, expanded
+// when the anchor is looked up; the values that find it (see insertAnchors)
+// should not change between runs.
+type Anchor struct {
+	Name   string
+	Values map[string]interface{} // column -> value
+}
+
+// AnchorResult is an anchor as the database has it.
+type AnchorResult struct {
+	Table    string
+	Name     string
+	Inserted bool                   // false if it was already there
+	Row      map[string]interface{} // every column, IDs included
+}
+
+// CheckAnchors returns an error if an anchor is unnamed, shares its name
+// with another of its table, has no values, or names a table or column s
+// does not have.
+func CheckAnchors(anchors map[string][]Anchor, s *schema.Schema) error {
+	for _, table := range sortedTables(anchors) {
+		t := s.TableMap[table]
+		if t == nil {
+			return fmt.Errorf("anchors: unknown table %q", table)
+		}
+		names := make(map[string]bool)
+		for i, a := range anchors[table] {
+			if a.Name == "" {
+				return fmt.Errorf("anchors.%s[%d]: needs a name", table, i)
+			}
+			if names[a.Name] {
+				return fmt.Errorf("anchors.%s: %q is named twice", table, a.Name)
+			}
+			names[a.Name] = true
+			if len(a.Values) == 0 {
+				return fmt.Errorf("anchors.%s.%s: needs values", table, a.Name)
+			}
+			for col := range a.Values {
+				if t.Column(col) == nil {
+					return fmt.Errorf("anchors.%s.%s: unknown column %q", table, a.Name, col)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// insertAnchors makes sure the anchors of the tables are in the database,
+// taking the tables in insert order, and returns them with the rows keyed
+// by table for refValues. An anchor is looked up by its UNIQUE and primary
+// key values, or all of its values if it has none, and inserted only when
+// no row matches.
+func insertAnchors(opts Options, ins *inserter.Inserter, tables []*schema.Table) ([]AnchorResult, map[string][]map[string]interface{}, error) {
+	var out []AnchorResult
+	rows := make(map[string][]map[string]interface{})
+	for _, t := range tables {
+		for _, a := range opts.Anchors[t.Name] {
+			values, err := resolveAnchor(opts, ins, t, a, out)
+			if err != nil {
+				return out, rows, &TableError{Op: OpAnchor, Table: t.Name, Err: err}
+			}
+			match := anchorMatch(t, values)
+			row, err := ins.FindRow(t.Name, match)
+			if err != nil {
+				return out, rows, &TableError{Op: OpAnchor, Table: t.Name, Err: err}
+			}
+			inserted := row == nil
+			if inserted {
+				if row, err = insertAnchor(opts, ins, t, values, match); err != nil {
+					return out, rows, &TableError{Op: OpAnchor, Table: t.Name, Err: fmt.Errorf("%s: %w", a.Name, err)}
+				}
+			}
+			out = append(out, AnchorResult{Table: t.Name, Name: a.Name, Inserted: inserted, Row: row})
+			rows[t.Name] = append(rows[t.Name], row)
+		}
+	}
+	return out, rows, nil
+}
+
+// resolveAnchor returns a's values with their {{ }} expressions expanded
+// (see package interp) and "@name" references in foreign key columns
+// replaced by the referenced anchor's value. Anchors of tables
+// outside the run are looked up in the database.
+func resolveAnchor(opts Options, ins *inserter.Inserter, t *schema.Table, a Anchor, done []AnchorResult) (map[string]interface{}, error) {
+	env := interp.Env{}
+	if opts.Clock != nil {
+		env.Now = opts.Clock.Now()
+	}
+	values := make(map[string]interface{}, len(a.Values))
+	for col, v := range a.Values {
+		if s, ok := v.(string); ok && strings.HasPrefix(s, "@") && !strings.HasPrefix(s, "@@") {
+			fk := t.Column(col).ForeignKey
+			if fk == nil {
+				return nil, fmt.Errorf("%s.%s: %s is not a foreign key, so it cannot refer to an anchor (write @@ for a literal @)", a.Name, col, col)
+			}
+			row, err := anchorRow(opts, ins, fk.RefTable, s[1:], done)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", a.Name, col, err)
+			}
+			values[col] = row[fk.RefColumn]
+			continue
+		}
+		if s, ok := v.(string); ok && strings.HasPrefix(s, "@@") {
+			v = s[1:]
+		}
+		var err error
+		if values[col], err = interp.Value(v, env); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", a.Name, col, err)
+		}
+	}
+	return values, nil
+}
+
+// anchorRow returns the row of the anchor called name in table.
+func anchorRow(opts Options, ins *inserter.Inserter, table, name string, done []AnchorResult) (map[string]interface{}, error) {
+	for _, r := range done {
+		if r.Table == table && r.Name == name {
+			return r.Row, nil
+		}
+	}
+	for _, a := range opts.Anchors[table] {
+		if a.Name != name {
+			continue
+		}
+		t := opts.Schema.TableMap[table]
+		values, err := resolveAnchor(opts, ins, t, a, done)
+		if err != nil {
+			return nil, err
+		}
+		row, err := ins.FindRow(table, anchorMatch(t, values))
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return nil, fmt.Errorf("anchor @%s of %s is not in the database; seed %s first", name, table, table)
+		}
+		return row, nil
+	}
+	return nil, fmt.Errorf("%s has no anchor @%s", table, name)
+}
+
+// anchorMatch returns the values that identify an anchor's row.
+func anchorMatch(t *schema.Table, values map[string]interface{}) map[string]interface{} {
+	match := make(map[string]interface{})
+	for _, c := range t.Columns {
+		if v, ok := values[c.Name]; ok && (c.Unique || c.PrimaryKey) {
+			match[c.Name] = v
+		}
+	}
+	if len(match) == 0 {
+		return values
+	}
+	return match
+}
+
+// insertAnchor inserts one anchor, tagged like the generated rows, and
+// reads it back with the values the database filled in.
+func insertAnchor(opts Options, ins *inserter.Inserter, t *schema.Table, values, match map[string]interface{}) (map[string]interface{}, error) {
+	var columns []string
+	for _, c := range t.Columns {
+		if _, ok := values[c.Name]; ok {
+			columns = append(columns, c.Name)
+		}
+	}
+	row := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		row[k] = v
+	}
+	rows := []map[string]interface{}{row}
+	inserter.ConvertRows(ins.Driver(), t, rows)
+	columns, tagged := opts.Tag.Apply(t, columns, rows)
+	var err error
+	if opts.Tag != nil && opts.Tag.Ledger && !tagged {
+		_, err = ins.InsertBatchLedger(t, columns, rows, opts.Tag.RunID)
+	} else {
+		_, err = ins.InsertBatch(t.Name, columns, rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := ins.FindRow(t.Name, match)
+	if err == nil && found == nil {
+		err = errors.New("inserted but not found again")
+	}
+	return found, err
+}
+
+// withAnchors puts the column values of the anchored rows first in ids,
+// so children can always reference them, keeping at most limit values.
+func withAnchors(anchored []map[string]interface{}, column string, ids []interface{}, limit int) []interface{} {
+	if len(anchored) == 0 {
+		return ids
+	}
+	var out []interface{}
+	seen := make(map[string]bool)
+	for _, row := range anchored {
+		if v := row[column]; v != nil && !seen[fmt.Sprint(v)] {
+			seen[fmt.Sprint(v)] = true
+			out = append(out, v)
+		}
+	}
+	for _, v := range ids {
+		if len(out) >= limit {
+			break
+		}
+		if !seen[fmt.Sprint(v)] {
+			seen[fmt.Sprint(v)] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func sortedTables(anchors map[string][]Anchor) []string {
+	names := make([]string, 0, len(anchors))
+	for name := range anchors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Row counts for archetypes, relative to Options.Rows (see
+// Options.ArchetypeRows).
+const (
+	// LookupRows caps lookup tables: a handful of statuses or categories,
+	// not a hundred.
+	LookupRows = 10
+	// JoinRowsFactor and EventRowsFactor scale join tables and event logs,
+	// which hold several rows per entity.
+	JoinRowsFactor  = 2
+	EventRowsFactor = 5
+)
+
+// archetype returns t's archetype, as configured or guessed.
+func (o Options) archetype(t *schema.Table) schema.Archetype {
+	return o.Config.ArchetypeOf(t, o.Schema)
+}
+
+// archetypeRows returns the rows wanted in t when base rows are asked for
+// per table: a lookup gets at most LookupRows, or one per allowed value of
+// a UNIQUE column with a CHECK list; join tables and event logs get more.
+func archetypeRows(t *schema.Table, a schema.Archetype, base int) int {
+	switch a {
+	case schema.ArchetypeLookup:
+		n := LookupRows
+		for _, c := range t.Columns {
+			if c.Unique && len(c.CheckIn) > 0 {
+				n = len(c.CheckIn)
+				break
+			}
+		}
+		return min(base, n)
+	case schema.ArchetypeJoin:
+		return base * JoinRowsFactor
+	case schema.ArchetypeEvent:
+		return base * EventRowsFactor
+	}
+	return base
+}
+
+// style returns the generation style for t: Config.Style, except that
+// with ArchetypeStyle join tables get short minimal values, since their
+// rows are mostly the links.
+func (o Options) style(t *schema.Table) string {
+	if o.ArchetypeStyle && o.archetype(t) == schema.ArchetypeJoin {
+		return string(generator.StyleMinimal)
+	}
+	return string(o.Config.Style)
+}
+
+// distinctLinks gives every row of a join table its own combination of
+// FK values: a row repeating one already in links is redrawn from refIDs
+// with r, and dropped if no free combination turns up. It returns the
+// rows dropped.
+func distinctLinks(t *schema.Table, gr *generator.GenerationResult, refIDs map[string][]interface{}, links map[string]bool, r *rand.Rand) int {
+	fks := t.FKColumns()
+	key := func(row map[string]interface{}) string {
+		parts := make([]string, len(fks))
+		for i, c := range fks {
+			parts[i] = fmt.Sprint(row[c.Name])
+		}
+		return strings.Join(parts, "\x00")
+	}
+	kept := gr.Rows[:0]
+	for _, row := range gr.Rows {
+		k := key(row)
+		for try := 0; links[k] && try < 20; try++ {
+			for _, c := range fks {
+				if ids := refIDs[c.Name]; len(ids) > 0 {
+					row[c.Name] = ids[r.IntN(len(ids))]
+				}
+			}
+			k = key(row)
+		}
+		if links[k] {
+			continue
+		}
+		links[k] = true
+		kept = append(kept, row)
+	}
+	dropped := len(gr.Rows) - len(kept)
+	gr.Rows = kept
+	return dropped
+}
+
+// sortEvents orders an event log's rows by their time column, so ids
+// grow with time as in a real log. Rows without a time go last.
+func sortEvents(t *schema.Table, rows []map[string]interface{}) {
+	col := schema.EventTimeColumn(t)
+	if col == "" {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i][col], rows[j][col]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	})
+}
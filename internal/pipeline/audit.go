@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"math/rand/v2"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// actorValues returns, for each actor column of t that is not a foreign
+// key (see schema.ActorColumns), the values it may take: the matching
+// column of the users table (see schema.ActorKey), sampled from the
+// database or, in a dry run, from the users generated so far.
+func actorValues(opts Options, t *schema.Table, generated map[string][]map[string]interface{}) map[string][]interface{} {
+	users := opts.usersTable()
+	if users == nil {
+		return nil
+	}
+	limit := opts.RefIDLimit
+	if limit <= 0 {
+		limit = DefaultRefIDLimit
+	}
+	out := make(map[string][]interface{})
+	for _, c := range schema.ActorColumns(t) {
+		key := schema.ActorKey(users, c)
+		if c.ForeignKey != nil || key == "" {
+			continue
+		}
+		var vals []interface{}
+		if opts.DB != nil {
+			vals, _ = inserter.FetchRefIDs(opts.DB, opts.Driver, users.Name, key, limit, opts.Config.Random())
+		} else {
+			for _, row := range generated[users.Name] {
+				if v := row[key]; v != nil && len(vals) < limit {
+					vals = append(vals, v)
+				}
+			}
+		}
+		if len(vals) > 0 {
+			out[c.Name] = vals
+		}
+	}
+	return out
+}
+
+// usersTable returns the schema's users table (see schema.UsersTable).
+func (o Options) usersTable() *schema.Table {
+	if o.Schema == nil {
+		return nil
+	}
+	return schema.UsersTable(o.Schema.Tables)
+}
+
+// fillActors sets the actor columns in values to one of their values,
+// drawn from r, in place of the made-up names and ids the model puts there.
+// deleted_by is only set in rows that are soft-deleted.
+func fillActors(t *schema.Table, rows []map[string]interface{}, values map[string][]interface{}, r *rand.Rand) {
+	if len(values) == 0 {
+		return
+	}
+	at, flag, by := schema.SoftDeleteColumns(t)
+	for _, row := range rows {
+		for col, vals := range values {
+			if col == by && !softDeleted(row, at, flag, by) {
+				continue
+			}
+			row[col] = vals[r.IntN(len(vals))]
+		}
+	}
+}
+
+// softDeleted reports whether row is deleted, by its deletion time, flag
+// or, failing both, deleted_by.
+func softDeleted(row map[string]interface{}, at, flag, by string) bool {
+	switch {
+	case at != "":
+		return row[at] != nil
+	case flag != "":
+		return row[flag] == true
+	}
+	return row[by] != nil
+}
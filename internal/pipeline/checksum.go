@@ -0,0 +1,35 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Checksum is an order-independent hash of a table's rows: the sum, mod
+// 2^256, of the SHA-256 of each row as JSON with its keys sorted. Rows
+// may be added chunk by chunk and in any order; a repeated row counts
+// twice. Rows are hashed as generated, before they are converted for a
+// driver or tagged with a run ID, so a replay into another database has
+// the same checksum as the original run.
+type Checksum struct {
+	sum [sha256.Size]byte
+}
+
+// Add adds row to the checksum.
+func (c *Checksum) Add(row map[string]interface{}) {
+	data, err := json.Marshal(row) // map keys come out sorted
+	if err != nil {
+		data = []byte(fmt.Sprint(row))
+	}
+	h := sha256.Sum256(data)
+	carry := 0
+	for i := len(c.sum) - 1; i >= 0; i-- {
+		v := int(c.sum[i]) + int(h[i]) + carry
+		c.sum[i], carry = byte(v), v>>8
+	}
+}
+
+// String returns the checksum in hex.
+func (c Checksum) String() string { return hex.EncodeToString(c.sum[:]) }
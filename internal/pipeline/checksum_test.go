@@ -0,0 +1,24 @@
+package pipeline
+
+import "testing"
+
+func TestChecksum(t *testing.T) {
+	a := map[string]interface{}{"id": 1, "email": "a@x.io"}
+	b := map[string]interface{}{"email": "b@x.io", "id": 2}
+	sum := func(rows ...map[string]interface{}) string {
+		var c Checksum
+		for _, r := range rows {
+			c.Add(r)
+		}
+		return c.String()
+	}
+	if sum(a, b) != sum(b, a) {
+		t.Error("checksum depends on row order")
+	}
+	if sum(a, b) == sum(a, a) || sum(a) == sum(a, a) {
+		t.Error("different rows have the same checksum")
+	}
+	if sum() != "0000000000000000000000000000000000000000000000000000000000000000" {
+		t.Errorf("empty checksum = %s", sum())
+	}
+}
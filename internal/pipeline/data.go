@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DataDir is the subdirectory of a record directory (see
+// generator.Config.RecordDir) that holds the rows each table was given,
+// one JSON object per line in <table>.jsonl. A replay from a directory
+// that has them inserts those rows as they were, so the database comes out
+// the same without running the local passes again.
+const DataDir = "data"
+
+// bytesKey wraps binary values, which JSON would turn into plain strings.
+const bytesKey = "$bytes"
+
+func dataPath(dir, table string) string {
+	return filepath.Join(dir, DataDir, table+".jsonl")
+}
+
+// saveRows appends rows to the table's data file, which first truncates.
+func saveRows(dir, table string, rows []map[string]interface{}, first bool) error {
+	if err := os.MkdirAll(filepath.Join(dir, DataDir), 0o755); err != nil {
+		return fmt.Errorf("record rows: %w", err)
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if first {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(dataPath(dir, table), flag, 0o644)
+	if err != nil {
+		return fmt.Errorf("record rows: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		out := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			if b, ok := v.([]byte); ok {
+				v = map[string]string{bytesKey: base64.StdEncoding.EncodeToString(b)}
+			}
+			out[k] = v
+		}
+		if err := enc.Encode(out); err != nil {
+			f.Close()
+			return fmt.Errorf("record rows of %s: %w", table, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("record rows: %w", err)
+	}
+	return f.Close()
+}
+
+// LoadRows reads the rows saved for table in dir. ok is false when none
+// were saved.
+func LoadRows(dir, table string) (rows []map[string]interface{}, ok bool, err error) {
+	f, err := os.Open(dataPath(dir, table))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("replay rows: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err != nil {
+			return nil, false, fmt.Errorf("replay rows of %s, line %d: %w", table, line, err)
+		}
+		for k, v := range row {
+			row[k] = savedValue(v)
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, false, fmt.Errorf("replay rows of %s: %w", table, err)
+	}
+	return rows, true, nil
+}
+
+// savedValue undoes the JSON encoding of a saved value: whole numbers
+// become int64 and wrapped bytes []byte again.
+func savedValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		if s, ok := x[bytesKey].(string); ok && len(x) == 1 {
+			if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
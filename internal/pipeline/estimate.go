@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Defaults for EstimateRun.
+const (
+	DefaultCalibrationRows = 10
+	// DefaultInsertRate is the rows per second assumed for inserts when no
+	// Limits.MaxWriteRate says otherwise; batched inserts into a local
+	// database usually run well above it.
+	DefaultInsertRate = 5000
+)
+
+// Estimate is the outcome of EstimateRun.
+type Estimate struct {
+	Rows       int           // rows the run asks for
+	Calibrated string        // table the sample was generated for
+	RowsPerSec float64       // generation speed on that table
+	Generate   time.Duration // generating every table
+	Insert     time.Duration // inserting every row
+	Total      time.Duration // generation and inserts overlap, so the longer of the two
+	Usage      generator.Usage
+}
+
+// PlannedRows returns the rows opts asks for in each of tables.
+func PlannedRows(opts Options, tables []*schema.Table) map[string]int {
+	out := make(map[string]int, len(tables))
+	for _, t := range tables {
+		out[t.Name] = opts.rows(t)
+	}
+	return out
+}
+
+// EstimateRun times a dry run of sample rows for the table that will get
+// the most rows and extrapolates to the whole run. Generation time is taken
+// to grow with rows times columns, since that is what the model writes.
+func EstimateRun(ctx context.Context, opts Options, tables []*schema.Table, sample int) (*Estimate, error) {
+	if sample <= 0 {
+		sample = DefaultCalibrationRows
+	}
+	planned := PlannedRows(opts, tables)
+	est := &Estimate{}
+	var widest *schema.Table
+	for _, t := range tables {
+		est.Rows += planned[t.Name]
+		if widest == nil || planned[t.Name] > planned[widest.Name] {
+			widest = t
+		}
+	}
+	if widest == nil {
+		return nil, fmt.Errorf("no tables to estimate")
+	}
+
+	dry := opts
+	dry.Tables, dry.DB, dry.TopUp, dry.Rows, dry.TableRows = []*schema.Table{widest}, nil, false, sample, nil
+	dry.ArchetypeRows, dry.MinimalViable, dry.Coverage, dry.Journal, dry.Resume = false, false, false, nil, nil
+	dry.ChunkRows, dry.Limits = sample, Limits{}
+	res, err := Run(ctx, dry, nil)
+	if err != nil {
+		return nil, err
+	}
+	est.Usage = res.Usage
+	est.Calibrated = widest.Name
+	gr := res.Tables[0].Generated
+	if len(gr.Rows) == 0 || gr.Elapsed <= 0 {
+		return nil, fmt.Errorf("calibration on %s produced no rows", widest.Name)
+	}
+	est.RowsPerSec = float64(len(gr.Rows)) / gr.Elapsed.Seconds()
+	perCell := gr.Elapsed.Seconds() / float64(len(gr.Rows)*max(1, len(widest.NonAutoColumns())))
+	for _, t := range tables {
+		cells := planned[t.Name] * max(1, len(t.NonAutoColumns()))
+		est.Generate += time.Duration(float64(cells) * perCell * float64(time.Second))
+	}
+	if opts.DB != nil {
+		rate := opts.Limits.MaxWriteRate
+		if rate <= 0 {
+			rate = DefaultInsertRate
+		}
+		est.Insert = time.Duration(float64(est.Rows) / rate * float64(time.Second))
+	}
+	est.Total = max(est.Generate, est.Insert)
+	return est, nil
+}
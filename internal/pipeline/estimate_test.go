@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// stepClock advances one second every time it is read.
+type stepClock struct{ t time.Time }
+
+func (c *stepClock) Now() time.Time {
+	c.t = c.t.Add(time.Second)
+	return c.t
+}
+
+func TestEstimateRun(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`,
+	}
+	cfg := generator.DefaultConfig()
+	cfg.MaxFollowUps = 0
+	opts := Options{Schema: s, Rows: 100, TableRows: map[string]int{"posts": 1000}, Config: cfg,
+		Client: client, Clock: &stepClock{}, Limits: Limits{MaxWriteRate: 100}}
+	est, err := EstimateRun(context.Background(), opts, s.Tables, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.Calibrated != "posts" || est.Rows != 1100 {
+		t.Errorf("calibrated on %s for %d rows, want posts for 1100", est.Calibrated, est.Rows)
+	}
+	// 2 rows of 2 columns took 1s: 1000 posts of 2 and 100 users of 1.
+	if want := 525 * time.Second; est.Generate != want {
+		t.Errorf("Generate = %v, want %v", est.Generate, want)
+	}
+	if est.Insert != 0 || est.Total != est.Generate {
+		t.Errorf("a dry run has no inserts: Insert %v, Total %v", est.Insert, est.Total)
+	}
+}
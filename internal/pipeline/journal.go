@@ -0,0 +1,227 @@
+package pipeline
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+)
+
+// DefaultJournalPath is where `seed` keeps its journal.
+const DefaultJournalPath = ".seeddb/journal.jsonl"
+
+// Journal records which insert batches committed, one JSON line per event,
+// synced to disk before and after every batch. After a crash, ResumeJournal
+// reads it back so the next run inserts only the rows still missing.
+//
+// A nil *Journal records nothing.
+type Journal struct {
+	f *os.File
+}
+
+// journalRecord is one line of the journal. Op is run, resume, table,
+// begin, commit, abort (a begun batch rolled back, to be retried smaller),
+// done or finish.
+type journalRecord struct {
+	Op       string    `json:"op"`
+	Time     time.Time `json:"time"`
+	Schema   string    `json:"schema,omitempty"`
+	RunID    string    `json:"run_id,omitempty"` // run, resume: the run's inserter.Tag ID
+	Table    string    `json:"table,omitempty"`
+	Rows     int       `json:"rows,omitempty"`     // begin, commit: rows in the batch
+	Existing int       `json:"existing,omitempty"` // table: rows in it before the run
+	Want     int       `json:"want,omitempty"`     // table: rows the run adds
+}
+
+// CreateJournal starts a new journal at path for a run seeding schema,
+// replacing any earlier one. runID, if set, is the ID the run tags its
+// rows with; a resumed run keeps it.
+func CreateJournal(path, schema, runID string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{f: f}
+	if err := j.write(journalRecord{Op: "run", Schema: schema, RunID: runID}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
+
+// write appends r and syncs the file.
+func (j *Journal) write(r journalRecord) error {
+	if j == nil {
+		return nil
+	}
+	r.Time = time.Now().UTC()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	return nil
+}
+
+// Resume is the state of an interrupted run, read from its journal.
+type Resume struct {
+	Schema   string // schema file of the interrupted run
+	RunID    string // ID the interrupted run tagged its rows with
+	Finished bool   // the run completed; there is nothing to resume
+	Tables   map[string]*TableProgress
+}
+
+// TableProgress is how far the interrupted run got with one table.
+type TableProgress struct {
+	Existing int  // rows in the table before the run
+	Want     int  // rows the run set out to add
+	Inserted int  // rows committed so far
+	Done     bool // every row is in
+	pending  int  // rows of a batch begun but not recorded as committed
+}
+
+// table returns the progress recorded for name, or nil. It is nil-safe.
+func (r *Resume) table(name string) *TableProgress {
+	if r == nil {
+		return nil
+	}
+	return r.Tables[name]
+}
+
+// LoadJournal reads the journal at path. A torn last line, left by a crash
+// in the middle of a write, is ignored.
+func LoadJournal(path string) (*Resume, error) {
+	r, _, err := loadJournal(path)
+	return r, err
+}
+
+// loadJournal is LoadJournal, also returning the size of the journal
+// without a torn last line.
+func loadJournal(path string) (*Resume, int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	r := &Resume{Tables: make(map[string]*TableProgress)}
+	var size int64
+	for line := 1; len(data) > 0; line++ {
+		text, rest, complete := bytes.Cut(data, []byte("\n"))
+		if !complete {
+			break // every record ends in a newline; this one was torn
+		}
+		var rec journalRecord
+		if err := json.Unmarshal(text, &rec); err != nil {
+			if len(bytes.TrimSpace(rest)) > 0 {
+				return nil, 0, fmt.Errorf("%s:%d: %w", path, line, err) // only the last line may be torn
+			}
+			break
+		}
+		r.apply(rec)
+		size += int64(len(text)) + 1
+		data = rest
+	}
+	if r.Schema == "" {
+		return nil, 0, fmt.Errorf("%s: not a seed journal", path)
+	}
+	return r, size, nil
+}
+
+// apply updates r with one journal record.
+func (r *Resume) apply(rec journalRecord) {
+	tp := r.Tables[rec.Table]
+	switch rec.Op {
+	case "run":
+		r.Schema, r.RunID = rec.Schema, rec.RunID
+	case "finish":
+		r.Finished = true
+	case "table":
+		if tp == nil { // a resumed run records the table again; keep the original
+			r.Tables[rec.Table] = &TableProgress{Existing: rec.Existing, Want: rec.Want}
+		}
+	case "begin":
+		if tp != nil {
+			tp.pending = rec.Rows
+		}
+	case "commit":
+		if tp != nil {
+			tp.Inserted += rec.Rows
+			tp.pending = 0
+		}
+	case "abort":
+		if tp != nil {
+			tp.pending = 0
+		}
+	case "done":
+		if tp == nil {
+			tp = &TableProgress{}
+			r.Tables[rec.Table] = tp
+		}
+		tp.Done = true
+	}
+}
+
+// ResumeJournal reads the journal at path for a run that did not finish and
+// reopens it for the resumed run. A batch that began without a recorded
+// commit is settled by counting the table's rows in db, so the crash
+// window between COMMIT and the journal write costs no duplicates. That
+// count assumes nothing else wrote to the table in between.
+func ResumeJournal(path string, db *sql.DB) (*Journal, *Resume, error) {
+	r, size, err := loadJournal(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.Finished {
+		return nil, r, nil
+	}
+	if err := os.Truncate(path, size); err != nil { // drop a torn last line
+		return nil, nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	j := &Journal{f: f}
+	if err := j.write(journalRecord{Op: "resume", Schema: r.Schema, RunID: r.RunID}); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	for name, tp := range r.Tables {
+		if tp.pending == 0 {
+			continue
+		}
+		n, err := inserter.CountRows(db, name)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		committed := min(max(n-tp.Existing-tp.Inserted, 0), tp.pending)
+		tp.Inserted += committed
+		tp.pending = 0
+		if err := j.write(journalRecord{Op: "commit", Table: name, Rows: committed}); err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+	}
+	return j, r, nil
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestResumeJournal(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	// The crashed run found a batch of four users too large and committed
+	// it as two of two, but died before recording the second commit,
+	// halfway through the next line.
+	for _, email := range []string{"a@x.io", "b@x.io", "c@x.io", "d@x.io"} {
+		if _, err := db.Exec(`INSERT INTO users (email) VALUES (?)`, email); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	crashed := `{"op":"run","schema":"schema.sql"}
+{"op":"table","table":"users","want":5}
+{"op":"begin","table":"users","rows":4}
+{"op":"abort","table":"users"}
+{"op":"begin","table":"users","rows":2}
+{"op":"commit","table":"users","rows":2}
+{"op":"begin","table":"users","rows":2}
+{"op":"comm`
+	if err := os.WriteFile(path, []byte(crashed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	j, resume, err := ResumeJournal(path, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp := resume.Tables["users"]; tp == nil || tp.Inserted != 4 || tp.Done {
+		t.Fatalf("expected users at 4 of 5 rows, got %+v", tp)
+	}
+	client := tableClient{
+		"users": `[{"email": "e@x.io"}, {"email": "a@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}]`,
+	}
+	res, err := Run(context.Background(), Options{Schema: s, Rows: 1, Config: generator.DefaultConfig(),
+		Client: client, DB: db, Driver: "sqlite3", Journal: j, Resume: resume}, nil)
+	j.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users := res.Tables[0]; users.Inserted != 1 || users.Existing != 4 {
+		t.Errorf("users: inserted %d with %d existing, want 1 with 4", users.Inserted, users.Existing)
+	}
+	if n, _ := inserter.CountRows(db, "users"); n != 5 {
+		t.Errorf("users has %d rows, want 5", n)
+	}
+
+	done, err := LoadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !done.Finished || !done.Tables["users"].Done || done.Tables["users"].Inserted != 5 || done.Tables["posts"].Inserted != 1 {
+		t.Errorf("journal after the resumed run: %+v", done)
+	}
+}
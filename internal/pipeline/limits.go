@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Limits are safety caps on a run, so a typo such as --rows 1000000
+// against a shared database is refused before anything is generated. Zero
+// fields are not enforced.
+type Limits struct {
+	MaxTableRows int     // rows wanted in any one table
+	MaxTotalRows int     // rows wanted across the run
+	MaxWriteRate float64 // rows inserted per second; inserts wait to stay under it
+}
+
+// LimitError reports a run that asks for more rows than Limits allow.
+type LimitError struct {
+	Table string // empty for the total
+	Rows  int    // rows the run would write
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	if e.Table == "" {
+		return fmt.Sprintf("the run would write %d rows, over the limit of %d (max_total_rows)", e.Rows, e.Max)
+	}
+	return fmt.Sprintf("%s would get %d rows, over the limit of %d (max_rows_per_table)", e.Table, e.Rows, e.Max)
+}
+
+// CheckLimits returns a *LimitError if the rows opts asks for break
+// opts.Limits. Run checks this itself before it starts; callers can check
+// earlier to fail before other work, such as sizing.
+func CheckLimits(opts Options, tables []*schema.Table) error {
+	l := opts.Limits
+	if l.MaxTableRows <= 0 && l.MaxTotalRows <= 0 {
+		return nil
+	}
+	total := 0
+	for _, t := range tables {
+		n := opts.rows(t)
+		if l.MaxTableRows > 0 && n > l.MaxTableRows {
+			return &LimitError{Table: t.Name, Rows: n, Max: l.MaxTableRows}
+		}
+		total += n
+	}
+	if l.MaxTotalRows > 0 && total > l.MaxTotalRows {
+		return &LimitError{Rows: total, Max: l.MaxTotalRows}
+	}
+	return nil
+}
+
+// ParseRate reads "10/s", "300/m", "5000/h" or a bare number of rows per
+// second, and returns rows per second.
+func ParseRate(s string) (float64, error) {
+	num, unit, _ := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %q (want e.g. 10/s, 300/m)", s)
+	}
+	switch unit {
+	case "", "s", "sec":
+		return n, nil
+	case "m", "min":
+		return n / 60, nil
+	case "h", "hour":
+		return n / 3600, nil
+	}
+	return 0, fmt.Errorf("invalid rate unit %q (use s, m or h)", unit)
+}
+
+// pacer holds inserts to a rate across the whole run. Time spent
+// generating is not banked, so a table inserted after a slow one does not
+// get a burst.
+type pacer struct {
+	rate float64 // rows per second; 0 means no limit
+	next time.Time
+}
+
+// wrote records n more rows and waits as long as writing them takes at
+// the rate.
+func (p *pacer) wrote(ctx context.Context, n int) error {
+	if p == nil || p.rate <= 0 {
+		return nil
+	}
+	if now := time.Now(); p.next.Before(now) {
+		p.next = now
+	}
+	p.next = p.next.Add(time.Duration(float64(n) / p.rate * float64(time.Second)))
+	if wait := time.Until(p.next); wait > 0 {
+		return sleepCtx(ctx, wait)
+	}
+	return nil
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
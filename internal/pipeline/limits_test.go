@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestCheckLimits(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Schema: s, Rows: 100, TableRows: map[string]int{"posts": 300}}
+	for _, tc := range []struct {
+		limits Limits
+		table  string // "" for the total, "-" for no error
+	}{
+		{Limits{}, "-"},
+		{Limits{MaxTableRows: 300, MaxTotalRows: 400}, "-"},
+		{Limits{MaxTableRows: 250}, "posts"},
+		{Limits{MaxTotalRows: 399}, ""},
+	} {
+		opts.Limits = tc.limits
+		err := CheckLimits(opts, s.Tables)
+		var le *LimitError
+		switch {
+		case tc.table == "-" && err != nil:
+			t.Errorf("%+v: unexpected error %v", tc.limits, err)
+		case tc.table != "-" && (!errors.As(err, &le) || le.Table != tc.table):
+			t.Errorf("%+v: got %v, want a limit error for %q", tc.limits, err, tc.table)
+		}
+	}
+
+	opts.Limits = Limits{MaxTotalRows: 10}
+	if _, err := Run(context.Background(), opts, nil); err == nil {
+		t.Error("Run started a run over its limits")
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for in, want := range map[string]float64{"10/s": 10, "300/m": 5, "7200/h": 2, "4": 4} {
+		if got, err := ParseRate(in); err != nil || got != want {
+			t.Errorf("ParseRate(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0/s", "5/d", "fast"} {
+		if _, err := ParseRate(in); err == nil {
+			t.Errorf("ParseRate(%q): expected an error", in)
+		}
+	}
+}
+
+func TestPacer(t *testing.T) {
+	p := &pacer{rate: 1000}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := p.wrote(context.Background(), 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("60 rows at 1000/s took %v, want about 60ms", elapsed)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.wrote(ctx, 1000); !errors.Is(err, context.Canceled) {
+		t.Errorf("wrote on a cancelled context = %v", err)
+	}
+}
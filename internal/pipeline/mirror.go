@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Mirror is another database that gets the same rows as Options.DB. Rows
+// are generated once, with foreign keys drawn from Options.DB, and each
+// chunk is inserted into every mirror after it commits there.
+type Mirror struct {
+	Name   string // in errors and Result.Mirrored
+	DB     *sql.DB
+	Driver string
+}
+
+// CheckMirrors returns an error unless every table has as many rows in
+// each mirror as in opts.DB, and its auto-increment columns will hand out
+// the same next ID. Generated foreign keys point at IDs in opts.DB, so the
+// mirrors must give the rows the same IDs for the data to match; a
+// sequence that moved on in one target, e.g. after rows were deleted,
+// would shift every reference.
+func CheckMirrors(opts Options, tables []*schema.Table) error {
+	for _, t := range tables {
+		want, err := inserter.CountRows(opts.DB, t.Name)
+		if err != nil {
+			return &TableError{Op: OpInsert, Table: t.Name, Err: err}
+		}
+		for _, m := range opts.Mirrors {
+			n, err := inserter.CountRows(m.DB, t.Name)
+			if err != nil {
+				return &TableError{Op: OpInsert, Table: t.Name, Err: fmt.Errorf("%s: %w", m.Name, err)}
+			}
+			if n != want {
+				return fmt.Errorf("%s has %d rows in %s but %d in the first database; the targets must start out the same (e.g. empty)", t.Name, n, m.Name, want)
+			}
+		}
+		for _, c := range t.AutoColumns() {
+			next, ok, err := inserter.NextID(opts.DB, opts.Driver, t.Name, c.Name)
+			if err != nil {
+				return &TableError{Op: OpInsert, Table: t.Name, Err: fmt.Errorf("next %s: %w", c.Name, err)}
+			}
+			if !ok {
+				continue
+			}
+			for _, m := range opts.Mirrors {
+				got, ok, err := inserter.NextID(m.DB, m.Driver, t.Name, c.Name)
+				if err != nil {
+					return &TableError{Op: OpInsert, Table: t.Name, Err: fmt.Errorf("%s: next %s: %w", m.Name, c.Name, err)}
+				}
+				if ok && got != next {
+					return fmt.Errorf("%s.%s would start at %d in %s but at %d in the first database; reset the sequences so the targets hand out the same IDs", t.Name, c.Name, got, m.Name, next)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mirrors inserts into the mirrors of a run, each at its own write rate.
+type mirrors struct {
+	list []Mirror
+	ins  []*inserter.Inserter
+	pace []*pacer
+}
+
+func newMirrors(opts Options) *mirrors {
+	ms := &mirrors{list: opts.Mirrors}
+	for _, m := range opts.Mirrors {
+		ms.ins = append(ms.ins, inserter.New(m.DB, m.Driver))
+		ms.pace = append(ms.pace, &pacer{rate: opts.Limits.MaxWriteRate})
+	}
+	return ms
+}
+
+// closeStatements closes the statements each mirror's inserter kept.
+func (ms *mirrors) closeStatements() {
+	for _, in := range ms.ins {
+		in.CloseStatements()
+	}
+}
+
+// copyRows returns a copy of rows for each mirror to convert and tag, or
+// nil without mirrors.
+func (ms *mirrors) copyRows(rows []map[string]interface{}) []map[string]interface{} {
+	if len(ms.list) == 0 {
+		return nil
+	}
+	return cloneRows(rows)
+}
+
+// insert inserts rows into every mirror, counting them in res.Mirrored.
+func (ms *mirrors) insert(ctx context.Context, t *schema.Table, columns []string, rows []map[string]interface{}, batchSize int, tag *inserter.Tag, res *Result) error {
+	for i, m := range ms.list {
+		gr := &generator.GenerationResult{Columns: columns, Rows: cloneRows(rows)}
+		batch := batchSize
+		n, _, err := insert(ctx, ms.ins[i], t, gr, &batch, nil, tag, ms.pace[i], false)
+		if res.Mirrored == nil {
+			res.Mirrored = make(map[string]int, len(ms.list))
+		}
+		res.Mirrored[m.Name] += n
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+func cloneRows(rows []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		c := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			c[k] = v
+		}
+		out[i] = c
+	}
+	return out
+}
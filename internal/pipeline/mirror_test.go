@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func memoryDB(t *testing.T, s *schema.Schema) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestRunMirrors(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, shared := memoryDB(t, s), memoryDB(t, s)
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`,
+	}
+	opts := Options{Schema: s, Rows: 2, Config: generator.DefaultConfig(), Client: client,
+		DB: db, Driver: "sqlite3", Mirrors: []Mirror{{Name: "shared", DB: shared, Driver: "sqlite3"}},
+		Tag: &inserter.Tag{RunID: "run-1"}}
+	if err := CheckMirrors(opts, s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Run(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 4 || res.Mirrored["shared"] != 4 {
+		t.Fatalf("inserted %d, mirrored %v", res.Inserted, res.Mirrored)
+	}
+	dump := func(db *sql.DB) string {
+		var b strings.Builder
+		if err := inserter.Dump(db, &b); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	if a, b := dump(db), dump(shared); a != b {
+		t.Errorf("databases differ:\n%s\n---\n%s", a, b)
+	}
+
+	// A further run needs the targets to start out the same.
+	if _, err := shared.Exec(`DELETE FROM posts`); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMirrors(opts, s.Tables); err == nil || !strings.Contains(err.Error(), "posts has 0 rows in shared but 2") {
+		t.Errorf("CheckMirrors = %v", err)
+	}
+
+	// Same rows, but the sequence of one target is ahead: the next users
+	// would get other IDs there.
+	db, shared = memoryDB(t, s), memoryDB(t, s)
+	if _, err := shared.Exec(`INSERT INTO users (email) VALUES ('gone@x.io'); DELETE FROM users`); err != nil {
+		t.Fatal(err)
+	}
+	opts.DB, opts.Mirrors[0].DB = db, shared
+	if err := CheckMirrors(opts, s.Tables); err == nil || !strings.Contains(err.Error(), "users.id would start at 2 in shared but at 1") {
+		t.Errorf("CheckMirrors with a sequence ahead = %v", err)
+	}
+
+	opts.DropRejected = true
+	if _, err := Run(context.Background(), opts, nil); err == nil || !strings.Contains(err.Error(), "DropRejected cannot be combined with Mirrors") {
+		t.Errorf("Run with DropRejected and mirrors = %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// orderParents moves rows that reference another row of the same table
+// (a reply and the post it answers) after that row, so the parent is
+// inserted first and gets the lower id. Other rows keep their order.
+func orderParents(t *schema.Table, rows []map[string]interface{}) {
+	var refs []schema.Column
+	for _, c := range t.FKColumns() {
+		if strings.EqualFold(c.ForeignKey.RefTable, t.Name) {
+			refs = append(refs, c)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+	keys := make(map[string]map[string]int, len(refs))
+	for _, c := range refs {
+		col := c.ForeignKey.RefColumn
+		if keys[col] != nil {
+			continue
+		}
+		keys[col] = make(map[string]int)
+		for i, row := range rows {
+			if v := row[col]; v != nil {
+				keys[col][fmt.Sprint(v)] = i
+			}
+		}
+	}
+
+	const (
+		unseen = iota
+		visiting
+		placed
+	)
+	state := make([]int, len(rows))
+	ordered := make([]map[string]interface{}, 0, len(rows))
+	var place func(i int)
+	place = func(i int) {
+		if state[i] != unseen {
+			return // placed, or a cycle of references
+		}
+		state[i] = visiting
+		for _, c := range refs {
+			if v := rows[i][c.Name]; v != nil {
+				if p, ok := keys[c.ForeignKey.RefColumn][fmt.Sprint(v)]; ok {
+					place(p)
+				}
+			}
+		}
+		state[i] = placed
+		ordered = append(ordered, rows[i])
+	}
+	for i := range rows {
+		place(i)
+	}
+	copy(rows, ordered)
+}
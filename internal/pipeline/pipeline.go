@@ -0,0 +1,675 @@
+// Package pipeline runs a whole seeding job: tables in dependency order,
+// FK values fetched from rows already inserted, generation, validation and
+// batched inserts. The CLI, the TUI and library callers all share it.
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// Defaults used when the matching Options field is zero.
+const (
+	DefaultBatchSize  = 500
+	DefaultRefIDLimit = 1000
+	DefaultQueueDepth = 2
+	DefaultChunkRows  = 1000
+)
+
+// Options configures one run.
+type Options struct {
+	Schema *schema.Schema
+	Tables []*schema.Table // tables to seed, in insert order; nil means all of Schema.Tables
+	Rows   int             // rows per table
+	// TableRows overrides Rows for the tables it names, e.g. to follow
+	// production volumes from a stats.Profile.
+	TableRows map[string]int
+	// ArchetypeRows scales Rows by each table's archetype (see
+	// archetypeRows) for the tables TableRows leaves out: fewer rows for
+	// lookups, more for join tables and event logs. ArchetypeStyle picks
+	// the style per archetype instead of always Config.Style. Callers set
+	// them when the user did not choose rows or a style.
+	ArchetypeRows  bool
+	ArchetypeStyle bool
+	// MinimalViable replaces all of the above with the fewest rows that
+	// show every value of each table's enum-like columns (see
+	// generator.Config.MinimalRows), and has the generator cover them.
+	MinimalViable bool
+	// Coverage raises each table's rows to at least the number its
+	// boundaries need (see generator.Config.Boundaries), and has the
+	// generator place them.
+	Coverage bool
+
+	Config generator.Config
+	Client generator.LLMClient // nil means Ollama as configured in Config
+	Clock  generator.Clock     // nil means generator.SystemClock
+
+	// DB and Driver ("pgx" or "sqlite3") receive the rows. With DB nil the
+	// run is a dry run: rows are generated and validated but not inserted,
+	// unless Inserter is set: it then gets them, BatchSize at a time, and
+	// FK values come from the rows generated earlier, as in a dry run.
+	DB         *sql.DB
+	Driver     string
+	Inserter   inserter.BatchInserter
+	BatchSize  int
+	RefIDLimit int // FK values sampled per referenced column
+	// RefSkew, when positive, reassigns FK columns after generation so the
+	// k-th sampled parent is picked with weight 1/k^RefSkew: a few parents
+	// get most of the children, as with real users and their orders. Zero
+	// lets the model choose among the sampled values.
+	RefSkew float64
+
+	// TopUp counts the rows already in each table and generates only
+	// enough to reach Rows. Generated rows that repeat an existing UNIQUE
+	// value are dropped. It needs DB.
+	TopUp bool
+	// SkipPopulated counts the rows already in each table and skips the
+	// tables that have at least Rows; the others get their Rows in full.
+	// Generated rows that repeat an existing UNIQUE value are dropped. It
+	// needs DB.
+	SkipPopulated bool
+
+	// DropRejected keeps the rest of a batch when the database rejects some
+	// of its rows: they are retried one at a time behind savepoints and the
+	// ones that fail again are dropped and listed in TableResult.Rejected,
+	// instead of failing the run. Rejected rows may still use up
+	// auto-increment IDs, so it cannot be combined with Mirrors.
+	DropRejected bool
+
+	// QueueDepth is how many generated chunks may wait for their insert
+	// while later ones are generated.
+	QueueDepth int
+	// ChunkRows caps the rows generated, validated and inserted at a time.
+	// Larger tables are seeded chunk by chunk, so memory stays flat however
+	// many rows are asked for: their TableResult.Generated keeps the totals
+	// but no rows, and rows repeating a UNIQUE value of an earlier chunk
+	// are dropped.
+	ChunkRows int
+
+	// Journal, if set, records every insert batch before and after it
+	// commits. Resume continues the run a journal recorded: finished
+	// tables are skipped and the others get only their missing rows.
+	Journal *Journal
+	Resume  *Resume
+
+	// Tag, if set, marks every inserted row with the run's ID; see
+	// inserter.Tag.
+	Tag *inserter.Tag
+
+	// TableTimeout, when positive, fails a table that takes longer to
+	// generate and insert. A model call cannot be interrupted, so one that
+	// overruns is left to finish in the background and its rows dropped;
+	// inserts stop at the next batch.
+	TableTimeout time.Duration
+	// SkipTimedOut gives up a table that runs past TableTimeout instead
+	// of failing the run: its committed rows stay, tables referencing it
+	// are given up too, and the rest are seeded. Result.Abandoned lists
+	// them.
+	SkipTimedOut bool
+	// FailOnIssues stops the run before inserting a table whose generated
+	// rows have validation issues.
+	FailOnIssues bool
+
+	// Limits caps the rows the run may ask for and how fast it inserts.
+	Limits Limits
+
+	// Mirrors get the rows inserted into DB as well; see Mirror. They
+	// need DB and cannot be combined with TopUp, Resume or DropRejected.
+	Mirrors []Mirror
+
+	// Anchors are rows that must exist, keyed by table; see Anchor. They
+	// need DB and are not counted in Rows.
+	Anchors  map[string][]Anchor
+	anchored map[string][]map[string]interface{} // the anchors' rows, for refValues
+}
+
+// Stage says where a table is in the pipeline.
+type Stage int
+
+const (
+	StageGenerating Stage = iota // Progress holds the latest generator step
+	StageGenerated               // Table holds the generated rows and validation issues
+	StageInserting               // rows are being written
+	StageInserted                // Table.Inserted is final
+	StageFailed                  // Err says why; the run stops
+	StageSkipped                 // top-up or SkipPopulated: Result.Existing already reaches Rows
+	StageAbandoned               // SkipTimedOut: Err says why the table was given up; the run goes on
+)
+
+// Event reports progress for one table.
+type Event struct {
+	Table    string
+	Stage    Stage
+	Progress generator.Progress
+	Result   *TableResult
+	Err      error
+}
+
+// ProgressFunc receives events. Run calls it from both of its stages, one
+// call at a time; it should return quickly, since a slow call holds up
+// both.
+type ProgressFunc func(Event)
+
+// TableResult is the outcome for one table.
+type TableResult struct {
+	Name      string
+	Generated *generator.GenerationResult
+	Rows      int                          // rows generated, after drops
+	Issues    []*validator.ValidationError // validator findings for the generated rows
+	Inserted  int
+	Existing  int           // rows already in the table (top-up and SkipPopulated runs)
+	Dropped   int           // generated rows skipped for repeating an existing UNIQUE value or join table link
+	Elapsed   time.Duration // generating, validating and inserting the table
+	Inserting time.Duration // the part of Elapsed spent inserting
+	Err       error         // why the table was given up (see Options.SkipTimedOut)
+	Checksum  Checksum      // of the generated rows
+	Rejected  []RejectedRow // rows the database refused (see Options.DropRejected)
+	BatchSize int           // rows per INSERT, below Options.BatchSize if larger batches were too large for the database
+}
+
+// RejectedRow is a generated row the database refused to insert.
+type RejectedRow struct {
+	Row int // the row's number among the table's generated rows, from 1
+	Err error
+}
+
+// Generating returns the part of Elapsed spent generating and validating
+// the table's rows.
+func (tr *TableResult) Generating() time.Duration { return tr.Elapsed - tr.Inserting }
+
+// Short reports whether fewer rows were generated than requested.
+func (tr *TableResult) Short() bool {
+	return tr.Generated != nil && tr.Rows < tr.Generated.Requested
+}
+
+// Op names the step of a table that failed.
+type Op string
+
+const (
+	OpCount    Op = "count"    // counting existing rows for a top-up
+	OpGenerate Op = "generate" // calling the model or parsing its answer
+	OpCheck    Op = "check"    // reading existing UNIQUE values for a top-up
+	OpValidate Op = "validate" // FailOnIssues found validation issues
+	OpInsert   Op = "insert"   // writing rows
+	OpJournal  Op = "journal"  // recording progress in the Journal
+	OpAnchor   Op = "anchor"   // finding or inserting an Anchor
+)
+
+// TableError is the error Run returns when a table fails.
+type TableError struct {
+	Op    Op
+	Table string
+	Err   error
+}
+
+func (e *TableError) Error() string { return fmt.Sprintf("%s %s: %v", e.Op, e.Table, e.Err) }
+
+func (e *TableError) Unwrap() error { return e.Err }
+
+// Result is the outcome of a run. On error it holds the tables finished
+// before the failure.
+type Result struct {
+	Tables   []*TableResult
+	Usage    generator.Usage
+	Inserted int
+	DryRun   bool
+	Anchors  []AnchorResult
+	Mirrored map[string]int // mirror name -> rows inserted
+}
+
+// Abandoned returns the tables given up under Options.SkipTimedOut.
+func (r *Result) Abandoned() []*TableResult {
+	var out []*TableResult
+	for _, tr := range r.Tables {
+		if tr.Err != nil {
+			out = append(out, tr)
+		}
+	}
+	return out
+}
+
+// Run seeds the tables in opts. progress may be nil. The context is checked
+// between tables and between insert batches.
+//
+// Generation and inserts overlap: while one chunk is being inserted the
+// next ones are generated, up to QueueDepth chunks ahead. A table is only
+// generated once the tables its foreign keys reference are inserted, so
+// its FK values come from their rows.
+func Run(ctx context.Context, opts Options, progress ProgressFunc) (*Result, error) {
+	if progress == nil {
+		progress = func(Event) {}
+	}
+	tables := opts.Tables
+	if tables == nil && opts.Schema != nil {
+		tables = opts.Schema.Tables
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	depth := opts.QueueDepth
+	if depth <= 0 {
+		depth = DefaultQueueDepth
+	}
+	if err := CheckLimits(opts, tables); err != nil {
+		return &Result{}, err
+	}
+	if opts.DropRejected && len(opts.Mirrors) > 0 {
+		return &Result{}, errors.New("DropRejected cannot be combined with Mirrors: rows DB rejects may use up its auto-increment IDs, and the mirrors' would no longer match")
+	}
+	pace := &pacer{rate: opts.Limits.MaxWriteRate}
+
+	// Both stages report progress. emit keeps the calls one at a time and
+	// drops any that come after the run has ended.
+	var mu sync.Mutex
+	ended := false
+	emit := func(ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ended {
+			progress(ev)
+		}
+	}
+	end := func() {
+		mu.Lock()
+		ended = true
+		mu.Unlock()
+	}
+	defer end()
+
+	opts.Config.CoverValues = opts.Config.CoverValues || opts.MinimalViable
+	opts.Config.Coverage = opts.Config.Coverage || opts.Coverage
+	client := opts.Client
+	if client == nil {
+		client = generator.NewOllamaClient(opts.Config, nil)
+	}
+	gen := generator.NewWithClient(opts.Config, client)
+	if opts.Clock != nil {
+		gen.SetClock(opts.Clock)
+	}
+	if opts.Tag != nil && opts.Tag.Ledger && opts.DB != nil {
+		if err := inserter.CreateLedger(opts.DB); err != nil {
+			return &Result{}, &TableError{Op: OpInsert, Table: inserter.LedgerTable, Err: err}
+		}
+	}
+	var ins *inserter.Inserter
+	if opts.DB != nil {
+		ins = inserter.New(opts.DB, opts.Driver)
+		defer ins.CloseStatements()
+	}
+	ms := newMirrors(opts)
+	defer ms.closeStatements()
+	for i, m := range opts.Mirrors {
+		if opts.Tag != nil && opts.Tag.Ledger {
+			if err := inserter.CreateLedger(m.DB); err != nil {
+				return &Result{}, &TableError{Op: OpInsert, Table: inserter.LedgerTable, Err: fmt.Errorf("%s: %w", m.Name, err)}
+			}
+		}
+		if len(opts.Anchors) > 0 {
+			if _, _, err := insertAnchors(opts, ms.ins[i], tables); err != nil {
+				return &Result{}, fmt.Errorf("%s: %w", m.Name, err)
+			}
+		}
+	}
+	var anchors []AnchorResult
+	if ins != nil && len(opts.Anchors) > 0 {
+		var err error
+		if anchors, opts.anchored, err = insertAnchors(opts, ins, tables); err != nil {
+			return &Result{Anchors: anchors}, err
+		}
+	}
+	g := newGenStage(ctx, opts, gen, emit, tables)
+	defer close(g.stop)
+	queue := make(chan chunk, depth)
+	go g.run(tables, queue)
+
+	res := &Result{DryRun: opts.DB == nil && opts.Inserter == nil, Anchors: anchors}
+	fail := func(table string, err error) (*Result, error) {
+		emit(Event{Table: table, Stage: StageFailed, Err: err})
+		end()
+		return res, err
+	}
+	var tr *TableResult
+	abandoned := make(map[string]bool)
+	giveUp := func(t *schema.Table, err error) {
+		tr.Err = err
+		abandoned[t.Name] = true
+		g.abandon(t.Name)
+		emit(Event{Table: t.Name, Stage: StageAbandoned, Result: tr, Err: err})
+		close(g.inserted[t.Name])
+	}
+	for c := range queue {
+		t := c.t
+		if abandoned[t.Name] {
+			continue // chunks generated before an insert timed out
+		}
+		if c.first && (c.gr != nil || c.skipped || c.abandon) {
+			tr = &TableResult{Name: t.Name, Existing: c.existing}
+			res.Tables = append(res.Tables, tr)
+		}
+		if c.skipped {
+			if err := opts.Journal.write(journalRecord{Op: "done", Table: t.Name}); err != nil {
+				return fail(t.Name, &TableError{Op: OpJournal, Table: t.Name, Err: err})
+			}
+			emit(Event{Table: t.Name, Stage: StageSkipped, Result: tr})
+			close(g.inserted[t.Name])
+			continue
+		}
+		if c.gr != nil {
+			tr.add(c)
+			res.Usage.Add(c.gr.Usage)
+			if c.last || c.err != nil {
+				emit(Event{Table: t.Name, Stage: StageGenerated, Result: tr})
+			}
+		}
+		if c.abandon {
+			giveUp(t, c.err)
+			continue
+		}
+		if c.err != nil {
+			return fail(t.Name, c.err)
+		}
+		if opts.DB == nil {
+			if opts.Inserter != nil {
+				if c.first {
+					emit(Event{Table: t.Name, Stage: StageInserting, Result: tr})
+				}
+				n, err := insertInto(opts.Inserter, t, c.gr, batchSize)
+				tr.Inserted += n
+				res.Inserted += n
+				if err != nil {
+					return fail(t.Name, &TableError{Op: OpInsert, Table: t.Name, Err: err})
+				}
+			}
+			if c.last {
+				close(g.inserted[t.Name])
+				if opts.Inserter != nil {
+					emit(Event{Table: t.Name, Stage: StageInserted, Result: tr})
+				}
+			}
+			continue
+		}
+		if c.first {
+			if err := opts.Journal.write(journalRecord{Op: "table", Table: t.Name, Existing: c.existing, Want: c.want}); err != nil {
+				return fail(t.Name, &TableError{Op: OpJournal, Table: t.Name, Err: err})
+			}
+			emit(Event{Table: t.Name, Stage: StageInserting, Result: tr})
+		}
+		started := time.Now()
+		ictx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline := opts.deadline(tr.Elapsed); !deadline.IsZero() {
+			ictx, cancel = context.WithDeadline(ctx, deadline)
+		}
+		columns, copies := c.gr.Columns, ms.copyRows(c.gr.Rows)
+		if c.first {
+			tr.BatchSize = batchSize
+		}
+		n, rejected, err := insert(ictx, ins, t, c.gr, &tr.BatchSize, opts.Journal, opts.Tag, pace, opts.DropRejected)
+		for _, r := range rejected {
+			tr.Rejected = append(tr.Rejected, RejectedRow{Row: c.offset + r.Row + 1, Err: r.Err})
+		}
+		if err == nil && copies != nil {
+			err = ms.insert(ictx, t, columns, copies, tr.BatchSize, opts.Tag, res)
+		}
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			err = timeoutError(opts.TableTimeout)
+		}
+		tr.Inserted += n
+		took := time.Since(started)
+		tr.Elapsed += took
+		tr.Inserting += took
+		res.Inserted += n
+		if err != nil {
+			err = &TableError{Op: OpInsert, Table: t.Name, Err: err}
+			if opts.SkipTimedOut && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				giveUp(t, err)
+				continue
+			}
+			return fail(t.Name, err)
+		}
+		if c.last {
+			if err := opts.Journal.write(journalRecord{Op: "done", Table: t.Name}); err != nil {
+				return fail(t.Name, &TableError{Op: OpJournal, Table: t.Name, Err: err})
+			}
+			close(g.inserted[t.Name])
+			emit(Event{Table: t.Name, Stage: StageInserted, Result: tr})
+		}
+	}
+	if err := opts.Journal.write(journalRecord{Op: "finish"}); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// rows returns the rows wanted in t.
+func (o Options) rows(t *schema.Table) int {
+	n := o.Rows
+	switch tn, ok := o.TableRows[t.Name]; {
+	case o.MinimalViable:
+		n = o.Config.MinimalRows(t)
+	case ok:
+		n = tn
+	case o.ArchetypeRows:
+		n = archetypeRows(t, o.archetype(t), o.Rows)
+	}
+	if o.Coverage {
+		n = max(n, o.Config.CoverageRows(t))
+	}
+	return n
+}
+
+// deadline returns when a table that has already taken used runs out of
+// time, or the zero time without a TableTimeout.
+func (o Options) deadline(used time.Duration) time.Time {
+	if o.TableTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(o.TableTimeout - used)
+}
+
+// within returns fn's result, or a timeout error if fn is still running at
+// deadline. A zero deadline waits for fn. fn is not interrupted; whatever
+// it returns late is dropped.
+func within[T any](deadline time.Time, limit time.Duration, fn func() (T, error)) (T, error) {
+	if deadline.IsZero() {
+		return fn()
+	}
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-timer.C:
+		var zero T
+		return zero, timeoutError(limit)
+	}
+}
+
+// timeoutError is the error for a table that ran past TableTimeout. It
+// wraps context.DeadlineExceeded.
+func timeoutError(limit time.Duration) error {
+	return fmt.Errorf("took longer than %s: %w", limit, context.DeadlineExceeded)
+}
+
+// refValues returns the values each FK column of t may use, keyed by
+// column name: rows already in the database, anchors first, or in a dry
+// run the rows generated earlier in this run.
+func refValues(opts Options, t *schema.Table, generated map[string][]map[string]interface{}) map[string][]interface{} {
+	limit := opts.RefIDLimit
+	if limit <= 0 {
+		limit = DefaultRefIDLimit
+	}
+	refIDs := make(map[string][]interface{})
+	for _, c := range t.FKColumns() {
+		fk := c.ForeignKey
+		if fk == nil {
+			continue
+		}
+		var ids []interface{}
+		if opts.DB != nil {
+			ids, _ = inserter.FetchRefIDs(opts.DB, opts.Driver, fk.RefTable, fk.RefColumn, limit, opts.Config.Random())
+			ids = withAnchors(opts.anchored[fk.RefTable], fk.RefColumn, ids, limit)
+		} else {
+			for _, row := range generated[fk.RefTable] {
+				if v, ok := row[fk.RefColumn]; ok && v != nil && len(ids) < limit {
+					ids = append(ids, v)
+				}
+			}
+		}
+		if len(ids) > 0 {
+			refIDs[c.Name] = ids
+		}
+	}
+	return refIDs
+}
+
+// uniqueColumns returns the UNIQUE and primary key columns of t that
+// generated rows fill in.
+func uniqueColumns(t *schema.Table) []string {
+	var cols []string
+	for _, c := range t.NonAutoColumns() {
+		if c.Unique || c.PrimaryKey {
+			cols = append(cols, c.Name)
+		}
+	}
+	return cols
+}
+
+// existingUniques returns the values already in t's UNIQUE and primary key
+// columns, keyed by column, for dropSeen.
+func existingUniques(db *sql.DB, t *schema.Table) (map[string]map[string]bool, error) {
+	seen := make(map[string]map[string]bool)
+	for _, col := range uniqueColumns(t) {
+		vals, err := inserter.ExistingValues(db, t.Name, col)
+		if err != nil {
+			return nil, err
+		}
+		seen[col] = vals
+	}
+	return seen, nil
+}
+
+// dropSeen removes generated rows whose UNIQUE or primary key values are
+// in seen, or repeat such a value among themselves, adds the values of
+// the rows it keeps to seen, and returns how many rows it removed.
+func dropSeen(gr *generator.GenerationResult, seen map[string]map[string]bool) int {
+	if len(seen) == 0 {
+		return 0
+	}
+	kept := gr.Rows[:0]
+	for _, row := range gr.Rows {
+		dup := false
+		for col, vals := range seen {
+			if v, ok := row[col]; ok && v != nil && vals[fmt.Sprint(v)] {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		for col, vals := range seen {
+			if v, ok := row[col]; ok && v != nil {
+				vals[fmt.Sprint(v)] = true
+			}
+		}
+		kept = append(kept, row)
+	}
+	dropped := len(gr.Rows) - len(kept)
+	gr.Rows = kept
+	return dropped
+}
+
+// insert writes the generated rows in transactions of *batch rows,
+// recording each in j, marking the rows with tag and keeping to pace. A
+// batch the database finds too large (see inserter.BatchTooLarge) is
+// retried at half the size, down to single rows, and *batch keeps the
+// size that went through. With partial, rows the database rejects are
+// dropped (see inserter.InsertBatchPartial) and returned with Row counted
+// from the first of gr.Rows.
+func insert(ctx context.Context, ins *inserter.Inserter, t *schema.Table, gr *generator.GenerationResult, batch *int, j *Journal, tag *inserter.Tag, pace *pacer, partial bool) (int, []inserter.Rejected, error) {
+	inserter.ConvertRows(ins.Driver(), t, gr.Rows)
+	columns, tagged := tag.Apply(t, gr.Columns, gr.Rows)
+	ledger := tag != nil && tag.Ledger && !tagged
+	inserted := 0
+	var rejected []inserter.Rejected
+	for i := 0; i < len(gr.Rows); {
+		if err := ctx.Err(); err != nil {
+			return inserted, rejected, err
+		}
+		end := min(i+*batch, len(gr.Rows))
+		if err := j.write(journalRecord{Op: "begin", Table: t.Name, Rows: end - i}); err != nil {
+			return inserted, rejected, err
+		}
+		var n int
+		var err error
+		switch {
+		case partial:
+			runID := ""
+			if ledger {
+				runID = tag.RunID
+			}
+			var rej []inserter.Rejected
+			n, rej, err = ins.InsertBatchPartial(t, columns, gr.Rows[i:end], runID)
+			for _, r := range rej {
+				rejected = append(rejected, inserter.Rejected{Row: i + r.Row, Err: r.Err})
+			}
+		case ledger:
+			n, err = ins.InsertBatchLedger(t, columns, gr.Rows[i:end], tag.RunID)
+		default:
+			n, err = ins.InsertBatch(t.Name, columns, gr.Rows[i:end])
+		}
+		if end-i > 1 && inserter.BatchTooLarge(err) {
+			if err := j.write(journalRecord{Op: "abort", Table: t.Name}); err != nil {
+				return inserted, rejected, err
+			}
+			*batch = (end - i) / 2
+			continue
+		}
+		if err != nil {
+			return inserted, rejected, err
+		}
+		i = end
+		inserted += n
+		if err := j.write(journalRecord{Op: "commit", Table: t.Name, Rows: n}); err != nil {
+			return inserted, rejected, err
+		}
+		if err := pace.wrote(ctx, n); err != nil {
+			return inserted, rejected, err
+		}
+	}
+	return inserted, rejected, nil
+}
+
+// insertInto hands the generated rows to ins, batch rows at a time, and
+// returns how many it took.
+func insertInto(ins inserter.BatchInserter, t *schema.Table, gr *generator.GenerationResult, batch int) (int, error) {
+	inserted := 0
+	for i := 0; i < len(gr.Rows); i += batch {
+		n, err := ins.InsertBatch(t.Name, gr.Columns, gr.Rows[i:min(i+batch, len(gr.Rows))])
+		inserted += n
+		if err != nil {
+			return inserted, err
+		}
+	}
+	return inserted, nil
+}
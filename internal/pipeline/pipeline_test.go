@@ -0,0 +1,1033 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// tableClient answers with canned rows for whichever table the prompt is for.
+type tableClient map[string]string
+
+func (c tableClient) Generate(prompt string) (string, generator.Usage, error) {
+	for table, rows := range c {
+		if strings.Contains(prompt, "TABLE NAME: "+table+"\n") {
+			return rows, generator.Usage{EvalTokens: 1, Calls: 1}, nil
+		}
+	}
+	return "[]", generator.Usage{Calls: 1}, nil
+}
+
+const testSchema = `
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);
+CREATE TABLE posts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id),
+  status TEXT CHECK (status IN ('draft', 'live'))
+);`
+
+func TestRunSQLite(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // every connection to :memory: is a new database
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "Live"}, {"user_id": 2, "status": "draft"}]`,
+	}
+	var stages []Stage
+	res, err := Run(context.Background(), Options{
+		Schema: s,
+		Rows:   2,
+		Config: generator.DefaultConfig(),
+		Client: client,
+		DB:     db,
+		Driver: "sqlite3",
+	}, func(ev Event) {
+		if ev.Stage != StageGenerating {
+			stages = append(stages, ev.Stage)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 4 || len(res.Tables) != 2 {
+		t.Fatalf("inserted %d rows in %d tables, want 4 in 2", res.Inserted, len(res.Tables))
+	}
+	if len(stages) != 6 {
+		t.Errorf("expected generated/inserting/inserted per table, got %v", stages)
+	}
+	var live int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM posts WHERE status = 'live'`).Scan(&live); err != nil || live != 1 {
+		t.Errorf("expected the repaired status to be inserted, got %d (%v)", live, err)
+	}
+	for _, tr := range res.Tables {
+		if len(tr.Issues) > 0 {
+			t.Errorf("%s: unexpected validation issues %v", tr.Name, tr.Issues)
+		}
+		if tr.Inserting <= 0 || tr.Generating() < 0 {
+			t.Errorf("%s: took %v inserting of %v", tr.Name, tr.Inserting, tr.Elapsed)
+		}
+	}
+}
+
+func TestRunReferenceCycle(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE teams (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, captain_id INTEGER REFERENCES players(id));
+CREATE TABLE players (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, team_id INTEGER REFERENCES teams(id));`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"teams":   `[{"name": "Reds", "captain_id": null}]`,
+		"players": `[{"name": "Ada", "team_id": 1}]`,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := Run(ctx, Options{Schema: s, Rows: 1, Config: generator.DefaultConfig(), Client: client, DB: db, Driver: "sqlite3"}, nil)
+	if err != nil {
+		t.Fatalf("tables referencing each other: %v", err)
+	}
+	if res.Inserted != 2 {
+		t.Errorf("inserted %d rows, want 2", res.Inserted)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, Options{Schema: s, Rows: 1, Config: generator.DefaultConfig(), Client: tableClient{}}, nil)
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// hookClient answers like tableClient after calling before with the
+// table the prompt is for.
+type hookClient struct {
+	tableClient
+	before func(table string)
+}
+
+func (c hookClient) Generate(prompt string) (string, generator.Usage, error) {
+	for table := range c.tableClient {
+		if strings.Contains(prompt, "TABLE NAME: "+table+"\n") {
+			c.before(table)
+		}
+	}
+	return c.tableClient.Generate(prompt)
+}
+
+func TestRunOverlapsStages(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema + `CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "seed.db")
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another connection holds the write lock until tags is generated, so
+	// users can only be inserted if tags is generated meanwhile.
+	ctx := context.Background()
+	lockDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lockDB.Close()
+	lock, err := lockDB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+	if _, err := lock.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatal(err)
+	}
+	var release sync.Once
+	var mu sync.Mutex
+	usersInserted, postsWaited := false, false
+	client := hookClient{tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"tags":  `[{"name": "go"}, {"name": "sql"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`,
+	}, func(table string) {
+		switch table {
+		case "tags":
+			release.Do(func() { lock.ExecContext(ctx, "COMMIT") })
+		case "posts":
+			mu.Lock()
+			postsWaited = usersInserted
+			mu.Unlock()
+		}
+	}}
+	users, tags, posts := s.TableMap["users"], s.TableMap["tags"], s.TableMap["posts"]
+	res, err := Run(ctx, Options{
+		Schema: s,
+		Tables: []*schema.Table{users, tags, posts},
+		Rows:   2,
+		Config: generator.DefaultConfig(),
+		Client: client,
+		DB:     db,
+		Driver: "sqlite3",
+	}, func(ev Event) {
+		if ev.Stage == StageInserted && ev.Table == "users" {
+			mu.Lock()
+			usersInserted = true
+			mu.Unlock()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 6 {
+		t.Errorf("inserted %d rows, want 6", res.Inserted)
+	}
+	if !postsWaited {
+		t.Error("posts was generated before the users it references were inserted")
+	}
+}
+
+// userClient answers every prompt with a new user and a user whose email
+// every answer repeats.
+type userClient struct{ calls *int }
+
+func (c userClient) Generate(prompt string) (string, generator.Usage, error) {
+	*c.calls++
+	return fmt.Sprintf(`[{"email": "u%d@x.io"}, {"email": "same@x.io"}]`, *c.calls), generator.Usage{Calls: 1}, nil
+}
+
+func TestRunChunks(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	var progress []int
+	res, err := Run(context.Background(), Options{
+		Schema:    s,
+		Tables:    s.Tables[:1],
+		Rows:      5,
+		ChunkRows: 2,
+		Config:    generator.Config{MaxFollowUps: 0},
+		Client:    userClient{&calls},
+		DB:        db,
+		Driver:    "sqlite3",
+	}, func(ev Event) {
+		if ev.Stage == StageGenerating && ev.Progress.Kind == generator.ProgressDone {
+			progress = append(progress, ev.Progress.Rows)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Chunks of 2, 2 and 1 rows; the second repeats same@x.io and loses it.
+	tr := res.Tables[0]
+	if calls != 3 || tr.Rows != 4 || tr.Dropped != 1 || tr.Inserted != 4 {
+		t.Errorf("got %d calls, %d rows, %d dropped, %d inserted; want 3, 4, 1, 4", calls, tr.Rows, tr.Dropped, tr.Inserted)
+	}
+	if gr := tr.Generated; gr.Rows != nil || gr.Requested != 5 || gr.Usage.Calls != 3 || !tr.Short() {
+		t.Errorf("expected totals without rows, got %+v", gr)
+	}
+	if fmt.Sprint(progress) != "[2 4 4]" { // counted before the repeat is dropped
+		t.Errorf("progress should count rows across chunks, got %v", progress)
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	issues := []*validator.ValidationError{
+		{Table: "users", Row: 2, Column: "email", Kind: validator.KindNotNull, Missing: true},
+		{Table: "users", Row: 5, Kind: validator.KindRule, Rule: "x", Want: []string{"y"}},
+	}
+	renumber(issues, 10)
+	if issues[0].Row != 12 || issues[1].Row != 15 {
+		t.Errorf("renumber rows = %d, %d", issues[0].Row, issues[1].Row)
+	}
+	if got := issues[0].Error(); got != "row 12: email: NOT NULL but missing" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+// slowClient answers like tableClient after a delay.
+type slowClient struct {
+	tableClient
+	delay time.Duration
+}
+
+func (c slowClient) Generate(prompt string) (string, generator.Usage, error) {
+	time.Sleep(c.delay)
+	return c.tableClient.Generate(prompt)
+}
+
+func TestRunTableTimeout(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := slowClient{tableClient{"users": `[{"email": "a@x.io"}]`}, time.Second}
+	start := time.Now()
+	res, err := Run(context.Background(), Options{Schema: s, Rows: 1, Config: generator.DefaultConfig(),
+		Client: client, TableTimeout: 50 * time.Millisecond}, nil)
+	var te *TableError
+	if !errors.As(err, &te) || te.Op != OpGenerate || te.Table != "users" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a generate timeout on users, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run waited %s for a model call past the timeout", elapsed)
+	}
+	if len(res.Tables) != 0 {
+		t.Errorf("expected no finished tables, got %d", len(res.Tables))
+	}
+}
+
+// stallClient takes a second to answer for the stalled table.
+type stallClient struct {
+	tableClient
+	stalled string
+}
+
+func (c stallClient) Generate(prompt string) (string, generator.Usage, error) {
+	if strings.Contains(prompt, "TABLE NAME: "+c.stalled+"\n") {
+		time.Sleep(time.Second)
+	}
+	return c.tableClient.Generate(prompt)
+}
+
+func TestRunSkipTimedOut(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema + `
+CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	client := stallClient{tableClient{"tags": `[{"name": "go"}]`}, "users"}
+	events := 0
+	res, err := Run(context.Background(), Options{Schema: s, Rows: 1, Config: generator.DefaultConfig(), Client: client,
+		DB: db, Driver: "sqlite3", TableTimeout: 50 * time.Millisecond, SkipTimedOut: true}, func(ev Event) {
+		if ev.Stage == StageAbandoned {
+			events++
+		}
+	})
+	if err != nil {
+		t.Fatalf("a timed-out table should not fail the run: %v", err)
+	}
+	gaveUp := res.Abandoned()
+	if len(gaveUp) != 2 || gaveUp[0].Name != "users" || gaveUp[1].Name != "posts" || events != 2 {
+		t.Fatalf("expected users and the posts referencing it to be given up, got %v", gaveUp)
+	}
+	if !errors.Is(gaveUp[0].Err, context.DeadlineExceeded) {
+		t.Errorf("users: %v, want a timeout", gaveUp[0].Err)
+	}
+	if res.Inserted != 1 {
+		t.Errorf("inserted %d rows, want the one tag", res.Inserted)
+	}
+	if rep := NewReport(res, nil, time.Now()); rep.Success || rep.Tables[0].Status != "given-up" {
+		t.Errorf("report: success %v, users %q", rep.Success, rep.Tables[0].Status)
+	}
+}
+
+// lateClient answers users only once the tags call starts, which then
+// waits for that answer to be handled.
+type lateClient struct {
+	tableClient
+	release, answered chan struct{}
+}
+
+func (c lateClient) Generate(prompt string) (string, generator.Usage, error) {
+	switch {
+	case strings.Contains(prompt, "TABLE NAME: users\n"):
+		<-c.release
+		defer close(c.answered)
+	case strings.Contains(prompt, "TABLE NAME: tags\n"):
+		close(c.release)
+		<-c.answered
+		time.Sleep(20 * time.Millisecond)
+	}
+	return c.tableClient.Generate(prompt)
+}
+
+func TestRunTimedOutCallStaysQuiet(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema + `
+CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := lateClient{tableClient{"users": `[{"email": "a@x.io"}]`, "tags": `[{"name": "go"}]`},
+		make(chan struct{}), make(chan struct{})}
+	var late []Event
+	tags := false
+	res, err := Run(context.Background(), Options{Schema: s, Rows: 1, Config: generator.DefaultConfig(), Client: client,
+		TableTimeout: 50 * time.Millisecond, SkipTimedOut: true}, func(ev Event) {
+		switch {
+		case ev.Table == "tags":
+			tags = true
+		case tags && ev.Table == "users" && ev.Stage == StageGenerating:
+			late = append(late, ev)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gaveUp := res.Abandoned(); len(gaveUp) == 0 || gaveUp[0].Name != "users" {
+		t.Fatalf("expected users to be given up, got %v", gaveUp)
+	}
+	if len(late) != 0 {
+		t.Errorf("progress from the given-up users call while tags was generated: %v", late)
+	}
+}
+
+func TestRunFailOnIssues(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "archived"}, {"user_id": 2, "status": "draft"}]`,
+	}
+	opts := Options{Schema: s, Rows: 2, Config: generator.DefaultConfig(), Client: client}
+	if _, err := Run(context.Background(), opts, nil); err != nil {
+		t.Fatalf("issues should not stop a run by default: %v", err)
+	}
+
+	opts.FailOnIssues = true
+	res, err := Run(context.Background(), opts, nil)
+	var te *TableError
+	if !errors.As(err, &te) || te.Op != OpValidate || te.Table != "posts" {
+		t.Fatalf("expected a validate error on posts, got %v", err)
+	}
+	if len(res.Tables) != 2 || len(res.Tables[1].Issues) == 0 {
+		t.Errorf("expected posts with its issues in the result, got %+v", res.Tables)
+	}
+}
+
+func TestRunTopUp(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (email) VALUES ('b@x.io');
+		INSERT INTO posts (user_id, status) VALUES (1, 'live'), (1, 'live'), (1, 'draft')`); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tableClient{"users": `[{"email": "b@x.io"}, {"email": "c@x.io"}]`}
+	res, err := Run(context.Background(), Options{
+		Schema: s,
+		Rows:   3,
+		Config: generator.DefaultConfig(),
+		Client: client,
+		DB:     db,
+		Driver: "sqlite3",
+		TopUp:  true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, posts := res.Tables[0], res.Tables[1]
+	if users.Existing != 1 || users.Dropped != 1 || users.Inserted != 1 {
+		t.Errorf("users: existing %d, dropped %d, inserted %d; want 1, 1, 1", users.Existing, users.Dropped, users.Inserted)
+	}
+	if posts.Existing != 3 || posts.Generated != nil {
+		t.Errorf("posts should be skipped, got %+v", posts)
+	}
+}
+
+func TestRunSkipPopulated(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (email) VALUES ('a@x.io'), ('b@x.io');
+		INSERT INTO posts (user_id, status) VALUES (1, 'live')`); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tableClient{"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`}
+	res, err := Run(context.Background(), Options{
+		Schema:        s,
+		Rows:          2,
+		Config:        generator.DefaultConfig(),
+		Client:        client,
+		DB:            db,
+		Driver:        "sqlite3",
+		SkipPopulated: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, posts := res.Tables[0], res.Tables[1]
+	if users.Existing != 2 || users.Generated != nil || users.Inserted != 0 {
+		t.Errorf("users should be skipped, got %+v", users)
+	}
+	if posts.Existing != 1 || posts.Inserted != 2 {
+		t.Errorf("posts: existing %d, inserted %d; want 1 and the full 2", posts.Existing, posts.Inserted)
+	}
+}
+
+func TestRunDropRejected(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	// The database has a constraint the schema file does not show.
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE CHECK (email NOT LIKE '%@blocked.io'))`); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tableClient{"users": `[{"email": "a@x.io"}, {"email": "b@blocked.io"}, {"email": "c@x.io"}, {"email": "d@blocked.io"}]`}
+	opts := Options{Schema: s, Tables: s.Tables[:1], Rows: 4, Config: generator.DefaultConfig(), Client: client,
+		DB: db, Driver: "sqlite3", BatchSize: 3}
+	if _, err := Run(context.Background(), opts, nil); err == nil {
+		t.Fatal("expected the rejected batch to fail the run without DropRejected")
+	}
+	if _, err := db.Exec(`DELETE FROM users`); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.DropRejected = true
+	res, err := Run(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := res.Tables[0]
+	if users.Inserted != 2 || len(users.Rejected) != 2 || users.Rejected[0].Row != 2 || users.Rejected[1].Row != 4 {
+		t.Fatalf("expected rows 2 and 4 rejected, got %d inserted, %+v", users.Inserted, users.Rejected)
+	}
+	if rep := NewReport(res, nil, time.Now()); rep.Tables[0].Status != "partial" || len(rep.Tables[0].Rejected) != 2 {
+		t.Errorf("report: %+v", rep.Tables[0])
+	}
+}
+
+func TestInsertHalvesBatch(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables[:1]); err != nil {
+		t.Fatal(err)
+	}
+	// SQLite takes at most 32766 parameters in a statement.
+	gr := &generator.GenerationResult{Columns: []string{"email"}}
+	for i := 0; i < 40000; i++ {
+		gr.Rows = append(gr.Rows, map[string]interface{}{"email": fmt.Sprintf("u%d@x.io", i)})
+	}
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := CreateJournal(path, "schema.sql", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.write(journalRecord{Op: "table", Table: "users", Want: len(gr.Rows)}); err != nil {
+		t.Fatal(err)
+	}
+	batch := len(gr.Rows)
+	n, _, err := insert(context.Background(), inserter.New(db, "sqlite3"), s.Tables[0], gr, &batch, j, nil, nil, false)
+	j.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 40000 || batch != 20000 {
+		t.Errorf("expected all rows inserted 20000 at a time, got %d rows, batch %d", n, batch)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n")[2:] {
+		var rec journalRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		ops = append(ops, fmt.Sprintf("%s %d", rec.Op, rec.Rows))
+	}
+	want := []string{"begin 40000", "abort 0", "begin 20000", "commit 20000", "begin 20000", "commit 20000"}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("journal: %v, want %v", ops, want)
+	}
+}
+
+func TestRunAnchors(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+
+	anchors := map[string][]Anchor{
+		"users": {{Name: "admin", Values: map[string]interface{}{"email": "admin@x.io"}}},
+		"posts": {{Name: "welcome", Values: map[string]interface{}{"user_id": "@admin", "status": "live"}}},
+	}
+	if err := CheckAnchors(anchors, s); err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "admin@x.io"}, {"email": "a@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "draft"}]`,
+	}
+	opts := Options{Schema: s, Rows: 2, Config: generator.DefaultConfig(), Client: client,
+		DB: db, Driver: "sqlite3", Anchors: anchors}
+	res, err := Run(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Anchors) != 2 || !res.Anchors[0].Inserted || res.Anchors[1].Row["user_id"] != res.Anchors[0].Row["id"] {
+		t.Fatalf("expected the admin and a post of theirs inserted, got %+v", res.Anchors)
+	}
+	if users := res.Tables[0]; users.Dropped != 1 || users.Inserted != 1 {
+		t.Errorf("users: dropped %d, inserted %d; want the admin's email dropped", users.Dropped, users.Inserted)
+	}
+	opts.anchored = map[string][]map[string]interface{}{"users": {res.Anchors[0].Row}}
+	if ids := refValues(opts, s.Tables[1], nil)["user_id"]; len(ids) != 2 || ids[0] != res.Anchors[0].Row["id"] {
+		t.Errorf("expected the admin's ID first in the posts' user IDs, got %v", ids)
+	}
+
+	opts.TopUp = true
+	res, err = Run(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Anchors[0].Inserted || res.Anchors[1].Inserted {
+		t.Errorf("anchors already there were inserted again: %+v", res.Anchors)
+	}
+	var admins int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users WHERE email = 'admin@x.io'`).Scan(&admins); err != nil || admins != 1 {
+		t.Errorf("expected one admin, got %d (%v)", admins, err)
+	}
+
+	bad := map[string][]Anchor{"users": {{Name: "admin", Values: map[string]interface{}{"login": "admin"}}}}
+	if err := CheckAnchors(bad, s); err == nil || !strings.Contains(err.Error(), `unknown column "login"`) {
+		t.Errorf("unknown column: %v", err)
+	}
+}
+
+func TestRunTag(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema + `
+CREATE TABLE tags (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, seed_batch_id TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`,
+		"tags":  `[{"name": "go", "seed_batch_id": "made-up"}, {"name": "sql"}]`,
+	}
+	_, err = Run(context.Background(), Options{Schema: s, Rows: 2, Config: generator.DefaultConfig(),
+		Client: client, DB: db, Driver: "sqlite3", Tag: &inserter.Tag{RunID: "run-1", Ledger: true}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tagged, recorded int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM tags WHERE seed_batch_id = 'run-1'`).Scan(&tagged); err != nil || tagged != 2 {
+		t.Errorf("expected both tags tagged with the run ID, got %d (%v)", tagged, err)
+	}
+	// Tables with the tag column need no ledger entries.
+	if err := db.QueryRow(`SELECT COUNT(*) FROM _seeddb_rows WHERE run_id = 'run-1'`).Scan(&recorded); err != nil || recorded != 4 {
+		t.Errorf("expected only the 4 users and posts in the ledger, got %d (%v)", recorded, err)
+	}
+}
+
+func TestRunTableRows(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`,
+	}
+	res, err := Run(context.Background(), Options{
+		Schema:    s,
+		Rows:      2,
+		TableRows: map[string]int{"users": 1},
+		Config:    generator.DefaultConfig(),
+		Client:    client,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(res.Tables[0].Generated.Rows); n != 1 {
+		t.Errorf("users: %d rows, want 1 from TableRows", n)
+	}
+	if n := len(res.Tables[1].Generated.Rows); n != 2 {
+		t.Errorf("posts: %d rows, want 2 from Rows", n)
+	}
+}
+
+func TestRunArchetypes(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);
+CREATE TABLE roles (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE);
+CREATE TABLE user_roles (user_id INTEGER NOT NULL REFERENCES users(id), role_id INTEGER NOT NULL REFERENCES roles(id), PRIMARY KEY (user_id, role_id));
+CREATE TABLE events (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER REFERENCES users(id), action TEXT, created_at TIMESTAMP);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users":      `[{"email": "a@x.io"}, {"email": "b@x.io"}, {"email": "c@x.io"}]`,
+		"roles":      `[{"name": "admin"}, {"name": "editor"}, {"name": "viewer"}]`,
+		"user_roles": `[{"user_id": 1, "role_id": 1}, {"user_id": 1, "role_id": 1}, {"user_id": 1, "role_id": 1}, {"user_id": 1, "role_id": 1}, {"user_id": 1, "role_id": 1}, {"user_id": 1, "role_id": 1}]`,
+		"events": `[{"user_id": 1, "action": "login", "created_at": "2025-03-02 10:00:00"},
+			{"user_id": 2, "action": "logout", "created_at": "2025-03-01 09:00:00"},
+			{"user_id": 1, "action": "login", "created_at": null}]`,
+	}
+	cfg := generator.DefaultConfig()
+	cfg.Archetypes = map[string]schema.Archetype{"roles": schema.ArchetypeLookup}
+	cfg.MaxFollowUps = 0
+	res, err := Run(context.Background(), Options{
+		Schema:        s,
+		Rows:          3,
+		ArchetypeRows: true,
+		Config:        cfg,
+		Client:        client,
+		DB:            db,
+		Driver:        "sqlite3",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requested := make(map[string]int)
+	for _, tr := range res.Tables {
+		requested[tr.Name] = tr.Generated.Requested
+	}
+	if requested["users"] != 3 || requested["roles"] != 3 || requested["user_roles"] != 6 || requested["events"] != 15 {
+		t.Errorf("requested rows %v, want 3 users and roles, 6 user_roles, 15 events", requested)
+	}
+
+	var links, distinct int
+	if err := db.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT user_id || '-' || role_id) FROM user_roles`).Scan(&links, &distinct); err != nil {
+		t.Fatal(err)
+	}
+	if links < 2 || links != distinct {
+		t.Errorf("user_roles: %d rows, %d distinct links; repeated links must be redrawn", links, distinct)
+	}
+	var order string
+	if err := db.QueryRow(`SELECT group_concat(action, ',') FROM (SELECT action FROM events ORDER BY id)`).Scan(&order); err != nil {
+		t.Fatal(err)
+	}
+	if order != "logout,login,login" {
+		t.Errorf("events in id order: %s, want them sorted by created_at", order)
+	}
+}
+
+func TestRunMinimalViable(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE, is_admin BOOLEAN);
+CREATE TABLE posts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id),
+  editor_id INTEGER REFERENCES users(id),
+  status TEXT NOT NULL CHECK (status IN ('draft', 'review', 'live'))
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io", "is_admin": false}, {"email": "b@x.io", "is_admin": false}]`,
+		"posts": `[{"user_id": 1, "editor_id": 1, "status": "draft"}, {"user_id": 1, "editor_id": 2, "status": "draft"}, {"user_id": 2, "editor_id": 2, "status": "draft"}]`,
+	}
+	res, err := Run(context.Background(), Options{
+		Schema:        s,
+		Rows:          100,
+		MinimalViable: true,
+		Config:        generator.DefaultConfig(),
+		Client:        client,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, posts := res.Tables[0].Generated, res.Tables[1].Generated
+	if users.Requested != 2 || posts.Requested != 3 {
+		t.Fatalf("requested %d users and %d posts, want 2 (true, false) and 3 (one per status)", users.Requested, posts.Requested)
+	}
+	if users.Rows[0]["is_admin"] != true || users.Rows[1]["is_admin"] != false {
+		t.Errorf("is_admin should be covered: %v", users.Rows)
+	}
+	var statuses []string
+	for _, row := range posts.Rows {
+		statuses = append(statuses, fmt.Sprint(row["status"]))
+	}
+	if strings.Join(statuses, ",") != "draft,review,live" {
+		t.Errorf("statuses %v, want each once", statuses)
+	}
+	if posts.Rows[2]["editor_id"] != nil || posts.Rows[0]["editor_id"] == nil {
+		t.Errorf("editor_id should be set and NULL at least once: %v", posts.Rows)
+	}
+}
+
+func TestRunCoverage(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 1, "status": "live"}, {"user_id": 1, "status": "live"}]`,
+	}
+	res, err := Run(context.Background(), Options{
+		Schema:   s,
+		Rows:     1,
+		Coverage: true,
+		Config:   generator.DefaultConfig(),
+		Client:   client,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := res.Tables[1].Generated
+	if posts.Requested != 3 {
+		t.Errorf("posts: %d rows requested, want 3 for NULL, draft and live", posts.Requested)
+	}
+	if len(posts.Coverage) != 3 || posts.Rows[0]["status"] != nil || posts.Rows[1]["status"] != "draft" {
+		t.Errorf("coverage %v, rows %v", posts.Coverage, posts.Rows)
+	}
+}
+
+// failClient fails every call, for runs that must not reach the model.
+type failClient struct{}
+
+func (failClient) Generate(string) (string, generator.Usage, error) {
+	return "", generator.Usage{}, errors.New("model called")
+}
+
+func TestRunRecordedRows(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL, api_key TEXT NOT NULL, avatar BLOB);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cfg := generator.DefaultConfig()
+	cfg.RecordDir = dir
+	client := tableClient{"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}, {"email": "c@x.io"}]`}
+	res, err := Run(context.Background(), Options{Schema: s, Rows: 3, ChunkRows: 2, Config: cfg, Client: client}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Tables[0].Rows != 3 {
+		t.Fatalf("recorded %d rows, want 3", res.Tables[0].Rows)
+	}
+	saved, ok, err := LoadRows(dir, "users")
+	if err != nil || !ok || len(saved) != 3 {
+		t.Fatalf("LoadRows: %d rows, %v, %v", len(saved), ok, err)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	cfg.RecordDir, cfg.ReplayDir = "", dir
+	res, err = Run(context.Background(), Options{Schema: s, Rows: 10, ChunkRows: 2, Config: cfg, Client: failClient{},
+		DB: db, Driver: "sqlite3"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 3 {
+		t.Fatalf("replayed %d rows, want the 3 recorded", res.Inserted)
+	}
+	var key string
+	var avatar []byte
+	if err := db.QueryRow(`SELECT api_key, avatar FROM users WHERE id = 3`).Scan(&key, &avatar); err != nil {
+		t.Fatal(err)
+	}
+	if key != saved[2]["api_key"] || string(avatar) != string(saved[2]["avatar"].([]byte)) {
+		t.Errorf("replayed row differs from the recorded one: %q vs %v", key, saved[2]["api_key"])
+	}
+}
+
+func TestRunSelfReference(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE categories (code TEXT PRIMARY KEY, parent_code TEXT REFERENCES categories(code), created_at TIMESTAMP);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"categories": `[{"code": "phones", "parent_code": "electronics", "created_at": "2024-01-01 09:00:00"},
+			{"code": "android", "parent_code": "phones", "created_at": "2024-05-01 09:00:00"},
+			{"code": "electronics", "parent_code": null, "created_at": "2024-03-01 09:00:00"}]`,
+	}
+	cfg := generator.DefaultConfig()
+	cfg.MaxFollowUps = 0
+	if _, err := Run(context.Background(), Options{Schema: s, Rows: 3, Config: cfg, Client: client, DB: db, Driver: "sqlite3"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	var order string
+	if err := db.QueryRow(`SELECT group_concat(code || '@' || created_at, ',') FROM (SELECT * FROM categories ORDER BY rowid)`).Scan(&order); err != nil {
+		t.Fatal(err)
+	}
+	if want := "electronics@2024-03-01 09:00:00,phones@2024-03-01 10:00:00,android@2024-05-01 09:00:00"; order != want {
+		t.Errorf("categories in insert order: %s, want %s", order, want)
+	}
+}
+
+func TestRunAuditColumns(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);
+CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, created_by INTEGER, updated_by TEXT, deleted_at TIMESTAMP);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"title": "One", "created_by": 99, "updated_by": "jdoe", "deleted_at": "2025-01-01 00:00:00"},
+			{"title": "Two", "created_by": 99, "updated_by": "jdoe", "deleted_at": "2025-01-01 00:00:00"},
+			{"title": "Three", "created_by": 99, "updated_by": "jdoe", "deleted_at": "2025-01-01 00:00:00"},
+			{"title": "Four", "created_by": 99, "updated_by": "jdoe", "deleted_at": "2025-01-01 00:00:00"}]`,
+	}
+	cfg := generator.DefaultConfig()
+	cfg.MaxFollowUps = 0
+	cfg.SoftDeleted = map[string]float64{"posts.deleted_at": 0.5}
+	opts := Options{Schema: s, Rows: 2, TableRows: map[string]int{"posts": 4}, Config: cfg, Client: client, DB: db, Driver: "sqlite3"}
+	if _, err := Run(context.Background(), opts, nil); err != nil {
+		t.Fatal(err)
+	}
+	var strangers, deleted int
+	if err := db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM posts WHERE created_by NOT IN (SELECT id FROM users) OR updated_by NOT IN (SELECT email FROM users)),
+		(SELECT COUNT(*) FROM posts WHERE deleted_at IS NOT NULL)`).Scan(&strangers, &deleted); err != nil {
+		t.Fatal(err)
+	}
+	if strangers != 0 || deleted != 2 {
+		t.Errorf("%d posts by someone not in users, %d deleted; want 0 and 2", strangers, deleted)
+	}
+}
@@ -0,0 +1,243 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// DefaultReportDir is where the TUI saves run reports and where its
+// History tab looks for them.
+const DefaultReportDir = ".seeddb/reports"
+
+// maxIssueSamples is the validation findings kept per table in a report,
+// and maxRepairSamples the repair actions.
+const (
+	maxIssueSamples  = 5
+	maxRepairSamples = 20
+)
+
+// Report is the machine-readable summary of a run, written as JSON by
+// `seed --report` and by the TUI.
+type Report struct {
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Seconds    float64       `json:"seconds"`
+	Schema     string        `json:"schema,omitempty"`
+	Database   string        `json:"database,omitempty"` // password removed
+	Model      string        `json:"model,omitempty"`
+	RunID      string        `json:"run_id,omitempty"` // what the rows are tagged with
+	DryRun     bool          `json:"dry_run"`
+	Success    bool          `json:"success"`
+	Error      string        `json:"error,omitempty"`
+	Inserted   int           `json:"inserted"`
+	Issues     int           `json:"issues"` // validation findings across all tables
+	Usage      ReportUsage   `json:"usage"`
+	Tables     []TableReport `json:"tables"`
+
+	Mirrored map[string]int `json:"mirrored,omitempty"` // rows inserted into each further --db target
+}
+
+// ReportUsage is the model usage of a run.
+type ReportUsage struct {
+	Calls        int     `json:"calls"`
+	PromptTokens int     `json:"prompt_tokens"`
+	EvalTokens   int     `json:"eval_tokens"`
+	ModelSeconds float64 `json:"model_seconds"`
+}
+
+// TableReport is the outcome for one table. Status is inserted, partial
+// (fewer rows inserted than generated), generated (dry run), skipped
+// (top-up found the table full) or given-up (see Options.SkipTimedOut,
+// with Error saying why).
+type TableReport struct {
+	Name          string            `json:"name"`
+	Status        string            `json:"status"`
+	Requested     int               `json:"requested"`
+	Generated     int               `json:"generated"`
+	Inserted      int               `json:"inserted"`
+	BatchSize     int               `json:"batch_size,omitempty"`
+	Existing      int               `json:"existing,omitempty"`
+	Dropped       int               `json:"dropped,omitempty"`
+	Rejected      []ReportRejected  `json:"rejected,omitempty"` // rows the database refused (--drop-rejected)
+	FollowUps     int               `json:"follow_ups"`
+	Repairs       map[string]int    `json:"repairs,omitempty"`        // by repair kind
+	RepairActions []string          `json:"repair_actions,omitempty"` // the first few repairs, as text
+	Issues        int               `json:"issues"`
+	Samples       []string          `json:"issue_samples,omitempty"` // the first few findings
+	Seconds       float64           `json:"seconds"`
+	InsertSeconds float64           `json:"insert_seconds"` // the part of Seconds spent inserting
+	Error         string            `json:"error,omitempty"`
+	Checksum      string            `json:"checksum,omitempty"`   // of the generated rows, in any order; see Checksum
+	Provenance    *ReportProvenance `json:"provenance,omitempty"` // nil for skipped tables
+
+	// Details are the findings of Samples, with the row, column, rule and
+	// values apart (see validator.ValidationError).
+	Details []*validator.ValidationError `json:"issue_details,omitempty"`
+}
+
+// ReportRejected is a generated row the database refused to insert.
+type ReportRejected struct {
+	Row   int    `json:"row"` // among the table's generated rows, from 1
+	Error string `json:"error"`
+}
+
+// ReportProvenance is how a table's rows were produced.
+type ReportProvenance struct {
+	Model           string   `json:"model,omitempty"`
+	PromptVersion   string   `json:"prompt_version,omitempty"`
+	Style           string   `json:"style,omitempty"`
+	Domain          string   `json:"domain,omitempty"`
+	NoPII           bool     `json:"no_pii,omitempty"`
+	Coverage        bool     `json:"coverage,omitempty"`
+	MaxFollowUps    int      `json:"max_follow_ups"`
+	PromptHashes    []string `json:"prompt_hashes,omitempty"` // one per prompt sent; also the --record file names
+	Retries         int      `json:"retries"`                 // responses that could not be parsed
+	ModelCalls      int      `json:"model_calls"`
+	CacheHits       int      `json:"cache_hits,omitempty"`
+	ModelSeconds    float64  `json:"model_seconds"`
+	GenerateSeconds float64  `json:"generate_seconds"`
+	Replayed        bool     `json:"replayed,omitempty"` // rows came from a recorded run
+}
+
+// NewReport summarizes res, the outcome of a run that started at start
+// and ended with err. res may hold only the tables finished before err.
+func NewReport(res *Result, err error, start time.Time) *Report {
+	now := time.Now()
+	r := &Report{StartedAt: start, FinishedAt: now, Seconds: seconds(now.Sub(start)), Success: err == nil, Tables: []TableReport{}}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if res == nil {
+		return r
+	}
+	r.DryRun, r.Inserted, r.Mirrored = res.DryRun, res.Inserted, res.Mirrored
+	r.Usage = ReportUsage{Calls: res.Usage.Calls, PromptTokens: res.Usage.PromptTokens,
+		EvalTokens: res.Usage.EvalTokens, ModelSeconds: seconds(res.Usage.Duration)}
+	for _, tr := range res.Tables {
+		tab := TableReport{Name: tr.Name, Inserted: tr.Inserted, Existing: tr.Existing, Dropped: tr.Dropped,
+			Issues: len(tr.Issues), Seconds: seconds(tr.Elapsed), InsertSeconds: seconds(tr.Inserting), BatchSize: tr.BatchSize}
+		for _, r := range tr.Rejected {
+			tab.Rejected = append(tab.Rejected, ReportRejected{Row: r.Row, Error: r.Err.Error()})
+		}
+		if len(tr.Issues) > 0 {
+			tab.Details = tr.Issues[:min(len(tr.Issues), maxIssueSamples)]
+			tab.Samples = validator.Messages(tab.Details)
+		}
+		if gr := tr.Generated; gr == nil {
+			tab.Status = "skipped"
+		} else {
+			tab.Requested, tab.Generated, tab.FollowUps = gr.Requested, tr.Rows, gr.FollowUps
+			tab.Checksum = tr.Checksum.String()
+			if counts := gr.Repairs.Counts(); len(counts) > 0 {
+				tab.Repairs = make(map[string]int, len(counts))
+				for k, n := range counts {
+					tab.Repairs[string(k)] = n
+				}
+			}
+			for _, a := range gr.Repairs.Actions[:min(len(gr.Repairs.Actions), maxRepairSamples)] {
+				tab.RepairActions = append(tab.RepairActions, a.String())
+			}
+			p := gr.Provenance
+			tab.Provenance = &ReportProvenance{Model: p.Model, PromptVersion: p.PromptVersion, Style: p.Style,
+				Domain: p.Domain, NoPII: p.NoPII, Coverage: p.Coverage, MaxFollowUps: p.MaxFollowUps,
+				PromptHashes: p.Prompts, Retries: gr.Retries, ModelCalls: gr.Usage.Calls, CacheHits: gr.Usage.CacheHits,
+				ModelSeconds: seconds(gr.Usage.Duration), GenerateSeconds: seconds(gr.Elapsed), Replayed: p.Replayed}
+			switch {
+			case tr.Err != nil:
+			case res.DryRun:
+				tab.Status = "generated"
+			case tr.Inserted < tr.Rows:
+				tab.Status = "partial"
+			default:
+				tab.Status = "inserted"
+			}
+		}
+		if tr.Err != nil {
+			tab.Status, tab.Error = "given-up", tr.Err.Error()
+			r.Success = false
+		}
+		r.Issues += tab.Issues
+		r.Tables = append(r.Tables, tab)
+	}
+	return r
+}
+
+// Save writes r as indented JSON to path, creating its directory.
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadReport reads a report written by Save.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// LoadReports reads every *.json report in dir, newest first, and returns
+// their paths alongside. Files that are not reports are skipped; a missing
+// directory is no error.
+func LoadReports(dir string) ([]*Report, []string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	type loaded struct {
+		r    *Report
+		path string
+	}
+	var all []loaded
+	for _, p := range paths {
+		if r, err := LoadReport(p); err == nil && !r.StartedAt.IsZero() {
+			all = append(all, loaded{r, p})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].r.StartedAt.After(all[j].r.StartedAt) })
+	reports, out := make([]*Report, len(all)), make([]string, len(all))
+	for i, l := range all {
+		reports[i], out[i] = l.r, l.path
+	}
+	return reports, out, nil
+}
+
+// ReportPath returns the file name under dir for a run that started at t.
+func ReportPath(dir string, t time.Time) string {
+	return filepath.Join(dir, t.Format("20060102-150405")+".json")
+}
+
+// RedactConn removes the password from a connection URL, so reports can be
+// shared. Strings that are not URLs, such as SQLite paths, are unchanged.
+func RedactConn(conn string) string {
+	u, err := url.Parse(conn)
+	if err != nil || u.User == nil {
+		return conn
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+func seconds(d time.Duration) float64 {
+	return float64(d.Round(time.Millisecond)) / float64(time.Second)
+}
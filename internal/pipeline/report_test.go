@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestReport(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "Live"}]`,
+	}
+	start := time.Now()
+	res, err := Run(context.Background(), Options{Schema: s, Rows: 2, Config: generator.DefaultConfig(),
+		Client: client, DB: db, Driver: "sqlite3"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReport(res, nil, start)
+	if !r.Success || r.Inserted != 4 || len(r.Tables) != 2 {
+		t.Fatalf("report: %+v", r)
+	}
+	posts := r.Tables[1]
+	if posts.Status != "inserted" || posts.Requested != 2 || posts.Generated != 2 || posts.FollowUps != 1 {
+		t.Errorf("posts: %+v", posts)
+	}
+	if posts.Repairs["enum-map"] != 2 || len(posts.RepairActions) != 2 {
+		t.Errorf("posts repairs = %v %v, want the status fix counted", posts.Repairs, posts.RepairActions)
+	}
+	if p := posts.Provenance; p == nil || p.Model != "llama3" || p.PromptVersion != generator.DefaultPromptVersion ||
+		len(p.PromptHashes) != 2 || p.PromptHashes[0] == p.PromptHashes[1] || p.ModelCalls != 2 {
+		t.Errorf("posts provenance: %+v", p)
+	}
+
+	dir := t.TempDir()
+	older := NewReport(nil, errors.New("connect db: refused"), start.Add(-time.Hour))
+	for _, rep := range []*Report{r, older} {
+		if err := rep.Save(ReportPath(dir, rep.StartedAt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reports, paths, err := LoadReports(dir)
+	if err != nil || len(reports) != 2 || len(paths) != 2 {
+		t.Fatalf("LoadReports: %d reports, %v", len(reports), err)
+	}
+	if !reports[0].Success || reports[1].Error != "connect db: refused" {
+		t.Errorf("reports should be newest first: %+v", reports)
+	}
+
+	if got := RedactConn("postgres://app:s3cret@db:5432/shop"); got != "postgres://app:xxxxx@db:5432/shop" {
+		t.Errorf("RedactConn = %q", got)
+	}
+	if got := RedactConn("sqlite:./dev.db"); got != "sqlite:./dev.db" {
+		t.Errorf("RedactConn changed a SQLite path: %q", got)
+	}
+}
@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// DefaultSizeSample is the rows per table PlanSize generates to measure
+// rows.
+const DefaultSizeSample = 10
+
+// SizePlan is the outcome of PlanSize.
+type SizePlan struct {
+	RowBytes map[string]float64 // estimated bytes per row, by table
+	Rows     int                // rows per table
+	Bytes    int64              // estimated size of Rows rows in every table
+	Usage    generator.Usage    // spent on the sample
+}
+
+// PlanSize generates a dry-run sample of sample rows per table, estimates
+// the average stored size of a row of each table, and returns the rows per
+// table at which the tables add up to target bytes. Every table gets the
+// same count, as with a plain Rows run, so the ratio of children to parents
+// is unchanged. The estimate covers table data as stored by opts.Driver
+// (Postgres when empty); indexes and free space come on top.
+func PlanSize(ctx context.Context, opts Options, target int64, sample int) (*SizePlan, error) {
+	if target <= 0 {
+		return nil, fmt.Errorf("target size must be positive")
+	}
+	if sample <= 0 {
+		sample = DefaultSizeSample
+	}
+	dry := opts
+	dry.DB, dry.TopUp, dry.Rows, dry.TableRows, dry.ArchetypeRows = nil, false, sample, nil, false
+	dry.ChunkRows = sample // keep the rows to measure
+	res, err := Run(ctx, dry, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SizePlan{RowBytes: make(map[string]float64), Usage: res.Usage}
+	tables := opts.Tables
+	if tables == nil && opts.Schema != nil {
+		tables = opts.Schema.Tables
+	}
+	var perRow float64
+	for i, tr := range res.Tables {
+		var total float64
+		rows := tr.Generated.Rows
+		for _, row := range rows {
+			total += float64(RowBytes(opts.Driver, tables[i], row))
+		}
+		avg := float64(RowBytes(opts.Driver, tables[i], nil))
+		if len(rows) > 0 {
+			avg = total / float64(len(rows))
+		}
+		plan.RowBytes[tr.Name] = avg
+		perRow += avg
+	}
+	if perRow == 0 {
+		return nil, fmt.Errorf("no tables to size")
+	}
+	plan.Rows = int(math.Max(1, math.Floor(float64(target)/perRow)))
+	plan.Bytes = int64(float64(plan.Rows) * perRow)
+	return plan, nil
+}
+
+// RowBytes estimates the stored size of row in t: a per-row header plus
+// each column's value. Columns missing from row, such as auto-increment
+// keys, are counted at their type's usual size.
+func RowBytes(driver string, t *schema.Table, row map[string]interface{}) int {
+	sqlite := driver == "sqlite3"
+	n := 28 // Postgres tuple header and line pointer
+	if sqlite {
+		n = 3 + len(t.Columns) // record header and rowid
+	}
+	for _, c := range t.Columns {
+		v, ok := row[c.Name]
+		if ok && v == nil {
+			continue
+		}
+		n += valueBytes(sqlite, c, v, ok)
+	}
+	return n
+}
+
+func valueBytes(sqlite bool, c schema.Column, v interface{}, present bool) int {
+	s := fmt.Sprint(v)
+	switch {
+	case strings.Contains(c.SQLType, "uuid") && !sqlite:
+		return 16
+	case c.Type == "integer":
+		if strings.Contains(c.SQLType, "big") {
+			return 8
+		}
+		return 4
+	case c.Type == "decimal":
+		if present && (strings.HasPrefix(c.SQLType, "numeric") || strings.HasPrefix(c.SQLType, "decimal")) {
+			return 3 + len(s)/2
+		}
+		return 8
+	case c.Type == "boolean":
+		return 1
+	case c.Type == "timestamp" && !sqlite:
+		if c.SQLType == "date" {
+			return 4
+		}
+		return 8
+	case !present:
+		return 16
+	case c.Type == "binary":
+		if b, ok := v.([]byte); ok {
+			return 1 + len(b)
+		}
+		if strings.HasPrefix(s, `\x`) {
+			return 1 + (len(s)-2)/2
+		}
+	}
+	return 1 + len(s)
+}
+
+// ParseSize reads a size such as 500MB, 1.5GB, 64k or 1048576 (bytes).
+// Units are powers of 1024 and case-insensitive.
+func ParseSize(s string) (int64, error) {
+	t := strings.ToUpper(strings.TrimSpace(s))
+	t = strings.TrimSuffix(strings.TrimSuffix(t, "IB"), "B")
+	mult := 1.0
+	for i, unit := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(t, unit) {
+			mult = math.Pow(1024, float64(i+1))
+			t = strings.TrimSuffix(t, unit)
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500MB, 2GB)", s)
+	}
+	return int64(n * mult), nil
+}
+
+// FormatSize renders bytes with the largest unit that keeps the number at
+// least 1, e.g. 1.5 GB.
+func FormatSize(n int64) string {
+	if n < 1024 {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	f := float64(n) / 1024
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		if f < 1024 {
+			return strconv.FormatFloat(f, 'f', 1, 64) + " " + unit
+		}
+		f /= 1024
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + " TB"
+}
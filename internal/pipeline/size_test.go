@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"500MB":   500 << 20,
+		"1.5gb":   3 << 29,
+		"64k":     64 << 10,
+		"2 GiB":   2 << 30,
+		"1048576": 1 << 20,
+	} {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1GB", "ten"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q): expected an error", in)
+		}
+	}
+	if got := FormatSize(3 << 29); got != "1.5 GB" {
+		t.Errorf("FormatSize = %q, want 1.5 GB", got)
+	}
+}
+
+func TestPlanSize(t *testing.T) {
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "status": "live"}, {"user_id": 2, "status": "draft"}]`,
+	}
+	opts := Options{Schema: s, Config: generator.DefaultConfig(), Client: client, Driver: "pgx"}
+	plan, err := PlanSize(context.Background(), opts, 1<<20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// users: 28 header + 4 id + 7 email; posts: 28 + 4 + 4 + 5 or 6 status.
+	if plan.RowBytes["users"] != 39 || plan.RowBytes["posts"] != 41.5 {
+		t.Fatalf("row sizes %v", plan.RowBytes)
+	}
+	if want := 13025; plan.Rows != want {
+		t.Errorf("rows = %d, want %d", plan.Rows, want)
+	}
+	if plan.Bytes > 1<<20 {
+		t.Errorf("plan of %d bytes overshoots the target", plan.Bytes)
+	}
+}
@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// skewRefs sets every non-NULL FK value in rows to a value drawn from
+// refIDs with Zipf weights, using r: the k-th value has weight 1/k^s. FK columns
+// that are UNIQUE, alone or together with other columns, or part of the
+// primary key are left alone, since redrawing them would break the
+// constraint.
+func skewRefs(t *schema.Table, rows []map[string]interface{}, refIDs map[string][]interface{}, s float64, r *rand.Rand) {
+	for _, c := range t.FKColumns() {
+		ids := refIDs[c.Name]
+		if c.Unique || c.PrimaryKey || inUniqueGroup(t, c.Name) || len(ids) < 2 {
+			continue
+		}
+		cum := zipfWeights(len(ids), s)
+		for _, row := range rows {
+			if v, ok := row[c.Name]; !ok || v == nil {
+				continue
+			}
+			row[c.Name] = ids[sort.SearchFloat64s(cum, r.Float64()*cum[len(cum)-1])]
+		}
+	}
+}
+
+// zipfWeights returns the cumulative weights 1/k^s for k = 1..n.
+func zipfWeights(n int, s float64) []float64 {
+	cum := make([]float64, n)
+	total := 0.0
+	for k := range cum {
+		total += 1 / math.Pow(float64(k+1), s)
+		cum[k] = total
+	}
+	return cum
+}
+
+func inUniqueGroup(t *schema.Table, col string) bool {
+	for _, group := range t.UniqueTogether {
+		for _, c := range group {
+			if c == col {
+				return true
+			}
+		}
+	}
+	return false
+}
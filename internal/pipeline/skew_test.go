@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestSkewRefs(t *testing.T) {
+	table := &schema.Table{
+		Name: "orders",
+		Columns: []schema.Column{
+			{Name: "user_id", Type: "integer", ForeignKey: &schema.ForeignKey{RefTable: "users", RefColumn: "id"}},
+			{Name: "coupon_id", Type: "integer", ForeignKey: &schema.ForeignKey{RefTable: "coupons", RefColumn: "id"}},
+			{Name: "invoice_id", Type: "integer", Unique: true, ForeignKey: &schema.ForeignKey{RefTable: "invoices", RefColumn: "id"}},
+		},
+	}
+	ids := []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	rows := make([]map[string]interface{}, 2000)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"user_id": 10, "coupon_id": nil, "invoice_id": i}
+	}
+	skewRefs(table, rows, map[string][]interface{}{"user_id": ids, "coupon_id": ids, "invoice_id": ids}, 1.2, rand.New(rand.NewPCG(1, 2)))
+
+	counts := make(map[interface{}]int)
+	for i, row := range rows {
+		counts[row["user_id"]]++
+		if row["coupon_id"] != nil {
+			t.Fatalf("row %d: NULL FK was filled in", i)
+		}
+		if row["invoice_id"] != i {
+			t.Fatalf("row %d: UNIQUE FK was redrawn", i)
+		}
+	}
+	// Expected shares are about 37% for the first value and 2.3% for the last.
+	if counts[1] < 5*counts[10] || counts[1] < counts[2] {
+		t.Errorf("user_id is not skewed towards the first values: %v", counts)
+	}
+	if len(counts) < 8 {
+		t.Errorf("expected most parents to get some children: %v", counts)
+	}
+}
@@ -0,0 +1,407 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// chunk is what the generation stage hands to Run's insert stage: up to
+// ChunkRows rows of a table, a table that needs no rows, or the error that
+// ends the run. A chunk rejected by FailOnIssues has both rows and error.
+type chunk struct {
+	t           *schema.Table
+	first, last bool
+	skipped     bool
+	abandon     bool // SkipTimedOut: err says why t is given up
+	want        int  // rows requested for the whole table
+	existing    int  // rows already in the table (top-up, resume)
+	offset      int  // rows generated for the table before this chunk
+	gr          *generator.GenerationResult
+	dropped     int
+	issues      []*validator.ValidationError
+	elapsed     time.Duration // generating this chunk
+	err         error
+}
+
+// add merges chunk c into tr. A table generated in one chunk keeps its
+// GenerationResult; for larger ones Generated sums the chunks and keeps
+// no rows.
+func (tr *TableResult) add(c chunk) {
+	gr := c.gr
+	tr.Rows += len(gr.Rows)
+	for _, row := range gr.Rows {
+		tr.Checksum.Add(row)
+	}
+	tr.Dropped += c.dropped
+	tr.Issues = append(tr.Issues, c.issues...)
+	tr.Elapsed += c.elapsed
+	if c.first && c.last {
+		tr.Generated = gr
+		return
+	}
+	if tr.Generated == nil {
+		tr.Generated = &generator.GenerationResult{TableName: gr.TableName, Columns: gr.Columns, Requested: c.want}
+		tr.Generated.Repairs.Table = gr.Repairs.Table
+		tr.Generated.Provenance = gr.Provenance
+		tr.Generated.Provenance.Prompts = nil
+	}
+	sum := tr.Generated
+	sum.FollowUps += gr.FollowUps
+	sum.Retries += gr.Retries
+	sum.Provenance.Prompts = append(sum.Provenance.Prompts, gr.Provenance.Prompts...)
+	sum.Usage.Add(gr.Usage)
+	sum.Elapsed += gr.Elapsed
+	for _, a := range gr.Repairs.Actions {
+		a.Row += c.offset
+		sum.Repairs.Actions = append(sum.Repairs.Actions, a)
+	}
+	sum.Coverage = append(sum.Coverage, gr.Coverage...)
+}
+
+// genStage generates tables in order for Run's insert stage.
+type genStage struct {
+	ctx  context.Context
+	opts Options
+	gen  *generator.Generator
+	emit ProgressFunc
+	stop chan struct{} // closed when Run returns
+	// inserted has a channel per table, closed once the table's rows are
+	// in the database (or it needed none).
+	inserted map[string]chan struct{}
+	// generated holds each table's rows (the first chunk of large ones),
+	// for FK values in dry runs.
+	generated map[string][]map[string]interface{}
+	// started holds the tables whose generation has begun, which come
+	// before the current one in the run.
+	started map[string]bool
+
+	mu        sync.Mutex
+	abandoned map[string]bool // tables given up, see Options.SkipTimedOut
+}
+
+// abandon records that table was given up.
+func (g *genStage) abandon(table string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.abandoned == nil {
+		g.abandoned = make(map[string]bool)
+	}
+	g.abandoned[table] = true
+}
+
+// gaveUp reports whether table was given up.
+func (g *genStage) gaveUp(table string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.abandoned[table]
+}
+
+// abandonedRef returns a table t references that was given up, if any.
+func (g *genStage) abandonedRef(t *schema.Table) string {
+	for _, c := range t.FKColumns() {
+		if ref := c.ForeignKey.RefTable; ref != t.Name && g.gaveUp(ref) {
+			return ref
+		}
+	}
+	return ""
+}
+
+func newGenStage(ctx context.Context, opts Options, gen *generator.Generator, emit ProgressFunc, tables []*schema.Table) *genStage {
+	g := &genStage{ctx: ctx, opts: opts, gen: gen, emit: emit, stop: make(chan struct{}),
+		inserted: make(map[string]chan struct{}, len(tables)), generated: make(map[string][]map[string]interface{}),
+		started: make(map[string]bool)}
+	for _, t := range tables {
+		g.inserted[t.Name] = make(chan struct{})
+	}
+	return g
+}
+
+// call runs fn within the table's deadline on a fork of the generator
+// whose progress counts from base of total rows. A call that runs out of
+// time is left running (see within), so its fork is detached: it cannot
+// report progress or record handles for the tables after it.
+func (g *genStage) call(deadline time.Time, base, total int, fn func(*generator.Generator) (*generator.GenerationResult, error)) (*generator.GenerationResult, error) {
+	var mu sync.Mutex
+	detached := false
+	gen := g.gen.Fork(func(p generator.Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if detached {
+			return
+		}
+		p.Rows += base
+		p.Requested = total
+		g.emit(Event{Table: p.Table, Stage: StageGenerating, Progress: p})
+	})
+	gr, err := within(deadline, g.opts.TableTimeout, func() (*generator.GenerationResult, error) {
+		return fn(gen)
+	})
+	if err != nil {
+		mu.Lock()
+		detached = true
+		mu.Unlock()
+		gen.Detach()
+	}
+	return gr, err
+}
+
+// run sends the chunks of each table to queue and closes it after the
+// last table or the first error.
+func (g *genStage) run(tables []*schema.Table, queue chan<- chunk) {
+	defer close(queue)
+	send := func(c chunk) bool {
+		select {
+		case queue <- c:
+			return c.err == nil || c.abandon
+		case <-g.stop:
+			return false
+		}
+	}
+	for _, t := range tables {
+		g.started[t.Name] = true
+		if !g.table(t, send) {
+			return
+		}
+	}
+}
+
+// table generates and validates t chunk by chunk and passes each chunk to
+// send. It returns false once send does.
+func (g *genStage) table(t *schema.Table, send func(chunk) bool) bool {
+	opts := g.opts
+	failed := func(err error) bool {
+		return send(chunk{t: t, err: err})
+	}
+	if err := g.ctx.Err(); err != nil {
+		return failed(err)
+	}
+	if opts.DB != nil {
+		if err := g.waitForRefs(t); err != nil {
+			return failed(err)
+		}
+		if err := g.waitForUsers(t); err != nil {
+			return failed(err)
+		}
+	}
+	if ref := g.abandonedRef(t); ref != "" {
+		g.abandon(t.Name)
+		err := &TableError{Op: OpGenerate, Table: t.Name, Err: fmt.Errorf("references %s, which was given up", ref)}
+		return send(chunk{t: t, first: true, last: true, abandon: true, err: err})
+	}
+	start := time.Now()
+	want, existing := opts.rows(t), 0
+	resumed := opts.Resume.table(t.Name)
+	if resumed != nil {
+		existing, want = resumed.Existing+resumed.Inserted, resumed.Want-resumed.Inserted
+		if resumed.Done || want <= 0 {
+			return send(chunk{t: t, first: true, last: true, skipped: true, existing: existing})
+		}
+	}
+	if (opts.TopUp || opts.SkipPopulated) && opts.DB != nil && resumed == nil {
+		n, err := inserter.CountRows(opts.DB, t.Name)
+		if err != nil {
+			return failed(&TableError{Op: OpCount, Table: t.Name, Err: err})
+		}
+		if n >= want {
+			return send(chunk{t: t, first: true, last: true, skipped: true, existing: n})
+		}
+		existing = n
+		if opts.TopUp {
+			want -= n
+		}
+	}
+	g.emit(Event{Table: t.Name, Stage: StageGenerating, Progress: generator.Progress{Table: t.Name, Requested: want}})
+
+	size := opts.ChunkRows
+	if size <= 0 {
+		size = DefaultChunkRows
+	}
+	if dir := opts.Config.ReplayDir; dir != "" && !opts.TopUp && resumed == nil {
+		saved, ok, err := LoadRows(dir, t.Name)
+		if err != nil {
+			return failed(&TableError{Op: OpGenerate, Table: t.Name, Err: err})
+		}
+		if ok {
+			return g.replay(t, saved, size, existing, send)
+		}
+	}
+	tr, translated, err := g.translation(t, want)
+	if err != nil {
+		return failed(err)
+	}
+	if translated {
+		return g.translate(t, tr, existing, send)
+	}
+	var seen map[string]map[string]bool
+	if (opts.TopUp || opts.SkipPopulated || resumed != nil) && opts.DB != nil {
+		var err error
+		if seen, err = existingUniques(opts.DB, t); err != nil {
+			return failed(&TableError{Op: OpCheck, Table: t.Name, Err: err})
+		}
+	} else if want > size {
+		seen = make(map[string]map[string]bool)
+		for _, col := range uniqueColumns(t) {
+			seen[col] = make(map[string]bool)
+		}
+	}
+	if rows := opts.anchored[t.Name]; len(rows) > 0 {
+		// generated rows must not take an anchor's UNIQUE values
+		if seen == nil {
+			seen = make(map[string]map[string]bool)
+		}
+		for _, col := range uniqueColumns(t) {
+			if seen[col] == nil {
+				seen[col] = make(map[string]bool)
+			}
+			for _, row := range rows {
+				if v := row[col]; v != nil {
+					seen[col][fmt.Sprint(v)] = true
+				}
+			}
+		}
+	}
+
+	refIDs := refValues(opts, t, g.generated)
+	actors := actorValues(opts, t, g.generated)
+	archetype := opts.archetype(t)
+	links := make(map[string]bool)
+	rows := 0
+	for requested := 0; requested < want; {
+		if err := g.ctx.Err(); err != nil {
+			return failed(err)
+		}
+		if g.gaveUp(t.Name) {
+			return true // an insert of an earlier chunk timed out
+		}
+		n := min(size, want-requested)
+		chunkStart := time.Now()
+		gr, err := g.call(opts.deadline(time.Since(start)), rows, want, func(gen *generator.Generator) (*generator.GenerationResult, error) {
+			return gen.Generate(t, n, opts.Schema, opts.style(t), refIDs)
+		})
+		if err != nil {
+			err = &TableError{Op: OpGenerate, Table: t.Name, Err: err}
+			if opts.SkipTimedOut && errors.Is(err, context.DeadlineExceeded) && g.ctx.Err() == nil {
+				g.abandon(t.Name)
+				return send(chunk{t: t, first: requested == 0, last: true, abandon: true, err: err})
+			}
+			return failed(err)
+		}
+		if opts.RefSkew > 0 {
+			skewRefs(t, gr.Rows, refIDs, opts.RefSkew, opts.Config.Random())
+		}
+		fillActors(t, gr.Rows, actors, opts.Config.Random())
+		c := chunk{t: t, first: requested == 0, want: want, existing: existing, offset: rows, gr: gr,
+			dropped: dropSeen(gr, seen)}
+		switch archetype {
+		case schema.ArchetypeJoin:
+			c.dropped += distinctLinks(t, gr, refIDs, links, opts.Config.Random())
+		case schema.ArchetypeEvent:
+			sortEvents(t, gr.Rows)
+		}
+		orderParents(t, gr.Rows)
+		if c.first {
+			g.generated[t.Name] = gr.Rows
+		}
+		if dir := opts.Config.RecordDir; dir != "" {
+			if err := saveRows(dir, t.Name, gr.Rows, c.first); err != nil {
+				return failed(&TableError{Op: OpGenerate, Table: t.Name, Err: err})
+			}
+		}
+		c.issues = validator.ValidateRows(t, gr.Rows)
+		c.issues = append(c.issues, validator.ValidateRules(t, gr.Rows, opts.Config.Rules)...)
+		renumber(c.issues, rows)
+		c.elapsed = time.Since(chunkStart)
+		requested += n
+		rows += len(gr.Rows)
+		c.last = requested >= want
+		if opts.FailOnIssues && len(c.issues) > 0 {
+			err := fmt.Errorf("%d validation issues, first: %s", len(c.issues), c.issues[0])
+			c.err = &TableError{Op: OpValidate, Table: t.Name, Err: err}
+		}
+		if !send(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// replay sends the rows a recorded run gave t (see DataDir) in chunks of
+// size, in place of generating any.
+func (g *genStage) replay(t *schema.Table, rows []map[string]interface{}, size, existing int, send func(chunk) bool) bool {
+	var columns []string
+	for _, c := range t.NonAutoColumns() {
+		columns = append(columns, c.Name)
+	}
+	g.generated[t.Name] = rows[:min(size, len(rows))]
+	for i := 0; i < len(rows) || i == 0; i += size {
+		part := rows[i:min(i+size, len(rows))]
+		gr := &generator.GenerationResult{TableName: t.Name, Columns: columns, Rows: part, Requested: len(part)}
+		gr.Repairs.Table = t.Name
+		gr.Provenance.Replayed = true
+		c := chunk{t: t, first: i == 0, last: i+size >= len(rows), want: len(rows), existing: existing, offset: i, gr: gr}
+		c.issues = validator.ValidateRows(t, part)
+		renumber(c.issues, i)
+		if !send(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForRefs blocks until every table t references, other than itself
+// and tables outside this run, has been inserted. A table still to come
+// is not waited for, as in waitForUsers: it references t too, and t can
+// only reference the rows it already has.
+func (g *genStage) waitForRefs(t *schema.Table) error {
+	for _, c := range t.FKColumns() {
+		if c.ForeignKey == nil || c.ForeignKey.RefTable == t.Name || !g.started[c.ForeignKey.RefTable] {
+			continue
+		}
+		ch, ok := g.inserted[c.ForeignKey.RefTable]
+		if !ok {
+			continue
+		}
+		select {
+		case <-ch:
+		case <-g.ctx.Done():
+			return g.ctx.Err()
+		case <-g.stop:
+			return context.Canceled
+		}
+	}
+	return nil
+}
+
+// waitForUsers blocks, for a table with actor columns (see actorValues),
+// until the users table has been inserted, if this run seeded it earlier.
+// A users table still to come is not waited for: it may reference t.
+func (g *genStage) waitForUsers(t *schema.Table) error {
+	users := g.opts.usersTable()
+	if users == nil || users == t || !g.started[users.Name] || len(schema.ActorColumns(t)) == 0 {
+		return nil
+	}
+	select {
+	case <-g.inserted[users.Name]:
+		return nil
+	case <-g.ctx.Done():
+		return g.ctx.Err()
+	case <-g.stop:
+		return context.Canceled
+	}
+}
+
+// renumber shifts the rows of validator findings by offset, so findings
+// in later chunks count rows from the start of the table.
+func renumber(issues []*validator.ValidationError, offset int) {
+	for _, issue := range issues {
+		issue.Row += offset
+	}
+}
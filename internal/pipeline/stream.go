@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// DefaultStreamChunk is the rows generated per prompt in Stream.
+const DefaultStreamChunk = 50
+
+// StreamOptions configures Stream.
+type StreamOptions struct {
+	Schema *schema.Schema
+	Tables []*schema.Table // tables to insert into, taking turns
+	Rate   float64         // rows per second across all tables
+	Chunk  int             // rows generated per prompt; 0 means DefaultStreamChunk
+
+	Config generator.Config
+	Client generator.LLMClient // nil means Ollama as configured in Config
+
+	DB         *sql.DB
+	Driver     string
+	RefIDLimit int
+}
+
+// StreamEvent reports one inserted row, or a failed generation or insert.
+type StreamEvent struct {
+	Table    string
+	Inserted int // rows inserted into Table so far
+	Total    int // rows inserted into all tables so far
+	Err      error
+}
+
+// StreamStats is the outcome of a stream.
+type StreamStats struct {
+	Inserted map[string]int
+	Total    int
+	Failed   int // rows that could not be inserted
+	Usage    generator.Usage
+	Elapsed  time.Duration
+}
+
+// Stream inserts generated rows at opts.Rate until ctx is done, which is
+// the normal way to stop it. Each table has a producer that generates
+// chunks ahead of time, with FK values fetched fresh for every chunk, so
+// rows streamed into a parent table become referable by its children. A
+// tick with no row ready for the table whose turn it is passes to the
+// next table. Failed inserts (a repeated UNIQUE value, say) are reported
+// through progress and skipped.
+func Stream(ctx context.Context, opts StreamOptions, progress func(StreamEvent)) (*StreamStats, error) {
+	if opts.Rate <= 0 {
+		return nil, fmt.Errorf("stream rate must be positive")
+	}
+	if opts.DB == nil || len(opts.Tables) == 0 {
+		return nil, fmt.Errorf("stream needs a database and at least one table")
+	}
+	if progress == nil {
+		progress = func(StreamEvent) {}
+	}
+	chunk := opts.Chunk
+	if chunk <= 0 {
+		chunk = DefaultStreamChunk
+	}
+	client := opts.Client
+	if client == nil {
+		client = generator.NewOllamaClient(opts.Config, nil)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type batch struct {
+		rows  []map[string]interface{}
+		cols  []string
+		usage generator.Usage
+		err   error
+	}
+	feeds := make([]chan batch, len(opts.Tables))
+	for i, t := range opts.Tables {
+		feeds[i] = make(chan batch, 1)
+		go func(t *schema.Table, out chan<- batch) {
+			gen := generator.NewWithClient(opts.Config, client)
+			runOpts := Options{Schema: opts.Schema, DB: opts.DB, Driver: opts.Driver, RefIDLimit: opts.RefIDLimit}
+			for ctx.Err() == nil {
+				gr, err := gen.Generate(t, chunk, opts.Schema, string(opts.Config.Style), refValues(runOpts, t, nil))
+				b := batch{err: err}
+				if err == nil {
+					fillActors(t, gr.Rows, actorValues(runOpts, t, nil), opts.Config.Random())
+					inserter.ConvertRows(opts.Driver, t, gr.Rows)
+					b = batch{rows: gr.Rows, cols: gr.Columns, usage: gr.Usage}
+				}
+				select {
+				case out <- b:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil {
+					select { // back off instead of hammering a failing model
+					case <-time.After(time.Second):
+					case <-ctx.Done():
+					}
+				}
+			}
+		}(t, feeds[i])
+	}
+
+	ins := inserter.New(opts.DB, opts.Driver)
+	defer ins.CloseStatements()
+	stats := &StreamStats{Inserted: make(map[string]int)}
+	start := time.Now()
+	pending := make([]batch, len(opts.Tables))
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+	defer ticker.Stop()
+	turn := 0
+	for {
+		select {
+		case <-ctx.Done():
+			stats.Elapsed = time.Since(start)
+			return stats, nil
+		case <-ticker.C:
+		}
+		for tries := 0; tries < len(opts.Tables); tries++ {
+			i := turn % len(opts.Tables)
+			turn++
+			if len(pending[i].rows) == 0 {
+				select {
+				case b := <-feeds[i]:
+					stats.Usage.Add(b.usage)
+					if b.err != nil {
+						progress(StreamEvent{Table: opts.Tables[i].Name, Total: stats.Total, Err: b.err})
+						continue
+					}
+					pending[i] = b
+				default:
+					continue
+				}
+			}
+			if len(pending[i].rows) == 0 {
+				continue
+			}
+			t := opts.Tables[i]
+			row := pending[i].rows[0]
+			pending[i].rows = pending[i].rows[1:]
+			if _, err := ins.InsertBatch(t.Name, pending[i].cols, []map[string]interface{}{row}); err != nil {
+				stats.Failed++
+				progress(StreamEvent{Table: t.Name, Inserted: stats.Inserted[t.Name], Total: stats.Total, Err: err})
+				break
+			}
+			stats.Inserted[t.Name]++
+			stats.Total++
+			progress(StreamEvent{Table: t.Name, Inserted: stats.Inserted[t.Name], Total: stats.Total})
+			break
+		}
+	}
+}
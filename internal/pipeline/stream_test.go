@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestStream(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`
+CREATE TABLE events (id INTEGER PRIMARY KEY AUTOINCREMENT, kind TEXT NOT NULL);
+CREATE TABLE orders (id INTEGER PRIMARY KEY AUTOINCREMENT, total INTEGER NOT NULL);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tableClient{
+		"events": `[{"kind": "click"}, {"kind": "view"}]`,
+		"orders": `[{"total": 10}, {"total": 20}]`,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	stats, err := Stream(ctx, StreamOptions{
+		Schema: s,
+		Tables: s.Tables,
+		Rate:   200,
+		Chunk:  2,
+		Config: generator.DefaultConfig(),
+		Client: client,
+		DB:     db,
+		Driver: "sqlite3",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Inserted["events"] == 0 || stats.Inserted["orders"] == 0 {
+		t.Fatalf("expected rows in both tables, got %v", stats.Inserted)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT (SELECT COUNT(*) FROM events) + (SELECT COUNT(*) FROM orders)`).Scan(&n); err != nil || n != stats.Total {
+		t.Errorf("database has %d rows, stats say %d (%v)", n, stats.Total, err)
+	}
+
+	if _, err := Stream(context.Background(), StreamOptions{Tables: s.Tables, DB: db}, nil); err == nil {
+		t.Error("expected an error for a zero rate")
+	}
+}
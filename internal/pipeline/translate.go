@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// translation returns t as a translation table (see
+// generator.TranslationOf) with the parent rows it translates: enough for
+// want rows over Config.Locales, from the database or else from this
+// run's rows. ok is false when t is generated like any other table:
+// without locales, or without parent rows that have their key.
+func (g *genStage) translation(t *schema.Table, want int) (tr generator.Translation, ok bool, err error) {
+	locales := g.opts.Config.Locales
+	if len(locales) == 0 {
+		return tr, false, nil
+	}
+	if tr, ok = generator.TranslationOf(t, g.opts.Schema); !ok {
+		return tr, false, nil
+	}
+	n := (want + len(locales) - 1) / len(locales)
+	if g.opts.DB != nil {
+		tr.Sources, err = inserter.FetchRows(g.opts.DB, tr.Parent.Name, append([]string{tr.Ref}, tr.Columns...), n)
+		if err != nil {
+			return tr, false, &TableError{Op: OpGenerate, Table: t.Name, Err: err}
+		}
+	} else {
+		for _, row := range g.generated[tr.Parent.Name] {
+			if row[tr.Ref] != nil && len(tr.Sources) < n {
+				tr.Sources = append(tr.Sources, row)
+			}
+		}
+	}
+	return tr, len(tr.Sources) > 0, nil
+}
+
+// translate generates t from tr, a chunk of parent rows at a time, and
+// passes each chunk to send as table does. Every parent row gets one row
+// per locale.
+func (g *genStage) translate(t *schema.Table, tr generator.Translation, existing int, send func(chunk) bool) bool {
+	opts := g.opts
+	locales := opts.Config.Locales
+	size := opts.ChunkRows
+	if size <= 0 {
+		size = DefaultChunkRows
+	}
+	per := max(1, size/len(locales)) // parent rows per chunk
+	want := len(tr.Sources) * len(locales)
+	start := time.Now()
+	rows := 0
+	for i := 0; i < len(tr.Sources); i += per {
+		if err := g.ctx.Err(); err != nil {
+			return send(chunk{t: t, err: err})
+		}
+		part := tr
+		part.Sources = tr.Sources[i:min(i+per, len(tr.Sources))]
+		chunkStart := time.Now()
+		gr, err := g.call(opts.deadline(time.Since(start)), rows, want, func(gen *generator.Generator) (*generator.GenerationResult, error) {
+			return gen.Translate(t, part, locales, opts.style(t))
+		})
+		if err != nil {
+			return send(chunk{t: t, err: &TableError{Op: OpGenerate, Table: t.Name, Err: err}})
+		}
+		c := chunk{t: t, first: i == 0, last: i+per >= len(tr.Sources), want: want, existing: existing, offset: rows, gr: gr}
+		if c.first {
+			g.generated[t.Name] = gr.Rows
+		}
+		if dir := opts.Config.RecordDir; dir != "" {
+			if err := saveRows(dir, t.Name, gr.Rows, c.first); err != nil {
+				return send(chunk{t: t, err: &TableError{Op: OpGenerate, Table: t.Name, Err: err}})
+			}
+		}
+		c.issues = validator.ValidateRows(t, gr.Rows)
+		c.issues = append(c.issues, validator.ValidateRules(t, gr.Rows, opts.Config.Rules)...)
+		renumber(c.issues, rows)
+		c.elapsed = time.Since(chunkStart)
+		rows += len(gr.Rows)
+		if opts.FailOnIssues && len(c.issues) > 0 {
+			err := fmt.Errorf("%d validation issues, first: %s", len(c.issues), c.issues[0])
+			c.err = &TableError{Op: OpValidate, Table: t.Name, Err: err}
+		}
+		if !send(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+package repair
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/address"
+	"github.com/satyammistari/db-seed-ai/internal/phone"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindAddress marks a city, state, postal code or country changed to
+// agree with the rest of its address.
+const KindAddress Kind = "address"
+
+// Addresses makes each address group of t (see address.Groups) name one
+// real place: the city the model wrote when the dataset knows it and its
+// country fits, else a place in the country the row's phone prefix or
+// country column points to, else one drawn from r. State, postal code and
+// country are then set from that place, as codes when the column or the
+// model's value is short. Countries, when given, limits the places used;
+// so does a CHECK list on the country column. Streets are kept, and NULLs
+// in nullable columns stay.
+func Addresses(t *schema.Table, rows []map[string]interface{}, countries []string, r *rand.Rand) Report {
+	rep := Report{Table: t.Name}
+	groups := address.Groups(t)
+	if len(groups) == 0 {
+		return rep
+	}
+	for _, g := range groups {
+		allowed := allowedCountries(t, g, countries)
+		phoneCol := addressPhone(t, g)
+		for i, row := range rows {
+			want := allowed
+			if cc := phoneCountries(row[phoneCol]); len(cc) > 0 {
+				if fit := intersect(cc, allowed); len(fit) > 0 {
+					want = fit
+				}
+			}
+			if c, ok := address.LookupCountry(asText(row[g.Country])); ok && (len(want) == 0 || slices.Contains(want, c.Code)) {
+				want = []string{c.Code}
+			}
+			p, ok := address.Find(asText(row[g.City]))
+			if !ok || len(want) > 0 && !slices.Contains(want, p.Country) {
+				p = address.Random(want, r)
+			}
+			c, _ := address.LookupCountry(p.Country)
+			set := func(colName, to string) {
+				if colName == "" || to == "" {
+					return
+				}
+				col := t.Column(colName)
+				v, present := row[colName]
+				if present && v == nil && !col.NotNull || v == to {
+					return
+				}
+				row[colName] = to
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: colName, Kind: KindAddress, From: v, To: to})
+			}
+			set(g.City, p.City)
+			if g.State != "" {
+				set(g.State, placeName(t.Column(g.State), row[g.State], p.State, p.StateName))
+			}
+			if g.Postal != "" && !fitsPattern(asText(row[g.Postal]), p.Postal) {
+				set(g.Postal, p.PostalCode(r))
+			}
+			if g.Country != "" {
+				set(g.Country, placeName(t.Column(g.Country), row[g.Country], c.Code, c.Name))
+			}
+		}
+	}
+	return rep
+}
+
+// allowedCountries returns the dataset countries the group may use: those
+// configured, narrowed to the country column's CHECK list if it has one.
+// Nil means any.
+func allowedCountries(t *schema.Table, g address.Group, configured []string) []string {
+	var out []string
+	for _, code := range address.Codes() {
+		if len(configured) > 0 && !slices.ContainsFunc(configured, func(c string) bool { return strings.EqualFold(c, code) }) {
+			continue
+		}
+		if g.Country != "" {
+			if col := t.Column(g.Country); len(col.CheckIn) > 0 {
+				c, _ := address.LookupCountry(code)
+				if placeName(col, nil, c.Code, c.Name) == "" {
+					continue
+				}
+			}
+		}
+		out = append(out, code)
+	}
+	if len(configured) == 0 && len(out) == len(address.Codes()) {
+		return nil
+	}
+	return out
+}
+
+// addressPhone returns the phone column that goes with the group: one
+// with the same prefix, else the table's only phone column.
+func addressPhone(t *schema.Table, g address.Group) string {
+	var phones []string
+	for _, col := range t.Columns {
+		if col.Type == "text" && isPhoneColumn(col.Name) {
+			if g.Prefix != "" && strings.HasPrefix(strings.ToLower(col.Name), strings.ToLower(g.Prefix)) {
+				return col.Name
+			}
+			phones = append(phones, col.Name)
+		}
+	}
+	if len(phones) == 1 {
+		return phones[0]
+	}
+	return ""
+}
+
+// phoneCountries returns the dataset countries whose calling code starts
+// an international number (+44 …, 0044 …): several for shared codes like
+// +1.
+func phoneCountries(v interface{}) []string {
+	s := strings.TrimSpace(asText(v))
+	switch {
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	case strings.HasPrefix(s, "00"):
+		s = s[2:]
+	default:
+		return nil
+	}
+	digits := onlyDigits(s)
+	var out []string
+	best := 0
+	for _, code := range address.Codes() {
+		c, ok := phone.Lookup(code)
+		if !ok || !strings.HasPrefix(digits, c.Calling) || len(c.Calling) < best {
+			continue
+		}
+		if len(c.Calling) > best {
+			out, best = nil, len(c.Calling)
+		}
+		out = append(out, code)
+	}
+	return out
+}
+
+// placeName returns code or name for a state or country column: the entry of
+// its CHECK list that matches either (or "" if none does), else the code
+// when the column or the model's value is short, else the name.
+func placeName(col *schema.Column, v interface{}, code, name string) string {
+	if len(col.CheckIn) > 0 {
+		for _, c := range col.CheckIn {
+			if strings.EqualFold(c, code) || strings.EqualFold(c, name) {
+				return c
+			}
+		}
+		return ""
+	}
+	n := col.MaxLength()
+	s := asText(v)
+	if strings.Contains(strings.ToLower(col.Name), "code") || n > 0 && n < len(name) || s != "" && len(s) <= 3 {
+		return code
+	}
+	return name
+}
+
+// fitsPattern reports whether s matches a postal pattern, # for a digit.
+func fitsPattern(s, pattern string) bool {
+	if len(s) != len(pattern) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if pattern[i] == '#' && (s[i] < '0' || s[i] > '9') || pattern[i] != '#' && !strings.EqualFold(s[i:i+1], pattern[i:i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func intersect(a, b []string) []string {
+	if b == nil {
+		return a
+	}
+	var out []string
+	for _, s := range a {
+		if slices.Contains(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func asText(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
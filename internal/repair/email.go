@@ -0,0 +1,150 @@
+package repair
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindEmail marks an email rewritten for syntax, domain or uniqueness.
+const KindEmail Kind = "email"
+
+// IsEmailColumn reports whether col holds email addresses: text columns
+// named like email, or any column a CHECK tests against an email pattern.
+func IsEmailColumn(col schema.Column) bool {
+	if col.CheckEmail {
+		return true
+	}
+	return col.Type == "text" && col.ForeignKey == nil && strings.Contains(strings.ToLower(col.Name), "email")
+}
+
+// Emails makes every email column of t hold valid, unique addresses. The
+// model's local part is kept when usable; values without an @ get one
+// built from the row's name columns. With domains, addresses on other
+// domains are moved to one of them. Duplicates (ignoring case) get a
+// number appended to the local part.
+func Emails(t *schema.Table, rows []map[string]interface{}, domains []string) Report {
+	rep := Report{Table: t.Name}
+	for _, col := range t.Columns {
+		if !IsEmailColumn(col) {
+			continue
+		}
+		seen := make(map[string]bool)
+		for i, row := range rows {
+			v, ok := row[col.Name]
+			if !ok || v == nil {
+				continue
+			}
+			from := fmt.Sprint(v)
+			local, domain := splitEmail(from)
+			if local == "" || domain == "" {
+				local = localFromName(row, i)
+			}
+			if len(domains) > 0 && !containsFold(domains, domain) {
+				domain = domains[i%len(domains)]
+			} else if !validDomain(domain) {
+				domain = "example.com"
+			}
+			to := uniqueEmail(local, strings.ToLower(domain), seen)
+			if to != from {
+				row[col.Name] = to
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindEmail, From: v, To: to})
+			}
+		}
+	}
+	return rep
+}
+
+// splitEmail returns the cleaned local part and the domain of s, or two
+// empty strings when s has no @.
+func splitEmail(s string) (local, domain string) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "mailto:"))
+	at := strings.LastIndexByte(s, '@')
+	if at == -1 {
+		return "", ""
+	}
+	return cleanLocal(s[:at]), strings.Trim(strings.ToLower(s[at+1:]), ". ")
+}
+
+// cleanLocal keeps the characters every mail system accepts in a local
+// part and removes leading, trailing and repeated dots.
+func cleanLocal(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '+':
+			b.WriteRune(r)
+		case r == '.' || r == ' ':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), ".") {
+				b.WriteByte('.')
+			}
+		}
+	}
+	local := strings.Trim(b.String(), ".")
+	if len(local) > 64 {
+		local = strings.TrimRight(local[:64], ".")
+	}
+	return local
+}
+
+// localFromName builds a local part from first_name/last_name or name
+// columns, falling back to user<row>.
+func localFromName(row map[string]interface{}, i int) string {
+	var parts []string
+	for _, k := range []string{"first_name", "last_name"} {
+		if s, ok := row[k].(string); ok {
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		for _, k := range []string{"full_name", "name", "username"} {
+			if s, ok := row[k].(string); ok {
+				parts = append(parts, s)
+				break
+			}
+		}
+	}
+	if local := cleanLocal(strings.Join(parts, ".")); local != "" {
+		return local
+	}
+	return fmt.Sprintf("user%d", i+1)
+}
+
+func validDomain(d string) bool {
+	labels := strings.Split(d, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" || strings.HasPrefix(l, "-") || strings.HasSuffix(l, "-") {
+			return false
+		}
+		for _, r := range l {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// uniqueEmail returns local@domain, numbering the local part until the
+// address is not in seen, and records it.
+func uniqueEmail(local, domain string, seen map[string]bool) string {
+	addr := local + "@" + domain
+	for n := 2; seen[addr]; n++ {
+		addr = fmt.Sprintf("%s%d@%s", local, n, domain)
+	}
+	seen[addr] = true
+	return addr
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package repair
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/satyammistari/db-seed-ai/internal/derive"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindHandle marks a slug, username or SKU rebuilt from the row's other
+// values or made unique.
+const KindHandle Kind = "handle"
+
+// handleKind is the style of a unique handle column.
+type handleKind int
+
+const (
+	notHandle handleKind = iota
+	slugHandle
+	usernameHandle
+	skuHandle
+)
+
+// handleOf returns the style of col: UNIQUE text columns named like a
+// slug, a username or a SKU.
+func handleOf(col schema.Column) handleKind {
+	if !col.Unique || col.Type != "text" || col.ForeignKey != nil {
+		return notHandle
+	}
+	name := strings.ToLower(col.Name)
+	switch {
+	case name == "slug" || strings.HasSuffix(name, "_slug") || name == "permalink" || name == "url_key":
+		return slugHandle
+	case name == "username" || name == "user_name" || name == "handle" || name == "login" || name == "screen_name" || name == "nickname":
+		return usernameHandle
+	case name == "sku" || strings.HasSuffix(name, "_sku"):
+		return skuHandle
+	}
+	return notHandle
+}
+
+// Handles builds slug, username and SKU columns from the row's other
+// values, since models repeat them: a slug from the title or name, a
+// username from the person's name, a SKU from the product name's
+// initials. Without such a value the model's own is cleaned up. Repeats
+// get a numbered suffix. used, keyed by column, holds the values already
+// given out, by earlier calls too; nil starts afresh.
+func Handles(t *schema.Table, rows []map[string]interface{}, used map[string]map[string]bool) Report {
+	rep := Report{Table: t.Name}
+	for _, col := range t.Columns {
+		kind := handleOf(col)
+		if kind == notHandle {
+			continue
+		}
+		seen := used[col.Name]
+		if seen == nil {
+			seen = make(map[string]bool)
+			if used != nil {
+				used[col.Name] = seen
+			}
+		}
+		limit := col.MaxLength()
+		for i, row := range rows {
+			v, ok := row[col.Name]
+			if !ok || v == nil && !col.NotNull {
+				continue
+			}
+			base := handleBase(kind, row, v)
+			if base == "" {
+				base = fmt.Sprintf("%s%d", strings.ToLower(t.Name), i+1)
+			}
+			to := uniqueHandle(kind, base, limit, seen)
+			if from, ok := v.(string); !ok || from != to {
+				row[col.Name] = to
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindHandle, From: v, To: to})
+			}
+		}
+	}
+	return rep
+}
+
+// handleBase returns the handle for row before deduplication.
+func handleBase(kind handleKind, row map[string]interface{}, model interface{}) string {
+	own := ""
+	if model != nil {
+		own = fmt.Sprint(model)
+	}
+	switch kind {
+	case slugHandle:
+		if src := firstText(row, "title", "name", "headline", "label", "full_name"); src != "" {
+			return derive.Slugify(src)
+		}
+		return derive.Slugify(own)
+	case usernameHandle:
+		src := strings.TrimSpace(firstText(row, "first_name") + " " + firstText(row, "last_name"))
+		if src == "" {
+			src = firstText(row, "full_name", "name", "display_name")
+		}
+		if src == "" {
+			src = own
+		}
+		if local, _, ok := strings.Cut(src, "@"); ok {
+			src = local
+		}
+		return strings.ReplaceAll(derive.Slugify(src), "-", "_")
+	case skuHandle:
+		name := firstText(row, "name", "title", "product_name")
+		if name == "" {
+			return strings.ToUpper(strings.ReplaceAll(derive.Slugify(own), "-", ""))
+		}
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		return fmt.Sprintf("%s-%04d", initials(name), 1000+h.Sum32()%9000)
+	}
+	return own
+}
+
+// firstText returns the first of the keys holding non-empty text in row.
+func firstText(row map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := row[k].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+// initials returns up to three upper-case letters: the first of each
+// word of name, filled up from the last word, so "Wireless Mouse" gives
+// "WMO".
+func initials(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	var b []rune
+	for _, w := range words {
+		if len(b) == 3 {
+			break
+		}
+		b = append(b, unicode.ToUpper([]rune(w)[0]))
+	}
+	if n := len(words); n > 0 {
+		for _, r := range []rune(words[n-1])[1:] {
+			if len(b) == 3 {
+				break
+			}
+			b = append(b, unicode.ToUpper(r))
+		}
+	}
+	if len(b) == 0 {
+		return "SKU"
+	}
+	return string(b)
+}
+
+// uniqueHandle returns base, cut to limit characters if positive, with the
+// first free numbered suffix if it is in seen, and records it.
+func uniqueHandle(kind handleKind, base string, limit int, seen map[string]bool) string {
+	sep := "-"
+	if kind == usernameHandle {
+		sep = ""
+	}
+	handle := cut(base, limit)
+	for n := 2; seen[handle]; n++ {
+		suffix := sep + strconv.Itoa(n)
+		handle = cut(base, limit-len(suffix)) + suffix
+	}
+	seen[handle] = true
+	return handle
+}
+
+// cut returns the first limit runes of s; a limit of zero or less keeps
+// all of s.
+func cut(s string, limit int) string {
+	if r := []rune(s); limit > 0 && len(r) > limit {
+		return strings.TrimRight(string(r[:limit]), "-_")
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package repair
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindImage marks an image URL replaced with a working placeholder.
+const KindImage Kind = "image"
+
+// Placeholder services used when no template is configured: DiceBear draws
+// avatars from a seed, Lorem Picsum serves a fixed photo per seed.
+const (
+	AvatarTemplate = "https://api.dicebear.com/9.x/initials/svg?seed={seed}"
+	PhotoTemplate  = "https://picsum.photos/seed/{seed}/{width}/{height}"
+)
+
+// KeepImages as a template leaves the model's URLs alone.
+const KeepImages = "keep"
+
+// imagePlaceholders are the fields a template may use.
+var imagePlaceholders = []string{"{seed}", "{width}", "{height}", "{table}", "{column}", "{row}"}
+
+// CheckImageTemplate reports a template Images cannot use: it must be
+// KeepImages or an http(s) URL whose braces are all known placeholders.
+func CheckImageTemplate(tmpl string) error {
+	if tmpl == KeepImages {
+		return nil
+	}
+	if !strings.HasPrefix(tmpl, "https://") && !strings.HasPrefix(tmpl, "http://") {
+		return fmt.Errorf("%q must be keep or start with http:// or https://", tmpl)
+	}
+	rest := tmpl
+	for _, p := range imagePlaceholders {
+		rest = strings.ReplaceAll(rest, p, "")
+	}
+	if i := strings.IndexAny(rest, "{}"); i >= 0 {
+		return fmt.Errorf("%q: unknown placeholder near %q (known: %s)", tmpl, rest[i:], strings.Join(imagePlaceholders, ", "))
+	}
+	return nil
+}
+
+// Images replaces the values of image URL columns with placeholder URLs
+// that load: columns named like an image (see imageColumn) and any column
+// a template names, keyed "table.column" or "column"; a "*" template
+// replaces the default of every image-named column. Avatars of people go
+// to DiceBear and other images to Lorem Picsum unless configured. The seed
+// comes from the row's slug, username or name, so an entity keeps its
+// picture; values missing are filled in and NULLs in nullable columns stay.
+func Images(t *schema.Table, rows []map[string]interface{}, templates map[string]string, r *rand.Rand) Report {
+	rep := Report{Table: t.Name}
+	for _, col := range t.Columns {
+		tmpl, ok := imageTemplate(templates, t, col)
+		if !ok || tmpl == KeepImages {
+			continue
+		}
+		w, h := imageSize(col.Name)
+		for i, row := range rows {
+			v, ok := row[col.Name]
+			if ok && v == nil && !col.NotNull {
+				continue
+			}
+			to := strings.NewReplacer(
+				"{seed}", url.QueryEscape(imageSeed(row, r)),
+				"{width}", fmt.Sprint(w), "{height}", fmt.Sprint(h),
+				"{table}", url.PathEscape(t.Name), "{column}", url.PathEscape(col.Name),
+				"{row}", fmt.Sprint(i+1),
+			).Replace(tmpl)
+			if n := col.MaxLength(); n > 0 && len(to) > n {
+				continue // left for the validator rather than cut into a dead link
+			}
+			row[col.Name] = to
+			rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindImage, From: v, To: to})
+		}
+	}
+	return rep
+}
+
+// ImageColumns returns the columns of t that Images fills.
+func ImageColumns(t *schema.Table, templates map[string]string) []string {
+	var out []string
+	for _, col := range t.Columns {
+		if tmpl, ok := imageTemplate(templates, t, col); ok && tmpl != KeepImages {
+			out = append(out, col.Name)
+		}
+	}
+	return out
+}
+
+func imageTemplate(templates map[string]string, t *schema.Table, col schema.Column) (string, bool) {
+	if col.Type != "text" || col.ForeignKey != nil {
+		return "", false
+	}
+	if tmpl, ok := templates[t.Name+"."+col.Name]; ok {
+		return tmpl, true
+	}
+	if tmpl, ok := templates[col.Name]; ok {
+		return tmpl, true
+	}
+	avatar, ok := imageColumn(t, col.Name)
+	if tmpl, all := templates["*"]; all && ok {
+		return tmpl, true
+	}
+	if avatar {
+		return AvatarTemplate, ok
+	}
+	return PhotoTemplate, ok
+}
+
+var (
+	imageWords  = map[string]bool{"image": true, "img": true, "photo": true, "picture": true, "pic": true, "thumbnail": true, "thumb": true, "cover": true, "banner": true, "logo": true, "poster": true, "avatar": true, "headshot": true}
+	avatarWords = map[string]bool{"avatar": true, "headshot": true, "profile": true}
+	personWords = map[string]bool{"photo": true, "picture": true, "pic": true}
+	urlWords    = map[string]bool{"url": true, "uri": true, "src": true, "link": true}
+)
+
+// imageColumn reports whether a column holds an image URL: avatar_url,
+// image, product_image_url, cover_photo_src. Avatar is true for avatars,
+// and for photos in tables of people (with a first_name, full_name or
+// username column).
+func imageColumn(t *schema.Table, name string) (avatar, ok bool) {
+	words := strings.Split(strings.ToLower(name), "_")
+	if len(words) > 1 && urlWords[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if !imageWords[words[len(words)-1]] {
+		return false, false
+	}
+	for _, w := range words {
+		if avatarWords[w] {
+			return true, true
+		}
+	}
+	if personWords[words[len(words)-1]] {
+		for _, c := range []string{"first_name", "full_name", "username"} {
+			if t.Column(c) != nil {
+				return true, true
+			}
+		}
+	}
+	return false, true
+}
+
+// imageSize returns the width and height for a column's placeholder:
+// square for thumbnails, logos and avatars, wide for covers and banners.
+func imageSize(name string) (w, h int) {
+	n := strings.ToLower(name)
+	switch {
+	case strings.Contains(n, "thumb"), strings.Contains(n, "avatar"), strings.Contains(n, "logo"), strings.Contains(n, "headshot"):
+		return 256, 256
+	case strings.Contains(n, "cover"), strings.Contains(n, "banner"):
+		return 1200, 400
+	}
+	return 640, 480
+}
+
+// imageSeed returns what the row is known by, or a word drawn from r.
+func imageSeed(row map[string]interface{}, r *rand.Rand) string {
+	for _, k := range []string{"slug", "username", "name", "title", "full_name", "email"} {
+		if v, ok := row[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	first, _ := row["first_name"].(string)
+	last, _ := row["last_name"].(string)
+	if s := strings.TrimSpace(first + " " + last); s != "" {
+		return s
+	}
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = "abcdefghijklmnopqrstuvwxyz0123456789"[r.IntN(36)]
+	}
+	return string(b)
+}
@@ -0,0 +1,182 @@
+package repair
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindLength marks a text cut down to its column's length target.
+const KindLength Kind = "length"
+
+// Length units.
+const (
+	Chars      = "characters"
+	Words      = "words"
+	Sentences  = "sentences"
+	Paragraphs = "paragraphs"
+)
+
+// Length is a target length for a text column, e.g. 1-2 sentences or at
+// most 140 characters. Max 0 means no upper bound.
+type Length struct {
+	Min, Max int
+	Unit     string // Chars, Words, Sentences or Paragraphs
+}
+
+var lengthRe = regexp.MustCompile(`^(?:(under|up to|at most|at least|<=|<|>=)\s*)?(\d+)(?:\s*(?:-|to)\s*(\d+))?\s*([a-z]+)$`)
+
+// ParseLength reads a length target: "N-M unit", "N unit", "at most N
+// unit" (or "under", "up to", "<="), or "at least N unit", where the unit
+// is chars, words, sentences or paragraphs.
+func ParseLength(s string) (Length, error) {
+	m := lengthRe.FindStringSubmatch(strings.ToLower(strings.Join(strings.Fields(s), " ")))
+	if m == nil {
+		return Length{}, fmt.Errorf("%q is not a length, e.g. 1-2 sentences or at most 140 chars", s)
+	}
+	var l Length
+	switch m[4] {
+	case "char", "chars", "character", "characters":
+		l.Unit = Chars
+	case "word", "words":
+		l.Unit = Words
+	case "sentence", "sentences":
+		l.Unit = Sentences
+	case "paragraph", "paragraphs":
+		l.Unit = Paragraphs
+	default:
+		return Length{}, fmt.Errorf("%q: unit must be chars, words, sentences or paragraphs", s)
+	}
+	n, _ := strconv.Atoi(m[2])
+	switch {
+	case m[3] != "":
+		if m[1] != "" {
+			return Length{}, fmt.Errorf("%q is not a length, e.g. 1-2 sentences or at most 140 chars", s)
+		}
+		hi, _ := strconv.Atoi(m[3])
+		l.Min, l.Max = n, hi
+	case m[1] == "at least" || m[1] == ">=":
+		l.Min = n
+	case m[1] != "":
+		l.Max = n
+	default:
+		l.Min, l.Max = n, n
+	}
+	if l.Max != 0 && l.Min > l.Max || l.Min == 0 && l.Max == 0 {
+		return Length{}, fmt.Errorf("%q is an empty range", s)
+	}
+	return l, nil
+}
+
+// String formats l for the prompt.
+func (l Length) String() string {
+	switch {
+	case l.Max == 0:
+		return fmt.Sprintf("at least %d %s", l.Min, l.Unit)
+	case l.Min == 0:
+		return fmt.Sprintf("at most %d %s", l.Max, l.Unit)
+	case l.Min == l.Max:
+		return fmt.Sprintf("%d %s", l.Min, l.Unit)
+	}
+	return fmt.Sprintf("%d-%d %s", l.Min, l.Max, l.Unit)
+}
+
+// LookupLength finds the length target of a column, keyed "table.column"
+// or "column".
+func LookupLength(lengths map[string]Length, table, column string) (Length, bool) {
+	if l, ok := lengths[table+"."+column]; ok {
+		return l, true
+	}
+	l, ok := lengths[column]
+	return l, ok
+}
+
+// Lengths cuts text values longer than their column's target (see
+// LookupLength) back to it at a word, sentence or paragraph boundary.
+// Texts that fall short are left as the model wrote them.
+func Lengths(t *schema.Table, rows []map[string]interface{}, lengths map[string]Length) Report {
+	rep := Report{Table: t.Name}
+	if len(lengths) == 0 {
+		return rep
+	}
+	for _, col := range t.Columns {
+		l, ok := LookupLength(lengths, t.Name, col.Name)
+		if !ok || l.Max == 0 {
+			continue
+		}
+		for i, row := range rows {
+			s, ok := row[col.Name].(string)
+			if !ok {
+				continue
+			}
+			to := trimLength(s, l)
+			if to == s {
+				continue
+			}
+			row[col.Name] = to
+			rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindLength, From: s, To: to})
+		}
+	}
+	return rep
+}
+
+var (
+	sentenceRe  = regexp.MustCompile(`[^.!?]*[.!?]+["')\]]*(\s+|$)`)
+	paragraphRe = regexp.MustCompile(`\n\s*\n`)
+)
+
+// trimLength returns s cut to at most l.Max units.
+func trimLength(s string, l Length) string {
+	switch l.Unit {
+	case Chars:
+		if utf8.RuneCountInString(s) <= l.Max {
+			return s
+		}
+		cut := string([]rune(s)[:l.Max])
+		if i := strings.LastIndexAny(cut, " \n\t"); i > len(cut)/2 && !isSpaceAt(s, len(cut)) {
+			cut = cut[:i]
+		}
+		return strings.TrimRight(cut, " \n\t,;:-")
+	case Words:
+		// Punctuation-only fields, such as a list bullet, are kept but do
+		// not count as words.
+		words := strings.Fields(s)
+		keep, n := 0, 0
+		for keep < len(words) && n < l.Max {
+			if strings.IndexFunc(words[keep], func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				n++
+			}
+			keep++
+		}
+		if keep == len(words) {
+			return s
+		}
+		out := strings.TrimRight(strings.Join(words[:keep], " "), ",;:-")
+		if end := strings.TrimSpace(s); strings.ContainsAny(end[len(end)-1:], ".!?") && !strings.ContainsAny(out[len(out)-1:], ".!?") {
+			out += "."
+		}
+		return out
+	case Sentences:
+		locs := sentenceRe.FindAllStringIndex(s, -1)
+		if len(locs) <= l.Max {
+			return s
+		}
+		return strings.TrimSpace(s[:locs[l.Max-1][1]])
+	case Paragraphs:
+		locs := paragraphRe.FindAllStringIndex(strings.TrimSpace(s), -1)
+		if len(locs) < l.Max {
+			return s
+		}
+		return strings.TrimSpace(s)[:locs[l.Max-1][0]]
+	}
+	return s
+}
+
+func isSpaceAt(s string, i int) bool {
+	return i < len(s) && (s[i] == ' ' || s[i] == '\n' || s[i] == '\t')
+}
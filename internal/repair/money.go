@@ -0,0 +1,114 @@
+package repair
+
+import (
+	"math"
+
+	"github.com/satyammistari/db-seed-ai/internal/money"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Kinds reported by Money.
+const (
+	KindCurrency    Kind = "currency"     // currency normalized to an allowed ISO 4217 code
+	KindMinorUnits  Kind = "minor-units"  // amount rounded to the currency's decimals
+	KindCurrencyFit Kind = "currency-fit" // amount converted along with a replaced currency
+)
+
+// Money keeps amount+currency column pairs consistent (see money.Pairs).
+// Currency values become ISO 4217 codes; symbols and names are mapped and
+// anything unknown, or outside allowed when it is non-empty, is replaced
+// by an allowed code in rotation, with the row's amounts converted at a
+// rough rate. Amounts are then rounded to the currency's minor units.
+// A CHECK IN list on the currency column is used when allowed is empty.
+func Money(t *schema.Table, rows []map[string]interface{}, allowed []string) Report {
+	rep := Report{Table: t.Name}
+	for _, p := range money.Pairs(t) {
+		codes := allowed
+		if col := t.Column(p.Currency); len(codes) == 0 && col != nil {
+			codes = col.CheckIn
+		}
+		for i, row := range rows {
+			s, ok := row[p.Currency].(string)
+			if !ok {
+				continue
+			}
+			code, known := money.Normalize(s)
+			if known && len(codes) > 0 && !contains(codes, code) {
+				to := pick(codes, i)
+				from, _ := money.Lookup(code)
+				if c, ok := money.Lookup(to); ok {
+					for _, a := range p.Amounts {
+						convert(row, a, from, c, i, &rep)
+					}
+				}
+				code = to
+			} else if !known {
+				code = "USD"
+				if len(codes) > 0 {
+					code = pick(codes, i)
+				}
+			}
+			if code != s {
+				row[p.Currency] = code
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: p.Currency, Kind: KindCurrency, From: s, To: code})
+			}
+			c, ok := money.Lookup(code)
+			if !ok {
+				continue
+			}
+			for _, a := range p.Amounts {
+				f, ok := row[a].(float64)
+				if !ok {
+					continue
+				}
+				r := money.Round(f, c)
+				if money.MinorAmount(a) {
+					r = math.Round(f)
+				}
+				if r != f {
+					row[a] = r
+					rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: a, Kind: KindMinorUnits, From: f, To: r})
+				}
+			}
+		}
+	}
+	return rep
+}
+
+// convert changes one amount from one currency to another, keeping
+// minor-unit columns in minor units.
+func convert(row map[string]interface{}, col string, from, to money.Currency, i int, rep *Report) {
+	f, ok := row[col].(float64)
+	if !ok {
+		return
+	}
+	var v float64
+	if money.MinorAmount(col) {
+		major := f / math.Pow(10, float64(from.MinorUnits))
+		v = math.Round(money.Convert(major, from, to) * math.Pow(10, float64(to.MinorUnits)))
+	} else {
+		v = money.Convert(f, from, to)
+	}
+	if v != f {
+		row[col] = v
+		rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col, Kind: KindCurrencyFit, From: f, To: v})
+	}
+}
+
+// pick returns the allowed code for row i, normalizing it.
+func pick(codes []string, i int) string {
+	code, _ := money.Normalize(codes[i%len(codes)])
+	if code == "" {
+		return codes[i%len(codes)]
+	}
+	return code
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if n, _ := money.Normalize(v); v == s || n == s {
+			return true
+		}
+	}
+	return false
+}
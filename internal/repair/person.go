@@ -0,0 +1,161 @@
+package repair
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/satyammistari/db-seed-ai/internal/person"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindPerson marks a gender, salutation or pronoun changed to agree with
+// the rest of the row.
+const KindPerson Kind = "person"
+
+// personColumns are the columns of t that describe one person.
+type personColumns struct {
+	first, gender, salutation, pronouns string
+}
+
+// Persons makes the gender, salutation and pronoun columns of each row
+// agree with its first name (see package person): "Mr" for Maria becomes
+// "Ms". A name the table does not know leaves the gender column to decide,
+// then the salutation, then the pronouns. A non-binary gender is kept
+// whatever the name. Replacements follow the column's CHECK list, or the
+// model's own style (M/F, a trailing dot, capitals). Titles like Dr stay.
+func Persons(t *schema.Table, rows []map[string]interface{}) Report {
+	rep := Report{Table: t.Name}
+	pc, ok := findPersonColumns(t)
+	if !ok {
+		return rep
+	}
+	for i, row := range rows {
+		gender := person.Parse(asText(row[pc.gender]))
+		g := gender
+		if g != person.NonBinary {
+			if n := person.FromName(asText(row[pc.first])); n != person.Unknown {
+				g = n
+			}
+		}
+		if g == person.Unknown {
+			g = person.FromSalutation(asText(row[pc.salutation]))
+		}
+		if g == person.Unknown {
+			g = person.FromPronouns(asText(row[pc.pronouns]))
+		}
+		if g == person.Unknown {
+			continue
+		}
+		fix := func(col string, from person.Gender, to string) {
+			v := row[col]
+			if col == "" || v == nil || from == g || to == "" || to == v {
+				return
+			}
+			row[col] = to
+			rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col, Kind: KindPerson, From: v, To: to})
+		}
+		if pc.gender != "" {
+			fix(pc.gender, gender, genderValue(t.Column(pc.gender), asText(row[pc.gender]), g))
+		}
+		if pc.salutation != "" {
+			v := asText(row[pc.salutation])
+			if from := person.FromSalutation(v); from != person.Unknown {
+				fix(pc.salutation, from, personValue(t.Column(pc.salutation), v, person.Salutation(g), g, person.FromSalutation))
+			}
+		}
+		if pc.pronouns != "" {
+			v := asText(row[pc.pronouns])
+			fix(pc.pronouns, person.FromPronouns(v), personValue(t.Column(pc.pronouns), v, person.Pronouns(g), g, person.FromPronouns))
+		}
+	}
+	return rep
+}
+
+// findPersonColumns finds a first name (or full name) column and at least
+// one of gender, salutation and pronouns. A title column counts as a
+// salutation only next to a name, and only its salutation values change.
+func findPersonColumns(t *schema.Table) (personColumns, bool) {
+	var pc personColumns
+	full := ""
+	for _, col := range t.Columns {
+		if col.Type != "text" || col.ForeignKey != nil {
+			continue
+		}
+		switch strings.ToLower(col.Name) {
+		case "first_name", "firstname", "given_name", "forename":
+			pc.first = col.Name
+		case "full_name", "name", "display_name":
+			full = col.Name
+		case "gender", "sex":
+			pc.gender = col.Name
+		case "salutation", "honorific", "name_prefix", "title":
+			pc.salutation = col.Name
+		case "pronouns", "pronoun":
+			pc.pronouns = col.Name
+		}
+	}
+	if pc.first == "" {
+		pc.first = full
+	}
+	if pc.first == "" {
+		return pc, false
+	}
+	return pc, pc.gender != "" || pc.salutation != "" || pc.pronouns != ""
+}
+
+// genderValue writes g the way the gender column does: an entry of its
+// CHECK list, else one letter or a word cased like the model's value.
+func genderValue(col *schema.Column, was string, g person.Gender) string {
+	if len(col.CheckIn) > 0 {
+		return checkEntry(col.CheckIn, g, person.Parse)
+	}
+	out := string(g)
+	if len([]rune(strings.TrimSpace(was))) == 1 {
+		out = map[person.Gender]string{person.Male: "m", person.Female: "f", person.NonBinary: "x"}[g]
+	}
+	return likeCase(out, was)
+}
+
+// personValue writes a salutation or pronouns the way the column does: an
+// entry of its CHECK list, else want with the model's trailing dot and
+// capitals.
+func personValue(col *schema.Column, was, want string, g person.Gender, parse func(string) person.Gender) string {
+	if len(col.CheckIn) > 0 {
+		for _, c := range col.CheckIn {
+			if strings.EqualFold(strings.TrimSuffix(c, "."), want) {
+				return c
+			}
+		}
+		return checkEntry(col.CheckIn, g, parse)
+	}
+	if strings.HasSuffix(strings.TrimSpace(was), ".") {
+		want += "."
+	}
+	return likeCase(want, was)
+}
+
+// checkEntry returns the first CHECK entry that parses as g, or "".
+func checkEntry(list []string, g person.Gender, parse func(string) person.Gender) string {
+	for _, c := range list {
+		if parse(c) == g {
+			return c
+		}
+	}
+	return ""
+}
+
+// likeCase cases s like was: all capitals, a capital first letter, or as
+// is.
+func likeCase(s, was string) string {
+	was = strings.TrimSpace(was)
+	r := []rune(was)
+	switch {
+	case len(r) == 0:
+		return s
+	case len(r) > 1 && strings.ToUpper(was) == was && strings.ToLower(was) != was, len(r) == 1 && unicode.IsUpper(r[0]):
+		return strings.ToUpper(s)
+	case unicode.IsUpper(r[0]):
+		return strings.ToUpper(s[:1]) + s[1:]
+	}
+	return s
+}
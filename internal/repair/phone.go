@@ -0,0 +1,56 @@
+package repair
+
+import (
+	"fmt"
+
+	"github.com/satyammistari/db-seed-ai/internal/phone"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindPhone marks a phone number rewritten into the column's format.
+const KindPhone Kind = "phone"
+
+// Phones formats phone columns by rules keyed "table.column", "column", or
+// "*" for every column named like a phone (see isPhoneColumn). Values that
+// are not phone numbers at all are left for review.
+func Phones(t *schema.Table, rows []map[string]interface{}, rules map[string]phone.Rule) Report {
+	rep := Report{Table: t.Name}
+	if len(rules) == 0 {
+		return rep
+	}
+	for _, col := range t.Columns {
+		rule, ok := phoneRule(rules, t.Name, col)
+		if !ok {
+			continue
+		}
+		c, ok := phone.Lookup(rule.Country)
+		if !ok {
+			continue // checked when the config is loaded
+		}
+		for i, row := range rows {
+			v, ok := row[col.Name]
+			if !ok || v == nil {
+				continue
+			}
+			from := fmt.Sprint(v)
+			to, err := phone.Format(from, c, rule.Format, i)
+			if err != nil || to == from {
+				continue
+			}
+			row[col.Name] = to
+			rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindPhone, From: v, To: to})
+		}
+	}
+	return rep
+}
+
+func phoneRule(rules map[string]phone.Rule, table string, col schema.Column) (phone.Rule, bool) {
+	if r, ok := rules[table+"."+col.Name]; ok {
+		return r, true
+	}
+	if r, ok := rules[col.Name]; ok {
+		return r, true
+	}
+	r, ok := rules["*"]
+	return r, ok && col.Type == "text" && isPhoneColumn(col.Name)
+}
@@ -0,0 +1,144 @@
+package repair
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Kinds reported by PII.
+const (
+	KindPIIEmail Kind = "pii-email" // email domain moved to example.com
+	KindPIIPhone Kind = "pii-phone" // phone number moved into 555-01xx
+	KindPIIID    Kind = "pii-id"    // SSN-shaped value given the never-issued area 000
+	KindPIICard  Kind = "pii-card"  // card number replaced with a test card
+)
+
+var (
+	emailRe = regexp.MustCompile(`([A-Za-z0-9._%+-]+)@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	ssnRe   = regexp.MustCompile(`\b\d{3}-\d{2}-(\d{4})\b`)
+	cardRe  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// testCard is a well-known test number that no issuer hands out.
+const testCard = "4242424242424242"
+
+// PII rewrites values that could be real personal data: email domains
+// other than the reserved example ones, phone numbers outside the
+// fictional 555-01xx range (in columns named like phone, mobile, tel or
+// fax), SSN-shaped identifiers and card numbers that pass the Luhn check.
+// Names cannot be checked this way; the prompt asks for fictional ones.
+func PII(t *schema.Table, rows []map[string]interface{}) Report {
+	rep := Report{Table: t.Name}
+	for i, row := range rows {
+		for _, col := range t.Columns {
+			s, ok := row[col.Name].(string)
+			if !ok || s == "" {
+				continue
+			}
+			to, kind := scrub(col.Name, s, i)
+			if to != s {
+				row[col.Name] = to
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: kind, From: s, To: to})
+			}
+		}
+	}
+	return rep
+}
+
+// scrub returns s with personal data replaced and the kind of the last
+// replacement made.
+func scrub(column, s string, row int) (string, Kind) {
+	var kind Kind
+	out := emailRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := emailRe.FindStringSubmatch(m)
+		if reservedDomain(parts[2]) {
+			return m
+		}
+		kind = KindPIIEmail
+		return parts[1] + "@example.com"
+	})
+	out = ssnRe.ReplaceAllStringFunc(out, func(m string) string {
+		if strings.HasPrefix(m, "000-") {
+			return m
+		}
+		kind = KindPIIID
+		return "000" + m[3:]
+	})
+	out = cardRe.ReplaceAllStringFunc(out, func(m string) string {
+		digits := onlyDigits(m)
+		if digits == testCard || !luhn(digits) {
+			return m
+		}
+		kind = KindPIICard
+		return testCard
+	})
+	if isPhoneColumn(column) && !fictionalPhone(out) && len(onlyDigits(out)) >= 7 {
+		kind = KindPIIPhone
+		out = fmt.Sprintf("+1-202-555-01%02d", row%100)
+	}
+	return out, kind
+}
+
+// reservedDomain reports whether an email domain is set aside for
+// documentation (RFC 2606) and so can never reach a real inbox.
+func reservedDomain(domain string) bool {
+	d := strings.ToLower(domain)
+	switch d {
+	case "example.com", "example.org", "example.net":
+		return true
+	}
+	for _, tld := range []string{".example", ".test", ".invalid", ".localhost"} {
+		if strings.HasSuffix(d, tld) {
+			return true
+		}
+	}
+	return strings.HasSuffix(d, ".example.com") || strings.HasSuffix(d, ".example.org") || strings.HasSuffix(d, ".example.net")
+}
+
+func isPhoneColumn(name string) bool {
+	n := strings.ToLower(name)
+	for _, w := range []string{"phone", "mobile", "tel", "fax"} {
+		if strings.Contains(n, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// fictionalPhone reports whether the number's last seven digits are in
+// 555-0100..555-0199, the range reserved for fiction in North America.
+func fictionalPhone(s string) bool {
+	d := onlyDigits(s)
+	return len(d) >= 7 && strings.HasPrefix(d[len(d)-7:], "55501")
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhn reports whether digits pass the card number checksum.
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return len(digits) > 0 && sum%10 == 0
+}
@@ -0,0 +1,151 @@
+package repair
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Kind names a category of repair.
+type Kind string
+
+const (
+	KindDropColumn Kind = "drop-column" // key not in the schema was removed
+	KindEnumMap    Kind = "enum-map"    // near-miss CHECK IN value was mapped
+	KindDerived    Kind = "derived"     // value recomputed from a derived-column rule
+)
+
+// Action is a single change made to a generated row.
+type Action struct {
+	Row    int // 1-based, matching validator output
+	Column string
+	Kind   Kind
+	From   interface{}
+	To     interface{}
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case KindDropColumn:
+		return fmt.Sprintf("row %d: dropped unknown column %q", a.Row, a.Column)
+	default:
+		return fmt.Sprintf("row %d: %s %v → %v (%s)", a.Row, a.Column, a.From, a.To, a.Kind)
+	}
+}
+
+// Report collects the repairs applied to one table.
+type Report struct {
+	Table   string
+	Actions []Action
+}
+
+// Counts returns the number of actions per kind.
+func (r Report) Counts() map[Kind]int {
+	out := make(map[Kind]int)
+	for _, a := range r.Actions {
+		out[a.Kind]++
+	}
+	return out
+}
+
+// Summary formats the counts on one line, e.g.
+// "drop-column ×3, enum-map ×12". Empty when nothing was repaired.
+func (r Report) Summary() string {
+	counts := r.Counts()
+	kinds := make([]string, 0, len(counts))
+	for k := range counts {
+		kinds = append(kinds, string(k))
+	}
+	sort.Strings(kinds)
+	var parts []string
+	for _, k := range kinds {
+		parts = append(parts, fmt.Sprintf("%s ×%d", k, counts[Kind(k)]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Rows cleans generated rows in place: keys the model invented that are not
+// columns of t are removed, and CHECK IN values that are near misses
+// ("Pending", "shippd") are mapped to the allowed value.
+func Rows(t *schema.Table, rows []map[string]interface{}) Report {
+	rep := Report{Table: t.Name}
+	known := make(map[string]*schema.Column, len(t.Columns))
+	for i := range t.Columns {
+		known[t.Columns[i].Name] = &t.Columns[i]
+	}
+	for i, row := range rows {
+		for key, v := range row {
+			col, ok := known[key]
+			if !ok {
+				delete(row, key)
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: key, Kind: KindDropColumn, From: v})
+				continue
+			}
+			if len(col.CheckIn) == 0 || v == nil {
+				continue
+			}
+			s := fmt.Sprint(v)
+			if to, ok := MatchEnum(s, col.CheckIn); ok && to != s {
+				row[key] = to
+				rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: key, Kind: KindEnumMap, From: s, To: to})
+			}
+		}
+	}
+	return rep
+}
+
+// MatchEnum returns the allowed value closest to s. Exact and
+// case-insensitive matches win; otherwise the value with the smallest edit
+// distance is used if it is within a third of its length (at least 1).
+func MatchEnum(s string, allowed []string) (string, bool) {
+	for _, a := range allowed {
+		if a == s {
+			return a, true
+		}
+	}
+	norm := strings.ToLower(strings.TrimSpace(s))
+	for _, a := range allowed {
+		if strings.ToLower(a) == norm {
+			return a, true
+		}
+	}
+	best, bestDist := "", -1
+	for _, a := range allowed {
+		d := levenshtein(norm, strings.ToLower(a))
+		if bestDist == -1 || d < bestDist {
+			best, bestDist = a, d
+		}
+	}
+	limit := len(best) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if bestDist >= 0 && bestDist <= limit {
+		return best, true
+	}
+	return "", false
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
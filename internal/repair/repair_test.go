@@ -0,0 +1,480 @@
+package repair
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/address"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/token"
+)
+
+func TestRows(t *testing.T) {
+	tbl := &schema.Table{Name: "orders", Columns: []schema.Column{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "status", Type: "text", CheckIn: []string{"pending", "paid", "shipped"}},
+	}}
+	rows := []map[string]interface{}{
+		{"status": "Pending"},
+		{"status": "shippd", "tracking_no": "X1"},
+		{"status": "cancelled"},
+	}
+	rep := Rows(tbl, rows)
+
+	if rows[0]["status"] != "pending" {
+		t.Errorf("row 1: expected pending, got %v", rows[0]["status"])
+	}
+	if rows[1]["status"] != "shipped" {
+		t.Errorf("row 2: expected shipped, got %v", rows[1]["status"])
+	}
+	if _, ok := rows[1]["tracking_no"]; ok {
+		t.Errorf("row 2: hallucinated column tracking_no should be dropped")
+	}
+	if rows[2]["status"] != "cancelled" {
+		t.Errorf("row 3: unrelated value should be left for the validator, got %v", rows[2]["status"])
+	}
+	counts := rep.Counts()
+	if counts[KindEnumMap] != 2 || counts[KindDropColumn] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
+
+func TestPII(t *testing.T) {
+	tbl := &schema.Table{Name: "people", Columns: []schema.Column{
+		{Name: "email"}, {Name: "phone"}, {Name: "ssn"}, {Name: "card"}, {Name: "note"},
+	}}
+	rows := []map[string]interface{}{
+		{"email": "sarah.m@gmail.com", "phone": "(415) 867-5309", "ssn": "123-45-6789", "card": "4111 1111 1111 1111", "note": "call 415-867-5309"},
+		{"email": "ada@example.org", "phone": "+1-202-555-0142", "ssn": "000-12-3456", "card": "1234 5678 9012 3456"},
+	}
+	rep := PII(tbl, rows)
+
+	want := map[string]interface{}{
+		"email": "sarah.m@example.com",
+		"phone": "+1-202-555-0100",
+		"ssn":   "000-45-6789",
+		"card":  "4242424242424242",
+		"note":  "call 415-867-5309", // not a phone column
+	}
+	for k, v := range want {
+		if rows[0][k] != v {
+			t.Errorf("%s: got %v, want %v", k, rows[0][k], v)
+		}
+	}
+	if len(rep.Actions) != 4 {
+		t.Errorf("expected 4 rewrites in row 1 only, got %v", rep.Actions)
+	}
+	if rows[1]["card"] != "1234 5678 9012 3456" {
+		t.Errorf("non-Luhn number should be left alone, got %v", rows[1]["card"])
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tbl := &schema.Table{Name: "payments", Columns: []schema.Column{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "amount", Type: "decimal"},
+		{Name: "fee_cents", Type: "integer"},
+		{Name: "currency", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"amount": 12.345, "fee_cents": float64(30), "currency": "usd"},
+		{"amount": 1999.5, "fee_cents": float64(40), "currency": "¥"},
+		{"amount": 10.0, "fee_cents": float64(100), "currency": "GBP"},
+	}
+	Money(tbl, rows, nil)
+	if rows[0]["currency"] != "USD" || rows[0]["amount"] != 12.35 {
+		t.Errorf("row 1: %v", rows[0])
+	}
+	if rows[1]["currency"] != "JPY" || rows[1]["amount"] != float64(2000) {
+		t.Errorf("row 2: yen has no minor units, got %v", rows[1])
+	}
+
+	Money(tbl, rows, []string{"EUR"})
+	if rows[2]["currency"] != "EUR" || rows[2]["amount"] != 11.65 || rows[2]["fee_cents"] != float64(116) {
+		t.Errorf("row 3: GBP should be converted to EUR, got %v", rows[2])
+	}
+}
+
+func TestEmails(t *testing.T) {
+	tbl := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "first_name", Type: "text"}, {Name: "last_name", Type: "text"}, {Name: "email", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"first_name": "Ada", "last_name": "Lovelace", "email": "Ada.Lovelace@Gmail.com"},
+		{"first_name": "Ada", "last_name": "Lovelace", "email": "ada.lovelace@gmail.com"},
+		{"first_name": "Grace", "last_name": "Hopper", "email": "not an email"},
+		{"first_name": "Alan", "last_name": "Turing", "email": "@@"},
+	}
+	Emails(tbl, rows, nil)
+	for i, want := range []string{"ada.lovelace@gmail.com", "ada.lovelace2@gmail.com", "grace.hopper@example.com", "alan.turing@example.com"} {
+		if rows[i]["email"] != want {
+			t.Errorf("row %d: got %v, want %s", i+1, rows[i]["email"], want)
+		}
+	}
+
+	Emails(tbl, rows, []string{"example.test"})
+	if rows[1]["email"] != "ada.lovelace2@example.test" {
+		t.Errorf("domain not applied: %v", rows[1]["email"])
+	}
+}
+
+func TestHandles(t *testing.T) {
+	tbl := &schema.Table{Name: "products", Columns: []schema.Column{
+		{Name: "name", Type: "text"},
+		{Name: "slug", Type: "text", Unique: true, SQLType: "varchar(12)"},
+		{Name: "sku", Type: "text", Unique: true},
+		{Name: "note", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"name": "Wireless Mouse", "slug": "mouse", "sku": "SKU-1"},
+		{"name": "Wireless Mouse", "slug": "mouse", "sku": "SKU-1"},
+		{"name": "Desk Lamp", "slug": nil, "sku": "x"},
+	}
+	used := make(map[string]map[string]bool)
+	rep := Handles(tbl, rows, used)
+	for i, want := range []interface{}{"wireless-mou", "wireless-m-2", nil} {
+		if rows[i]["slug"] != want {
+			t.Errorf("row %d slug: got %v, want %v", i+1, rows[i]["slug"], want)
+		}
+	}
+	sku := rows[0]["sku"].(string)
+	if !strings.HasPrefix(sku, "WMO-") || rows[1]["sku"] != sku+"-2" || !strings.HasPrefix(rows[2]["sku"].(string), "DLA-") {
+		t.Errorf("unexpected SKUs %v, %v, %v", rows[0]["sku"], rows[1]["sku"], rows[2]["sku"])
+	}
+	if len(rep.Actions) != 5 {
+		t.Errorf("expected 5 repairs (a NULL slug stays NULL), got %d", len(rep.Actions))
+	}
+
+	// A later call keeps clear of the handles already given out.
+	users := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "first_name", Type: "text"}, {Name: "last_name", Type: "text"},
+		{Name: "username", Type: "text", Unique: true, NotNull: true},
+	}}
+	used = make(map[string]map[string]bool)
+	Handles(users, []map[string]interface{}{{"first_name": "Ada", "last_name": "Lovelace", "username": "ada"}}, used)
+	more := []map[string]interface{}{{"first_name": "Ada", "last_name": "Lovelace", "username": nil}}
+	Handles(users, more, used)
+	if more[0]["username"] != "ada_lovelace2" {
+		t.Errorf("got username %v, want ada_lovelace2", more[0]["username"])
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tbl := &schema.Table{Name: "api_keys", Columns: []schema.Column{
+		{Name: "user_id", Type: "integer"},
+		{Name: "api_key", Type: "text", NotNull: true},
+		{Name: "access_token", Type: "text"},
+		{Name: "label", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"user_id": 7, "api_key": "my-key", "access_token": "abc", "label": "CI"},
+		{"user_id": 8, "access_token": nil, "label": "prod"},
+	}
+	rules := map[string]token.Rule{"api_keys.label": {Format: token.Random, Prefix: "lbl_", Length: 4}}
+	rep := Tokens(tbl, rows, rules)
+	for i, row := range rows {
+		if key, _ := row["api_key"].(string); !strings.HasPrefix(key, "sk_test_") || len(key) != 8+token.DefaultLength {
+			t.Errorf("row %d: unexpected api_key %v", i+1, row["api_key"])
+		}
+		if label, _ := row["label"].(string); !strings.HasPrefix(label, "lbl_") || len(label) != 8 {
+			t.Errorf("row %d: label rule not applied: %v", i+1, row["label"])
+		}
+	}
+	claims, ok := token.Verify(rows[0]["access_token"].(string), "")
+	if !ok || claims["sub"] != "7" {
+		t.Errorf("access_token: got claims %v, verified %v", claims, ok)
+	}
+	if rows[1]["access_token"] != nil {
+		t.Errorf("a NULL token in a nullable column should stay NULL, got %v", rows[1]["access_token"])
+	}
+	if len(rep.Actions) != 5 {
+		t.Errorf("expected 5 repairs, got %d", len(rep.Actions))
+	}
+	if cols := TokenColumns(tbl, map[string]token.Rule{"*": {Format: token.Keep}}); len(cols) != 0 {
+		t.Errorf("a keep rule for * should leave all columns, got %v", cols)
+	}
+}
+
+func TestTokensFitVarchar(t *testing.T) {
+	tbl := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "id", Type: "integer"},
+		{Name: "api_key", Type: "text", SQLType: "varchar(32)"},
+		{Name: "reset_token", Type: "text", SQLType: "varchar(32)"},
+		{Name: "access_token", Type: "text", SQLType: "varchar(64)"},
+	}}
+	rows := []map[string]interface{}{{"id": 1}, {"id": 2}}
+	Tokens(tbl, rows, nil)
+	for i, row := range rows {
+		for _, col := range tbl.Columns[1:] {
+			v, _ := row[col.Name].(string)
+			if v == "" || len(v) > col.MaxLength() {
+				t.Errorf("row %d: %s = %q does not fit %s", i+1, col.Name, v, col.SQLType)
+			}
+		}
+		if key, _ := row["api_key"].(string); !strings.HasPrefix(key, "sk_test_") || len(key) != 32 {
+			t.Errorf("row %d: api_key %v should keep its prefix and fill the column", i+1, row["api_key"])
+		}
+		if _, ok := token.Verify(row["access_token"].(string), ""); ok {
+			t.Errorf("row %d: a JWT cannot fit varchar(64), got %v", i+1, row["access_token"])
+		}
+	}
+
+	if err := CheckTokens(tbl, nil); err != nil {
+		t.Errorf("CheckTokens: %v", err)
+	}
+	long := map[string]token.Rule{"api_key": {Format: token.Random, Prefix: "live_secret_key_for_tenant_"}}
+	if err := CheckTokens(tbl, long); err == nil || !strings.Contains(err.Error(), "users.api_key") {
+		t.Errorf("a prefix that leaves no room should be refused, got %v", err)
+	}
+}
+
+func TestImages(t *testing.T) {
+	tbl := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "username", Type: "text"},
+		{Name: "avatar_url", Type: "text"},
+		{Name: "cover_image", Type: "text"},
+		{Name: "image_alt", Type: "text"},
+		{Name: "logo", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"username": "ada lovelace", "avatar_url": "https://cdn.fake/ada.png", "image_alt": "Ada"},
+		{"username": "grace", "avatar_url": nil, "cover_image": nil},
+	}
+	rep := Images(tbl, rows, map[string]string{"users.logo": "https://img.test/{table}/{row}.png"}, rand.New(rand.NewPCG(1, 2)))
+	if rows[0]["avatar_url"] != "https://api.dicebear.com/9.x/initials/svg?seed=ada+lovelace" {
+		t.Errorf("avatar_url: got %v", rows[0]["avatar_url"])
+	}
+	if rows[1]["avatar_url"] != nil || rows[1]["cover_image"] != nil {
+		t.Errorf("NULLs in nullable columns should stay, got %v", rows[1])
+	}
+	if rows[0]["cover_image"] != "https://picsum.photos/seed/ada+lovelace/1200/400" {
+		t.Errorf("cover_image: got %v", rows[0]["cover_image"])
+	}
+	if rows[0]["image_alt"] != "Ada" {
+		t.Errorf("image_alt is not an image URL, got %v", rows[0]["image_alt"])
+	}
+	if rows[1]["logo"] != "https://img.test/users/2.png" {
+		t.Errorf("logo template not applied: %v", rows[1]["logo"])
+	}
+	if len(rep.Actions) != 4 {
+		t.Errorf("expected 4 repairs, got %d", len(rep.Actions))
+	}
+	if err := CheckImageTemplate("https://x.test/{size}.png"); err == nil {
+		t.Error("expected an error for an unknown placeholder")
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	tbl := &schema.Table{Name: "customers", Columns: []schema.Column{
+		{Name: "street", Type: "text"},
+		{Name: "city", Type: "text"},
+		{Name: "state", Type: "text"},
+		{Name: "zip", Type: "text"},
+		{Name: "country", Type: "text"},
+		{Name: "phone", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"street": "1 Main St", "city": "Austin", "state": "CA", "zip": "10001", "country": "US", "phone": "+1 512 555 0100"},
+		{"street": "2 High St", "city": "Austin", "state": "Texas", "zip": "78701", "country": "United States", "phone": "+44 20 7946 0018"},
+		{"street": "3 Rue X", "city": "Lyon", "state": nil, "country": "France"},
+	}
+	Addresses(tbl, rows, nil, rand.New(rand.NewPCG(1, 2)))
+	if r := rows[0]; r["city"] != "Austin" || r["state"] != "TX" || r["country"] != "US" || !strings.HasPrefix(r["zip"].(string), "787") {
+		t.Errorf("row 1: got %v", r)
+	}
+	if r := rows[1]; r["country"] != "United Kingdom" || r["city"] == "Austin" || r["street"] != "2 High St" {
+		t.Errorf("row 2: country should follow the +44 phone, got %v", r)
+	}
+	if r := rows[2]; r["city"] != "Lyon" || r["state"] != nil || r["country"] != "France" || !strings.HasPrefix(r["zip"].(string), "6900") {
+		t.Errorf("row 3: got %v", r)
+	}
+
+	rows = []map[string]interface{}{{"city": "Tokyo", "country": "Japan", "zip": "x"}}
+	Addresses(tbl, rows, []string{"CA"}, rand.New(rand.NewPCG(1, 2)))
+	if p, ok := address.Find(rows[0]["city"].(string)); !ok || p.Country != "CA" || rows[0]["country"] != "Canada" {
+		t.Errorf("countries limit not applied: %v", rows[0])
+	}
+}
+
+func TestPersons(t *testing.T) {
+	tbl := &schema.Table{Name: "contacts", Columns: []schema.Column{
+		{Name: "salutation", Type: "text"},
+		{Name: "first_name", Type: "text"},
+		{Name: "last_name", Type: "text"},
+		{Name: "gender", Type: "text", CheckIn: []string{"M", "F", "X"}},
+		{Name: "pronouns", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"salutation": "Mr.", "first_name": "Maria", "last_name": "Garcia", "gender": "M", "pronouns": "He/Him"},
+		{"salutation": "Dr.", "first_name": "James", "gender": "F", "pronouns": nil},
+		{"salutation": "Mrs", "first_name": "Sam", "gender": "M", "pronouns": "she/her"},
+		{"salutation": "Ms", "first_name": "Maria", "gender": "X", "pronouns": "she/her"},
+	}
+	rep := Persons(tbl, rows)
+	want := []map[string]interface{}{
+		{"salutation": "Ms.", "gender": "F", "pronouns": "She/her"},
+		{"salutation": "Dr.", "gender": "M", "pronouns": nil},
+		{"salutation": "Mr", "gender": "M", "pronouns": "he/him"},
+		{"salutation": "Mx", "gender": "X", "pronouns": "they/them"},
+	}
+	for i, w := range want {
+		for k, v := range w {
+			if rows[i][k] != v {
+				t.Errorf("row %d %s: got %v, want %v", i+1, k, rows[i][k], v)
+			}
+		}
+	}
+	if len(rep.Actions) != 8 {
+		t.Errorf("expected 8 repairs, got %d", len(rep.Actions))
+	}
+}
+
+func TestTimes(t *testing.T) {
+	tbl := &schema.Table{Name: "comments", Columns: []schema.Column{
+		{Name: "id", Type: "integer", PrimaryKey: true},
+		{Name: "parent_id", Type: "integer", ForeignKey: &schema.ForeignKey{RefTable: "comments", RefColumn: "id"}},
+		{Name: "created_at", Type: "timestamp"},
+		{Name: "updated_at", Type: "timestamp"},
+	}}
+	rows := []map[string]interface{}{
+		{"id": 1, "parent_id": nil, "created_at": "2025-03-01 10:00:00", "updated_at": "2025-02-01 08:00:00"},
+		{"id": 2, "parent_id": 1, "created_at": "2025-02-27 09:00:00", "updated_at": "2025-03-05 12:00:00"},
+		{"id": 3, "parent_id": 2, "created_at": "2025-03-01T10:30:00Z", "updated_at": nil},
+		{"id": 4, "parent_id": 1, "created_at": "2025-03-02 11:00:00", "updated_at": "2025-03-02 11:00:00"},
+	}
+	rep := Times(tbl, rows)
+	want := []map[string]interface{}{
+		{"created_at": "2025-03-01 10:00:00", "updated_at": "2025-03-01 10:00:00"},
+		{"created_at": "2025-03-01 11:00:00", "updated_at": "2025-03-05 12:00:00"},
+		{"created_at": "2025-03-01T12:00:00Z", "updated_at": nil},
+		{"created_at": "2025-03-02 11:00:00", "updated_at": "2025-03-02 11:00:00"},
+	}
+	for i, w := range want {
+		for k, v := range w {
+			if rows[i][k] != v {
+				t.Errorf("row %d %s: got %v, want %v", i+1, k, rows[i][k], v)
+			}
+		}
+	}
+	if n := rep.Counts()[KindTimeOrder]; n != 3 {
+		t.Errorf("expected 3 repairs, got %d: %v", n, rep.Actions)
+	}
+}
+
+func TestSoftDeletes(t *testing.T) {
+	tbl := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "email", Type: "text"},
+		{Name: "created_at", Type: "timestamp"},
+		{Name: "deleted_at", Type: "timestamp"},
+		{Name: "is_deleted", Type: "boolean"},
+		{Name: "deleted_by", Type: "text"},
+	}}
+	var rows []map[string]interface{}
+	for i := 0; i < 20; i++ {
+		// The model deletes every row, some before they were created.
+		rows = append(rows, map[string]interface{}{
+			"created_at": "2025-03-01 10:00:00", "deleted_at": "2025-02-01 10:00:00", "is_deleted": false, "deleted_by": "admin",
+		})
+	}
+	rep := SoftDeletes(tbl, rows, 0.1, time.Now(), rand.New(rand.NewPCG(1, 2)))
+	deleted := 0
+	for i, row := range rows {
+		switch {
+		case row["deleted_at"] == nil:
+			if row["is_deleted"] != false || row["deleted_by"] != nil {
+				t.Errorf("row %d: live but %v", i+1, row)
+			}
+		case row["deleted_at"] == "2025-03-01 10:00:00" && row["is_deleted"] == true && row["deleted_by"] == "admin":
+			deleted++
+		default:
+			t.Errorf("row %d: %v", i+1, row)
+		}
+	}
+	if deleted != 2 || len(rep.Actions) != 18*2+2*2 {
+		t.Errorf("%d rows deleted, %d repairs; want 2 and 40", deleted, len(rep.Actions))
+	}
+}
+
+func TestLengths(t *testing.T) {
+	for src, want := range map[string]Length{
+		"1-2 sentences":     {Min: 1, Max: 2, Unit: Sentences},
+		"2 paragraphs":      {Min: 2, Max: 2, Unit: Paragraphs},
+		"under 140 chars":   {Max: 140, Unit: Chars},
+		"at least 10 words": {Min: 10, Unit: Words},
+	} {
+		if l, err := ParseLength(src); err != nil || l != want {
+			t.Errorf("ParseLength(%q) = %+v, %v; want %+v", src, l, err, want)
+		}
+	}
+	for _, src := range []string{"", "2-1 words", "at most 3-4 words", "3 lines"} {
+		if _, err := ParseLength(src); err == nil {
+			t.Errorf("ParseLength(%q): expected an error", src)
+		}
+	}
+
+	tbl := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "bio", Type: "text"},
+		{Name: "status", Type: "text"},
+		{Name: "about", Type: "text"},
+	}}
+	rows := []map[string]interface{}{
+		{"bio": "Runs a bakery. Loves hiking! Has two cats.", "status": "Shipping the new release this weekend", "about": "One.\n\nTwo.\n\nThree."},
+		{"bio": "Writes code.", "status": "ok", "about": nil},
+	}
+	lengths := map[string]Length{
+		"bio":          {Min: 1, Max: 2, Unit: Sentences},
+		"users.status": {Max: 20, Unit: Chars},
+		"about":        {Max: 2, Unit: Paragraphs},
+	}
+	rep := Lengths(tbl, rows, lengths)
+	if got := rows[0]["bio"]; got != "Runs a bakery. Loves hiking!" {
+		t.Errorf("bio = %q", got)
+	}
+	if got := rows[0]["status"]; got != "Shipping the new" {
+		t.Errorf("status = %q, want it cut at a word", got)
+	}
+	if got := rows[0]["about"]; got != "One.\n\nTwo." {
+		t.Errorf("about = %q", got)
+	}
+	if len(rep.Actions) != 3 || rows[1]["bio"] != "Writes code." {
+		t.Errorf("expected 3 repairs leaving row 2 alone, got %v", rep.Actions)
+	}
+	if got := trimLength("- first item of a list.", Length{Max: 1, Unit: Words}); got != "- first." {
+		t.Errorf("a bullet does not count as a word: %q", got)
+	}
+}
+
+func TestRichText(t *testing.T) {
+	for in, want := range map[string]string{
+		"Plain text & more.\n\nSecond part.":                                        "<p>Plain text &amp; more.</p>\n<p>Second part.</p>",
+		`<h2 class="x">Hi</h2><p>See <a href="https://x.io" onclick="y()">this</a>`: `<h2>Hi</h2><p>See <a href="https://x.io">this</a></p>`,
+		`<ul><li>one<li>two</ul><script>alert(1)</script><div>end</div>`:            `<ul><li>one</li><li>two</li></ul>end`,
+		`<a href="javascript:alert(1)">x</a></p>`:                                   `<a href="#">x</a>`,
+	} {
+		if got := SanitizeHTML(in); got != want {
+			t.Errorf("SanitizeHTML(%q) = %q, want %q", in, got, want)
+		}
+	}
+	for in, want := range map[string]string{
+		"##Intro\nText here.\n- one\n- two":   "## Intro\nText here.\n\n- one\n- two",
+		"See [docs](javascript:x) <b>now</b>": "See [docs](#) now",
+		"```go\nfmt.Println()":                "```go\nfmt.Println()\n```",
+	} {
+		if got := CleanMarkdown(in); got != want {
+			t.Errorf("CleanMarkdown(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	tbl := &schema.Table{Name: "posts", Columns: []schema.Column{
+		{Name: "title", Type: "text"},
+		{Name: "body_html", Type: "text"},
+		{Name: "summary_md", Type: "text"},
+	}}
+	rows := []map[string]interface{}{{"title": "<b>x</b>", "body_html": "Hello", "summary_md": "## Done"}}
+	rep := RichText(tbl, rows)
+	if rows[0]["title"] != "<b>x</b>" || rows[0]["body_html"] != "<p>Hello</p>" || len(rep.Actions) != 1 {
+		t.Errorf("expected only body_html repaired, got %v (%d repairs)", rows[0], len(rep.Actions))
+	}
+}
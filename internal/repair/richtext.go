@@ -0,0 +1,216 @@
+package repair
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindRichText marks an HTML or Markdown value cleaned up to be well
+// formed and safe to render.
+const KindRichText Kind = "rich-text"
+
+// Rich text formats.
+const (
+	RichHTML     = "html"
+	RichMarkdown = "markdown"
+)
+
+// RichTextFormat returns the format of a rich-text column, named like
+// body_html or content_md, or "".
+func RichTextFormat(col schema.Column) string {
+	if col.Type != "text" || col.ForeignKey != nil || col.PrimaryKey {
+		return ""
+	}
+	name := strings.ToLower(col.Name)
+	switch {
+	case name == "html" || strings.HasSuffix(name, "_html"):
+		return RichHTML
+	case name == "markdown" || strings.HasSuffix(name, "_md") || strings.HasSuffix(name, "_markdown"):
+		return RichMarkdown
+	}
+	return ""
+}
+
+// RichText cleans the values of rich-text columns (see RichTextFormat):
+// HTML is sanitized with SanitizeHTML and Markdown tidied with
+// CleanMarkdown.
+func RichText(t *schema.Table, rows []map[string]interface{}) Report {
+	rep := Report{Table: t.Name}
+	for _, col := range t.Columns {
+		clean := SanitizeHTML
+		switch RichTextFormat(col) {
+		case "":
+			continue
+		case RichMarkdown:
+			clean = CleanMarkdown
+		}
+		for i, row := range rows {
+			s, ok := row[col.Name].(string)
+			if !ok {
+				continue
+			}
+			to := clean(s)
+			if to == s {
+				continue
+			}
+			row[col.Name] = to
+			rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindRichText, From: s, To: to})
+		}
+	}
+	return rep
+}
+
+// htmlTags are the tags SanitizeHTML keeps; true marks void tags.
+var htmlTags = map[string]bool{
+	"h1": false, "h2": false, "h3": false, "h4": false, "p": false, "br": true,
+	"ul": false, "ol": false, "li": false, "a": false, "strong": false, "em": false,
+	"b": false, "i": false, "code": false, "pre": false, "blockquote": false,
+}
+
+var (
+	htmlDropRe   = regexp.MustCompile(`(?is)<!--.*?-->|<(script|style|iframe|object|embed|template)\b.*?</(script|style|iframe|object|embed|template)\s*>`)
+	htmlTagRe    = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:\s[^<>]*)?/?)>`)
+	htmlHrefRe   = regexp.MustCompile(`(?i)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	blankLinesRe = regexp.MustCompile(`\n\s*\n`)
+	htmlText     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	htmlBrackets = strings.NewReplacer("<", "&lt;", ">", "&gt;") // entities stay
+)
+
+// SanitizeHTML returns s as an HTML snippet that only uses headings,
+// paragraphs, lists, links and inline emphasis, with every tag closed.
+// Scripts, styles, comments and other tags are dropped, attributes other
+// than a safe href with them. Text without any tags becomes paragraphs.
+func SanitizeHTML(s string) string {
+	s = strings.TrimSpace(htmlDropRe.ReplaceAllString(s, ""))
+	if !htmlTagRe.MatchString(s) {
+		var paras []string
+		for _, p := range blankLinesRe.Split(s, -1) {
+			if p = strings.TrimSpace(p); p != "" {
+				paras = append(paras, "<p>"+htmlText.Replace(p)+"</p>")
+			}
+		}
+		return strings.Join(paras, "\n")
+	}
+	var sb strings.Builder
+	var open []string
+	text := func(t string) { sb.WriteString(htmlBrackets.Replace(t)) }
+	last := 0
+	for _, m := range htmlTagRe.FindAllStringSubmatchIndex(s, -1) {
+		text(s[last:m[0]])
+		last = m[1]
+		closing, name, attrs := s[m[2]:m[3]] == "/", strings.ToLower(s[m[4]:m[5]]), s[m[6]:m[7]]
+		void, ok := htmlTags[name]
+		switch {
+		case !ok:
+		case void:
+			if !closing {
+				sb.WriteString("<" + name + ">")
+			}
+		case !closing:
+			if (name == "li" || name == "p") && len(open) > 0 && open[len(open)-1] == name {
+				sb.WriteString("</" + name + ">") // <li>one<li>two
+				open = open[:len(open)-1]
+			}
+			if name == "a" {
+				sb.WriteString(fmt.Sprintf(`<a href="%s">`, html.EscapeString(safeHref(attrs))))
+			} else {
+				sb.WriteString("<" + name + ">")
+			}
+			open = append(open, name)
+		default:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for len(open) > i {
+					sb.WriteString("</" + open[len(open)-1] + ">")
+					open = open[:len(open)-1]
+				}
+				break
+			}
+		}
+	}
+	text(s[last:])
+	for len(open) > 0 {
+		sb.WriteString("</" + open[len(open)-1] + ">")
+		open = open[:len(open)-1]
+	}
+	return sb.String()
+}
+
+// safeHref returns the href in attrs if it is a safe URL, and "#"
+// otherwise.
+func safeHref(attrs string) string {
+	m := htmlHrefRe.FindStringSubmatch(attrs)
+	if m == nil {
+		return "#"
+	}
+	if href := html.UnescapeString(strings.TrimSpace(m[1] + m[2] + m[3])); safeURL(href) {
+		return href
+	}
+	return "#"
+}
+
+// safeURL reports whether u is a web or mail link, or a relative one: not
+// javascript: or data: and the like.
+func safeURL(u string) bool {
+	if u == "" {
+		return false
+	}
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	switch strings.ToLower(u[:i]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+var (
+	mdHeadingRe = regexp.MustCompile(`(?m)^(#{1,6})([^#\s])`)
+	mdLinkRe    = regexp.MustCompile(`\]\(\s*([^)\s]*)`)
+	mdBlockRe   = regexp.MustCompile(`^(#{1,6} |[-*+] |\d+\. |>)`)
+)
+
+// CleanMarkdown returns s as Markdown that renders as meant: raw HTML is
+// dropped, headings get their space and a blank line before them, as do
+// lists that follow a paragraph, unsafe links (see safeURL) go to "#", and
+// a code fence left open is closed.
+func CleanMarkdown(s string) string {
+	s = htmlDropRe.ReplaceAllString(strings.ReplaceAll(s, "\r\n", "\n"), "")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = mdHeadingRe.ReplaceAllString(s, "$1 $2")
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		if safeURL(mdLinkRe.FindStringSubmatch(m)[1]) {
+			return m
+		}
+		return "](#"
+	})
+	lines := strings.Split(s, "\n")
+	var out []string
+	fenced := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+		}
+		if !fenced && len(out) > 0 && mdBlockRe.MatchString(line) {
+			prev := out[len(out)-1]
+			heading := strings.HasPrefix(line, "#")
+			if prev != "" && (heading || !mdBlockRe.MatchString(prev)) {
+				out = append(out, "")
+			}
+		}
+		out = append(out, line)
+	}
+	if fenced {
+		out = append(out, "```")
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,28 @@
+package repair
+
+import (
+	"github.com/satyammistari/db-seed-ai/internal/rules"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindRule marks a value changed to satisfy a conditional rule.
+const KindRule Kind = "rule"
+
+// Rules makes each row satisfy the conditional rules that apply to t,
+// where the fix is unambiguous (see rules.Rule.Fix). Violations it cannot
+// fix are left for the validator to report.
+func Rules(t *schema.Table, rows []map[string]interface{}, rs []*rules.Rule) Report {
+	rep := Report{Table: t.Name}
+	rs = rules.ForTable(rs, t.Name, func(c string) bool { return t.Column(c) != nil })
+	for i, row := range rows {
+		for _, r := range rs {
+			changed := r.Fix(row)
+			for _, col := range t.Columns {
+				if old, ok := changed[col.Name]; ok {
+					rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindRule, From: old, To: row[col.Name]})
+				}
+			}
+		}
+	}
+	return rep
+}
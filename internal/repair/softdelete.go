@@ -0,0 +1,70 @@
+package repair
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindSoftDelete marks a soft-delete column set to agree with the share of
+// rows that are deleted.
+const KindSoftDelete Kind = "soft-delete"
+
+// SoftDeletes makes share of the rows of a soft-deleting table (see
+// schema.SoftDeleteColumns) deleted, picked with r, and the rest not:
+// the model tends to fill deleted_at as often as any other column. A
+// deleted row gets the flag set and a deletion time no earlier than its
+// created and updated times, keeping the model's time if it is; now is
+// used when the row has no time at all. The other rows get a NULL
+// deletion time and deleted_by, and the flag unset.
+func SoftDeletes(t *schema.Table, rows []map[string]interface{}, share float64, now time.Time, r *rand.Rand) Report {
+	rep := Report{Table: t.Name}
+	at, flag, by := schema.SoftDeleteColumns(t)
+	if at == "" && flag == "" {
+		return rep
+	}
+	created, updated := timeColumns(t)
+	deleted := int(math.Round(share * float64(len(rows))))
+	for i, j := range r.Perm(len(rows)) {
+		row := rows[j]
+		set := func(col string, v interface{}) {
+			if col == "" || v == nil && row[col] == nil || v != nil && row[col] != nil && fmt.Sprint(v) == fmt.Sprint(row[col]) {
+				return
+			}
+			rep.Actions = append(rep.Actions, Action{Row: j + 1, Column: col, Kind: KindSoftDelete, From: row[col], To: v})
+			row[col] = v
+		}
+		if i >= deleted {
+			if c := t.Column(at); c != nil && !c.NotNull {
+				set(at, nil)
+			}
+			set(flag, false)
+			if c := t.Column(by); c != nil && !c.NotNull {
+				set(by, nil)
+			}
+			continue
+		}
+		set(flag, true)
+		if at == "" {
+			continue
+		}
+		var last time.Time
+		var lastValue interface{}
+		for _, col := range append([]string{created}, updated...) {
+			if ts, _, ok := parseTime(row[col]); ok && ts.After(last) {
+				last, lastValue = ts, row[col]
+			}
+		}
+		ts, _, ok := parseTime(row[at])
+		switch {
+		case lastValue != nil && (!ok || ts.Before(last)):
+			set(at, lastValue)
+		case lastValue == nil && row[at] == nil:
+			set(at, now.UTC().Format("2006-01-02 15:04:05"))
+		}
+	}
+	return rep
+}
@@ -0,0 +1,142 @@
+package repair
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// KindTimeOrder marks a timestamp moved so that a row is not updated
+// before it was created, or created before the row it belongs to.
+const KindTimeOrder Kind = "time-order"
+
+// childDelay is how long after its parent a child row whose time came out
+// earlier is said to have been created.
+const childDelay = time.Hour
+
+var (
+	// createdColumns and updatedColumns are the names, lowercased and
+	// without underscores, of the columns saying when a row was created
+	// and when it last changed.
+	createdColumns = map[string]bool{
+		"createdat": true, "createdon": true, "created": true, "createddate": true,
+		"creationdate": true, "insertedat": true,
+	}
+	updatedColumns = map[string]bool{
+		"updatedat": true, "updatedon": true, "updated": true, "updateddate": true,
+		"modifiedat": true, "modifiedon": true, "modified": true, "lastmodified": true, "lastmodifiedat": true,
+	}
+	// timeLayouts are the forms timestamps come in; a value is written
+	// back in the form it was read in.
+	timeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02",
+	}
+)
+
+// Times keeps the timestamps of each row in a believable order. A row that
+// references another row of t (a reply and the post it answers, a category
+// and its parent) is created no earlier than that row: one created before
+// its parent is moved to childDelay after it. Then an updated_at earlier
+// than created_at is set to created_at. Parents are only found among rows,
+// so they need their key in the generated rows.
+func Times(t *schema.Table, rows []map[string]interface{}) Report {
+	rep := Report{Table: t.Name}
+	created, updated := timeColumns(t)
+	if created == "" {
+		return rep
+	}
+	var selfRefs []schema.Column
+	for _, c := range t.FKColumns() {
+		if strings.EqualFold(c.ForeignKey.RefTable, t.Name) {
+			selfRefs = append(selfRefs, c)
+		}
+	}
+	fix := func(i int, col string, to string) {
+		rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col, Kind: KindTimeOrder, From: rows[i][col], To: to})
+		rows[i][col] = to
+	}
+
+	// A parent may itself move, so repeat until nothing does; a cycle of
+	// references stops after a pass per row.
+	for pass, moved := 0, true; moved && pass < len(rows); pass++ {
+		moved = false
+		for _, c := range selfRefs {
+			parents := make(map[string]int)
+			for i, row := range rows {
+				if v := row[c.ForeignKey.RefColumn]; v != nil {
+					parents[fmt.Sprint(v)] = i
+				}
+			}
+			for i, row := range rows {
+				ref := row[c.Name]
+				if ref == nil {
+					continue
+				}
+				p, ok := parents[fmt.Sprint(ref)]
+				if !ok || p == i {
+					continue
+				}
+				pt, _, ok := parseTime(rows[p][created])
+				if !ok {
+					continue
+				}
+				ct, layout, ok := parseTime(row[created])
+				if ok && ct.Before(pt) {
+					fix(i, created, pt.Add(childDelay).Format(layout))
+					moved = true
+				}
+			}
+		}
+	}
+	for _, col := range updated {
+		for i, row := range rows {
+			ct, _, ok := parseTime(row[created])
+			if !ok {
+				continue
+			}
+			ut, _, ok := parseTime(row[col])
+			if ok && ut.Before(ct) {
+				fix(i, col, row[created].(string))
+			}
+		}
+	}
+	return rep
+}
+
+// timeColumns returns t's created column and its updated columns, if it
+// has a created column.
+func timeColumns(t *schema.Table) (created string, updated []string) {
+	for _, c := range t.Columns {
+		if c.Type != "timestamp" {
+			continue
+		}
+		name := strings.ReplaceAll(strings.ToLower(c.Name), "_", "")
+		switch {
+		case createdColumns[name] && created == "":
+			created = c.Name
+		case updatedColumns[name]:
+			updated = append(updated, c.Name)
+		}
+	}
+	return created, updated
+}
+
+// parseTime reads a timestamp string and returns the layout it was in.
+func parseTime(v interface{}) (time.Time, string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, "", false
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, layout, true
+		}
+	}
+	return time.Time{}, "", false
+}
@@ -0,0 +1,132 @@
+package repair
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/token"
+)
+
+// KindToken marks an API key, token or JWT built locally.
+const KindToken Kind = "token"
+
+// Tokens replaces the values of token columns with well-formed ones:
+// columns named like a token (see token.Default) and any column a rule
+// names, keyed "table.column" or "column"; a "*" rule replaces the
+// defaults of every token-named column. Values are filled in when missing
+// too, so the model can leave the columns out; NULLs in nullable columns
+// stay. A JWT's sub claim is the row's user_id, account_id or id when it
+// has one. Tokens fit the column's varchar(n): the random part is cut to
+// fit, and a column too short for the JWTs gets random tokens instead. A
+// prefix that leaves no room is refused by CheckTokens.
+func Tokens(t *schema.Table, rows []map[string]interface{}, rules map[string]token.Rule) Report {
+	rep := Report{Table: t.Name}
+	now := time.Now()
+	for _, col := range t.Columns {
+		rule, ok := tokenRule(rules, t.Name, col)
+		if !ok || rule.Format == token.Keep {
+			continue
+		}
+		limit := col.MaxLength()
+		values := make([]string, len(rows))
+		if rule.Format == token.JWT {
+			for i, row := range rows {
+				claims := make(map[string]interface{})
+				for _, k := range []string{"user_id", "account_id", "id"} {
+					if sub, ok := row[k]; ok && sub != nil {
+						claims["sub"] = fmt.Sprint(sub)
+						break
+					}
+				}
+				values[i] = token.Sign(rule, claims, now.Add(-time.Duration(i)*time.Minute))
+				if limit > 0 && len(values[i]) > limit {
+					rule = token.Rule{Format: token.Random}
+					break
+				}
+			}
+		}
+		if rule.Format != token.JWT {
+			var err error
+			if rule, err = fitToken(rule, limit); err != nil {
+				continue
+			}
+			for i := range values {
+				values[i] = token.New(rule)
+			}
+		}
+		for i, row := range rows {
+			v, ok := row[col.Name]
+			if ok && v == nil && !col.NotNull {
+				continue
+			}
+			row[col.Name] = values[i]
+			rep.Actions = append(rep.Actions, Action{Row: i + 1, Column: col.Name, Kind: KindToken, From: v, To: values[i]})
+		}
+	}
+	return rep
+}
+
+// minTokenLength is the fewest random characters a token may be cut to
+// when its column is short.
+const minTokenLength = 8
+
+// fitToken cuts the random part of a token built by r to fit a column of
+// at most limit characters, 0 for no limit.
+func fitToken(r token.Rule, limit int) (token.Rule, error) {
+	if limit <= 0 {
+		return r, nil
+	}
+	room := limit - len(r.Prefix)
+	if room < minTokenLength {
+		return r, fmt.Errorf("token prefix %q leaves %d of the column's %d characters for the random part, fewer than %d", r.Prefix, max(room, 0), limit, minTokenLength)
+	}
+	if r.Length == 0 {
+		r.Length = token.DefaultLength
+	}
+	r.Length = min(r.Length, room)
+	return r, nil
+}
+
+// CheckTokens returns an error for a token column of t whose varchar(n)
+// cannot hold the rule's prefix and enough random characters after it.
+func CheckTokens(t *schema.Table, rules map[string]token.Rule) error {
+	for _, col := range t.Columns {
+		rule, ok := tokenRule(rules, t.Name, col)
+		if !ok || rule.Format == token.Keep || rule.Format == token.JWT {
+			continue
+		}
+		if _, err := fitToken(rule, col.MaxLength()); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name, col.Name, err)
+		}
+	}
+	return nil
+}
+
+// TokenColumns returns the columns of t that Tokens fills.
+func TokenColumns(t *schema.Table, rules map[string]token.Rule) []string {
+	var out []string
+	for _, col := range t.Columns {
+		if r, ok := tokenRule(rules, t.Name, col); ok && r.Format != token.Keep {
+			out = append(out, col.Name)
+		}
+	}
+	return out
+}
+
+func tokenRule(rules map[string]token.Rule, table string, col schema.Column) (token.Rule, bool) {
+	if col.Type != "text" || col.ForeignKey != nil {
+		return token.Rule{}, false
+	}
+	if r, ok := rules[table+"."+col.Name]; ok {
+		return r, true
+	}
+	if r, ok := rules[col.Name]; ok {
+		return r, true
+	}
+	def, ok := token.Default(col.Name)
+	if r, all := rules["*"]; all && ok {
+		return r, true
+	}
+	return def, ok
+}
@@ -0,0 +1,69 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// GitHubActions is set when running as a GitHub Actions step. Annotate
+// prints nothing otherwise.
+var GitHubActions = os.Getenv("GITHUB_ACTIONS") == "true"
+
+// annotations is where workflow commands go; the runner reads stdout.
+var annotations io.Writer = os.Stdout
+
+// Annotation levels.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Location is where an annotation points. Line and Col are 1-based; zero
+// means unknown.
+type Location struct {
+	File string
+	Line int
+	Col  int
+}
+
+// Annotate prints a GitHub Actions workflow command, so the message shows
+// on the file in the workflow summary and in pull request checks. Example:
+//
+//	::error file=schema.sql,line=12,col=3,title=Schema parse error::cannot parse column definition
+func Annotate(level string, at Location, title, msg string) {
+	if !GitHubActions {
+		return
+	}
+	var props []string
+	if at.File != "" {
+		props = append(props, "file="+escapeProperty(at.File))
+		if at.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", at.Line))
+		}
+		if at.Col > 0 {
+			props = append(props, fmt.Sprintf("col=%d", at.Col))
+		}
+	}
+	if title != "" {
+		props = append(props, "title="+escapeProperty(title))
+	}
+	cmd := "::" + level
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+	fmt.Fprintf(annotations, "%s::%s\n", cmd, escapeData(msg))
+}
+
+// escapeData escapes a workflow command message: a newline would end the
+// command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property, where ':' and ','
+// are separators as well.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
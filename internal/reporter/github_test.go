@@ -0,0 +1,27 @@
+package reporter
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	var buf bytes.Buffer
+	annotations = &buf
+	defer func(on bool) { GitHubActions = on }(GitHubActions)
+
+	GitHubActions = false
+	Annotate(LevelError, Location{File: "schema.sql", Line: 3}, "", "ignored")
+	if buf.Len() != 0 {
+		t.Fatalf("annotated outside GitHub Actions: %q", buf.String())
+	}
+
+	GitHubActions = true
+	Annotate(LevelError, Location{File: "db/schema,v2.sql", Line: 12, Col: 3}, "Schema: parse error", "50% done\nsecond line")
+	Annotate(LevelWarning, Location{}, "", "plain")
+	want := "::error file=db/schema%2Cv2.sql,line=12,col=3,title=Schema%3A parse error::50%25 done%0Asecond line\n" +
+		"::warning::plain\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -9,6 +9,10 @@ import (
 // NoColor disables ANSI color output.
 var NoColor = false
 
+// Quiet drops Ok and Info lines, for compact CI logs. Warnings and errors
+// still print.
+var Quiet = false
+
 const (
 	green  = "\033[32m"
 	yellow = "\033[33m"
@@ -25,11 +29,17 @@ func c(s string) string {
 
 // Ok prints a green check message.
 func Ok(msg string) {
+	if Quiet {
+		return
+	}
 	fmt.Fprintf(os.Stderr, "  %s✓%s %s\n", c(green), c(reset), msg)
 }
 
 // Info prints an info line.
 func Info(msg string) {
+	if Quiet {
+		return
+	}
 	fmt.Fprintln(os.Stderr, msg)
 }
 
@@ -55,7 +65,7 @@ func Table(columns []string, rows []map[string]interface{}) {
 	for _, row := range rows {
 		for i, col := range columns {
 			v := row[col]
-			s := fmt.Sprint(v)
+			s := cell(v)
 			if len(s) > 30 {
 				s = s[:27] + "..."
 			}
@@ -84,7 +94,7 @@ func Table(columns []string, rows []map[string]interface{}) {
 	for _, row := range rows {
 		line := "|"
 		for i, col := range columns {
-			s := fmt.Sprint(row[col])
+			s := cell(row[col])
 			if len(s) > 40 {
 				s = s[:37] + "..."
 			}
@@ -95,6 +105,14 @@ func Table(columns []string, rows []map[string]interface{}) {
 	fmt.Println(sep)
 }
 
+// cell formats a value for Table; bytes are shown in Postgres hex format.
+func cell(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return fmt.Sprintf(`\x%x`, b)
+	}
+	return fmt.Sprint(v)
+}
+
 func pad(s string, w int) string {
 	if len(s) > w {
 		return s[:w]
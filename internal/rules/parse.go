@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParsePredicate parses conditions joined by AND. An empty string gives an
+// empty predicate.
+func ParsePredicate(src string) (Predicate, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return Predicate{}, err
+	}
+	p := Predicate{src: strings.TrimSpace(src)}
+	for len(toks) > 0 {
+		c, rest, err := parseCond(toks)
+		if err != nil {
+			return Predicate{}, fmt.Errorf("%q: %w", src, err)
+		}
+		p.conds = append(p.conds, c)
+		toks = rest
+		if len(toks) == 0 {
+			break
+		}
+		if !strings.EqualFold(toks[0].text, "AND") || toks[0].quoted {
+			return Predicate{}, fmt.Errorf("%q: expected AND, got %q", src, toks[0].text)
+		}
+		toks = toks[1:]
+		if len(toks) == 0 {
+			return Predicate{}, fmt.Errorf("%q: condition missing after AND", src)
+		}
+	}
+	return p, nil
+}
+
+type token struct {
+	text   string
+	quoted bool // a 'string' literal
+}
+
+func (t token) is(word string) bool { return !t.quoted && strings.EqualFold(t.text, word) }
+
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src); j++ {
+				if src[j] == '\'' {
+					if j+1 < len(src) && src[j+1] == '\'' {
+						b.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string in %q", src)
+			}
+			toks = append(toks, token{text: b.String(), quoted: true})
+			i = j + 1
+		case c == '(' || c == ')' || c == ',' || c == '=':
+			toks = append(toks, token{text: string(c)})
+			i++
+		case c == '!' || c == '<':
+			if i+1 < len(src) && (src[i:i+2] == "!=" || src[i:i+2] == "<>") {
+				toks = append(toks, token{text: "!="})
+				i += 2
+				continue
+			}
+			return nil, fmt.Errorf("unexpected %q in %q", c, src)
+		default:
+			j := i
+			for j < len(src) && !strings.ContainsRune(" \t\n'(),=!<", rune(src[j])) {
+				j++
+			}
+			toks = append(toks, token{text: strings.Trim(src[i:j], `"`)})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+// parseCond reads one condition and returns the remaining tokens.
+func parseCond(toks []token) (cond, []token, error) {
+	if toks[0].quoted {
+		return cond{}, nil, fmt.Errorf("expected a column name, got '%s'", toks[0].text)
+	}
+	c := cond{col: toks[0].text}
+	rest := toks[1:]
+	at := func(i int, word string) bool { return i < len(rest) && rest[i].is(word) }
+	switch {
+	case at(0, "IS") && at(1, "NOT") && at(2, "NULL"):
+		c.op, rest = opNotNull, rest[3:]
+	case at(0, "IS") && at(1, "NULL"):
+		c.op, rest = opNull, rest[2:]
+	case at(0, "NULL"):
+		c.op, rest = opNull, rest[1:]
+	case at(0, "NOT") && at(1, "NULL"):
+		c.op, rest = opNotNull, rest[2:]
+	case at(0, "=") || at(0, "!="):
+		c.op = opEq
+		if at(0, "!=") {
+			c.op = opNe
+		}
+		if len(rest) < 2 {
+			return cond{}, nil, fmt.Errorf("missing value after %s %s", c.col, rest[0].text)
+		}
+		c.values, rest = []string{rest[1].text}, rest[2:]
+	case at(0, "IN") || at(0, "NOT") && at(1, "IN"):
+		c.op = opIn
+		if at(0, "NOT") {
+			c.op, rest = opNotIn, rest[1:]
+		}
+		if !at(1, "(") {
+			return cond{}, nil, fmt.Errorf("expected ( after IN")
+		}
+		i := 2
+		for ; i < len(rest) && !rest[i].is(")"); i++ {
+			if !rest[i].is(",") {
+				c.values = append(c.values, rest[i].text)
+			}
+		}
+		if i == len(rest) || len(c.values) == 0 {
+			return cond{}, nil, fmt.Errorf("unclosed or empty IN list")
+		}
+		rest = rest[i+1:]
+	default:
+		return cond{}, nil, fmt.Errorf("expected =, !=, IN or NULL after %s", c.col)
+	}
+	return c, rest, nil
+}
@@ -0,0 +1,254 @@
+// Package rules handles the cross-column invariants that real schemas keep
+// in application code, declared in seeddb.yaml:
+//
+//	rules:
+//	  - table: orders
+//	    if: status = 'refunded'
+//	    then: refunded_at NOT NULL
+//	    else: refunded_at IS NULL
+//
+// Conditions are one or more comparisons joined by AND:
+//
+//	col = 'text' | col != 42 | col IN ('a', 'b') | col NOT IN (...)
+//	col IS NULL | col IS NOT NULL | col NULL | col NOT NULL
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule is one "if ... then ... else ..." invariant.
+type Rule struct {
+	Table string // empty: every table that has the columns
+	If    Predicate
+	Then  Predicate
+	Else  Predicate // may be empty
+}
+
+// Predicate is a list of conditions that must all hold.
+type Predicate struct {
+	src   string
+	conds []cond
+}
+
+func (p Predicate) String() string { return p.src }
+
+// Empty reports whether the predicate has no conditions.
+func (p Predicate) Empty() bool { return len(p.conds) == 0 }
+
+type op int
+
+const (
+	opEq op = iota
+	opNe
+	opIn
+	opNotIn
+	opNull
+	opNotNull
+)
+
+type cond struct {
+	col    string
+	op     op
+	values []string // literals, unquoted
+}
+
+// New parses a rule from its config parts. elseSrc may be empty.
+func New(table, ifSrc, thenSrc, elseSrc string) (*Rule, error) {
+	r := &Rule{Table: table}
+	var err error
+	if r.If, err = ParsePredicate(ifSrc); err != nil {
+		return nil, fmt.Errorf("if: %w", err)
+	}
+	if r.Then, err = ParsePredicate(thenSrc); err != nil {
+		return nil, fmt.Errorf("then: %w", err)
+	}
+	if r.If.Empty() || r.Then.Empty() {
+		return nil, fmt.Errorf("a rule needs both if and then")
+	}
+	if elseSrc != "" {
+		if r.Else, err = ParsePredicate(elseSrc); err != nil {
+			return nil, fmt.Errorf("else: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Rule) String() string {
+	s := "if " + r.If.src + " then " + r.Then.src
+	if !r.Else.Empty() {
+		s += " else " + r.Else.src
+	}
+	return s
+}
+
+// Columns returns every column the rule mentions.
+func (r *Rule) Columns() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, p := range []Predicate{r.If, r.Then, r.Else} {
+		for _, c := range p.conds {
+			if !seen[c.col] {
+				seen[c.col] = true
+				out = append(out, c.col)
+			}
+		}
+	}
+	return out
+}
+
+// AppliesTo reports whether the rule is for a table with these columns.
+func (r *Rule) AppliesTo(table string, hasColumn func(string) bool) bool {
+	if r.Table != "" {
+		return r.Table == table
+	}
+	for _, c := range r.Columns() {
+		if !hasColumn(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ForTable returns the rules that apply to table.
+func ForTable(rs []*Rule, table string, hasColumn func(string) bool) []*Rule {
+	var out []*Rule
+	for _, r := range rs {
+		if r.AppliesTo(table, hasColumn) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Check returns the failed conditions of the branch that applies to row,
+// or nil when the row satisfies the rule.
+func (r *Rule) Check(row map[string]interface{}) []string {
+	branch := r.branch(row)
+	var failed []string
+	for _, c := range branch.conds {
+		if !c.holds(row) {
+			failed = append(failed, c.String())
+		}
+	}
+	return failed
+}
+
+// Fix changes row so the applicable branch holds where the fix is
+// unambiguous: IS NULL by clearing the column, = and single-value IN by
+// setting the value. It returns the columns it changed with their old
+// values. Conditions it cannot fix (NOT NULL, !=, IN with a choice) are
+// left for Check to report.
+func (r *Rule) Fix(row map[string]interface{}) map[string]interface{} {
+	branch := r.branch(row)
+	changed := make(map[string]interface{})
+	for _, c := range branch.conds {
+		if c.holds(row) {
+			continue
+		}
+		old := row[c.col]
+		switch {
+		case c.op == opNull:
+			row[c.col] = nil
+		case c.op == opEq || c.op == opIn && len(c.values) == 1:
+			row[c.col] = literalValue(c.values[0], old)
+		default:
+			continue
+		}
+		changed[c.col] = old
+	}
+	return changed
+}
+
+func (r *Rule) branch(row map[string]interface{}) Predicate {
+	if r.If.holds(row) {
+		return r.Then
+	}
+	return r.Else
+}
+
+func (p Predicate) holds(row map[string]interface{}) bool {
+	for _, c := range p.conds {
+		if !c.holds(row) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c cond) holds(row map[string]interface{}) bool {
+	v := row[c.col]
+	switch c.op {
+	case opNull:
+		return v == nil
+	case opNotNull:
+		return v != nil
+	}
+	if v == nil {
+		return false // SQL: comparisons with NULL are never true
+	}
+	in := false
+	for _, want := range c.values {
+		if equal(v, want) {
+			in = true
+			break
+		}
+	}
+	if c.op == opEq || c.op == opIn {
+		return in
+	}
+	return !in
+}
+
+func (c cond) String() string {
+	quoted := make([]string, len(c.values))
+	for i, v := range c.values {
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			quoted[i] = v
+		} else {
+			quoted[i] = "'" + v + "'"
+		}
+	}
+	switch c.op {
+	case opEq:
+		return c.col + " = " + quoted[0]
+	case opNe:
+		return c.col + " != " + quoted[0]
+	case opIn:
+		return c.col + " IN (" + strings.Join(quoted, ", ") + ")"
+	case opNotIn:
+		return c.col + " NOT IN (" + strings.Join(quoted, ", ") + ")"
+	case opNull:
+		return c.col + " IS NULL"
+	}
+	return c.col + " IS NOT NULL"
+}
+
+// equal compares a row value with a literal, numerically when both are
+// numbers and case-sensitively as text otherwise.
+func equal(v interface{}, lit string) bool {
+	s := fmt.Sprint(v)
+	if s == lit {
+		return true
+	}
+	a, err1 := strconv.ParseFloat(s, 64)
+	b, err2 := strconv.ParseFloat(lit, 64)
+	return err1 == nil && err2 == nil && a == b
+}
+
+// literalValue converts a literal to the type of the value it replaces.
+func literalValue(lit string, like interface{}) interface{} {
+	switch like.(type) {
+	case float64:
+		if f, err := strconv.ParseFloat(lit, 64); err == nil {
+			return f
+		}
+	case bool:
+		if b, err := strconv.ParseBool(lit); err == nil {
+			return b
+		}
+	}
+	return lit
+}
@@ -0,0 +1,59 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAndFix(t *testing.T) {
+	r, err := New("orders", "status = 'refunded'", "refunded_at NOT NULL AND refund_reason IS NOT NULL", "refunded_at IS NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	paid := map[string]interface{}{"status": "paid", "refunded_at": "2024-03-01", "refund_reason": nil}
+	if got := r.Check(paid); len(got) != 1 || got[0] != "refunded_at IS NULL" {
+		t.Errorf("paid: Check = %v", got)
+	}
+	if changed := r.Fix(paid); paid["refunded_at"] != nil || changed["refunded_at"] != "2024-03-01" {
+		t.Errorf("paid: Fix changed %v, row %v", changed, paid)
+	}
+
+	refunded := map[string]interface{}{"status": "refunded", "refunded_at": nil, "refund_reason": nil}
+	if got := r.Check(refunded); len(got) != 2 {
+		t.Errorf("refunded: Check = %v", got)
+	}
+	if changed := r.Fix(refunded); len(changed) != 0 {
+		t.Errorf("refunded: NOT NULL cannot be fixed, changed %v", changed)
+	}
+
+	r, err = New("", "tier IN ('gold', 'platinum')", "discount = 10", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := map[string]interface{}{"tier": "gold", "discount": float64(5)}
+	r.Fix(row)
+	if row["discount"] != float64(10) {
+		t.Errorf("discount = %#v, want 10", row["discount"])
+	}
+	if len(r.Check(map[string]interface{}{"tier": "basic", "discount": nil})) != 0 {
+		t.Error("rule without else should accept rows that fail the condition")
+	}
+}
+
+func TestParsePredicateErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"status = 'open":   "unterminated string",
+		"status =":         "missing value",
+		"status IN ()":     "empty IN list",
+		"status > 3":       "expected =, !=, IN or NULL",
+		"a = 1 b = 2":      "expected AND",
+		"a = 1 AND":        "condition missing after AND",
+		"'x' = status":     "expected a column name",
+		"status LIKE 'a%'": "expected =, !=, IN or NULL",
+	} {
+		_, err := ParsePredicate(src)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", src, want, err)
+		}
+	}
+}
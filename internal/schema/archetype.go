@@ -0,0 +1,140 @@
+package schema
+
+import "strings"
+
+// Archetype is the role a table plays in a schema, guessed from its name,
+// columns and foreign keys. Seeding picks row counts, styles and prompt
+// hints per archetype.
+type Archetype string
+
+const (
+	// ArchetypeEntity is an ordinary table of things: users, products,
+	// orders.
+	ArchetypeEntity Archetype = "entity"
+	// ArchetypeLookup is a short list of values other tables point at:
+	// statuses, roles, countries, categories.
+	ArchetypeLookup Archetype = "lookup"
+	// ArchetypeJoin links two or more tables many-to-many, and carries
+	// little else: post_tags, team_members.
+	ArchetypeJoin Archetype = "join"
+	// ArchetypeEvent is an append-only log of things that happened:
+	// audit_logs, page_views, events.
+	ArchetypeEvent Archetype = "event-log"
+)
+
+// Archetypes lists every archetype, for flag and config help.
+var Archetypes = []Archetype{ArchetypeEntity, ArchetypeLookup, ArchetypeJoin, ArchetypeEvent}
+
+// ParseArchetype returns the archetype named s, or false.
+func ParseArchetype(s string) (Archetype, bool) {
+	for _, a := range Archetypes {
+		if strings.EqualFold(s, string(a)) {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+// lookupWords and eventWords are the last word of table names, singular,
+// that mark lookups and event logs.
+var (
+	lookupWords = map[string]bool{
+		"status": true, "type": true, "kind": true, "category": true, "role": true, "country": true,
+		"currency": true, "language": true, "locale": true, "timezone": true, "state": true, "region": true,
+		"priority": true, "level": true, "tier": true, "plan": true, "unit": true, "gender": true,
+	}
+	eventWords = map[string]bool{
+		"log": true, "event": true, "audit": true, "history": true, "activity": true, "view": true,
+		"click": true, "visit": true, "impression": true, "hit": true, "metric": true, "trail": true,
+	}
+	// lookupColumns are the columns a lookup table carries besides its key.
+	lookupColumns = map[string]bool{
+		"name": true, "code": true, "label": true, "title": true, "description": true, "value": true,
+		"slug": true, "key": true, "symbol": true, "abbreviation": true, "display_name": true,
+		"sort_order": true, "position": true, "color": true, "icon": true, "is_active": true, "active": true,
+	}
+	// timeColumns are the names an event's time goes by.
+	timeColumns = []string{"occurred_at", "created_at", "logged_at", "timestamp", "event_time", "happened_at", "recorded_at", "at"}
+)
+
+// Classify guesses the archetype of t; tables is the whole schema, to see
+// which tables point at t.
+//
+//   - join: at least two FK columns to other tables, at most two other
+//     columns besides timestamps, and nothing references it
+//   - event log: named like a log or events and has a time column, or has
+//     a time column and an event_type, action or level column; nothing
+//     references it
+//   - lookup: no FKs, at most three columns of its own, a text column,
+//     and either a lookup name (statuses, roles, *_types), or other tables
+//     pointing at it and only columns like name, code and label
+//   - entity: everything else
+func Classify(t *Table, tables []*Table) Archetype {
+	fks, other, text, plain := 0, 0, false, true
+	for _, c := range t.Columns {
+		switch {
+		case c.ForeignKey != nil:
+			if !strings.EqualFold(c.ForeignKey.RefTable, t.Name) {
+				fks++
+			}
+		case c.PrimaryKey && c.Type == "integer":
+		case c.Type == "timestamp":
+		default:
+			other++
+			text = text || c.Type == "text"
+			plain = plain && lookupColumns[strings.ToLower(c.Name)]
+		}
+	}
+	referenced := false
+	for _, o := range tables {
+		if o == t {
+			continue
+		}
+		for _, c := range o.FKColumns() {
+			referenced = referenced || strings.EqualFold(c.ForeignKey.RefTable, t.Name)
+		}
+	}
+	word := lastWord(t.Name)
+	switch {
+	case fks >= 2 && other <= 2 && !referenced:
+		return ArchetypeJoin
+	case !referenced && EventTimeColumn(t) != "" &&
+		(eventWords[word] || t.Column("event_type") != nil || t.Column("action") != nil || t.Column("level") != nil):
+		return ArchetypeEvent
+	case fks == 0 && text && other <= 3 && (lookupWords[word] || referenced && plain):
+		return ArchetypeLookup
+	}
+	return ArchetypeEntity
+}
+
+// EventTimeColumn returns the column of t holding when a row happened, or
+// "".
+func EventTimeColumn(t *Table) string {
+	for _, name := range timeColumns {
+		if c := t.Column(name); c != nil && c.Type == "timestamp" {
+			return name
+		}
+	}
+	return ""
+}
+
+// lastWord returns the last underscore-separated word of a table name,
+// lowercased and singular: "order_statuses" gives "status".
+func lastWord(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.LastIndexAny(name, "_-"); i >= 0 {
+		name = name[i+1:]
+	}
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "uses"), strings.HasSuffix(name, "sses"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && !strings.HasSuffix(name, "us"):
+		return name[:len(name)-1]
+	}
+	return name
+}
@@ -0,0 +1,79 @@
+package schema
+
+import "strings"
+
+var (
+	// actorColumns are the names, lowercased and without underscores, of
+	// columns naming the user who created, changed or deleted a row.
+	actorColumns = map[string]bool{
+		"createdby": true, "createdbyid": true, "updatedby": true, "updatedbyid": true,
+		"modifiedby": true, "modifiedbyid": true, "deletedby": true, "deletedbyid": true,
+	}
+	// userWords are the names of the table those users are in, in order
+	// of preference, singular.
+	userWords = []string{"user", "account", "member", "employee"}
+)
+
+// SoftDeleteColumns returns the columns of t that soft-delete a row: the
+// timestamp of the deletion (deleted_at, deleted_on), a boolean flag
+// (is_deleted, deleted) and the user who deleted it (deleted_by); "" for
+// each t does not have. A table without at or flag is not soft-deleted.
+func SoftDeleteColumns(t *Table) (at, flag, by string) {
+	for _, c := range t.Columns {
+		switch name := strings.ReplaceAll(strings.ToLower(c.Name), "_", ""); {
+		case c.Type == "timestamp" && (name == "deletedat" || name == "deletedon"):
+			at = c.Name
+		case c.Type == "boolean" && (name == "isdeleted" || name == "deleted"):
+			flag = c.Name
+		case name == "deletedby" || name == "deletedbyid":
+			by = c.Name
+		}
+	}
+	return at, flag, by
+}
+
+// ActorColumns returns the columns of t naming the user who created,
+// changed or deleted a row: created_by, updated_by, deleted_by and the
+// like, with or without an _id suffix.
+func ActorColumns(t *Table) []Column {
+	var out []Column
+	for _, c := range t.Columns {
+		if actorColumns[strings.ReplaceAll(strings.ToLower(c.Name), "_", "")] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// UsersTable returns the table of tables that actor columns refer to when
+// they are not foreign keys: users, or else accounts, members or
+// employees. It returns nil if there is none.
+func UsersTable(tables []*Table) *Table {
+	for _, w := range userWords {
+		for _, t := range tables {
+			if lastWord(t.Name) == w {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// ActorKey returns the column of users whose values an actor column c
+// holds: the primary key when its type matches c's, else for a text column
+// the username, email or name; "" if none fits.
+func ActorKey(users *Table, c Column) string {
+	for _, k := range users.Columns {
+		if k.PrimaryKey && k.Type == c.Type {
+			return k.Name
+		}
+	}
+	if c.Type == "text" {
+		for _, name := range []string{"username", "email", "name"} {
+			if k := users.Column(name); k != nil && k.Type == "text" {
+				return k.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package schema
+
+import "fmt"
+
+// DeletePlan says how to delete rows from a set of tables without
+// breaking their foreign keys.
+type DeletePlan struct {
+	Order []*Table // each table before the tables it references
+	// Unlink lists nullable references to set to NULL in the rows being
+	// deleted before any delete, where the tables reference each other in
+	// a cycle that no ON DELETE action resolves.
+	Unlink []Edge
+	// Cascades lists the references into the tables whose ON DELETE
+	// action (CASCADE, SET NULL, SET DEFAULT) changes other rows that
+	// point at deleted ones.
+	Cascades []Edge
+}
+
+// PlanDelete orders tables for deleting rows from them: children first,
+// so no delete leaves a reference to a missing row. A cycle is broken at
+// a reference the database resolves itself with its ON DELETE action,
+// or else at a nullable one, which goes in Unlink. Ties keep the reverse
+// of the given order, so insert order comes back reversed. It fails on a
+// cycle of NOT NULL references without such an action.
+func PlanDelete(tables []*Table) (*DeletePlan, error) {
+	plan := &DeletePlan{}
+	remaining := make(map[string]*Table, len(tables))
+	for _, t := range tables {
+		remaining[t.Name] = t
+	}
+	var edges []Edge
+	for _, e := range Edges(tables) {
+		if remaining[e.To] == nil {
+			continue
+		}
+		if e.ResolvesDelete() {
+			plan.Cascades = append(plan.Cascades, e)
+		}
+		if e.From == e.To {
+			// One DELETE removes rows that reference each other, except
+			// that RESTRICT is checked row by row.
+			if e.OnDelete == ActionRestrict && nullable(remaining[e.From], e.Column) {
+				plan.Unlink = append(plan.Unlink, e)
+			}
+			continue
+		}
+		edges = append(edges, e)
+	}
+
+	cut := make(map[Edge]bool)
+	for len(remaining) > 0 {
+		if t := nextToDelete(tables, remaining, edges, cut); t != nil {
+			plan.Order = append(plan.Order, t)
+			delete(remaining, t.Name)
+			continue
+		}
+		e, err := breakCycle(remaining, edges, cut)
+		if err != nil {
+			return nil, err
+		}
+		cut[e] = true
+		if !e.ResolvesDelete() {
+			plan.Unlink = append(plan.Unlink, e)
+		}
+	}
+	return plan, nil
+}
+
+// nextToDelete returns the last table in tables, among those remaining,
+// that no other remaining table references through an edge not yet cut.
+func nextToDelete(tables []*Table, remaining map[string]*Table, edges []Edge, cut map[Edge]bool) *Table {
+	referenced := make(map[string]bool)
+	for _, e := range edges {
+		if !cut[e] && remaining[e.From] != nil {
+			referenced[e.To] = true
+		}
+	}
+	for i := len(tables) - 1; i >= 0; i-- {
+		if t := tables[i]; remaining[t.Name] != nil && !referenced[t.Name] {
+			return t
+		}
+	}
+	return nil
+}
+
+// breakCycle picks the edge to cut in a cycle among the remaining tables:
+// the first one the database resolves on delete, or else the first
+// nullable one.
+func breakCycle(remaining map[string]*Table, edges []Edge, cut map[Edge]bool) (Edge, error) {
+	var live []Edge
+	for _, e := range edges {
+		if !cut[e] && remaining[e.From] != nil && remaining[e.To] != nil {
+			live = append(live, e)
+		}
+	}
+	// An edge is in a cycle when its target leads back to its source.
+	reaches := func(from, to string) bool {
+		seen := map[string]bool{from: true}
+		queue := []string{from}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			for _, e := range live {
+				if e.From != name || seen[e.To] {
+					continue
+				}
+				if e.To == to {
+					return true
+				}
+				seen[e.To] = true
+				queue = append(queue, e.To)
+			}
+		}
+		return false
+	}
+	var cycle []Edge
+	for _, e := range live {
+		if reaches(e.To, e.From) {
+			cycle = append(cycle, e)
+		}
+	}
+	for _, e := range cycle {
+		if e.ResolvesDelete() {
+			return e, nil
+		}
+	}
+	for _, e := range cycle {
+		if nullable(remaining[e.From], e.Column) {
+			return e, nil
+		}
+	}
+	if len(cycle) == 0 {
+		return Edge{}, fmt.Errorf("cannot order deletes") // unreachable: every table left is referenced
+	}
+	e := cycle[0]
+	return Edge{}, fmt.Errorf("cannot delete from %s and %s: they reference each other through NOT NULL columns (e.g. %s.%s) without ON DELETE CASCADE",
+		e.From, e.To, e.From, e.Column)
+}
+
+// nullable reports whether column name of t accepts NULL.
+func nullable(t *Table, name string) bool {
+	c := t.Column(name)
+	return c != nil && !c.NotNull && !c.PrimaryKey
+}
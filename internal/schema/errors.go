@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError reports where in the schema file parsing failed.
+type ParseError struct {
+	Line     int
+	Col      int
+	Table    string // table being parsed, if known
+	Msg      string
+	Fragment string // offending source text, truncated
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "line %d, col %d: ", e.Line, e.Col)
+	if e.Table != "" {
+		fmt.Fprintf(&b, "table %s: ", e.Table)
+	}
+	b.WriteString(e.Msg)
+	if e.Fragment != "" {
+		fmt.Fprintf(&b, ": %s", e.Fragment)
+	}
+	return b.String()
+}
+
+// ParseErrors is every problem found in a schema file.
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("%d schema parse errors (use --lenient to skip them):\n  %s",
+		len(errs), strings.Join(msgs, "\n  "))
+}
+
+// maxFragment caps how much source text is quoted in an error.
+const maxFragment = 60
+
+// errorAt builds a ParseError pointing at toks[0] that quotes the source
+// text spanned by toks.
+func errorAt(src, table string, toks []token, msg string) *ParseError {
+	if len(toks) == 0 {
+		return &ParseError{Table: table, Msg: msg}
+	}
+	first, last := toks[0], toks[len(toks)-1]
+	frag := strings.Join(strings.Fields(src[first.pos:last.pos+len(last.text)]), " ")
+	if len(frag) > maxFragment {
+		cut := maxFragment - 3
+		for cut > 0 && !utf8.RuneStart(frag[cut]) {
+			cut-- // do not split a multi-byte character
+		}
+		frag = frag[:cut] + "..."
+	}
+	return &ParseError{Line: first.line, Col: first.col, Table: table, Msg: msg, Fragment: frag}
+}
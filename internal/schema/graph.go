@@ -0,0 +1,144 @@
+package schema
+
+import "sort"
+
+// Edge is one foreign key: From.Column references To.RefColumn.
+type Edge struct {
+	From      string
+	Column    string
+	To        string
+	RefColumn string
+	OnDelete  string
+}
+
+// ResolvesDelete reports whether the database itself deals with rows that
+// reference a deleted row, by deleting them or changing the reference.
+func (e Edge) ResolvesDelete() bool {
+	switch e.OnDelete {
+	case ActionCascade, ActionSetNull, ActionSetDefault:
+		return true
+	}
+	return false
+}
+
+// Edges returns every foreign key in the schema, in table and column order.
+// References to tables that are not in the schema are included; callers
+// decide whether to draw them.
+func Edges(tables []*Table) []Edge {
+	var edges []Edge
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			if c.ForeignKey == nil {
+				continue
+			}
+			fk := c.ForeignKey
+			edges = append(edges, Edge{From: t.Name, Column: c.Name, To: fk.RefTable, RefColumn: fk.RefColumn, OnDelete: fk.OnDelete})
+		}
+	}
+	return edges
+}
+
+// Cycles returns the groups of tables that reference each other through
+// foreign keys, directly or transitively. A table that references itself
+// forms a group of one. Such groups cannot be seeded in a strict parent-first
+// order. Each group is sorted by name; groups are ordered by their first name.
+func Cycles(tables []*Table) [][]string {
+	known := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		known[t.Name] = true
+	}
+	adj := make(map[string][]string)
+	selfRef := make(map[string]bool)
+	for _, e := range Edges(tables) {
+		if !known[e.To] {
+			continue
+		}
+		if e.From == e.To {
+			selfRef[e.From] = true
+			continue
+		}
+		adj[e.From] = append(adj[e.From], e.To)
+	}
+
+	// Tarjan's strongly connected components.
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var groups [][]string
+	next := 0
+	var visit func(name string)
+	visit = func(name string) {
+		index[name], low[name] = next, next
+		next++
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, dep := range adj[name] {
+			if _, seen := index[dep]; !seen {
+				visit(dep)
+				low[name] = min(low[name], low[dep])
+			} else if onStack[dep] {
+				low[name] = min(low[name], index[dep])
+			}
+		}
+		if low[name] != index[name] {
+			return
+		}
+		var group []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			group = append(group, top)
+			if top == name {
+				break
+			}
+		}
+		if len(group) > 1 || selfRef[name] {
+			sort.Strings(group)
+			groups = append(groups, group)
+		}
+	}
+	for _, t := range tables {
+		if _, seen := index[t.Name]; !seen {
+			visit(t.Name)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+// Descendants returns the tables that reference the named tables through
+// foreign keys, directly or transitively, in the order of tables. The named
+// tables themselves are left out.
+func Descendants(tables []*Table, names []string) []*Table {
+	children := make(map[string][]string)
+	for _, e := range Edges(tables) {
+		if e.From != e.To {
+			children[e.To] = append(children[e.To], e.From)
+		}
+	}
+	named := make(map[string]bool, len(names))
+	for _, name := range names {
+		named[name] = true
+	}
+	seen := make(map[string]bool)
+	queue := append([]string(nil), names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, child := range children[name] {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	var out []*Table
+	for _, t := range tables {
+		if seen[t.Name] && !named[t.Name] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
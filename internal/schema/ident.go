@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// unquoteIdent strips identifier quoting, preserving the exact case and
+// spaces of quoted names. Unquoted names are folded to lower case, as
+// Postgres does when it creates them.
+func unquoteIdent(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 {
+		switch {
+		case s[0] == '"' && s[len(s)-1] == '"':
+			return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+		case s[0] == '`' && s[len(s)-1] == '`':
+			return s[1 : len(s)-1]
+		}
+	}
+	return strings.ToLower(s)
+}
+
+// QuoteIdent double-quotes name when it is not a plain lowercase-safe word,
+// so names such as "Order Items" survive in generated SQL.
+func QuoteIdent(name string) string {
+	if plainIdentRe.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+var plainIdentRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// SplitName splits a table name as Table.Name holds it into the schema it
+// is qualified with, "" if none, and the table's own name.
+func SplitName(name string) (qualifier, table string) {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// QuoteTable double-quotes a table name for SQL, and the schema it is
+// qualified with separately: "billing"."Order Items".
+func QuoteTable(name string) string {
+	quote := func(s string) string { return `"` + strings.ReplaceAll(s, `"`, `""`) + `"` }
+	if qualifier, table := SplitName(name); qualifier != "" {
+		return quote(qualifier) + "." + quote(table)
+	}
+	return quote(name)
+}
@@ -0,0 +1,66 @@
+package schema
+
+// applyUniqueIndex marks the columns of a CREATE UNIQUE INDEX statement as
+// unique. Many schemas declare uniqueness only through indexes. Simple
+// expressions such as lower(email) count as the wrapped column; indexes on
+// anything more complex are ignored.
+func applyUniqueIndex(st statement, tables []*Table) {
+	toks := st.toks
+	on := -1
+	for i, t := range toks {
+		if t.is("ON") {
+			on = i
+			break
+		}
+	}
+	if on == -1 {
+		return
+	}
+	i := skipWords(toks, on+1, "ONLY")
+	name, _, i := qualifiedName(toks, i)
+	t := TableByName(tables, name)
+	if t == nil {
+		return
+	}
+	for i < len(toks) && !toks[i].isPunct("(") {
+		i++
+	}
+	end := matchParen(toks, i)
+	if end == -1 {
+		return
+	}
+	var cols []string
+	for _, item := range splitTokens(toks[i+1 : end]) {
+		col := ""
+		for _, tok := range item {
+			if tok.isName() && t.Column(tok.name()) != nil {
+				col = tok.name()
+				break
+			}
+		}
+		if col == "" {
+			return
+		}
+		cols = append(cols, col)
+	}
+	t.markUnique(cols)
+}
+
+// markUnique records a uniqueness constraint over cols. A single column is
+// flagged on the Column itself; a composite key is kept in UniqueTogether.
+func (t *Table) markUnique(cols []string) {
+	var known []string
+	for _, name := range cols {
+		if t.Column(name) != nil {
+			known = append(known, name)
+		}
+	}
+	switch {
+	case len(known) == 0 || len(known) != len(cols):
+		return
+	case len(known) == 1:
+		t.Column(known[0]).Unique = true
+	default:
+		t.UniqueTogether = append(t.UniqueTogether, known)
+	}
+}
@@ -0,0 +1,390 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind classifies a lexical token.
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota // keyword or unquoted identifier
+	tokIdent                   // "quoted" or `quoted` identifier
+	tokString                  // 'literal', E'literal' or $tag$literal$tag$
+	tokNumber                  // 42, 3.14
+	tokPunct                   // ( ) , ; . [ ]
+	tokOp                      // any other operator characters: = <> :: >=
+)
+
+// token is one lexical unit of a SQL file. Comments and whitespace are
+// dropped; pos/line/col point at the first byte of the token.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	line int
+	col  int
+}
+
+// is reports whether the token is the (case-insensitive) keyword word.
+func (t token) is(word string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, word)
+}
+
+// isPunct reports whether the token is the punctuation character p.
+func (t token) isPunct(p string) bool {
+	return t.kind == tokPunct && t.text == p
+}
+
+// isName reports whether the token can be an identifier.
+func (t token) isName() bool {
+	return t.kind == tokWord || t.kind == tokIdent
+}
+
+// name returns the identifier with quoting removed, folded to lower case
+// if it was not quoted.
+func (t token) name() string {
+	return unquoteIdent(t.text)
+}
+
+// value returns the contents of a string literal.
+func (t token) value() string {
+	s := t.text
+	if strings.HasPrefix(s, "$") {
+		end := strings.Index(s[1:], "$") + 2
+		return s[end : len(s)-end]
+	}
+	if len(s) > 0 && (s[0] == 'E' || s[0] == 'e') {
+		s = s[1:]
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+}
+
+// tokenize splits SQL source into tokens. It understands doubled-quote escapes in
+// string literals, "" escapes in quoted identifiers, Postgres dollar-quoted
+// bodies, and both -- and (nested) /* */ comments, so commas and parens
+// inside any of those never confuse the parser.
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	line, lineStart := 1, 0
+	i := 0
+	emit := func(kind tokenKind, start int) {
+		toks = append(toks, token{kind: kind, text: src[start:i], pos: start, line: line, col: start - lineStart + 1})
+	}
+	// advance moves i to end, keeping line/col bookkeeping for newlines.
+	advance := func(end int) {
+		for ; i < end; i++ {
+			if src[i] == '\n' {
+				line++
+				lineStart = i + 1
+			}
+		}
+	}
+	for i < len(src) {
+		c := src[i]
+		start, startLine, startCol := i, line, i-lineStart+1
+		errAt := func(format string, args ...interface{}) error {
+			return &ParseError{Line: startLine, Col: startCol, Msg: fmt.Sprintf(format, args...)}
+		}
+		switch {
+		case c == '\n' || c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			advance(i + 1)
+
+		case c == '-' && strings.HasPrefix(src[i:], "--"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end == -1 {
+				end = len(src) - i
+			}
+			advance(i + end)
+
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			depth, j := 0, i
+			for j < len(src) {
+				if strings.HasPrefix(src[j:], "/*") {
+					depth++
+					j += 2
+				} else if strings.HasPrefix(src[j:], "*/") {
+					depth--
+					j += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					j++
+				}
+			}
+			if depth != 0 {
+				return nil, errAt("unterminated /* comment")
+			}
+			advance(j)
+
+		case c == '\'' || ((c == 'E' || c == 'e') && i+1 < len(src) && src[i+1] == '\''):
+			j := i + 1
+			if c != '\'' {
+				j++
+			}
+			closed := false
+			for j < len(src) {
+				if src[j] == '\\' && c != '\'' {
+					j += 2
+					continue
+				}
+				if src[j] == '\'' {
+					if j+1 < len(src) && src[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					closed = true
+					j++
+					break
+				}
+				j++
+			}
+			if !closed {
+				return nil, errAt("unterminated string literal")
+			}
+			advance(j)
+			toks = append(toks, token{kind: tokString, text: src[start:j], pos: start, line: startLine, col: startCol})
+
+		case c == '"' || c == '`':
+			j := i + 1
+			closed := false
+			for j < len(src) {
+				if src[j] == c {
+					if c == '"' && j+1 < len(src) && src[j+1] == '"' {
+						j += 2
+						continue
+					}
+					closed = true
+					j++
+					break
+				}
+				j++
+			}
+			if !closed {
+				return nil, errAt("unterminated quoted identifier")
+			}
+			advance(j)
+			toks = append(toks, token{kind: tokIdent, text: src[start:j], pos: start, line: startLine, col: startCol})
+
+		case c == '$' && dollarTag(src[i:]) != "":
+			tag := dollarTag(src[i:])
+			end := strings.Index(src[i+len(tag):], tag)
+			if end == -1 {
+				return nil, errAt("unterminated dollar-quoted string %s", tag)
+			}
+			j := i + len(tag) + end + len(tag)
+			advance(j)
+			toks = append(toks, token{kind: tokString, text: src[start:j], pos: start, line: startLine, col: startCol})
+
+		case isWordStart(c):
+			for i < len(src) && isWordChar(src[i]) {
+				i++
+			}
+			emit(tokWord, start)
+
+		case c >= '0' && c <= '9':
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			emit(tokNumber, start)
+
+		case strings.IndexByte("(),;.[]", c) >= 0:
+			i++
+			emit(tokPunct, start)
+
+		default:
+			for i < len(src) && strings.IndexByte("+-*/<>=~!@#%^&|?:$", src[i]) >= 0 {
+				if strings.HasPrefix(src[i:], "--") || strings.HasPrefix(src[i:], "/*") {
+					break
+				}
+				i++
+			}
+			if i == start {
+				i++
+			}
+			emit(tokOp, start)
+		}
+	}
+	return toks, nil
+}
+
+// dollarTag returns the opening tag ($$ or $name$) at the start of s, or ""
+// when s starts with a positional parameter such as $1.
+func dollarTag(s string) string {
+	j := 1
+	for j < len(s) && isWordChar(s[j]) {
+		if j == 1 && s[j] >= '0' && s[j] <= '9' {
+			return ""
+		}
+		j++
+	}
+	if j < len(s) && s[j] == '$' {
+		return s[:j+1]
+	}
+	return ""
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c >= '0' && c <= '9' || c == '$'
+}
+
+// statement is the tokens of one top-level SQL statement, without the ';'.
+type statement struct {
+	toks []token
+}
+
+// splitStatements groups tokens into statements on top-level semicolons.
+// SQLite trigger bodies (BEGIN ... END) contain semicolons of their own and
+// are kept whole.
+func splitStatements(toks []token) []statement {
+	var out []statement
+	var cur []token
+	depth, block := 0, 0
+	isTrigger := false
+	for _, t := range toks {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case t.is("TRIGGER") && len(cur) > 0 && cur[0].is("CREATE"):
+			isTrigger = true
+		case t.is("BEGIN") && isTrigger:
+			block++
+		case t.is("END") && block > 0:
+			block--
+		}
+		if t.isPunct(";") && depth <= 0 && block == 0 {
+			if len(cur) > 0 {
+				out = append(out, statement{toks: cur})
+			}
+			cur, depth, isTrigger = nil, 0, false
+			continue
+		}
+		cur = append(cur, t)
+	}
+	if len(cur) > 0 {
+		out = append(out, statement{toks: cur})
+	}
+	return out
+}
+
+// createKind returns the object keyword of a CREATE statement (TABLE, VIEW,
+// INDEX, TRIGGER, ...) and the index just past it, skipping modifiers such
+// as OR REPLACE, TEMPORARY, MATERIALIZED and UNIQUE. modifiers holds the
+// upper-cased modifiers that were skipped.
+func (s statement) createKind() (kind string, next int, modifiers []string) {
+	if len(s.toks) == 0 || !s.toks[0].is("CREATE") {
+		return "", 0, nil
+	}
+	for i := 1; i < len(s.toks); i++ {
+		t := s.toks[i]
+		if t.kind != tokWord {
+			return "", 0, nil
+		}
+		switch strings.ToUpper(t.text) {
+		case "OR", "REPLACE", "GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED",
+			"MATERIALIZED", "UNIQUE", "CONSTRAINT", "RECURSIVE", "VIRTUAL":
+			modifiers = append(modifiers, strings.ToUpper(t.text))
+			continue
+		}
+		return strings.ToUpper(t.text), i + 1, modifiers
+	}
+	return "", 0, nil
+}
+
+// matchParen returns the index of the ')' closing the '(' at toks[open],
+// or -1 if it is never closed.
+func matchParen(toks []token, open int) int {
+	depth := 0
+	for i := open; i < len(toks); i++ {
+		switch {
+		case toks[i].isPunct("("):
+			depth++
+		case toks[i].isPunct(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTokens splits toks on top-level commas.
+func splitTokens(toks []token) [][]token {
+	var parts [][]token
+	var cur []token
+	depth := 0
+	for _, t := range toks {
+		switch {
+		case t.isPunct("(") || t.isPunct("["):
+			depth++
+		case t.isPunct(")") || t.isPunct("]"):
+			depth--
+		case t.isPunct(",") && depth == 0:
+			parts = append(parts, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	if len(cur) > 0 {
+		parts = append(parts, cur)
+	}
+	return parts
+}
+
+// qualifiedName reads a possibly schema-qualified name starting at toks[i]
+// and returns it as Table.Name holds it ("billing.order items", see
+// SplitName), the schema it is qualified with, and the index after it. A
+// database before the schema is dropped, and so is public (main in
+// SQLite), where unqualified names go: pg_dump qualifies every table with
+// it.
+func qualifiedName(toks []token, i int) (name, qualifier string, next int) {
+	if i >= len(toks) || !toks[i].isName() {
+		return "", "", i
+	}
+	name = toks[i].name()
+	i++
+	for i+1 < len(toks) && toks[i].isPunct(".") && toks[i+1].isName() {
+		qualifier, name = name, toks[i+1].name()
+		i += 2
+	}
+	if qualifier == "public" || qualifier == "main" {
+		qualifier = ""
+	}
+	if qualifier != "" {
+		return qualifier + "." + name, qualifier, i
+	}
+	return name, "", i
+}
+
+// skipWords advances past the given keyword sequence if it is present.
+func skipWords(toks []token, i int, words ...string) int {
+	for k, w := range words {
+		if i+k >= len(toks) || !toks[i+k].is(w) {
+			return i
+		}
+	}
+	return i + len(words)
+}
+
+// renderType turns type tokens back into text: "character varying(255)",
+// "numeric(10,2)", "timestamp with time zone", "int[]".
+func renderType(toks []token) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 && t.kind == tokWord && (toks[i-1].kind == tokWord || toks[i-1].isPunct(")")) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
@@ -1,200 +1,545 @@
 package schema
 
 import (
-	"bufio"
-	"regexp"
 	"strings"
 )
 
+// ParseOptions controls how strictly a schema file is parsed.
+type ParseOptions struct {
+	// Lenient skips CREATE TABLE statements (and column definitions) that
+	// cannot be parsed, recording them in Schema.ParseErrors, instead of
+	// failing the whole file.
+	Lenient bool
+}
+
 // ParseFile reads a SQL file and returns tables in dependency order (topological sort).
 func ParseFile(content string) ([]*Table, error) {
-	tables := parseTables(content)
-	return topologicalSort(tables), nil
+	s, err := ParseFileToSchema(content)
+	if err != nil {
+		return nil, err
+	}
+	return s.Tables, nil
 }
 
 // ParseFileToSchema reads a SQL file and returns a Schema with all metadata.
 func ParseFileToSchema(content string) (*Schema, error) {
-	tables, err := ParseFile(content)
+	return ParseSchema(content, ParseOptions{})
+}
+
+// ParseSchema is ParseFileToSchema with options. In strict mode any
+// unparseable CREATE TABLE fails with ParseErrors listing every problem.
+func ParseSchema(content string, opts ParseOptions) (*Schema, error) {
+	toks, err := tokenize(content)
 	if err != nil {
 		return nil, err
 	}
-	return NewSchema(tables), nil
+	var (
+		tables   []*Table
+		views    []View
+		triggers []Trigger
+		indexes  []statement
+		problems ParseErrors
+	)
+	for _, st := range splitStatements(toks) {
+		kind, next, mods := st.createKind()
+		switch kind {
+		case "TABLE":
+			t, errs := parseCreateTable(content, st, next)
+			problems = append(problems, errs...)
+			if t != nil && (opts.Lenient || len(errs) == 0) {
+				tables = append(tables, t)
+			}
+		case "VIEW":
+			views = append(views, parseView(st, next, hasWord(mods, "MATERIALIZED")))
+		case "INDEX":
+			if hasWord(mods, "UNIQUE") {
+				indexes = append(indexes, st)
+			}
+		case "TRIGGER":
+			if tr, ok := parseTrigger(st, next); ok {
+				triggers = append(triggers, tr)
+			}
+		}
+	}
+	for _, st := range indexes {
+		applyUniqueIndex(st, tables)
+	}
+	resolveSchemaRefs(tables)
+	resolveImplicitRefs(tables)
+	if len(problems) > 0 && !opts.Lenient {
+		return nil, problems
+	}
+
+	s := NewSchema(topologicalSort(tables))
+	s.Views = views
+	s.Triggers = triggers
+	s.ParseErrors = problems
+	return s, nil
 }
 
-func parseTables(content string) []*Table {
-	var tables []*Table
-	// Normalize: single line per statement for simpler parsing
-	content = normalizeSQL(content)
-	// Split by CREATE TABLE (Go regexp has no (?:), so we use two groups)
-	re := regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?["']?(\w+)["']?\s*\(`)
-	matches := re.FindAllStringSubmatchIndex(content, -1)
-	for i, loc := range matches {
-		tableName := content[loc[4]:loc[5]]
-		start := loc[0]
-		var end int
-		if i+1 < len(matches) {
-			end = matches[i+1][0]
+// parseCreateTable parses CREATE TABLE [IF NOT EXISTS] name ( ... ).
+// next is the index just after the TABLE keyword. It returns the table
+// (nil if nothing usable was found) and any problems with its definition.
+func parseCreateTable(src string, st statement, next int) (*Table, []*ParseError) {
+	toks := st.toks
+	i := skipWords(toks, next, "IF", "NOT", "EXISTS")
+	name, qualifier, i := qualifiedName(toks, i)
+	if name == "" {
+		return nil, []*ParseError{errorAt(src, "", toks, "expected table name")}
+	}
+	own := strings.TrimPrefix(name, qualifier+".")
+	if strings.Contains(qualifier, ".") || strings.Contains(own, ".") {
+		return nil, []*ParseError{errorAt(src, name, toks, "table and schema names containing a dot are not supported")}
+	}
+	if i >= len(toks) || !toks[i].isPunct("(") {
+		return nil, []*ParseError{errorAt(src, name, toks[min(i, len(toks)-1):], "expected ( with column definitions")}
+	}
+	closeIdx := matchParen(toks, i)
+	if closeIdx == -1 {
+		return nil, []*ParseError{errorAt(src, name, toks[i:], "unclosed ( in CREATE TABLE")}
+	}
+	var errs []*ParseError
+	t := &Table{Name: name, Schema: qualifier, Line: toks[0].line}
+	body := toks[i+1 : closeIdx]
+	var constraints [][]token
+	for _, el := range splitTokens(body) {
+		if len(el) == 0 {
+			continue
+		}
+		if isTableConstraint(el) {
+			// Applied after all columns are known: constraints may come first.
+			constraints = append(constraints, el)
+			continue
+		}
+		if col := parseColumnDef(el); col != nil {
+			t.Columns = append(t.Columns, *col)
 		} else {
-			end = len(content)
+			errs = append(errs, errorAt(src, name, el, "cannot parse column definition"))
 		}
-		body := content[start:end]
-		// Find matching closing paren for CREATE TABLE (
-		body = extractParenBlock(body)
-		t := parseTableBody(tableName, body)
-		if t != nil {
-			tables = append(tables, t)
+	}
+	for _, el := range constraints {
+		applyTableConstraint(t, el)
+	}
+	if len(t.Columns) == 0 {
+		if len(errs) > 0 {
+			return nil, errs
 		}
+		return nil, []*ParseError{errorAt(src, name, toks, "no columns found")}
+	}
+	ddlName := QuoteIdent(own)
+	if qualifier != "" {
+		ddlName = QuoteIdent(qualifier) + "." + ddlName
 	}
-	return tables
+	t.DDL = "CREATE TABLE " + ddlName + " (" +
+		strings.TrimSpace(src[toks[i].pos+1:toks[closeIdx].pos]) + ")"
+	return t, errs
 }
 
-func normalizeSQL(s string) string {
-	var b strings.Builder
-	sc := bufio.NewScanner(strings.NewReader(s))
-	for sc.Scan() {
-		line := strings.TrimSpace(sc.Text())
-		if line == "" || strings.HasPrefix(line, "--") {
-			continue
-		}
-		b.WriteString(" ")
-		b.WriteString(line)
+// isTableConstraint reports whether a CREATE TABLE element is a table-level
+// constraint rather than a column definition.
+func isTableConstraint(el []token) bool {
+	first := el[0]
+	if first.kind != tokWord {
+		return false
 	}
-	return b.String()
+	switch strings.ToUpper(first.text) {
+	case "CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "EXCLUDE", "LIKE":
+		return true
+	case "INDEX", "FULLTEXT", "SPATIAL":
+		// MySQL inline index definitions: INDEX idx (col)
+		return true
+	case "KEY":
+		// KEY (col) is an index; "key TEXT" is a column named key.
+		return len(el) > 1 && el[1].isPunct("(")
+	}
+	return false
 }
 
-func extractParenBlock(s string) string {
-	start := strings.Index(s, "(")
-	if start == -1 {
-		return ""
+// columnKeywords end the type part of a column definition.
+var columnKeywords = map[string]bool{
+	"CONSTRAINT": true, "NOT": true, "NULL": true, "PRIMARY": true, "UNIQUE": true,
+	"CHECK": true, "REFERENCES": true, "DEFAULT": true, "GENERATED": true,
+	"COLLATE": true, "AUTOINCREMENT": true, "AUTO_INCREMENT": true, "ON": true,
+	"IDENTITY": true, "COMMENT": true, "AS": true,
+}
+
+func isColumnKeyword(t token) bool {
+	return t.kind == tokWord && columnKeywords[strings.ToUpper(t.text)]
+}
+
+// parseColumnDef parses "name type [constraints...]".
+func parseColumnDef(el []token) *Column {
+	if !el[0].isName() {
+		return nil
 	}
+	col := &Column{Name: el[0].name()}
+
+	// Type: everything up to the first constraint keyword, including
+	// parenthesised arguments and multi-word names.
+	i := 1
 	depth := 0
-	for i := start; i < len(s); i++ {
-		switch s[i] {
-		case '(':
+	for i < len(el) {
+		t := el[i]
+		if depth == 0 && isColumnKeyword(t) {
+			break
+		}
+		if t.isPunct("(") || t.isPunct("[") {
 			depth++
-		case ')':
+		} else if t.isPunct(")") || t.isPunct("]") {
 			depth--
-			if depth == 0 {
-				return s[start+1 : i]
+		}
+		i++
+	}
+	typePart := renderType(el[1:i])
+	col.SQLType = strings.ToLower(typePart)
+	col.Type = normalizeType(typePart)
+	col.Identity = strings.HasSuffix(col.SQLType, "serial")
+
+	for i < len(el) {
+		t := el[i]
+		switch {
+		case t.is("NOT") && i+1 < len(el) && el[i+1].is("NULL"):
+			col.NotNull = true
+			i += 2
+		case t.is("PRIMARY") && i+1 < len(el) && el[i+1].is("KEY"):
+			col.PrimaryKey = true
+			i += 2
+		case t.is("UNIQUE"):
+			col.Unique = true
+			i++
+		case t.is("IDENTITY") || t.is("AUTOINCREMENT") || t.is("AUTO_INCREMENT"):
+			col.Identity = true
+			i++
+		case t.is("DEFAULT"):
+			end := defaultEnd(el, i+1)
+			col.Default = renderExpr(el[i+1 : end])
+			if strings.HasPrefix(strings.ToLower(col.Default), "nextval(") {
+				col.Identity = true
 			}
+			i = end
+		case t.is("CHECK") && i+1 < len(el) && el[i+1].isPunct("("):
+			end := matchParen(el, i+1)
+			if end == -1 {
+				return col
+			}
+			if _, vals := checkInValues(el[i+2:end], func(string) bool { return true }); len(vals) > 0 {
+				col.CheckIn = vals
+			}
+			if checkEmailColumn(el[i+2:end], func(string) bool { return true }) != "" {
+				col.CheckEmail = true
+			}
+			i = end + 1
+		case t.is("REFERENCES"):
+			fk, next := parseReferences(el, i+1)
+			if len(fk) > 0 {
+				col.ForeignKey = fk[0]
+			}
+			i = next
+		case t.isPunct("("):
+			// Skip default expressions and other parenthesised clauses.
+			if end := matchParen(el, i); end != -1 {
+				i = end + 1
+			} else {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return col
+}
+
+// defaultEnd returns the index just past a DEFAULT expression starting at
+// el[i]: the next constraint keyword outside parentheses.
+func defaultEnd(el []token, i int) int {
+	depth := 0
+	for ; i < len(el); i++ {
+		t := el[i]
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case depth == 0 && isColumnKeyword(t) && !t.is("NULL"):
+			return i
+		}
+	}
+	return i
+}
+
+// renderExpr writes toks back out as SQL, with a single space wherever the
+// source had whitespace between them.
+func renderExpr(toks []token) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 && t.pos > toks[i-1].pos+len(toks[i-1].text) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// parseReferences parses "table [(col, ...)] [ON DELETE action] [ON UPDATE
+// action]" starting at toks[i] and returns one ForeignKey per referenced
+// column. RefColumn is empty when the column list is omitted;
+// resolveImplicitRefs fills it in later.
+func parseReferences(toks []token, i int) ([]*ForeignKey, int) {
+	table, _, i := qualifiedName(toks, i)
+	if table == "" {
+		return nil, i
+	}
+	fks := []*ForeignKey{{RefTable: table}}
+	if i < len(toks) && toks[i].isPunct("(") {
+		if end := matchParen(toks, i); end != -1 {
+			fks = nil
+			for _, name := range nameList(toks[i+1 : end]) {
+				fks = append(fks, &ForeignKey{RefTable: table, RefColumn: name})
+			}
+			i = end + 1
 		}
 	}
-	return s[start+1:]
+	onDelete, onUpdate, i := referentialActions(toks, i)
+	for _, fk := range fks {
+		fk.OnDelete, fk.OnUpdate = onDelete, onUpdate
+	}
+	return fks, i
 }
 
-func parseTableBody(tableName, body string) *Table {
-	t := &Table{Name: tableName}
-	// Parse column and constraint lines (comma-separated, respecting parens)
-	parts := splitTopLevel(body, ',')
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p == "" {
+// referentialActions parses the ON DELETE and ON UPDATE clauses at toks[i],
+// in either order, and returns the actions, upper-cased, and the index
+// after them.
+func referentialActions(toks []token, i int) (onDelete, onUpdate string, next int) {
+	for i+2 < len(toks) && toks[i].is("ON") {
+		var action string
+		switch {
+		case toks[i+2].is("SET") && i+3 < len(toks):
+			action, next = "SET "+strings.ToUpper(toks[i+3].text), i+4
+		case toks[i+2].is("NO") && i+3 < len(toks):
+			action, next = "NO ACTION", i+4
+		default:
+			action, next = strings.ToUpper(toks[i+2].text), i+3
+		}
+		switch {
+		case toks[i+1].is("DELETE"):
+			onDelete = action
+		case toks[i+1].is("UPDATE"):
+			onUpdate = action
+		default:
+			return onDelete, onUpdate, i
+		}
+		i = next
+	}
+	return onDelete, onUpdate, i
+}
+
+// nameList returns the identifiers of a comma-separated column list.
+func nameList(toks []token) []string {
+	var out []string
+	for _, part := range splitTokens(toks) {
+		if len(part) > 0 && part[0].isName() {
+			out = append(out, part[0].name())
+		}
+	}
+	return out
+}
+
+// parenList returns the names inside the first parenthesised group at or
+// after toks[i], and the index after its closing paren.
+func parenList(toks []token, i int) ([]string, int) {
+	for i < len(toks) && !toks[i].isPunct("(") {
+		i++
+	}
+	end := matchParen(toks, i)
+	if end == -1 {
+		return nil, len(toks)
+	}
+	return nameList(toks[i+1 : end]), end + 1
+}
+
+// applyTableConstraint applies a table-level PRIMARY KEY, FOREIGN KEY,
+// UNIQUE or CHECK clause, optionally introduced by CONSTRAINT name.
+func applyTableConstraint(t *Table, el []token) {
+	i := 0
+	if el[0].is("CONSTRAINT") {
+		i = 2
+	}
+	if i >= len(el) {
+		return
+	}
+	switch {
+	case el[i].is("PRIMARY"):
+		names, _ := parenList(el, i)
+		for _, name := range names {
+			if c := t.Column(name); c != nil {
+				c.PrimaryKey = true
+			}
+		}
+	case el[i].is("FOREIGN"):
+		cols, next := parenList(el, i)
+		next = skipWords(el, next, "REFERENCES")
+		fks, _ := parseReferences(el, next)
+		for k, name := range cols {
+			c := t.Column(name)
+			if c == nil {
+				continue
+			}
+			switch {
+			case k < len(fks):
+				c.ForeignKey = fks[k]
+			case len(fks) == 1:
+				c.ForeignKey = &ForeignKey{RefTable: fks[0].RefTable, OnDelete: fks[0].OnDelete, OnUpdate: fks[0].OnUpdate}
+			}
+		}
+	case el[i].is("UNIQUE"):
+		names, _ := parenList(el, i)
+		t.markUnique(names)
+	case el[i].is("CHECK") && i+1 < len(el) && el[i+1].isPunct("("):
+		end := matchParen(el, i+1)
+		if end == -1 {
+			return
+		}
+		isCol := func(name string) bool { return t.Column(name) != nil }
+		if name, vals := checkInValues(el[i+2:end], isCol); name != "" && len(vals) > 0 {
+			t.Column(name).CheckIn = vals
+		}
+		if name := checkEmailColumn(el[i+2:end], isCol); name != "" {
+			t.Column(name).CheckEmail = true
+		}
+	}
+}
+
+// checkEmailColumn finds an email-shaped pattern match inside a CHECK body,
+// such as "email LIKE '%_@_%'" or "email ~* '^[^@]+@[^@]+$'", and returns
+// the column it tests.
+func checkEmailColumn(toks []token, isCol func(string) bool) string {
+	for i := 1; i+1 < len(toks); i++ {
+		t := toks[i]
+		match := t.is("LIKE") || t.is("ILIKE") || t.is("REGEXP") || t.is("SIMILAR") ||
+			t.kind == tokOp && strings.HasPrefix(t.text, "~")
+		if !match {
 			continue
 		}
-		// CONSTRAINT name ... or column def
-		if strings.HasPrefix(strings.ToUpper(p), "CONSTRAINT ") {
-			// Parse FK or CHECK that references our columns
-			applyTableConstraint(t, p)
+		pat := i + 1
+		if t.is("SIMILAR") && pat < len(toks) && toks[pat].is("TO") {
+			pat++
+		}
+		if pat >= len(toks) || toks[pat].kind != tokString || !strings.Contains(toks[pat].value(), "@") {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if j > 0 && toks[j-1].kind == tokOp && toks[j-1].text == "::" {
+				j-- // skip the cast in (email)::text
+				continue
+			}
+			if toks[j].isName() && isCol(toks[j].name()) {
+				return toks[j].name()
+			}
+			if !toks[j].isPunct("(") && !toks[j].isPunct(")") && toks[j].kind != tokOp {
+				break
+			}
+		}
+	}
+	return ""
+}
+
+// checkInValues finds "col IN ('a', 'b')" or pg_dump's
+// "(col)::text = ANY (ARRAY['a'::text, 'b'::text])" inside a CHECK body and
+// returns the column name and the allowed string values. isCol decides
+// which identifiers count as column names.
+func checkInValues(toks []token, isCol func(string) bool) (string, []string) {
+	for i, t := range toks {
+		if !(t.is("IN") || t.is("ANY")) {
 			continue
 		}
-		if strings.HasPrefix(strings.ToUpper(p), "PRIMARY KEY") {
-			applyPrimaryKey(t, p)
+		// Column: nearest preceding name that is a column.
+		name := ""
+		for j := i - 1; j >= 0; j-- {
+			if toks[j].is("AND") || toks[j].is("OR") {
+				break
+			}
+			if toks[j].isName() && isCol(toks[j].name()) && !toks[j].is("ARRAY") {
+				name = toks[j].name()
+				break
+			}
+		}
+		open := i + 1
+		if open >= len(toks) || !toks[open].isPunct("(") {
 			continue
 		}
-		if strings.HasPrefix(strings.ToUpper(p), "FOREIGN KEY") {
-			applyForeignKey(t, p)
+		end := matchParen(toks, open)
+		if end == -1 {
 			continue
 		}
-		// Column definition
-		col := parseColumnDef(p)
-		if col != nil {
-			t.Columns = append(t.Columns, *col)
+		var vals []string
+		for _, v := range toks[open+1 : end] {
+			if v.kind == tokString {
+				vals = append(vals, v.value())
+			}
+		}
+		if len(vals) > 0 {
+			return name, vals
 		}
 	}
-	return t
+	return "", nil
 }
 
-func splitTopLevel(s string, sep byte) []string {
-	var parts []string
-	var cur strings.Builder
-	depth := 0
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		switch c {
-		case '(':
-			depth++
-			cur.WriteByte(c)
-		case ')':
-			depth--
-			cur.WriteByte(c)
-		case sep:
-			if depth == 0 {
-				parts = append(parts, cur.String())
-				cur.Reset()
-			} else {
-				cur.WriteByte(c)
+// resolveSchemaRefs points an unqualified reference from a table in
+// another schema at the table of that name in the same schema, when there
+// is no unqualified one: the schema file most likely set the search path.
+func resolveSchemaRefs(tables []*Table) {
+	for _, t := range tables {
+		if t.Schema == "" {
+			continue
+		}
+		for _, c := range t.FKColumns() {
+			fk := c.ForeignKey
+			if qualifier, _ := SplitName(fk.RefTable); qualifier != "" || TableByName(tables, fk.RefTable) != nil {
+				continue
+			}
+			if TableByName(tables, t.Schema+"."+fk.RefTable) != nil {
+				fk.RefTable = t.Schema + "." + fk.RefTable
 			}
-		default:
-			cur.WriteByte(c)
 		}
 	}
-	if cur.Len() > 0 {
-		parts = append(parts, cur.String())
-	}
-	return parts
 }
 
-var colDefRe = regexp.MustCompile(`(?i)^["']?(\w+)["']?\s+(\w+)(\s*\([^)]*\))?`)
-
-func parseColumnDef(s string) *Column {
-	col := &Column{}
-	upper := strings.ToUpper(s)
-	col.NotNull = strings.Contains(upper, "NOT NULL")
-	col.Unique = strings.Contains(upper, "UNIQUE")
-	// PRIMARY KEY in column def
-	if strings.Contains(upper, "PRIMARY KEY") {
-		col.PrimaryKey = true
-	}
-	// CHECK (col IN ('a','b'))
-	checkIn := regexp.MustCompile(`(?i)CHECK\s*\(\s*\w+\s+IN\s*\(([^)]+)\)`)
-	if m := checkIn.FindStringSubmatch(s); len(m) > 1 {
-		col.CheckIn = parseQuotedList(m[1])
-	}
-	// REFERENCES other(col)
-	refRe := regexp.MustCompile(`(?i)REFERENCES\s+["']?(\w+)["']?\s*\(\s*["']?(\w+)["']?\s*\)`)
-	if m := refRe.FindStringSubmatch(s); len(m) >= 3 {
-		col.ForeignKey = &ForeignKey{RefTable: m[1], RefColumn: m[2]}
-	}
-	// Name and type
-	idx := colDefRe.FindStringSubmatchIndex(s)
-	if idx == nil {
-		return nil
-	}
-	col.Name = s[idx[2]:idx[3]]
-	typePart := strings.TrimSpace(s[idx[4]:idx[5]])
-	if len(idx) > 6 && idx[6] >= 0 {
-		typePart += strings.TrimSpace(s[idx[6]:idx[7]])
+// resolveImplicitRefs fills in RefColumn for "REFERENCES users" with no
+// column list, which points at the referenced table's primary key.
+func resolveImplicitRefs(tables []*Table) {
+	for _, t := range tables {
+		for i := range t.Columns {
+			fk := t.Columns[i].ForeignKey
+			if fk == nil || fk.RefColumn != "" {
+				continue
+			}
+			fk.RefColumn = "id"
+			if ref := TableByName(tables, fk.RefTable); ref != nil {
+				for _, c := range ref.Columns {
+					if c.PrimaryKey {
+						fk.RefColumn = c.Name
+						break
+					}
+				}
+			}
+		}
 	}
-	col.Type = normalizeType(typePart)
-	return col
 }
 
-func parseQuotedList(s string) []string {
-	var out []string
-	// 'a', 'b', 'c'
-	re := regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
-	for _, m := range re.FindAllStringSubmatch(s, -1) {
-		if m[1] != "" {
-			out = append(out, m[1])
-		} else if m[2] != "" {
-			out = append(out, m[2])
+func hasWord(words []string, w string) bool {
+	for _, x := range words {
+		if x == w {
+			return true
 		}
 	}
-	return out
+	return false
 }
 
 func normalizeType(t string) string {
 	t = strings.ToLower(strings.TrimSpace(t))
+	if t == "bytea" || strings.Contains(t, "blob") || strings.HasPrefix(t, "binary") || strings.HasPrefix(t, "varbinary") {
+		return "binary"
+	}
 	// varchar(n), char(n) -> text
 	if strings.HasPrefix(t, "varchar") || strings.HasPrefix(t, "char") || t == "text" || strings.HasPrefix(t, "character") {
 		return "text"
@@ -214,47 +559,6 @@ func normalizeType(t string) string {
 	return "text"
 }
 
-func applyTableConstraint(t *Table, s string) {
-	// FOREIGN KEY (col) REFERENCES other(col)
-	fkRe := regexp.MustCompile(`(?i)FOREIGN\s+KEY\s*\(\s*["']?(\w+)["']?\s*\)\s+REFERENCES\s+["']?(\w+)["']?\s*\(\s*["']?(\w+)["']?\s*\)`)
-	if m := fkRe.FindStringSubmatch(s); len(m) >= 4 {
-		for i := range t.Columns {
-			if t.Columns[i].Name == m[1] {
-				t.Columns[i].ForeignKey = &ForeignKey{RefTable: m[2], RefColumn: m[3]}
-				break
-			}
-		}
-	}
-}
-
-func applyPrimaryKey(t *Table, s string) {
-	// PRIMARY KEY (col) or PRIMARY KEY (a, b)
-	re := regexp.MustCompile(`(?i)PRIMARY\s+KEY\s*\(\s*([^)]+)\)`)
-	if m := re.FindStringSubmatch(s); len(m) > 1 {
-		for _, part := range strings.Split(m[1], ",") {
-			name := strings.TrimSpace(part)
-			for i := range t.Columns {
-				if t.Columns[i].Name == name {
-					t.Columns[i].PrimaryKey = true
-					break
-				}
-			}
-		}
-	}
-}
-
-func applyForeignKey(t *Table, s string) {
-	fkRe := regexp.MustCompile(`(?i)FOREIGN\s+KEY\s*\(\s*["']?(\w+)["']?\s*\)\s+REFERENCES\s+["']?(\w+)["']?\s*\(\s*["']?(\w+)["']?\s*\)`)
-	if m := fkRe.FindStringSubmatch(s); len(m) >= 4 {
-		for i := range t.Columns {
-			if t.Columns[i].Name == m[1] {
-				t.Columns[i].ForeignKey = &ForeignKey{RefTable: m[2], RefColumn: m[3]}
-				break
-			}
-		}
-	}
-}
-
 // topologicalSort returns tables in insert order (dependencies first).
 func topologicalSort(tables []*Table) []*Table {
 	byName := make(map[string]*Table)
@@ -291,7 +595,3 @@ func TableByName(tables []*Table, name string) *Table {
 	}
 	return nil
 }
-
-
-
-
@@ -1,7 +1,9 @@
 package schema
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestParseFile(t *testing.T) {
@@ -43,6 +45,437 @@ CREATE TABLE orders (
 	}
 }
 
+func TestParseFileToSchemaViews(t *testing.T) {
+	sql := `
+CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT);
+CREATE VIEW active_users AS SELECT id, name FROM users WHERE name <> '';
+CREATE MATERIALIZED VIEW user_stats AS
+  SELECT u.id, count(*) FROM users u JOIN active_users a ON a.id = u.id GROUP BY u.id;
+CREATE TABLE orders (id SERIAL PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+`
+	s, err := ParseFileToSchema(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(s.Tables))
+	}
+	if len(s.Views) != 2 {
+		t.Fatalf("expected 2 views, got %d", len(s.Views))
+	}
+	mv := s.MaterializedViews()
+	if len(mv) != 1 || mv[0].Name != "user_stats" {
+		t.Fatalf("expected user_stats materialized view, got %v", mv)
+	}
+	if len(mv[0].DependsOn) != 2 || mv[0].DependsOn[0] != "users" || mv[0].DependsOn[1] != "active_users" {
+		t.Errorf("user_stats dependencies: %v", mv[0].DependsOn)
+	}
+}
 
+func TestParseUniqueIndexes(t *testing.T) {
+	sql := `
+CREATE TABLE users (
+  id SERIAL PRIMARY KEY,
+  email TEXT NOT NULL,
+  org_id INTEGER,
+  handle TEXT,
+  UNIQUE (org_id, handle)
+);
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
+CREATE INDEX users_org_idx ON users (org_id);
+`
+	tables, err := ParseFile(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := tables[0]
+	if len(u.Columns) != 4 {
+		t.Fatalf("expected 4 columns, got %d", len(u.Columns))
+	}
+	if !u.Column("email").Unique {
+		t.Errorf("email should be unique via index")
+	}
+	if u.Column("org_id").Unique {
+		t.Errorf("org_id has a non-unique index only")
+	}
+	if len(u.UniqueTogether) != 1 || len(u.UniqueTogether[0]) != 2 {
+		t.Errorf("expected (org_id, handle) unique set, got %v", u.UniqueTogether)
+	}
+}
 
+func TestParseQuotedIdentifiers(t *testing.T) {
+	sql := `
+CREATE TABLE "Customers" (
+  "Id" SERIAL PRIMARY KEY,
+  "Full Name" VARCHAR(100) NOT NULL
+);
+CREATE TABLE public."Order Items" (
+  id SERIAL PRIMARY KEY,
+  "Customer Id" INTEGER NOT NULL REFERENCES "Customers"("Id"),
+  "Unit Price" DECIMAL(10,2)
+);
+`
+	tables, err := ParseFile(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(tables))
+	}
+	if tables[0].Name != "Customers" || tables[1].Name != "Order Items" {
+		t.Fatalf("unexpected table names: %q, %q", tables[0].Name, tables[1].Name)
+	}
+	items := tables[1]
+	fk := items.Column("Customer Id")
+	if fk == nil || fk.ForeignKey == nil || fk.ForeignKey.RefTable != "Customers" || fk.ForeignKey.RefColumn != "Id" {
+		t.Errorf("Customer Id should reference Customers.Id, got %+v", fk)
+	}
+	if items.Column("Unit Price") == nil || items.Column("Unit Price").Type != "decimal" {
+		t.Errorf("Unit Price should be a decimal column")
+	}
+}
+
+func TestParseIdentifierCase(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE Users (ID SERIAL PRIMARY KEY, "Display Name" TEXT);
+CREATE TABLE posts (id SERIAL PRIMARY KEY, Author_ID INTEGER REFERENCES users(id));`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, posts := TableByName(tables, "users"), TableByName(tables, "posts")
+	if users == nil || posts == nil {
+		t.Fatalf("unquoted names should be folded to lower case, got %q, %q", tables[0].Name, tables[1].Name)
+	}
+	if users.Column("id") == nil || users.Column("Display Name") == nil {
+		t.Errorf("columns: got %+v", users.Columns)
+	}
+	fk := posts.Column("author_id")
+	if fk == nil || fk.ForeignKey == nil || fk.ForeignKey.RefTable != "users" {
+		t.Errorf("author_id should reference users, got %+v", fk)
+	}
+}
+
+func TestParseSchemaQualifiedNames(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE a.users (id SERIAL PRIMARY KEY);
+CREATE TABLE b.users (id SERIAL PRIMARY KEY);
+CREATE TABLE billing."Order Items" (id SERIAL PRIMARY KEY, user_id INTEGER REFERENCES b.users(id));
+CREATE TABLE billing.refunds (id SERIAL PRIMARY KEY, item_id INTEGER REFERENCES "Order Items"(id));`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 4 || TableByName(tables, "a.users") == nil || TableByName(tables, "b.users") == nil {
+		t.Fatalf("a.users and b.users should stay apart, got %d tables", len(tables))
+	}
+	items := TableByName(tables, "billing.Order Items")
+	if items == nil || items.Schema != "billing" {
+		t.Fatalf("billing.\"Order Items\" should keep its schema, got %+v", items)
+	}
+	if !strings.HasPrefix(items.DDL, `CREATE TABLE billing."Order Items" (`) {
+		t.Errorf("DDL: got %s", items.DDL)
+	}
+	if got := QuoteTable(items.Name); got != `"billing"."Order Items"` {
+		t.Errorf("QuoteTable: got %s", got)
+	}
+	if fk := items.Column("user_id").ForeignKey; fk.RefTable != "b.users" {
+		t.Errorf("user_id should reference b.users, got %s", fk.RefTable)
+	}
+	// Unqualified, it is the table in the referencing table's schema.
+	if fk := TableByName(tables, "billing.refunds").Column("item_id").ForeignKey; fk.RefTable != "billing.Order Items" {
+		t.Errorf("item_id should reference billing.Order Items, got %s", fk.RefTable)
+	}
+}
+
+func TestParseRealWorldDump(t *testing.T) {
+	sql := `
+/* CREATE TABLE ghost (id int); -- inside a block comment */
+CREATE FUNCTION touch() RETURNS trigger AS $body$
+BEGIN
+  -- CREATE TABLE not_a_table (a int);
+  NEW.updated_at := now();
+  RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+
+CREATE TABLE public.products (
+    id integer NOT NULL,
+    name character varying(100) DEFAULT 'Widget, (large)'::character varying NOT NULL,
+    status character varying(20) NOT NULL,
+    price numeric(10,2),
+    note text DEFAULT 'it''s; fine',
+    CONSTRAINT products_status_check CHECK (((status)::text = ANY ((ARRAY['draft'::character varying, 'live'::character varying])::text[])) AND (price > (0)::numeric)),
+    PRIMARY KEY (id)
+);
+CREATE TABLE reviews (
+  id INTEGER PRIMARY KEY,
+  product_id INTEGER REFERENCES products ON DELETE CASCADE,
+  body TEXT
+);
+`
+	tables, err := ParseFile(sql)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(tables))
+	}
+	p := tables[0]
+	if p.Name != "products" || len(p.Columns) != 5 {
+		t.Fatalf("products: expected 5 columns, got %d (%s)", len(p.Columns), p.Name)
+	}
+	if c := p.Column("name"); c.SQLType != "character varying(100)" || !c.NotNull {
+		t.Errorf("name: got type %q notnull=%v", c.SQLType, c.NotNull)
+	}
+	if got := p.Column("status").CheckIn; len(got) != 2 || got[0] != "draft" || got[1] != "live" {
+		t.Errorf("status CheckIn from table CHECK: %v", got)
+	}
+	if !p.Column("id").PrimaryKey {
+		t.Errorf("id should be primary key via table constraint")
+	}
+	fk := tables[1].Column("product_id").ForeignKey
+	if fk == nil || fk.RefTable != "products" || fk.RefColumn != "id" || fk.OnDelete != ActionCascade {
+		t.Errorf("product_id should reference products.id ON DELETE CASCADE, got %+v", fk)
+	}
+}
 
+func TestParseUnterminatedString(t *testing.T) {
+	_, err := ParseFile("CREATE TABLE t (\n  a TEXT DEFAULT 'oops\n);")
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if perr.Line != 2 || perr.Col != 18 {
+		t.Errorf("expected line 2 col 18, got %d:%d", perr.Line, perr.Col)
+	}
+}
+
+func TestErrorFragmentKeepsUTF8(t *testing.T) {
+	// 'é' is two bytes, so the cut lands in the middle of one.
+	src := "xx" + strings.Repeat("é", 40)
+	perr := errorAt(src, "t", []token{{text: src, line: 1, col: 1}}, "bad")
+	if !utf8.ValidString(perr.Fragment) || !strings.HasSuffix(perr.Fragment, "é...") {
+		t.Errorf("fragment %q", perr.Fragment)
+	}
+}
+
+func TestParseLenientSkipsBrokenTables(t *testing.T) {
+	src := "CREATE TABLE a (id INT);\nCREATE TABLE b (\n  42 nonsense\n);\nCREATE TABLE c (id INT);"
+	if _, err := ParseSchema(src, ParseOptions{}); err == nil {
+		t.Fatal("expected strict parse to fail")
+	}
+	s, err := ParseSchema(src, ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("lenient parse: %v", err)
+	}
+	if len(s.Tables) != 2 || len(s.ParseErrors) != 1 {
+		t.Fatalf("expected 2 tables and 1 error, got %d and %d", len(s.Tables), len(s.ParseErrors))
+	}
+	if e := s.ParseErrors[0]; e.Line != 3 || e.Table != "b" {
+		t.Errorf("expected error at line 3 in table b, got %+v", e)
+	}
+	if c := s.TableMap["c"]; c == nil || c.Line != 5 {
+		t.Errorf("expected table c to start on line 5, got %+v", c)
+	}
+}
+
+func TestParseEmailCheck(t *testing.T) {
+	tables, err := ParseFile(`CREATE TABLE contacts (
+  id SERIAL PRIMARY KEY,
+  work TEXT CHECK (work LIKE '%_@_%'),
+  home TEXT,
+  note TEXT CHECK (note <> ''),
+  CONSTRAINT home_email CHECK ((home)::text ~* '^[^@]+@[^@]+$')
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := tables[0]
+	if !c.Column("work").CheckEmail || !c.Column("home").CheckEmail || c.Column("note").CheckEmail {
+		t.Errorf("CheckEmail: work=%v home=%v note=%v",
+			c.Column("work").CheckEmail, c.Column("home").CheckEmail, c.Column("note").CheckEmail)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE users (id INT PRIMARY KEY, team_id INT REFERENCES teams(id), manager_id INT REFERENCES users(id));
+CREATE TABLE teams (id INT PRIMARY KEY, owner_id INT REFERENCES users(id));
+CREATE TABLE posts (id INT PRIMARY KEY, author_id INT REFERENCES users(id));
+CREATE TABLE nodes (id INT PRIMARY KEY, parent_id INT REFERENCES nodes(id));`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Cycles(tables)
+	if len(got) != 2 || strings.Join(got[0], ",") != "nodes" || strings.Join(got[1], ",") != "teams,users" {
+		t.Errorf("unexpected cycles: %v", got)
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE teams (id INT PRIMARY KEY);
+CREATE TABLE users (id INT PRIMARY KEY, team_id INT REFERENCES teams(id), manager_id INT REFERENCES users(id));
+CREATE TABLE tags (id INT PRIMARY KEY);
+CREATE TABLE posts (id INT PRIMARY KEY, author_id INT REFERENCES users(id));
+CREATE TABLE post_tags (post_id INT REFERENCES posts(id), tag_id INT REFERENCES tags(id));
+CREATE TABLE comments (id INT PRIMARY KEY, post_id INT REFERENCES posts(id));`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := func(ts []*Table) string {
+		var out []string
+		for _, t := range ts {
+			out = append(out, t.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := names(Descendants(tables, []string{"posts"})); got != "post_tags,comments" {
+		t.Errorf("descendants of posts: %s", got)
+	}
+	if got := names(Descendants(tables, []string{"teams"})); got != "users,posts,post_tags,comments" {
+		t.Errorf("descendants of teams: %s", got)
+	}
+	if got := names(Descendants(tables, []string{"comments"})); got != "" {
+		t.Errorf("descendants of comments: %s", got)
+	}
+}
+
+func TestParseReferentialActions(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE users (id INT PRIMARY KEY);
+CREATE TABLE posts (
+  id INT PRIMARY KEY,
+  author_id INT REFERENCES users(id) ON UPDATE CASCADE ON DELETE SET NULL,
+  editor_id INT NOT NULL,
+  reviewer_id INT REFERENCES users ON DELETE NO ACTION DEFERRABLE,
+  CONSTRAINT fk_editor FOREIGN KEY (editor_id) REFERENCES users (id) ON DELETE RESTRICT
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := tables[1]
+	for col, want := range map[string][2]string{
+		"author_id":   {ActionSetNull, ActionCascade},
+		"editor_id":   {ActionRestrict, ""},
+		"reviewer_id": {ActionNoAction, ""},
+	} {
+		fk := posts.Column(col).ForeignKey
+		if fk == nil || fk.OnDelete != want[0] || fk.OnUpdate != want[1] {
+			t.Errorf("%s: got %+v, want ON DELETE %q ON UPDATE %q", col, fk, want[0], want[1])
+		}
+	}
+}
+
+func TestPlanDelete(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE teams (id INT PRIMARY KEY, owner_id INT REFERENCES users(id));
+CREATE TABLE users (id INT PRIMARY KEY, team_id INT NOT NULL REFERENCES teams(id), manager_id INT REFERENCES users(id) ON DELETE RESTRICT);
+CREATE TABLE posts (id INT PRIMARY KEY, author_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE);
+CREATE TABLE tags (id INT PRIMARY KEY);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanDelete(tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, tbl := range plan.Order {
+		order = append(order, tbl.Name)
+	}
+	// teams.owner_id is the only nullable reference in the teams/users cycle.
+	if got := strings.Join(order, ","); got != "tags,posts,users,teams" {
+		t.Errorf("order %s, want tags,posts,users,teams", got)
+	}
+	var unlink []string
+	for _, e := range plan.Unlink {
+		unlink = append(unlink, e.From+"."+e.Column)
+	}
+	if got := strings.Join(unlink, ","); got != "users.manager_id,teams.owner_id" {
+		t.Errorf("unlink %s, want users.manager_id,teams.owner_id", got)
+	}
+	if len(plan.Cascades) != 1 || plan.Cascades[0].From != "posts" {
+		t.Errorf("cascades %+v, want posts.author_id", plan.Cascades)
+	}
+
+	TableByName(tables, "teams").Column("owner_id").NotNull = true
+	if _, err := PlanDelete(tables); err == nil || !strings.Contains(err.Error(), "NOT NULL") {
+		t.Errorf("expected a NOT NULL cycle error, got %v", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tables, err := ParseFile(`
+CREATE TABLE order_statuses (id SERIAL PRIMARY KEY, code TEXT UNIQUE, label TEXT);
+CREATE TABLE tags (id SERIAL PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+CREATE TABLE users (id SERIAL PRIMARY KEY, email TEXT UNIQUE, manager_id INT REFERENCES users(id), team_id INT REFERENCES teams(id));
+CREATE TABLE teams (id SERIAL PRIMARY KEY, name TEXT);
+CREATE TABLE posts (id SERIAL PRIMARY KEY, author_id INT REFERENCES users(id), title TEXT, body TEXT, status_id INT REFERENCES order_statuses(id));
+CREATE TABLE post_tags (post_id INT REFERENCES posts(id), tag_id INT REFERENCES tags(id), created_at TIMESTAMP, PRIMARY KEY (post_id, tag_id));
+CREATE TABLE audit_logs (id SERIAL PRIMARY KEY, user_id INT REFERENCES users(id), action TEXT, details TEXT, created_at TIMESTAMPTZ);
+CREATE TABLE page_views (id BIGSERIAL PRIMARY KEY, post_id INT REFERENCES posts(id), viewed_at TIMESTAMP, occurred_at TIMESTAMP);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Archetype{
+		"order_statuses": ArchetypeLookup,
+		"tags":           ArchetypeLookup,
+		"users":          ArchetypeEntity,
+		"teams":          ArchetypeLookup,
+		"posts":          ArchetypeEntity,
+		"post_tags":      ArchetypeJoin,
+		"audit_logs":     ArchetypeEvent,
+		"page_views":     ArchetypeEvent,
+	}
+	for name, a := range want {
+		if got := Classify(TableByName(tables, name), tables); got != a {
+			t.Errorf("%s: %s, want %s", name, got, a)
+		}
+	}
+	if cols := TableByName(tables, "post_tags").NonAutoColumns(); len(cols) != 3 {
+		t.Errorf("a join table's key columns must be generated, got %d columns", len(cols))
+	}
+}
+
+func TestParseColumnDetails(t *testing.T) {
+	tables, err := ParseFile(`CREATE TABLE invoices (
+  id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+  seq INTEGER DEFAULT nextval('invoices_seq'::regclass) NOT NULL,
+  code VARCHAR(12) NOT NULL,
+  total NUMERIC(10, 2) DEFAULT 0 NOT NULL,
+  rate DECIMAL(5) DEFAULT NULL,
+  status TEXT DEFAULT 'draft' CHECK (status IN ('draft', 'sent')),
+  created_at TIMESTAMP DEFAULT (now() AT TIME ZONE 'utc') NOT NULL
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv := tables[0]
+	if !inv.Column("id").Identity || !inv.Column("seq").Identity || inv.Column("code").Identity {
+		t.Errorf("Identity: id=%v seq=%v code=%v", inv.Column("id").Identity, inv.Column("seq").Identity, inv.Column("code").Identity)
+	}
+	for col, want := range map[string]string{"total": "0", "rate": "NULL", "status": "'draft'", "code": "", "created_at": "(now() AT TIME ZONE 'utc')"} {
+		if got := inv.Column(col).Default; got != want {
+			t.Errorf("%s default = %q, want %q", col, got, want)
+		}
+	}
+	if !inv.Column("total").NotNull || len(inv.Column("status").CheckIn) != 2 {
+		t.Error("constraints after DEFAULT were not parsed")
+	}
+	if d, s := inv.Column("total").Precision(); d != 10 || s != 2 {
+		t.Errorf("total precision = %d, %d", d, s)
+	}
+	if d, s := inv.Column("rate").Precision(); d != 5 || s != 0 {
+		t.Errorf("rate precision = %d, %d", d, s)
+	}
+	if n := inv.Column("code").MaxLength(); n != 12 {
+		t.Errorf("code MaxLength = %d", n)
+	}
+	var names []string
+	for _, c := range inv.NonAutoColumns() {
+		names = append(names, c.Name)
+	}
+	if strings.Join(names, ",") != "code,total,rate,status,created_at" {
+		t.Errorf("NonAutoColumns = %v", names)
+	}
+}
@@ -1,11 +1,19 @@
 package schema
 
+import (
+	"regexp"
+	"strconv"
+)
+
 // Schema wraps a slice of tables for historical API compatibility.
 // prompt.go uses *schema.Schema to pass the full set of tables.
 type Schema struct {
 	Tables      []*Table
 	InsertOrder []string          // Table names in dependency order
 	TableMap    map[string]*Table // Quick lookup by table name
+	Views       []View            // parsed but never seeded
+	Triggers    []Trigger
+	ParseErrors ParseErrors // problems skipped in lenient mode
 }
 
 // NewSchema creates a Schema from a list of tables (already in dependency order).
@@ -25,30 +33,80 @@ func NewSchema(tables []*Table) *Schema {
 
 // Table represents a parsed database table.
 type Table struct {
-	Name    string
-	Columns []Column
+	Name           string // "billing.order_items" when qualified with a schema, see SplitName
+	Schema         string // the schema it is qualified with, or ""
+	Columns        []Column
+	UniqueTogether [][]string // composite UNIQUE constraints and unique indexes
+	DDL            string     // normalized CREATE TABLE statement from the schema file
+	Line           int        // line of the CREATE TABLE statement in the schema file
+}
+
+// Column returns the column with the given name, or nil.
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
 }
 
 // Column represents a table column with constraints.
 type Column struct {
 	Name       string
-	Type       string   // normalized: integer, text, decimal, timestamp, boolean
+	Type       string   // normalized: integer, text, decimal, timestamp, boolean, binary
+	SQLType    string   // declared type, lowercased: varchar(255), jsonb, timestamptz
 	NotNull    bool
 	Unique     bool
 	PrimaryKey bool
 	CheckIn    []string // allowed values from CHECK (col IN (...))
+	CheckEmail bool     // a CHECK matches the column against an email pattern
+	Default    string   // DEFAULT expression as written, e.g. now() or 'active'; "" when none
+	Identity   bool     // the database fills it: SERIAL, IDENTITY, AUTOINCREMENT or a nextval() default
 	ForeignKey *ForeignKey
 }
 
-// DataType is an alias accessor for Type, used by prompt.go.
-func (c Column) GetDataType() string { return c.Type }
+var lengthRe = regexp.MustCompile(`^(?:var)?char(?:acter)?(?: varying)?\((\d+)\)`)
+
+// MaxLength returns the declared length of a varchar(n) or char(n)
+// column, or 0.
+func (c Column) MaxLength() int {
+	if m := lengthRe.FindStringSubmatch(c.SQLType); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+var precisionRe = regexp.MustCompile(`^(?:numeric|decimal|number)\s*\((\d+)(?:\s*,\s*(\d+))?\)`)
+
+// Precision returns the declared digits and digits after the point of a
+// numeric(p, s) or decimal(p, s) column, or 0, 0.
+func (c Column) Precision() (digits, scale int) {
+	if m := precisionRe.FindStringSubmatch(c.SQLType); m != nil {
+		digits, _ = strconv.Atoi(m[1])
+		scale, _ = strconv.Atoi(m[2])
+	}
+	return digits, scale
+}
 
 // ForeignKey describes a reference to another table.
 type ForeignKey struct {
 	RefTable  string
 	RefColumn string
+	OnDelete  string // referential action, e.g. CASCADE or SET NULL; "" when not declared (NO ACTION)
+	OnUpdate  string
 }
 
+// Referential actions, as found in ForeignKey.OnDelete and OnUpdate.
+const (
+	ActionCascade    = "CASCADE"
+	ActionSetNull    = "SET NULL"
+	ActionSetDefault = "SET DEFAULT"
+	ActionRestrict   = "RESTRICT"
+	ActionNoAction   = "NO ACTION"
+)
+
 // DependsOn returns table names this table's FKs reference (for topological sort).
 func (t *Table) DependsOn() []string {
 	var out []string
@@ -63,11 +121,12 @@ func (t *Table) DependsOn() []string {
 }
 
 // NonAutoColumns returns all columns that are not auto-generated serial PKs.
-// Columns named "id" with type "integer" and PrimaryKey=true are skipped.
+// Integer primary key columns and identity columns are skipped, unless they
+// are also foreign keys, as in a join table keyed by the pair it links.
 func (t *Table) NonAutoColumns() []Column {
 	var out []Column
 	for _, c := range t.Columns {
-		if c.PrimaryKey && c.Type == "integer" {
+		if c.auto() {
 			continue
 		}
 		out = append(out, c)
@@ -78,6 +137,25 @@ func (t *Table) NonAutoColumns() []Column {
 	return out
 }
 
+// AutoColumns returns the columns NonAutoColumns skips, which the
+// database fills.
+func (t *Table) AutoColumns() []Column {
+	if len(t.NonAutoColumns()) == len(t.Columns) {
+		return nil
+	}
+	var out []Column
+	for _, c := range t.Columns {
+		if c.auto() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (c Column) auto() bool {
+	return (c.Identity || c.PrimaryKey && c.Type == "integer") && c.ForeignKey == nil
+}
+
 // FKColumns returns columns that have foreign key constraints.
 func (t *Table) FKColumns() []Column {
 	var out []Column
@@ -0,0 +1,62 @@
+package schema
+
+import (
+	"strings"
+)
+
+// Trigger is a CREATE TRIGGER statement. Triggers may fire while seed rows
+// are inserted, so they are reported before seeding.
+type Trigger struct {
+	Name   string
+	Table  string
+	Timing string // BEFORE, AFTER or INSTEAD OF
+	Events string // e.g. "INSERT OR UPDATE"
+}
+
+// parseTrigger parses CREATE TRIGGER [IF NOT EXISTS] name timing events ON
+// table. next is the index just after the TRIGGER keyword.
+func parseTrigger(st statement, next int) (Trigger, bool) {
+	toks := st.toks
+	i := skipWords(toks, next, "IF", "NOT", "EXISTS")
+	name, _, i := qualifiedName(toks, i)
+	if name == "" || i >= len(toks) {
+		return Trigger{}, false
+	}
+	tr := Trigger{Name: name}
+	switch {
+	case toks[i].is("BEFORE"), toks[i].is("AFTER"):
+		tr.Timing = strings.ToUpper(toks[i].text)
+		i++
+	case toks[i].is("INSTEAD") && i+1 < len(toks) && toks[i+1].is("OF"):
+		tr.Timing = "INSTEAD OF"
+		i += 2
+	default:
+		return Trigger{}, false
+	}
+	var events []string
+	for ; i < len(toks) && !toks[i].is("ON"); i++ {
+		events = append(events, strings.ToUpper(toks[i].text))
+	}
+	if i >= len(toks) {
+		return Trigger{}, false
+	}
+	tr.Events = strings.Join(events, " ")
+	tr.Table, _, _ = qualifiedName(toks, i+1)
+	return tr, tr.Table != ""
+}
+
+// FiresOnInsert reports whether the trigger runs for INSERT statements.
+func (tr Trigger) FiresOnInsert() bool {
+	return strings.Contains(tr.Events, "INSERT")
+}
+
+// InsertTriggers returns triggers that fire on INSERT into any table.
+func (s *Schema) InsertTriggers() []Trigger {
+	var out []Trigger
+	for _, tr := range s.Triggers {
+		if tr.FiresOnInsert() {
+			out = append(out, tr)
+		}
+	}
+	return out
+}
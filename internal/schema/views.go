@@ -0,0 +1,42 @@
+package schema
+
+// View is a CREATE VIEW or CREATE MATERIALIZED VIEW statement. Views are
+// never seeded; they are kept so materialized views can be refreshed after
+// their base tables are filled.
+type View struct {
+	Name         string
+	Materialized bool
+	DependsOn    []string // tables and views named in FROM / JOIN clauses
+}
+
+// parseView parses CREATE [MATERIALIZED] VIEW [IF NOT EXISTS] name ... AS
+// select. next is the index just after the VIEW keyword.
+func parseView(st statement, next int, materialized bool) View {
+	toks := st.toks
+	i := skipWords(toks, next, "IF", "NOT", "EXISTS")
+	name, _, i := qualifiedName(toks, i)
+	v := View{Name: name, Materialized: materialized}
+	seen := make(map[string]bool)
+	for ; i < len(toks); i++ {
+		if !(toks[i].is("FROM") || toks[i].is("JOIN")) {
+			continue
+		}
+		dep, _, _ := qualifiedName(toks, i+1)
+		if dep != "" && !seen[dep] {
+			seen[dep] = true
+			v.DependsOn = append(v.DependsOn, dep)
+		}
+	}
+	return v
+}
+
+// MaterializedViews returns the materialized views in declaration order.
+func (s *Schema) MaterializedViews() []View {
+	var out []View
+	for _, v := range s.Views {
+		if v.Materialized {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,436 @@
+// Package server is the HTTP API of the serve command, so web frontends
+// and internal platforms can drive seeding without shelling out to the
+// CLI:
+//
+//	POST /preview   {"schema": "CREATE TABLE ...", "table": "users", "rows": 5}
+//	POST /validate  {"schema": "CREATE TABLE ...", "rows": 10}
+//	POST /seed      {"schema": "CREATE TABLE ...", "rows": 100}
+//
+// Bodies are a JSON Request, sent as application/json. The answer is one JSON object: a Preview, or
+// the run's pipeline.Report for /validate and /seed. A client that sends
+// "Accept: text/event-stream" gets server-sent events instead: "progress"
+// events (see Progress) while tables are generated and inserted, then one
+// "result" event with that object or an "error" event.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// MaxBodyBytes caps a request body.
+const MaxBodyBytes = 10 << 20
+
+// Default rows per table, as in the preview, validate and seed commands.
+const (
+	DefaultPreviewRows  = 5
+	DefaultValidateRows = 10
+	DefaultSeedRows     = 100
+)
+
+// Options configures the server.
+type Options struct {
+	Config  generator.Config    // model, cache, prompt version and the rest; requests may change model, style and no_pii
+	Project *config.File        // seeddb.yaml for requests that bring no config; nil means none
+	DB      string              // the only connection /seed writes to
+	Token   string              // bearer token every request must send; empty accepts any request
+	Client  generator.LLMClient // nil means Ollama as configured in Config
+}
+
+// Request is the body of every endpoint. Fields an endpoint has no use
+// for are ignored.
+type Request struct {
+	Schema  string `json:"schema"` // CREATE TABLE statements
+	Config  string `json:"config"` // seeddb.yaml contents, replacing the server's; no hooks or {{ env }}, and the server's protect and limits stay
+	Table   string `json:"table"`  // preview: the table; seed: only this table
+	Rows    int    `json:"rows"`
+	Model   string `json:"model"`
+	Style   string `json:"style"`
+	Domain  string `json:"domain"`
+	NoPII   bool   `json:"no_pii"`
+	Lenient bool   `json:"lenient"`
+
+	// seed only
+	DB     string `json:"db"` // must be the server's DB when set
+	DryRun bool   `json:"dry_run"`
+	TopUp  bool   `json:"top_up"`
+	RunID  string `json:"run_id"` // tag for clean; default: a new one
+}
+
+// Preview is the answer of /preview.
+type Preview struct {
+	Table   string                   `json:"table"`
+	Columns []string                 `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+	Usage   pipeline.ReportUsage     `json:"usage"`
+}
+
+// Progress is the data of a "progress" event.
+type Progress struct {
+	Table     string `json:"table"`
+	Stage     string `json:"stage"` // generating, generated, inserting, inserted, skipped, failed or abandoned
+	Rows      int    `json:"rows"`  // generated so far
+	Requested int    `json:"requested,omitempty"`
+	Inserted  int    `json:"inserted,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+var stageNames = map[pipeline.Stage]string{
+	pipeline.StageGenerating: "generating",
+	pipeline.StageGenerated:  "generated",
+	pipeline.StageInserting:  "inserting",
+	pipeline.StageInserted:   "inserted",
+	pipeline.StageFailed:     "failed",
+	pipeline.StageSkipped:    "skipped",
+	pipeline.StageAbandoned:  "abandoned",
+}
+
+// requestError is an error with the HTTP status it is answered with.
+type requestError struct {
+	status int
+	err    error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+type server struct {
+	opts Options
+}
+
+// New returns the API's handler.
+func New(opts Options) http.Handler {
+	s := &server{opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/preview", s.handle(s.preview))
+	mux.HandleFunc("/validate", s.handle(s.validate))
+	mux.HandleFunc("/seed", s.handle(s.seed))
+	return mux
+}
+
+// endpoint does the work of one request and returns the object to answer
+// with. A non-nil result goes out even with an error, e.g. the report of
+// a failed run.
+type endpoint func(ctx context.Context, req Request, out *stream) (interface{}, error)
+
+func (s *server) handle(fn endpoint) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+			return
+		}
+		if s.opts.Token != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.opts.Token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+				return
+			}
+		}
+		if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("send the body as Content-Type: application/json"))
+			return
+		}
+		var req Request
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("request body: %w", err))
+			return
+		}
+
+		out := &stream{}
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			if f, ok := w.(http.Flusher); ok {
+				out.w, out.flush = w, f.Flush
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.WriteHeader(http.StatusOK)
+				f.Flush()
+			}
+		}
+		result, err := fn(r.Context(), req, out)
+		if out.w != nil {
+			if err != nil {
+				out.send("error", errorBody{Error: err.Error(), Result: result})
+			} else {
+				out.send("result", result)
+			}
+			return
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			var re *requestError
+			if errors.As(err, &re) {
+				status = re.status
+			}
+			if result != nil {
+				writeJSON(w, status, result)
+			} else {
+				writeError(w, status, err)
+			}
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+type errorBody struct {
+	Error  string      `json:"error"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// stream sends server-sent events, one at a time. Without a writer it
+// drops them: the client asked for a single answer.
+type stream struct {
+	mu    sync.Mutex
+	w     http.ResponseWriter
+	flush func()
+}
+
+func (s *stream) send(event string, v interface{}) {
+	if s.w == nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(errorBody{Error: err.Error()})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+	s.flush()
+}
+
+// progress sends a pipeline event as a "progress" event.
+func (s *stream) progress(ev pipeline.Event) {
+	p := Progress{Table: ev.Table, Stage: stageNames[ev.Stage], Rows: ev.Progress.Rows, Requested: ev.Progress.Requested}
+	if tr := ev.Result; tr != nil {
+		p.Rows, p.Inserted = tr.Rows, tr.Inserted
+		if tr.Generated != nil {
+			p.Requested = tr.Generated.Requested
+		}
+	}
+	if ev.Err != nil {
+		p.Error = ev.Err.Error()
+	}
+	s.send("progress", p)
+}
+
+// envCall matches a {{ env }} expression, which reads the environment.
+var envCall = regexp.MustCompile(`\{\{-?[^}]*\benv\b`)
+
+// checkRequestConfig refuses what a config sent by a client must not do
+// on the server: run commands (hooks) or read its environment ({{ env }}),
+// where secrets such as connection passwords live.
+func checkRequestConfig(f *config.File, raw string) error {
+	if len(f.Hooks) > 0 {
+		return errors.New("config: hooks run commands on the server and are not accepted in a request")
+	}
+	if envCall.MatchString(raw) {
+		return errors.New(`config: {{ env }} reads the server's environment and is not accepted in a request`)
+	}
+	return nil
+}
+
+// prepare parses the request's schema and builds its generator settings
+// and project config.
+func (s *server) prepare(req Request, defaultRows int) (*schema.Schema, generator.Config, *config.File, int, error) {
+	cfg := s.opts.Config
+	if req.Schema == "" {
+		return nil, cfg, nil, 0, badRequest("schema is required")
+	}
+	sch, err := schema.ParseSchema(req.Schema, schema.ParseOptions{Lenient: req.Lenient})
+	if err != nil {
+		return nil, cfg, nil, 0, badRequest("schema: %w", err)
+	}
+	project := s.opts.Project
+	if req.Config != "" {
+		if project, err = config.Parse([]byte(req.Config), "config"); err != nil {
+			return nil, cfg, nil, 0, badRequest("%w", err)
+		}
+		if err := checkRequestConfig(project, req.Config); err != nil {
+			return nil, cfg, nil, 0, &requestError{http.StatusForbidden, err}
+		}
+	}
+	if project == nil {
+		project = &config.File{}
+	}
+	project.Apply(&cfg)
+	if req.Domain != "" {
+		if cfg.Domain, err = generator.LookupDomain(req.Domain); err != nil {
+			return nil, cfg, nil, 0, badRequest("%w", err)
+		}
+	}
+	if req.Model != "" {
+		cfg.Model = req.Model
+	}
+	if req.Style != "" {
+		cfg.Style = generator.Style(req.Style)
+	}
+	cfg.NoPII = cfg.NoPII || req.NoPII
+	rows := req.Rows
+	if rows < 0 {
+		return nil, cfg, nil, 0, badRequest("rows must not be negative")
+	}
+	if rows == 0 {
+		rows = defaultRows
+	}
+	return sch, cfg, project, rows, nil
+}
+
+// guards returns the server's own project config, whose protection and
+// limits hold for every request: a request's config must not lift them.
+func (s *server) guards() *config.File {
+	if s.opts.Project == nil {
+		return &config.File{}
+	}
+	return s.opts.Project
+}
+
+func (s *server) preview(ctx context.Context, req Request, out *stream) (interface{}, error) {
+	sch, cfg, _, rows, err := s.prepare(req, DefaultPreviewRows)
+	if err != nil {
+		return nil, err
+	}
+	t := schema.TableByName(sch.Tables, req.Table)
+	if t == nil {
+		return nil, badRequest("table %q not found in schema", req.Table)
+	}
+	limits := pipeline.Options{Rows: rows, Limits: s.guards().Limits.Pipeline()}
+	if err := pipeline.CheckLimits(limits, []*schema.Table{t}); err != nil {
+		return nil, runError(err)
+	}
+	gen := generator.New(cfg)
+	if s.opts.Client != nil {
+		gen = generator.NewWithClient(cfg, s.opts.Client)
+	}
+	gen.OnProgress(func(p generator.Progress) {
+		out.progress(pipeline.Event{Table: p.Table, Stage: pipeline.StageGenerating, Progress: p})
+	})
+	result, err := gen.Generate(t, rows, sch, string(cfg.Style), nil)
+	if err != nil {
+		return nil, &requestError{http.StatusBadGateway, err}
+	}
+	p := &Preview{Table: t.Name, Columns: result.Columns, Rows: result.Rows,
+		Usage: pipeline.ReportUsage{Calls: result.Usage.Calls, PromptTokens: result.Usage.PromptTokens,
+			EvalTokens: result.Usage.EvalTokens, ModelSeconds: result.Usage.Duration.Seconds()}}
+	if p.Rows == nil {
+		p.Rows = []map[string]interface{}{}
+	}
+	return p, nil
+}
+
+func (s *server) validate(ctx context.Context, req Request, out *stream) (interface{}, error) {
+	sch, cfg, _, rows, err := s.prepare(req, DefaultValidateRows)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := pipeline.Run(ctx, pipeline.Options{Schema: sch, Rows: rows, Config: cfg, Client: s.opts.Client,
+		Limits: s.guards().Limits.Pipeline()}, out.progress)
+	return pipeline.NewReport(res, err, start), runError(err)
+}
+
+func (s *server) seed(ctx context.Context, req Request, out *stream) (interface{}, error) {
+	sch, cfg, project, rows, err := s.prepare(req, DefaultSeedRows)
+	if err != nil {
+		return nil, err
+	}
+	own := s.guards()
+	opts := pipeline.Options{Schema: sch, Rows: rows, Config: cfg, Client: s.opts.Client, TopUp: req.TopUp,
+		Limits: own.Limits.Pipeline(), Anchors: project.AnchorRows()}
+	if req.Table != "" {
+		t := schema.TableByName(sch.Tables, req.Table)
+		if t == nil {
+			return nil, badRequest("table %q not found in schema", req.Table)
+		}
+		opts.Tables = []*schema.Table{t}
+	}
+	if err := pipeline.CheckAnchors(opts.Anchors, sch); err != nil {
+		return nil, badRequest("%w", err)
+	}
+	if req.DB != "" && req.DB != s.opts.DB {
+		return nil, &requestError{http.StatusForbidden, errors.New("db: this server only seeds the database it was started with (serve --db)")}
+	}
+	conn := s.opts.DB
+	switch {
+	case req.DryRun && req.TopUp:
+		return nil, badRequest("top_up counts rows in the database and cannot be combined with dry_run")
+	case !req.DryRun && conn == "":
+		return nil, badRequest("the server has no database to seed (start it with --db) and dry_run is not set")
+	}
+
+	if !req.DryRun {
+		ins, err := inserter.Connect(conn)
+		if err != nil {
+			return nil, &requestError{http.StatusBadGateway, fmt.Errorf("connect db: %w", err)}
+		}
+		defer ins.Close()
+		reason, err := inserter.Protected(ins.DB(), ins.Driver(), conn, own.Protect.Hosts, own.Protect.MarkerTable)
+		if err != nil {
+			return nil, &requestError{http.StatusBadGateway, err}
+		}
+		if reason != "" {
+			return nil, &requestError{http.StatusForbidden, fmt.Errorf("refusing to seed a production database: %s", reason)}
+		}
+		opts.DB, opts.Driver = ins.DB(), ins.Driver()
+		opts.Tag = &inserter.Tag{RunID: req.RunID}
+		if opts.Tag.RunID == "" {
+			opts.Tag.RunID = inserter.NewRunID(time.Now())
+		}
+	}
+	start := time.Now()
+	res, err := pipeline.Run(ctx, opts, out.progress)
+	rep := pipeline.NewReport(res, err, start)
+	rep.Database, rep.Model = pipeline.RedactConn(conn), cfg.Model
+	if opts.Tag != nil {
+		rep.RunID = opts.Tag.RunID
+	}
+	return rep, runError(err)
+}
+
+// runError gives a failed run its HTTP status: 400 for a run over the
+// configured limits, 502 when the model failed, 500 otherwise.
+func runError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var le *pipeline.LimitError
+	if errors.As(err, &le) {
+		return &requestError{http.StatusBadRequest, err}
+	}
+	var te *pipeline.TableError
+	if errors.As(err, &te) && te.Op == pipeline.OpGenerate {
+		return &requestError{http.StatusBadGateway, err}
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+type tableClient map[string]string
+
+func (c tableClient) Generate(prompt string) (string, generator.Usage, error) {
+	for table, rows := range c {
+		if strings.Contains(prompt, "TABLE NAME: "+table+"\n") {
+			return rows, generator.Usage{EvalTokens: 1, Calls: 1}, nil
+		}
+	}
+	return "[]", generator.Usage{Calls: 1}, nil
+}
+
+const testSchema = `
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);
+CREATE TABLE posts (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL REFERENCES users(id), title TEXT);`
+
+func post(t *testing.T, h http.Handler, path string, req Request, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer(t *testing.T) {
+	client := tableClient{
+		"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`,
+		"posts": `[{"user_id": 1, "title": "Hello"}, {"user_id": 2, "title": "Again"}]`,
+	}
+	path := filepath.Join(t.TempDir(), "seed.db")
+	h := New(Options{Config: generator.DefaultConfig(), Client: client, Token: "s3cret", DB: "sqlite:" + path})
+	auth := map[string]string{"Authorization": "Bearer s3cret"}
+
+	if w := post(t, h, "/preview", Request{Schema: testSchema, Table: "users"}, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: got %d, want 401", w.Code)
+	}
+
+	w := post(t, h, "/preview", Request{Schema: testSchema, Table: "users", Rows: 2}, auth)
+	var preview Preview
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("preview: %d %s", w.Code, w.Body)
+	}
+	if len(preview.Rows) != 2 || preview.Rows[1]["email"] != "b@x.io" || preview.Usage.Calls != 1 {
+		t.Errorf("preview: got %+v", preview)
+	}
+
+	if w := post(t, h, "/preview", Request{Schema: testSchema, Table: "orders"}, auth); w.Code != http.StatusBadRequest ||
+		!strings.Contains(w.Body.String(), `table \"orders\" not found`) {
+		t.Errorf("unknown table: %d %s", w.Code, w.Body)
+	}
+
+	w = post(t, h, "/validate", Request{Schema: testSchema, Rows: 2},
+		map[string]string{"Authorization": "Bearer s3cret", "Accept": "text/event-stream"})
+	events := w.Body.String()
+	if w.Header().Get("Content-Type") != "text/event-stream" ||
+		!strings.Contains(events, "event: progress\ndata: {\"table\":\"users\",\"stage\":\"generated\",\"rows\":2,\"requested\":2}\n\n") ||
+		!strings.Contains(events, "event: result\ndata: {") {
+		t.Errorf("validate events:\n%s", events)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s, err := schema.ParseFileToSchema(testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inserter.CreateTables(db, "sqlite3", s.Tables); err != nil {
+		t.Fatal(err)
+	}
+	w = post(t, h, "/seed", Request{Schema: testSchema, DB: "sqlite:" + path, Rows: 2, RunID: "run-1"}, auth)
+	var rep pipeline.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("seed: %d %s", w.Code, w.Body)
+	}
+	if !rep.Success || rep.Inserted != 4 || rep.RunID != "run-1" {
+		t.Errorf("seed report: %+v", rep)
+	}
+
+	// A second seed repeats the UNIQUE emails: the run fails and the
+	// report says why.
+	w = post(t, h, "/seed", Request{Schema: testSchema, DB: "sqlite:" + path, Rows: 2}, auth)
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil || w.Code != http.StatusInternalServerError {
+		t.Fatalf("failed seed: %d %s", w.Code, w.Body)
+	}
+	if rep.Success || !strings.Contains(rep.Error, "UNIQUE") {
+		t.Errorf("failed seed report: %+v", rep)
+	}
+
+	if w := post(t, h, "/seed", Request{Schema: testSchema, DB: "sqlite:" + path + ".other"}, auth); w.Code != http.StatusForbidden {
+		t.Errorf("seed into another db: got %d, want 403", w.Code)
+	}
+	noDB := New(Options{Config: generator.DefaultConfig(), Client: client})
+	if w := post(t, noDB, "/seed", Request{Schema: testSchema, DB: "sqlite:" + path}, nil); w.Code != http.StatusForbidden {
+		t.Errorf("seed into a db of the request's: got %d, want 403", w.Code)
+	}
+	if w := post(t, noDB, "/seed", Request{Schema: testSchema}, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("seed without db: got %d, want 400", w.Code)
+	}
+}
+
+func TestRequestChecks(t *testing.T) {
+	h := New(Options{Config: generator.DefaultConfig(), Client: tableClient{}})
+
+	r := httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(`{"schema": "CREATE TABLE t (a TEXT);", "table": "t"}`))
+	r.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain body: got %d, want 415", w.Code)
+	}
+
+	for name, cfg := range map[string]string{
+		"hooks": "hooks:\n  - {table: users, command: [sh, -c, 'id']}\n",
+		"env":   "anchors:\n  users:\n    - {name: leak, values: {email: '{{ env \"DATABASE_URL\" }}'}}\n",
+	} {
+		if w := post(t, h, "/preview", Request{Schema: testSchema, Table: "users", Config: cfg}, nil); w.Code != http.StatusForbidden {
+			t.Errorf("config with %s: got %d %s, want 403", name, w.Code, w.Body)
+		}
+	}
+	if w := post(t, h, "/preview", Request{Schema: testSchema, Table: "users", Config: "anchors:\n  users:\n    - {name: admin, values: {email: '{{ uuid }}@x.io'}}\n"}, nil); w.Code != http.StatusOK {
+		t.Errorf("config with {{ uuid }}: got %d %s, want 200", w.Code, w.Body)
+	}
+}
+
+func TestRequestConfigKeepsServerGuards(t *testing.T) {
+	client := tableClient{"users": `[{"email": "a@x.io"}, {"email": "b@x.io"}]`}
+	path := filepath.Join(t.TempDir(), "prod.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);
+		CREATE TABLE ` + inserter.DefaultGuardTable + ` (id INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+	userSchema := "CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE);"
+
+	// A config naming another marker table must not make the server's
+	// marker table go unnoticed.
+	h := New(Options{Config: generator.DefaultConfig(), Client: client, DB: "sqlite:" + path})
+	w := post(t, h, "/seed", Request{Schema: userSchema, Rows: 2, Config: "protect:\n  marker_table: nothing_here\n"}, nil)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "marker table "+inserter.DefaultGuardTable) {
+		t.Errorf("seed a marked database with a request config: got %d %s, want 403", w.Code, w.Body)
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("users after refused seed: %d, %v", n, err)
+	}
+
+	// Nor lift the server's limits.
+	if _, err := db.Exec(`DROP TABLE ` + inserter.DefaultGuardTable); err != nil {
+		t.Fatal(err)
+	}
+	project, err := config.Parse([]byte("limits:\n  max_total_rows: 1\n"), "seeddb.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = New(Options{Config: generator.DefaultConfig(), Client: client, DB: "sqlite:" + path, Project: project})
+	w = post(t, h, "/seed", Request{Schema: userSchema, Rows: 2, Config: "limits:\n  max_total_rows: 1000\n"}, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("seed over the server's limits with a request config: got %d %s, want 400", w.Code, w.Body)
+	}
+}
+
+func TestServerLimitsEveryEndpoint(t *testing.T) {
+	project, err := config.Parse([]byte("limits:\n  max_rows_per_table: 5\n"), "seeddb.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(Options{Config: generator.DefaultConfig(), Client: tableClient{}, Project: project})
+	for _, path := range []string{"/preview", "/validate", "/seed"} {
+		w := post(t, h, path, Request{Schema: testSchema, Table: "users", Rows: 10000000, DryRun: true,
+			Config: "limits:\n  max_rows_per_table: 0\n"}, nil)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "max_rows_per_table") {
+			t.Errorf("%s over the server's limits: got %d %s, want 400", path, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package stats reads aggregate statistics from a database: row counts,
+// distinct counts, NULL ratios, ranges and the common values of
+// low-cardinality columns. A Profile describes the shape of production
+// data without holding any of its rows, so it can be saved, reviewed and
+// handed to the generator elsewhere.
+package stats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// Defaults for Options fields left zero.
+const (
+	DefaultTop      = 10 // common values kept per categorical column
+	DefaultMaxCats  = 50 // distinct values above which a column is not categorical
+	DefaultMinCount = 5  // rows a value needs before it is reported
+)
+
+// Profile holds the statistics of every collected table.
+type Profile struct {
+	Tables map[string]*Table `json:"tables"`
+}
+
+// Table holds the statistics of one table.
+type Table struct {
+	Rows    int64              `json:"rows"`
+	Columns map[string]*Column `json:"columns"`
+}
+
+// Column holds the statistics of one column. Min and Max are only kept
+// for numbers and times, AvgLen only for text, and Top only for columns
+// with few distinct values.
+type Column struct {
+	Distinct  int64   `json:"distinct"`
+	NullRatio float64 `json:"null_ratio"`
+	Min       string  `json:"min,omitempty"`
+	Max       string  `json:"max,omitempty"`
+	AvgLen    float64 `json:"avg_len,omitempty"`
+	Top       []Value `json:"top,omitempty"`
+}
+
+// Value is a common value and its share of the non-NULL rows.
+type Value struct {
+	Value string  `json:"value"`
+	Share float64 `json:"share"`
+}
+
+// Options limits what Collect reports.
+type Options struct {
+	Top      int // common values per categorical column
+	MaxCats  int // most distinct values a categorical column may have
+	MinCount int // fewest rows a value needs to be listed, so rare values stay private
+}
+
+// Collect runs aggregate queries against every table and column in tables.
+// No row is read: only COUNT, MIN, MAX, AVG and GROUP BY counts. Primary
+// and foreign keys get counts only, and UNIQUE columns never list values.
+func Collect(db *sql.DB, tables []*schema.Table, opts Options) (*Profile, error) {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+	if opts.MaxCats <= 0 {
+		opts.MaxCats = DefaultMaxCats
+	}
+	if opts.MinCount <= 0 {
+		opts.MinCount = DefaultMinCount
+	}
+	p := &Profile{Tables: make(map[string]*Table)}
+	for _, t := range tables {
+		ts, err := collectTable(db, t, opts)
+		if err != nil {
+			return nil, fmt.Errorf("stats %s: %w", t.Name, err)
+		}
+		p.Tables[t.Name] = ts
+	}
+	return p, nil
+}
+
+func collectTable(db *sql.DB, t *schema.Table, opts Options) (*Table, error) {
+	ts := &Table{Columns: make(map[string]*Column)}
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + schema.QuoteTable(t.Name)).Scan(&ts.Rows); err != nil {
+		return nil, err
+	}
+	if ts.Rows == 0 {
+		return ts, nil
+	}
+	for _, c := range t.Columns {
+		if c.Type == "binary" {
+			continue
+		}
+		col, table := quote(c.Name), schema.QuoteTable(t.Name)
+		cs := &Column{}
+		var nonNull int64
+		q := fmt.Sprintf("SELECT COUNT(%s), COUNT(DISTINCT %s) FROM %s", col, col, table)
+		if err := db.QueryRow(q).Scan(&nonNull, &cs.Distinct); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		cs.NullRatio = round(float64(ts.Rows-nonNull) / float64(ts.Rows))
+		ts.Columns[c.Name] = cs
+		if nonNull == 0 || c.PrimaryKey || c.ForeignKey != nil {
+			continue
+		}
+		switch c.Type {
+		case "integer", "decimal", "timestamp":
+			var lo, hi interface{}
+			q := fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", col, col, table)
+			if err := db.QueryRow(q).Scan(&lo, &hi); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Name, err)
+			}
+			cs.Min, cs.Max = text(lo), text(hi)
+		case "text":
+			var avg sql.NullFloat64
+			q := fmt.Sprintf("SELECT AVG(LENGTH(%s)) FROM %s", col, table)
+			if err := db.QueryRow(q).Scan(&avg); err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Name, err)
+			}
+			cs.AvgLen = round(avg.Float64)
+		}
+		if c.Unique || c.Type == "timestamp" || cs.Distinct > int64(opts.MaxCats) || cs.Distinct*2 > nonNull {
+			continue
+		}
+		top, err := topValues(db, t.Name, c.Name, nonNull, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		cs.Top = top
+	}
+	return ts, nil
+}
+
+// topValues returns the most common values of column, leaving out values
+// seen in fewer than opts.MinCount rows.
+func topValues(db *sql.DB, table, column string, nonNull int64, opts Options) ([]Value, error) {
+	col := quote(column)
+	q := fmt.Sprintf("SELECT %s, COUNT(*) AS n FROM %s WHERE %s IS NOT NULL GROUP BY %s HAVING COUNT(*) >= %d ORDER BY n DESC, %s LIMIT %d",
+		col, schema.QuoteTable(table), col, col, opts.MinCount, col, opts.Top)
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var top []Value
+	for rows.Next() {
+		var v interface{}
+		var n int64
+		if err := rows.Scan(&v, &n); err != nil {
+			return nil, err
+		}
+		top = append(top, Value{Value: text(v), Share: round(float64(n) / float64(nonNull))})
+	}
+	return top, rows.Err()
+}
+
+// Load reads a profile written by Save.
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Tables == nil {
+		return nil, fmt.Errorf("%s: no tables", path)
+	}
+	return &p, nil
+}
+
+// Save writes p as indented JSON.
+func (p *Profile) Save(path string) error {
+	data, err := p.JSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// JSON returns p as indented JSON.
+func (p *Profile) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Table returns the statistics of the named table, or nil. It is safe to
+// call on a nil profile.
+func (p *Profile) Table(name string) *Table {
+	if p == nil {
+		return nil
+	}
+	return p.Tables[name]
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(x)
+	case time.Time:
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 {
+			return x.Format("2006-01-02")
+		}
+		return x.Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprint(v)
+}
+
+func round(f float64) float64 {
+	return float64(int64(f*1000+0.5)) / 1000
+}
@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestCollect(t *testing.T) {
+	s, err := schema.ParseFileToSchema(`CREATE TABLE orders (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT UNIQUE,
+  status TEXT NOT NULL,
+  total DECIMAL(10,2),
+  note TEXT
+);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT UNIQUE, status TEXT NOT NULL, total REAL, note TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		status := "paid"
+		switch {
+		case i < 5:
+			status = "refunded"
+		case i == 5:
+			status = "disputed" // too rare to list
+		}
+		var note interface{}
+		if i%4 == 0 {
+			note = "gift"
+		}
+		if _, err := db.Exec(`INSERT INTO orders (email, status, total, note) VALUES (?, ?, ?, ?)`,
+			fmt.Sprintf("u%d@x.io", i), status, 10+i, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := Collect(db, s.Tables, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := p.Table("orders")
+	if ts == nil || ts.Rows != 20 {
+		t.Fatalf("orders: %+v", ts)
+	}
+	status := ts.Columns["status"]
+	want := []Value{{"paid", 0.7}, {"refunded", 0.25}}
+	if status.Distinct != 3 || !reflect.DeepEqual(status.Top, want) {
+		t.Errorf("status: %+v", status)
+	}
+	if email := ts.Columns["email"]; email.Distinct != 20 || email.Top != nil || email.AvgLen != 7.5 {
+		t.Errorf("email should have counts and length only: %+v", email)
+	}
+	if total := ts.Columns["total"]; total.Min != "10" || total.Max != "29" {
+		t.Errorf("total: %+v", total)
+	}
+	if note := ts.Columns["note"]; note.NullRatio != 0.75 {
+		t.Errorf("note null ratio = %v, want 0.75", note.NullRatio)
+	}
+	if id := ts.Columns["id"]; id.Min != "" || id.Distinct != 20 {
+		t.Errorf("id should have counts only: %+v", id)
+	}
+
+	path := filepath.Join(t.TempDir(), "stats.json")
+	if err := p.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, p) {
+		t.Errorf("round trip changed the profile:\n%+v\n%+v", back, p)
+	}
+}
@@ -0,0 +1,153 @@
+// Package token builds API keys, session tokens and JWTs that have the
+// shape applications check on read, instead of the made-up strings a
+// model writes.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Formats.
+const (
+	Random = "random" // prefix plus random letters and digits, e.g. sk_test_4eC39HqLyjWDarjtT1zdp7dc
+	JWT    = "jwt"    // HS256-signed JSON Web Token
+	Keep   = "keep"   // leave the model's value alone
+)
+
+// DevSecret signs JWTs when a rule gives no secret. Configure the same
+// key in the application's development settings to accept them.
+const DevSecret = "seeddb-dev-secret"
+
+// DefaultLength is the number of random characters after the prefix.
+const DefaultLength = 32
+
+// Rule says how one column's tokens are built.
+type Rule struct {
+	Format string        `yaml:"format"` // Random (default), JWT or Keep
+	Prefix string        `yaml:"prefix"` // Random: prepended, e.g. sk_test_
+	Length int           `yaml:"length"` // Random: characters after the prefix; 0 means DefaultLength
+	Secret string        `yaml:"secret"` // JWT: HMAC-SHA256 key; empty means DevSecret
+	Issuer string        `yaml:"issuer"` // JWT: iss claim, if set
+	TTL    time.Duration `yaml:"ttl"`    // JWT: exp minus iat; 0 means a year
+}
+
+// Check reports a rule that cannot be applied.
+func (r Rule) Check() error {
+	switch r.Format {
+	case "", Random, JWT, Keep:
+	default:
+		return fmt.Errorf("format must be random, jwt or keep, got %q", r.Format)
+	}
+	if r.Length < 0 || r.Length > 512 {
+		return fmt.Errorf("length must be between 1 and 512 (0 for the default), got %d", r.Length)
+	}
+	if strings.ContainsAny(r.Prefix, " \t\n\"'") {
+		return fmt.Errorf("prefix %q must not contain spaces or quotes", r.Prefix)
+	}
+	return nil
+}
+
+// Default returns the rule for a column named like a token, or false:
+// JWTs for jwt, id_token and access_token columns; prefixed keys for API
+// keys; longer random strings for client and webhook secrets; plain
+// random strings for other *_token columns.
+func Default(column string) (Rule, bool) {
+	name := strings.ToLower(column)
+	switch {
+	case name == "jwt" || strings.HasSuffix(name, "_jwt") || name == "id_token" || name == "access_token":
+		return Rule{Format: JWT}, true
+	case name == "api_key" || name == "apikey" || strings.HasSuffix(name, "_api_key") || name == "secret_key":
+		return Rule{Format: Random, Prefix: "sk_test_"}, true
+	case name == "publishable_key":
+		return Rule{Format: Random, Prefix: "pk_test_"}, true
+	case name == "client_secret" || name == "webhook_secret" || name == "signing_secret":
+		return Rule{Format: Random, Length: 48}, true
+	case name == "token" || strings.HasSuffix(name, "_token"):
+		return Rule{Format: Random, Length: 40}, true
+	}
+	return Rule{}, false
+}
+
+const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// New returns a random token: r.Prefix and r.Length letters and digits.
+func New(r Rule) string {
+	n := r.Length
+	if n == 0 {
+		n = DefaultLength
+	}
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		k, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err) // crypto/rand does not fail on supported platforms
+		}
+		b[i] = alphabet[k.Int64()]
+	}
+	return r.Prefix + string(b)
+}
+
+// Sign returns an HS256 JWT for claims, signed with r.Secret, with iat
+// set to issued and exp to issued plus r.TTL and a random jti, so no two
+// tokens are equal; an iss claim is added when r.Issuer is set. Claims
+// already in the map win.
+func Sign(r Rule, claims map[string]interface{}, issued time.Time) string {
+	secret, ttl := r.Secret, r.TTL
+	if secret == "" {
+		secret = DevSecret
+	}
+	if ttl == 0 {
+		ttl = 365 * 24 * time.Hour
+	}
+	all := map[string]interface{}{"iat": issued.Unix(), "exp": issued.Add(ttl).Unix(), "jti": New(Rule{Length: 16})}
+	if r.Issuer != "" {
+		all["iss"] = r.Issuer
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(all)
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether tok is an HS256 JWT signed with secret (DevSecret
+// when empty), and returns its claims.
+func Verify(tok, secret string) (map[string]interface{}, bool) {
+	if secret == "" {
+		secret = DevSecret
+	}
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	enc := base64.RawURLEncoding
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, false
+	}
+	payload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	var claims map[string]interface{}
+	if json.Unmarshal(payload, &claims) != nil {
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,46 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	issued := time.Unix(1700000000, 0)
+	r := Rule{Format: JWT, Secret: "k", Issuer: "app", TTL: time.Hour}
+	tok := Sign(r, map[string]interface{}{"sub": "42"}, issued)
+	claims, ok := Verify(tok, "k")
+	if !ok {
+		t.Fatalf("token %q did not verify", tok)
+	}
+	if claims["sub"] != "42" || claims["iss"] != "app" || claims["exp"].(float64) != float64(issued.Unix()+3600) {
+		t.Errorf("unexpected claims %v", claims)
+	}
+	if _, ok := Verify(tok, ""); ok {
+		t.Error("token verified with the wrong secret")
+	}
+	if Sign(r, nil, issued) == Sign(r, nil, issued) {
+		t.Error("expected distinct tokens for the same claims")
+	}
+}
+
+func TestNewDefault(t *testing.T) {
+	key := New(Rule{Prefix: "sk_", Length: 10})
+	if !strings.HasPrefix(key, "sk_") || len(key) != 13 || strings.Trim(key[3:], alphabet) != "" {
+		t.Errorf("unexpected key %q", key)
+	}
+	cases := map[string]string{"api_key": Random, "ID_TOKEN": JWT, "reset_token": Random, "name": ""}
+	for col, want := range cases {
+		r, ok := Default(col)
+		if ok != (want != "") || r.Format != want {
+			t.Errorf("Default(%q) = %+v, %v; want format %q", col, r, ok, want)
+		}
+	}
+	if r, _ := Default("api_key"); r.Prefix != "sk_test_" {
+		t.Errorf("api_key prefix: got %q", r.Prefix)
+	}
+	if err := (Rule{Format: "uuid"}).Check(); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
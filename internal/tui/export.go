@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PreviewDir is where w and W save previews.
+const PreviewDir = ".seeddb/previews"
+
+// writeClipboard puts text on the system clipboard; tests replace it.
+var writeClipboard = clipboard.WriteAll
+
+type previewExportedMsg struct{ desc string }
+
+// encodePreview returns rows with the given columns, in that order, as a
+// JSON array of objects (format "json") or as CSV with a header line
+// (format "csv", NULL as an empty field).
+func encodePreview(format string, cols []string, rows []map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "json":
+		buf.WriteString("[")
+		for i, row := range rows {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n  {")
+			for j, col := range cols {
+				k, _ := json.Marshal(col)
+				v, err := json.Marshal(row[col])
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", col, err)
+				}
+				if j > 0 {
+					buf.WriteString(", ")
+				}
+				buf.Write(k)
+				buf.WriteString(": ")
+				buf.Write(v)
+			}
+			buf.WriteString("}")
+		}
+		buf.WriteString("\n]\n")
+	case "csv":
+		w := csv.NewWriter(&buf)
+		_ = w.Write(cols)
+		for _, row := range rows {
+			rec := make([]string, len(cols))
+			for j, col := range cols {
+				if v := row[col]; v != nil {
+					rec[j] = fmt.Sprint(v)
+				}
+			}
+			_ = w.Write(rec)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// exportPreview copies the previewed rows to the clipboard, or with toFile
+// writes them to a new file in PreviewDir, in format. Only the columns
+// shown in the grid are included.
+func (m Model) exportPreview(format string, toFile bool) tea.Cmd {
+	cols, rows, table := m.PreviewColumns(), m.PreviewRows, m.PreviewTable
+	return func() tea.Msg {
+		data, err := encodePreview(format, cols, rows)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		what := fmt.Sprintf("%d rows of %s as %s", len(rows), table, format)
+		if !toFile {
+			if err := writeClipboard(string(data)); err != nil {
+				return errMsg{err: fmt.Errorf("clipboard: %w (press w to save a file instead)", err)}
+			}
+			return previewExportedMsg{desc: "Copied " + what}
+		}
+		path := filepath.Join(PreviewDir, fmt.Sprintf("%s-%s.%s", table, time.Now().Format("20060102-150405"), format))
+		if err := os.MkdirAll(PreviewDir, 0o755); err != nil {
+			return errMsg{err: err}
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return errMsg{err: err}
+		}
+		return previewExportedMsg{desc: "Saved " + what + " to " + path}
+	}
+}
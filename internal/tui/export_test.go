@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEncodePreview(t *testing.T) {
+	cols := []string{"id", "name", "note"}
+	rows := []map[string]interface{}{
+		{"id": 1, "name": "Ana", "note": nil},
+		{"id": 2, "name": `O'Brien, "Pat"`, "note": "x"},
+	}
+	got, err := encodePreview("json", cols, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n  {\"id\": 1, \"name\": \"Ana\", \"note\": null},\n  {\"id\": 2, \"name\": \"O'Brien, \\\"Pat\\\"\", \"note\": \"x\"}\n]\n"
+	if string(got) != want {
+		t.Errorf("json:\n%s\nwant:\n%s", got, want)
+	}
+	got, err = encodePreview("csv", cols, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "id,name,note\n1,Ana,\n2,\"O'Brien, \"\"Pat\"\"\",x\n"
+	if string(got) != want {
+		t.Errorf("csv:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExportPreview(t *testing.T) {
+	t.Chdir(t.TempDir())
+	var copied string
+	orig := writeClipboard
+	writeClipboard = func(s string) error { copied = s; return nil }
+	t.Cleanup(func() { writeClipboard = orig })
+
+	m := NewModel()
+	m.ActiveTab = TabPreview
+	next, _ := m.Update(previewReadyMsg{table: "users", cols: []string{"id", "email"}, rows: []map[string]interface{}{{"id": 1, "email": "a@example.com"}}})
+	m = next.(Model)
+	m.PreviewHidden = map[string]bool{"email": true}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Y")})
+	if msg, ok := cmd().(previewExportedMsg); !ok || !strings.HasPrefix(msg.desc, "Copied 1 rows of users as csv") {
+		t.Errorf("y gave %#v", msg)
+	}
+	if copied != "id\n1\n" {
+		t.Errorf("copied %q, want only the shown column", copied)
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if _, ok := cmd().(previewExportedMsg); !ok {
+		t.Fatal("w did not save")
+	}
+	files, _ := filepath.Glob(filepath.Join(PreviewDir, "users-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("saved %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != "[\n  {\"id\": 1}\n]\n" {
+		t.Errorf("saved %q", data)
+	}
+}
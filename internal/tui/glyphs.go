@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// glyphs are the non-ASCII characters the TUI draws. The legacy Windows
+// console (cmd.exe or PowerShell outside Windows Terminal) has no glyphs
+// for most of them and miscounts the width of others, which breaks the
+// layout under ConPTY, so it gets plain ASCII.
+type glyphs struct {
+	done, fail, up, waiting, selected string
+	arrow, bullet, sep, ellipsis      string
+	barFull, barEmpty                 string
+	tagline                           string
+	border                            lipgloss.Border
+	spinner                           spinner.Spinner
+}
+
+var unicodeGlyphs = glyphs{
+	done: "✓", fail: "✗", up: "↑", waiting: "◦", selected: "▶",
+	arrow: "→", bullet: "•", sep: "│", ellipsis: "…",
+	barFull: "█", barEmpty: "░",
+	tagline: "🌱 AI-Powered Database Seeding Tool 🌱",
+	border:  lipgloss.RoundedBorder(),
+	spinner: spinner.Dot,
+}
+
+var asciiGlyphs = glyphs{
+	done: "+", fail: "x", up: "^", waiting: "o", selected: ">",
+	arrow: "->", bullet: "*", sep: "|", ellipsis: "~",
+	barFull: "#", barEmpty: ".",
+	tagline: "-- AI-Powered Database Seeding Tool --",
+	border: lipgloss.Border{
+		Top: "-", Bottom: "-", Left: "|", Right: "|",
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+	},
+	spinner: spinner.Line,
+}
+
+var sym = detectGlyphs(runtime.GOOS, os.Getenv)
+
+// detectGlyphs picks ASCII on Windows unless the terminal is known to
+// render Unicode: Windows Terminal, VS Code, ConEmu or an xterm-like
+// terminal such as mintty. SEEDDB_ASCII=1 or 0 overrides the choice on
+// any system.
+func detectGlyphs(goos string, getenv func(string) string) glyphs {
+	switch getenv("SEEDDB_ASCII") {
+	case "1", "true":
+		return asciiGlyphs
+	case "0", "false":
+		return unicodeGlyphs
+	}
+	if goos != "windows" {
+		return unicodeGlyphs
+	}
+	if getenv("WT_SESSION") != "" || getenv("TERM_PROGRAM") != "" ||
+		getenv("ConEmuANSI") == "ON" || strings.HasPrefix(getenv("TERM"), "xterm") {
+		return unicodeGlyphs
+	}
+	return asciiGlyphs
+}
@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action is something a key does, named as in the ui section of
+// seeddb.yaml.
+type Action string
+
+const (
+	ActQuit      Action = "quit"
+	ActNextTab   Action = "next_tab"
+	ActPrevTab   Action = "prev_tab"
+	ActTab1      Action = "tab_1" // jump to Generate; tab_2 to tab_5 follow
+	ActTab2      Action = "tab_2"
+	ActTab3      Action = "tab_3"
+	ActTab4      Action = "tab_4"
+	ActTab5      Action = "tab_5"
+	ActDown      Action = "down" // next row, run, failure or column
+	ActUp        Action = "up"
+	ActLeft      Action = "left" // scroll the preview grid
+	ActRight     Action = "right"
+	ActTop       Action = "top"
+	ActBottom    Action = "bottom"
+	ActNextField Action = "next_field" // Generate tab, while typing in a field
+	ActPrevField Action = "prev_field"
+	ActOpen      Action = "open"
+	ActBack      Action = "back"
+)
+
+// Keymap binds each Action to keys, as bubbletea names them: "j",
+// "J" (Shift+j), "ctrl+n", "alt+<", "down".
+type Keymap map[Action][]string
+
+// Keymaps are the built-in profiles. default keeps the original bindings;
+// vim and emacs move with lowercase and control keys.
+var Keymaps = map[string]Keymap{
+	"default": {
+		ActQuit: {"Q", "ctrl+c"}, ActNextTab: {"tab"}, ActPrevTab: {"shift+tab"},
+		ActTab1: {"!"}, ActTab2: {"@"}, ActTab3: {"#"}, ActTab4: {"$"}, ActTab5: {"%"},
+		ActDown: {"J"}, ActUp: {"K"}, ActLeft: {"h"}, ActRight: {"l"}, ActTop: {"g"}, ActBottom: {"G"},
+		ActNextField: {"I", "J"}, ActPrevField: {"L", "K"}, ActOpen: {"enter"}, ActBack: {"esc"},
+	},
+	"vim": {
+		ActQuit: {"q", "ctrl+c"}, ActNextTab: {"tab", "L"}, ActPrevTab: {"shift+tab", "H"},
+		ActTab1: {"1"}, ActTab2: {"2"}, ActTab3: {"3"}, ActTab4: {"4"}, ActTab5: {"5"},
+		ActDown: {"j", "down"}, ActUp: {"k", "up"}, ActLeft: {"h", "left"}, ActRight: {"l", "right"},
+		ActTop: {"g", "home"}, ActBottom: {"G", "end"},
+		ActNextField: {"ctrl+j", "down"}, ActPrevField: {"ctrl+k", "up"}, ActOpen: {"enter"}, ActBack: {"esc"},
+	},
+	"emacs": {
+		ActQuit: {"ctrl+c", "ctrl+x"}, ActNextTab: {"tab"}, ActPrevTab: {"shift+tab"},
+		ActTab1: {"alt+1"}, ActTab2: {"alt+2"}, ActTab3: {"alt+3"}, ActTab4: {"alt+4"}, ActTab5: {"alt+5"},
+		ActDown: {"ctrl+n", "down"}, ActUp: {"ctrl+p", "up"}, ActLeft: {"ctrl+b", "left"}, ActRight: {"ctrl+f", "right"},
+		ActTop: {"alt+<", "home"}, ActBottom: {"alt+>", "end"},
+		ActNextField: {"ctrl+n", "down"}, ActPrevField: {"ctrl+p", "up"}, ActOpen: {"enter"}, ActBack: {"esc", "ctrl+g"},
+	},
+}
+
+// KeymapNames returns the built-in profile names, sorted.
+func KeymapNames() []string {
+	var out []string
+	for name := range Keymaps {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NewKeymap returns the profile named (default when ""), with the keys of
+// each action in overrides replacing the profile's.
+func NewKeymap(profile string, overrides map[string][]string) (Keymap, error) {
+	if profile == "" {
+		profile = "default"
+	}
+	base, ok := Keymaps[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap %q (want %s)", profile, strings.Join(KeymapNames(), ", "))
+	}
+	km := make(Keymap, len(base))
+	for a, keys := range base {
+		km[a] = keys
+	}
+	for name, keys := range overrides {
+		a := Action(name)
+		if _, ok := base[a]; !ok {
+			return nil, fmt.Errorf("keymap: unknown action %q", name)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("keymap: %s needs at least one key", name)
+		}
+		km[a] = keys
+	}
+	return km, nil
+}
+
+// Is reports whether key is bound to a.
+func (km Keymap) Is(a Action, key string) bool {
+	for _, k := range km[a] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// switchTab returns the tab key moves to from t, if it is a tab key.
+func (km Keymap) switchTab(t Tab, key string) (Tab, bool) {
+	switch {
+	case km.Is(ActNextTab, key):
+		return Tab((int(t) + 1) % tabCount), true
+	case km.Is(ActPrevTab, key):
+		return Tab((int(t) + tabCount - 1) % tabCount), true
+	}
+	for i, a := range []Action{ActTab1, ActTab2, ActTab3, ActTab4, ActTab5} {
+		if km.Is(a, key) {
+			return Tab(i), true
+		}
+	}
+	return t, false
+}
+
+// Help names the keys of actions for the Help tab and key bar, e.g.
+// "Shift+j / k" or "j / k".
+func (km Keymap) Help(actions ...Action) string {
+	var parts []string
+	for i, a := range actions {
+		keys := km[a]
+		if len(keys) == 0 {
+			continue
+		}
+		name := keyName(keys[0])
+		// Shift+j / Shift+k reads better as Shift+j / k.
+		if i > 0 && strings.HasPrefix(name, "Shift+") && len(parts) > 0 && strings.HasPrefix(parts[0], "Shift+") {
+			name = strings.TrimPrefix(name, "Shift+")
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// All names every key bound to a, e.g. "Shift+q / Ctrl+c".
+func (km Keymap) All(a Action) string {
+	names := make([]string, len(km[a]))
+	for i, k := range km[a] {
+		names[i] = keyName(k)
+	}
+	return strings.Join(names, " / ")
+}
+
+// tabsHelp names the tab jump keys, e.g. "Shift+1-5" or "Alt+1-5".
+func (km Keymap) tabsHelp() string {
+	first, last := km.Help(ActTab1), km.Help(ActTab5)
+	if first != "" && len(first) == len(last) && first[:len(first)-1] == last[:len(last)-1] {
+		return first + "-" + last[len(last)-1:]
+	}
+	return first + " … " + last
+}
+
+// shifted are the characters Shift and a digit type on a US keyboard.
+const shifted = "!@#$%"
+
+// keyName shows a bubbletea key name as it is pressed: "J" is Shift+j,
+// "!" Shift+1, "ctrl+n" Ctrl+n.
+func keyName(key string) string {
+	if len(key) == 1 {
+		if i := strings.Index(shifted, key); i >= 0 {
+			return fmt.Sprintf("Shift+%d", i+1)
+		}
+		if key >= "A" && key <= "Z" {
+			return "Shift+" + strings.ToLower(key)
+		}
+		return key
+	}
+	for _, mod := range []string{"ctrl", "alt", "shift"} {
+		if rest, ok := strings.CutPrefix(key, mod+"+"); ok {
+			return strings.ToUpper(mod[:1]) + mod[1:] + "+" + rest
+		}
+	}
+	switch key {
+	case "enter", "esc", "tab", "home", "end", "down", "up", "left", "right":
+		return strings.ToUpper(key[:1]) + key[1:]
+	}
+	return key
+}
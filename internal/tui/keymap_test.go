@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewKeymap(t *testing.T) {
+	km, err := NewKeymap("vim", map[string][]string{"down": {"n"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !km.Is(ActDown, "n") || km.Is(ActDown, "j") || !km.Is(ActUp, "k") {
+		t.Errorf("down = %v, up = %v", km[ActDown], km[ActUp])
+	}
+	if Keymaps["vim"].Is(ActDown, "n") {
+		t.Error("an override changed the built-in profile")
+	}
+	for _, c := range []struct {
+		profile string
+		keys    map[string][]string
+	}{
+		{"nano", nil},
+		{"vim", map[string][]string{"jump": {"x"}}},
+		{"vim", map[string][]string{"down": {}}},
+	} {
+		if _, err := NewKeymap(c.profile, c.keys); err == nil {
+			t.Errorf("NewKeymap(%q, %v) should fail", c.profile, c.keys)
+		}
+	}
+}
+
+func TestKeymapHelp(t *testing.T) {
+	cases := []struct {
+		profile, nav, tabs string
+	}{
+		{"default", "Shift+j / k", "Shift+1-5"},
+		{"vim", "j / k", "1-5"},
+		{"emacs", "Ctrl+n / Ctrl+p", "Alt+1-5"},
+	}
+	for _, c := range cases {
+		km := Keymaps[c.profile]
+		if got := km.Help(ActDown, ActUp); got != c.nav {
+			t.Errorf("%s: nav = %q, want %q", c.profile, got, c.nav)
+		}
+		if got := km.tabsHelp(); got != c.tabs {
+			t.Errorf("%s: tabs = %q, want %q", c.profile, got, c.tabs)
+		}
+	}
+}
+
+func TestVimKeymap(t *testing.T) {
+	m := NewModel()
+	m.Keys = Keymaps["vim"]
+	key := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	update := func(msg tea.Msg) {
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+
+	// The schema field has focus: letters and digits are typed, not keys.
+	update(key("q"))
+	update(key("2"))
+	if got := m.Fields[0].Value(); got != "q2" || m.ActiveTab != TabGenerate {
+		t.Fatalf("typed %q, tab %v", got, m.ActiveTab)
+	}
+	update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	if m.FocusedField != 1 {
+		t.Errorf("ctrl+j focused field %d", m.FocusedField)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	update(key("2"))
+	if m.ActiveTab != TabPreview {
+		t.Fatalf("2 went to tab %v", m.ActiveTab)
+	}
+	update(previewReadyMsg{table: "t", cols: []string{"id"}, rows: []map[string]interface{}{{"id": 1}, {"id": 2}}})
+	update(key("j"))
+	if m.PreviewScroll != 1 {
+		t.Errorf("j selected row %d", m.PreviewScroll)
+	}
+	if _, cmd := m.Update(key("q")); cmd == nil || cmd() != tea.Quit() {
+		t.Error("q did not quit")
+	}
+}
@@ -2,10 +2,16 @@ package tui
 
 import (
     "fmt"
+    "path/filepath"
     "time"
 
     "github.com/charmbracelet/bubbles/spinner"
     "github.com/charmbracelet/bubbles/textinput"
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/satyammistari/db-seed-ai/internal/config"
+    "github.com/satyammistari/db-seed-ai/internal/generator"
+    "github.com/satyammistari/db-seed-ai/internal/pipeline"
+    "github.com/satyammistari/db-seed-ai/internal/validator"
 )
 
 type Tab int
@@ -13,14 +19,19 @@ type Tab int
 const (
     TabGenerate Tab = iota
     TabPreview
+    TabValidate
     TabHistory
     TabHelp
 )
 
+// tabCount is the number of tabs.
+const tabCount = 5
+
 func (t Tab) String() string {
     return []string{
         " Generate ",
         " Preview ",
+        " Validate ",
         " History ",
         " Help ",
     }[t]
@@ -46,6 +57,9 @@ type TableProgress struct {
     RowsDone  int
     RowsTotal int
     Err       error
+    Started    time.Time     // first progress for the table
+    Generating time.Duration // generating and validating, once generated
+    Inserting  time.Duration // inserting, once inserted
 }
 
 func (tp TableProgress) Percent() float64 {
@@ -53,6 +67,32 @@ func (tp TableProgress) Percent() float64 {
     return float64(tp.RowsDone) / float64(tp.RowsTotal)
 }
 
+// Timing describes how long the table took, e.g. "gen 4.2s  ins 0.3s
+// 27 rows/s", or while it is generating how long it has been at it.
+func (tp TableProgress) Timing(now time.Time) string {
+    switch {
+    case tp.Started.IsZero():
+        return ""
+    case tp.Generating == 0:
+        return "gen " + now.Sub(tp.Started).Round(time.Second).String()
+    }
+    s := "gen " + formatSeconds(tp.Generating.Seconds())
+    if tp.Inserting > 0 { s += "  ins " + formatSeconds(tp.Inserting.Seconds()) }
+    return s + "  " + throughput(tp.RowsDone, (tp.Generating+tp.Inserting).Seconds())
+}
+
+// formatSeconds shows a duration in seconds to a tenth, e.g. 4.2s.
+func formatSeconds(s float64) string {
+    return fmt.Sprintf("%.1fs", s)
+}
+
+// throughput shows rows per second, e.g. "27 rows/s"; "" when there is no
+// time to divide by.
+func throughput(rows int, seconds float64) string {
+    if seconds <= 0 || rows == 0 { return "" }
+    return fmt.Sprintf("%.0f rows/s", float64(rows)/seconds)
+}
+
 type HistoryEntry struct {
     Timestamp    time.Time
     SchemaFile   string
@@ -61,8 +101,29 @@ type HistoryEntry struct {
     TablesSeeded int
     TotalRows    int
     Duration     time.Duration
+    Usage        generator.Usage
     Success      bool
     ErrMsg       string
+    Report       *pipeline.Report // nil for runs without a saved report
+    ReportPath   string
+}
+
+// historyFromReport turns a saved run report into a History entry.
+func historyFromReport(r *pipeline.Report, path string) HistoryEntry {
+    return HistoryEntry{
+        Timestamp:    r.StartedAt,
+        SchemaFile:   r.Schema,
+        Database:     r.Database,
+        Model:        r.Model,
+        TablesSeeded: len(r.Tables),
+        TotalRows:    r.Inserted,
+        Duration:     time.Duration(r.Seconds * float64(time.Second)),
+        Usage:        generator.Usage{PromptTokens: r.Usage.PromptTokens, EvalTokens: r.Usage.EvalTokens, Calls: r.Usage.Calls},
+        Success:      r.Success,
+        ErrMsg:       r.Error,
+        Report:       r,
+        ReportPath:   path,
+    }
 }
 
 type Config struct {
@@ -75,14 +136,19 @@ type Config struct {
 }
 
 type Model struct {
+    Keys          Keymap
     ActiveTab     Tab
     Width         int
     Height        int
     Config        Config
     FocusedField  int
     Fields        []textinput.Model
+    Targets       []config.TargetSpec // databases from seeddb.yaml, picked with t
+    TargetAt      int                 // selected target; len(Targets) seeds them all in turn
+    SeedTarget    string              // target being seeded when seeding them all
     IsRunning     bool
     Progress      []TableProgress
+    seedCh        chan tea.Msg // progress and the final result of a running seed
     StartTime     time.Time
     FinishTime    time.Time
     TotalRows     int
@@ -92,18 +158,30 @@ type Model struct {
     PreviewCols   []string
     PreviewLoading bool
     PreviewScroll int
+    PreviewTypes  map[string]string // column -> declared type, for the row inspector
+    PreviewOpen   bool              // show the selected row key by key
+    PreviewColScroll int             // first of the shown columns in the grid
+    PreviewHidden    map[string]bool // columns toggled off in the grid
+    PreviewPalette   bool            // show the column toggle list
+    PreviewPaletteAt int             // its selected column
+    ValidateSamples []ValidateSample // nil until the first validation finishes
+    ValidateIssues  []*validator.ValidationError
+    ValidateScroll  int // index of the selected failure, shown first
+    ValidateLoading bool
     History       []HistoryEntry
-    HistoryScroll int
+    HistoryScroll int  // index of the selected entry, shown first
+    HistoryOpen   bool // show the selected entry's report in detail
     StatusMsg     string
     StatusKind    string
     Err           error
+    Recovery      *Recovery // what to try after the last seed failed; nil once dismissed
 }
 
 func NewModel() Model {
     inputs := make([]textinput.Model, 4)
 
     inputs[0] = textinput.New()
-    inputs[0].Placeholder = "testdata/ecommerce.sql"
+    inputs[0].Placeholder = filepath.FromSlash("testdata/ecommerce.sql")
     inputs[0].Focus()
     inputs[0].Width = 45
     inputs[0].Prompt = ""
@@ -125,10 +203,11 @@ func NewModel() Model {
     inputs[3].CharLimit = 6
 
     s := spinner.New()
-    s.Spinner = spinner.Dot
+    s.Spinner = sym.spinner
     s.Style = spinnerStyle
 
     return Model{
+        Keys:        Keymaps["default"],
         ActiveTab:   TabGenerate,
         FocusedField: 0,
         Fields:      inputs,
@@ -136,19 +215,19 @@ func NewModel() Model {
         Config:      Config{Model: "deepseek-r1:7b", Rows: 100, Style: "realistic"},
         History:     []HistoryEntry{},
         Progress:    []TableProgress{},
-        StatusMsg:   "Ready → configure schema and database then press Enter",
+        StatusMsg:   "Ready " + sym.arrow + " configure schema and database then press Enter",
         StatusKind:  "info",
     }
 }
 
 func (m Model) GetSchemaPath() string {
-    v := m.Fields[0].Value()
+    v := cleanPath(m.Fields[0].Value())
     if v == "" { return m.Fields[0].Placeholder }
     return v
 }
 
 func (m Model) GetDBConn() string {
-    v := m.Fields[1].Value()
+    v := cleanConn(m.Fields[1].Value())
     if v == "" { return m.Fields[1].Placeholder }
     return v
 }
@@ -165,6 +244,15 @@ func (m Model) GetRows() string {
     return v
 }
 
+// PreviewColumns returns the preview's columns that are not hidden.
+func (m Model) PreviewColumns() []string {
+    var out []string
+    for _, c := range m.PreviewCols {
+        if !m.PreviewHidden[c] { out = append(out, c) }
+    }
+    return out
+}
+
 func (m Model) TotalProgress() float64 {
     if len(m.Progress) == 0 { return 0 }
     total, done := 0, 0
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// cleanPath turns what users type or paste into a path for this system:
+// surrounding spaces and quotes (Explorer's "Copy as path" adds them) are
+// removed, a file:// URL becomes its path, a leading ~ is the home
+// directory and separators are normalized.
+func cleanPath(p string) string {
+	home, _ := os.UserHomeDir()
+	return cleanPathFor(p, runtime.GOOS, home)
+}
+
+func cleanPathFor(p, goos, home string) string {
+	p = strings.TrimSpace(p)
+	if len(p) >= 2 && (p[0] == '"' || p[0] == '\'') && p[len(p)-1] == p[0] {
+		p = strings.TrimSpace(p[1 : len(p)-1])
+	}
+	if p == "" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(p, "file://"); ok {
+		p = rest
+		// file:///C:/x.sql names C:/x.sql
+		if goos == "windows" && len(p) > 2 && p[0] == '/' && p[2] == ':' {
+			p = p[1:]
+		}
+	}
+	if home != "" && (p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`)) {
+		p = home + p[1:]
+	}
+	if goos == "windows" {
+		return strings.ReplaceAll(p, "/", `\`)
+	}
+	return filepath.Clean(p)
+}
+
+// cleanConn cleans the path of a SQLite connection string; other
+// connection strings only lose surrounding spaces and quotes.
+func cleanConn(conn string) string {
+	conn = strings.TrimSpace(conn)
+	if len(conn) >= 2 && (conn[0] == '"' || conn[0] == '\'') && conn[len(conn)-1] == conn[0] {
+		conn = strings.TrimSpace(conn[1 : len(conn)-1])
+	}
+	if rest, ok := strings.CutPrefix(conn, "sqlite:"); ok && !strings.HasPrefix(rest, "//") {
+		return "sqlite:" + cleanPath(rest)
+	}
+	return conn
+}
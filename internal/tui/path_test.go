@@ -0,0 +1,64 @@
+package tui
+
+import "testing"
+
+func TestCleanPathFor(t *testing.T) {
+	cases := []struct{ in, goos, want string }{
+		{`"C:\Users\Ana\schema.sql"`, "windows", `C:\Users\Ana\schema.sql`},
+		{`  C:/work/db/schema.sql `, "windows", `C:\work\db\schema.sql`},
+		{`file:///C:/work/schema.sql`, "windows", `C:\work\schema.sql`},
+		{`~\proj\schema.sql`, "windows", `C:\Users\Ana\proj\schema.sql`},
+		{`'./testdata/ecommerce.sql'`, "linux", `testdata/ecommerce.sql`},
+		{`~/proj/schema.sql`, "linux", `/home/ana/proj/schema.sql`},
+		{`file:///tmp/schema.sql`, "darwin", `/tmp/schema.sql`},
+		{`""`, "windows", ``},
+	}
+	for _, c := range cases {
+		home := "/home/ana"
+		if c.goos == "windows" {
+			home = `C:\Users\Ana`
+		}
+		if got := cleanPathFor(c.in, c.goos, home); got != c.want {
+			t.Errorf("cleanPathFor(%q, %s) = %q, want %q", c.in, c.goos, got, c.want)
+		}
+	}
+}
+
+func TestCleanConn(t *testing.T) {
+	if got := cleanConn(` "postgres://u:p@h/db" `); got != "postgres://u:p@h/db" {
+		t.Errorf("cleanConn stripped too much or too little: %q", got)
+	}
+	if got := cleanConn("sqlite://./dev.db"); got != "sqlite://./dev.db" {
+		t.Errorf("sqlite:// strings should be left alone, got %q", got)
+	}
+}
+
+func TestDetectGlyphs(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	cases := []struct {
+		goos  string
+		vars  map[string]string
+		ascii bool
+	}{
+		{"windows", nil, true}, // conhost
+		{"windows", map[string]string{"WT_SESSION": "1"}, false},        // Windows Terminal
+		{"windows", map[string]string{"TERM": "xterm-256color"}, false}, // mintty, Git Bash
+		{"linux", nil, false},
+		{"linux", map[string]string{"SEEDDB_ASCII": "1"}, true},
+		{"windows", map[string]string{"SEEDDB_ASCII": "0"}, false},
+	}
+	for _, c := range cases {
+		got := detectGlyphs(c.goos, env(c.vars))
+		if (got.done == asciiGlyphs.done) != c.ascii {
+			t.Errorf("%s %v: ascii = %v, want %v", c.goos, c.vars, !c.ascii, c.ascii)
+		}
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := truncate("données/schéma.sql", 8); got != "données"+sym.ellipsis {
+		t.Errorf("truncate = %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+)
+
+// Recovery is what to try after a failed seed.
+type Recovery struct {
+	Title string
+	Steps []string
+}
+
+// recoveryFor maps a seed error to suggested fixes: the model server or
+// model missing, the database unreachable, and rows rejected for a
+// repeated UNIQUE value or a missing parent. Other errors get a generic
+// hint.
+func recoveryFor(err error, model, dbConn string) *Recovery {
+	var te *pipeline.TableError
+	generating := errors.As(err, &te) && te.Op == pipeline.OpGenerate
+	msg := err.Error()
+	switch {
+	case errors.Is(err, os.ErrNotExist) && strings.Contains(msg, "schema"):
+		return &Recovery{Title: "The schema file was not found", Steps: []string{
+			"Check the Schema path; it is relative to " + workDir(),
+			"Paths can be pasted with quotes, ~ or file:///",
+		}}
+	case generating && strings.Contains(msg, "status 404"):
+		return &Recovery{Title: "Ollama does not have the model " + model, Steps: []string{
+			"Pull it: ollama pull " + model,
+			"Or enter a model you have (ollama list) in the AI Model field",
+		}}
+	case generating && (isConnError(err) || strings.Contains(msg, "ollama request")):
+		return &Recovery{Title: "The model server is not answering", Steps: []string{
+			"Start Ollama: ollama serve",
+			"Check that it listens on localhost:11434",
+		}}
+	case isConnError(err):
+		return &Recovery{Title: "The database is not reachable", Steps: []string{
+			"Start it, e.g. docker compose up -d db",
+			"Check the host, port and credentials in " + pipeline.RedactConn(dbConn),
+		}}
+	case sqlState(err) == "23505" || strings.Contains(msg, "UNIQUE constraint failed"):
+		return &Recovery{Title: "A row repeats a UNIQUE value already in the table", Steps: []string{
+			"Seed an empty database, or remove earlier seeds with seeddb clean --all",
+			"Or add rows to what is there with seeddb seed --top-up",
+		}}
+	case sqlState(err) == "23503" || strings.Contains(msg, "FOREIGN KEY constraint failed"):
+		return &Recovery{Title: "A row references a parent row that is not there", Steps: []string{
+			"Check that the schema file's REFERENCES match the database",
+			"Tables the schema leaves out must already have rows",
+		}}
+	}
+	return &Recovery{Title: "The seed failed", Steps: []string{
+		"Rows committed before the error stay in the database",
+		"Run seeddb seed with --debug-dir to see every prompt and response",
+	}}
+}
+
+// isConnError reports whether err means a server could not be reached or
+// dropped the connection.
+func isConnError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.As(err, &netErr)
+}
+
+// sqlState returns the PostgreSQL error code of err, or "".
+func sqlState(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// workDir returns the working directory, for messages.
+func workDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "the working directory"
+	}
+	return dir
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+)
+
+func TestRecoveryFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&pipeline.TableError{Op: pipeline.OpGenerate, Table: "users", Err: errors.New("ollama status 404: model \"llama3\" not found")}, "ollama pull llama3"},
+		{&pipeline.TableError{Op: pipeline.OpGenerate, Table: "users", Err: fmt.Errorf("ollama request: %w", syscall.ECONNREFUSED)}, "ollama serve"},
+		{fmt.Errorf("connect db: %w", syscall.ECONNREFUSED), "docker compose"},
+		{&pipeline.TableError{Op: pipeline.OpInsert, Table: "users", Err: &pgconn.PgError{Code: "23505"}}, "--top-up"},
+		{errors.New("insert users: UNIQUE constraint failed: users.email"), "--top-up"},
+		{&pipeline.TableError{Op: pipeline.OpInsert, Table: "orders", Err: &pgconn.PgError{Code: "23503"}}, "REFERENCES"},
+		{errors.New("something else"), "--debug-dir"},
+	}
+	for _, tt := range tests {
+		r := recoveryFor(tt.err, "llama3", "postgres://app:pw@localhost/shop")
+		if !strings.Contains(strings.Join(r.Steps, "\n"), tt.want) {
+			t.Errorf("recoveryFor(%v) = %+v, want a step with %q", tt.err, r, tt.want)
+		}
+	}
+}
+
+func TestRecoveryPanelKeys(t *testing.T) {
+	m := NewModel()
+	m.Width, m.Height = 120, 40
+	next, _ := m.Update(seedErrMsg{err: fmt.Errorf("connect db: %w", syscall.ECONNREFUSED)})
+	m = next.(Model)
+	if m.Recovery == nil || !strings.Contains(m.View(), "not reachable") {
+		t.Fatalf("no recovery panel after a failed seed")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if next.(Model).Recovery != nil {
+		t.Errorf("Esc did not dismiss the panel")
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if m = next.(Model); m.Recovery != nil || !m.IsRunning || cmd == nil {
+		t.Errorf("r did not retry: running %v, panel %v", m.IsRunning, m.Recovery)
+	}
+}
@@ -16,12 +16,12 @@ var (
 )
 
 var panelStyle = lipgloss.NewStyle().
-    Border(lipgloss.RoundedBorder()).
+    Border(sym.border).
     BorderForeground(colorBorder).
     Padding(0, 1)
 
 var activePanelStyle = lipgloss.NewStyle().
-    Border(lipgloss.RoundedBorder()).
+    Border(sym.border).
     BorderForeground(colorCyan).
     Padding(0, 1)
 
@@ -52,11 +52,7 @@ var badgeError       = lipgloss.NewStyle().Foreground(colorRed).Bold(true)
 var keyStyle         = lipgloss.NewStyle().Foreground(colorCyan).Bold(true)
 var keyDescStyle     = lipgloss.NewStyle().Foreground(colorGray)
 
-const (
-    barFull  = "█"
-    barEmpty = "░"
-    barWidth = 20
-)
+const barWidth = 20
 
 func RenderProgressBar(pct float64) string {
     if pct < 0 { pct = 0 }
@@ -64,8 +60,8 @@ func RenderProgressBar(pct float64) string {
     filled := int(pct * float64(barWidth))
     empty  := barWidth - filled
     bar := ""
-    for i := 0; i < filled; i++ { bar += barFull }
-    for i := 0; i < empty;  i++ { bar += barEmpty }
+    for i := 0; i < filled; i++ { bar += sym.barFull }
+    for i := 0; i < empty;  i++ { bar += sym.barEmpty }
     var style lipgloss.Style
     switch {
     case pct >= 1.0:
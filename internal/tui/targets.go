@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// seedTarget is a database a seed goes to; name is "" for the Database
+// field.
+type seedTarget struct {
+	name string
+	conn string
+}
+
+// targetStartedMsg says that seeding every target moved on to the n-th of
+// them.
+type targetStartedMsg struct {
+	name  string
+	n, of int
+}
+
+// allTargets reports whether the Target row is on "all".
+func (m Model) allTargets() bool {
+	return len(m.Targets) > 1 && m.TargetAt == len(m.Targets)
+}
+
+// selectTarget picks the i-th target and puts its connection string in the
+// Database field, or with i == len(Targets) picks them all.
+func (m Model) selectTarget(i int) Model {
+	m.TargetAt = i
+	if i >= len(m.Targets) {
+		return m
+	}
+	conn, err := m.Targets[i].Conn()
+	if err != nil {
+		m.StatusMsg, m.StatusKind = err.Error(), "error"
+		return m
+	}
+	m.Fields[1].SetValue(conn)
+	return m
+}
+
+// nextTarget moves the Target row on, through "all" when there are
+// several targets.
+func (m Model) nextTarget() Model {
+	n := len(m.Targets)
+	if n > 1 {
+		n++ // all
+	}
+	return m.selectTarget((m.TargetAt + 1) % n)
+}
+
+// seedTargets returns the databases Enter seeds, in order: every target
+// when the Target row is on "all", else the Database field.
+func (m Model) seedTargets() ([]seedTarget, error) {
+	if !m.allTargets() {
+		return []seedTarget{{conn: m.GetDBConn()}}, nil
+	}
+	out := make([]seedTarget, len(m.Targets))
+	for i, t := range m.Targets {
+		conn, err := t.Conn()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = seedTarget{name: t.Name, conn: conn}
+	}
+	return out, nil
+}
+
+// renderTargetRow lists the targets with the selected one in brackets, as
+// the Style row does.
+func (m Model) renderTargetRow() string {
+	var sb strings.Builder
+	sb.WriteString(labelStyle.Render("Target:"))
+	names := make([]string, 0, len(m.Targets)+1)
+	for _, t := range m.Targets {
+		names = append(names, t.Name)
+	}
+	if len(m.Targets) > 1 {
+		names = append(names, "all")
+	}
+	for i, name := range names {
+		if i == m.TargetAt {
+			sb.WriteString(lipgloss.NewStyle().Foreground(colorCyan).Bold(true).Padding(0, 1).Render("[" + name + "]"))
+		} else {
+			sb.WriteString(dimStyle.Copy().Padding(0, 1).Render(name))
+		}
+	}
+	return sb.String()
+}
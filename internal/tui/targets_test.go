@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satyammistari/db-seed-ai/internal/config"
+)
+
+func TestTargets(t *testing.T) {
+	t.Setenv("SHARED_DB", "postgres://app:pw@shared/app")
+	m := NewModel()
+	m.Width, m.Height = 120, 40
+	m.Targets = []config.TargetSpec{
+		{Name: "local", DB: "sqlite:dev.db"},
+		{Name: "shared", DB: `{{ env "SHARED_DB" }}`},
+	}
+	m = m.selectTarget(0).blurAllFields()
+	if got := m.GetDBConn(); got != "sqlite:dev.db" {
+		t.Fatalf("Database field = %q", got)
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if m = next.(Model); m.GetDBConn() != "postgres://app:pw@shared/app" {
+		t.Errorf("t did not select shared: %q", m.GetDBConn())
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if m = next.(Model); !m.allTargets() || !strings.Contains(m.View(), "[all]") {
+		t.Fatalf("t did not select all")
+	}
+	targets, err := m.seedTargets()
+	if err != nil || len(targets) != 2 || targets[1].conn != "postgres://app:pw@shared/app" {
+		t.Errorf("seedTargets = %+v, %v", targets, err)
+	}
+
+	// A finished target that is not the last keeps the seed running.
+	m.IsRunning, m.seedCh = true, make(chan tea.Msg)
+	next, _ = m.Update(targetStartedMsg{name: "local", n: 1, of: 2})
+	next, _ = next.(Model).Update(seedDoneMsg{totalRows: 10, more: true})
+	next, _ = next.(Model).Update(seedDoneMsg{totalRows: 10})
+	if m = next.(Model); m.IsRunning || m.TotalRows != 20 || m.SeedTarget != "" {
+		t.Errorf("after both targets: running %v, rows %d, target %q", m.IsRunning, m.TotalRows, m.SeedTarget)
+	}
+}
@@ -4,10 +4,18 @@ import (
     "fmt"
 
     tea "github.com/charmbracelet/bubbletea"
+    "github.com/satyammistari/db-seed-ai/internal/config"
 )
 
-func Run() error {
+// Run starts the terminal UI with the given key bindings (see NewKeymap)
+// and the targets of seeddb.yaml, the first of them selected.
+func Run(keys Keymap, targets []config.TargetSpec) error {
     m := NewModel()
+    if keys != nil { m.Keys = keys }
+    if len(targets) > 0 {
+        m.Targets = targets
+        m = m.selectTarget(0)
+    }
     p := tea.NewProgram(
         m,
         tea.WithAltScreen(),
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,31 +10,60 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satyammistari/db-seed-ai/internal/config"
 	"github.com/satyammistari/db-seed-ai/internal/generator"
 	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
 	"github.com/satyammistari/db-seed-ai/internal/schema"
 )
 
 type schemaLoadedMsg  struct{ s *schema.Schema }
 type tableProgressMsg struct {
-	tableName string
-	rowsDone  int
-	rowsTotal int
-	status    TableStatus
+	tableName  string
+	rowsDone   int
+	rowsTotal  int
+	status     TableStatus
+	generating time.Duration // 0 until the table is generated
+	inserting  time.Duration
 }
 type seedDoneMsg  struct {
 	totalRows int
 	duration  time.Duration
+	usage     generator.Usage
+	report    *pipeline.Report
+	path      string
+	more      bool // more targets follow
 }
-type seedErrMsg   struct{ err error }
+type seedErrMsg   struct {
+	err    error
+	report *pipeline.Report
+	path   string
+	conn   string
+}
+type historyLoadedMsg struct{ entries []HistoryEntry }
 type previewReadyMsg struct {
-	rows []map[string]interface{}
-	cols []string
+	table string
+	rows  []map[string]interface{}
+	cols  []string
+	types map[string]string
 }
 type errMsg struct{ err error }
 
 func (m Model) Init() tea.Cmd {
-    return tea.Batch(m.Spinner.Tick, textinput.Blink)
+    return tea.Batch(m.Spinner.Tick, textinput.Blink, loadHistory)
+}
+
+// loadHistory reads the run reports saved by earlier sessions.
+func loadHistory() tea.Msg {
+    reports, paths, err := pipeline.LoadReports(pipeline.DefaultReportDir)
+    if err != nil {
+        return errMsg{err: err}
+    }
+    entries := make([]HistoryEntry, len(reports))
+    for i, r := range reports {
+        entries[i] = historyFromReport(r, paths[i])
+    }
+    return historyLoadedMsg{entries: entries}
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -47,7 +77,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         return m, nil
 
     case tea.KeyMsg:
-        if msg.String() == "ctrl+c" || msg.String() == "Q" {
+        // A quit key that is a letter types it into a focused field.
+        if m.Keys.Is(ActQuit, msg.String()) && !(len(msg.Runes) > 0 && m.ActiveTab == TabGenerate && m.anyFieldFocused()) {
             return m, tea.Quit
         }
         switch m.ActiveTab {
@@ -55,6 +86,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
             return m.handleGenerateKey(msg)
         case TabPreview:
             return m.handlePreviewKey(msg)
+        case TabValidate:
+            return m.handleValidateKey(msg)
         case TabHistory:
             return m.handleHistoryKey(msg)
         case TabHelp:
@@ -77,8 +110,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 RowsTotal: 100,
             }
         }
-        m.StatusMsg  = fmt.Sprintf("Schema loaded → %d tables", len(msg.s.Tables))
+        m.StatusMsg  = fmt.Sprintf("Schema loaded %s %d tables", sym.arrow, len(msg.s.Tables))
         m.StatusKind = "success"
+        if m.IsRunning {
+            cmds = append(cmds, waitForSeed(m.seedCh))
+        }
 
     case tableProgressMsg:
         for i, p := range m.Progress {
@@ -86,21 +122,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 m.Progress[i].RowsDone  = msg.rowsDone
                 m.Progress[i].RowsTotal = msg.rowsTotal
                 m.Progress[i].Status    = msg.status
+                if p.Started.IsZero() { m.Progress[i].Started = time.Now() }
+                if msg.generating > 0 { m.Progress[i].Generating = msg.generating }
+                if msg.inserting > 0 { m.Progress[i].Inserting = msg.inserting }
                 break
             }
         }
-        cmds = append(cmds, m.Spinner.Tick)
+        cmds = append(cmds, waitForSeed(m.seedCh))
+
+    case targetStartedMsg:
+        m.SeedTarget = msg.name
+        m.StatusMsg  = fmt.Sprintf("Seeding %s (%d of %d)...", msg.name, msg.n, msg.of)
+        cmds = append(cmds, waitForSeed(m.seedCh))
 
     case seedDoneMsg:
+        if msg.more {
+            if msg.report != nil { m.History = append([]HistoryEntry{historyFromReport(msg.report, msg.path)}, m.History...) }
+            m.TotalRows += msg.totalRows
+            return m, waitForSeed(m.seedCh)
+        }
         m.IsRunning  = false
         m.FinishTime = time.Now()
-		m.TotalRows  = msg.totalRows
+        m.SeedTarget = ""
+		m.TotalRows += msg.totalRows
 		m.StatusMsg  = fmt.Sprintf(
-			"✓ Done in %s → %d rows inserted",
-			msg.duration.Round(time.Second), msg.totalRows,
+			"%s Done in %s %s %d rows inserted (%d tokens)",
+			sym.done, msg.duration.Round(time.Second), sym.arrow, m.TotalRows, msg.usage.TotalTokens(),
 		)
 		m.StatusKind = "success"
-		m.History = append([]HistoryEntry{{
+		entry := HistoryEntry{
 			Timestamp:    time.Now(),
 			SchemaFile:   m.GetSchemaPath(),
 			Database:     m.GetDBConn(),
@@ -108,33 +158,76 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			TablesSeeded: len(m.Progress),
 			TotalRows:    msg.totalRows,
 			Duration:     msg.duration,
+			Usage:        msg.usage,
 			Success:      true,
-		}}, m.History...)
+		}
+		if msg.report != nil {
+			entry = historyFromReport(msg.report, msg.path)
+		}
+		m.History = append([]HistoryEntry{entry}, m.History...)
 
 	case seedErrMsg:
 		m.IsRunning  = false
 		m.FinishTime = time.Now()
 		m.Err        = msg.err
-		m.StatusMsg  = fmt.Sprintf("✗ Error: %v", msg.err)
+		m.SeedTarget = ""
+		m.Recovery   = recoveryFor(msg.err, m.GetModel(), msg.conn)
+		m            = m.blurAllFields() // so r and Esc reach the recovery panel
+		m.StatusMsg  = fmt.Sprintf("%s Error: %v", sym.fail, msg.err)
 		m.StatusKind = "error"
-		m.History = append([]HistoryEntry{{
+		entry := HistoryEntry{
 			Timestamp:  time.Now(),
 			SchemaFile: m.GetSchemaPath(),
 			Database:   m.GetDBConn(),
 			Model:      m.GetModel(),
 			Success:    false,
 			ErrMsg:     msg.err.Error(),
-		}}, m.History...)
+		}
+		if msg.report != nil {
+			entry = historyFromReport(msg.report, msg.path)
+		}
+		m.History = append([]HistoryEntry{entry}, m.History...)
+
+	case historyLoadedMsg:
+		m.History, m.HistoryScroll, m.HistoryOpen = msg.entries, 0, false
 
 	case previewReadyMsg:
+		if msg.table != m.PreviewTable {
+			m.PreviewHidden = nil
+		}
+		m.PreviewTable   = msg.table
 		m.PreviewRows    = msg.rows
 		m.PreviewCols    = msg.cols
+		m.PreviewTypes   = msg.types
+		m.PreviewScroll  = 0
+		m.PreviewOpen    = false
+		m.PreviewPalette = false
+		m.PreviewColScroll, m.PreviewPaletteAt = 0, 0
 		m.PreviewLoading = false
-		m.StatusMsg      = fmt.Sprintf("Preview ready → %d rows", len(msg.rows))
+		m.StatusMsg      = fmt.Sprintf("Preview ready %s %d rows", sym.arrow, len(msg.rows))
 		m.StatusKind     = "success"
 
+	case validateReadyMsg:
+		m.ValidateSamples = msg.samples
+		m.ValidateIssues  = msg.issues
+		m.ValidateScroll  = 0
+		m.ValidateLoading = false
+		if m.ValidateSamples == nil {
+			m.ValidateSamples = []ValidateSample{}
+		}
+		m.StatusMsg  = fmt.Sprintf("Validated %d tables %s %d failures", len(msg.samples), sym.arrow, len(msg.issues))
+		m.StatusKind = "success"
+		if len(msg.issues) > 0 {
+			m.StatusKind = "warning"
+		}
+
+	case previewExportedMsg:
+		m.StatusMsg  = fmt.Sprintf("%s %s", sym.done, msg.desc)
+		m.StatusKind = "success"
+
 	case errMsg:
-		m.StatusMsg  = fmt.Sprintf("✗ %v", msg.err)
+		m.PreviewLoading, m.ValidateLoading = false, false
+		m.StatusMsg  = fmt.Sprintf("%s %v", sym.fail, msg.err)
 		m.StatusKind = "error"
 	}
 
@@ -149,39 +242,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m Model) handleGenerateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
     var cmds []tea.Cmd
-    switch msg.String() {
-    case "tab":
-        m = m.blurAllFields()
-        m.ActiveTab = Tab((int(m.ActiveTab) + 1) % 4)
-        return m, nil
-    case "shift+tab":
+    km, key := m.Keys, msg.String()
+    // Letters, digits and symbols type into a focused field unless they
+    // move between fields.
+    typing := m.anyFieldFocused() && len(msg.Runes) > 0 && !km.Is(ActNextField, key) && !km.Is(ActPrevField, key)
+    if t, ok := km.switchTab(m.ActiveTab, key); ok && !typing {
         m = m.blurAllFields()
-        m.ActiveTab = Tab((int(m.ActiveTab) + 3) % 4)
+        m.ActiveTab = t
         return m, nil
-    case "!": m = m.blurAllFields(); m.ActiveTab = TabGenerate; return m, nil  // Shift+1
-    case "@": m = m.blurAllFields(); m.ActiveTab = TabPreview;  return m, nil  // Shift+2
-    case "#": m = m.blurAllFields(); m.ActiveTab = TabHistory;  return m, nil  // Shift+3
-    case "$": m = m.blurAllFields(); m.ActiveTab = TabHelp;     return m, nil  // Shift+4
-    case "I", "J": // Shift+i or Shift+j for focus next field
-        if !m.anyFieldFocused() {
-            m.FocusedField = 0
-            m.Fields[0].Focus()
-            return m, textinput.Blink
-        }
+    }
+    switch {
+    case typing:
+    case !m.anyFieldFocused() && (km.Is(ActNextField, key) || km.Is(ActDown, key)):
+        m.FocusedField = 0
+        m.Fields[0].Focus()
+        return m, textinput.Blink
+    case m.anyFieldFocused() && km.Is(ActNextField, key):
         m.Fields[m.FocusedField].Blur()
         m.FocusedField = (m.FocusedField + 1) % len(m.Fields)
         m.Fields[m.FocusedField].Focus()
         return m, textinput.Blink
-    case "L", "K": // Shift+l or Shift+k for focus previous field
-        if !m.anyFieldFocused() { return m, nil }
+    case m.anyFieldFocused() && km.Is(ActPrevField, key):
         m.Fields[m.FocusedField].Blur()
         m.FocusedField = (m.FocusedField + len(m.Fields) - 1) % len(m.Fields)
         m.Fields[m.FocusedField].Focus()
         return m, textinput.Blink
-    case "esc":
+    case km.Is(ActBack, key):
+        if !m.anyFieldFocused() { m.Recovery = nil }
         m = m.blurAllFields()
         return m, nil
-    case "enter":
+    case key == "r" && m.Recovery != nil && !m.IsRunning && !m.anyFieldFocused(): // retry the failed seed
+        return m.startSeeding()
+    case key == "t" && len(m.Targets) > 0 && !m.IsRunning && !m.anyFieldFocused(): // next target
+        return m.nextTarget(), nil
+    case km.Is(ActOpen, key):
         if m.IsRunning { return m, nil }
         m = m.blurAllFields()
         return m.startSeeding()
@@ -195,141 +289,241 @@ func (m Model) handleGenerateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handlePreviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.String() {
-    case "tab":       m.ActiveTab = Tab((int(m.ActiveTab)+1)%4)
-    case "shift+tab": m.ActiveTab = Tab((int(m.ActiveTab)+3)%4)
-    case "!": m.ActiveTab = TabGenerate  // Shift+1
-    case "@": m.ActiveTab = TabPreview   // Shift+2
-    case "#": m.ActiveTab = TabHistory   // Shift+3
-    case "$": m.ActiveTab = TabHelp      // Shift+4
-    case "J": // Shift+j for scroll down
+    km, key := m.Keys, msg.String()
+    if t, ok := km.switchTab(m.ActiveTab, key); ok {
+        m.ActiveTab = t
+        return m, nil
+    }
+    if m.PreviewPalette {
+        return m.handleColumnPaletteKey(msg)
+    }
+    switch {
+    case km.Is(ActLeft, key): // scroll the grid left a column
+        if m.PreviewColScroll > 0 { m.PreviewColScroll-- }
+    case km.Is(ActRight, key): // and right
+        if m.PreviewColScroll < len(m.PreviewColumns())-1 { m.PreviewColScroll++ }
+    case km.Is(ActDown, key):
         if m.PreviewScroll < len(m.PreviewRows)-1 { m.PreviewScroll++ }
-    case "K": // Shift+k for scroll up
+    case km.Is(ActUp, key):
         if m.PreviewScroll > 0 { m.PreviewScroll-- }
-    case "g": m.PreviewScroll = 0
-    case "G":
+    case km.Is(ActTop, key): m.PreviewScroll = 0
+    case km.Is(ActBottom, key):
         if len(m.PreviewRows) > 0 { m.PreviewScroll = len(m.PreviewRows)-1 }
-    case "enter":
+    case km.Is(ActOpen, key): // open or close the selected row, or generate the first preview
+        if len(m.PreviewRows) > 0 {
+            m.PreviewOpen = !m.PreviewOpen
+            return m, nil
+        }
+        if m.PreviewLoading { return m, nil }
         return m.startPreview()
+    case km.Is(ActBack, key):
+        m.PreviewOpen = false
+    case key == "c": // choose the columns shown
+        if len(m.PreviewCols) > 0 { m.PreviewPalette, m.PreviewOpen = true, false }
+    case key == "r": // generate a new preview
+        if m.PreviewLoading { return m, nil }
+        return m.startPreview()
+    case key == "y", key == "Y": // copy the rows as JSON, or with Shift as CSV
+        if len(m.PreviewRows) > 0 { return m, m.exportPreview(exportFormat(key), false) }
+    case key == "w", key == "W": // save them to a file in PreviewDir
+        if len(m.PreviewRows) > 0 { return m, m.exportPreview(exportFormat(key), true) }
+    }
+    return m, nil
+}
+
+// exportFormat is the format a y or w key exports in: CSV for Y and W,
+// JSON otherwise.
+func exportFormat(key string) string {
+    if key == "Y" || key == "W" { return "csv" }
+    return "json"
+}
+
+// handleColumnPaletteKey moves through the column toggle list and shows or
+// hides the selected column.
+func (m Model) handleColumnPaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    km, key := m.Keys, msg.String()
+    switch {
+    case km.Is(ActDown, key):
+        if m.PreviewPaletteAt < len(m.PreviewCols)-1 { m.PreviewPaletteAt++ }
+    case km.Is(ActUp, key):
+        if m.PreviewPaletteAt > 0 { m.PreviewPaletteAt-- }
+    case key == " ", key == "x": // show or hide it
+        col := m.PreviewCols[m.PreviewPaletteAt]
+        hidden := make(map[string]bool, len(m.PreviewHidden)+1)
+        for c, h := range m.PreviewHidden { hidden[c] = h }
+        hidden[col] = !hidden[col]
+        m.PreviewHidden = hidden
+        if n := len(m.PreviewColumns()); m.PreviewColScroll >= n { m.PreviewColScroll = maxInt(n-1, 0) }
+    case key == "a": // show every column
+        m.PreviewHidden = nil
+    case key == "c", km.Is(ActBack, key), km.Is(ActOpen, key):
+        m.PreviewPalette = false
     }
     return m, nil
 }
 
 func (m Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.String() {
-    case "tab":       m.ActiveTab = Tab((int(m.ActiveTab)+1)%4)
-    case "shift+tab": m.ActiveTab = Tab((int(m.ActiveTab)+3)%4)
-    case "!": m.ActiveTab = TabGenerate  // Shift+1
-    case "@": m.ActiveTab = TabPreview   // Shift+2
-    case "#": m.ActiveTab = TabHistory   // Shift+3
-    case "$": m.ActiveTab = TabHelp      // Shift+4
-    case "J": // Shift+j for scroll down
+    km, key := m.Keys, msg.String()
+    if t, ok := km.switchTab(m.ActiveTab, key); ok {
+        m.ActiveTab = t
+        return m, nil
+    }
+    switch {
+    case km.Is(ActDown, key):
         if m.HistoryScroll < len(m.History)-1 { m.HistoryScroll++ }
-    case "K": // Shift+k for scroll up
+    case km.Is(ActUp, key):
         if m.HistoryScroll > 0 { m.HistoryScroll-- }
+    case km.Is(ActOpen, key): // open or close the selected run's report
+        m.HistoryOpen = !m.HistoryOpen
+    case km.Is(ActBack, key):
+        m.HistoryOpen = false
+    case key == "r": // reload reports from disk
+        return m, loadHistory
     }
     return m, nil
 }
 
 func (m Model) handleHelpKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.String() {
-    case "tab":       m.ActiveTab = Tab((int(m.ActiveTab)+1)%4)
-    case "shift+tab": m.ActiveTab = Tab((int(m.ActiveTab)+3)%4)
-    case "!": m.ActiveTab = TabGenerate  // Shift+1
-    case "@": m.ActiveTab = TabPreview   // Shift+2
-    case "#": m.ActiveTab = TabHistory   // Shift+3
-    case "$": m.ActiveTab = TabHelp      // Shift+4
+    if t, ok := m.Keys.switchTab(m.ActiveTab, msg.String()); ok {
+        m.ActiveTab = t
     }
     return m, nil
 }
 
 func (m Model) startSeeding() (Model, tea.Cmd) {
+    targets, err := m.seedTargets()
+    if err != nil {
+        m.StatusMsg, m.StatusKind = fmt.Sprintf("%s %v", sym.fail, err), "error"
+        return m, nil
+    }
     m.IsRunning  = true
     m.StartTime  = time.Now()
     m.FinishTime = time.Time{}
     m.TotalRows  = 0
     m.Err        = nil
+    m.Recovery   = nil
     m.StatusMsg  = "Starting seed pipeline..."
     m.StatusKind = "info"
 
     schemaPath := m.GetSchemaPath()
-    dbConn     := m.GetDBConn()
     modelName  := m.GetModel()
     rows, _    := strconv.Atoi(m.GetRows())
     if rows <= 0 { rows = 100 }
 
-    return m, tea.Batch(
-        m.Spinner.Tick,
-        func() tea.Msg {
-            return runSeedPipeline(schemaPath, dbConn, modelName, rows)
-        },
-    )
+    // The pipeline runs in its own goroutine and reports through seedCh;
+    // waitForSeed delivers one message at a time to Update. Targets are
+    // seeded one after the other, stopping at the first that fails.
+    ch := make(chan tea.Msg)
+    m.seedCh = ch
+    go func() {
+        send := func(msg tea.Msg) { ch <- msg }
+        for i, t := range targets {
+            if t.name != "" { send(targetStartedMsg{name: t.name, n: i + 1, of: len(targets)}) }
+            switch res := runSeedPipeline(schemaPath, t.conn, modelName, rows, send).(type) {
+            case seedErrMsg:
+                if t.name != "" { res.err = fmt.Errorf("target %s: %w", t.name, res.err) }
+                send(res)
+                return
+            case seedDoneMsg:
+                res.more = i < len(targets)-1
+                send(res)
+            }
+        }
+    }()
+
+    return m, tea.Batch(m.Spinner.Tick, waitForSeed(ch))
+}
+
+// waitForSeed returns a command that receives the next pipeline message.
+func waitForSeed(ch chan tea.Msg) tea.Cmd {
+    return func() tea.Msg { return <-ch }
 }
 
-func runSeedPipeline(schemaPath, dbConn, modelName string, numRows int) tea.Msg {
+// runSeedPipeline seeds every table, calling send with schemaLoadedMsg and
+// tableProgressMsg updates as it goes, and returns the final result message.
+// Every run, failed or not, is saved as a report for the History tab.
+func runSeedPipeline(schemaPath, dbConn, modelName string, numRows int, send func(tea.Msg)) tea.Msg {
 	start := time.Now()
+	done := func(res *pipeline.Result, err error) tea.Msg {
+		rep := pipeline.NewReport(res, err, start)
+		rep.Schema, rep.Database, rep.Model = schemaPath, pipeline.RedactConn(dbConn), modelName
+		path := pipeline.ReportPath(pipeline.DefaultReportDir, start)
+		if rep.Save(path) != nil {
+			path = ""
+		}
+		if err != nil {
+			return seedErrMsg{err: err, report: rep, path: path, conn: dbConn}
+		}
+		return seedDoneMsg{totalRows: res.Inserted, duration: time.Since(start), usage: res.Usage, report: rep, path: path}
+	}
 
 	// Read schema file
 	content, err := os.ReadFile(schemaPath)
 	if err != nil {
-		return seedErrMsg{err: fmt.Errorf("read schema file: %w", err)}
+		return done(nil, fmt.Errorf("read schema file: %w", err))
 	}
 
 	// Parse schema
 	s, err := schema.ParseFileToSchema(string(content))
 	if err != nil {
-		return seedErrMsg{err: fmt.Errorf("parse schema: %w", err)}
+		return done(nil, fmt.Errorf("parse schema: %w", err))
 	}
+	send(schemaLoadedMsg{s: s})
 
-	// Create generator
 	cfg := generator.DefaultConfig()
 	cfg.Model = modelName
 	cfg.Style = generator.StyleRealistic
-	gen := generator.New(cfg)
+	projectCfg, err := config.Load("")
+	if err != nil {
+		return done(nil, err)
+	}
+	projectCfg.Apply(&cfg)
 
 	// Open database connection
-	db, driver, err := inserter.Open(dbConn)
+	ins, err := inserter.Connect(dbConn)
 	if err != nil {
-		return seedErrMsg{err: fmt.Errorf("connect db: %w", err)}
+		return done(nil, fmt.Errorf("connect db: %w", err))
+	}
+	defer ins.Close()
+	reason, err := inserter.Protected(ins.DB(), ins.Driver(), dbConn, projectCfg.Protect.Hosts, projectCfg.Protect.MarkerTable)
+	if err != nil {
+		return done(nil, err)
+	}
+	if reason != "" {
+		return done(nil, fmt.Errorf("refusing to seed a production database: %s (use seeddb seed --force)", reason))
 	}
-	defer db.Close()
-
-	totalRows := 0
-
-	// Generate and insert for each table
-	for _, tableName := range s.InsertOrder {
-		t := s.TableMap[tableName]
-		if t == nil {
-			continue
-		}
 
-		// Fetch existing IDs for FK references
-		existingIDs := make(map[string][]interface{})
-		for _, col := range t.FKColumns() {
-			if col.ForeignKey == nil {
-				continue
-			}
-			ids, err := inserter.FetchRefIDs(db, col.ForeignKey.RefTable, col.ForeignKey.RefColumn, 1000)
-			if err == nil {
-				existingIDs[col.Name] = ids
+	res, err := pipeline.Run(context.Background(), pipeline.Options{
+		Schema: s,
+		Rows:   numRows,
+		Config: cfg,
+		DB:     ins.DB(),
+		Driver: ins.Driver(),
+	}, func(ev pipeline.Event) {
+		p := tableProgressMsg{tableName: ev.Table, rowsTotal: numRows}
+		switch ev.Stage {
+		case pipeline.StageGenerating:
+			p.status, p.rowsDone = StatusRunning, ev.Progress.Rows
+		case pipeline.StageGenerated:
+			p.status, p.rowsDone = StatusRunning, ev.Result.Rows
+			if ev.Result.Inserting > 0 { // a chunked table, partly inserted
+				p.status, p.rowsDone = StatusInserting, ev.Result.Inserted
 			}
+		case pipeline.StageInserting:
+			p.status, p.rowsDone = StatusInserting, ev.Result.Rows
+		case pipeline.StageInserted:
+			p.status, p.rowsDone = StatusDone, ev.Result.Inserted
+		case pipeline.StageSkipped:
+			p.status, p.rowsDone = StatusDone, ev.Result.Existing
+		case pipeline.StageFailed, pipeline.StageAbandoned:
+			p.status = StatusError
 		}
-
-		// Generate rows
-		result, err := gen.Generate(t, numRows, s, "realistic", existingIDs)
-		if err != nil {
-			return seedErrMsg{err: fmt.Errorf("generate %s: %w", tableName, err)}
+		if ev.Result != nil && ev.Result.Generated != nil && ev.Stage != pipeline.StageGenerating {
+			p.generating, p.inserting = ev.Result.Generating(), ev.Result.Inserting
 		}
-
-		// Insert rows
-		n, err := inserter.InsertBatch(db, driver, tableName, result.Columns, result.Rows)
-		if err != nil {
-			return seedErrMsg{err: fmt.Errorf("insert %s: %w", tableName, err)}
-		}
-		totalRows += n
-	}
-
-	return seedDoneMsg{totalRows: totalRows, duration: time.Since(start)}
+		send(p)
+	})
+	return done(res, err)
 }
 
 func (m Model) startPreview() (Model, tea.Cmd) {
@@ -369,7 +563,17 @@ func (m Model) startPreview() (Model, tea.Cmd) {
 			return errMsg{err: err}
 		}
 
-		return previewReadyMsg{rows: result.Rows, cols: result.Columns}
+		types := make(map[string]string, len(t.Columns))
+		for _, c := range t.Columns {
+			types[c.Name] = c.SQLType
+			if types[c.Name] == "" {
+				types[c.Name] = c.Type
+			}
+			if c.NotNull {
+				types[c.Name] += " not null"
+			}
+		}
+		return previewReadyMsg{table: t.Name, rows: result.Rows, cols: result.Columns, types: types}
 	}
 }
 
@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+// validateSampleRows is how many rows per table the Validate tab generates.
+const validateSampleRows = 10
+
+// ValidateSample is one table's generated sample.
+type ValidateSample struct {
+	Table   string
+	Columns []string
+	Rows    []map[string]interface{}
+}
+
+type validateReadyMsg struct {
+	samples []ValidateSample
+	issues  []*validator.ValidationError
+}
+
+// startValidate generates a sample of every table without inserting it and
+// runs the validator on it, as seeddb validate does.
+func (m Model) startValidate() (Model, tea.Cmd) {
+	m.ValidateLoading = true
+	m.StatusMsg = "Generating a sample of every table to validate..."
+	m.StatusKind = "info"
+
+	schemaPath := m.GetSchemaPath()
+	modelName := m.GetModel()
+
+	return m, func() tea.Msg {
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		s, err := schema.ParseFileToSchema(string(content))
+		if err != nil {
+			return errMsg{err: err}
+		}
+		cfg := generator.DefaultConfig()
+		cfg.Model = modelName
+		cfg.Style = generator.StyleRealistic
+		projectCfg, err := config.Load("")
+		if err != nil {
+			return errMsg{err: err}
+		}
+		projectCfg.Apply(&cfg)
+
+		res, err := pipeline.Run(context.Background(), pipeline.Options{
+			Schema: s,
+			Rows:   validateSampleRows,
+			Config: cfg,
+		}, func(pipeline.Event) {})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		var msg validateReadyMsg
+		for _, tr := range res.Tables {
+			sample := ValidateSample{Table: tr.Name}
+			if tr.Generated != nil {
+				sample.Columns, sample.Rows = tr.Generated.Columns, tr.Generated.Rows
+			}
+			msg.samples = append(msg.samples, sample)
+			msg.issues = append(msg.issues, tr.Issues...)
+		}
+		return msg
+	}
+}
+
+func (m Model) handleValidateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	km, key := m.Keys, msg.String()
+	if t, ok := km.switchTab(m.ActiveTab, key); ok {
+		m.ActiveTab = t
+		return m, nil
+	}
+	switch {
+	case km.Is(ActDown, key):
+		if m.ValidateScroll < len(m.ValidateIssues)-1 {
+			m.ValidateScroll++
+		}
+	case km.Is(ActUp, key):
+		if m.ValidateScroll > 0 {
+			m.ValidateScroll--
+		}
+	case km.Is(ActTop, key):
+		m.ValidateScroll = 0
+	case km.Is(ActBottom, key):
+		if len(m.ValidateIssues) > 0 {
+			m.ValidateScroll = len(m.ValidateIssues) - 1
+		}
+	case km.Is(ActOpen, key):
+		if m.ValidateLoading {
+			return m, nil
+		}
+		return m.startValidate()
+	}
+	return m, nil
+}
+
+// validateRow returns the sampled row e was found in, with its table's
+// columns, or nil if it is not in the samples.
+func (m Model) validateRow(e *validator.ValidationError) ([]string, map[string]interface{}) {
+	for _, s := range m.ValidateSamples {
+		if s.Table == e.Table && e.Row >= 1 && e.Row <= len(s.Rows) {
+			return s.Columns, s.Rows[e.Row-1]
+		}
+	}
+	return nil, nil
+}
+
+func (m Model) renderValidateTab() string {
+	var sb strings.Builder
+	width := m.Width - 6
+	sb.WriteString(titleStyle.Render("Validate Generated Rows") + "\n\n")
+
+	switch {
+	case m.ValidateLoading:
+		sb.WriteString(warningStyle.Render(m.Spinner.View() + " Generating and validating..."))
+	case m.ValidateSamples == nil:
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("Press Enter to generate %d rows of every table and check them against the schema and seeddb.yaml rules.\nNothing will be inserted.", validateSampleRows)))
+	case len(m.ValidateIssues) == 0:
+		sb.WriteString(successStyle.Render(fmt.Sprintf("%s All %d tables passed validation", sym.done, len(m.ValidateSamples))))
+	default:
+		listWidth := width / 2
+		sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.renderValidateList(listWidth), "  ", m.renderRowInspector(width-listWidth-2)))
+		sb.WriteString("\n" + dimStyle.Render(strings.Join([]string{m.Keys.Help(ActDown, ActUp) + " select", m.Keys.Help(ActOpen) + " run again"}, "  "+sym.bullet+"  ")))
+	}
+	return panelStyle.Width(width).Render(sb.String())
+}
+
+// renderValidateList lists the failures, the selected one first.
+func (m Model) renderValidateList(width int) string {
+	var sb strings.Builder
+	sb.WriteString(errorStyle.Render(fmt.Sprintf("%d failures", len(m.ValidateIssues))) + "\n\n")
+	for i, e := range m.ValidateIssues {
+		if i < m.ValidateScroll {
+			continue
+		}
+		line := truncate(e.Table+" "+e.Error(), maxInt(width-4, 10))
+		if i == m.ValidateScroll {
+			sb.WriteString(keyStyle.Render(sym.selected+" ") + valueStyle.Render(line) + "\n")
+		} else {
+			sb.WriteString("  " + dimStyle.Render(line) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// renderRowInspector shows the row of the selected failure column by
+// column, with the offending cell highlighted.
+func (m Model) renderRowInspector(width int) string {
+	var sb strings.Builder
+	if m.ValidateScroll >= len(m.ValidateIssues) {
+		return ""
+	}
+	e := m.ValidateIssues[m.ValidateScroll]
+	sb.WriteString(titleStyle.Render(fmt.Sprintf("%s row %d", e.Table, e.Row)) + "\n\n")
+	cols, row := m.validateRow(e)
+	for _, col := range cols {
+		v := "NULL"
+		if val, ok := row[col]; !ok {
+			v = "(missing)"
+		} else if val != nil {
+			v = fmt.Sprintf("%v", val)
+		}
+		cell := fmt.Sprintf("%-18s %s", truncate(col, 17), truncate(v, maxInt(width-20, 10)))
+		if col == e.Column {
+			sb.WriteString(badgeError.Render(sym.selected+" "+cell) + "\n")
+		} else {
+			sb.WriteString(valueStyle.Render("  "+cell) + "\n")
+		}
+	}
+	sb.WriteString("\n" + highlightStyle.Render(truncate(e.Error(), maxInt(width-2, 10))) + "\n")
+	g := &validator.Group{Table: e.Table, Column: e.Column, Kind: e.Kind, Rule: e.Rule, Errors: []*validator.ValidationError{e}}
+	sb.WriteString(dimStyle.Width(width).Render("try: "+g.Suggestion()) + "\n")
+	return sb.String()
+}
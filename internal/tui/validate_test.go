@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satyammistari/db-seed-ai/internal/validator"
+)
+
+func TestValidateTabNavigation(t *testing.T) {
+	m := NewModel()
+	m.Width, m.Height = 120, 40
+	m.ActiveTab = TabPreview
+	next, _ := m.handlePreviewKey(tea.KeyMsg{Type: tea.KeyTab})
+	if got := next.(Model).ActiveTab; got != TabValidate {
+		t.Fatalf("Tab from Preview went to %v", got)
+	}
+	if tab, ok := m.Keys.switchTab(TabHelp, "tab"); !ok || tab != TabGenerate {
+		t.Errorf("Tab from Help = %v, %v", tab, ok)
+	}
+	if tab, ok := m.Keys.switchTab(TabGenerate, "%"); !ok || tab != TabHelp {
+		t.Errorf("Shift+5 = %v, %v", tab, ok)
+	}
+
+	m.ActiveTab = TabValidate
+	m.ValidateSamples = []ValidateSample{{
+		Table:   "users",
+		Columns: []string{"id", "role"},
+		Rows:    []map[string]interface{}{{"id": 1, "role": "admin"}, {"id": 2, "role": "owner"}},
+	}}
+	m.ValidateIssues = []*validator.ValidationError{
+		{Table: "users", Row: 2, Column: "role", Kind: validator.KindCheckIn, Got: "owner", Want: []string{"admin", "member"}},
+		{Table: "users", Row: 1, Column: "id", Kind: validator.KindNotNull},
+	}
+	cols, row := m.validateRow(m.ValidateIssues[0])
+	if len(cols) != 2 || row["role"] != "owner" {
+		t.Errorf("validateRow = %v, %v", cols, row)
+	}
+	if out := m.renderRowInspector(60); !strings.Contains(out, "users row 2") || !strings.Contains(out, "owner") {
+		t.Errorf("inspector:\n%s", out)
+	}
+	next, _ = m.handleValidateKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("J")})
+	if got := next.(Model).ValidateScroll; got != 1 {
+		t.Errorf("Shift+j selected %d, want 1", got)
+	}
+}
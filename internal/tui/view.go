@@ -26,10 +26,10 @@ func (m Model) renderHeader() string {
       ██ ██      ██      ██   ██ ██   ██ ██   ██ 
  ███████ ███████ ███████ ██████  ██████  ██████  
                                                   
-         🌱 AI-Powered Database Seeding Tool 🌱`)
+         ` + sym.tagline)
     
     tabs  := ""
-    for i := Tab(0); i < 4; i++ {
+    for i := Tab(0); i < tabCount; i++ {
         if i == m.ActiveTab {
             tabs += activeTabStyle.Render(i.String())
         } else {
@@ -56,6 +56,7 @@ func (m Model) renderContent() string {
     switch m.ActiveTab {
     case TabGenerate: return m.renderGenerateTab()
     case TabPreview:  return m.renderPreviewTab()
+    case TabValidate: return m.renderValidateTab()
     case TabHistory:  return m.renderHistoryTab()
     case TabHelp:     return m.renderHelpTab()
     }
@@ -66,9 +67,26 @@ func (m Model) renderGenerateTab() string {
     cw := m.Width - 4
     lw := cw / 2
     rw := cw - lw - 3
-    return lipgloss.JoinHorizontal(lipgloss.Top,
+    panels := lipgloss.JoinHorizontal(lipgloss.Top,
         m.renderConfigPanel(lw), "  ", m.renderProgressPanel(rw),
     )
+    if m.Recovery == nil || m.IsRunning { return panels }
+    return lipgloss.JoinVertical(lipgloss.Left, panels, m.renderRecoveryPanel(cw - 2))
+}
+
+// renderRecoveryPanel suggests what to do about the last failed seed.
+func (m Model) renderRecoveryPanel(width int) string {
+    var sb strings.Builder
+    sb.WriteString(errorStyle.Render(sym.fail+" "+m.Recovery.Title) + "\n")
+    if m.Err != nil {
+        sb.WriteString(dimStyle.Width(width - 4).Render(m.Err.Error()) + "\n")
+    }
+    sb.WriteString("\n")
+    for _, step := range m.Recovery.Steps {
+        sb.WriteString(valueStyle.Render("  "+sym.bullet+" "+step) + "\n")
+    }
+    sb.WriteString("\n" + RenderKeyBinding("r", "retry") + dimStyle.Render("  "+sym.bullet+"  ") + RenderKeyBinding(m.Keys.Help(ActBack), "dismiss"))
+    return panelStyle.BorderForeground(colorRed).Width(width).Render(sb.String())
 }
 
 func (m Model) renderConfigPanel(width int) string {
@@ -86,6 +104,7 @@ func (m Model) renderConfigPanel(width int) string {
         sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Left, lbl, m.Fields[fd.idx].View()) + "\n")
     }
 
+    if len(m.Targets) > 0 { sb.WriteString(m.renderTargetRow() + "\n") }
     sb.WriteString("\n" + labelStyle.Render("Style:"))
     for _, s := range []string{"realistic", "minimal", "edge-cases"} {
         if s == m.Config.Style {
@@ -97,7 +116,11 @@ func (m Model) renderConfigPanel(width int) string {
 
     sb.WriteString("\n\n")
     if m.IsRunning {
-        sb.WriteString(warningStyle.Render(m.Spinner.View() + " Generating..."))
+        if m.SeedTarget != "" {
+            sb.WriteString(warningStyle.Render(m.Spinner.View() + " Seeding " + m.SeedTarget + "..."))
+        } else {
+            sb.WriteString(warningStyle.Render(m.Spinner.View() + " Generating..."))
+        }
     } else {
         sb.WriteString(
             lipgloss.NewStyle().Foreground(colorBg).Background(colorCyan).Bold(true).Padding(0,3).Render("  Enter to Start  "),
@@ -118,12 +141,13 @@ func (m Model) renderProgressPanel(width int) string {
             sb.WriteString(RenderProgressBar(p.Percent()) + " ")
             sb.WriteString(dimStyle.Render(fmt.Sprintf("%-10s", fmt.Sprintf("%d/%d", p.RowsDone, p.RowsTotal))))
             switch p.Status {
-            case StatusDone:      sb.WriteString(badgeDone.Render("✓"))
+            case StatusDone:      sb.WriteString(badgeDone.Render(sym.done))
             case StatusRunning:   sb.WriteString(badgeRunning.Render(m.Spinner.View()))
-            case StatusInserting: sb.WriteString(badgeRunning.Render("↑"))
-            case StatusError:     sb.WriteString(badgeError.Render("✗"))
-            default:              sb.WriteString(badgeWaiting.Render("◦"))
+            case StatusInserting: sb.WriteString(badgeRunning.Render(sym.up))
+            case StatusError:     sb.WriteString(badgeError.Render(sym.fail))
+            default:              sb.WriteString(badgeWaiting.Render(sym.waiting))
             }
+            if t := p.Timing(time.Now()); t != "" { sb.WriteString(dimStyle.Render("  " + t)) }
             sb.WriteString("\n")
         }
 
@@ -133,7 +157,7 @@ func (m Model) renderProgressPanel(width int) string {
             sb.WriteString("Total  " + RenderProgressBar(overall))
             sb.WriteString(fmt.Sprintf("  %d%%  %s\n", int(overall*100), m.ElapsedTime()))
             if m.IsFinished() {
-                sb.WriteString("\n" + successStyle.Render(fmt.Sprintf("✓ Done → %d rows in %s", m.TotalRows, m.ElapsedTime())))
+                sb.WriteString("\n" + successStyle.Render(fmt.Sprintf("%s Done %s %d rows in %s", sym.done, sym.arrow, m.TotalRows, m.ElapsedTime())))
             }
         }
     }
@@ -149,31 +173,99 @@ func (m Model) renderPreviewTab() string {
         sb.WriteString(warningStyle.Render(m.Spinner.View() + " Generating preview..."))
     } else if len(m.PreviewRows) == 0 {
         sb.WriteString(dimStyle.Render("Press Enter to generate a 5-row preview.\nNothing will be inserted."))
+    } else if m.PreviewPalette {
+        sb.WriteString(m.renderColumnPalette())
+    } else if m.PreviewOpen && m.PreviewScroll < len(m.PreviewRows) {
+        sb.WriteString(m.renderPreviewRow(width))
     } else {
-        if len(m.PreviewCols) > 0 {
+        cols, left, right := m.previewGridColumns(width)
+        if len(cols) == 0 {
+            sb.WriteString(dimStyle.Render("Every column is hidden; press c to choose some.") + "\n")
+        } else {
             var hparts []string
-            for _, col := range m.PreviewCols {
+            for _, col := range cols {
                 hparts = append(hparts, highlightStyle.Render(fmt.Sprintf("%-18s", truncate(col, 17))))
             }
-            sb.WriteString(strings.Join(hparts, dimStyle.Render(" │ ")) + "\n")
+            sb.WriteString("  " + strings.Join(hparts, dimStyle.Render(" "+sym.sep+" ")))
+            if right > 0 { sb.WriteString(dimStyle.Render(fmt.Sprintf("  %d more %s", right, sym.arrow))) }
+            sb.WriteString("\n")
+            if left > 0 { sb.WriteString(dimStyle.Render(fmt.Sprintf("  %d columns to the left (h)", left)) + "\n") }
             sb.WriteString(dimStyle.Render(strings.Repeat("-", width)) + "\n")
 
             for i, row := range m.PreviewRows {
                 if i < m.PreviewScroll { continue }
+                mark := "  "
+                if i == m.PreviewScroll { mark = keyStyle.Render(sym.selected + " ") }
+                sb.WriteString(mark)
                 var rparts []string
-                for _, col := range m.PreviewCols {
+                for _, col := range cols {
                     v := "NULL"
                     if row[col] != nil { v = fmt.Sprintf("%v", row[col]) }
                     rparts = append(rparts, valueStyle.Render(fmt.Sprintf("%-18s", truncate(v, 17))))
                 }
-                sb.WriteString(strings.Join(rparts, dimStyle.Render(" │ ")) + "\n")
+                sb.WriteString(strings.Join(rparts, dimStyle.Render(" "+sym.sep+" ")) + "\n")
             }
         }
-        sb.WriteString("\n" + dimStyle.Render("↑↓ scroll  •  read-only"))
+        sb.WriteString("\n" + dimStyle.Render(strings.Join([]string{m.Keys.Help(ActDown, ActUp) + " select", m.Keys.Help(ActLeft, ActRight) + " scroll columns", "c columns", "Enter inspect row", "y/Y copy JSON/CSV", "w/W save", "r regenerate"}, "  "+sym.bullet+"  ")))
     }
     return panelStyle.Width(width).Render(sb.String())
 }
 
+// previewGridColumns returns the shown columns that fit in width from
+// PreviewColScroll on, and how many shown columns are left of and right of
+// them.
+func (m Model) previewGridColumns(width int) (cols []string, left, right int) {
+    visible := m.PreviewColumns()
+    if len(visible) == 0 { return nil, 0, 0 }
+    start := m.PreviewColScroll
+    if start > len(visible)-1 { start = len(visible) - 1 }
+    fit := maxInt((width-16)/21, 1) // 18 per cell and 3 per separator, room for the "more" marker
+    end := start + fit
+    if end > len(visible) { end = len(visible) }
+    return visible[start:end], start, len(visible) - end
+}
+
+// renderColumnPalette lists the preview's columns with whether each is
+// shown in the grid.
+func (m Model) renderColumnPalette() string {
+    var sb strings.Builder
+    sb.WriteString(valueStyle.Render(fmt.Sprintf("Columns of %s (%d of %d shown)", m.PreviewTable, len(m.PreviewColumns()), len(m.PreviewCols))) + "\n\n")
+    for i, col := range m.PreviewCols {
+        mark := "  "
+        if i == m.PreviewPaletteAt { mark = keyStyle.Render(sym.selected + " ") }
+        box, style := "[x] ", valueStyle
+        if m.PreviewHidden[col] { box, style = "[ ] ", dimStyle }
+        sb.WriteString(mark + style.Render(box+col) + dimStyle.Render("  "+m.PreviewTypes[col]) + "\n")
+    }
+    sb.WriteString("\n" + dimStyle.Render(strings.Join([]string{m.Keys.Help(ActDown, ActUp) + " select", "Space show/hide", "a show all", "c / " + m.Keys.Help(ActBack) + " back"}, "  "+sym.bullet+"  ")))
+    return sb.String()
+}
+
+// renderPreviewRow shows the selected preview row one column per line,
+// with its declared type and the whole value, wrapped.
+func (m Model) renderPreviewRow(width int) string {
+    var sb strings.Builder
+    row := m.PreviewRows[m.PreviewScroll]
+    sb.WriteString(valueStyle.Render(fmt.Sprintf("%s row %d of %d", m.PreviewTable, m.PreviewScroll+1, len(m.PreviewRows))) + "\n\n")
+    valueWidth := maxInt(width-48, 20)
+    for _, col := range m.PreviewCols {
+        v, ok := row[col]
+        var val string
+        switch {
+        case !ok:      val = dimStyle.Render("(not generated)")
+        case v == nil: val = dimStyle.Render("NULL")
+        default:       val = valueStyle.Width(valueWidth).Render(fmt.Sprintf("%v", v))
+        }
+        sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+            highlightStyle.Width(24).Render(truncate(col, 23)),
+            dimStyle.Width(20).Render(truncate(m.PreviewTypes[col], 19)),
+            val,
+        ) + "\n")
+    }
+    sb.WriteString("\n" + dimStyle.Render(strings.Join([]string{m.Keys.Help(ActDown, ActUp) + " next row", m.Keys.Help(ActOpen, ActBack) + " back"}, "  "+sym.bullet+"  ")))
+    return sb.String()
+}
+
 func (m Model) renderHistoryTab() string {
     var sb strings.Builder
     width := m.Width - 6
@@ -181,16 +273,20 @@ func (m Model) renderHistoryTab() string {
 
     if len(m.History) == 0 {
         sb.WriteString(dimStyle.Render("No runs yet.\nHistory appears here after you seed a database."))
+    } else if m.HistoryOpen && m.HistoryScroll < len(m.History) {
+        sb.WriteString(renderReport(m.History[m.HistoryScroll], width))
     } else {
         for i, h := range m.History {
             if i < m.HistoryScroll { continue }
-            icon := successStyle.Render("✓")  // checkmark
-            if !h.Success { icon = errorStyle.Render("✗") }  // X mark
+            icon := successStyle.Render(sym.done)
+            if !h.Success { icon = errorStyle.Render(sym.fail) }
+            if i == m.HistoryScroll { icon = keyStyle.Render(sym.selected) + icon } else { icon = " " + icon }
             ts     := dimStyle.Render(h.Timestamp.Format("Jan 02 15:04"))
             schema := valueStyle.Render(truncate(h.SchemaFile, 25))
             var stats string
             if h.Success {
-                stats = successStyle.Render(fmt.Sprintf("%d rows  %s", h.TotalRows, h.Duration.Round(time.Second)))
+                stats = successStyle.Render(fmt.Sprintf("%d rows  %s", h.TotalRows, h.Duration.Round(time.Second))) +
+                    dimStyle.Render(fmt.Sprintf("  %d tokens", h.Usage.TotalTokens()))
             } else {
                 stats = errorStyle.Render(truncate(h.ErrMsg, 30))
             }
@@ -199,29 +295,97 @@ func (m Model) renderHistoryTab() string {
                 sb.WriteString(dimStyle.Render(strings.Repeat("-", width-4)) + "\n")
             }
         }
+        sb.WriteString("\n" + dimStyle.Render(strings.Join([]string{m.Keys.Help(ActDown, ActUp) + " select", m.Keys.Help(ActOpen) + " open report", "r reload"}, "  "+sym.bullet+"  ")))
     }
     return panelStyle.Width(width).Render(sb.String())
 }
 
+// renderReport shows one run's report table by table.
+func renderReport(h HistoryEntry, width int) string {
+    var sb strings.Builder
+    sb.WriteString(valueStyle.Render(h.SchemaFile) + dimStyle.Render("  "+sym.arrow+"  "+h.Database+"  "+sym.bullet+"  "+h.Model) + "\n")
+    sb.WriteString(dimStyle.Render(h.Timestamp.Format("Jan 02 15:04:05")+"  "+sym.bullet+"  "+h.Duration.Round(time.Second).String()) + "\n\n")
+    r := h.Report
+    if r == nil {
+        sb.WriteString(dimStyle.Render("No report saved for this run."))
+        return sb.String()
+    }
+    if r.Error != "" {
+        sb.WriteString(errorStyle.Render(sym.fail+" "+r.Error) + "\n\n")
+    }
+    sb.WriteString(keyStyle.Render(fmt.Sprintf("  %-20s %-10s %9s %9s %9s %6s %7s %8s %8s %8s", "table", "status", "requested", "generated", "inserted", "retry", "issues", "gen", "insert", "rows/s")) + "\n")
+    slowest := -1
+    for i, t := range r.Tables {
+        if slowest < 0 || t.Seconds > r.Tables[slowest].Seconds { slowest = i }
+    }
+    for i, t := range r.Tables {
+        style := successStyle
+        switch {
+        case t.Status == "partial":
+            style = errorStyle
+        case t.Issues > 0:
+            style = warningStyle
+        }
+        rate := "-"
+        if t.Seconds > 0 && t.Generated > 0 { rate = fmt.Sprintf("%.0f", float64(t.Generated)/t.Seconds) }
+        line := style.Render(fmt.Sprintf("  %-20s %-10s %9d %9d %9d %6d %7d %7.1fs %7.1fs %8s",
+            truncate(t.Name, 20), t.Status, t.Requested, t.Generated, t.Inserted, t.FollowUps, t.Issues, t.Seconds-t.InsertSeconds, t.InsertSeconds, rate))
+        if i == slowest && len(r.Tables) > 1 { line += dimStyle.Render("  " + sym.arrow + " slowest") }
+        sb.WriteString(line + "\n")
+        for _, issue := range t.Samples {
+            sb.WriteString(dimStyle.Render("      "+truncate(issue, maxInt(width-10, 20))) + "\n")
+        }
+    }
+    sb.WriteString("\n" + dimStyle.Render(fmt.Sprintf("%d rows inserted  %s  %d validation issues  %s  %d model calls, %d tokens",
+        r.Inserted, sym.bullet, r.Issues, sym.bullet, r.Usage.Calls, r.Usage.PromptTokens+r.Usage.EvalTokens)) + "\n")
+    if h.ReportPath != "" {
+        sb.WriteString(dimStyle.Render(h.ReportPath) + "\n")
+    }
+    sb.WriteString("\n" + dimStyle.Render("Enter/Esc back"))
+    return sb.String()
+}
+
 func (m Model) renderHelpTab() string {
     var sb strings.Builder
     sb.WriteString(titleStyle.Render("Keyboard Shortcuts") + "\n\n")
 
+    km := m.Keys
     sections := []struct {
         title string
         keys  [][2]string
     }{
         {"Navigation", [][2]string{
-            {"Tab / Shift+Tab", "Switch tabs"},
-            {"Shift+1,2,3,4",  "Jump to tab"},
-            {"Shift+j / k",        "Navigate fields / scroll"},
-            {"Esc",             "Blur text fields"},
-            {"Shift+q / Ctrl+C",      "Quit"},
+            {km.Help(ActNextTab, ActPrevTab), "Switch tabs"},
+            {km.tabsHelp(),                   "Jump to tab"},
+            {km.Help(ActDown, ActUp),         "Navigate fields / scroll"},
+            {km.Help(ActTop, ActBottom),      "First / last"},
+            {km.All(ActBack),                 "Blur text fields / back"},
+            {km.All(ActQuit),                 "Quit"},
         }},
         {"Generate Tab", [][2]string{
-            {"Shift+i / j", "Focus next field"},
-            {"Shift+l / k", "Focus previous field"},
-            {"Enter", "Start seed pipeline"},
+            {km.All(ActNextField), "Focus next field"},
+            {km.All(ActPrevField), "Focus previous field"},
+            {km.Help(ActOpen),     "Start seed pipeline"},
+            {"t",                  "Next target from seeddb.yaml, then all of them"},
+            {"r",                  "Retry a failed seed"},
+        }},
+        {"Preview Tab", [][2]string{
+            {km.Help(ActOpen),           "Generate a preview, then open or close the selected row"},
+            {km.Help(ActDown, ActUp),    "Select a row"},
+            {km.Help(ActLeft, ActRight), "Scroll the grid a column left or right"},
+            {"c",                        "Choose the columns shown (Space toggles, a shows all)"},
+            {"y / Shift+y",              "Copy the rows to the clipboard as JSON / CSV"},
+            {"w / Shift+w",              "Save them to .seeddb/previews as JSON / CSV"},
+            {"r",                        "Generate a new preview"},
+        }},
+        {"Validate Tab", [][2]string{
+            {km.Help(ActOpen),        "Generate a sample of every table and validate it"},
+            {km.Help(ActDown, ActUp), "Select a failure; its row is shown with the cell highlighted"},
+        }},
+        {"History Tab", [][2]string{
+            {km.Help(ActDown, ActUp), "Select a run"},
+            {km.Help(ActOpen),        "Open or close its report"},
+            {"r",           "Reload reports from .seeddb/reports"},
         }},
         {"Config Fields", [][2]string{
             {"Schema",   "Path to your .sql file"},
@@ -263,19 +427,22 @@ func (m Model) renderStatusBar() string {
 
 func (m Model) renderKeyBar() string {
     keys := []string{
-        RenderKeyBinding("Tab","switch"),
-        RenderKeyBinding("Shift+j/k","navigate"),
-        RenderKeyBinding("Enter","run"),
-        RenderKeyBinding("Shift+1-4","tabs"),
-        RenderKeyBinding("Esc","blur"),
-        RenderKeyBinding("Shift+q","quit"),
+        RenderKeyBinding(m.Keys.Help(ActNextTab),"switch"),
+        RenderKeyBinding(m.Keys.Help(ActDown, ActUp),"navigate"),
+        RenderKeyBinding(m.Keys.Help(ActOpen),"run"),
+        RenderKeyBinding(m.Keys.tabsHelp(),"tabs"),
+        RenderKeyBinding(m.Keys.Help(ActBack),"blur"),
+        RenderKeyBinding(m.Keys.Help(ActQuit),"quit"),
     }
-    return dimStyle.Width(m.Width-4).Render("  " + strings.Join(keys, dimStyle.Render("  │  ")))
+    return dimStyle.Width(m.Width-4).Render("  " + strings.Join(keys, dimStyle.Render("  "+sym.sep+"  ")))
 }
 
+// truncate shortens s to n characters, counting runes so paths with
+// accented or CJK names are not cut mid-character.
 func truncate(s string, n int) string {
-    if len(s) <= n { return s }
-    return s[:n-1] + "…"  // ellipsis
+    r := []rune(s)
+    if len(r) <= n { return s }
+    return string(r[:n-1]) + sym.ellipsis
 }
 
 func maxInt(a, b int) int {
@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPreviewRowInspector(t *testing.T) {
+	m := NewModel()
+	m.Width, m.Height = 160, 40
+	m.ActiveTab = TabPreview
+	long := strings.Repeat("lorem ipsum ", 10) + "end"
+	next, _ := m.Update(previewReadyMsg{
+		table: "posts",
+		cols:  []string{"id", "body", "deleted_at"},
+		rows:  []map[string]interface{}{{"id": 1, "body": "short"}, {"id": 2, "body": long, "deleted_at": nil}},
+		types: map[string]string{"id": "integer not null", "body": "text", "deleted_at": "timestamp"},
+	})
+	m = next.(Model)
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("J")}, {Type: tea.KeyEnter}} {
+		next, _ = m.Update(key)
+		m = next.(Model)
+	}
+	if !m.PreviewOpen || m.PreviewScroll != 1 {
+		t.Fatalf("open = %v, row = %d", m.PreviewOpen, m.PreviewScroll)
+	}
+	out := m.renderPreviewRow(m.Width - 6)
+	for _, want := range []string{"posts row 2 of 2", "integer not null", "NULL", "end"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("inspector is missing %q:\n%s", want, out)
+		}
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if next.(Model).PreviewOpen {
+		t.Error("Esc did not close the row")
+	}
+}
+
+func TestPreviewColumns(t *testing.T) {
+	m := NewModel()
+	m.Width, m.Height = 100, 40
+	m.ActiveTab = TabPreview
+	cols := []string{"id", "a", "b", "c", "d", "e", "f"}
+	row := map[string]interface{}{}
+	for _, c := range cols {
+		row[c] = c + "-value"
+	}
+	next, _ := m.Update(previewReadyMsg{table: "wide", cols: cols, rows: []map[string]interface{}{row}})
+	m = next.(Model)
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			next, _ := m.Update(k)
+			m = next.(Model)
+		}
+	}
+	key := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	shown, left, right := m.previewGridColumns(m.Width - 6)
+	if shown[0] != "id" || left != 0 || right == 0 {
+		t.Fatalf("grid shows %v, %d left, %d right", shown, left, right)
+	}
+	press(key("l"), key("l"))
+	if shown, left, _ = m.previewGridColumns(m.Width - 6); shown[0] != "b" || left != 2 {
+		t.Errorf("after l l the grid shows %v, %d left", shown, left)
+	}
+
+	// Hide id and a from the palette.
+	press(key("c"), tea.KeyMsg{Type: tea.KeySpace}, key("J"), tea.KeyMsg{Type: tea.KeySpace}, tea.KeyMsg{Type: tea.KeyEsc})
+	if got := strings.Join(m.PreviewColumns(), ","); got != "b,c,d,e,f" {
+		t.Errorf("shown columns = %s", got)
+	}
+	if m.PreviewPalette {
+		t.Error("Esc did not close the palette")
+	}
+	press(key("c"), key("a"), key("c"))
+	if len(m.PreviewColumns()) != len(cols) {
+		t.Errorf("a did not show every column: %v", m.PreviewColumns())
+	}
+}
+
+func TestTableTiming(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tp := TableProgress{Name: "users", RowsDone: 40, Started: now.Add(-3 * time.Second)}
+	if got := tp.Timing(now); got != "gen 3s" {
+		t.Errorf("while generating: %q", got)
+	}
+	tp.Generating, tp.Inserting = 3*time.Second, time.Second
+	if got := tp.Timing(now); got != "gen 3.0s  ins 1.0s  10 rows/s" {
+		t.Errorf("when done: %q", got)
+	}
+	if got := (TableProgress{}).Timing(now); got != "" {
+		t.Errorf("before it starts: %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxListedValues is how many distinct offending values a suggestion names.
+const maxListedValues = 5
+
+// Group is the findings of one check on one column (or of one rule) in a
+// table, in row order.
+type Group struct {
+	Table  string
+	Column string
+	Kind   Kind
+	Rule   string
+	Errors []*ValidationError
+}
+
+// GroupErrors groups errs by table, column, kind and rule, in the order
+// each group is first found.
+func GroupErrors(errs []*ValidationError) []*Group {
+	var out []*Group
+	index := make(map[[4]string]*Group)
+	for _, e := range errs {
+		key := [4]string{e.Table, e.Column, string(e.Kind), e.Rule}
+		g := index[key]
+		if g == nil {
+			g = &Group{Table: e.Table, Column: e.Column, Kind: e.Kind, Rule: e.Rule}
+			index[key] = g
+			out = append(out, g)
+		}
+		g.Errors = append(g.Errors, e)
+	}
+	return out
+}
+
+// Rows returns the rows the group's findings are in.
+func (g *Group) Rows() []int {
+	out := make([]int, len(g.Errors))
+	for i, e := range g.Errors {
+		out[i] = e.Row
+	}
+	return out
+}
+
+// Summary describes the group in one line, e.g. `users.role: value not in
+// [admin member] (rows 2, 7)`.
+func (g *Group) Summary() string {
+	var what string
+	e := g.Errors[0]
+	switch g.Kind {
+	case KindNotNull:
+		what = "NOT NULL but got nil"
+		if g.all(func(e *ValidationError) bool { return e.Missing }) {
+			what = "NOT NULL but missing"
+		}
+	case KindCheckIn:
+		what = fmt.Sprintf("value not in %v", e.Want)
+	case KindLength:
+		what = "longer than " + strings.Join(e.Want, "")
+	case KindRule:
+		what = fmt.Sprintf("rule %q broken", g.Rule)
+	default:
+		what = string(g.Kind)
+	}
+	subject := g.Table
+	if g.Column != "" {
+		subject += "." + g.Column
+	}
+	rows := strings.Trim(fmt.Sprint(g.Rows()), "[]")
+	noun := "row"
+	if len(g.Errors) > 1 {
+		noun = "rows"
+		rows = strings.ReplaceAll(rows, " ", ", ")
+	}
+	return fmt.Sprintf("%s: %s (%s %s)", subject, what, noun, rows)
+}
+
+// Suggestion says what could be done about the group's findings, naming
+// the schema change or seeddb.yaml setting that would avoid them.
+func (g *Group) Suggestion() string {
+	key := g.Table + "." + g.Column
+	switch g.Kind {
+	case KindNotNull:
+		return fmt.Sprintf("give %s a DEFAULT in the schema, or values for the model with columns: %s in seeddb.yaml", g.Column, key)
+	case KindCheckIn:
+		return fmt.Sprintf("add %s to the allowed values if they are valid, or pin %s to them with columns: %s {values: [...], mode: direct}",
+			g.values(), g.Column, key)
+	case KindLength:
+		return fmt.Sprintf("widen %s beyond %s, or give the model shorter values with columns: %s in seeddb.yaml; --style edge-cases asks for strings near their limit",
+			g.Column, strings.Join(g.Errors[0].Want, ""), key)
+	case KindRule:
+		want := g.Errors[0].Want
+		return fmt.Sprintf("the rows did not meet %s; compute the column with derived: in seeddb.yaml, or check that the rule is right",
+			strings.Join(want, " AND "))
+	}
+	return ""
+}
+
+// all reports whether every finding in the group satisfies f.
+func (g *Group) all(f func(*ValidationError) bool) bool {
+	for _, e := range g.Errors {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// values lists the distinct values found, quoted, e.g. 'archived', 'old'.
+func (g *Group) values() string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, e := range g.Errors {
+		v := fmt.Sprint(e.Got)
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if len(out) == maxListedValues {
+			out = append(out, "...")
+			break
+		}
+		out = append(out, "'"+v+"'")
+	}
+	return strings.Join(out, ", ")
+}
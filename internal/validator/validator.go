@@ -1,31 +1,93 @@
+// Package validator checks generated rows against the schema and the
+// project's rules before they are inserted. Each finding is a
+// *ValidationError naming the table, row and column, what was broken and
+// what was wanted, so callers can report it as text or as data.
 package validator
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/satyammistari/db-seed-ai/internal/rules"
 	"github.com/satyammistari/db-seed-ai/internal/schema"
 )
 
-// ValidateRow checks one row against the table schema.
-func ValidateRow(t *schema.Table, row map[string]interface{}) []string {
-	var errs []string
+// Kind is the sort of check a row failed.
+type Kind string
+
+const (
+	KindNotNull Kind = "not-null" // a NOT NULL column is NULL or missing
+	KindCheckIn Kind = "check-in" // a value is not in the column's CHECK IN list
+	KindLength  Kind = "length"   // a value is longer than its varchar(n) or char(n) column
+	KindRule    Kind = "rule"     // a conditional rule from the project config does not hold
+)
+
+// ValidationError is one finding about one row.
+type ValidationError struct {
+	Table  string `json:"table"`
+	Row    int    `json:"row"`              // 1-based
+	Column string `json:"column,omitempty"` // "" for KindRule
+	Kind   Kind   `json:"kind"`
+	Rule   string `json:"rule,omitempty"` // the rule, for KindRule
+	// Got is the value found: nil when it is NULL, and for KindRule.
+	// Missing means the row left the column out altogether.
+	Got     interface{} `json:"got,omitempty"`
+	Missing bool        `json:"missing,omitempty"`
+	// Want is what would have passed: the allowed values for
+	// KindCheckIn, the declared type for KindLength, the conditions that
+	// failed for KindRule.
+	Want []string `json:"want,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	var msg string
+	switch e.Kind {
+	case KindNotNull:
+		if e.Missing {
+			msg = fmt.Sprintf("%s: NOT NULL but missing", e.Column)
+		} else {
+			msg = fmt.Sprintf("%s: NOT NULL but got nil", e.Column)
+		}
+	case KindCheckIn:
+		msg = fmt.Sprintf("%s: value %q not in %v", e.Column, e.Got, e.Want)
+	case KindLength:
+		msg = fmt.Sprintf("%s: %d characters, longer than %s", e.Column, utf8.RuneCountInString(fmt.Sprint(e.Got)), strings.Join(e.Want, ""))
+	case KindRule:
+		msg = fmt.Sprintf("rule %q: want %s", e.Rule, strings.Join(e.Want, " AND "))
+	default:
+		msg = fmt.Sprintf("%s: %s", e.Column, e.Kind)
+	}
+	if e.Row == 0 {
+		return msg
+	}
+	return fmt.Sprintf("row %d: %s", e.Row, msg)
+}
+
+// ValidateRow checks one row against the table schema. The findings have
+// Row 0; ValidateRows numbers them.
+func ValidateRow(t *schema.Table, row map[string]interface{}) []*ValidationError {
+	var errs []*ValidationError
 	for _, col := range t.Columns {
 		v, ok := row[col.Name]
 		if !ok {
 			if col.NotNull {
-				errs = append(errs, fmt.Sprintf("%s: NOT NULL but missing", col.Name))
+				errs = append(errs, &ValidationError{Table: t.Name, Column: col.Name, Kind: KindNotNull, Missing: true})
 			}
 			continue
 		}
 		if v == nil {
 			if col.NotNull {
-				errs = append(errs, fmt.Sprintf("%s: NOT NULL but got nil", col.Name))
+				errs = append(errs, &ValidationError{Table: t.Name, Column: col.Name, Kind: KindNotNull})
 			}
 			continue
 		}
+		s := fmt.Sprint(v)
+		if n := col.MaxLength(); n > 0 && utf8.RuneCountInString(s) > n {
+			errs = append(errs, &ValidationError{Table: t.Name, Column: col.Name, Kind: KindLength, Got: v, Want: []string{col.SQLType}})
+		}
 		if len(col.CheckIn) > 0 {
 			found := false
-			s := fmt.Sprint(v)
 			for _, allowed := range col.CheckIn {
 				if s == allowed {
 					found = true
@@ -33,7 +95,7 @@ func ValidateRow(t *schema.Table, row map[string]interface{}) []string {
 				}
 			}
 			if !found {
-				errs = append(errs, fmt.Sprintf("%s: value %q not in %v", col.Name, v, col.CheckIn))
+				errs = append(errs, &ValidationError{Table: t.Name, Column: col.Name, Kind: KindCheckIn, Got: v, Want: col.CheckIn})
 			}
 		}
 		// Type sanity (optional): we could check number/string format
@@ -42,17 +104,38 @@ func ValidateRow(t *schema.Table, row map[string]interface{}) []string {
 }
 
 // ValidateRows runs ValidateRow on each row and returns all errors.
-func ValidateRows(t *schema.Table, rows []map[string]interface{}) []string {
-	var errs []string
+func ValidateRows(t *schema.Table, rows []map[string]interface{}) []*ValidationError {
+	var errs []*ValidationError
 	for i, row := range rows {
-		rowErrs := ValidateRow(t, row)
-		for _, e := range rowErrs {
-			errs = append(errs, fmt.Sprintf("row %d: %s", i+1, e))
+		for _, e := range ValidateRow(t, row) {
+			e.Row = i + 1
+			errs = append(errs, e)
 		}
 	}
 	return errs
 }
 
+// ValidateRules checks each row against the conditional rules that apply
+// to the table.
+func ValidateRules(t *schema.Table, rows []map[string]interface{}, rs []*rules.Rule) []*ValidationError {
+	rs = rules.ForTable(rs, t.Name, func(c string) bool { return t.Column(c) != nil })
+	var errs []*ValidationError
+	for i, row := range rows {
+		for _, r := range rs {
+			if failed := r.Check(row); len(failed) > 0 {
+				errs = append(errs, &ValidationError{Table: t.Name, Row: i + 1, Kind: KindRule, Rule: r.String(), Want: failed})
+			}
+		}
+	}
+	return errs
+}
 
-
-
+// Messages returns the findings as text, e.g. "row 3: email: NOT NULL but
+// got nil".
+func Messages(errs []*ValidationError) []string {
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.Error()
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/satyammistari/db-seed-ai/internal/rules"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func TestValidateRows(t *testing.T) {
+	tbl := &schema.Table{Name: "users", Columns: []schema.Column{
+		{Name: "email", Type: "text", NotNull: true},
+		{Name: "role", Type: "text", CheckIn: []string{"admin", "member"}},
+	}}
+	errs := ValidateRows(tbl, []map[string]interface{}{
+		{"email": "a@example.com", "role": "admin"},
+		{"role": "owner"},
+	})
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), Messages(errs))
+	}
+	e := errs[0]
+	if e.Table != "users" || e.Row != 2 || e.Column != "email" || e.Kind != KindNotNull || !e.Missing {
+		t.Errorf("first error = %+v", e)
+	}
+	e = errs[1]
+	if e.Column != "role" || e.Kind != KindCheckIn || e.Got != "owner" || len(e.Want) != 2 {
+		t.Errorf("second error = %+v", e)
+	}
+	if got, want := e.Error(), `row 2: role: value "owner" not in [admin member]`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tbl := &schema.Table{Name: "orders", Columns: []schema.Column{
+		{Name: "status", Type: "text"}, {Name: "shipped_at", Type: "timestamp"},
+	}}
+	r, err := rules.New("orders", "status = 'shipped'", "shipped_at IS NOT NULL", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := ValidateRules(tbl, []map[string]interface{}{
+		{"status": "shipped", "shipped_at": "2024-01-02"},
+		{"status": "shipped", "shipped_at": nil},
+	}, []*rules.Rule{r})
+	if len(errs) != 1 || errs[0].Row != 2 || errs[0].Kind != KindRule || errs[0].Rule == "" || len(errs[0].Want) == 0 {
+		t.Fatalf("errors = %v", Messages(errs))
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	tbl := &schema.Table{Name: "posts", Columns: []schema.Column{
+		{Name: "status", Type: "text", CheckIn: []string{"draft", "published"}},
+		{Name: "title", Type: "text", SQLType: "varchar(10)"},
+	}}
+	errs := ValidateRows(tbl, []map[string]interface{}{
+		{"status": "archived", "title": "short"},
+		{"status": "draft", "title": "much too long a title"},
+		{"status": "archived", "title": "ok"},
+		{"status": "deleted", "title": "ok"},
+	})
+	groups := GroupErrors(errs)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	g := groups[0]
+	if g.Column != "status" || g.Kind != KindCheckIn || len(g.Errors) != 3 {
+		t.Fatalf("first group = %+v", g)
+	}
+	if got, want := g.Summary(), "posts.status: value not in [draft published] (rows 1, 3, 4)"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if s := g.Suggestion(); !strings.Contains(s, "add 'archived', 'deleted' to the allowed values") {
+		t.Errorf("Suggestion() = %q", s)
+	}
+	g = groups[1]
+	if g.Kind != KindLength || g.Summary() != "posts.title: longer than varchar(10) (row 2)" || !strings.Contains(g.Suggestion(), "widen title beyond varchar(10)") {
+		t.Errorf("second group = %q: %q", g.Summary(), g.Suggestion())
+	}
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/satyammistari/db-seed-ai/internal/cache"
+	"github.com/satyammistari/db-seed-ai/internal/config"
 	"github.com/satyammistari/db-seed-ai/internal/generator"
 	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pack"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
 	"github.com/satyammistari/db-seed-ai/internal/reporter"
 	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/stats"
 	"github.com/satyammistari/db-seed-ai/internal/tui"
 	"github.com/satyammistari/db-seed-ai/internal/validator"
 )
@@ -20,21 +29,38 @@ const version = "0.1.0"
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 	cmd := os.Args[1]
 	switch cmd {
 	case "ui":
-		if err := tui.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "UI error: %v\n", err)
-			os.Exit(1)
-		}
+		runUI(os.Args[2:])
 	case "preview":
 		runPreview(os.Args[2:])
 	case "seed":
 		runSeed(os.Args[2:])
 	case "validate":
 		runValidate(os.Args[2:])
+	case "graph":
+		runGraph(os.Args[2:])
+	case "shift":
+		runShift(os.Args[2:])
+	case "stream":
+		runStream(os.Args[2:])
+	case "stats":
+		runStats(os.Args[2:])
+	case "clean":
+		runClean(os.Args[2:])
+	case "dbdiff":
+		runDBDiff(os.Args[2:])
+	case "pack":
+		runPack(os.Args[2:])
+	case "unpack":
+		runUnpack(os.Args[2:])
+	case "codegen":
+		runCodegen(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
 	case "version", "-v", "--version":
 		fmt.Println("db-seed-ai v" + version)
 	case "help", "-h", "--help":
@@ -42,7 +68,7 @@ func main() {
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", cmd)
 		printUsage()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 }
 
@@ -50,27 +76,72 @@ func printUsage() {
 	fmt.Fprint(os.Stderr, `db-seed-ai — generate seed data with local AI
 
 Usage:
-  seeddb ui                                    Launch interactive terminal UI
-  seeddb preview  --schema <file> [--table <name>] [--rows N] [--model M]
-  seeddb seed     --schema <file> --db <conn> [--table <name>] [--rows N] [--dry-run] [--model M] [--batch-size N] [--style S]
-  seeddb validate --schema <file> [--rows N]
+  seeddb ui       [--keymap default|vim|emacs]
+  seeddb preview  --schema <file> [--table <name>] [--rows N] [--model M] [--lenient] [--config F] [--domain D] [--no-pii] [--debug-dir D] [--record D | --replay D] [--cache LOC] [--prompt-version V]
+  seeddb seed     --schema <file> --db <conn>... [--table <name>] [--rows N] [--dry-run] [--model M] [--batch-size N] [--style S]
+                  [--prompt-price USD] [--eval-price USD] [--max-followups N] [--create-tables] [--refresh-views]
+                  [--disable-triggers] [--top-up] [--skip-populated] [--drop-rejected] [--target-size SIZE] [--stats F] [--stats-scale X] [--ref-skew S] [--report F] [--lenient] [--config F] [--domain D] [--no-pii] [--debug-dir D] [--record D | --replay D] [--cache LOC] [--prompt-version V]
+                  [--chunk N] [--journal F] [--resume] [--ci] [--table-timeout D] [--run-id ID] [--tag-column C] [--ledger] [--minimal-viable] [--coverage]
+                  [--production-guard T] [--force] [--yes] [--ephemeral IMAGE] [--wait-db D] [--export F]
+                  [--idempotency-key K] [--all-targets]
+  seeddb validate --schema <file> [--rows N] [--lenient] [--config F] [--domain D] [--no-pii] [--debug-dir D] [--record D | --replay D] [--cache LOC] [--prompt-version V]
+  seeddb graph    --schema <file> [--format dot|mermaid] [--lenient]
+  seeddb shift    --schema <file> --db <conn> --days N [--hours N] [--table <name>] [--lenient] [--production-guard T] [--force] [--wait-db D]
+  seeddb stream   --schema <file> --db <conn> [--rate 10/s] [--tables a,b] [--duration D] [--chunk N] [--model M]
+                  [--lenient] [--config F] [--domain D] [--no-pii] [--production-guard T] [--force] [--wait-db D]
+  seeddb stats    --schema <file> --db <conn> [--table <name>] [--out F] [--top N] [--min-count N] [--lenient] [--wait-db D]
+  seeddb clean    --schema <file> --db <conn> (--run-id ID | --all) [--tag-column C] [--lenient] [--production-guard T] [--force] [--wait-db D]
+  seeddb dbdiff   --schema <file> --a <conn> --b <conn> [--table <name>] [--sample N] [--ignore COLS] [--config F] [--lenient] [--wait-db D]
+  seeddb pack     --schema <file> --record D [--config F] [--out F]
+  seeddb unpack   [--dir D] <pack.tar.gz>
+  seeddb codegen  --schema <file> --record D [--lang go] [--package P] [--out F] [--table <name>] [--lenient]
+  seeddb serve    [--addr HOST:PORT] [--db <conn>] [--model M] [--config F] [--domain D] [--cache LOC] [--prompt-version V] [--token T]
 
 Commands:
   ui        Launch interactive terminal UI (recommended)
   preview   Show generated rows (no DB)
   seed      Generate and insert into database
   validate  Generate sample and validate constraints
+  graph     Print the foreign key dependency graph (cycles highlighted)
+  shift     Move every timestamp in seeded tables by a fixed offset
+  stream    Keep inserting generated rows at a steady rate until stopped
+  stats     Profile a database's data shape (aggregates only) for seed --stats
+  clean     Delete the rows seed inserted, by run ID
+  dbdiff    Compare row counts and sampled rows of two databases
+  pack      Bundle schema, config, recorded responses and rows into one archive
+  unpack    Unpack an archive from pack for seed --replay
+  codegen   Write typed structs, named constants and loaders for recorded rows
+  serve     HTTP API for preview, validate and seed, with progress as server-sent events
   help      Show this help message
   version   Show version information
 `)
 }
 
-func loadSchema(path string) ([]*schema.Table, error) {
+func loadSchema(path string, lenient bool) ([]*schema.Table, error) {
+	s, err := loadFullSchema(path, lenient)
+	if err != nil {
+		return nil, err
+	}
+	return s.Tables, nil
+}
+
+// loadFullSchema parses the schema file. With lenient set, tables that
+// cannot be parsed are skipped and reported as warnings.
+func loadFullSchema(path string, lenient bool) (*schema.Schema, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("schema file: %w", err)
 	}
-	return schema.ParseFile(string(data))
+	s, err := schema.ParseSchema(string(data), schema.ParseOptions{Lenient: lenient})
+	if err != nil {
+		annotateParseErrors(path, reporter.LevelError, err)
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, e := range s.ParseErrors {
+		reporter.Warn(fmt.Sprintf("%s: skipped %v", path, e))
+	}
+	annotateParseErrors(path, reporter.LevelWarning, s.ParseErrors)
+	return s, nil
 }
 
 func runPreview(args []string) {
@@ -80,176 +151,632 @@ func runPreview(args []string) {
 	rows := fs.Int("rows", 5, "Number of rows")
 	model := fs.String("model", "llama3", "Ollama model")
 	style := fs.String("style", "realistic", "realistic, minimal, edge-cases")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	configPath := fs.String("config", "", "Project config with value dictionaries (default: ./seeddb.yaml if present)")
+	domain := fs.String("domain", "", "Domain preset: "+strings.Join(generator.DomainNames(), ", "))
+	noPII := fs.Bool("no-pii", false, "Only obviously fictional personal data (example.com emails, 555-01xx phones)")
+	debugDir := fs.String("debug-dir", "", "Save every prompt and raw model response to this directory")
+	recordDir := fs.String("record", "", "Save model responses to this directory for --replay")
+	replayDir := fs.String("replay", "", "Answer prompts from a --record directory instead of calling the model")
+	cacheLoc := fs.String("cache", "", "Share model responses through this cache: a directory, http(s)://, s3:// or gs:// URL (default: cache in seeddb.yaml)")
+	promptVersion := fs.String("prompt-version", generator.DefaultPromptVersion, "Prompt format: "+strings.Join(generator.PromptVersions, ", ")+" (an older one reproduces earlier runs)")
 	_ = fs.Parse(args)
+	packDefaults(*replayDir, schemaPath, configPath)
 
 	if *schemaPath == "" || *tableName == "" {
 		fmt.Fprintln(os.Stderr, "preview requires --schema and --table")
 		fs.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
-	tables, err := loadSchema(*schemaPath)
+	tables, err := loadSchema(*schemaPath, *lenient)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitSchema)
 	}
 	t := schema.TableByName(tables, *tableName)
 	if t == nil {
 		fmt.Fprintf(os.Stderr, "table %q not found in schema\n", *tableName)
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
 	cfg := generator.DefaultConfig()
 	cfg.Model = *model
+	cfg.NoPII = *noPII
+	f := applyConfig(*configPath, *domain, &cfg)
+	cfg.DebugDir = ensureDir("debug-dir", *debugDir)
+	cfg.RecordDir, cfg.ReplayDir = recordReplayDirs(*recordDir, *replayDir)
+	cfg.Cache = openCache(*cacheLoc, f.Cache)
+	cfg.PromptVersion = checkPromptVersion(*promptVersion)
 	cfg.Style = generator.Style(*style)
 
 	reporter.Info(fmt.Sprintf("  Asking %s to generate %d rows...\n", cfg.Model, *rows))
-	prompt := generator.BuildPrompt(t, *rows, nil, string(cfg.Style), nil)
-	raw, err := generator.CallOllama(cfg, prompt)
+	gen := generator.New(cfg)
+	gen.OnProgress(reportProgress)
+	result, err := gen.Generate(t, *rows, nil, string(cfg.Style), nil)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Ollama error:", err)
-		os.Exit(1)
-	}
-	colNames := columnNames(t)
-	parsed, err := generator.ParseJSONRows(raw, colNames)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Parse error:", err)
-		fmt.Fprintln(os.Stderr, "Raw response:", raw)
-		os.Exit(1)
+		os.Exit(exitModel)
 	}
 	reporter.Info("")
-	reporter.Table(colNames, parsed)
+	reporter.Table(columnNames(t), result.Rows)
 	reporter.Info("\n  Dry run — no data was inserted.")
 }
 
 func runSeed(args []string) {
 	fs := flag.NewFlagSet("seed", flag.ExitOnError)
 	schemaPath := fs.String("schema", "", "Path to .sql schema file")
-	dbConn := fs.String("db", "", "Database connection string")
-	tableName := fs.String("table", "", "Only this table (default: all)")
-	rows := fs.Int("rows", 100, "Rows per table")
+	dbFlag := addDBFlag(fs)
+	allTargets := fs.Bool("all-targets", false, "Insert the same rows into every database under targets in seeddb.yaml")
+	dbWait := addWaitFlag(fs)
+	tableName := fs.String("table", "", "Only these tables, comma-separated, and the parent tables they reference that have no rows yet (default: all)")
+	rows := fs.Int("rows", 100, "Rows per table (if unset: fewer for lookup tables, more for join tables and event logs)")
 	dryRun := fs.Bool("dry-run", false, "Generate but do not insert")
 	model := fs.String("model", "llama3", "Ollama model")
-	style := fs.String("style", "realistic", "realistic, minimal, edge-cases")
+	style := fs.String("style", "realistic", "realistic, minimal, edge-cases (if unset: minimal for join tables)")
 	batchSize := fs.Int("batch-size", 500, "Rows per INSERT batch")
+	dropRejected := fs.Bool("drop-rejected", false, "When the database rejects rows of a batch, retry its rows one at a time behind savepoints, drop the ones it still rejects and list them, instead of failing")
+	promptPrice := fs.Float64("prompt-price", 0, "USD per 1M prompt tokens (hosted models only)")
+	evalPrice := fs.Float64("eval-price", 0, "USD per 1M output tokens (hosted models only)")
+	maxFollowUps := fs.Int("max-followups", 3, "Extra prompts when the model returns too few rows")
+	createTables := fs.Bool("create-tables", false, "Run the schema's CREATE TABLE statements before seeding")
+	refreshViews := fs.Bool("refresh-views", false, "Refresh materialized views after seeding (Postgres)")
+	disableTriggers := fs.Bool("disable-triggers", false, "Disable triggers during seeding (Postgres, session_replication_role=replica)")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	configPath := fs.String("config", "", "Project config with value dictionaries (default: ./seeddb.yaml if present)")
+	domain := fs.String("domain", "", "Domain preset: "+strings.Join(generator.DomainNames(), ", "))
+	noPII := fs.Bool("no-pii", false, "Only obviously fictional personal data (example.com emails, 555-01xx phones)")
+	debugDir := fs.String("debug-dir", "", "Save every prompt and raw model response to this directory")
+	recordDir := fs.String("record", "", "Save model responses and the rows each table got to this directory for --replay (and pack)")
+	replayDir := fs.String("replay", "", "Answer prompts from a --record directory instead of calling the model")
+	cacheLoc := fs.String("cache", "", "Share model responses through this cache: a directory, http(s)://, s3:// or gs:// URL (default: cache in seeddb.yaml)")
+	promptVersion := fs.String("prompt-version", generator.DefaultPromptVersion, "Prompt format: "+strings.Join(generator.PromptVersions, ", ")+" (an older one reproduces earlier runs)")
+	topUp := fs.Bool("top-up", false, "Only generate the rows each table is missing to reach --rows")
+	skipPopulated := fs.Bool("skip-populated", false, "Skip the tables that already have at least --rows rows, e.g. lookups seeded by an earlier run")
+	targetSize := fs.String("target-size", "", "Pick --rows so the seeded tables take about this much space, e.g. 500MB")
+	reportPath := fs.String("report", "", "Write a JSON run report (rows, durations, follow-ups, validation) to this file")
+	refSkew := fs.Float64("ref-skew", 0, "Give sampled FK values power-law weights with this exponent, e.g. 1.1 (0: the model picks)")
+	statsPath := fs.String("stats", "", "Profile written by the stats command: match its distributions and table sizes")
+	statsScale := fs.Float64("stats-scale", 1, "With --stats, rows per table as a fraction of the profiled count (unless --rows or --target-size is set)")
+	chunkRows := fs.Int("chunk", pipeline.DefaultChunkRows, "Larger tables are generated, validated and inserted this many rows at a time")
+	journalPath := fs.String("journal", pipeline.DefaultJournalPath, "Record every committed batch here so --resume can continue after a crash")
+	resume := fs.Bool("resume", false, "Continue the run recorded in --journal: skip finished tables and committed batches")
+	ci := fs.Bool("ci", false, "Build-log output: no colors, one line per table, fail on any validation issue, per-table timeout")
+	tableTimeout := fs.Duration("table-timeout", 0, "Fail a table that takes longer than this to generate and insert (default 10m with --ci)")
+	skipTimeouts := fs.Bool("skip-timeouts", false, "Give up a table that runs past --table-timeout, and the tables referencing it, and seed the rest (exit 8 at the end)")
+	runID := fs.String("run-id", "", "ID to tag inserted rows with, for clean (default: start time plus a random suffix)")
+	tagColumn := fs.String("tag-column", inserter.DefaultTagColumn, "Tables with this column get the run ID in it")
+	ledger := fs.Bool("ledger", false, "Record the primary keys of rows in tables without --tag-column in "+inserter.LedgerTable)
+	coverage := fs.Bool("coverage", false, "Put every boundary of every column (NULL, max length, min/max, each allowed value, unicode) in some row and print a coverage matrix")
+	minimalViable := fs.Bool("minimal-viable", false, "Seed the fewest rows that show every CHECK value, boolean and NULL-able reference once")
+	yes := fs.Bool("yes", false, "Do not ask before a large run (see the estimate)")
+	ephemeral := fs.String("ephemeral", "", "Seed a throwaway database in a new Docker container of this Postgres image, e.g. postgres:16; prints its connection string and keeps it until Ctrl+C")
+	idempotencyKey := fs.String("idempotency-key", "", "Do nothing if a run that finished with this key and the same schema is recorded in the database ("+inserter.RunsTable+"), e.g. the CI pipeline ID")
+	exportPath := fs.String("export", "", "After seeding a SQLite database (e.g. --db sqlite::memory:), save it to this file: a .sql dump, or a SQLite database for any other name")
+	guard := addGuardFlags(fs)
 	_ = fs.Parse(args)
+	packDefaults(*replayDir, schemaPath, configPath)
+	// The first database gets the rows as usual; the others mirror it.
+	targets := resolveTargets(*dbFlag, *allTargets, *configPath)
+	dbConn := new(string)
+	if len(targets) > 0 {
+		*dbConn = targets[0].conn
+	}
 
 	if *schemaPath == "" {
 		fmt.Fprintln(os.Stderr, "seed requires --schema")
 		fs.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
+	}
+	if *ephemeral != "" {
+		if *dbConn != "" || *dryRun || *resume || *topUp {
+			fmt.Fprintln(os.Stderr, "--ephemeral makes its own database and cannot be combined with --db, --dry-run, --resume or --top-up")
+			os.Exit(exitUsage)
+		}
+		runEphemeral(*ephemeral, fs)
+		return
 	}
 	if !*dryRun && *dbConn == "" {
-		fmt.Fprintln(os.Stderr, "seed requires --db (or use --dry-run)")
+		fmt.Fprintln(os.Stderr, "seed requires --db (or use --dry-run or --ephemeral)")
 		fs.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
+	}
+	if *refSkew < 0 {
+		fmt.Fprintln(os.Stderr, "--ref-skew must not be negative")
+		os.Exit(exitUsage)
+	}
+	if *topUp && *dryRun {
+		fmt.Fprintln(os.Stderr, "--top-up counts rows in --db and cannot be combined with --dry-run")
+		os.Exit(exitUsage)
+	}
+	if *skipPopulated && *dryRun {
+		fmt.Fprintln(os.Stderr, "--skip-populated counts rows in --db and cannot be combined with --dry-run")
+		os.Exit(exitUsage)
+	}
+	if *minimalViable {
+		explicit := *topUp || *targetSize != ""
+		fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "rows" })
+		if explicit {
+			fmt.Fprintln(os.Stderr, "--minimal-viable picks the rows itself and cannot be combined with --rows, --target-size or --top-up")
+			os.Exit(exitUsage)
+		}
+	}
+	if *resume && (*dryRun || *topUp) {
+		fmt.Fprintln(os.Stderr, "--resume continues an interrupted insert and cannot be combined with --dry-run or --top-up")
+		os.Exit(exitUsage)
+	}
+	inMemory := !*dryRun && inserter.InMemory(*dbConn)
+	if inMemory && *resume {
+		fmt.Fprintln(os.Stderr, "--resume cannot continue in an in-memory database: it was gone when the last run ended")
+		os.Exit(exitUsage)
+	}
+	if *idempotencyKey != "" && *dryRun {
+		fmt.Fprintln(os.Stderr, "--idempotency-key is recorded in --db and cannot be combined with --dry-run")
+		os.Exit(exitUsage)
+	}
+	if *exportPath != "" && (*dryRun || !strings.HasPrefix(*dbConn, "sqlite:")) {
+		fmt.Fprintln(os.Stderr, "--export saves a SQLite --db and cannot be combined with --dry-run or another database")
+		os.Exit(exitUsage)
+	}
+	checkMirrorFlags(targets, *dryRun, *resume, *topUp, *dropRejected, *exportPath, *idempotencyKey)
+
+	if *ci {
+		reporter.NoColor, reporter.Quiet = true, true
+		if *tableTimeout == 0 {
+			*tableTimeout = defaultCITimeout
+		}
 	}
 
-	tables, err := loadSchema(*schemaPath)
+	var target int64
+	if *targetSize != "" {
+		var err error
+		if target, err = pipeline.ParseSize(*targetSize); err != nil {
+			fmt.Fprintln(os.Stderr, "--target-size:", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	full, err := loadFullSchema(*schemaPath, *lenient)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitSchema)
 	}
+	tables := full.Tables
 
 	order := tables
+	var subset []string // --table: these tables and the parents they need
 	if *tableName != "" {
-		t := schema.TableByName(tables, *tableName)
-		if t == nil {
-			fmt.Fprintf(os.Stderr, "table %q not found\n", *tableName)
-			os.Exit(1)
-		}
-		order = []*schema.Table{t}
+		subset = parseTableList(tables, *tableName)
+		order, _ = subsetTables(tables, subset, nil)
 	}
 
 	reporter.Info("db-seed-ai v" + version)
 	reporter.Info(fmt.Sprintf("Schema loaded:  %d tables", len(tables)))
-	var orderNames []string
-	for _, t := range order {
-		orderNames = append(orderNames, t.Name)
+	if subset == nil { // a --table run reports its order once the parents with rows are pruned
+		reporter.Info("Insert order:   " + joinNames(tableNames(order)))
+	}
+	if len(full.Views) > 0 {
+		reporter.Info(fmt.Sprintf("Views skipped:  %d", len(full.Views)))
+	}
+	if trig := full.InsertTriggers(); len(trig) > 0 && !*disableTriggers {
+		reporter.Warn(fmt.Sprintf("%d triggers fire on INSERT and may run during seeding (use --disable-triggers on Postgres):", len(trig)))
+		for _, tr := range trig {
+			reporter.Info(fmt.Sprintf("      %s on %s (%s %s)", tr.Name, tr.Table, tr.Timing, tr.Events))
+		}
 	}
-	reporter.Info("Insert order:   " + joinNames(orderNames))
-	reporter.Info("AI model: " + *model)
-	reporter.Info("")
 
 	cfg := generator.DefaultConfig()
 	cfg.Model = *model
+	cfg.NoPII = *noPII
+	f := applyConfig(*configPath, *domain, &cfg)
+	cfg.DebugDir = ensureDir("debug-dir", *debugDir)
+	cfg.RecordDir, cfg.ReplayDir = recordReplayDirs(*recordDir, *replayDir)
+	cfg.Cache = openCache(*cacheLoc, f.Cache)
+	cfg.PromptVersion = checkPromptVersion(*promptVersion)
 	cfg.Style = generator.Style(*style)
-
-	var dbObj *sql.DB
-	var driver string
-	if *dbConn != "" && !*dryRun {
-		var err error
-		dbObj, driver, err = inserter.Open(*dbConn)
+	cfg.Pricing = generator.Pricing{PromptPerMillion: *promptPrice, EvalPerMillion: *evalPrice}
+	cfg.MaxFollowUps = *maxFollowUps
+	rowsSet, styleSet := *targetSize != "", false
+	fs.Visit(func(f *flag.Flag) {
+		rowsSet = rowsSet || f.Name == "rows"
+		styleSet = styleSet || f.Name == "style"
+	})
+	reportArchetypes(full, order, cfg)
+	reporter.Info("AI model: " + *model)
+	reporter.Info("")
+	var tableRows map[string]int
+	if *statsPath != "" {
+		profile, err := stats.Load(*statsPath)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "db open:", err)
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, "stats:", err)
+			os.Exit(exitUsage)
 		}
-		defer dbObj.Close()
+		cfg.Stats = profile
+		if !rowsSet {
+			tableRows = scaledRows(profile, order, *statsScale)
+		}
+		reporter.Info("Matching data shape from " + *statsPath)
+	}
+	limits := f.Limits.Pipeline()
+	anchors := f.AnchorRows()
+	if err := pipeline.CheckAnchors(anchors, full); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	if len(anchors) > 0 && (*dbConn == "" || *dryRun) {
+		reporter.Warn("anchors are only inserted into a database; this run leaves them out")
+	}
+	if target == 0 { // --target-size picks the rows later; they are checked then
+		checkLimits(pipeline.Options{Schema: full, Rows: *rows, Config: cfg, TableRows: tableRows,
+			ArchetypeRows: !rowsSet, MinimalViable: *minimalViable, Coverage: *coverage, Limits: limits}, order)
 	}
 
-	reporter.Info("Generating seed data...")
-	totalInserted := 0
-	insertHeaderDone := false
-	for _, t := range order {
-		// Build ref IDs from already-inserted tables (so FKs reference real rows)
-		refIDs := make(map[string][]interface{})
-		if dbObj != nil {
-			for _, c := range t.Columns {
-				if c.ForeignKey != nil {
-					key := c.ForeignKey.RefTable + "." + c.ForeignKey.RefColumn
-					ids, err := inserter.FetchRefIDs(dbObj, c.ForeignKey.RefTable, c.ForeignKey.RefColumn, 1000)
-					if err == nil && len(ids) > 0 {
-						refIDs[key] = ids
-					}
+	var dbObj *sql.DB
+	var driver, schemaHash string
+	var mirrors []pipeline.Mirror
+	if *dbConn != "" && !*dryRun {
+		prepare := func(db *sql.DB, driver string) {
+			if *createTables || inMemory {
+				if err := inserter.CreateTables(db, driver, tables); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(dbExitCode(err))
 				}
+				reporter.Ok(fmt.Sprintf("Created %d tables (if missing)", len(tables)))
 			}
+			if *disableTriggers {
+				if err := inserter.DisableTriggers(db, driver); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(dbExitCode(err))
+				}
+				reporter.Ok("Triggers disabled for this session")
+			}
+		}
+		dbObj, driver = openDB(*dbConn, dbWait)
+		defer dbObj.Close()
+		guard.check("seed", *dbConn, dbObj, driver, f.Protect)
+		if *idempotencyKey != "" {
+			schemaHash = checkIdempotencyKey(dbObj, driver, *idempotencyKey, *schemaPath, *resume)
+		}
+		prepare(dbObj, driver)
+		mirrors = openMirrors(targets[1:], dbWait, guard, f.Protect, prepare)
+		for _, m := range mirrors {
+			defer m.DB.Close()
+		}
+		if subset != nil {
+			order = pruneParents(dbObj, tables, subset)
+		}
+		checkWritable("", dbObj, driver, order)
+		for _, m := range mirrors {
+			checkWritable(m.Name, m.DB, m.Driver, order)
 		}
+	}
+	if subset != nil {
+		reporter.Info("Insert order:   " + joinNames(tableNames(order)))
+		reportSubset(tables, order, subset)
+	}
 
-		prompt := generator.BuildPrompt(t, *rows, nil, string(cfg.Style), refIDs)
-		raw, err := generator.CallOllama(cfg, prompt)
+	if target > 0 {
+		reporter.Info(fmt.Sprintf("Sizing: generating %d sample rows per table...", pipeline.DefaultSizeSample))
+		plan, err := pipeline.PlanSize(context.Background(), pipeline.Options{
+			Schema: full,
+			Tables: order,
+			Config: cfg,
+			Driver: driver,
+		}, target, pipeline.DefaultSizeSample)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Ollama:", err)
-			os.Exit(1)
+			reporter.Err("sizing: " + err.Error())
+			os.Exit(runExitCode(err, 0))
 		}
-		colNames := columnNames(t)
-		parsed, err := generator.ParseJSONRows(raw, colNames)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Parse:", err)
-			os.Exit(1)
+		for _, t := range order {
+			reporter.Info(fmt.Sprintf("    %-20s ~%.0f bytes/row", t.Name, plan.RowBytes[t.Name]))
 		}
-		reporter.Ok(fmt.Sprintf("%-20s %d rows", t.Name, len(parsed)))
+		*rows = plan.Rows
+		reporter.Ok(fmt.Sprintf("%d rows per table for ~%s of table data (target %s)",
+			plan.Rows, pipeline.FormatSize(plan.Bytes), pipeline.FormatSize(target)))
+		reporter.Info("")
+	}
+
+	runOpts := pipeline.Options{
+		Schema:         full,
+		Tables:         order,
+		Rows:           *rows,
+		Config:         cfg,
+		DB:             dbObj,
+		Driver:         driver,
+		TableRows:      tableRows,
+		ArchetypeRows:  !rowsSet,
+		ArchetypeStyle: !styleSet,
+		MinimalViable:  *minimalViable,
+		Coverage:       *coverage,
+		RefSkew:        *refSkew,
+		BatchSize:      *batchSize,
+		ChunkRows:      *chunkRows,
+		TopUp:          *topUp,
+		SkipPopulated:  *skipPopulated,
+		DropRejected:   *dropRejected,
+		TableTimeout:   *tableTimeout,
+		SkipTimedOut:   *skipTimeouts,
+		FailOnIssues:   *ci,
+		Limits:         limits,
+		Anchors:        anchors,
+		Mirrors:        mirrors,
+	}
+	if target > 0 {
+		checkLimits(runOpts, order)
+	}
+	if len(mirrors) > 0 {
+		if err := pipeline.CheckMirrors(runOpts, order); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(dbExitCode(err))
+		}
+		reporter.Info("Mirrored to:    " + joinTargetNames(targets[1:]))
+	}
+	if !*yes && !*ci && !*resume && cfg.ReplayDir == "" {
+		confirmLargeRun(runOpts, order)
+	}
 
-		if *dryRun || dbObj == nil {
-			continue
+	var journal *pipeline.Journal
+	var resumed *pipeline.Resume
+	var tag *inserter.Tag
+	if dbObj != nil {
+		tag = &inserter.Tag{RunID: *runID, Column: *tagColumn, Ledger: *ledger}
+		if tag.RunID == "" {
+			tag.RunID = inserter.NewRunID(time.Now())
 		}
-		if !insertHeaderDone {
-			reporter.Info("\nInserting into database...")
-			insertHeaderDone = true
+		if !inMemory {
+			journal, resumed = openJournal(*journalPath, *schemaPath, tag.RunID, dbObj, *resume)
+			defer journal.Close()
 		}
-		inserted := 0
-		for i := 0; i < len(parsed); i += *batchSize {
-			end := i + *batchSize
-			if end > len(parsed) {
-				end = len(parsed)
+		if resumed != nil && resumed.RunID != "" && *runID == "" {
+			tag.RunID = resumed.RunID
+		}
+		reporter.Info("Run ID:         " + tag.RunID)
+	}
+
+	if *dryRun {
+		reporter.Info("Generating seed data...")
+	} else {
+		reporter.Info("Generating and inserting seed data...")
+	}
+	progress := func(ev pipeline.Event) {
+		switch ev.Stage {
+		case pipeline.StageGenerating:
+			reportProgress(ev.Progress)
+		case pipeline.StageSkipped:
+			reporter.Ok(fmt.Sprintf("%-20s already has %d rows", ev.Table, ev.Result.Existing))
+		case pipeline.StageAbandoned:
+			reporter.Warn(fmt.Sprintf("%-20s given up: %v", ev.Table, ev.Err))
+		case pipeline.StageGenerated:
+			reportGenerated(ev.Result)
+			if ev.Result.Dropped > 0 {
+				reporter.Warn(fmt.Sprintf("    %d rows dropped: they repeat UNIQUE values already in %s", ev.Result.Dropped, ev.Table))
+			}
+		case pipeline.StageInserted:
+			if ev.Result.Existing > 0 {
+				reporter.Ok(fmt.Sprintf("%-20s %d inserted (%d already there)", ev.Table, ev.Result.Inserted, ev.Result.Existing))
+			} else {
+				reporter.Ok(fmt.Sprintf("%-20s %d inserted", ev.Table, ev.Result.Inserted))
 			}
-			batch := parsed[i:end]
-			n, err := inserter.InsertBatch(dbObj, driver, t.Name, colNames, batch)
-			if err != nil {
-				reporter.Err(fmt.Sprintf("%s: %v", t.Name, err))
-				os.Exit(1)
+			reportRejected(ev.Result)
+			if ev.Result.BatchSize < *batchSize {
+				reporter.Warn(fmt.Sprintf("%-20s inserted %d rows at a time: larger batches were too large for the database", ev.Table, ev.Result.BatchSize))
 			}
-			inserted += n
 		}
-		totalInserted += inserted
-		reporter.Ok(fmt.Sprintf("%-20s %d inserted", t.Name, inserted))
 	}
+	if *ci {
+		progress = ciProgress(*dryRun)
+	}
+	start := time.Now()
+	runOpts.Journal, runOpts.Resume, runOpts.Tag = journal, resumed, tag
+	if *idempotencyKey != "" {
+		claimIdempotencyKey(dbObj, driver, *idempotencyKey, schemaHash, tag.RunID)
+	}
+	res, err := pipeline.Run(context.Background(), runOpts, progress)
+	if *idempotencyKey != "" {
+		settleIdempotencyKey(dbObj, driver, *idempotencyKey, schemaHash, tag.RunID, err == nil && len(res.Abandoned()) == 0,
+			res != nil && res.Inserted > 0)
+	}
+	if *reportPath != "" {
+		rep := pipeline.NewReport(res, err, start)
+		rep.Schema, rep.Database, rep.Model = *schemaPath, pipeline.RedactConn(*dbConn), cfg.Model
+		if tag != nil {
+			rep.RunID = tag.RunID
+		}
+		if werr := rep.Save(*reportPath); werr != nil {
+			reporter.Warn("report: " + werr.Error())
+		}
+	}
+	issueLevel := reporter.LevelWarning
+	if *ci {
+		issueLevel = reporter.LevelError
+	}
+	for _, tr := range res.Tables {
+		annotateIssues(*schemaPath, full, tr, issueLevel)
+	}
+	if *ci {
+		code := 0
+		if err != nil {
+			code = runExitCode(err, res.Inserted)
+		} else if len(res.Abandoned()) > 0 {
+			code = exitTimeout
+		}
+		ciSummary(res, code, time.Since(start))
+		if err != nil {
+			os.Exit(code)
+		}
+	}
+	if err != nil {
+		reporter.Err(err.Error())
+		os.Exit(runExitCode(err, res.Inserted))
+	}
+	for _, a := range res.Anchors {
+		if a.Inserted {
+			reporter.Ok(fmt.Sprintf("%-20s anchor %s inserted", a.Table, a.Name))
+		} else {
+			reporter.Ok(fmt.Sprintf("%-20s anchor %s already there", a.Table, a.Name))
+		}
+	}
+	if *coverage {
+		if n := printCoverage(res, full, cfg); n > 0 {
+			reporter.Warn(fmt.Sprintf("%d boundary cases got no row: the model returned too few rows", n))
+		}
+	}
+	usage := res.Usage
+	totalInserted := res.Inserted
 
+	reporter.Info("")
+	reporter.Info("Model usage:    " + usage.String())
+	if !cfg.Pricing.IsZero() {
+		reporter.Info(fmt.Sprintf("Estimated cost: $%.4f", usage.Cost(cfg.Pricing)))
+	}
 	if *dryRun {
 		reporter.Info("\nDry run — no data inserted.")
+		exitIfAbandoned(res)
 		return
 	}
+	if *refreshViews {
+		if mv := full.MaterializedViews(); len(mv) > 0 && dbObj != nil {
+			if err := inserter.RefreshMaterializedViews(dbObj, driver, mv); err != nil {
+				reporter.Warn("refresh views: " + err.Error())
+			} else {
+				reporter.Ok(fmt.Sprintf("Refreshed %d materialized views", len(mv)))
+			}
+		}
+	}
 	reporter.Info("")
 	reporter.Ok(fmt.Sprintf("Done — %d rows inserted across %d tables", totalInserted, len(order)))
+	for _, m := range mirrors {
+		reporter.Ok(fmt.Sprintf("Same %d rows inserted into %s", res.Mirrored[m.Name], m.Name))
+	}
+	if *exportPath != "" {
+		if err := inserter.Export(dbObj, *exportPath); err != nil {
+			reporter.Err(err.Error())
+			os.Exit(exitFailure)
+		}
+		reporter.Ok("Exported to " + *exportPath)
+	} else if inMemory {
+		reporter.Warn("the in-memory database is gone now; use --export to keep it")
+	}
+	exitIfAbandoned(res)
+}
+
+// checkIdempotencyKey returns the hash of the schema file at schemaPath,
+// and exits without seeding if a run with key for that schema is recorded
+// in db (see exitHeldKey). A failed run is left to the claim when
+// resuming, which lets the same run take its key back. It is checked again
+// when the run claims the key, right before it inserts.
+func checkIdempotencyKey(db *sql.DB, driver, key, schemaPath string, resuming bool) string {
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "schema file:", err)
+		os.Exit(exitSchema)
+	}
+	hash := inserter.SchemaHash(string(data))
+	run, err := inserter.FindRun(db, driver, key, hash)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "idempotency key:", err)
+		os.Exit(dbExitCode(err))
+	}
+	if run != nil && !(run.Failed() && resuming) {
+		exitHeldKey(run)
+	}
+	return hash
+}
+
+// claimIdempotencyKey records that run runID holds key for the schema
+// hash, or exits if another run holds it already.
+func claimIdempotencyKey(db *sql.DB, driver, key, hash, runID string) {
+	run, err := inserter.ClaimRun(db, driver, key, hash, runID, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "idempotency key:", err)
+		os.Exit(dbExitCode(err))
+	}
+	if run != nil {
+		exitHeldKey(run)
+	}
+}
+
+// settleIdempotencyKey marks the claim of key finished. A run that did not
+// seed everything gives the key up if it inserted nothing, so a retry
+// seeds again, and otherwise keeps it marked failed, so a retry stops
+// instead of seeding on top of its rows.
+func settleIdempotencyKey(db *sql.DB, driver, key, hash, runID string, finished, inserted bool) {
+	switch {
+	case finished:
+		if err := inserter.FinishRun(db, driver, key, hash, time.Now()); err != nil {
+			reporter.Warn("idempotency key not recorded (a retry will seed again): " + err.Error())
+		}
+		return
+	case inserted:
+		if err := inserter.FailRun(db, driver, key, hash, time.Now()); err != nil {
+			reporter.Warn(fmt.Sprintf("idempotency key still marked as seeding (clean --run-id %s to free it): %v", runID, err))
+			return
+		}
+		reporter.Warn(fmt.Sprintf("idempotency key %q kept by this failed run: seed --resume finishes it, clean --run-id %s removes its rows and frees the key", key, runID))
+		return
+	}
+	if err := inserter.ReleaseRun(db, driver, key, hash); err != nil {
+		reporter.Warn(fmt.Sprintf("idempotency key still held by this failed run (clean --run-id to free it): %v", err))
+	}
+}
+
+// exitHeldKey exits without seeding because run holds its key: with 0 if
+// it finished, so a retried pipeline goes on, and with exitFailure while
+// it is still seeding or if it failed, so nothing goes on with half the
+// rows.
+func exitHeldKey(run *inserter.Run) {
+	if run.Finished() {
+		reporter.Ok(fmt.Sprintf("Already seeded by run %s at %s (idempotency key %q); nothing to do", run.RunID, run.FinishedAt, run.Key))
+		os.Exit(0)
+	}
+	if run.Failed() {
+		fmt.Fprintf(os.Stderr, "run %s failed under idempotency key %q at %s with some rows inserted; clean --run-id %s removes them and frees the key, or seed --resume finishes the run\n",
+			run.RunID, run.Key, run.FailedAt, run.RunID)
+		os.Exit(exitFailure)
+	}
+	fmt.Fprintf(os.Stderr, "run %s has been seeding under idempotency key %q since %s; if it is gone, clean --run-id %s frees the key\n",
+		run.RunID, run.Key, run.StartedAt, run.RunID)
+	os.Exit(exitFailure)
+}
+
+// exitIfAbandoned lists the tables a --skip-timeouts run gave up and exits
+// with exitTimeout if there are any.
+func exitIfAbandoned(res *pipeline.Result) {
+	gaveUp := res.Abandoned()
+	if len(gaveUp) == 0 {
+		return
+	}
+	reporter.Warn(fmt.Sprintf("%d tables were given up:", len(gaveUp)))
+	for _, tr := range gaveUp {
+		reporter.Info(fmt.Sprintf("      %-20s %v (%d rows inserted)", tr.Name, tr.Err, tr.Inserted))
+	}
+	os.Exit(exitTimeout)
+}
+
+func runUI(args []string) {
+	fs := flag.NewFlagSet("ui", flag.ExitOnError)
+	keymap := fs.String("keymap", "", "Key bindings: "+strings.Join(tui.KeymapNames(), ", ")+" (default: ui.keymap in seeddb.yaml, else default)")
+	_ = fs.Parse(args)
+
+	// The UI reads ./seeddb.yaml for its runs too.
+	f, err := config.Load("")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	profile := f.UI.Keymap
+	if *keymap != "" {
+		profile = *keymap
+	}
+	keys, err := tui.NewKeymap(profile, f.UI.Keys)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	if err := tui.Run(keys, f.Targets); err != nil {
+		fmt.Fprintf(os.Stderr, "UI error: %v\n", err)
+		os.Exit(exitFailure)
+	}
 }
 
 func runValidate(args []string) {
@@ -257,48 +784,244 @@ func runValidate(args []string) {
 	schemaPath := fs.String("schema", "", "Path to .sql schema file")
 	rows := fs.Int("rows", 10, "Sample rows to generate")
 	model := fs.String("model", "llama3", "Ollama model")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	configPath := fs.String("config", "", "Project config with value dictionaries (default: ./seeddb.yaml if present)")
+	domain := fs.String("domain", "", "Domain preset: "+strings.Join(generator.DomainNames(), ", "))
+	noPII := fs.Bool("no-pii", false, "Only obviously fictional personal data (example.com emails, 555-01xx phones)")
+	debugDir := fs.String("debug-dir", "", "Save every prompt and raw model response to this directory")
+	recordDir := fs.String("record", "", "Save model responses to this directory for --replay")
+	replayDir := fs.String("replay", "", "Answer prompts from a --record directory instead of calling the model")
+	cacheLoc := fs.String("cache", "", "Share model responses through this cache: a directory, http(s)://, s3:// or gs:// URL (default: cache in seeddb.yaml)")
+	promptVersion := fs.String("prompt-version", generator.DefaultPromptVersion, "Prompt format: "+strings.Join(generator.PromptVersions, ", ")+" (an older one reproduces earlier runs)")
 	_ = fs.Parse(args)
+	packDefaults(*replayDir, schemaPath, configPath)
 
 	if *schemaPath == "" {
 		fmt.Fprintln(os.Stderr, "validate requires --schema")
 		fs.PrintDefaults()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
-	tables, err := loadSchema(*schemaPath)
+	full, err := loadFullSchema(*schemaPath, *lenient)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitSchema)
 	}
 
 	cfg := generator.DefaultConfig()
 	cfg.Model = *model
-	var allErrs []string
-	for _, t := range tables {
-		prompt := generator.BuildPrompt(t, *rows, nil, string(generator.StyleRealistic), nil)
-		raw, err := generator.CallOllama(cfg, prompt)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Ollama:", err)
-			os.Exit(1)
+	cfg.NoPII = *noPII
+	f := applyConfig(*configPath, *domain, &cfg)
+	cfg.DebugDir = ensureDir("debug-dir", *debugDir)
+	cfg.RecordDir, cfg.ReplayDir = recordReplayDirs(*recordDir, *replayDir)
+	cfg.Cache = openCache(*cacheLoc, f.Cache)
+	cfg.PromptVersion = checkPromptVersion(*promptVersion)
+	res, err := pipeline.Run(context.Background(), pipeline.Options{
+		Schema: full,
+		Rows:   *rows,
+		Config: cfg,
+	}, func(ev pipeline.Event) {
+		if ev.Stage == pipeline.StageGenerating {
+			reportProgress(ev.Progress)
 		}
-		colNames := columnNames(t)
-		parsed, err := generator.ParseJSONRows(raw, colNames)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(runExitCode(err, 0))
+	}
+	var allErrs []*validator.ValidationError
+	for _, tr := range res.Tables {
+		annotateIssues(*schemaPath, full, tr, reporter.LevelError)
+		allErrs = append(allErrs, tr.Issues...)
+	}
+	if len(allErrs) > 0 {
+		for _, g := range validator.GroupErrors(allErrs) {
+			reporter.Err(g.Summary())
+			reporter.Info("      try: " + g.Suggestion())
+		}
+		os.Exit(exitValidation)
+	}
+	reporter.Ok("All generated rows passed validation")
+}
+
+// applyConfig loads the project config into cfg and returns it. A
+// --domain flag overrides the config's domain.
+func applyConfig(path, domain string, cfg *generator.Config) *config.File {
+	f, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	f.Apply(cfg)
+	if domain != "" {
+		if cfg.Domain, err = generator.LookupDomain(domain); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+	}
+	return f
+}
+
+// checkPromptVersion exits with exitUsage unless v names a prompt format.
+func checkPromptVersion(v string) string {
+	if err := generator.CheckPromptVersion(v); err != nil {
+		fmt.Fprintln(os.Stderr, "--prompt-version:", err)
+		os.Exit(exitUsage)
+	}
+	return v
+}
+
+// openCache opens the --cache location, or the config's when the flag is
+// not given. A cache that stops answering only costs model calls, so its
+// failures are a warning.
+func openCache(flagLoc, configLoc string) cache.Backend {
+	loc := flagLoc
+	if loc == "" {
+		loc = configLoc
+	}
+	if loc == "" {
+		return nil
+	}
+	b, err := cache.Open(loc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	return cache.Tolerant(b, func(err error) {
+		reporter.Warn("cache: " + err.Error() + " (calling the model instead)")
+	})
+}
+
+// ensureDir creates the directory given with flag, --debug-dir or
+// --record, if one was given.
+func ensureDir(flag, dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "--%s: %v\n", flag, err)
+		os.Exit(exitFailure)
+	}
+	return dir
+}
+
+// recordReplayDirs checks the --record and --replay flags, creating the
+// record directory if needed. A --replay pack directory (see seeddb
+// unpack) replays its recordings.
+func recordReplayDirs(record, replay string) (string, string) {
+	if record != "" && replay != "" {
+		fmt.Fprintln(os.Stderr, "--record and --replay cannot be used together")
+		os.Exit(exitUsage)
+	}
+	if replay != "" {
+		if fi, err := os.Stat(replay); err != nil || !fi.IsDir() {
+			fmt.Fprintf(os.Stderr, "replay dir %s: not a directory\n", replay)
+			os.Exit(exitUsage)
+		}
+		if _, ok := pack.Read(replay); ok {
+			replay = filepath.Join(replay, pack.RecordingsDir)
+		}
+	}
+	return ensureDir("record", record), replay
+}
+
+// openJournal starts the seed journal at path, or with resume set reads
+// the interrupted run recorded there and reports how far it got. It exits
+// if there is nothing to resume. A journal that cannot be written only
+// costs the ability to resume, so that is a warning.
+func openJournal(path, schemaPath, runID string, db *sql.DB, resume bool) (*pipeline.Journal, *pipeline.Resume) {
+	if !resume {
+		j, err := pipeline.CreateJournal(path, schemaPath, runID)
 		if err != nil {
-			allErrs = append(allErrs, t.Name+": parse error - "+err.Error())
-			continue
+			reporter.Warn("journal: " + err.Error() + " (--resume will not be possible)")
 		}
-		errs := validator.ValidateRows(t, parsed)
-		for _, e := range errs {
-			allErrs = append(allErrs, t.Name+": "+e)
+		return j, nil
+	}
+	j, r, err := pipeline.ResumeJournal(path, db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "resume:", err)
+		os.Exit(exitUsage)
+	}
+	if r.Finished {
+		reporter.Ok("The last run finished; nothing to resume")
+		os.Exit(0)
+	}
+	if r.Schema != schemaPath {
+		reporter.Warn(fmt.Sprintf("the interrupted run seeded %s, not %s", r.Schema, schemaPath))
+	}
+	names := make([]string, 0, len(r.Tables))
+	for name := range r.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tp := r.Tables[name]
+		if tp.Done {
+			reporter.Info(fmt.Sprintf("Resuming: %-20s done", name))
+		} else {
+			reporter.Info(fmt.Sprintf("Resuming: %-20s %d of %d rows in", name, tp.Inserted, tp.Want))
 		}
 	}
-	if len(allErrs) > 0 {
-		for _, e := range allErrs {
-			reporter.Err(e)
+	return j, r
+}
+
+// reportGenerated prints the per-table generation summary.
+func reportGenerated(tr *pipeline.TableResult) {
+	gr := tr.Generated
+	if tr.Short() {
+		reporter.Warn(fmt.Sprintf("%-20s %d of %d rows after %d follow-up prompts",
+			tr.Name, tr.Rows, gr.Requested, gr.FollowUps))
+	} else {
+		reporter.Ok(fmt.Sprintf("%-20s %d rows  (%d tokens)", tr.Name, tr.Rows, gr.Usage.TotalTokens()))
+	}
+	if len(gr.Repairs.Actions) > 0 {
+		reporter.Warn(fmt.Sprintf("%-20s repaired: %s", tr.Name, gr.Repairs.Summary()))
+	}
+	if len(tr.Issues) > 0 {
+		reporter.Warn(fmt.Sprintf("%-20s %d validation issues, first: %s", tr.Name, len(tr.Issues), tr.Issues[0]))
+	}
+}
+
+// reportRejected lists the rows the database refused and --drop-rejected
+// dropped, the first few with the database's reason.
+func reportRejected(tr *pipeline.TableResult) {
+	if len(tr.Rejected) == 0 {
+		return
+	}
+	reporter.Warn(fmt.Sprintf("%-20s %d rows rejected by the database and dropped:", tr.Name, len(tr.Rejected)))
+	for _, r := range tr.Rejected[:min(len(tr.Rejected), maxRejectedShown)] {
+		reporter.Info(fmt.Sprintf("      row %d: %v", r.Row, r.Err))
+	}
+	if more := len(tr.Rejected) - maxRejectedShown; more > 0 {
+		reporter.Info(fmt.Sprintf("      and %d more (all of them are in --report)", more))
+	}
+}
+
+// maxRejectedShown is how many rejected rows reportRejected prints.
+const maxRejectedShown = 10
+
+// reportProgress prints the generator steps worth a line of their own:
+// follow-up prompts for short tables and the ones that failed or whose
+// responses had to be dropped.
+func reportProgress(p generator.Progress) {
+	switch {
+	case p.Kind == generator.ProgressRequest && p.Chunk > 1:
+		reporter.Info(fmt.Sprintf("    %s: %d of %d rows, asking for more (prompt %d)", p.Table, p.Rows, p.Requested, p.Chunk))
+	case p.Kind == generator.ProgressRetry:
+		reporter.Warn(fmt.Sprintf("%s: follow-up %d dropped: %v", p.Table, p.Chunk-1, p.Err))
+	}
+}
+
+// scaledRows returns the profiled row count of each table times scale,
+// at least one row. Tables missing from the profile keep --rows.
+func scaledRows(p *stats.Profile, tables []*schema.Table, scale float64) map[string]int {
+	rows := make(map[string]int)
+	for _, t := range tables {
+		if ts := p.Table(t.Name); ts != nil {
+			rows[t.Name] = max(1, int(float64(ts.Rows)*scale+0.5))
 		}
-		os.Exit(1)
 	}
-	reporter.Ok("All generated rows passed validation")
+	return rows
 }
 
 func columnNames(t *schema.Table) []string {
@@ -317,3 +1040,22 @@ func joinNames(names []string) string {
 }
 
 
+
+// reportArchetypes lists the tables seeded as lookups, join tables or
+// event logs, which get their own row counts and prompt hints.
+func reportArchetypes(s *schema.Schema, tables []*schema.Table, cfg generator.Config) {
+	byType := make(map[schema.Archetype][]string)
+	for _, t := range tables {
+		a := cfg.ArchetypeOf(t, s)
+		byType[a] = append(byType[a], t.Name)
+	}
+	var parts []string
+	for _, a := range schema.Archetypes {
+		if a != schema.ArchetypeEntity && len(byType[a]) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", a, strings.Join(byType[a], ", ")))
+		}
+	}
+	if len(parts) > 0 {
+		reporter.Info("Table types:    " + strings.Join(parts, "; "))
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/pack"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+)
+
+const defaultPackPath = "seeddb-pack.tar.gz"
+
+func runPack(args []string) {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file")
+	configPath := fs.String("config", "", "Project config to include (default: ./seeddb.yaml if present)")
+	recordDir := fs.String("record", "", "Directory a seed --record run wrote")
+	out := fs.String("out", defaultPackPath, "Archive to write")
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" || *recordDir == "" {
+		fmt.Fprintln(os.Stderr, "pack requires --schema and --record")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	if fi, err := os.Stat(*recordDir); err != nil || !fi.IsDir() {
+		fmt.Fprintf(os.Stderr, "record dir %s: not a directory\n", *recordDir)
+		os.Exit(exitUsage)
+	}
+	if *configPath == "" {
+		if _, err := os.Stat(config.DefaultPath); err == nil {
+			*configPath = config.DefaultPath
+		}
+	}
+	if _, err := os.Stat(filepath.Join(*recordDir, pipeline.DataDir)); err != nil {
+		reporter.Warn(fmt.Sprintf("%s has no recorded rows: a replay will answer from the recorded responses but may not give identical rows (record with seed --record)", *recordDir))
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+	m, err := pack.Write(f, *schemaPath, *configPath, *recordDir, "db-seed-ai v"+version, time.Now())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*out)
+		fmt.Fprintln(os.Stderr, "pack:", err)
+		os.Exit(exitFailure)
+	}
+	reporter.Ok(fmt.Sprintf("Packed %d files into %s", len(m.Files), *out))
+	reporter.Info("Teammates run: seeddb unpack " + *out + " && seeddb seed --replay " + unpackDir(*out) + " --db <conn>")
+}
+
+func runUnpack(args []string) {
+	fs := flag.NewFlagSet("unpack", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory to unpack into (default: the archive name without .tar.gz)")
+	_ = fs.Parse(args)
+
+	path := fs.Arg(0)
+	if path == "" {
+		path = defaultPackPath
+	}
+	if *dir == "" {
+		*dir = unpackDir(path)
+	}
+	if entries, err := os.ReadDir(*dir); err == nil && len(entries) > 0 {
+		fmt.Fprintf(os.Stderr, "%s is not empty; pick another --dir\n", *dir)
+		os.Exit(exitUsage)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	defer f.Close()
+	m, err := pack.Extract(f, *dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		os.Exit(exitFailure)
+	}
+	reporter.Ok(fmt.Sprintf("Unpacked %d files into %s (made %s by %s)", len(m.Files), *dir, m.Created.Format(time.DateOnly), m.Tool))
+	reporter.Info("Seed it with: seeddb seed --replay " + *dir + " --db <conn>")
+}
+
+// unpackDir names the directory a pack unpacks into by default.
+func unpackDir(path string) string {
+	base := filepath.Base(path)
+	for _, ext := range []string{".tar.gz", ".tgz"} {
+		if name, ok := strings.CutSuffix(base, ext); ok {
+			return filepath.Join(filepath.Dir(path), name)
+		}
+	}
+	return path + ".d"
+}
+
+// packDefaults fills in --schema and --config from an unpacked pack given
+// as --replay, when they were left out.
+func packDefaults(replay string, schemaPath, configPath *string) {
+	if replay == "" {
+		return
+	}
+	m, ok := pack.Read(replay)
+	if !ok {
+		return
+	}
+	if *schemaPath == "" {
+		*schemaPath = filepath.Join(replay, pack.SchemaName)
+	}
+	if *configPath == "" && m.Config {
+		*configPath = filepath.Join(replay, pack.ConfigName)
+	}
+}
@@ -0,0 +1,67 @@
+// Package seed is the public face of the seeding pipeline, for programs
+// that embed it instead of running the db-seed-ai command. Its types are
+// aliases of the internal ones, so values move freely between the two.
+//
+// The seams a test or an embedder replaces are Options fields: Client
+// stands in for Ollama, Clock for time.Now, Config.Rand for the random
+// source, and Inserter for the database.
+package seed
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+type (
+	// LLMClient sends one prompt to a model and returns its raw answer.
+	LLMClient = generator.LLMClient
+	// Usage is the token usage of model calls.
+	Usage = generator.Usage
+	// Clock supplies the current time.
+	Clock = generator.Clock
+	// Inserter takes generated rows in batches; see Options.Inserter.
+	Inserter = inserter.BatchInserter
+	// Config configures generation: model, style, locale, Rand and so on.
+	Config = generator.Config
+	// Options configures one run.
+	Options = pipeline.Options
+	// Result is the outcome of a run.
+	Result = pipeline.Result
+	// Event reports a table's progress through a run.
+	Event = pipeline.Event
+	// ProgressFunc receives events.
+	ProgressFunc = pipeline.ProgressFunc
+	// Schema is a parsed schema.
+	Schema = schema.Schema
+)
+
+// SystemClock is the Clock backed by time.Now.
+var SystemClock = generator.SystemClock
+
+// NewRand returns a Rand drawing from src that is safe for concurrent use,
+// as Config.Rand must be.
+func NewRand(src rand.Source) *rand.Rand {
+	return generator.NewRand(src)
+}
+
+// ParseSchema parses SQL DDL.
+func ParseSchema(ddl string) (*Schema, error) {
+	return schema.ParseFileToSchema(ddl)
+}
+
+// NewOllamaClient returns the LLMClient a run uses when Options.Client is
+// nil. A nil httpClient means http.DefaultClient.
+func NewOllamaClient(cfg Config, httpClient *http.Client) LLMClient {
+	return generator.NewOllamaClient(cfg, httpClient)
+}
+
+// Run seeds the tables in opts. progress may be nil.
+func Run(ctx context.Context, opts Options, progress ProgressFunc) (*Result, error) {
+	return pipeline.Run(ctx, opts, progress)
+}
@@ -0,0 +1,70 @@
+package seed_test
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/seed"
+)
+
+type stubClient map[string]string
+
+func (c stubClient) Generate(prompt string) (string, seed.Usage, error) {
+	for table, rows := range c {
+		if strings.Contains(prompt, "TABLE NAME: "+table+"\n") {
+			return rows, seed.Usage{Calls: 1}, nil
+		}
+	}
+	return "[]", seed.Usage{Calls: 1}, nil
+}
+
+type stubClock struct{ now time.Time }
+
+func (c *stubClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+type stubInserter map[string][]map[string]interface{}
+
+func (s stubInserter) InsertBatch(table string, columns []string, rows []map[string]interface{}) (int, error) {
+	s[table] = append(s[table], rows...)
+	return len(rows), nil
+}
+
+func TestRunWithStubs(t *testing.T) {
+	s, err := seed.ParseSchema(`
+CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);
+CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users(id), title TEXT);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := stubClient{
+		"users": `[{"id": 1, "email": "a@x.io"}, {"id": 2, "email": "b@x.io"}]`,
+		"posts": `[{"id": 1, "user_id": 2, "title": "hi"}, {"id": 2, "user_id": 1, "title": "yo"}]`,
+	}
+	sink := stubInserter{}
+	res, err := seed.Run(context.Background(), seed.Options{
+		Schema:   s,
+		Rows:     2,
+		Config:   seed.Config{Rand: seed.NewRand(rand.NewPCG(1, 2))},
+		Client:   client,
+		Clock:    &stubClock{},
+		Inserter: sink,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DryRun || res.Inserted != 4 {
+		t.Fatalf("DryRun = %v, Inserted = %d, want false, 4", res.DryRun, res.Inserted)
+	}
+	if len(sink["users"]) != 2 || len(sink["posts"]) != 2 {
+		t.Fatalf("inserted %v", sink)
+	}
+	if got := sink["posts"][0]["title"]; got != "hi" {
+		t.Errorf("posts[0].title = %v, want hi", got)
+	}
+}
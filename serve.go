@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/server"
+)
+
+// serveTokenEnv holds the bearer token when --token is not given.
+const serveTokenEnv = "SEEDDB_SERVE_TOKEN"
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	dbConn := fs.String("db", "", "Database /seed writes to (requests cannot name another)")
+	model := fs.String("model", "llama3", "Ollama model (requests may pick another)")
+	configPath := fs.String("config", "", "Project config for requests that bring none (default: ./seeddb.yaml if present)")
+	domain := fs.String("domain", "", "Domain preset: "+strings.Join(generator.DomainNames(), ", "))
+	cacheLoc := fs.String("cache", "", "Share model responses through this cache: a directory, http(s)://, s3:// or gs:// URL (default: cache in seeddb.yaml)")
+	promptVersion := fs.String("prompt-version", generator.DefaultPromptVersion, "Prompt format: "+strings.Join(generator.PromptVersions, ", ")+" (an older one reproduces earlier runs)")
+	token := fs.String("token", "", "Bearer token every request must send (default: $"+serveTokenEnv+"; required unless --addr is a loopback address)")
+	_ = fs.Parse(args)
+
+	cfg := generator.DefaultConfig()
+	cfg.Model = *model
+	f, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	if *domain != "" {
+		if cfg.Domain, err = generator.LookupDomain(*domain); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+	}
+	cfg.Cache = openCache(*cacheLoc, f.Cache)
+	cfg.PromptVersion = checkPromptVersion(*promptVersion)
+	if *token == "" {
+		*token = os.Getenv(serveTokenEnv)
+	}
+	if *token == "" && !loopbackAddr(*addr) {
+		fmt.Fprintf(os.Stderr, "serve: %s is reachable from other machines; set --token or $%s, or listen on localhost\n", *addr, serveTokenEnv)
+		os.Exit(exitUsage)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(server.Options{Config: cfg, Project: f, DB: *dbConn, Token: *token}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	reporter.Info("db-seed-ai v" + version)
+	reporter.Info("Listening on   http://" + *addr + " (POST /preview, /validate, /seed)")
+	if *token == "" {
+		reporter.Warn("no --token: any process on this machine can call the API")
+	}
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+}
+
+// loopbackAddr reports whether addr, a --addr value, listens only on
+// this machine. An empty host listens on every interface.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func runShift(args []string) {
+	fs := flag.NewFlagSet("shift", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file (names the tables and timestamp columns)")
+	dbConn := fs.String("db", "", "Database connection string")
+	dbWait := addWaitFlag(fs)
+	tableName := fs.String("table", "", "Only this table (default: all)")
+	days := fs.Int("days", 0, "Days to move timestamps forward (negative moves them back)")
+	hours := fs.Int("hours", 0, "Hours to add on top of --days")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	guard := addGuardFlags(fs)
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" || *dbConn == "" {
+		fmt.Fprintln(os.Stderr, "shift requires --schema and --db")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	by := time.Duration(*days)*24*time.Hour + time.Duration(*hours)*time.Hour
+	if by == 0 {
+		fmt.Fprintln(os.Stderr, "shift requires --days or --hours")
+		os.Exit(exitUsage)
+	}
+	tables, err := loadSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+	if *tableName != "" {
+		t := schema.TableByName(tables, *tableName)
+		if t == nil {
+			fmt.Fprintf(os.Stderr, "table %q not found\n", *tableName)
+			os.Exit(exitUsage)
+		}
+		tables = []*schema.Table{t}
+	}
+
+	db, driver := openDB(*dbConn, dbWait)
+	defer db.Close()
+	guard.check("shift", *dbConn, db, driver, protectSpec())
+
+	updated, err := inserter.ShiftTimestamps(db, driver, tables, by)
+	if err != nil {
+		reporter.Err(err.Error())
+		os.Exit(dbExitCode(err))
+	}
+	if len(updated) == 0 {
+		reporter.Warn("No timestamp or date columns to shift")
+		return
+	}
+	for _, t := range tables {
+		if n, ok := updated[t.Name]; ok {
+			reporter.Ok(fmt.Sprintf("%-20s %d rows shifted by %s", t.Name, n, by))
+		}
+	}
+}
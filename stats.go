@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+	"github.com/satyammistari/db-seed-ai/internal/stats"
+)
+
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file")
+	dbConn := fs.String("db", "", "Connection string of the database to profile (read only)")
+	dbWait := addWaitFlag(fs)
+	tableName := fs.String("table", "", "Only this table (default: all)")
+	out := fs.String("out", "", "Write the profile to this file (default: stdout)")
+	top := fs.Int("top", stats.DefaultTop, "Most common values kept per categorical column")
+	minCount := fs.Int("min-count", stats.DefaultMinCount, "Leave out values found in fewer rows than this")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" || *dbConn == "" {
+		fmt.Fprintln(os.Stderr, "stats requires --schema and --db")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	tables, err := loadSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+	if *tableName != "" {
+		t := schema.TableByName(tables, *tableName)
+		if t == nil {
+			fmt.Fprintf(os.Stderr, "table %q not found\n", *tableName)
+			os.Exit(exitUsage)
+		}
+		tables = []*schema.Table{t}
+	}
+
+	db, _ := openDB(*dbConn, dbWait)
+	defer db.Close()
+
+	p, err := stats.Collect(db, tables, stats.Options{Top: *top, MinCount: *minCount})
+	if err != nil {
+		reporter.Err(err.Error())
+		os.Exit(dbExitCode(err))
+	}
+	if *out == "" {
+		data, err := p.JSON()
+		if err != nil {
+			reporter.Err(err.Error())
+			os.Exit(exitFailure)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	if err := p.Save(*out); err != nil {
+		reporter.Err(err.Error())
+		os.Exit(exitFailure)
+	}
+	for _, t := range tables {
+		reporter.Ok(fmt.Sprintf("%-20s %d rows profiled", t.Name, p.Tables[t.Name].Rows))
+	}
+	reporter.Info("Profile written to " + *out + " (use with seed --stats)")
+}
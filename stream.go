@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/satyammistari/db-seed-ai/internal/generator"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+func runStream(args []string) {
+	fs := flag.NewFlagSet("stream", flag.ExitOnError)
+	schemaPath := fs.String("schema", "", "Path to .sql schema file")
+	dbConn := fs.String("db", "", "Database connection string")
+	dbWait := addWaitFlag(fs)
+	tableList := fs.String("tables", "", "Comma-separated tables to stream into (default: all)")
+	rateFlag := fs.String("rate", "1/s", "Rows per second, minute or hour across all tables: 10/s, 300/m, 5000/h")
+	duration := fs.Duration("duration", 0, "Stop after this long, e.g. 10m (default: run until Ctrl-C)")
+	chunk := fs.Int("chunk", pipeline.DefaultStreamChunk, "Rows generated per prompt")
+	model := fs.String("model", "llama3", "Ollama model")
+	lenient := fs.Bool("lenient", false, "Skip CREATE TABLE statements that cannot be parsed")
+	configPath := fs.String("config", "", "Project config with value dictionaries (default: ./seeddb.yaml if present)")
+	domain := fs.String("domain", "", "Domain preset: "+strings.Join(generator.DomainNames(), ", "))
+	noPII := fs.Bool("no-pii", false, "Only obviously fictional personal data (example.com emails, 555-01xx phones)")
+	guard := addGuardFlags(fs)
+	_ = fs.Parse(args)
+
+	if *schemaPath == "" || *dbConn == "" {
+		fmt.Fprintln(os.Stderr, "stream requires --schema and --db")
+		fs.PrintDefaults()
+		os.Exit(exitUsage)
+	}
+	rate, err := pipeline.ParseRate(*rateFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "--rate:", err)
+		os.Exit(exitUsage)
+	}
+	full, err := loadFullSchema(*schemaPath, *lenient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitSchema)
+	}
+	tables := full.Tables
+	if *tableList != "" {
+		tables = nil
+		for _, name := range strings.Split(*tableList, ",") {
+			t := schema.TableByName(full.Tables, strings.TrimSpace(name))
+			if t == nil {
+				fmt.Fprintf(os.Stderr, "table %q not found\n", strings.TrimSpace(name))
+				os.Exit(exitUsage)
+			}
+			tables = append(tables, t)
+		}
+	}
+
+	cfg := generator.DefaultConfig()
+	cfg.Model = *model
+	cfg.NoPII = *noPII
+	f := applyConfig(*configPath, *domain, &cfg)
+	if limit := f.Limits.Pipeline().MaxWriteRate; limit > 0 && rate > limit {
+		fmt.Fprintf(os.Stderr, "--rate %s is over max_write_rate %s in the project config\n", *rateFlag, f.Limits.MaxWriteRate)
+		os.Exit(exitUsage)
+	}
+
+	db, driver := openDB(*dbConn, dbWait)
+	defer db.Close()
+	guard.check("stream into", *dbConn, db, driver, f.Protect)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	reporter.Info(fmt.Sprintf("Streaming into %s at %s rows/s (Ctrl-C to stop)", joinNames(tableNames(tables)), strconv.FormatFloat(rate, 'g', 4, 64)))
+	lastReport := time.Now()
+	stats, err := pipeline.Stream(ctx, pipeline.StreamOptions{
+		Schema: full,
+		Tables: tables,
+		Rate:   rate,
+		Chunk:  *chunk,
+		Config: cfg,
+		DB:     db,
+		Driver: driver,
+	}, func(ev pipeline.StreamEvent) {
+		if ev.Err != nil {
+			reporter.Warn(fmt.Sprintf("%s: %v", ev.Table, ev.Err))
+			return
+		}
+		if time.Since(lastReport) >= 5*time.Second {
+			reporter.Info(fmt.Sprintf("    %d rows inserted", ev.Total))
+			lastReport = time.Now()
+		}
+	})
+	if err != nil {
+		reporter.Err(err.Error())
+		os.Exit(exitUsage)
+	}
+
+	reporter.Info("")
+	for _, t := range tables {
+		reporter.Ok(fmt.Sprintf("%-20s %d inserted", t.Name, stats.Inserted[t.Name]))
+	}
+	if stats.Failed > 0 {
+		reporter.Warn(fmt.Sprintf("%d rows failed to insert", stats.Failed))
+	}
+	reporter.Info(fmt.Sprintf("%d rows in %s", stats.Total, stats.Elapsed.Round(time.Second)))
+	reporter.Info("Model usage:    " + stats.Usage.String())
+}
+
+func tableNames(tables []*schema.Table) []string {
+	names := make([]string, len(tables))
+	for i, t := range tables {
+		names[i] = t.Name
+	}
+	return names
+}
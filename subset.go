@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/reporter"
+	"github.com/satyammistari/db-seed-ai/internal/schema"
+)
+
+// parseTableList returns the tables of a comma-separated --table value,
+// checked against the schema.
+func parseTableList(tables []*schema.Table, list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if schema.TableByName(tables, name) == nil {
+			fmt.Fprintf(os.Stderr, "table %q not found\n", name)
+			os.Exit(exitUsage)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// subsetTables returns the named tables in insert order with the tables
+// their foreign keys need: every table a seeded table references is seeded
+// too, unless count reports rows it already has, which are referenced
+// instead. Those tables are returned in existing with their row counts.
+// A nil count seeds every referenced table.
+func subsetTables(tables []*schema.Table, names []string, count func(table string) int) (order []*schema.Table, existing map[string]int) {
+	byName := make(map[string]*schema.Table, len(tables))
+	for _, t := range tables {
+		byName[t.Name] = t
+	}
+	named := make(map[string]bool, len(names))
+	for _, name := range names {
+		named[name] = true
+	}
+	existing = make(map[string]int)
+	seed := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		t := byName[name]
+		if t == nil || seed[name] {
+			return
+		}
+		seed[name] = true
+		for _, dep := range t.DependsOn() {
+			if seed[dep] || existing[dep] > 0 || byName[dep] == nil {
+				continue
+			}
+			if count != nil && !named[dep] {
+				if n := count(dep); n > 0 {
+					existing[dep] = n
+					continue
+				}
+			}
+			visit(dep)
+		}
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	for _, t := range tables {
+		if seed[t.Name] {
+			order = append(order, t)
+		}
+	}
+	return order, existing
+}
+
+// reportSubset lists the tables a --table run adds for their references
+// and warns about the tables it leaves out that reference the seeded ones.
+func reportSubset(tables, order []*schema.Table, names []string) {
+	named := make(map[string]bool, len(names))
+	for _, name := range names {
+		named[name] = true
+	}
+	var added []string
+	for _, t := range order {
+		if !named[t.Name] {
+			added = append(added, t.Name)
+		}
+	}
+	if len(added) > 0 {
+		reporter.Info("Parents added:  " + strings.Join(added, ", ") + " (referenced by the requested tables, unless they already have rows)")
+	}
+	seeded := make(map[string]bool, len(order))
+	for _, t := range order {
+		seeded[t.Name] = true
+	}
+	var skipped []string
+	for _, t := range schema.Descendants(tables, tableNames(order)) {
+		if !seeded[t.Name] {
+			skipped = append(skipped, t.Name)
+		}
+	}
+	if len(skipped) > 0 {
+		reporter.Warn("Skipped " + strings.Join(skipped, ", ") + ": they reference the seeded tables but were not requested (add them to --table to seed them too)")
+	}
+}
+
+// pruneParents drops the parents a --table run added (see subsetTables)
+// that already have rows in db; their rows are referenced instead.
+func pruneParents(db *sql.DB, tables []*schema.Table, names []string) []*schema.Table {
+	order, existing := subsetTables(tables, names, func(table string) int {
+		n, err := inserter.CountRows(db, table)
+		if err != nil {
+			return 0
+		}
+		return n
+	})
+	for _, t := range tables {
+		if n := existing[t.Name]; n > 0 {
+			reporter.Ok(fmt.Sprintf("%s already has %d rows; referencing them instead of seeding it", t.Name, n))
+		}
+	}
+	return order
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/satyammistari/db-seed-ai/internal/config"
+	"github.com/satyammistari/db-seed-ai/internal/inserter"
+	"github.com/satyammistari/db-seed-ai/internal/pipeline"
+)
+
+// dbFlag is the --db flag of seed, which may be given more than once.
+type dbFlag []string
+
+func addDBFlag(fs *flag.FlagSet) *dbFlag {
+	d := &dbFlag{}
+	fs.Var(d, "db", "Database connection string, or the name of one of the targets in seeddb.yaml; repeat it to insert the same rows into several databases")
+	return d
+}
+
+func (d *dbFlag) String() string { return strings.Join(*d, ",") }
+
+func (d *dbFlag) Set(s string) error {
+	*d = append(*d, s)
+	return nil
+}
+
+// dbTarget is a database seed writes to.
+type dbTarget struct {
+	name string // the target's name, or the redacted connection string
+	conn string
+}
+
+// resolveTargets returns the databases named by --db, or with all every
+// target in the config at configPath, in order. A --db value that is the
+// name of a target stands for its connection string.
+func resolveTargets(values []string, all bool, configPath string) []dbTarget {
+	if len(values) == 0 && !all {
+		return nil
+	}
+	f, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	specs := make(map[string]config.TargetSpec, len(f.Targets))
+	for _, t := range f.Targets {
+		specs[t.Name] = t
+	}
+	if all {
+		switch {
+		case len(values) > 0:
+			fmt.Fprintln(os.Stderr, "--all-targets seeds the targets in seeddb.yaml and cannot be combined with --db")
+			os.Exit(exitUsage)
+		case len(f.Targets) == 0:
+			fmt.Fprintln(os.Stderr, "--all-targets: seeddb.yaml has no targets")
+			os.Exit(exitUsage)
+		}
+		for _, t := range f.Targets {
+			values = append(values, t.Name)
+		}
+	}
+	var out []dbTarget
+	seen := make(map[string]bool)
+	for _, v := range values {
+		t := dbTarget{name: pipeline.RedactConn(v), conn: v}
+		if spec, ok := specs[v]; ok {
+			if t.conn, err = spec.Conn(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(exitUsage)
+			}
+			t.name = spec.Name
+		}
+		if seen[t.conn] {
+			fmt.Fprintf(os.Stderr, "%s is given twice\n", t.name)
+			os.Exit(exitUsage)
+		}
+		seen[t.conn] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// openMirrors connects to the targets after the first, checks them as the
+// first was checked, and returns them for pipeline.Options.Mirrors.
+func openMirrors(targets []dbTarget, wait *dbWait, guard guardFlags, protect config.ProtectSpec, prepare func(*sql.DB, string)) []pipeline.Mirror {
+	var out []pipeline.Mirror
+	for _, t := range targets {
+		db, driver := openDB(t.conn, wait)
+		guard.check("seed", t.conn, db, driver, protect)
+		prepare(db, driver)
+		out = append(out, pipeline.Mirror{Name: t.name, DB: db, Driver: driver})
+	}
+	return out
+}
+
+// joinTargetNames lists the targets' names.
+func joinTargetNames(targets []dbTarget) string {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkMirrorFlags exits with exitUsage when a flag that only makes sense
+// for one database is combined with several.
+func checkMirrorFlags(targets []dbTarget, dryRun, resume, topUp, dropRejected bool, export, idempotencyKey string) {
+	if len(targets) < 2 {
+		return
+	}
+	if dryRun || resume || topUp || export != "" || idempotencyKey != "" {
+		fmt.Fprintln(os.Stderr, "several --db targets cannot be combined with --dry-run, --resume, --top-up, --export or --idempotency-key")
+		os.Exit(exitUsage)
+	}
+	// A rejected row still takes a sequence value in the first database
+	// (on Postgres), so the others would hand out other IDs from there on.
+	if dropRejected {
+		fmt.Fprintln(os.Stderr, "several --db targets cannot be combined with --drop-rejected: rows the first database rejects use up its auto-increment IDs, and the others' would no longer match")
+		os.Exit(exitUsage)
+	}
+	for _, t := range targets {
+		if inserter.InMemory(t.conn) {
+			fmt.Fprintf(os.Stderr, "%s is an in-memory database, gone when the run ends; several --db targets need databases that last\n", t.name)
+			os.Exit(exitUsage)
+		}
+	}
+}